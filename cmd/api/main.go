@@ -3,8 +3,11 @@ package main
 
 import (
 	"github.com/gaborage/go-bricks-demo-project/internal/modules/analytics"
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/dbadmin"
 	"github.com/gaborage/go-bricks-demo-project/internal/modules/legacy"
 	"github.com/gaborage/go-bricks-demo-project/internal/modules/products"
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/shared/secrets"
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/tenants"
 	"github.com/gaborage/go-bricks-demo-project/internal/modules/tokens"
 	"github.com/gaborage/go-bricks-demo-project/internal/modules/webhooks"
 	"github.com/gaborage/go-bricks/app"
@@ -39,6 +42,10 @@ type ModuleConfig struct {
 }
 
 func getModulesToLoad() []ModuleConfig {
+	// Modules needing tenant configuration share one store through this
+	// provider, so it is built once and closed once.
+	tenantStores := secrets.NewProvider()
+
 	return []ModuleConfig{
 		// --- Framework modules (order matters: scheduler → outbox → keystore) ---
 		{
@@ -64,6 +71,13 @@ func getModulesToLoad() []ModuleConfig {
 		},
 
 		// --- Business modules ---
+		{
+			// Tenants exposes the tenant store (multitenant mode only; AWS Secrets
+			// Manager, file or mock per custom.tenants.store) via GET /readyz.
+			Name:    "tenants",
+			Enabled: true,
+			Module:  tenants.NewModule(tenantStores),
+		},
 		{
 			Name:    "products",
 			Enabled: true,
@@ -76,6 +90,13 @@ func getModulesToLoad() []ModuleConfig {
 			Enabled: true,
 			Module:  analytics.NewModule(),
 		},
+		{
+			// DBAdmin reports migration status of the default and analytics
+			// databases via GET /admin/db/migrations (admin-guarded).
+			Name:    "dbadmin",
+			Enabled: true,
+			Module:  dbadmin.NewModule(),
+		},
 		{
 			// Legacy module demonstrates WithRawResponse() for Strangler Fig migrations.
 			// Routes bypass the standard APIResponse envelope, returning JSON directly.
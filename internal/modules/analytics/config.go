@@ -0,0 +1,41 @@
+package analytics
+
+import "time"
+
+// Config holds the analytics module settings under custom.analytics.
+// It is populated with config.InjectInto during Init.
+type Config struct {
+	// ConsumerMaxRetries is how many times a product.viewed message is attempted
+	// before it is dead-lettered.
+	ConsumerMaxRetries int `config:"custom.analytics.consumer.max.retries" default:"3"`
+
+	// RequireProduct rejects views of products that do not exist in the
+	// products table of the default database.
+	RequireProduct bool `config:"custom.analytics.views.require.product" default:"false"`
+
+	// ProductsDeletePolicy is the products module's delete policy, so the
+	// RequireProduct check does not accept soft-deleted products.
+	ProductsDeletePolicy string `config:"custom.products.delete.policy" default:"hard"`
+
+	// IncludeStatsFreshness adds source and computedAt to view stats
+	// responses. It is off by default so existing clients see no new fields.
+	IncludeStatsFreshness bool `config:"custom.analytics.stats.include.freshness" default:"false"`
+
+	// ViewDedupWindow skips a view when the same session viewed the product
+	// within it. Zero records every view.
+	ViewDedupWindow time.Duration `config:"custom.analytics.views.dedup.window" default:"30m"`
+
+	// ViewBufferSize batches recorded views into one INSERT of up to this many
+	// rows. Zero inserts each view as it is recorded.
+	ViewBufferSize int `config:"custom.analytics.views.buffer.size" default:"0"`
+
+	// ViewBufferInterval is the longest a buffered view waits to be written.
+	ViewBufferInterval time.Duration `config:"custom.analytics.views.buffer.interval" default:"5s"`
+
+	// SchemaCheckOnStartup runs the product_views schema check in the
+	// background when the module starts and logs an error if it fails.
+	SchemaCheckOnStartup bool `config:"custom.analytics.schema.check.startup" default:"true"`
+
+	// SchemaCheckTimeout bounds the startup schema check.
+	SchemaCheckTimeout time.Duration `config:"custom.analytics.schema.check.timeout" default:"10s"`
+}
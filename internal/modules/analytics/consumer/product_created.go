@@ -0,0 +1,75 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/gaborage/go-bricks/logger"
+)
+
+// EventProductCreated is the routing key and event type of product creation
+// events published by the products module.
+const EventProductCreated = "product.created"
+
+// ErrMalformedProductCreated is returned for product.created payloads that
+// can never be processed. Such messages are dead-lettered.
+var ErrMalformedProductCreated = errors.New("malformed product.created message")
+
+// ProductTracker seeds analytics for a new product. It is satisfied by
+// service.AnalyticsService.
+type ProductTracker interface {
+	TrackProduct(ctx context.Context, productID string) error
+}
+
+// ProductCreatedMessage is the part of a product.created payload the
+// analytics module needs.
+type ProductCreatedMessage struct {
+	ID string `json:"id"`
+}
+
+// ProductCreatedHandler tracks newly created products so they are listed
+// with zero views before their first view. Tracking is idempotent, so
+// duplicate deliveries are harmless. A failed message is returned as an
+// error, nacked without requeue and routed to the queue's DLX.
+type ProductCreatedHandler struct {
+	tracker ProductTracker
+	logger  logger.Logger
+}
+
+// NewProductCreatedHandler creates a product.created handler.
+func NewProductCreatedHandler(tracker ProductTracker, l logger.Logger) *ProductCreatedHandler {
+	return &ProductCreatedHandler{
+		tracker: tracker,
+		logger:  l,
+	}
+}
+
+// EventType returns the event type this handler processes.
+func (h *ProductCreatedHandler) EventType() string {
+	return EventProductCreated
+}
+
+// Handle processes a single product.created delivery.
+func (h *ProductCreatedHandler) Handle(ctx context.Context, delivery *amqp.Delivery) error {
+	var msg ProductCreatedMessage
+	if err := json.Unmarshal(delivery.Body, &msg); err != nil {
+		return fmt.Errorf("%w: %v", ErrMalformedProductCreated, err)
+	}
+	if msg.ID == "" {
+		return fmt.Errorf("%w: id is required", ErrMalformedProductCreated)
+	}
+
+	if err := h.tracker.TrackProduct(ctx, msg.ID); err != nil {
+		h.logger.Error().
+			Err(err).
+			Str("productId", msg.ID).
+			Msg("Failed to track created product - dead-lettering")
+		return fmt.Errorf("failed to track product %s: %w", msg.ID, err)
+	}
+
+	return nil
+}
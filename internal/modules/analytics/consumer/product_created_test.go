@@ -0,0 +1,96 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/gaborage/go-bricks/logger"
+)
+
+// mockTracker implements ProductTracker for testing and records tracked IDs
+type mockTracker struct {
+	err     error
+	tracked []string
+}
+
+func (m *mockTracker) TrackProduct(_ context.Context, productID string) error {
+	m.tracked = append(m.tracked, productID)
+	return m.err
+}
+
+func TestProductCreatedHandlerHandle(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		trackErr    error
+		wantErr     error
+		wantTracked int
+	}{
+		{
+			name:        "tracked",
+			body:        `{"id":"product-1","name":"Widget","price":9.99}`,
+			wantTracked: 1,
+		},
+		{
+			name:    "malformed JSON is dead-lettered",
+			body:    `{"id":`,
+			wantErr: ErrMalformedProductCreated,
+		},
+		{
+			name:    "missing ID is dead-lettered",
+			body:    `{"name":"Widget"}`,
+			wantErr: ErrMalformedProductCreated,
+		},
+		{
+			name:        "tracking failure is dead-lettered",
+			body:        `{"id":"product-1"}`,
+			trackErr:    errors.New("analytics database unavailable"),
+			wantTracked: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := &mockTracker{err: tt.trackErr}
+			handler := NewProductCreatedHandler(tracker, logger.New("info", false))
+
+			err := handler.Handle(context.Background(), &amqp.Delivery{Body: []byte(tt.body)})
+
+			wantErr := tt.wantErr
+			if tt.trackErr != nil {
+				wantErr = tt.trackErr
+			}
+			if wantErr != nil {
+				if !errors.Is(err, wantErr) {
+					t.Errorf("Handle() error = %v, want %v", err, wantErr)
+				}
+			} else if err != nil {
+				t.Errorf("Handle() unexpected error = %v", err)
+			}
+			if len(tracker.tracked) != tt.wantTracked {
+				t.Errorf("TrackProduct called %d times, want %d", len(tracker.tracked), tt.wantTracked)
+			}
+		})
+	}
+}
+
+func TestProductCreatedHandlerDuplicateDelivery(t *testing.T) {
+	tracker := &mockTracker{}
+	handler := NewProductCreatedHandler(tracker, logger.New("info", false))
+	delivery := &amqp.Delivery{Body: []byte(`{"id":"product-1"}`)}
+
+	for range 2 {
+		if err := handler.Handle(context.Background(), delivery); err != nil {
+			t.Fatalf("Handle() unexpected error = %v", err)
+		}
+	}
+
+	// Both deliveries reach the tracker; EnsureProductTracked makes the
+	// second one a no-op in the database.
+	if len(tracker.tracked) != 2 || tracker.tracked[0] != tracker.tracked[1] {
+		t.Errorf("tracked = %v, want product-1 twice", tracker.tracked)
+	}
+}
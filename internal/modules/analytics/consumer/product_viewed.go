@@ -0,0 +1,156 @@
+// Package consumer provides AMQP message handlers for the analytics module.
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/gaborage/go-bricks/logger"
+	"github.com/gaborage/go-bricks/messaging"
+)
+
+const (
+	// EventProductViewed is the routing key and event type of product view events.
+	EventProductViewed = "product.viewed"
+
+	// HeaderRetryCount tracks how many times a message has been retried.
+	HeaderRetryCount = "x-retry-count"
+
+	defaultMaxRetries = 3
+)
+
+// ErrMalformedMessage is returned for payloads that can never be processed.
+// Such messages are dead-lettered on the first attempt.
+var ErrMalformedMessage = errors.New("malformed product.viewed message")
+
+// ViewRecorder records product views. It is satisfied by service.AnalyticsService.
+type ViewRecorder interface {
+	RecordProductView(ctx context.Context, productID, userAgent, ipAddress, sessionID, referrer string) error
+}
+
+// Republisher sends a message back to the broker for another attempt.
+// It is satisfied by publisher.Publisher.
+type Republisher interface {
+	Publish(ctx context.Context, options messaging.PublishOptions, data []byte) error
+}
+
+// ProductViewedMessage is the payload of a product.viewed event.
+type ProductViewedMessage struct {
+	ProductID string `json:"productId"`
+	UserAgent string `json:"userAgent"`
+	IPAddress string `json:"ipAddress"`
+	SessionID string `json:"sessionId"`
+	Referrer  string `json:"referrer"`
+}
+
+// ProductViewedHandler records product.viewed events. A failed message is
+// republished to its queue with an incremented x-retry-count header; once it
+// has failed MaxRetries times, or if it is malformed, the handler returns an
+// error so the message is nacked without requeue and routed to the queue's DLX.
+type ProductViewedHandler struct {
+	recorder    ViewRecorder
+	republisher Republisher
+	queue       string
+	maxRetries  int
+	logger      logger.Logger
+}
+
+// NewProductViewedHandler creates a handler consuming from queue.
+func NewProductViewedHandler(recorder ViewRecorder, republisher Republisher, queue string, maxRetries int, l logger.Logger) *ProductViewedHandler {
+	if maxRetries <= 0 {
+		maxRetries = defaultMaxRetries
+	}
+	return &ProductViewedHandler{
+		recorder:    recorder,
+		republisher: republisher,
+		queue:       queue,
+		maxRetries:  maxRetries,
+		logger:      l,
+	}
+}
+
+// EventType returns the event type this handler processes.
+func (h *ProductViewedHandler) EventType() string {
+	return EventProductViewed
+}
+
+// Handle processes a single product.viewed delivery.
+func (h *ProductViewedHandler) Handle(ctx context.Context, delivery *amqp.Delivery) error {
+	var msg ProductViewedMessage
+	if err := json.Unmarshal(delivery.Body, &msg); err != nil {
+		return fmt.Errorf("%w: %v", ErrMalformedMessage, err)
+	}
+	if msg.ProductID == "" {
+		return fmt.Errorf("%w: productId is required", ErrMalformedMessage)
+	}
+
+	err := h.recorder.RecordProductView(ctx, msg.ProductID, msg.UserAgent, msg.IPAddress, msg.SessionID, msg.Referrer)
+	if err == nil {
+		return nil
+	}
+
+	attempts := retryCount(delivery.Headers) + 1
+	if attempts >= h.maxRetries {
+		h.logger.Error().
+			Err(err).
+			Str("productId", msg.ProductID).
+			Int("attempts", attempts).
+			Msg("Product view failed after max retries - dead-lettering")
+		return fmt.Errorf("product view failed after %d attempts: %w", attempts, err)
+	}
+
+	if pubErr := h.retry(ctx, delivery, attempts); pubErr != nil {
+		return fmt.Errorf("failed to schedule retry: %w (processing error: %v)", pubErr, err)
+	}
+
+	h.logger.Warn().
+		Err(err).
+		Str("productId", msg.ProductID).
+		Int("attempts", attempts).
+		Msg("Product view failed - retrying")
+	return nil
+}
+
+// retry republishes the delivery straight to this handler's queue through the
+// default exchange, so other product.viewed bindings do not see it again.
+func (h *ProductViewedHandler) retry(ctx context.Context, delivery *amqp.Delivery, attempts int) error {
+	headers := make(map[string]any, len(delivery.Headers)+1)
+	maps.Copy(headers, delivery.Headers)
+	headers[HeaderRetryCount] = int64(attempts)
+
+	return h.republisher.Publish(ctx, messaging.PublishOptions{
+		Exchange:   "",
+		RoutingKey: h.queue,
+		Headers:    headers,
+	}, delivery.Body)
+}
+
+// retryCount reads x-retry-count. AMQP tables decode integers with varying
+// widths, so every integer type is accepted; anything else counts as zero.
+func retryCount(headers amqp.Table) int {
+	switch v := headers[HeaderRetryCount].(type) {
+	case int:
+		return v
+	case int8:
+		return int(v)
+	case int16:
+		return int(v)
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	case uint8:
+		return int(v)
+	case uint16:
+		return int(v)
+	case uint32:
+		return int(v)
+	default:
+		return 0
+	}
+}
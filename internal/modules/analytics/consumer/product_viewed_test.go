@@ -0,0 +1,140 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/gaborage/go-bricks/logger"
+	"github.com/gaborage/go-bricks/messaging"
+)
+
+const (
+	testQueue      = "analytics.product-viewed"
+	testMaxRetries = 3
+	validPayload   = `{"productId":"product-1","sessionId":"session-1"}`
+)
+
+// mockRecorder implements ViewRecorder for testing
+type mockRecorder struct {
+	err   error
+	calls int
+}
+
+func (m *mockRecorder) RecordProductView(context.Context, string, string, string, string, string) error {
+	m.calls++
+	return m.err
+}
+
+// captureRepublisher implements Republisher and keeps every republished message
+type captureRepublisher struct {
+	published []messaging.PublishOptions
+}
+
+func (c *captureRepublisher) Publish(_ context.Context, options messaging.PublishOptions, _ []byte) error {
+	c.published = append(c.published, options)
+	return nil
+}
+
+func newTestHandler(recorder ViewRecorder, republisher Republisher) *ProductViewedHandler {
+	return NewProductViewedHandler(recorder, republisher, testQueue, testMaxRetries, logger.New("info", false))
+}
+
+func TestProductViewedHandlerDeadLettersAfterMaxRetries(t *testing.T) {
+	recorder := &mockRecorder{err: errors.New("analytics database unavailable")}
+	republisher := &captureRepublisher{}
+	handler := newTestHandler(recorder, republisher)
+
+	delivery := &amqp.Delivery{Body: []byte(validPayload), RoutingKey: EventProductViewed}
+
+	var err error
+	for attempt := 1; attempt <= testMaxRetries; attempt++ {
+		err = handler.Handle(context.Background(), delivery)
+		if attempt < testMaxRetries {
+			if err != nil {
+				t.Fatalf("Handle() attempt %d error = %v, want nil (retry scheduled)", attempt, err)
+			}
+			// Redeliver the republished message as the broker would.
+			retried := republisher.published[len(republisher.published)-1]
+			delivery = &amqp.Delivery{Body: delivery.Body, Headers: amqp.Table(retried.Headers)}
+		}
+	}
+
+	if err == nil {
+		t.Fatal("Handle() on final attempt error = nil, want error so the message is dead-lettered")
+	}
+	if recorder.calls != testMaxRetries {
+		t.Errorf("RecordProductView called %d times, want %d", recorder.calls, testMaxRetries)
+	}
+	if len(republisher.published) != testMaxRetries-1 {
+		t.Fatalf("republished %d times, want %d", len(republisher.published), testMaxRetries-1)
+	}
+	for i, options := range republisher.published {
+		if options.RoutingKey != testQueue {
+			t.Errorf("retry %d routing key = %q, want %q", i+1, options.RoutingKey, testQueue)
+		}
+		if got := retryCount(options.Headers); got != i+1 {
+			t.Errorf("retry %d %s = %d, want %d", i+1, HeaderRetryCount, got, i+1)
+		}
+	}
+}
+
+func TestProductViewedHandlerHandle(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		recordErr     error
+		wantErr       error
+		wantRecorded  int
+		wantRepublish int
+	}{
+		{
+			name:         "recorded",
+			body:         validPayload,
+			wantRecorded: 1,
+		},
+		{
+			name:    "malformed JSON is dead-lettered immediately",
+			body:    `{"productId":`,
+			wantErr: ErrMalformedMessage,
+		},
+		{
+			name:    "missing product ID is dead-lettered immediately",
+			body:    `{"sessionId":"session-1"}`,
+			wantErr: ErrMalformedMessage,
+		},
+		{
+			name:          "first failure is retried",
+			body:          validPayload,
+			recordErr:     errors.New("timeout"),
+			wantRecorded:  1,
+			wantRepublish: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := &mockRecorder{err: tt.recordErr}
+			republisher := &captureRepublisher{}
+			handler := newTestHandler(recorder, republisher)
+
+			err := handler.Handle(context.Background(), &amqp.Delivery{Body: []byte(tt.body)})
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Handle() error = %v, want %v", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Errorf("Handle() unexpected error = %v", err)
+			}
+			if recorder.calls != tt.wantRecorded {
+				t.Errorf("RecordProductView called %d times, want %d", recorder.calls, tt.wantRecorded)
+			}
+			if len(republisher.published) != tt.wantRepublish {
+				t.Errorf("republished %d times, want %d", len(republisher.published), tt.wantRepublish)
+			}
+		})
+	}
+}
@@ -58,11 +58,12 @@ func (pv *ProductView) ToEntity() *ProductViewEntity {
 }
 
 // ToProductView converts a database entity to a domain model.
+// ViewedAt is normalized to UTC regardless of the database session timezone.
 func ToProductView(e *ProductViewEntity) *ProductView {
 	return &ProductView{
 		ID:        e.ID,
 		ProductID: e.ProductID,
-		ViewedAt:  e.ViewedAt,
+		ViewedAt:  e.ViewedAt.UTC(),
 		UserAgent: e.UserAgent,
 		IPAddress: e.IPAddress,
 		SessionID: e.SessionID,
@@ -70,13 +71,21 @@ func ToProductView(e *ProductViewEntity) *ProductView {
 	}
 }
 
+// StatsSourceLive marks ViewStats aggregated from product_views at request time.
+const StatsSourceLive = "live"
+
 // ViewStats represents aggregated view statistics for a product.
+// Source and ComputedAt tell how and when the figures were produced.
 type ViewStats struct {
 	ProductID     string    `json:"productId"`
 	TotalViews    int64     `json:"totalViews"`
 	ViewsToday    int64     `json:"viewsToday"`
 	ViewsThisWeek int64     `json:"viewsThisWeek"`
-	LastViewedAt  time.Time `json:"lastViewedAt,omitempty"`
+	LastViewedAt  time.Time `json:"lastViewedAt,omitzero"`
+	UniqueViewers int64     `json:"uniqueViewers"` // distinct non-empty session IDs
+	UniqueIPs     int64     `json:"uniqueIps"`     // distinct non-empty IP addresses
+	Source        string    `json:"source,omitempty"`
+	ComputedAt    time.Time `json:"computedAt,omitzero"`
 }
 
 // TopProductStats represents a product in the top-viewed list.
@@ -84,3 +93,9 @@ type TopProductStats struct {
 	ProductID  string `json:"productId"`
 	TotalViews int64  `json:"totalViews"`
 }
+
+// HistogramBucket is the number of views of a product within one time bucket.
+type HistogramBucket struct {
+	BucketStart time.Time `json:"bucketStart"`
+	Count       int64     `json:"count"`
+}
@@ -0,0 +1,29 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestToProductViewNormalizesViewedAtToUTC(t *testing.T) {
+	// The analytics database session runs in Asia/Tokyo (see config.development.yaml).
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+
+	viewedAt := time.Date(2024, 3, 1, 9, 0, 0, 0, tokyo)
+
+	view := ToProductView(&ProductViewEntity{
+		ID:        "view-id",
+		ProductID: "product-id",
+		ViewedAt:  viewedAt,
+	})
+
+	if view.ViewedAt.Location() != time.UTC {
+		t.Errorf("ViewedAt location = %v, want UTC", view.ViewedAt.Location())
+	}
+	if !view.ViewedAt.Equal(viewedAt) {
+		t.Errorf("ViewedAt = %v, want same instant as %v", view.ViewedAt, viewedAt)
+	}
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/analytics/domain"
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/analytics/service"
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/shared/apitime"
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/shared/dbutil"
+	"github.com/gaborage/go-bricks/server"
+)
+
+const (
+	csvContentType = "text/csv; charset=utf-8"
+
+	// exportFlushInterval is the number of CSV rows written between flushes.
+	exportFlushInterval = 100
+)
+
+// exportHeader is the first CSV row of a view export.
+var exportHeader = []string{"id", "product_id", "viewed_at", "user_agent", "ip_address", "session_id", "referrer"}
+
+// ExportViews handles GET /analytics/views/:productId/export - writes the
+// product's views as CSV, oldest first, as they are read from the database.
+// The optional from and to query parameters (RFC 3339) bound viewed_at. Like
+// the products NDJSON stream it is a plain server.Handler so the body can be
+// written incrementally, bypassing the APIResponse envelope.
+func (h *AnalyticsHandler) ExportViews(ctx server.HandlerContext) error {
+	productID := ctx.Param("productId")
+	from, err := queryTime(ctx, "from")
+	if err != nil {
+		return err
+	}
+	to, err := queryTime(ctx, "to")
+	if err != nil {
+		return err
+	}
+
+	w := ctx.ResponseWriter()
+	rc := http.NewResponseController(w)
+	cw := csv.NewWriter(w)
+
+	// The header row is written with the first view, so a failure before any
+	// view is read can still be reported as an API error.
+	started := false
+	start := func() error {
+		started = true
+		w.Header().Set("Content-Type", csvContentType)
+		w.Header().Set("Content-Disposition", `attachment; filename="product-views-`+productID+`.csv"`)
+		w.WriteHeader(http.StatusOK)
+		return cw.Write(exportHeader)
+	}
+
+	written := 0
+	err = h.service.ExportProductViews(ctx.RequestContext(), productID, from, to, func(v *domain.ProductView) error {
+		if !started {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		if err := cw.Write(exportRecord(v)); err != nil {
+			return err
+		}
+		written++
+		if written%exportFlushInterval == 0 {
+			cw.Flush()
+			_ = rc.Flush() // best effort; not every writer can flush
+		}
+		return nil
+	})
+	if err != nil {
+		h.logger.Error().Err(err).Str("productId", productID).Int("written", written).Msg("Failed to export views")
+		if !started {
+			if errors.Is(err, service.ErrInvalidTimeRange) {
+				return server.NewBadRequestError("from must be before to")
+			}
+			return dbutil.HandlerError(ctx, err, "Failed to export views")
+		}
+		// Headers are already sent; the truncated body is all the client gets.
+		cw.Flush()
+		return nil
+	}
+
+	if !started {
+		if err := start(); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// exportRecord converts a view to a CSV row.
+func exportRecord(v *domain.ProductView) []string {
+	return []string{
+		v.ID,
+		v.ProductID,
+		apitime.Format(v.ViewedAt),
+		csvSafe(v.UserAgent),
+		csvSafe(v.IPAddress),
+		csvSafe(v.SessionID),
+		csvSafe(v.Referrer),
+	}
+}
+
+// csvSafe prefixes client-supplied values that a spreadsheet would evaluate
+// as a formula with a single quote.
+func csvSafe(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// queryTime parses an optional RFC 3339 query parameter; absent means the zero time.
+func queryTime(ctx server.HandlerContext, name string) (time.Time, error) {
+	raw := ctx.Query(name)
+	if raw == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := apitime.Parse(raw)
+	if err != nil {
+		return time.Time{}, server.NewBadRequestError(name + " must be an RFC 3339 timestamp")
+	}
+	return t, nil
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/analytics/domain"
+	"github.com/gaborage/go-bricks/config"
+	"github.com/gaborage/go-bricks/logger"
+	"github.com/gaborage/go-bricks/server"
+)
+
+// newExportContext returns a handler context for GET target with the
+// productId path parameter set, as the router would.
+func newExportContext(target, productID string) (server.HandlerContext, *httptest.ResponseRecorder) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	ctx := server.NewHandlerContextForTest(rec, req, &config.Config{})
+	ctx.SetPathParams([]server.PathParam{{Name: "productId", Value: productID}})
+	return ctx, rec
+}
+
+func TestExportViews(t *testing.T) {
+	viewedAt := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	svc := &mockService{views: []*domain.ProductView{
+		{ID: "view-1", ProductID: "product-1", ViewedAt: viewedAt, UserAgent: "Mozilla/5.0", SessionID: "session-1"},
+		{ID: "view-2", ProductID: "product-1", ViewedAt: viewedAt.Add(time.Minute), IPAddress: "10.0.0.1", Referrer: "=HYPERLINK()"},
+	}}
+	handler := NewAnalyticsHandler(svc, logger.New("info", false))
+
+	ctx, rec := newExportContext("/analytics/views/product-1/export", "product-1")
+	if err := handler.ExportViews(ctx); err != nil {
+		t.Fatalf("ExportViews() unexpected error = %v", err)
+	}
+
+	if got := rec.Header().Get("Content-Type"); got != csvContentType {
+		t.Errorf("ExportViews() Content-Type = %q, want %q", got, csvContentType)
+	}
+
+	records, err := csv.NewReader(strings.NewReader(rec.Body.String())).ReadAll()
+	if err != nil {
+		t.Fatalf("ExportViews() body is not CSV: %v", err)
+	}
+	want := [][]string{
+		exportHeader,
+		{"view-1", "product-1", "2024-03-01T09:00:00Z", "Mozilla/5.0", "", "session-1", ""},
+		{"view-2", "product-1", "2024-03-01T09:01:00Z", "", "10.0.0.1", "", "'=HYPERLINK()"},
+	}
+	if !slices.EqualFunc(records, want, slices.Equal) {
+		t.Errorf("ExportViews() rows = %q, want %q", records, want)
+	}
+}
+
+func TestExportViewsNoViews(t *testing.T) {
+	handler := NewAnalyticsHandler(&mockService{}, logger.New("info", false))
+
+	ctx, rec := newExportContext("/analytics/views/product-1/export", "product-1")
+	if err := handler.ExportViews(ctx); err != nil {
+		t.Fatalf("ExportViews() unexpected error = %v", err)
+	}
+
+	if got, want := rec.Body.String(), strings.Join(exportHeader, ",")+"\n"; got != want {
+		t.Errorf("ExportViews() body = %q, want only the header %q", got, want)
+	}
+}
+
+func TestExportViewsInvalidTime(t *testing.T) {
+	handler := NewAnalyticsHandler(&mockService{}, logger.New("info", false))
+
+	ctx, _ := newExportContext("/analytics/views/product-1/export?from=yesterday", "product-1")
+	err := handler.ExportViews(ctx)
+
+	if _, ok := err.(*server.BadRequestError); !ok {
+		t.Errorf("ExportViews() error = %v, want a bad request", err)
+	}
+}
@@ -3,8 +3,15 @@ package handlers
 
 import (
 	"context"
+	"errors"
+	"net/http"
+	"time"
 
 	"github.com/gaborage/go-bricks-demo-project/internal/modules/analytics/domain"
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/analytics/repository"
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/analytics/service"
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/shared/apitime"
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/shared/dbutil"
 	"github.com/gaborage/go-bricks/logger"
 	"github.com/gaborage/go-bricks/server"
 )
@@ -25,6 +32,13 @@ type GetProductStatsRequest struct {
 	ProductID string `param:"productId" binding:"required"`
 }
 
+// GetViewHistogramRequest is the request for a product's view histogram.
+// Bucket is hour (the default) or day.
+type GetViewHistogramRequest struct {
+	ProductID string `param:"productId" binding:"required"`
+	Bucket    string `query:"bucket"`
+}
+
 // ListTopViewedRequest is the request for getting top viewed products.
 type ListTopViewedRequest struct {
 	Limit int `query:"limit"`
@@ -39,6 +53,27 @@ type ViewStatsResponse struct {
 	ViewsToday    int64  `json:"viewsToday"`
 	ViewsThisWeek int64  `json:"viewsThisWeek"`
 	LastViewedAt  string `json:"lastViewedAt,omitempty"`
+	UniqueViewers int64  `json:"uniqueViewers"`
+	UniqueIPs     int64  `json:"uniqueIps"`
+
+	// Source ("live") and ComputedAt say how fresh the figures are. They are
+	// only set when the handler is built with WithStatsFreshness(true).
+	Source     string `json:"source,omitempty"`
+	ComputedAt string `json:"computedAt,omitempty"`
+}
+
+// ViewHistogramResponse is the response for a product's view histogram.
+// Buckets without views are omitted.
+type ViewHistogramResponse struct {
+	ProductID string                    `json:"productId"`
+	Bucket    string                    `json:"bucket"`
+	Buckets   []HistogramBucketResponse `json:"buckets"`
+}
+
+// HistogramBucketResponse is the view count of one histogram bucket.
+type HistogramBucketResponse struct {
+	BucketStart string `json:"bucketStart"`
+	Count       int64  `json:"count"`
 }
 
 // TopViewedResponse is the response for top viewed products.
@@ -56,6 +91,8 @@ type TopProductResponse struct {
 type AnalyticsServiceInterface interface {
 	RecordProductView(ctx context.Context, productID, userAgent, ipAddress, sessionID, referrer string) error
 	GetProductViewStats(ctx context.Context, productID string) (*domain.ViewStats, error)
+	GetViewHistogram(ctx context.Context, productID, bucket string) ([]*domain.HistogramBucket, error)
+	ExportProductViews(ctx context.Context, productID string, from, to time.Time, fn func(*domain.ProductView) error) error
 	GetTopViewedProducts(ctx context.Context, limit int) ([]*domain.TopProductStats, error)
 }
 
@@ -63,17 +100,40 @@ type AnalyticsServiceInterface interface {
 type AnalyticsHandler struct {
 	service AnalyticsServiceInterface
 	logger  logger.Logger
+
+	// statsFreshness adds source and computedAt to view stats responses.
+	statsFreshness bool
+
+	// schema backs GET /analytics/health; nil leaves it unregistered.
+	schema SchemaChecker
+}
+
+// HandlerOption configures optional AnalyticsHandler behavior.
+type HandlerOption func(*AnalyticsHandler)
+
+// WithStatsFreshness controls whether view stats responses say which query
+// path computed them and when. It is off by default, so the response shape
+// only changes for deployments that opt in.
+func WithStatsFreshness(include bool) HandlerOption {
+	return func(h *AnalyticsHandler) {
+		h.statsFreshness = include
+	}
 }
 
 // NewAnalyticsHandler creates a new analytics handler.
-func NewAnalyticsHandler(s AnalyticsServiceInterface, l logger.Logger) *AnalyticsHandler {
-	return &AnalyticsHandler{
+func NewAnalyticsHandler(s AnalyticsServiceInterface, l logger.Logger, opts ...HandlerOption) *AnalyticsHandler {
+	h := &AnalyticsHandler{
 		service: s,
 		logger:  l,
 	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
 }
 
 // RecordView handles POST /analytics/views - records a product view event.
+// Views of unknown products are rejected with 400 when product validation is enabled.
 func (h *AnalyticsHandler) RecordView(req *RecordViewRequest, ctx server.HandlerContext) (server.NoContentResult, server.IAPIError) {
 	err := h.service.RecordProductView(
 		ctx.RequestContext(),
@@ -84,7 +144,13 @@ func (h *AnalyticsHandler) RecordView(req *RecordViewRequest, ctx server.Handler
 		req.Referrer,
 	)
 	if err != nil {
+		if errors.Is(err, service.ErrUnknownProduct) {
+			return server.NoContentResult{}, server.NewBadRequestError("Product " + req.ProductID + " does not exist")
+		}
 		h.logger.Error().Err(err).Str("productId", req.ProductID).Msg("Failed to record view")
+		if errors.Is(err, dbutil.ErrDBUnavailable) || errors.Is(err, dbutil.ErrInternal) {
+			return server.NoContentResult{}, dbutil.APIError(ctx, err, "Failed to record view")
+		}
 		return server.NoContentResult{}, server.NewBadRequestError(err.Error())
 	}
 
@@ -96,7 +162,7 @@ func (h *AnalyticsHandler) GetProductStats(req GetProductStatsRequest, ctx serve
 	stats, err := h.service.GetProductViewStats(ctx.RequestContext(), req.ProductID)
 	if err != nil {
 		h.logger.Error().Err(err).Str("productId", req.ProductID).Msg("Failed to get view stats")
-		return nil, server.NewInternalServerError("Failed to retrieve view statistics")
+		return nil, dbutil.APIError(ctx, err, "Failed to retrieve view statistics")
 	}
 
 	response := &ViewStatsResponse{
@@ -104,9 +170,46 @@ func (h *AnalyticsHandler) GetProductStats(req GetProductStatsRequest, ctx serve
 		TotalViews:    stats.TotalViews,
 		ViewsToday:    stats.ViewsToday,
 		ViewsThisWeek: stats.ViewsThisWeek,
+		UniqueViewers: stats.UniqueViewers,
+		UniqueIPs:     stats.UniqueIPs,
 	}
-	if !stats.LastViewedAt.IsZero() {
-		response.LastViewedAt = stats.LastViewedAt.Format("2006-01-02T15:04:05Z07:00")
+	// A product with no views has a zero LastViewedAt; leave the field out.
+	response.LastViewedAt = apitime.FormatOptional(stats.LastViewedAt)
+	if h.statsFreshness {
+		response.Source = stats.Source
+		response.ComputedAt = apitime.FormatOptional(stats.ComputedAt)
+	}
+
+	return response, nil
+}
+
+// GetViewHistogram handles GET /analytics/views/:productId/histogram - gets a
+// product's views per hour or day bucket.
+func (h *AnalyticsHandler) GetViewHistogram(req GetViewHistogramRequest, ctx server.HandlerContext) (*ViewHistogramResponse, server.IAPIError) {
+	bucket := req.Bucket
+	if bucket == "" {
+		bucket = "hour" // Default bucket
+	}
+
+	buckets, err := h.service.GetViewHistogram(ctx.RequestContext(), req.ProductID, bucket)
+	if err != nil {
+		if errors.Is(err, repository.ErrInvalidBucket) {
+			return nil, server.NewBadRequestError("bucket must be hour or day")
+		}
+		h.logger.Error().Err(err).Str("productId", req.ProductID).Str("bucket", bucket).Msg("Failed to get view histogram")
+		return nil, dbutil.APIError(ctx, err, "Failed to retrieve view histogram")
+	}
+
+	response := &ViewHistogramResponse{
+		ProductID: req.ProductID,
+		Bucket:    bucket,
+		Buckets:   make([]HistogramBucketResponse, len(buckets)),
+	}
+	for i, b := range buckets {
+		response.Buckets[i] = HistogramBucketResponse{
+			BucketStart: apitime.Format(b.BucketStart),
+			Count:       b.Count,
+		}
 	}
 
 	return response, nil
@@ -122,7 +225,7 @@ func (h *AnalyticsHandler) GetTopViewed(req ListTopViewedRequest, ctx server.Han
 	stats, err := h.service.GetTopViewedProducts(ctx.RequestContext(), limit)
 	if err != nil {
 		h.logger.Error().Err(err).Int("limit", limit).Msg("Failed to get top viewed")
-		return nil, server.NewInternalServerError("Failed to retrieve top viewed products")
+		return nil, dbutil.APIError(ctx, err, "Failed to retrieve top viewed products")
 	}
 
 	products := make([]TopProductResponse, len(stats))
@@ -140,5 +243,13 @@ func (h *AnalyticsHandler) GetTopViewed(req ListTopViewedRequest, ctx server.Han
 func (h *AnalyticsHandler) RegisterRoutes(hr *server.HandlerRegistry, r server.RouteRegistrar) {
 	server.POST(hr, r, "/analytics/views", h.RecordView)
 	server.GET(hr, r, "/analytics/views/:productId", h.GetProductStats)
+	server.GET(hr, r, "/analytics/views/:productId/histogram", h.GetViewHistogram)
+	r.Add(http.MethodGet, "/analytics/views/:productId/export", h.ExportViews)
 	server.GET(hr, r, "/analytics/views", h.GetTopViewed)
+	if h.schema != nil {
+		server.GET(hr, r, "/analytics/health", h.SchemaHealth,
+			server.WithRawResponse(),
+			server.WithTags("health"),
+		)
+	}
 }
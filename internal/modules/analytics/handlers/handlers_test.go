@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/analytics/domain"
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/analytics/repository"
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/analytics/service"
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/shared/dbutil"
+	"github.com/gaborage/go-bricks/config"
+	"github.com/gaborage/go-bricks/logger"
+	"github.com/gaborage/go-bricks/server"
+)
+
+// mockService implements AnalyticsServiceInterface, failing every call with err
+// and otherwise serving stats, histogram and views.
+type mockService struct {
+	err       error
+	stats     *domain.ViewStats
+	histogram []*domain.HistogramBucket
+	views     []*domain.ProductView
+}
+
+func (m *mockService) RecordProductView(context.Context, string, string, string, string, string) error {
+	return m.err
+}
+
+func (m *mockService) GetProductViewStats(context.Context, string) (*domain.ViewStats, error) {
+	return m.stats, m.err
+}
+
+func (m *mockService) GetViewHistogram(context.Context, string, string) ([]*domain.HistogramBucket, error) {
+	return m.histogram, m.err
+}
+
+func (m *mockService) ExportProductViews(_ context.Context, _ string, _, _ time.Time, fn func(*domain.ProductView) error) error {
+	if m.err != nil {
+		return m.err
+	}
+	for _, v := range m.views {
+		if err := fn(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *mockService) GetTopViewedProducts(context.Context, int) ([]*domain.TopProductStats, error) {
+	return nil, m.err
+}
+
+func TestContextErrors(t *testing.T) {
+	expired, cancelExpired := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancelExpired()
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name       string
+		reqCtx     context.Context
+		err        error
+		wantStatus int
+	}{
+		{name: "client disconnected", reqCtx: canceled, err: context.Canceled, wantStatus: dbutil.StatusClientClosedRequest},
+		{name: "request deadline exceeded", reqCtx: expired, err: context.DeadlineExceeded, wantStatus: http.StatusRequestTimeout},
+		{name: "query timeout", reqCtx: context.Background(), err: context.DeadlineExceeded, wantStatus: http.StatusGatewayTimeout},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &mockService{err: fmt.Errorf("failed to record product view: %w", dbutil.Internal(tt.err))}
+			handler := NewAnalyticsHandler(svc, logger.New("info", false))
+
+			req := httptest.NewRequestWithContext(tt.reqCtx, http.MethodPost, "/analytics/views", nil)
+			ctx := server.NewHandlerContextForTest(httptest.NewRecorder(), req, &config.Config{})
+
+			_, apiErr := handler.RecordView(&RecordViewRequest{ProductID: "product-1"}, ctx)
+			if apiErr == nil || apiErr.HTTPStatus() != tt.wantStatus {
+				t.Errorf("RecordView() error = %v, want status %d", apiErr, tt.wantStatus)
+			}
+
+			_, apiErr = handler.GetTopViewed(ListTopViewedRequest{}, ctx)
+			if apiErr == nil || apiErr.HTTPStatus() != tt.wantStatus {
+				t.Errorf("GetTopViewed() error = %v, want status %d", apiErr, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestRecordViewUnknownProduct(t *testing.T) {
+	svc := &mockService{err: fmt.Errorf("%w: product-1", service.ErrUnknownProduct)}
+	handler := NewAnalyticsHandler(svc, logger.New("info", false))
+
+	req := httptest.NewRequest(http.MethodPost, "/analytics/views", nil)
+	ctx := server.NewHandlerContextForTest(httptest.NewRecorder(), req, &config.Config{})
+
+	_, apiErr := handler.RecordView(&RecordViewRequest{ProductID: "product-1"}, ctx)
+	if apiErr == nil || apiErr.ErrorCode() != "BAD_REQUEST" {
+		t.Errorf("RecordView() error = %v, want BAD_REQUEST", apiErr)
+	}
+}
+
+func TestGetProductStatsFreshness(t *testing.T) {
+	computedAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	svc := &mockService{stats: &domain.ViewStats{
+		ProductID:  "product-1",
+		TotalViews: 3,
+		Source:     domain.StatsSourceLive,
+		ComputedAt: computedAt,
+	}}
+
+	tests := []struct {
+		name           string
+		opts           []HandlerOption
+		wantSource     string
+		wantComputedAt string
+	}{
+		{name: "default"},
+		{name: "enabled", opts: []HandlerOption{WithStatsFreshness(true)}, wantSource: domain.StatsSourceLive, wantComputedAt: "2024-03-01T12:00:00Z"},
+		{name: "disabled", opts: []HandlerOption{WithStatsFreshness(false)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewAnalyticsHandler(svc, logger.New("info", false), tt.opts...)
+
+			req := httptest.NewRequest(http.MethodGet, "/analytics/views/product-1", nil)
+			ctx := server.NewHandlerContextForTest(httptest.NewRecorder(), req, &config.Config{})
+
+			resp, apiErr := handler.GetProductStats(GetProductStatsRequest{ProductID: "product-1"}, ctx)
+			if apiErr != nil {
+				t.Fatalf("GetProductStats() unexpected error = %v", apiErr)
+			}
+			if resp.Source != tt.wantSource || resp.ComputedAt != tt.wantComputedAt {
+				t.Errorf("GetProductStats() source, computedAt = %q, %q, want %q, %q",
+					resp.Source, resp.ComputedAt, tt.wantSource, tt.wantComputedAt)
+			}
+			body, err := json.Marshal(resp)
+			if err != nil {
+				t.Fatalf("json.Marshal() error = %v", err)
+			}
+			if got := strings.Contains(string(body), `"computedAt"`); got != (tt.wantComputedAt != "") {
+				t.Errorf("GetProductStats() body %s, want computedAt present = %v", body, tt.wantComputedAt != "")
+			}
+		})
+	}
+}
+
+func TestGetProductStatsLastViewedAt(t *testing.T) {
+	tests := []struct {
+		name         string
+		lastViewedAt time.Time
+		want         string
+	}{
+		{name: "no views", want: ""},
+		{name: "viewed", lastViewedAt: time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC), want: "2024-03-01T09:30:00Z"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &mockService{stats: &domain.ViewStats{ProductID: "product-1", LastViewedAt: tt.lastViewedAt}}
+			handler := NewAnalyticsHandler(svc, logger.New("info", false))
+
+			req := httptest.NewRequest(http.MethodGet, "/analytics/views/product-1", nil)
+			ctx := server.NewHandlerContextForTest(httptest.NewRecorder(), req, &config.Config{})
+
+			resp, apiErr := handler.GetProductStats(GetProductStatsRequest{ProductID: "product-1"}, ctx)
+			if apiErr != nil {
+				t.Fatalf("GetProductStats() unexpected error = %v", apiErr)
+			}
+			if resp.LastViewedAt != tt.want {
+				t.Errorf("GetProductStats() lastViewedAt = %q, want %q", resp.LastViewedAt, tt.want)
+			}
+
+			body, err := json.Marshal(resp)
+			if err != nil {
+				t.Fatalf("json.Marshal() unexpected error = %v", err)
+			}
+			if hasField := strings.Contains(string(body), `"lastViewedAt"`); hasField != (tt.want != "") {
+				t.Errorf("GetProductStats() JSON = %s, want lastViewedAt present only for a viewed product", body)
+			}
+		})
+	}
+}
+
+func TestGetViewHistogram(t *testing.T) {
+	bucketStart := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	t.Run("default hour buckets", func(t *testing.T) {
+		svc := &mockService{histogram: []*domain.HistogramBucket{{BucketStart: bucketStart, Count: 3}}}
+		handler := NewAnalyticsHandler(svc, logger.New("info", false))
+
+		req := httptest.NewRequest(http.MethodGet, "/analytics/views/product-1/histogram", nil)
+		ctx := server.NewHandlerContextForTest(httptest.NewRecorder(), req, &config.Config{})
+
+		resp, apiErr := handler.GetViewHistogram(GetViewHistogramRequest{ProductID: "product-1"}, ctx)
+		if apiErr != nil {
+			t.Fatalf("GetViewHistogram() unexpected error = %v", apiErr)
+		}
+		if resp.Bucket != "hour" || len(resp.Buckets) != 1 ||
+			resp.Buckets[0].BucketStart != "2024-03-01T09:00:00Z" || resp.Buckets[0].Count != 3 {
+			t.Errorf("GetViewHistogram() = %+v, want one hour bucket of 3 views", resp)
+		}
+	})
+
+	t.Run("invalid bucket", func(t *testing.T) {
+		svc := &mockService{err: fmt.Errorf("failed to get view histogram: %w", repository.ErrInvalidBucket)}
+		handler := NewAnalyticsHandler(svc, logger.New("info", false))
+
+		req := httptest.NewRequest(http.MethodGet, "/analytics/views/product-1/histogram?bucket=minute", nil)
+		ctx := server.NewHandlerContextForTest(httptest.NewRecorder(), req, &config.Config{})
+
+		_, apiErr := handler.GetViewHistogram(GetViewHistogramRequest{ProductID: "product-1", Bucket: "minute"}, ctx)
+		if apiErr == nil || apiErr.ErrorCode() != "BAD_REQUEST" {
+			t.Errorf("GetViewHistogram() error = %v, want BAD_REQUEST", apiErr)
+		}
+	})
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/analytics/repository"
+	"github.com/gaborage/go-bricks/server"
+)
+
+const (
+	schemaHealthReady      = "ready"
+	schemaHealthCompatible = "compatible"
+)
+
+// SchemaChecker verifies that the analytics database can run the
+// product_views aggregates. It is implemented by the analytics repository.
+type SchemaChecker interface {
+	CheckSchema(ctx context.Context) error
+}
+
+// SchemaHealthRequest carries no input; the probe is a plain GET.
+type SchemaHealthRequest struct{}
+
+// SchemaHealthResponse reports that the analytics schema is usable.
+type SchemaHealthResponse struct {
+	Status string `json:"status"`
+	Schema string `json:"schema"`
+}
+
+// WithSchemaChecker registers GET /analytics/health, which runs checker on
+// every request. Without it the endpoint is not registered.
+func WithSchemaChecker(checker SchemaChecker) HandlerOption {
+	return func(h *AnalyticsHandler) {
+		h.schema = checker
+	}
+}
+
+// SchemaHealth reports 503 when the analytics database is unreachable or
+// cannot run the product_views aggregates, naming which of the two it is.
+func (h *AnalyticsHandler) SchemaHealth(_ SchemaHealthRequest, ctx server.HandlerContext) (*SchemaHealthResponse, server.IAPIError) {
+	err := h.schema.CheckSchema(ctx.RequestContext())
+	if err == nil {
+		return &SchemaHealthResponse{Status: schemaHealthReady, Schema: schemaHealthCompatible}, nil
+	}
+
+	h.logger.Warn().Err(err).Msg("Analytics schema health check failed")
+	if errors.Is(err, repository.ErrSchemaIncompatible) {
+		return nil, server.NewServiceUnavailableError(repository.ErrSchemaIncompatible.Error())
+	}
+	return nil, server.NewServiceUnavailableError("Analytics database is unreachable")
+}
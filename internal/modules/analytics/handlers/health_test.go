@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/analytics/repository"
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/shared/dbutil"
+	"github.com/gaborage/go-bricks/config"
+	"github.com/gaborage/go-bricks/logger"
+	"github.com/gaborage/go-bricks/server"
+)
+
+// schemaCheckerFunc adapts a function to SchemaChecker.
+type schemaCheckerFunc func(ctx context.Context) error
+
+func (f schemaCheckerFunc) CheckSchema(ctx context.Context) error {
+	return f(ctx)
+}
+
+func TestSchemaHealth(t *testing.T) {
+	tests := []struct {
+		name        string
+		checkErr    error
+		wantStatus  int
+		wantMessage string
+	}{
+		{name: "compatible schema", wantStatus: http.StatusOK},
+		{
+			name:        "incompatible schema",
+			checkErr:    fmt.Errorf("%w: %w", repository.ErrSchemaIncompatible, dbutil.Internal(fmt.Errorf(`relation "product_views" does not exist`))),
+			wantStatus:  http.StatusServiceUnavailable,
+			wantMessage: "PostgreSQL with the product_views table",
+		},
+		{
+			name:        "unreachable database",
+			checkErr:    dbutil.Unavailable(fmt.Errorf("connection refused")),
+			wantStatus:  http.StatusServiceUnavailable,
+			wantMessage: "unreachable",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := schemaCheckerFunc(func(context.Context) error { return tt.checkErr })
+			handler := NewAnalyticsHandler(&mockService{}, logger.New("info", false), WithSchemaChecker(checker))
+
+			req := httptest.NewRequest(http.MethodGet, "/analytics/health", nil)
+			ctx := server.NewHandlerContextForTest(httptest.NewRecorder(), req, &config.Config{})
+
+			response, apiErr := handler.SchemaHealth(SchemaHealthRequest{}, ctx)
+
+			if tt.wantStatus == http.StatusOK {
+				if apiErr != nil {
+					t.Fatalf("SchemaHealth() unexpected error = %v", apiErr)
+				}
+				if response.Schema != schemaHealthCompatible {
+					t.Errorf("SchemaHealth() schema = %q, want %q", response.Schema, schemaHealthCompatible)
+				}
+				return
+			}
+			if apiErr == nil || apiErr.HTTPStatus() != tt.wantStatus {
+				t.Fatalf("SchemaHealth() error = %v, want status %d", apiErr, tt.wantStatus)
+			}
+			if !strings.Contains(apiErr.Message(), tt.wantMessage) {
+				t.Errorf("SchemaHealth() message = %q, want it to mention %q", apiErr.Message(), tt.wantMessage)
+			}
+		})
+	}
+}
@@ -5,10 +5,18 @@ package analytics
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
 
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/analytics/consumer"
 	"github.com/gaborage/go-bricks-demo-project/internal/modules/analytics/handlers"
 	"github.com/gaborage/go-bricks-demo-project/internal/modules/analytics/repository"
 	"github.com/gaborage/go-bricks-demo-project/internal/modules/analytics/service"
+	productsrepo "github.com/gaborage/go-bricks-demo-project/internal/modules/products/repository"
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/shared/dbutil"
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/shared/publisher"
 	"github.com/gaborage/go-bricks/app"
 	"github.com/gaborage/go-bricks/database"
 	"github.com/gaborage/go-bricks/logger"
@@ -20,6 +28,21 @@ const (
 	// analyticsDBName is the name of the named database in config.yaml.
 	// This matches the key under the "databases:" section in config.development.yaml.
 	analyticsDBName = "analytics"
+
+	// productEventsExchange is declared by the products module.
+	productEventsExchange = "product-events"
+
+	// productViewedQueue receives product.viewed events. Messages that fail
+	// ConsumerMaxRetries times are dead-lettered through deadLetterExchange
+	// into productViewedDLQ for inspection.
+	productViewedQueue = "analytics.product-viewed"
+	deadLetterExchange = "analytics.dlx"
+	productViewedDLQ   = "analytics.product-viewed.dlq"
+
+	// productCreatedQueue receives product.created events so new products
+	// are tracked before their first view. Failures go to productCreatedDLQ.
+	productCreatedQueue = "analytics.product-created"
+	productCreatedDLQ   = "analytics.product-created.dlq"
 )
 
 // Module demonstrates the go-bricks named databases feature.
@@ -32,6 +55,22 @@ type Module struct {
 	handler *handlers.AnalyticsHandler
 	repo    repository.Repository
 	logger  logger.Logger
+	cfg     Config
+
+	// viewBuffer batches view inserts when custom.analytics.views.buffer.size
+	// is set; nil otherwise.
+	viewBuffer *service.ViewBuffer
+
+	// viewedHandler consumes product.viewed events from productViewedQueue.
+	viewedHandler *consumer.ProductViewedHandler
+
+	// createdHandler consumes product.created events from productCreatedQueue.
+	createdHandler *consumer.ProductCreatedHandler
+
+	// cancelSchemaCheck stops a running startup schema check; schemaChecking
+	// lets Shutdown wait for it.
+	cancelSchemaCheck context.CancelFunc
+	schemaChecking    sync.WaitGroup
 
 	// getAnalyticsDB retrieves the analytics database connection.
 	// This uses DBByName to access the named database configured under "databases.analytics".
@@ -58,6 +97,10 @@ func (m *Module) Init(deps *app.ModuleDeps) error {
 
 	m.logger.Info().Msg("Initializing analytics module")
 
+	if err := deps.Config.InjectInto(&m.cfg); err != nil {
+		return fmt.Errorf("failed to load analytics config: %w", err)
+	}
+
 	// KEY PATTERN: Create a wrapper function that calls DBByName with the analytics database name.
 	// This is the core demonstration of the named databases feature.
 	//
@@ -77,25 +120,150 @@ func (m *Module) Init(deps *app.ModuleDeps) error {
 
 	// Initialize repository with the analytics database getter.
 	// The repository will use this function to get connections to the analytics database.
-	m.repo = repository.NewAnalyticsRepository(m.getAnalyticsDB)
+	repo := repository.NewAnalyticsRepository(m.getAnalyticsDB)
+	m.repo = repo
 
-	// Initialize service and handler.
-	m.service = service.NewService(m.repo, m.logger)
-	m.handler = handlers.NewAnalyticsHandler(m.service, m.logger)
+	// Initialize service and handler. Checking that a viewed product exists
+	// reads the products table in the default database through the products
+	// repository alone; the delete policy keeps soft-deleted products out.
+	serviceOpts := []service.Option{service.WithDedupWindow(m.cfg.ViewDedupWindow)}
+	if m.cfg.RequireProduct {
+		deletePolicy, err := productsrepo.ParseDeletePolicy(m.cfg.ProductsDeletePolicy)
+		if err != nil {
+			return fmt.Errorf("custom.products.delete.policy: %w", err)
+		}
+		productsRepo := productsrepo.NewSQLProductRepository(deps.DB, productsrepo.WithDeletePolicy(deletePolicy))
+		serviceOpts = append(serviceOpts, service.WithProductExistsChecker(service.ProductExistsFunc(productsRepo.Exists)))
+	}
+	if m.cfg.ViewBufferSize > 0 {
+		m.viewBuffer = service.NewViewBuffer(m.repo, m.logger, m.cfg.ViewBufferSize, m.cfg.ViewBufferInterval)
+		serviceOpts = append(serviceOpts, service.WithViewBuffer(m.viewBuffer))
+	}
+	m.service = service.NewService(m.repo, m.logger, serviceOpts...)
+	m.handler = handlers.NewAnalyticsHandler(m.service, m.logger,
+		handlers.WithStatsFreshness(m.cfg.IncludeStatsFreshness),
+		handlers.WithSchemaChecker(repo),
+	)
+
+	// Failed product.viewed messages are republished for retry with confirms.
+	var publisherCfg publisher.Config
+	if err := deps.Config.InjectInto(&publisherCfg); err != nil {
+		return fmt.Errorf("failed to load publisher config: %w", err)
+	}
+	republisher := publisher.NewPublisher(deps.Messaging, publisherCfg, m.logger)
+	m.viewedHandler = consumer.NewProductViewedHandler(m.service, republisher, productViewedQueue, m.cfg.ConsumerMaxRetries, m.logger)
+	m.createdHandler = consumer.NewProductCreatedHandler(m.service, m.logger)
+
+	if m.cfg.SchemaCheckOnStartup {
+		m.startSchemaCheck(repo, m.cfg.SchemaCheckTimeout)
+	}
 
 	m.logger.Info().Msg("Analytics module initialized successfully")
 
 	return nil
 }
 
+// startSchemaCheck runs checker in the background so a mis-provisioned
+// analytics database is reported at startup instead of by the first stats
+// query. Startup is not held up and a failure is only logged; GET
+// /analytics/health keeps reporting it. A non-positive timeout leaves the
+// check bounded only by Shutdown.
+func (m *Module) startSchemaCheck(checker handlers.SchemaChecker, timeout time.Duration) {
+	ctx, cancel := context.WithCancel(context.Background())
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), timeout)
+	}
+	m.cancelSchemaCheck = cancel
+
+	m.schemaChecking.Go(func() {
+		defer cancel()
+		err := checker.CheckSchema(ctx)
+		switch {
+		case err == nil:
+			m.logger.Info().Str("database", analyticsDBName).Msg("Analytics schema check passed")
+		case errors.Is(err, dbutil.ErrDBUnavailable):
+			m.logger.Warn().Err(err).Str("database", analyticsDBName).Msg("Analytics schema check skipped: database unreachable")
+		default:
+			m.logger.Error().Err(err).Str("database", analyticsDBName).Msg("Analytics schema check failed; view stats and histograms will fail until the database is fixed")
+		}
+	})
+}
+
 // RegisterRoutes registers HTTP endpoints for analytics operations.
 func (m *Module) RegisterRoutes(hr *server.HandlerRegistry, r server.RouteRegistrar) {
 	m.handler.RegisterRoutes(hr, r)
 }
 
-// DeclareMessaging declares messaging infrastructure for this module.
-func (m *Module) DeclareMessaging(_ *messaging.Declarations) {
-	// No messaging needed for analytics module.
+// DeclareMessaging declares the product.viewed and product.created queues and
+// their dead-letter paths. The framework nacks failed messages without
+// requeue, so each queue's x-dead-letter-exchange argument routes them to its DLQ.
+func (m *Module) DeclareMessaging(decls *messaging.Declarations) {
+	decls.RegisterExchange(&messaging.ExchangeDeclaration{
+		Name:    deadLetterExchange,
+		Type:    "direct",
+		Durable: true,
+	})
+	decls.RegisterQueue(&messaging.QueueDeclaration{
+		Name:    productViewedDLQ,
+		Durable: true,
+	})
+	decls.RegisterBinding(&messaging.BindingDeclaration{
+		Queue:      productViewedDLQ,
+		Exchange:   deadLetterExchange,
+		RoutingKey: consumer.EventProductViewed,
+	})
+
+	decls.RegisterQueue(&messaging.QueueDeclaration{
+		Name:    productViewedQueue,
+		Durable: true,
+		Args: map[string]any{
+			"x-dead-letter-exchange":    deadLetterExchange,
+			"x-dead-letter-routing-key": consumer.EventProductViewed,
+		},
+	})
+	decls.RegisterBinding(&messaging.BindingDeclaration{
+		Queue:      productViewedQueue,
+		Exchange:   productEventsExchange,
+		RoutingKey: consumer.EventProductViewed,
+	})
+	decls.RegisterConsumer(&messaging.ConsumerDeclaration{
+		Queue:       productViewedQueue,
+		Consumer:    "analytics-product-viewed",
+		EventType:   consumer.EventProductViewed,
+		Description: "Records product views; dead-letters after max retries",
+		Handler:     m.viewedHandler,
+	})
+
+	decls.RegisterQueue(&messaging.QueueDeclaration{
+		Name:    productCreatedDLQ,
+		Durable: true,
+	})
+	decls.RegisterBinding(&messaging.BindingDeclaration{
+		Queue:      productCreatedDLQ,
+		Exchange:   deadLetterExchange,
+		RoutingKey: consumer.EventProductCreated,
+	})
+
+	decls.RegisterQueue(&messaging.QueueDeclaration{
+		Name:    productCreatedQueue,
+		Durable: true,
+		Args: map[string]any{
+			"x-dead-letter-exchange":    deadLetterExchange,
+			"x-dead-letter-routing-key": consumer.EventProductCreated,
+		},
+	})
+	decls.RegisterBinding(&messaging.BindingDeclaration{
+		Queue:      productCreatedQueue,
+		Exchange:   productEventsExchange,
+		RoutingKey: consumer.EventProductCreated,
+	})
+	decls.RegisterConsumer(&messaging.ConsumerDeclaration{
+		Queue:       productCreatedQueue,
+		Consumer:    "analytics-product-created",
+		EventType:   consumer.EventProductCreated,
+		Description: "Tracks new products so they are listed with zero views",
+		Handler:     m.createdHandler,
+	})
 }
 
 // RegisterJobs registers scheduled jobs for this module.
@@ -107,5 +275,15 @@ func (m *Module) RegisterJobs(_ app.JobRegistrar) error {
 // Shutdown performs cleanup when the module is stopped.
 func (m *Module) Shutdown() error {
 	m.logger.Info().Msg("Shutting down analytics module")
+
+	if m.cancelSchemaCheck != nil {
+		m.cancelSchemaCheck()
+		m.schemaChecking.Wait()
+	}
+
+	// Write any buffered views before the analytics database is closed.
+	if m.viewBuffer != nil {
+		m.viewBuffer.Close()
+	}
 	return nil
 }
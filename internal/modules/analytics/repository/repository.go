@@ -3,23 +3,90 @@ package repository
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/gaborage/go-bricks-demo-project/internal/modules/analytics/domain"
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/shared/dbutil"
 	"github.com/gaborage/go-bricks/database"
 	"github.com/google/uuid"
 )
 
 const (
 	dbUnavailableErrMsg = "failed to get analytics database connection: %w"
+
+	// MaxTopViewedLimit caps the number of products GetTopViewed returns.
+	MaxTopViewedLimit = 100
+
+	// exportPageSize is the number of views StreamViews reads per query.
+	exportPageSize = 500
+
+	// MaxHistogramBuckets caps the number of buckets GetViewHistogram returns:
+	// a week of hourly buckets.
+	MaxHistogramBuckets = 168
+)
+
+var (
+	// ErrViewNotFound is returned when no product view has the given ID.
+	ErrViewNotFound = errors.New("product view not found")
+
+	// ErrInvalidLimit is returned by GetTopViewed for a limit below one.
+	ErrInvalidLimit = errors.New("limit must be positive")
+
+	// ErrInvalidBucket is returned by GetViewHistogram for a bucket outside
+	// histogramBuckets.
+	ErrInvalidBucket = errors.New("bucket must be hour or day")
+
+	// ErrFieldNotUpdatable is returned by UpdateViewField for a field outside
+	// updatableViewFields.
+	ErrFieldNotUpdatable = errors.New("product view field cannot be updated")
+
+	// ErrSchemaIncompatible is returned by CheckSchema when the analytics
+	// database cannot run the product_views aggregates, e.g. because it is not
+	// PostgreSQL or the table has not been migrated.
+	ErrSchemaIncompatible = errors.New("analytics database cannot run product_views aggregates; it must be PostgreSQL with the product_views table migrated")
 )
 
+// histogramBuckets is the allowlist of date_trunc units GetViewHistogram
+// groups views by.
+var histogramBuckets = map[string]bool{
+	"hour": true,
+	"day":  true,
+}
+
+// updatableViewFields is the allowlist of product_views columns that may be
+// backfilled after a view is recorded. The identifying columns (id,
+// product_id, viewed_at) are never rewritten.
+var updatableViewFields = map[string]bool{
+	"country":    true,
+	"referrer":   true,
+	"session_id": true,
+	"user_agent": true,
+}
+
 // Repository defines the interface for analytics data access.
+// Connection failures match dbutil.ErrDBUnavailable and failed statements
+// match dbutil.ErrInternal.
 type Repository interface {
 	RecordView(ctx context.Context, view *domain.ProductView) error
+	RecordViewsBatch(ctx context.Context, views []*domain.ProductView) error
 	GetViewStats(ctx context.Context, productID string) (*domain.ViewStats, error)
 	GetTopViewed(ctx context.Context, limit int) ([]*domain.TopProductStats, error)
+	EnsureProductTracked(ctx context.Context, productID string) error
+	UpdateViewField(ctx context.Context, id, field string, value any) error
+	GetViewHistogram(ctx context.Context, productID, bucket string) ([]*domain.HistogramBucket, error)
+	RecentViewExists(ctx context.Context, productID, sessionID string, since time.Time) (bool, error)
+	StreamViews(ctx context.Context, productID string, filter ViewFilter, fn func(*domain.ProductView) error) error
+}
+
+// ViewFilter bounds StreamViews to views at or after From and before To.
+// A zero time leaves that end open.
+type ViewFilter struct {
+	From time.Time
+	To   time.Time
 }
 
 // AnalyticsRepository implements analytics data access using a named database.
@@ -43,7 +110,7 @@ func NewAnalyticsRepository(getDB func(context.Context) (database.Interface, err
 func (r *AnalyticsRepository) RecordView(ctx context.Context, view *domain.ProductView) error {
 	db, err := r.getDB(ctx)
 	if err != nil {
-		return fmt.Errorf(dbUnavailableErrMsg, err)
+		return fmt.Errorf(dbUnavailableErrMsg, dbutil.Unavailable(err))
 	}
 
 	// Generate UUID for the view event
@@ -56,36 +123,218 @@ func (r *AnalyticsRepository) RecordView(ctx context.Context, view *domain.Produ
 		Values(entity.ID, entity.ProductID, entity.ViewedAt, entity.UserAgent, entity.IPAddress, entity.SessionID, entity.Referrer).
 		ToSQL()
 	if err != nil {
-		return fmt.Errorf("failed to build insert query: %w", err)
+		return fmt.Errorf("failed to build insert query: %w", dbutil.Internal(err))
 	}
 
 	_, err = db.Exec(ctx, query, args...)
 	if err != nil {
-		return fmt.Errorf("failed to insert product view: %w", err)
+		return fmt.Errorf("failed to insert product view: %w", dbutil.Internal(err))
 	}
 
 	return nil
 }
 
-// GetViewStats retrieves aggregated view statistics for a product.
+// RecordViewsBatch inserts views with one multi-row INSERT, assigning each a
+// new ID. An empty batch is a no-op.
+func (r *AnalyticsRepository) RecordViewsBatch(ctx context.Context, views []*domain.ProductView) error {
+	if len(views) == 0 {
+		return nil
+	}
+
+	db, err := r.getDB(ctx)
+	if err != nil {
+		return fmt.Errorf(dbUnavailableErrMsg, dbutil.Unavailable(err))
+	}
+
+	qb := database.NewQueryBuilder(database.PostgreSQL)
+	insert := qb.Insert((&domain.ProductViewEntity{}).TableName()).
+		Columns("id", "product_id", "viewed_at", "user_agent", "ip_address", "session_id", "referrer")
+	for _, view := range views {
+		view.ID = uuid.New().String()
+		entity := view.ToEntity()
+		insert = insert.Values(entity.ID, entity.ProductID, entity.ViewedAt, entity.UserAgent, entity.IPAddress, entity.SessionID, entity.Referrer)
+	}
+
+	query, args, err := insert.ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build batch insert query: %w", dbutil.Internal(err))
+	}
+
+	_, err = db.Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to insert product views: %w", dbutil.Internal(err))
+	}
+
+	return nil
+}
+
+// RecentViewExists reports whether sessionID viewed productID at or after since.
+func (r *AnalyticsRepository) RecentViewExists(ctx context.Context, productID, sessionID string, since time.Time) (bool, error) {
+	db, err := r.getDB(ctx)
+	if err != nil {
+		return false, fmt.Errorf(dbUnavailableErrMsg, dbutil.Unavailable(err))
+	}
+
+	query := `
+		SELECT 1
+		FROM product_views
+		WHERE product_id = $1 AND session_id = $2 AND viewed_at >= $3
+		LIMIT 1
+	`
+
+	var one int
+	if err := db.QueryRow(ctx, query, productID, sessionID, since).Scan(&one); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to check recent product view: %w", dbutil.Internal(err))
+	}
+
+	return true, nil
+}
+
+// StreamViews calls fn for each view of productID within filter, oldest
+// first. Views are read exportPageSize at a time, paging on (viewed_at, id),
+// so memory stays bounded however many views the product has. An error from
+// fn stops the stream and is returned unchanged.
+func (r *AnalyticsRepository) StreamViews(ctx context.Context, productID string, filter ViewFilter, fn func(*domain.ProductView) error) error {
+	db, err := r.getDB(ctx)
+	if err != nil {
+		return fmt.Errorf(dbUnavailableErrMsg, dbutil.Unavailable(err))
+	}
+
+	where := "product_id = $1"
+	args := []any{productID}
+	if !filter.From.IsZero() {
+		args = append(args, filter.From)
+		where += fmt.Sprintf(" AND viewed_at >= $%d", len(args))
+	}
+	if !filter.To.IsZero() {
+		args = append(args, filter.To)
+		where += fmt.Sprintf(" AND viewed_at < $%d", len(args))
+	}
+
+	var last *domain.ProductViewEntity
+	for {
+		pageWhere, pageArgs := where, slices.Clone(args)
+		if last != nil {
+			pageArgs = append(pageArgs, last.ViewedAt, last.ID)
+			pageWhere += fmt.Sprintf(" AND (viewed_at, id) > ($%d, $%d)", len(pageArgs)-1, len(pageArgs))
+		}
+		pageArgs = append(pageArgs, exportPageSize)
+
+		query := fmt.Sprintf(`
+			SELECT id, product_id, viewed_at, COALESCE(user_agent, ''), COALESCE(ip_address, ''),
+				COALESCE(session_id, ''), COALESCE(referrer, '')
+			FROM product_views
+			WHERE %s
+			ORDER BY viewed_at, id
+			LIMIT $%d
+		`, pageWhere, len(pageArgs))
+
+		n, pageLast, err := r.streamViewsPage(ctx, db, query, pageArgs, fn)
+		if err != nil {
+			return err
+		}
+		if n < exportPageSize {
+			return nil
+		}
+		last = pageLast
+	}
+}
+
+// streamViewsPage runs one StreamViews query, returning how many views it
+// read and the last of them.
+func (r *AnalyticsRepository) streamViewsPage(ctx context.Context, db database.Interface, query string, args []any, fn func(*domain.ProductView) error) (int, *domain.ProductViewEntity, error) {
+	rows, err := db.Query(ctx, query, args...)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to query product views: %w", dbutil.Internal(err))
+	}
+	defer rows.Close()
+
+	n := 0
+	var entity domain.ProductViewEntity
+	for rows.Next() {
+		if err := rows.Scan(
+			&entity.ID,
+			&entity.ProductID,
+			&entity.ViewedAt,
+			&entity.UserAgent,
+			&entity.IPAddress,
+			&entity.SessionID,
+			&entity.Referrer,
+		); err != nil {
+			return n, nil, fmt.Errorf("failed to scan product view: %w", dbutil.Internal(err))
+		}
+		n++
+		if err := fn(domain.ToProductView(&entity)); err != nil {
+			return n, nil, err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return n, nil, fmt.Errorf("error iterating product views: %w", dbutil.Internal(err))
+	}
+
+	return n, &entity, nil
+}
+
+// UpdateViewField sets one column of an existing product view, for example
+// the country resolved by an asynchronous enrichment worker. field must be in
+// updatableViewFields or ErrFieldNotUpdatable is returned; ErrViewNotFound is
+// returned when no view has the given ID.
+func (r *AnalyticsRepository) UpdateViewField(ctx context.Context, id, field string, value any) error {
+	if !updatableViewFields[field] {
+		return fmt.Errorf("%w: %q", ErrFieldNotUpdatable, field)
+	}
+
+	db, err := r.getDB(ctx)
+	if err != nil {
+		return fmt.Errorf(dbUnavailableErrMsg, dbutil.Unavailable(err))
+	}
+
+	// field is interpolated only after the allowlist check above.
+	query := fmt.Sprintf("UPDATE product_views SET %s = $1 WHERE id = $2", field)
+
+	result, err := db.Exec(ctx, query, value, id)
+	if err != nil {
+		return fmt.Errorf("failed to update product view: %w", dbutil.Internal(err))
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", dbutil.Internal(err))
+	}
+	if rowsAffected == 0 {
+		return ErrViewNotFound
+	}
+
+	return nil
+}
+
+// GetViewStats retrieves aggregated view statistics for a product. The stats
+// are computed live from product_views, which Source and ComputedAt record.
 func (r *AnalyticsRepository) GetViewStats(ctx context.Context, productID string) (*domain.ViewStats, error) {
 	db, err := r.getDB(ctx)
 	if err != nil {
-		return nil, fmt.Errorf(dbUnavailableErrMsg, err)
+		return nil, fmt.Errorf(dbUnavailableErrMsg, dbutil.Unavailable(err))
 	}
 
 	now := time.Now().UTC()
 	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
 	startOfWeek := startOfDay.AddDate(0, 0, -int(now.Weekday()))
 
-	// Query to get total views, views today, views this week, and last viewed time.
+	// Query to get total views, views today, views this week, last viewed time,
+	// and distinct sessions and IPs. Empty session and IP values are not counted.
 	// Using raw SQL here for the aggregate functions with FILTER clauses.
 	query := `
 		SELECT
 			COUNT(*) as total_views,
 			COUNT(*) FILTER (WHERE viewed_at >= $2) as views_today,
 			COUNT(*) FILTER (WHERE viewed_at >= $3) as views_this_week,
-			MAX(viewed_at) as last_viewed_at
+			MAX(viewed_at) as last_viewed_at,
+			COUNT(DISTINCT NULLIF(session_id, '')) as unique_viewers,
+			COUNT(DISTINCT NULLIF(ip_address, '')) as unique_ips
 		FROM product_views
 		WHERE product_id = $1
 	`
@@ -94,30 +343,146 @@ func (r *AnalyticsRepository) GetViewStats(ctx context.Context, productID string
 	var lastViewedAt *time.Time
 
 	row := db.QueryRow(ctx, query, productID, startOfDay, startOfWeek)
-	err = row.Scan(&stats.TotalViews, &stats.ViewsToday, &stats.ViewsThisWeek, &lastViewedAt)
+	err = row.Scan(&stats.TotalViews, &stats.ViewsToday, &stats.ViewsThisWeek, &lastViewedAt, &stats.UniqueViewers, &stats.UniqueIPs)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query view stats: %w", err)
+		return nil, fmt.Errorf("failed to query view stats: %w", dbutil.Internal(err))
 	}
 
 	stats.ProductID = productID
+	stats.Source = domain.StatsSourceLive
+	stats.ComputedAt = now
 	if lastViewedAt != nil {
-		stats.LastViewedAt = *lastViewedAt
+		stats.LastViewedAt = lastViewedAt.UTC()
 	}
 
 	return &stats, nil
 }
 
-// GetTopViewed retrieves the top viewed products.
-func (r *AnalyticsRepository) GetTopViewed(ctx context.Context, limit int) ([]*domain.TopProductStats, error) {
+// CheckSchema runs a trivial aggregate using the Postgres-specific FILTER
+// clause and date_trunc against product_views, so a mis-provisioned analytics
+// database is reported up front rather than by the first stats query. The
+// aggregate reads at most one row. A failed query matches
+// ErrSchemaIncompatible; an unreachable database matches
+// dbutil.ErrDBUnavailable instead.
+func (r *AnalyticsRepository) CheckSchema(ctx context.Context) error {
+	db, err := r.getDB(ctx)
+	if err != nil {
+		return fmt.Errorf(dbUnavailableErrMsg, dbutil.Unavailable(err))
+	}
+
+	query := `
+		SELECT
+			COUNT(*) FILTER (WHERE viewed_at IS NOT NULL) as views,
+			date_trunc('hour', MAX(viewed_at)) as last_bucket
+		FROM (SELECT viewed_at FROM product_views LIMIT 1) sample
+	`
+
+	var views int64
+	var lastBucket *time.Time
+	if err := db.QueryRow(ctx, query).Scan(&views, &lastBucket); err != nil {
+		return fmt.Errorf("%w: %w", ErrSchemaIncompatible, dbutil.Internal(err))
+	}
+
+	return nil
+}
+
+// GetViewHistogram counts a product's views per hour or day bucket, oldest
+// first. Only the most recent MaxHistogramBuckets non-empty buckets are
+// returned. Buckets are UTC regardless of the database session timezone.
+func (r *AnalyticsRepository) GetViewHistogram(ctx context.Context, productID, bucket string) ([]*domain.HistogramBucket, error) {
+	if !histogramBuckets[bucket] {
+		return nil, fmt.Errorf("%w: got %q", ErrInvalidBucket, bucket)
+	}
+
 	db, err := r.getDB(ctx)
 	if err != nil {
-		return nil, fmt.Errorf(dbUnavailableErrMsg, err)
+		return nil, fmt.Errorf(dbUnavailableErrMsg, dbutil.Unavailable(err))
 	}
 
-	// Query to get top viewed products with their view counts.
+	// The newest buckets are selected so the cap drops the oldest ones; the
+	// rows are reversed below.
 	query := `
-		SELECT product_id, COUNT(*) as total_views
+		SELECT date_trunc($2, viewed_at AT TIME ZONE 'UTC') as bucket_start, COUNT(*) as views
 		FROM product_views
+		WHERE product_id = $1
+		GROUP BY 1
+		ORDER BY 1 DESC
+		LIMIT $3
+	`
+
+	rows, err := db.Query(ctx, query, productID, bucket, MaxHistogramBuckets)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query view histogram: %w", dbutil.Internal(err))
+	}
+	defer rows.Close()
+
+	var results []*domain.HistogramBucket
+	for rows.Next() {
+		var b domain.HistogramBucket
+		if err := rows.Scan(&b.BucketStart, &b.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", dbutil.Internal(err))
+		}
+		b.BucketStart = b.BucketStart.UTC()
+		results = append(results, &b)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", dbutil.Internal(err))
+	}
+
+	slices.Reverse(results)
+	return results, nil
+}
+
+// EnsureProductTracked records productID as tracked so it is listed by
+// GetTopViewed with zero views. It is a no-op if the product is already
+// tracked, so repeated calls are safe.
+func (r *AnalyticsRepository) EnsureProductTracked(ctx context.Context, productID string) error {
+	db, err := r.getDB(ctx)
+	if err != nil {
+		return fmt.Errorf(dbUnavailableErrMsg, dbutil.Unavailable(err))
+	}
+
+	query := `
+		INSERT INTO tracked_products (product_id, tracked_at)
+		VALUES ($1, $2)
+		ON CONFLICT (product_id) DO NOTHING
+	`
+
+	_, err = db.Exec(ctx, query, productID, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to insert tracked product: %w", dbutil.Internal(err))
+	}
+
+	return nil
+}
+
+// GetTopViewed retrieves the top viewed products, including tracked products
+// that have no views yet. A limit above MaxTopViewedLimit is clamped to it;
+// one below one fails with ErrInvalidLimit.
+func (r *AnalyticsRepository) GetTopViewed(ctx context.Context, limit int) ([]*domain.TopProductStats, error) {
+	if limit < 1 {
+		return nil, fmt.Errorf("%w: got %d", ErrInvalidLimit, limit)
+	}
+	limit = min(limit, MaxTopViewedLimit)
+
+	db, err := r.getDB(ctx)
+	if err != nil {
+		return nil, fmt.Errorf(dbUnavailableErrMsg, dbutil.Unavailable(err))
+	}
+
+	// Query to get top viewed products with their view counts. Tracked
+	// products contribute a zero count so unviewed products are listed too.
+	query := `
+		SELECT product_id, SUM(views)::BIGINT as total_views
+		FROM (
+			SELECT product_id, COUNT(*) as views
+			FROM product_views
+			GROUP BY product_id
+			UNION ALL
+			SELECT product_id, 0
+			FROM tracked_products
+		) counts
 		GROUP BY product_id
 		ORDER BY total_views DESC
 		LIMIT $1
@@ -125,7 +490,7 @@ func (r *AnalyticsRepository) GetTopViewed(ctx context.Context, limit int) ([]*d
 
 	rows, err := db.Query(ctx, query, limit)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query top viewed products: %w", err)
+		return nil, fmt.Errorf("failed to query top viewed products: %w", dbutil.Internal(err))
 	}
 	defer rows.Close()
 
@@ -133,13 +498,13 @@ func (r *AnalyticsRepository) GetTopViewed(ctx context.Context, limit int) ([]*d
 	for rows.Next() {
 		var stat domain.TopProductStats
 		if err := rows.Scan(&stat.ProductID, &stat.TotalViews); err != nil {
-			return nil, fmt.Errorf("failed to scan row: %w", err)
+			return nil, fmt.Errorf("failed to scan row: %w", dbutil.Internal(err))
 		}
 		results = append(results, &stat)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating rows: %w", err)
+		return nil, fmt.Errorf("error iterating rows: %w", dbutil.Internal(err))
 	}
 
 	return results, nil
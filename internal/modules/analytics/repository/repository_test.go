@@ -0,0 +1,419 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/analytics/domain"
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/shared/dbutil"
+	"github.com/gaborage/go-bricks/database"
+	dbtest "github.com/gaborage/go-bricks/database/testing"
+	dbtypes "github.com/gaborage/go-bricks/database/types"
+)
+
+func TestErrorClassification(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("connection failure", func(t *testing.T) {
+		getDB := func(ctx context.Context) (database.Interface, error) {
+			return nil, errors.New("analytics database not configured")
+		}
+		repo := NewAnalyticsRepository(getDB)
+
+		_, err := repo.GetTopViewed(ctx, 10)
+
+		if !errors.Is(err, dbutil.ErrDBUnavailable) {
+			t.Errorf("GetTopViewed() error = %v, want ErrDBUnavailable", err)
+		}
+		if errors.Is(err, dbutil.ErrInternal) {
+			t.Errorf("GetTopViewed() error = %v, should not match ErrInternal", err)
+		}
+	})
+
+	t.Run("statement failure", func(t *testing.T) {
+		db := dbtest.NewTestDB(dbtypes.PostgreSQL)
+		db.ExpectExec("INSERT INTO product_views").WillReturnError(errors.New("disk full"))
+		getDB := func(ctx context.Context) (database.Interface, error) {
+			return db, nil
+		}
+		repo := NewAnalyticsRepository(getDB)
+
+		err := repo.RecordView(ctx, domain.NewProductView("product-1", "", "", "", ""))
+
+		if !errors.Is(err, dbutil.ErrInternal) {
+			t.Errorf("RecordView() error = %v, want ErrInternal", err)
+		}
+		if errors.Is(err, dbutil.ErrDBUnavailable) {
+			t.Errorf("RecordView() error = %v, should not match ErrDBUnavailable", err)
+		}
+	})
+}
+
+func TestRecordViewsBatch(t *testing.T) {
+	ctx := context.Background()
+	db := dbtest.NewTestDB(dbtypes.PostgreSQL)
+	db.ExpectExec("INSERT INTO product_views").WillReturnRowsAffected(3)
+	getDB := func(ctx context.Context) (database.Interface, error) {
+		return db, nil
+	}
+	repo := NewAnalyticsRepository(getDB)
+
+	if err := repo.RecordViewsBatch(ctx, nil); err != nil {
+		t.Fatalf("RecordViewsBatch(nil) unexpected error = %v", err)
+	}
+	dbtest.AssertExecCount(t, db, "INSERT INTO product_views", 0)
+
+	views := []*domain.ProductView{
+		domain.NewProductView("product-1", "", "", "session-1", ""),
+		domain.NewProductView("product-2", "", "", "session-1", ""),
+		domain.NewProductView("product-1", "", "", "session-2", ""),
+	}
+	if err := repo.RecordViewsBatch(ctx, views); err != nil {
+		t.Fatalf("RecordViewsBatch() unexpected error = %v", err)
+	}
+
+	dbtest.AssertExecCount(t, db, "INSERT INTO product_views", 1)
+	if got := len(db.ExecLog()[0].Args); got != 3*7 {
+		t.Errorf("RecordViewsBatch() bound %d args, want %d", got, 3*7)
+	}
+	for i, view := range views {
+		if view.ID == "" {
+			t.Errorf("RecordViewsBatch() left view %d without an ID", i)
+		}
+	}
+}
+
+func TestEnsureProductTracked(t *testing.T) {
+	ctx := context.Background()
+	db := dbtest.NewTestDB(dbtypes.PostgreSQL)
+	db.ExpectExec("INSERT INTO tracked_products").WillReturnRowsAffected(0)
+	getDB := func(ctx context.Context) (database.Interface, error) {
+		return db, nil
+	}
+	repo := NewAnalyticsRepository(getDB)
+
+	// A second call for the same product affects no rows and is not an error.
+	for range 2 {
+		if err := repo.EnsureProductTracked(ctx, "product-1"); err != nil {
+			t.Fatalf("EnsureProductTracked() unexpected error = %v", err)
+		}
+	}
+
+	dbtest.AssertExecCount(t, db, "ON CONFLICT (product_id) DO NOTHING", 2)
+}
+
+func TestUpdateViewField(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name         string
+		field        string
+		rowsAffected int64
+		wantErr      error
+	}{
+		{name: "allowed field", field: "country", rowsAffected: 1},
+		{name: "missing view", field: "country", rowsAffected: 0, wantErr: ErrViewNotFound},
+		{name: "disallowed field", field: "product_id", wantErr: ErrFieldNotUpdatable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := dbtest.NewTestDB(dbtypes.PostgreSQL)
+			db.ExpectExec("UPDATE product_views SET " + tt.field).WillReturnRowsAffected(tt.rowsAffected)
+			getDB := func(ctx context.Context) (database.Interface, error) {
+				return db, nil
+			}
+			repo := NewAnalyticsRepository(getDB)
+
+			err := repo.UpdateViewField(ctx, "view-1", tt.field, "JP")
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UpdateViewField() error = %v, want %v", err, tt.wantErr)
+			}
+			wantExecs := 1
+			if errors.Is(tt.wantErr, ErrFieldNotUpdatable) {
+				wantExecs = 0
+			}
+			dbtest.AssertExecCount(t, db, "UPDATE product_views", wantExecs)
+		})
+	}
+}
+
+func TestGetViewStats(t *testing.T) {
+	ctx := context.Background()
+	lastViewed := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	db := dbtest.NewTestDB(dbtypes.PostgreSQL)
+	db.ExpectQuery("FROM product_views").
+		WillReturnRows(
+			dbtest.NewRowSet("total_views", "views_today", "views_this_week", "last_viewed_at", "unique_viewers", "unique_ips").
+				AddRow(int64(5), int64(1), int64(2), lastViewed, int64(3), int64(2)),
+		)
+	getDB := func(ctx context.Context) (database.Interface, error) {
+		return db, nil
+	}
+	repo := NewAnalyticsRepository(getDB)
+
+	before := time.Now().UTC()
+	stats, err := repo.GetViewStats(ctx, "product-1")
+	if err != nil {
+		t.Fatalf("GetViewStats() unexpected error = %v", err)
+	}
+
+	if stats.Source != domain.StatsSourceLive {
+		t.Errorf("GetViewStats() source = %q, want %q", stats.Source, domain.StatsSourceLive)
+	}
+	if stats.ComputedAt.Before(before) || stats.ComputedAt.After(time.Now().UTC()) {
+		t.Errorf("GetViewStats() computedAt = %v, want the time of the query", stats.ComputedAt)
+	}
+	if stats.TotalViews != 5 || !stats.LastViewedAt.Equal(lastViewed) {
+		t.Errorf("GetViewStats() = %+v, want 5 views last viewed at %v", stats, lastViewed)
+	}
+	if stats.UniqueViewers != 3 || stats.UniqueIPs != 2 {
+		t.Errorf("GetViewStats() unique viewers, IPs = %d, %d, want 3, 2", stats.UniqueViewers, stats.UniqueIPs)
+	}
+	dbtest.AssertQueryExecuted(t, db, "COUNT(DISTINCT NULLIF(session_id, ''))")
+	dbtest.AssertQueryExecuted(t, db, "COUNT(DISTINCT NULLIF(ip_address, ''))")
+}
+
+func TestGetViewStatsNoViews(t *testing.T) {
+	ctx := context.Background()
+	db := dbtest.NewTestDB(dbtypes.PostgreSQL)
+	db.ExpectQuery("FROM product_views").
+		WillReturnRows(
+			dbtest.NewRowSet("total_views", "views_today", "views_this_week", "last_viewed_at", "unique_viewers", "unique_ips").
+				AddRow(int64(0), int64(0), int64(0), nil, int64(0), int64(0)),
+		)
+	getDB := func(ctx context.Context) (database.Interface, error) {
+		return db, nil
+	}
+	repo := NewAnalyticsRepository(getDB)
+
+	stats, err := repo.GetViewStats(ctx, "product-1")
+	if err != nil {
+		t.Fatalf("GetViewStats() unexpected error = %v", err)
+	}
+	if stats.TotalViews != 0 || !stats.LastViewedAt.IsZero() {
+		t.Errorf("GetViewStats() = %+v, want no views and a zero LastViewedAt", stats)
+	}
+}
+
+func TestCheckSchema(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("compatible schema", func(t *testing.T) {
+		db := dbtest.NewTestDB(dbtypes.PostgreSQL)
+		db.ExpectQuery("FROM product_views").
+			WillReturnRows(dbtest.NewRowSet("views", "last_bucket").AddRow(int64(1), time.Now().UTC()))
+		repo := NewAnalyticsRepository(func(context.Context) (database.Interface, error) {
+			return db, nil
+		})
+
+		if err := repo.CheckSchema(ctx); err != nil {
+			t.Fatalf("CheckSchema() unexpected error = %v", err)
+		}
+		dbtest.AssertQueryExecuted(t, db, "FILTER (WHERE")
+		dbtest.AssertQueryExecuted(t, db, "date_trunc(")
+	})
+
+	t.Run("incompatible engine", func(t *testing.T) {
+		db := dbtest.NewTestDB(dbtypes.PostgreSQL)
+		db.ExpectQuery("FROM product_views").
+			WillReturnError(errors.New(`syntax error at or near "FILTER"`))
+		repo := NewAnalyticsRepository(func(context.Context) (database.Interface, error) {
+			return db, nil
+		})
+
+		err := repo.CheckSchema(ctx)
+
+		if !errors.Is(err, ErrSchemaIncompatible) {
+			t.Fatalf("CheckSchema() error = %v, want ErrSchemaIncompatible", err)
+		}
+		for _, want := range []string{"PostgreSQL", "product_views", `syntax error at or near "FILTER"`} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("CheckSchema() error = %q, want it to mention %q", err, want)
+			}
+		}
+	})
+
+	t.Run("unreachable database", func(t *testing.T) {
+		repo := NewAnalyticsRepository(func(context.Context) (database.Interface, error) {
+			return nil, errors.New("connection refused")
+		})
+
+		err := repo.CheckSchema(ctx)
+
+		if !errors.Is(err, dbutil.ErrDBUnavailable) || errors.Is(err, ErrSchemaIncompatible) {
+			t.Errorf("CheckSchema() error = %v, want ErrDBUnavailable only", err)
+		}
+	})
+}
+
+func TestGetTopViewedLimit(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("negative limit", func(t *testing.T) {
+		db := dbtest.NewTestDB(dbtypes.PostgreSQL)
+		getDB := func(ctx context.Context) (database.Interface, error) {
+			return db, nil
+		}
+		repo := NewAnalyticsRepository(getDB)
+
+		_, err := repo.GetTopViewed(ctx, -1)
+
+		if !errors.Is(err, ErrInvalidLimit) {
+			t.Errorf("GetTopViewed() error = %v, want ErrInvalidLimit", err)
+		}
+		dbtest.AssertQueryNotExecuted(t, db, "FROM product_views")
+	})
+
+	t.Run("excessive limit is clamped", func(t *testing.T) {
+		db := dbtest.NewTestDB(dbtypes.PostgreSQL)
+		db.ExpectQuery("FROM product_views").
+			WillReturnRows(dbtest.NewRowSet("product_id", "total_views"))
+		getDB := func(ctx context.Context) (database.Interface, error) {
+			return db, nil
+		}
+		repo := NewAnalyticsRepository(getDB)
+
+		if _, err := repo.GetTopViewed(ctx, 1_000_000); err != nil {
+			t.Fatalf("GetTopViewed() unexpected error = %v", err)
+		}
+
+		queries := db.QueryLog()
+		if len(queries) != 1 || len(queries[0].Args) != 1 || queries[0].Args[0] != MaxTopViewedLimit {
+			t.Errorf("GetTopViewed() queries = %+v, want one query limited to %d", queries, MaxTopViewedLimit)
+		}
+	})
+}
+
+func TestGetViewHistogram(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("buckets oldest first", func(t *testing.T) {
+		first := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+		second := first.Add(time.Hour)
+		db := dbtest.NewTestDB(dbtypes.PostgreSQL)
+		db.ExpectQuery("date_trunc").
+			WillReturnRows(
+				dbtest.NewRowSet("bucket_start", "views").
+					AddRow(second, int64(4)).
+					AddRow(first, int64(2)),
+			)
+		getDB := func(ctx context.Context) (database.Interface, error) {
+			return db, nil
+		}
+		repo := NewAnalyticsRepository(getDB)
+
+		buckets, err := repo.GetViewHistogram(ctx, "product-1", "hour")
+		if err != nil {
+			t.Fatalf("GetViewHistogram() unexpected error = %v", err)
+		}
+
+		if len(buckets) != 2 || !buckets[0].BucketStart.Equal(first) || buckets[0].Count != 2 || buckets[1].Count != 4 {
+			t.Errorf("GetViewHistogram() = %+v, want %v then %v", buckets, first, second)
+		}
+		queries := db.QueryLog()
+		if len(queries) != 1 || queries[0].Args[1] != "hour" || queries[0].Args[2] != MaxHistogramBuckets {
+			t.Errorf("GetViewHistogram() queries = %+v, want hour buckets capped at %d", queries, MaxHistogramBuckets)
+		}
+	})
+
+	t.Run("bucket not allowed", func(t *testing.T) {
+		db := dbtest.NewTestDB(dbtypes.PostgreSQL)
+		getDB := func(ctx context.Context) (database.Interface, error) {
+			return db, nil
+		}
+		repo := NewAnalyticsRepository(getDB)
+
+		_, err := repo.GetViewHistogram(ctx, "product-1", "minute")
+
+		if !errors.Is(err, ErrInvalidBucket) {
+			t.Errorf("GetViewHistogram() error = %v, want ErrInvalidBucket", err)
+		}
+		dbtest.AssertQueryNotExecuted(t, db, "date_trunc")
+	})
+}
+
+func TestRecentViewExists(t *testing.T) {
+	ctx := context.Background()
+	since := time.Now().UTC().Add(-30 * time.Minute)
+
+	tests := []struct {
+		name       string
+		rows       *dbtest.RowSet
+		queryErr   error
+		wantExists bool
+		wantErr    bool
+	}{
+		{name: "recent view", rows: dbtest.NewRowSet("?column?").AddRow(1), wantExists: true},
+		{name: "no recent view", rows: dbtest.NewRowSet("?column?")},
+		{name: "database error", queryErr: errors.New("database error"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := dbtest.NewTestDB(dbtypes.PostgreSQL)
+			if tt.queryErr != nil {
+				db.ExpectQuery("FROM product_views").WillReturnError(tt.queryErr)
+			} else {
+				db.ExpectQuery("FROM product_views").WillReturnRows(tt.rows)
+			}
+			getDB := func(ctx context.Context) (database.Interface, error) {
+				return db, nil
+			}
+			repo := NewAnalyticsRepository(getDB)
+
+			exists, err := repo.RecentViewExists(ctx, "product-1", "session-1", since)
+
+			if tt.wantErr {
+				if !errors.Is(err, dbutil.ErrInternal) {
+					t.Errorf("RecentViewExists() error = %v, want ErrInternal", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RecentViewExists() unexpected error = %v", err)
+			}
+			if exists != tt.wantExists {
+				t.Errorf("RecentViewExists() = %v, want %v", exists, tt.wantExists)
+			}
+			dbtest.AssertQueryExecuted(t, db, "session_id = $2 AND viewed_at >= $3")
+		})
+	}
+}
+
+func TestStreamViews(t *testing.T) {
+	ctx := context.Background()
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	db := dbtest.NewTestDB(dbtypes.PostgreSQL)
+	db.ExpectQuery("FROM product_views").
+		WillReturnRows(
+			dbtest.NewRowSet("id", "product_id", "viewed_at", "user_agent", "ip_address", "session_id", "referrer").
+				AddRow("view-1", "product-1", from.Add(time.Hour), "agent", "10.0.0.1", "session-1", "").
+				AddRow("view-2", "product-1", from.Add(2*time.Hour), "", "", "", ""),
+		)
+	getDB := func(ctx context.Context) (database.Interface, error) {
+		return db, nil
+	}
+	repo := NewAnalyticsRepository(getDB)
+
+	var ids []string
+	err := repo.StreamViews(ctx, "product-1", ViewFilter{From: from}, func(v *domain.ProductView) error {
+		ids = append(ids, v.ID)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("StreamViews() unexpected error = %v", err)
+	}
+
+	if len(ids) != 2 || ids[0] != "view-1" || ids[1] != "view-2" {
+		t.Errorf("StreamViews() views = %v, want [view-1 view-2]", ids)
+	}
+	// A short page is the last one: no follow-up query is made.
+	dbtest.AssertQueryCount(t, db, "FROM product_views", 1)
+	dbtest.AssertQueryExecuted(t, db, "viewed_at >= $2")
+	dbtest.AssertQueryNotExecuted(t, db, "viewed_at < $")
+}
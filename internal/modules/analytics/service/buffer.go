@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/analytics/domain"
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/analytics/repository"
+	"github.com/gaborage/go-bricks/logger"
+)
+
+// ErrBufferClosed is returned by ViewBuffer.Add after Close.
+var ErrBufferClosed = errors.New("view buffer closed")
+
+const (
+	// DefaultFlushInterval is used by NewViewBuffer for a non-positive interval.
+	DefaultFlushInterval = 5 * time.Second
+
+	// flushTimeout bounds a single RecordViewsBatch call made by a ViewBuffer.
+	flushTimeout = 10 * time.Second
+)
+
+// ViewBuffer accumulates product views in memory and writes them with
+// Repository.RecordViewsBatch once maxSize views are pending or interval has
+// passed, whichever comes first. A background goroutine started by
+// NewViewBuffer does the writing until Close.
+type ViewBuffer struct {
+	repo     repository.Repository
+	logger   logger.Logger
+	maxSize  int
+	interval time.Duration
+
+	mu      sync.Mutex
+	pending []*domain.ProductView
+	closed  bool
+
+	full      chan struct{} // signals that maxSize views are pending
+	stopCh    chan struct{}
+	doneCh    chan struct{} // closed when the flush loop exits
+	closeOnce sync.Once
+}
+
+// NewViewBuffer creates a ViewBuffer and starts its flush loop. A maxSize
+// below 1 flushes every view on its own.
+func NewViewBuffer(repo repository.Repository, log logger.Logger, maxSize int, interval time.Duration) *ViewBuffer {
+	if interval <= 0 {
+		interval = DefaultFlushInterval
+	}
+	b := &ViewBuffer{
+		repo:     repo,
+		logger:   log,
+		maxSize:  max(maxSize, 1),
+		interval: interval,
+		full:     make(chan struct{}, 1),
+		stopCh:   make(chan struct{}),
+		doneCh:   make(chan struct{}),
+	}
+	go b.flushLoop()
+	return b
+}
+
+// Add queues view for the next flush.
+func (b *ViewBuffer) Add(view *domain.ProductView) error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return ErrBufferClosed
+	}
+	b.pending = append(b.pending, view)
+	full := len(b.pending) >= b.maxSize
+	b.mu.Unlock()
+
+	if full {
+		select {
+		case b.full <- struct{}{}:
+		default: // a flush is already signaled
+		}
+	}
+	return nil
+}
+
+// Close stops the flush loop after writing every pending view. Views added
+// after Close are rejected with ErrBufferClosed.
+func (b *ViewBuffer) Close() {
+	b.closeOnce.Do(func() {
+		b.mu.Lock()
+		b.closed = true
+		b.mu.Unlock()
+		close(b.stopCh)
+	})
+	<-b.doneCh
+}
+
+func (b *ViewBuffer) flushLoop() {
+	defer close(b.doneCh)
+
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			b.flush()
+		case <-b.full:
+			b.flush()
+		case <-b.stopCh:
+			b.flush()
+			return
+		}
+	}
+}
+
+// flush writes the pending views in batches of at most maxSize. A failed
+// batch is logged and dropped.
+func (b *ViewBuffer) flush() {
+	b.mu.Lock()
+	views := b.pending
+	b.pending = nil
+	b.mu.Unlock()
+
+	for len(views) > 0 {
+		n := min(len(views), b.maxSize)
+		b.writeBatch(views[:n])
+		views = views[n:]
+	}
+}
+
+func (b *ViewBuffer) writeBatch(views []*domain.ProductView) {
+	ctx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+	defer cancel()
+
+	if err := b.repo.RecordViewsBatch(ctx, views); err != nil {
+		b.logger.Error().
+			Err(err).
+			Int("count", len(views)).
+			Msg("Failed to flush buffered product views")
+		return
+	}
+
+	b.logger.Debug().
+		Int("count", len(views)).
+		Msg("Buffered product views flushed")
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/analytics/domain"
+	"github.com/gaborage/go-bricks/logger"
+)
+
+// waitForBatches polls repo until it has received want batches or a second passes.
+func waitForBatches(t *testing.T, repo *mockRepository, want int) []int {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		sizes := repo.batchSizes()
+		if len(sizes) >= want || time.Now().After(deadline) {
+			return sizes
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestViewBufferFlushesWhenFull(t *testing.T) {
+	repo := &mockRepository{}
+	buf := NewViewBuffer(repo, logger.New("info", false), 3, time.Hour)
+	defer buf.Close()
+
+	for range 3 {
+		if err := buf.Add(domain.NewProductView("product-1", "", "", "", "")); err != nil {
+			t.Fatalf("Add() unexpected error = %v", err)
+		}
+	}
+
+	if sizes := waitForBatches(t, repo, 1); !slices.Equal(sizes, []int{3}) {
+		t.Errorf("batches = %v, want [3]", sizes)
+	}
+}
+
+func TestViewBufferFlushesOnInterval(t *testing.T) {
+	repo := &mockRepository{}
+	buf := NewViewBuffer(repo, logger.New("info", false), 100, 20*time.Millisecond)
+	defer buf.Close()
+
+	if err := buf.Add(domain.NewProductView("product-1", "", "", "", "")); err != nil {
+		t.Fatalf("Add() unexpected error = %v", err)
+	}
+
+	if sizes := waitForBatches(t, repo, 1); !slices.Equal(sizes, []int{1}) {
+		t.Errorf("batches = %v, want [1]", sizes)
+	}
+}
+
+func TestViewBufferCloseFlushesPending(t *testing.T) {
+	repo := &mockRepository{}
+	buf := NewViewBuffer(repo, logger.New("info", false), 100, time.Hour)
+
+	for range 2 {
+		if err := buf.Add(domain.NewProductView("product-1", "", "", "", "")); err != nil {
+			t.Fatalf("Add() unexpected error = %v", err)
+		}
+	}
+	buf.Close()
+
+	if sizes := repo.batchSizes(); !slices.Equal(sizes, []int{2}) {
+		t.Errorf("batches after Close() = %v, want [2]", sizes)
+	}
+	if err := buf.Add(domain.NewProductView("product-1", "", "", "", "")); !errors.Is(err, ErrBufferClosed) {
+		t.Errorf("Add() after Close() error = %v, want ErrBufferClosed", err)
+	}
+}
+
+func TestRecordProductViewBuffered(t *testing.T) {
+	repo := &mockRepository{}
+	buf := NewViewBuffer(repo, logger.New("info", false), 100, time.Hour)
+	svc := NewService(repo, logger.New("info", false), WithViewBuffer(buf))
+
+	if err := svc.RecordProductView(context.Background(), "product-1", "", "", "", ""); err != nil {
+		t.Fatalf("RecordProductView() unexpected error = %v", err)
+	}
+	if len(repo.views) != 0 {
+		t.Errorf("RecordProductView() inserted %d views directly, want 0", len(repo.views))
+	}
+
+	buf.Close()
+	if sizes := repo.batchSizes(); !slices.Equal(sizes, []int{1}) {
+		t.Errorf("batches after Close() = %v, want [1]", sizes)
+	}
+}
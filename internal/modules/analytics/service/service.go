@@ -3,36 +3,146 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/gaborage/go-bricks-demo-project/internal/modules/analytics/domain"
 	"github.com/gaborage/go-bricks-demo-project/internal/modules/analytics/repository"
 	"github.com/gaborage/go-bricks/logger"
 )
 
+// ErrUnknownProduct is returned by RecordProductView for a product the
+// ProductExistsChecker does not know.
+var ErrUnknownProduct = errors.New("unknown product")
+
+// ErrInvalidTimeRange is returned by ExportProductViews when from is not
+// before to.
+var ErrInvalidTimeRange = errors.New("invalid time range")
+
+// ProductExistsChecker reports whether a product exists. The module backs it
+// with the products repository in the default database.
+type ProductExistsChecker interface {
+	ProductExists(ctx context.Context, id string) (bool, error)
+}
+
+// ProductExistsFunc adapts a function, such as a repository's Exists method,
+// to ProductExistsChecker.
+type ProductExistsFunc func(ctx context.Context, id string) (bool, error)
+
+// ProductExists calls f(ctx, id).
+func (f ProductExistsFunc) ProductExists(ctx context.Context, id string) (bool, error) {
+	return f(ctx, id)
+}
+
 // AnalyticsService handles analytics business logic.
 type AnalyticsService struct {
 	repo   repository.Repository
 	logger logger.Logger
+
+	// products, when set, is consulted before recording a view so views of
+	// unknown products are rejected.
+	products ProductExistsChecker
+
+	// dedupWindow skips a view when the same session viewed the same product
+	// within it. Zero records every view.
+	dedupWindow time.Duration
+
+	// buffer, when set, receives views instead of the repository so they are
+	// written in batches.
+	buffer *ViewBuffer
+}
+
+// Option configures optional AnalyticsService dependencies.
+type Option func(*AnalyticsService)
+
+// WithProductExistsChecker makes RecordProductView reject views of products
+// the checker does not know. Without it any product ID is accepted.
+func WithProductExistsChecker(checker ProductExistsChecker) Option {
+	return func(s *AnalyticsService) {
+		s.products = checker
+	}
+}
+
+// WithDedupWindow makes RecordProductView skip a view when the same session
+// already viewed the product within window. Views without a session ID are
+// always recorded.
+func WithDedupWindow(window time.Duration) Option {
+	return func(s *AnalyticsService) {
+		s.dedupWindow = window
+	}
+}
+
+// WithViewBuffer makes RecordProductView queue views in buffer instead of
+// inserting each one. The dedup window does not see views still in the buffer.
+func WithViewBuffer(buffer *ViewBuffer) Option {
+	return func(s *AnalyticsService) {
+		s.buffer = buffer
+	}
 }
 
 // NewService creates a new analytics service.
-func NewService(repo repository.Repository, log logger.Logger) *AnalyticsService {
-	return &AnalyticsService{
+func NewService(repo repository.Repository, log logger.Logger, opts ...Option) *AnalyticsService {
+	s := &AnalyticsService{
 		repo:   repo,
 		logger: log,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 // RecordProductView records a product view event in the analytics database.
+// With a ProductExistsChecker configured, views of unknown products are
+// rejected with ErrUnknownProduct. With a dedup window, a repeat view by the
+// same session is skipped without error.
 func (s *AnalyticsService) RecordProductView(ctx context.Context, productID, userAgent, ipAddress, sessionID, referrer string) error {
 	// Validate product ID
 	if productID == "" {
 		return fmt.Errorf("product ID is required")
 	}
 
+	if s.products != nil {
+		exists, err := s.products.ProductExists(ctx, productID)
+		if err != nil {
+			s.logger.Error().
+				Err(err).
+				Str("productId", productID).
+				Msg("Failed to check product existence")
+			return fmt.Errorf("failed to check product existence: %w", err)
+		}
+		if !exists {
+			return fmt.Errorf("%w: %s", ErrUnknownProduct, productID)
+		}
+	}
+
+	if sessionID != "" && s.dedupWindow > 0 {
+		seen, err := s.repo.RecentViewExists(ctx, productID, sessionID, time.Now().UTC().Add(-s.dedupWindow))
+		if err != nil {
+			s.logger.Error().
+				Err(err).
+				Str("productId", productID).
+				Msg("Failed to check recent product view")
+			return fmt.Errorf("failed to check recent product view: %w", err)
+		}
+		if seen {
+			s.logger.Debug().
+				Str("productId", productID).
+				Msg("Product view already recorded for session, skipping")
+			return nil
+		}
+	}
+
 	view := domain.NewProductView(productID, userAgent, ipAddress, sessionID, referrer)
 
+	if s.buffer != nil {
+		if err := s.buffer.Add(view); err != nil {
+			return fmt.Errorf("failed to buffer product view: %w", err)
+		}
+		return nil
+	}
+
 	if err := s.repo.RecordView(ctx, view); err != nil {
 		s.logger.Error().
 			Err(err).
@@ -48,6 +158,28 @@ func (s *AnalyticsService) RecordProductView(ctx context.Context, productID, use
 	return nil
 }
 
+// TrackProduct makes a newly created product visible in the top viewed list
+// before its first view. Tracking a product twice is a no-op.
+func (s *AnalyticsService) TrackProduct(ctx context.Context, productID string) error {
+	if productID == "" {
+		return fmt.Errorf("product ID is required")
+	}
+
+	if err := s.repo.EnsureProductTracked(ctx, productID); err != nil {
+		s.logger.Error().
+			Err(err).
+			Str("productId", productID).
+			Msg("Failed to track product")
+		return fmt.Errorf("failed to track product: %w", err)
+	}
+
+	s.logger.Debug().
+		Str("productId", productID).
+		Msg("Product tracked")
+
+	return nil
+}
+
 // GetProductViewStats retrieves view statistics for a specific product.
 func (s *AnalyticsService) GetProductViewStats(ctx context.Context, productID string) (*domain.ViewStats, error) {
 	if productID == "" {
@@ -66,14 +198,56 @@ func (s *AnalyticsService) GetProductViewStats(ctx context.Context, productID st
 	return stats, nil
 }
 
+// GetViewHistogram retrieves a product's views grouped into hour or day
+// buckets. An unknown bucket fails with repository.ErrInvalidBucket.
+func (s *AnalyticsService) GetViewHistogram(ctx context.Context, productID, bucket string) ([]*domain.HistogramBucket, error) {
+	if productID == "" {
+		return nil, fmt.Errorf("product ID is required")
+	}
+
+	buckets, err := s.repo.GetViewHistogram(ctx, productID, bucket)
+	if err != nil {
+		s.logger.Error().
+			Err(err).
+			Str("productId", productID).
+			Str("bucket", bucket).
+			Msg("Failed to get view histogram")
+		return nil, fmt.Errorf("failed to get view histogram: %w", err)
+	}
+
+	return buckets, nil
+}
+
+// ExportProductViews calls fn for each view of productID recorded at or after
+// from and before to, oldest first. A zero from or to leaves that end open.
+func (s *AnalyticsService) ExportProductViews(ctx context.Context, productID string, from, to time.Time, fn func(*domain.ProductView) error) error {
+	if productID == "" {
+		return fmt.Errorf("product ID is required")
+	}
+	if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		return fmt.Errorf("%w: from must be before to", ErrInvalidTimeRange)
+	}
+
+	err := s.repo.StreamViews(ctx, productID, repository.ViewFilter{From: from, To: to}, fn)
+	if err != nil {
+		s.logger.Error().
+			Err(err).
+			Str("productId", productID).
+			Msg("Failed to export product views")
+		return fmt.Errorf("failed to export product views: %w", err)
+	}
+
+	return nil
+}
+
 // GetTopViewedProducts retrieves the top viewed products.
 func (s *AnalyticsService) GetTopViewedProducts(ctx context.Context, limit int) ([]*domain.TopProductStats, error) {
 	// Apply default and maximum limits
 	if limit <= 0 {
 		limit = 10 // Default limit
 	}
-	if limit > 100 {
-		limit = 100 // Maximum limit
+	if limit > repository.MaxTopViewedLimit {
+		limit = repository.MaxTopViewedLimit
 	}
 
 	stats, err := s.repo.GetTopViewed(ctx, limit)
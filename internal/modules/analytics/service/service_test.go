@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/analytics/domain"
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/analytics/repository"
+	"github.com/gaborage/go-bricks/logger"
+)
+
+// mockRepository implements repository.Repository, recording the views it is given.
+type mockRepository struct {
+	views []*domain.ProductView
+
+	// recentViewExists answers RecentViewExists.
+	recentViewExists bool
+	recentViewCalls  int
+
+	// batches records RecordViewsBatch calls, which a ViewBuffer makes from
+	// its own goroutine.
+	mu      sync.Mutex
+	batches [][]*domain.ProductView
+}
+
+func (m *mockRepository) RecordView(_ context.Context, view *domain.ProductView) error {
+	m.views = append(m.views, view)
+	return nil
+}
+
+func (m *mockRepository) RecordViewsBatch(_ context.Context, views []*domain.ProductView) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.batches = append(m.batches, views)
+	return nil
+}
+
+// batchSizes returns the length of each RecordViewsBatch call so far.
+func (m *mockRepository) batchSizes() []int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	sizes := make([]int, len(m.batches))
+	for i, batch := range m.batches {
+		sizes[i] = len(batch)
+	}
+	return sizes
+}
+
+func (m *mockRepository) GetViewStats(context.Context, string) (*domain.ViewStats, error) {
+	return nil, nil
+}
+
+func (m *mockRepository) GetTopViewed(context.Context, int) ([]*domain.TopProductStats, error) {
+	return nil, nil
+}
+
+func (m *mockRepository) EnsureProductTracked(context.Context, string) error {
+	return nil
+}
+
+func (m *mockRepository) UpdateViewField(context.Context, string, string, any) error {
+	return nil
+}
+
+func (m *mockRepository) RecentViewExists(context.Context, string, string, time.Time) (bool, error) {
+	m.recentViewCalls++
+	return m.recentViewExists, nil
+}
+
+func (m *mockRepository) StreamViews(context.Context, string, repository.ViewFilter, func(*domain.ProductView) error) error {
+	return nil
+}
+
+func (m *mockRepository) GetViewHistogram(context.Context, string, string) ([]*domain.HistogramBucket, error) {
+	return nil, nil
+}
+
+func TestRecordProductViewProductExistsChecker(t *testing.T) {
+	checkErr := errors.New("database error")
+
+	tests := []struct {
+		name      string
+		checker   ProductExistsChecker
+		wantErr   error
+		wantViews int
+	}{
+		{name: "no checker", wantViews: 1},
+		{name: "known product", checker: ProductExistsFunc(func(context.Context, string) (bool, error) { return true, nil }), wantViews: 1},
+		{name: "unknown product", checker: ProductExistsFunc(func(context.Context, string) (bool, error) { return false, nil }), wantErr: ErrUnknownProduct},
+		{name: "check fails", checker: ProductExistsFunc(func(context.Context, string) (bool, error) { return false, checkErr }), wantErr: checkErr},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockRepository{}
+			var opts []Option
+			if tt.checker != nil {
+				opts = append(opts, WithProductExistsChecker(tt.checker))
+			}
+			svc := NewService(repo, logger.New("info", false), opts...)
+
+			err := svc.RecordProductView(context.Background(), "product-1", "", "", "", "")
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("RecordProductView() error = %v, want %v", err, tt.wantErr)
+			}
+			if len(repo.views) != tt.wantViews {
+				t.Errorf("RecordProductView() recorded %d views, want %d", len(repo.views), tt.wantViews)
+			}
+		})
+	}
+}
+
+func TestRecordProductViewDedup(t *testing.T) {
+	tests := []struct {
+		name        string
+		sessionID   string
+		window      time.Duration
+		seen        bool
+		wantViews   int
+		wantChecked bool
+	}{
+		{name: "new session view", sessionID: "session-1", window: 30 * time.Minute, wantViews: 1, wantChecked: true},
+		{name: "repeat session view", sessionID: "session-1", window: 30 * time.Minute, seen: true, wantChecked: true},
+		{name: "no session", window: 30 * time.Minute, seen: true, wantViews: 1},
+		{name: "dedup disabled", sessionID: "session-1", seen: true, wantViews: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockRepository{recentViewExists: tt.seen}
+			svc := NewService(repo, logger.New("info", false), WithDedupWindow(tt.window))
+
+			if err := svc.RecordProductView(context.Background(), "product-1", "", "", tt.sessionID, ""); err != nil {
+				t.Fatalf("RecordProductView() unexpected error = %v", err)
+			}
+
+			if len(repo.views) != tt.wantViews {
+				t.Errorf("RecordProductView() recorded %d views, want %d", len(repo.views), tt.wantViews)
+			}
+			if (repo.recentViewCalls > 0) != tt.wantChecked {
+				t.Errorf("RecordProductView() recent view checks = %d, want checked %v", repo.recentViewCalls, tt.wantChecked)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+// Package handlers provides HTTP handlers for the dbadmin module.
+package handlers
+
+import (
+	"context"
+
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/shared/admin"
+	"github.com/gaborage/go-bricks/database"
+	"github.com/gaborage/go-bricks/logger"
+	"github.com/gaborage/go-bricks/server"
+)
+
+// Database is one connection whose migration status is reported.
+type Database struct {
+	Name  string
+	GetDB func(context.Context) (database.Interface, error)
+}
+
+// MigrationsRequest carries no input; the report covers every database.
+type MigrationsRequest struct{}
+
+// DatabaseMigrationsResponse is the migration status of one database. Error is
+// set instead of the other fields when the database could not be inspected.
+type DatabaseMigrationsResponse struct {
+	Name        string `json:"name"`
+	Table       string `json:"table,omitempty"`
+	TableExists bool   `json:"tableExists"`
+	Version     string `json:"version,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// MigrationsResponse lists the migration status of each database in registration order.
+type MigrationsResponse struct {
+	Databases []DatabaseMigrationsResponse `json:"databases"`
+}
+
+// MigrationHandler serves database administration endpoints.
+type MigrationHandler struct {
+	databases []Database
+	guard     *admin.Guard
+	logger    logger.Logger
+}
+
+// NewMigrationHandler creates a handler reporting on the given databases.
+func NewMigrationHandler(databases []Database, guard *admin.Guard, l logger.Logger) *MigrationHandler {
+	return &MigrationHandler{
+		databases: databases,
+		guard:     guard,
+		logger:    l,
+	}
+}
+
+// Migrations reports whether each database has a migration table and its
+// current version. A database that cannot be reached or inspected is reported
+// with an error rather than failing the whole request.
+func (h *MigrationHandler) Migrations(_ MigrationsRequest, ctx server.HandlerContext) (*MigrationsResponse, server.IAPIError) {
+	if apiErr := h.guard.Authorize(ctx); apiErr != nil {
+		return nil, apiErr
+	}
+
+	response := &MigrationsResponse{Databases: make([]DatabaseMigrationsResponse, 0, len(h.databases))}
+	for _, d := range h.databases {
+		response.Databases = append(response.Databases, h.databaseStatus(ctx.RequestContext(), d))
+	}
+	return response, nil
+}
+
+func (h *MigrationHandler) databaseStatus(ctx context.Context, d Database) DatabaseMigrationsResponse {
+	db, err := d.GetDB(ctx)
+	if err != nil {
+		h.logger.Warn().Err(err).Str("database", d.Name).Msg("Database unavailable for migration status")
+		return DatabaseMigrationsResponse{Name: d.Name, Error: "database unavailable"}
+	}
+
+	status, err := ReadMigrationStatus(ctx, db)
+	if err != nil {
+		h.logger.Error().Err(err).Str("database", d.Name).Msg("Failed to read migration status")
+		return DatabaseMigrationsResponse{Name: d.Name, Table: status.Table, Error: "failed to read migration status"}
+	}
+
+	return DatabaseMigrationsResponse{
+		Name:        d.Name,
+		Table:       status.Table,
+		TableExists: status.TableExists,
+		Version:     status.Version,
+	}
+}
+
+// RegisterRoutes registers database administration routes.
+func (h *MigrationHandler) RegisterRoutes(hr *server.HandlerRegistry, r server.RouteRegistrar) {
+	server.GET(hr, r, "/admin/db/migrations", h.Migrations,
+		server.WithTags("admin"),
+	)
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/shared/admin"
+	"github.com/gaborage/go-bricks/config"
+	"github.com/gaborage/go-bricks/database"
+	dbtest "github.com/gaborage/go-bricks/database/testing"
+	dbtypes "github.com/gaborage/go-bricks/database/types"
+	"github.com/gaborage/go-bricks/logger"
+	"github.com/gaborage/go-bricks/server"
+)
+
+const migrationTable = "flyway_schema_history"
+
+func newAdminTestContext() server.HandlerContext {
+	req := httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/admin/db/migrations", nil)
+	rec := httptest.NewRecorder()
+	return server.NewHandlerContextForTest(rec, req, &config.Config{})
+}
+
+// migrationDB returns a test database whose migration table exists when
+// version is non-nil, reporting *version as the latest applied migration.
+func migrationDB(version *string) *dbtest.TestDB {
+	db := dbtest.NewTestDB(dbtypes.PostgreSQL)
+	if version == nil {
+		db.ExpectQuery("information_schema.tables").WillReturnRows(dbtest.NewRowSet("count").AddRow(0))
+		return db
+	}
+	db.ExpectQuery("information_schema.tables").WillReturnRows(dbtest.NewRowSet("count").AddRow(1))
+	rows := dbtest.NewRowSet("version")
+	if *version != "" {
+		rows.AddRow(*version)
+	}
+	db.ExpectQuery("FROM " + migrationTable).WillReturnRows(rows)
+	return db
+}
+
+func staticDB(db database.Interface) func(context.Context) (database.Interface, error) {
+	return func(context.Context) (database.Interface, error) {
+		return db, nil
+	}
+}
+
+func TestReadMigrationStatus(t *testing.T) {
+	version := func(v string) *string { return &v }
+
+	tests := []struct {
+		name    string
+		version *string
+		want    MigrationStatus
+	}{
+		{name: "no migration table", want: MigrationStatus{Table: migrationTable}},
+		{name: "table without applied migrations", version: version(""), want: MigrationStatus{Table: migrationTable, TableExists: true}},
+		{name: "applied migrations", version: version("3"), want: MigrationStatus{Table: migrationTable, TableExists: true, Version: "3"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := migrationDB(tt.version)
+
+			got, err := ReadMigrationStatus(context.Background(), db)
+			if err != nil {
+				t.Fatalf("ReadMigrationStatus() unexpected error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ReadMigrationStatus() = %+v, want %+v", got, tt.want)
+			}
+			if tt.version == nil {
+				dbtest.AssertQueryNotExecuted(t, db, "FROM "+migrationTable)
+			}
+		})
+	}
+}
+
+func TestMigrations(t *testing.T) {
+	applied := "3"
+	broken := dbtest.NewTestDB(dbtypes.PostgreSQL)
+	broken.ExpectQuery("information_schema.tables").WillReturnError(errors.New("permission denied"))
+
+	databases := []Database{
+		{Name: "default", GetDB: staticDB(migrationDB(&applied))},
+		{Name: "analytics", GetDB: staticDB(migrationDB(nil))},
+		{Name: "broken", GetDB: staticDB(broken)},
+		{Name: "offline", GetDB: func(context.Context) (database.Interface, error) {
+			return nil, errors.New("connection refused")
+		}},
+	}
+	guard := admin.NewGuard(admin.Config{Enabled: true})
+	handler := NewMigrationHandler(databases, guard, logger.New("info", false))
+
+	response, apiErr := handler.Migrations(MigrationsRequest{}, newAdminTestContext())
+	if apiErr != nil {
+		t.Fatalf("Migrations() unexpected error = %v", apiErr)
+	}
+
+	want := []DatabaseMigrationsResponse{
+		{Name: "default", Table: migrationTable, TableExists: true, Version: "3"},
+		{Name: "analytics", Table: migrationTable},
+		{Name: "broken", Table: migrationTable, Error: "failed to read migration status"},
+		{Name: "offline", Error: "database unavailable"},
+	}
+	if !reflect.DeepEqual(response.Databases, want) {
+		t.Errorf("Migrations() databases = %+v, want %+v", response.Databases, want)
+	}
+}
+
+func TestMigrationsRequiresAdmin(t *testing.T) {
+	handler := NewMigrationHandler(nil, admin.NewGuard(admin.Config{}), logger.New("info", false))
+
+	_, apiErr := handler.Migrations(MigrationsRequest{}, newAdminTestContext())
+	if apiErr == nil || apiErr.HTTPStatus() != http.StatusForbidden {
+		t.Errorf("Migrations() error = %v, want %d", apiErr, http.StatusForbidden)
+	}
+}
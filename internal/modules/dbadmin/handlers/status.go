@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/shared/dbutil"
+	"github.com/gaborage/go-bricks/database"
+)
+
+// MigrationStatus describes the migration history of one database.
+type MigrationStatus struct {
+	// Table is the migration history table reported by the database driver.
+	Table string
+	// TableExists is false until the first migration run creates Table.
+	TableExists bool
+	// Version is the latest successfully applied versioned migration, or
+	// empty when none has been applied.
+	Version string
+}
+
+// ReadMigrationStatus inspects db's migration table. The table name comes
+// from database.Interface.MigrationTable; Version is read from the Flyway
+// history columns that CreateMigrationTable defines.
+func ReadMigrationStatus(ctx context.Context, db database.Interface) (MigrationStatus, error) {
+	status := MigrationStatus{Table: db.MigrationTable()}
+
+	qb := database.NewQueryBuilder(database.PostgreSQL)
+	f := qb.Filter()
+
+	existsQuery, existsArgs, err := qb.Select("COUNT(*)").
+		From("information_schema.tables").
+		Where(f.And(
+			f.Eq("table_name", status.Table),
+			f.Raw("table_schema = current_schema()"),
+		)).
+		ToSQL()
+	if err != nil {
+		return status, fmt.Errorf("failed to build migration table query: %w", err)
+	}
+
+	count, err := dbutil.ScanScalar[int](ctx, db, existsQuery, existsArgs...)
+	if err != nil {
+		return status, fmt.Errorf("failed to check migration table: %w", err)
+	}
+	status.TableExists = count > 0
+	if !status.TableExists {
+		return status, nil
+	}
+
+	versionQuery, versionArgs, err := qb.Select("version").
+		From(status.Table).
+		Where(f.And(f.Eq("success", true), f.NotNull("version"))).
+		OrderBy("installed_rank DESC").
+		Limit(1).
+		ToSQL()
+	if err != nil {
+		return status, fmt.Errorf("failed to build migration version query: %w", err)
+	}
+
+	version, err := dbutil.ScanScalar[sql.NullString](ctx, db, versionQuery, versionArgs...)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return status, fmt.Errorf("failed to read migration version: %w", err)
+	}
+	status.Version = version.String
+
+	return status, nil
+}
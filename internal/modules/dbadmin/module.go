@@ -0,0 +1,79 @@
+// Package dbadmin exposes database administration endpoints, such as the
+// migration status of the default and analytics databases via
+// GET /admin/db/migrations.
+package dbadmin
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/dbadmin/handlers"
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/shared/admin"
+	"github.com/gaborage/go-bricks/app"
+	"github.com/gaborage/go-bricks/database"
+	"github.com/gaborage/go-bricks/logger"
+	"github.com/gaborage/go-bricks/messaging"
+	"github.com/gaborage/go-bricks/server"
+)
+
+// analyticsDBName matches the named database under "databases:" in config.development.yaml.
+const analyticsDBName = "analytics"
+
+// Module wires the database administration handlers.
+type Module struct {
+	handler *handlers.MigrationHandler
+	logger  logger.Logger
+}
+
+// NewModule creates a new dbadmin module instance.
+func NewModule() *Module {
+	return &Module{}
+}
+
+// Name returns the module name for registration.
+func (m *Module) Name() string {
+	return "dbadmin"
+}
+
+// Init registers the default and analytics databases for status reporting.
+func (m *Module) Init(deps *app.ModuleDeps) error {
+	m.logger = deps.Logger.WithFields(map[string]any{
+		"module": "dbadmin",
+	})
+
+	var adminCfg admin.Config
+	if err := deps.Config.InjectInto(&adminCfg); err != nil {
+		return fmt.Errorf("failed to load admin config: %w", err)
+	}
+
+	databases := []handlers.Database{
+		{Name: "default", GetDB: deps.DB},
+		{Name: analyticsDBName, GetDB: func(ctx context.Context) (database.Interface, error) {
+			return deps.DBByName(ctx, analyticsDBName)
+		}},
+	}
+	m.handler = handlers.NewMigrationHandler(databases, admin.NewGuard(adminCfg), m.logger)
+
+	return nil
+}
+
+// RegisterRoutes registers HTTP endpoints for database administration.
+func (m *Module) RegisterRoutes(hr *server.HandlerRegistry, r server.RouteRegistrar) {
+	m.handler.RegisterRoutes(hr, r)
+}
+
+// DeclareMessaging declares messaging infrastructure for this module.
+func (m *Module) DeclareMessaging(_ *messaging.Declarations) {
+	// No messaging needed for dbadmin module.
+}
+
+// RegisterJobs registers scheduled jobs for this module.
+func (m *Module) RegisterJobs(_ app.JobRegistrar) error {
+	// No scheduled jobs for dbadmin module.
+	return nil
+}
+
+// Shutdown performs cleanup when the module is stopped.
+func (m *Module) Shutdown() error {
+	return nil
+}
@@ -9,6 +9,7 @@ import (
 	producthandlers "github.com/gaborage/go-bricks-demo-project/internal/modules/products/handlers"
 	"github.com/gaborage/go-bricks-demo-project/internal/modules/products/repository"
 	"github.com/gaborage/go-bricks-demo-project/internal/modules/products/service"
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/shared/dbutil"
 	"github.com/gaborage/go-bricks/logger"
 	"github.com/gaborage/go-bricks/server"
 )
@@ -28,38 +29,35 @@ func NewLegacyHandler(s producthandlers.ProductServiceInterface, l logger.Logger
 	}
 }
 
-// GetProduct returns a single product without the APIResponse envelope.
-func (h *LegacyHandler) GetProduct(req producthandlers.GetProductRequest, ctx server.HandlerContext) (*producthandlers.ProductResponse, server.IAPIError) {
+// GetProduct returns a single product without the APIResponse envelope. Like
+// GET /products/:id, it sets an ETag and answers a matching If-None-Match
+// with 304 Not Modified.
+func (h *LegacyHandler) GetProduct(req producthandlers.GetProductRequest, ctx server.HandlerContext) (server.Result[*producthandlers.ProductResponse], server.IAPIError) {
 	product, err := h.service.GetProductByID(ctx.RequestContext(), req.ID)
 	if err != nil {
 		if errors.Is(err, repository.ErrProductNotFound) {
-			return nil, server.NewNotFoundError("Product")
+			return server.Result[*producthandlers.ProductResponse]{}, server.NewNotFoundError("Product")
 		}
 		h.logger.Error().Err(err).Str("productID", req.ID).Msg("Failed to get product")
-		return nil, server.NewInternalServerError("Failed to retrieve product")
+		return server.Result[*producthandlers.ProductResponse]{}, dbutil.APIError(ctx, err, "Failed to retrieve product")
 	}
 
-	return producthandlers.ToProductResponse(product), nil
+	return producthandlers.ProductResult(ctx, product), nil
 }
 
 // ListProducts returns a paginated list of products without the APIResponse envelope.
 func (h *LegacyHandler) ListProducts(req producthandlers.ListProductsRequest, ctx server.HandlerContext) (*producthandlers.ListProductsResponse, server.IAPIError) {
-	products, total, err := h.service.ListProducts(ctx.RequestContext(), req.Page, req.PageSize)
+	products, total, err := h.service.ListProducts(ctx.RequestContext(), req.Page, req.PageSize, req.ListOptions())
 	if err != nil {
 		h.logger.Error().Err(err).Int("page", req.Page).Int("pageSize", req.PageSize).Msg("Failed to list products")
 		if errors.Is(err, service.ErrValidation) {
 			return nil, server.NewBadRequestError(err.Error())
 		}
-		return nil, server.NewInternalServerError("Failed to retrieve products")
-	}
-
-	productResponses := make([]producthandlers.ProductResponse, len(products))
-	for i, p := range products {
-		productResponses[i] = *producthandlers.ToProductResponse(p)
+		return nil, dbutil.APIError(ctx, err, "Failed to retrieve products")
 	}
 
 	return &producthandlers.ListProductsResponse{
-		Products: productResponses,
+		Products: producthandlers.ToProductResponseList(products),
 		Total:    total,
 		Page:     req.Page,
 		PageSize: req.PageSize,
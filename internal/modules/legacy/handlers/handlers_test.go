@@ -17,7 +17,10 @@ import (
 	"github.com/gaborage/go-bricks/server"
 )
 
-const testID = "test-id"
+const (
+	testID  = "test-id"
+	testSKU = "MUG-001"
+)
 
 // mockService implements the subset of ProductServiceInterface needed by legacy handlers.
 type mockService struct {
@@ -25,10 +28,18 @@ type mockService struct {
 	listProductsFunc   func(ctx context.Context, page, pageSize int) ([]*domain.Product, int, error)
 }
 
-func (m *mockService) CreateProduct(context.Context, string, string, float64, string) (*domain.Product, error) {
+func (m *mockService) CreateProduct(context.Context, service.CreateProductInput) (*domain.Product, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockService) CreateProducts(context.Context, []service.CreateProductInput) ([]*domain.Product, error) {
 	return nil, errors.New("not implemented")
 }
 
+func (m *mockService) CreateProductsPartial(context.Context, []service.CreateProductInput) ([]*domain.Product, []service.BatchItemError, error) {
+	return nil, nil, errors.New("not implemented")
+}
+
 func (m *mockService) GetProductByID(ctx context.Context, id string) (*domain.Product, error) {
 	if m.getProductByIDFunc != nil {
 		return m.getProductByIDFunc(ctx, id)
@@ -36,21 +47,57 @@ func (m *mockService) GetProductByID(ctx context.Context, id string) (*domain.Pr
 	return nil, errors.New("not implemented")
 }
 
-func (m *mockService) ListProducts(ctx context.Context, page, pageSize int) ([]*domain.Product, int, error) {
+func (m *mockService) GetProductBySKU(context.Context, string) (*domain.Product, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockService) ListProducts(ctx context.Context, page, pageSize int, _ service.ListOptions) ([]*domain.Product, int, error) {
 	if m.listProductsFunc != nil {
 		return m.listProductsFunc(ctx, page, pageSize)
 	}
 	return nil, 0, errors.New("not implemented")
 }
 
-func (m *mockService) UpdateProduct(context.Context, string, *string, *string, *float64, *string) (*domain.Product, error) {
+func (m *mockService) ListProductsAfter(context.Context, string, int) ([]*domain.Product, string, error) {
+	return nil, "", errors.New("not implemented")
+}
+
+func (m *mockService) PriceStats(context.Context, string, *string) (repository.PriceStats, error) {
+	return repository.PriceStats{}, errors.New("not implemented")
+}
+
+func (m *mockService) FindDuplicates(context.Context) ([]repository.DuplicateGroup, error) {
 	return nil, errors.New("not implemented")
 }
 
+func (m *mockService) StreamProducts(context.Context, repository.StreamOptions, func(*domain.Product) error) error {
+	return errors.New("not implemented")
+}
+
+func (m *mockService) UpdateProduct(context.Context, string, *string, domain.OptionalString, *string, domain.OptionalString, domain.OptionalString, *int) (*domain.Product, []domain.FieldChange, error) {
+	return nil, nil, errors.New("not implemented")
+}
+
 func (m *mockService) DeleteProduct(context.Context, string) error {
 	return errors.New("not implemented")
 }
 
+func (m *mockService) LockProduct(context.Context, string) error {
+	return errors.New("not implemented")
+}
+
+func (m *mockService) UnlockProduct(context.Context, string) error {
+	return errors.New("not implemented")
+}
+
+func (m *mockService) ReserveStock(context.Context, string, int) error {
+	return errors.New("not implemented")
+}
+
+func (m *mockService) ReleaseStock(context.Context, string, int) error {
+	return errors.New("not implemented")
+}
+
 func newMockLogger() logger.Logger {
 	return logger.New("info", false)
 }
@@ -89,7 +136,7 @@ func TestGetProduct(t *testing.T) {
 			name:      "successful get",
 			productID: testID,
 			serviceFunc: func(_ context.Context, id string) (*domain.Product, error) {
-				return domain.New(id, "Test Product", "Description", 99.99, "https://example.com/image.jpg"), nil
+				return domain.New(id, testSKU, "Test Product", "Description", 9999, domain.DefaultCurrency, "https://example.com/image.jpg"), nil
 			},
 			wantStatus:    http.StatusOK,
 			checkResponse: true,
@@ -126,7 +173,8 @@ func TestGetProduct(t *testing.T) {
 			req := &producthandlers.GetProductRequest{ID: tt.productID}
 			ctx := newTestContext(cfg)
 
-			response, apiErr := handler.GetProduct(*req, ctx)
+			result, apiErr := handler.GetProduct(*req, ctx)
+			response := result.Data
 
 			if tt.wantErrCode != "" {
 				if apiErr == nil {
@@ -157,6 +205,40 @@ func TestGetProduct(t *testing.T) {
 	}
 }
 
+func TestGetProductConditional(t *testing.T) {
+	product := domain.New(testID, testSKU, "Test Product", "Description", 9999, domain.DefaultCurrency, "")
+	handler := NewLegacyHandler(&mockService{
+		getProductByIDFunc: func(context.Context, string) (*domain.Product, error) {
+			return product, nil
+		},
+	}, newMockLogger())
+
+	first, apiErr := handler.GetProduct(producthandlers.GetProductRequest{ID: testID}, newTestContext(newMockConfig()))
+	if apiErr != nil {
+		t.Fatalf("GetProduct() unexpected error: %v", apiErr)
+	}
+	etag := first.Headers.Get("ETag")
+	if first.Status != http.StatusOK || etag == "" {
+		t.Fatalf("GetProduct() = status %d, ETag %q, want 200 with an ETag", first.Status, etag)
+	}
+
+	req := httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/legacy/products/"+testID, nil)
+	req.Header.Set("If-None-Match", etag)
+	ctx := server.NewHandlerContextForTest(httptest.NewRecorder(), req, newMockConfig())
+
+	result, apiErr := handler.GetProduct(producthandlers.GetProductRequest{ID: testID}, ctx)
+
+	if apiErr != nil {
+		t.Fatalf("GetProduct() unexpected error: %v", apiErr)
+	}
+	if result.Status != http.StatusNotModified || result.Data != nil {
+		t.Errorf("GetProduct() = status %d, data %v, want 304 with no body", result.Status, result.Data)
+	}
+	if got := result.Headers.Get("ETag"); got != etag {
+		t.Errorf("GetProduct() ETag = %q, want %q", got, etag)
+	}
+}
+
 func TestListProducts(t *testing.T) {
 	log := newMockLogger()
 	cfg := newMockConfig()
@@ -177,8 +259,8 @@ func TestListProducts(t *testing.T) {
 			pageSize: 10,
 			serviceFunc: func(_ context.Context, _, _ int) ([]*domain.Product, int, error) {
 				products := []*domain.Product{
-					domain.New("1", "Product 1", "Desc 1", 10.00, ""),
-					domain.New("2", "Product 2", "Desc 2", 20.00, ""),
+					domain.New("1", testSKU, "Product 1", "Desc 1", 1000, domain.DefaultCurrency, ""),
+					domain.New("2", testSKU, "Product 2", "Desc 2", 2000, domain.DefaultCurrency, ""),
 				}
 				return products, 2, nil
 			},
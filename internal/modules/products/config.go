@@ -0,0 +1,105 @@
+package products
+
+import "time"
+
+// Config holds the products module settings under custom.products.
+// It is populated with config.InjectInto during Init.
+type Config struct {
+	// CompressionEnabled turns on gzip for handler-written bodies (stream, export).
+	// Leave it off when server.gzip already compresses every response.
+	CompressionEnabled bool `config:"custom.products.compression.enabled" default:"false"`
+	// CompressionMinBytes is the body size at which compression kicks in.
+	CompressionMinBytes int `config:"custom.products.compression.min.bytes" default:"1024"`
+	// StrictQueryParams rejects unrecognized query parameters on list endpoints
+	// with 400. Off by default so existing clients keep working.
+	StrictQueryParams bool `config:"custom.products.query.strict" default:"false"`
+	// AllowedContentTypes lists the request media types accepted by create and
+	// update. Anything else, including a missing Content-Type, gets 415.
+	AllowedContentTypes []string `config:"custom.products.content.types" default:"application/json"`
+	// MaxListOffset is the deepest OFFSET GET /products may request; pages
+	// beyond it are rejected with 400. Zero leaves paging unlimited.
+	MaxListOffset int `config:"custom.products.list.max.offset" default:"0"`
+	// MaxListLimit is the hard cap the repository puts on a list query's
+	// LIMIT, independent of the page sizes the API accepts. Larger limits
+	// are clamped and logged. Zero leaves the repository unlimited.
+	MaxListLimit int `config:"custom.products.list.max.limit" default:"1000"`
+	// LargeResultThreshold flags list responses with more matching rows than
+	// this via X-Result-Large: true. Zero disables the header.
+	LargeResultThreshold int `config:"custom.products.result.large.threshold" default:"0"`
+	// StreamMaxRows caps GET /products/stream; a capped stream ends with the
+	// X-Result-Truncated: true trailer. Zero streams every row.
+	StreamMaxRows int `config:"custom.products.stream.max.rows" default:"0"`
+	// AllowZeroPrice accepts free products (e.g. samples). When false, create
+	// and update reject a price of exactly 0 with 400.
+	AllowZeroPrice bool `config:"custom.products.price.allow.zero" default:"true"`
+	// DefaultCurrency is the ISO 4217 code given to created products that
+	// omit a currency. An unsupported code fails startup.
+	DefaultCurrency string `config:"custom.products.price.default.currency" default:"USD"`
+	// MaxDescriptionLength is the longest product description, in characters,
+	// that create and update accept; longer ones get 400. Zero is unlimited.
+	MaxDescriptionLength int `config:"custom.products.description.max.length" default:"2000"`
+	// ImageURLSchemes lists the URL schemes accepted for product image URLs.
+	// Use "https" alone to forbid plain http, or add e.g. "s3" for internal references.
+	ImageURLSchemes []string `config:"custom.products.image.url.schemes" default:"http,https"`
+	// ImageURLHosts restricts product image URLs to these hosts, e.g. the CDN
+	// serving product images. Empty accepts any host.
+	ImageURLHosts []string `config:"custom.products.image.url.hosts"`
+	// CacheEnabled serves GET /products/:id from a short-lived in-process
+	// cache, keyed by tenant, coalescing concurrent reads of the same ID.
+	CacheEnabled bool `config:"custom.products.cache.enabled" default:"false"`
+	// CacheTTL is how long a cached product is served before re-reading it.
+	CacheTTL time.Duration `config:"custom.products.cache.ttl" default:"5s"`
+	// CacheMaxSize bounds the number of cached products.
+	CacheMaxSize int `config:"custom.products.cache.max.size" default:"1000"`
+	// IdempotencyTTL is how long an Idempotency-Key on POST /products is
+	// remembered. Zero ignores the header.
+	IdempotencyTTL time.Duration `config:"custom.products.idempotency.ttl" default:"24h"`
+	// IdempotencyMaxSize bounds the number of remembered keys.
+	IdempotencyMaxSize int `config:"custom.products.idempotency.max.size" default:"10000"`
+	// DeletePolicy is "hard" to remove deleted products' rows or "soft" to
+	// keep them with deleted_at set. Either way a deleted product is not found.
+	// Switching from soft back to hard makes soft-deleted products visible again.
+	DeletePolicy string `config:"custom.products.delete.policy" default:"hard"`
+	// ReportJobEnabled registers the scheduled report job. When false the job
+	// is not registered at all.
+	ReportJobEnabled bool `config:"custom.products.report.job.enabled" default:"true"`
+	// ReportJobInterval is how often the report job runs. Zero disables the
+	// job like ReportJobEnabled=false; a negative interval fails startup.
+	ReportJobInterval time.Duration `config:"custom.products.report.job.interval" default:"30s"`
+	// ReportJobTimeout bounds a single report run; a run still reading or
+	// uploading when it expires is cancelled. Zero uses ReportJobInterval, so
+	// a run never outlasts the next tick.
+	ReportJobTimeout time.Duration `config:"custom.products.report.job.timeout" default:"0s"`
+	// ReportJobDestination is the directory (or key prefix) reports are
+	// uploaded to, relative to the custom.storage backend's root.
+	ReportJobDestination string `config:"custom.products.report.job.destination" default:"products"`
+	// ReportJobFormat is the report file format: "txt" (tab-delimited),
+	// "csv" or "json". It sets the file extension and the uploaded content
+	// type.
+	ReportJobFormat string `config:"custom.products.report.job.format" default:"txt"`
+	// LowStockJobEnabled registers the scheduled low-stock job, which
+	// publishes a product.low_stock event per product below LowStockThreshold.
+	LowStockJobEnabled bool `config:"custom.products.lowstock.job.enabled" default:"true"`
+	// LowStockJobInterval is how often the low-stock job runs. Zero disables
+	// the job; a negative interval fails startup.
+	LowStockJobInterval time.Duration `config:"custom.products.lowstock.job.interval" default:"5m"`
+	// LowStockThreshold is the stock level below which a product is reported.
+	LowStockThreshold int `config:"custom.products.lowstock.threshold" default:"5"`
+}
+
+// compressionThreshold returns the gzip threshold for handlers, or zero when disabled.
+func (c Config) compressionThreshold() int {
+	if !c.CompressionEnabled {
+		return 0
+	}
+	return c.CompressionMinBytes
+}
+
+// reportJobTimeout returns the bound of a report run: ReportJobTimeout, or
+// ReportJobInterval when it is not set.
+func (c Config) reportJobTimeout() time.Duration {
+	if c.ReportJobTimeout > 0 {
+		return c.ReportJobTimeout
+	}
+	return c.ReportJobInterval
+}
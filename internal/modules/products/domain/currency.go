@@ -0,0 +1,164 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is the ISO 4217 code assumed for products that do not carry one.
+const DefaultCurrency = "USD"
+
+// defaultCurrencyScale is the number of minor-unit digits used for currencies
+// not listed in currencyScales.
+const defaultCurrencyScale = 2
+
+var (
+	// ErrUnsupportedCurrency is returned for currency codes not in currencyScales.
+	ErrUnsupportedCurrency = errors.New("unsupported currency")
+	// ErrInvalidPrice is returned for price strings that are not a plain decimal
+	// number or carry more decimals than the currency's minor unit.
+	ErrInvalidPrice = errors.New("invalid price")
+)
+
+// currencyScales lists the supported ISO 4217 currencies with the number of
+// digits of their minor unit.
+var currencyScales = map[string]int{
+	"AUD": 2,
+	"BHD": 3,
+	"BRL": 2,
+	"CAD": 2,
+	"CHF": 2,
+	"CLP": 0,
+	"CNY": 2,
+	"CZK": 2,
+	"DKK": 2,
+	"EUR": 2,
+	"GBP": 2,
+	"HKD": 2,
+	"HUF": 2,
+	"INR": 2,
+	"ISK": 0,
+	"JOD": 3,
+	"JPY": 0,
+	"KRW": 0,
+	"KWD": 3,
+	"MXN": 2,
+	"NOK": 2,
+	"NZD": 2,
+	"OMR": 3,
+	"PLN": 2,
+	"SEK": 2,
+	"SGD": 2,
+	"TND": 3,
+	"USD": 2,
+	"VND": 0,
+	"ZAR": 2,
+}
+
+// NormalizeCurrency returns code upper-cased, or DefaultCurrency when code is
+// empty. Codes not in currencyScales fail with ErrUnsupportedCurrency.
+func NormalizeCurrency(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return DefaultCurrency, nil
+	}
+	if _, ok := currencyScales[code]; !ok {
+		return "", fmt.Errorf("%w %q", ErrUnsupportedCurrency, code)
+	}
+	return code, nil
+}
+
+// CurrencyScale returns the number of decimal digits used by the given currency.
+func CurrencyScale(currency string) int {
+	if scale, ok := currencyScales[strings.ToUpper(currency)]; ok {
+		return scale
+	}
+	return defaultCurrencyScale
+}
+
+// ParsePrice converts a decimal string such as "19.99" to minor units of
+// currency without going through float64, so it never rounds. Trailing zeros
+// past the currency's scale are accepted; other extra decimals, exponents and
+// anything else that is not a plain decimal fail with ErrInvalidPrice.
+func ParsePrice(amount, currency string) (int64, error) {
+	scale := CurrencyScale(currency)
+
+	digits := strings.TrimSpace(amount)
+	negative := strings.HasPrefix(digits, "-")
+	digits = strings.TrimPrefix(digits, "-")
+
+	whole, fraction, _ := strings.Cut(digits, ".")
+	if len(fraction) > scale {
+		if strings.Trim(fraction[scale:], "0") != "" {
+			return 0, fmt.Errorf("%w: %q has more than %d decimal places", ErrInvalidPrice, amount, scale)
+		}
+		fraction = fraction[:scale]
+	}
+	if whole == "" || !isDigits(whole) || !isDigits(fraction) {
+		return 0, fmt.Errorf("%w: %q is not a decimal number", ErrInvalidPrice, amount)
+	}
+
+	minor, err := strconv.ParseInt(whole+fraction+strings.Repeat("0", scale-len(fraction)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q is out of range", ErrInvalidPrice, amount)
+	}
+	if negative {
+		minor = -minor
+	}
+	return minor, nil
+}
+
+// isDigits reports whether s holds only ASCII digits. The empty string does.
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// FromMinorUnits converts minor units of currency to major units. Use
+// FormatMinorUnits to render a price; the float64 is for arithmetic only.
+func FromMinorUnits(minor int64, currency string) float64 {
+	return float64(minor) / math.Pow10(CurrencyScale(currency))
+}
+
+// FormatMinorUnits renders minor units of currency as a decimal string with
+// exactly the currency's scale, e.g. 1999 USD as "19.99" and 1999 JPY as "1999".
+func FormatMinorUnits(minor int64, currency string) string {
+	scale := CurrencyScale(currency)
+	sign := ""
+	if minor < 0 {
+		sign = "-"
+	}
+	digits := strconv.FormatUint(absMinor(minor), 10)
+	if scale == 0 {
+		return sign + digits
+	}
+	if len(digits) <= scale {
+		digits = strings.Repeat("0", scale-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-scale] + "." + digits[len(digits)-scale:]
+}
+
+// absMinor returns the magnitude of minor; it is exact for math.MinInt64.
+func absMinor(minor int64) uint64 {
+	if minor < 0 {
+		return uint64(-(minor + 1)) + 1
+	}
+	return uint64(minor)
+}
+
+// FormatPrice renders price with exactly the decimal scale of currency,
+// removing binary floating point artifacts such as 19.989999999999998.
+// An empty currency falls back to DefaultCurrency.
+func FormatPrice(price float64, currency string) string {
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	return strconv.FormatFloat(price, 'f', CurrencyScale(currency), 64)
+}
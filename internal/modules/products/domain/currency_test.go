@@ -0,0 +1,114 @@
+package domain
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestNormalizeCurrency(t *testing.T) {
+	tests := []struct {
+		code    string
+		want    string
+		wantErr bool
+	}{
+		{code: "", want: DefaultCurrency},
+		{code: "eur", want: "EUR"},
+		{code: " JPY ", want: "JPY"},
+		{code: "XYZ", wantErr: true},
+		{code: "US", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := NormalizeCurrency(tt.code)
+		if tt.wantErr {
+			if !errors.Is(err, ErrUnsupportedCurrency) {
+				t.Errorf("NormalizeCurrency(%q) error = %v, want ErrUnsupportedCurrency", tt.code, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("NormalizeCurrency(%q) = %q, %v, want %q", tt.code, got, err, tt.want)
+		}
+	}
+}
+
+func TestParsePrice(t *testing.T) {
+	tests := []struct {
+		amount   string
+		currency string
+		want     int64
+		wantErr  bool
+	}{
+		{amount: "19.99", currency: "USD", want: 1999},
+		{amount: "19.9", currency: "USD", want: 1990},
+		{amount: "19", currency: "USD", want: 1900},
+		{amount: "19.", currency: "USD", want: 1900},
+		{amount: "0.1", currency: "USD", want: 10},
+		{amount: "19.990", currency: "USD", want: 1999},
+		{amount: "-5.25", currency: "USD", want: -525},
+		{amount: "1999", currency: "JPY", want: 1999},
+		{amount: "1999.00", currency: "JPY", want: 1999},
+		{amount: "1.234", currency: "KWD", want: 1234},
+		{amount: "92233720368547758.07", currency: "USD", want: math.MaxInt64},
+		{amount: "19.999", currency: "USD", wantErr: true},
+		{amount: "1999.5", currency: "JPY", wantErr: true},
+		{amount: "1e3", currency: "USD", wantErr: true},
+		{amount: ".5", currency: "USD", wantErr: true},
+		{amount: "", currency: "USD", wantErr: true},
+		{amount: "+1", currency: "USD", wantErr: true},
+		{amount: "1,00", currency: "USD", wantErr: true},
+		{amount: "92233720368547758.08", currency: "USD", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParsePrice(tt.amount, tt.currency)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidPrice) {
+				t.Errorf("ParsePrice(%q, %s) = %d, %v, want ErrInvalidPrice", tt.amount, tt.currency, got, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParsePrice(%q, %s) = %d, %v, want %d", tt.amount, tt.currency, got, err, tt.want)
+		}
+	}
+}
+
+func TestFormatMinorUnits(t *testing.T) {
+	tests := []struct {
+		minor    int64
+		currency string
+		want     string
+	}{
+		{minor: 1999, currency: "USD", want: "19.99"},
+		{minor: 5, currency: "USD", want: "0.05"},
+		{minor: 0, currency: "USD", want: "0.00"},
+		{minor: -525, currency: "USD", want: "-5.25"},
+		{minor: 1999, currency: "JPY", want: "1999"},
+		{minor: 1234, currency: "KWD", want: "1.234"},
+		{minor: math.MinInt64, currency: "USD", want: "-92233720368547758.08"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatMinorUnits(tt.minor, tt.currency); got != tt.want {
+			t.Errorf("FormatMinorUnits(%d, %s) = %q, want %q", tt.minor, tt.currency, got, tt.want)
+		}
+	}
+}
+
+func TestMinorUnitsRoundTrip(t *testing.T) {
+	for _, amount := range []string{"0.01", "0.10", "19.99", "1000000.07"} {
+		minor, err := ParsePrice(amount, DefaultCurrency)
+		if err != nil {
+			t.Fatalf("ParsePrice(%q) unexpected error = %v", amount, err)
+		}
+		if got := FormatMinorUnits(minor, DefaultCurrency); got != amount {
+			t.Errorf("FormatMinorUnits(ParsePrice(%q)) = %q", amount, got)
+		}
+	}
+
+	if got := FromMinorUnits(1999, "JPY"); got != 1999 {
+		t.Errorf("FromMinorUnits(1999, JPY) = %v, want 1999", got)
+	}
+}
@@ -0,0 +1,27 @@
+package domain
+
+// FieldChange is one product field an update changed, named by its JSON key.
+type FieldChange struct {
+	Field  string `json:"field"`
+	Before any    `json:"before"`
+	After  any    `json:"after"`
+}
+
+// Diff lists the fields that differ between before and after, in the order
+// name, description, price, imageURL, categoryId. Prices are reported in minor units.
+// updatedDate and version are left out because every update changes them.
+func Diff(before, after *Product) []FieldChange {
+	var changes []FieldChange
+	add := func(field string, b, a any) {
+		if b != a {
+			changes = append(changes, FieldChange{Field: field, Before: b, After: a})
+		}
+	}
+
+	add("name", before.Name, after.Name)
+	add("description", before.Description, after.Description)
+	add("price", before.PriceMinor, after.PriceMinor)
+	add("imageURL", before.ImageURL, after.ImageURL)
+	add("categoryId", before.CategoryID, after.CategoryID)
+	return changes
+}
@@ -0,0 +1,38 @@
+package domain
+
+import "encoding/json"
+
+// OptionalString is an update field that tells an absent JSON key from an
+// explicit value. The zero value is absent. Decoding sets Present, and a JSON
+// null additionally sets Null, leaving Value empty.
+type OptionalString struct {
+	Present bool
+	Null    bool
+	Value   string
+}
+
+// SomeString returns a present OptionalString holding v.
+func SomeString(v string) OptionalString {
+	return OptionalString{Present: true, Value: v}
+}
+
+// NullString returns a present OptionalString holding JSON null.
+func NullString() OptionalString {
+	return OptionalString{Present: true, Null: true}
+}
+
+// UnmarshalJSON implements json.Unmarshaler. encoding/json only calls it for
+// keys present in the body, null included, so an absent key stays the zero value.
+func (o *OptionalString) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*o = NullString()
+		return nil
+	}
+
+	var v string
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*o = SomeString(v)
+	return nil
+}
@@ -10,25 +10,44 @@ var (
 )
 
 type Product struct {
-	ID          string    `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	Price       float64   `json:"price"`
+	ID string `json:"id"`
+	// SKU is the unique business identifier. It is set on creation.
+	SKU         string `json:"sku"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// PriceMinor is the price in minor units of Currency, e.g. cents for USD.
+	PriceMinor int64 `json:"priceMinor"`
+	// Currency is the ISO 4217 code of the price. It is set on creation.
+	Currency    string    `json:"currency"`
 	ImageURL    string    `json:"imageURL"`
 	CreatedDate time.Time `json:"createdDate"`
 	UpdatedDate time.Time `json:"updatedDate"`
+	// Version is incremented on every update; clients send it back to detect
+	// concurrent modifications.
+	Version int `json:"version"`
+	// Locked products reject updates and deletes until an admin unlocks them.
+	Locked bool `json:"locked"`
+	// CategoryID is the category the product belongs to; empty when it has none.
+	CategoryID string `json:"categoryId"`
+	// StockQuantity is the number of units available. It never goes below
+	// zero and only changes through reservations and releases.
+	StockQuantity int `json:"stockQuantity"`
 }
 
-func New(id, name, description string, price float64, imageURL string) *Product {
+// New creates a product priced at priceMinor minor units of currency.
+func New(id, sku, name, description string, priceMinor int64, currency, imageURL string) *Product {
 	timestamp := time.Now().UTC()
 	return &Product{
 		ID:          id,
+		SKU:         sku,
 		Name:        name,
 		Description: description,
-		Price:       price,
+		PriceMinor:  priceMinor,
+		Currency:    currency,
 		ImageURL:    imageURL,
 		CreatedDate: timestamp,
 		UpdatedDate: timestamp,
+		Version:     1,
 	}
 }
 
@@ -39,33 +58,50 @@ func (p *Product) Update(updates map[string]any) {
 	if description, ok := updates["description"].(string); ok {
 		p.Description = description
 	}
-	if price, ok := updates["price"].(float64); ok {
-		p.Price = price
+	if price, ok := updates["price"].(int64); ok {
+		p.PriceMinor = price
 	}
 	if imageURL, ok := updates["image_url"].(string); ok {
 		p.ImageURL = imageURL
 	}
+	if categoryID, ok := updates["categoryId"]; ok {
+		// nil clears the category.
+		p.CategoryID, _ = categoryID.(string)
+	}
 	p.UpdatedDate = time.Now().UTC()
 }
 
 func (p *Product) Validate() error {
-	if p.Name == "" {
+	if p.SKU == "" || p.Name == "" {
 		return ErrInvalidProduct
 	}
-	if p.Price < 0 {
+	if p.PriceMinor < 0 {
 		return ErrInvalidProduct
 	}
 	return nil
 }
 
+// FormattedPrice renders the price as a decimal string with the scale of
+// its currency, e.g. "19.99".
+func (p *Product) FormattedPrice() string {
+	return FormatMinorUnits(p.PriceMinor, p.Currency)
+}
+
 type ProductEntity struct {
 	ID          string    `json:"id" db:"id"`
+	SKU         string    `json:"sku" db:"sku"`
 	Name        string    `json:"name" db:"name"`
 	Description string    `json:"description" db:"description"`
-	Price       float64   `json:"price" db:"price"`
+	PriceMinor  int64     `json:"priceMinor" db:"price_minor"`
+	Currency    string    `json:"currency" db:"currency"`
 	ImageURL    string    `json:"imageURL" db:"image_url"`
 	CreatedDate time.Time `json:"createdDate" db:"created_date"`
 	UpdatedDate time.Time `json:"updatedDate" db:"updated_date"`
+	Version     int       `json:"version" db:"version"`
+	Locked      bool      `json:"locked" db:"locked"`
+	// CategoryID is NULL for uncategorized products.
+	CategoryID    *string `json:"categoryId" db:"category_id"`
+	StockQuantity int     `json:"stockQuantity" db:"stock_quantity"`
 }
 
 func (p *ProductEntity) TableName() string {
@@ -73,10 +109,10 @@ func (p *ProductEntity) TableName() string {
 }
 
 func (p *ProductEntity) Validate() error {
-	if p.Name == "" {
+	if p.SKU == "" || p.Name == "" {
 		return ErrInvalidProduct
 	}
-	if p.Price < 0 {
+	if p.PriceMinor < 0 {
 		return ErrInvalidProduct
 	}
 	return nil
@@ -84,32 +120,62 @@ func (p *ProductEntity) Validate() error {
 
 func ToProductEntity(p *Product) *ProductEntity {
 	return &ProductEntity{
-		ID:          p.ID,
-		Name:        p.Name,
-		Description: p.Description,
-		Price:       p.Price,
-		ImageURL:    p.ImageURL,
-		CreatedDate: p.CreatedDate,
-		UpdatedDate: p.UpdatedDate,
+		ID:            p.ID,
+		SKU:           p.SKU,
+		Name:          p.Name,
+		Description:   p.Description,
+		PriceMinor:    p.PriceMinor,
+		Currency:      p.Currency,
+		ImageURL:      p.ImageURL,
+		CreatedDate:   p.CreatedDate,
+		UpdatedDate:   p.UpdatedDate,
+		Version:       p.Version,
+		Locked:        p.Locked,
+		CategoryID:    nullableString(p.CategoryID),
+		StockQuantity: p.StockQuantity,
 	}
 }
 
+// nullableString maps the empty string to nil, which is stored as NULL.
+func nullableString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// ToProduct converts a database entity to a domain model. Timestamps are
+// normalized to UTC regardless of the zone the driver scanned them in.
 func ToProduct(pe *ProductEntity) *Product {
+	var categoryID string
+	if pe.CategoryID != nil {
+		categoryID = *pe.CategoryID
+	}
 	return &Product{
-		ID:          pe.ID,
-		Name:        pe.Name,
-		Description: pe.Description,
-		Price:       pe.Price,
-		ImageURL:    pe.ImageURL,
-		CreatedDate: pe.CreatedDate,
-		UpdatedDate: pe.UpdatedDate,
+		ID:            pe.ID,
+		SKU:           pe.SKU,
+		Name:          pe.Name,
+		Description:   pe.Description,
+		PriceMinor:    pe.PriceMinor,
+		Currency:      pe.Currency,
+		ImageURL:      pe.ImageURL,
+		CreatedDate:   pe.CreatedDate.UTC(),
+		UpdatedDate:   pe.UpdatedDate.UTC(),
+		Version:       pe.Version,
+		Locked:        pe.Locked,
+		CategoryID:    categoryID,
+		StockQuantity: pe.StockQuantity,
 	}
 }
 
+// ToProductList converts entities to domain models. The products share one
+// backing array, so a page costs two allocations rather than one per product.
 func ToProductList(entities []*ProductEntity) []*Product {
+	values := make([]Product, len(entities))
 	products := make([]*Product, len(entities))
 	for i, e := range entities {
-		products[i] = ToProduct(e)
+		values[i] = *ToProduct(e)
+		products[i] = &values[i]
 	}
 	return products
 }
@@ -0,0 +1,119 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestToProductNormalizesTimestampsToUTC(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+
+	created := time.Date(2024, 3, 1, 9, 0, 0, 0, tokyo)
+	updated := time.Date(2024, 3, 2, 18, 30, 0, 0, tokyo)
+
+	product := ToProduct(&ProductEntity{
+		ID:          "test-id",
+		Name:        "Test Product",
+		CreatedDate: created,
+		UpdatedDate: updated,
+	})
+
+	if product.CreatedDate.Location() != time.UTC {
+		t.Errorf("CreatedDate location = %v, want UTC", product.CreatedDate.Location())
+	}
+	if product.UpdatedDate.Location() != time.UTC {
+		t.Errorf("UpdatedDate location = %v, want UTC", product.UpdatedDate.Location())
+	}
+	if !product.CreatedDate.Equal(created) {
+		t.Errorf("CreatedDate = %v, want same instant as %v", product.CreatedDate, created)
+	}
+	if got := product.CreatedDate.Format(time.RFC3339); got != "2024-03-01T00:00:00Z" {
+		t.Errorf("CreatedDate formatted = %q, want %q", got, "2024-03-01T00:00:00Z")
+	}
+}
+
+func TestToProductListMatchesToProduct(t *testing.T) {
+	entities := []*ProductEntity{
+		{ID: "a", Name: "First", PriceMinor: 100, Currency: DefaultCurrency, Version: 1},
+		{ID: "b", Name: "Second", PriceMinor: 200, Currency: DefaultCurrency, Version: 3},
+	}
+
+	products := ToProductList(entities)
+	if len(products) != len(entities) {
+		t.Fatalf("ToProductList() returned %d products, want %d", len(products), len(entities))
+	}
+	for i, e := range entities {
+		if *products[i] != *ToProduct(e) {
+			t.Errorf("ToProductList()[%d] = %+v, want %+v", i, *products[i], *ToProduct(e))
+		}
+	}
+
+	// Products share a backing array but must stay independent values.
+	products[0].Name = "Changed"
+	if products[1].Name != "Second" {
+		t.Errorf("changing product 0 changed product 1 to %q", products[1].Name)
+	}
+}
+
+func TestCategoryIDRoundTrip(t *testing.T) {
+	uncategorized := New("a", "MUG-001", "Mug", "", 100, DefaultCurrency, "")
+	if entity := ToProductEntity(uncategorized); entity.CategoryID != nil {
+		t.Errorf("ToProductEntity() CategoryID = %q, want nil for an uncategorized product", *entity.CategoryID)
+	}
+
+	categorized := New("b", "MUG-002", "Mug", "", 100, DefaultCurrency, "")
+	categorized.CategoryID = "7c9e6679-7425-40de-944b-e07fc1f90ae2"
+	if got := ToProduct(ToProductEntity(categorized)).CategoryID; got != categorized.CategoryID {
+		t.Errorf("CategoryID after round trip = %q, want %q", got, categorized.CategoryID)
+	}
+}
+
+func BenchmarkToProductList(b *testing.B) {
+	entities := make([]*ProductEntity, 100)
+	for i := range entities {
+		entities[i] = &ProductEntity{ID: "id", Name: "Product", PriceMinor: int64(i), Currency: DefaultCurrency}
+	}
+
+	b.ReportAllocs()
+	for b.Loop() {
+		_ = ToProductList(entities)
+	}
+}
+
+func TestOptionalStringUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want OptionalString
+	}{
+		{name: "absent", body: `{}`, want: OptionalString{}},
+		{name: "null", body: `{"imageURL":null}`, want: NullString()},
+		{name: "empty string", body: `{"imageURL":""}`, want: SomeString("")},
+		{name: "value", body: `{"imageURL":"https://example.com/a.png"}`, want: SomeString("https://example.com/a.png")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req struct {
+				ImageURL OptionalString `json:"imageURL"`
+			}
+			if err := json.Unmarshal([]byte(tt.body), &req); err != nil {
+				t.Fatalf("Unmarshal() unexpected error = %v", err)
+			}
+			if req.ImageURL != tt.want {
+				t.Errorf("Unmarshal() = %+v, want %+v", req.ImageURL, tt.want)
+			}
+		})
+	}
+
+	var req struct {
+		ImageURL OptionalString `json:"imageURL"`
+	}
+	if err := json.Unmarshal([]byte(`{"imageURL":42}`), &req); err == nil {
+		t.Error("Unmarshal() of a number expected error, got nil")
+	}
+}
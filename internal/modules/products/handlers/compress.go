@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"strings"
+)
+
+const (
+	headerAcceptEncoding  = "Accept-Encoding"
+	headerContentEncoding = "Content-Encoding"
+	headerContentLength   = "Content-Length"
+	headerVary            = "Vary"
+	encodingGzip          = "gzip"
+)
+
+// gzipResponseWriter wraps the echo response writer for handlers that write
+// their own body. Output is buffered until it reaches minBytes: larger bodies
+// are gzip-encoded, smaller ones are written as-is on Close so tiny responses
+// do not pay the gzip overhead. Callers must call Close once the body is complete.
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	minBytes int
+	enabled  bool
+	status   int
+	buf      bytes.Buffer
+	gz       *gzip.Writer
+	decided  bool
+}
+
+// newGzipResponseWriter wraps w. Compression is only considered when minBytes
+// is positive and the client sent Accept-Encoding: gzip; otherwise writes pass
+// straight through.
+func newGzipResponseWriter(w http.ResponseWriter, r *http.Request, minBytes int) *gzipResponseWriter {
+	enabled := minBytes > 0 && acceptsGzip(r)
+	if enabled {
+		w.Header().Add(headerVary, headerAcceptEncoding)
+	}
+	return &gzipResponseWriter{
+		ResponseWriter: w,
+		minBytes:       minBytes,
+		enabled:        enabled,
+		status:         http.StatusOK,
+		decided:        !enabled,
+	}
+}
+
+// WriteHeader records the status; it is sent once the encoding is decided.
+func (w *gzipResponseWriter) WriteHeader(status int) {
+	if w.decided {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	w.status = status
+}
+
+func (w *gzipResponseWriter) Write(p []byte) (int, error) {
+	if w.gz != nil {
+		return w.gz.Write(p)
+	}
+	if w.decided {
+		return w.ResponseWriter.Write(p)
+	}
+
+	w.buf.Write(p)
+	if w.buf.Len() >= w.minBytes {
+		if err := w.startGzip(); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Flush pushes buffered output to the client. Flushing before the threshold is
+// reached commits the response to being sent uncompressed.
+func (w *gzipResponseWriter) Flush() {
+	if !w.decided {
+		_ = w.writePlain()
+	}
+	if w.gz != nil {
+		_ = w.gz.Flush()
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Close finishes the gzip stream, or writes the buffered body uncompressed when
+// it never reached the threshold.
+func (w *gzipResponseWriter) Close() error {
+	if w.gz != nil {
+		return w.gz.Close()
+	}
+	if !w.decided {
+		return w.writePlain()
+	}
+	return nil
+}
+
+func (w *gzipResponseWriter) startGzip() error {
+	w.decided = true
+	header := w.ResponseWriter.Header()
+	header.Set(headerContentEncoding, encodingGzip)
+	header.Del(headerContentLength)
+	w.ResponseWriter.WriteHeader(w.status)
+
+	w.gz = gzip.NewWriter(w.ResponseWriter)
+	_, err := w.gz.Write(w.buf.Bytes())
+	w.buf.Reset()
+	return err
+}
+
+func (w *gzipResponseWriter) writePlain() error {
+	w.decided = true
+	w.ResponseWriter.WriteHeader(w.status)
+	_, err := w.ResponseWriter.Write(w.buf.Bytes())
+	w.buf.Reset()
+	return err
+}
+
+// acceptsGzip reports whether the request advertises gzip support.
+func acceptsGzip(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get(headerAcceptEncoding), ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if !strings.EqualFold(strings.TrimSpace(coding), encodingGzip) {
+			continue
+		}
+		return strings.ReplaceAll(strings.TrimSpace(params), " ", "") != "q=0"
+	}
+	return false
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/products/domain"
+	"github.com/gaborage/go-bricks/server"
+)
+
+const (
+	headerETag            = "ETag"
+	headerIfNoneMatch     = "If-None-Match"
+	headerLastModified    = "Last-Modified"
+	headerIfModifiedSince = "If-Modified-Since"
+)
+
+// productETag returns the entity tag of resp: a hash of its JSON, so any
+// visible change to the product, including stock and lock state, changes
+// the tag. The tag is weak because the envelope around the product, such as
+// its timestamp, differs between otherwise identical responses.
+func productETag(resp *ProductResponse) string {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return `W/"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// etagMatches reports whether an If-None-Match header value names etag. The
+// comparison is weak, as RFC 9110 requires for If-None-Match, and * matches
+// any current representation.
+func etagMatches(ifNoneMatch, etag string) bool {
+	if etag == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// modifiedSince reports whether lastModified is later than an
+// If-Modified-Since date. The comparison is in whole seconds, the precision
+// of Last-Modified and of response timestamps. A date http.ParseTime cannot
+// read counts as modified, so the client gets the full response.
+func modifiedSince(ifModifiedSince string, lastModified time.Time) bool {
+	since, err := http.ParseTime(ifModifiedSince)
+	if err != nil {
+		return true
+	}
+	return lastModified.Truncate(time.Second).After(since)
+}
+
+// setLastModified sets Last-Modified to lastModified in UTC, unless it is the
+// zero time.
+func setLastModified(h http.Header, lastModified time.Time) {
+	if !lastModified.IsZero() {
+		h.Set(headerLastModified, lastModified.UTC().Format(http.TimeFormat))
+	}
+}
+
+// notModified reports whether a GET can be answered with 304 Not Modified.
+// As RFC 9110 specifies, If-Modified-Since is only evaluated when the request
+// has no If-None-Match.
+func notModified(ctx server.HandlerContext, etag string, lastModified time.Time) bool {
+	if ifNoneMatch := ctx.RequestHeader(headerIfNoneMatch); ifNoneMatch != "" {
+		return etagMatches(ifNoneMatch, etag)
+	}
+	ifModifiedSince := ctx.RequestHeader(headerIfModifiedSince)
+	return ifModifiedSince != "" && !lastModified.IsZero() && !modifiedSince(ifModifiedSince, lastModified)
+}
+
+// ProductResult wraps product for a conditional GET. The response carries
+// the product's ETag and its updated date as Last-Modified; when the
+// request's If-None-Match or If-Modified-Since shows the client already has
+// it, the result is 304 Not Modified with no body, which the framework writes
+// the same way with and without the APIResponse envelope.
+func ProductResult(ctx server.HandlerContext, product *domain.Product) server.Result[*ProductResponse] {
+	return conditionalProduct(ctx, ToProductResponse(product), product.UpdatedDate)
+}
+
+// conditionalProduct is ProductResult for a response the caller has already
+// built, for example with links.
+func conditionalProduct(ctx server.HandlerContext, resp *ProductResponse, lastModified time.Time) server.Result[*ProductResponse] {
+	result := server.Result[*ProductResponse]{
+		Data:    resp,
+		Status:  http.StatusOK,
+		Headers: http.Header{},
+	}
+	etag := productETag(resp)
+	if etag != "" {
+		result.Headers.Set(headerETag, etag)
+	}
+	setLastModified(result.Headers, lastModified)
+	if notModified(ctx, etag, lastModified) {
+		result.Data = nil
+		result.Status = http.StatusNotModified
+	}
+	return result
+}
+
+// listResult wraps a page of products for a conditional GET. Last-Modified
+// is the latest updated date on the page, so a page whose products are
+// unchanged answers If-Modified-Since with 304. Products leaving the page,
+// for example when one is deleted, do not advance it.
+func listResult(ctx server.HandlerContext, resp *ListProductsResponse) server.Result[*ListProductsResponse] {
+	result := server.Result[*ListProductsResponse]{
+		Data:    resp,
+		Status:  http.StatusOK,
+		Headers: http.Header{},
+	}
+	setLastModified(result.Headers, resp.lastModified)
+	if notModified(ctx, "", resp.lastModified) {
+		result.Data = nil
+		result.Status = http.StatusNotModified
+	}
+	return result
+}
+
+// pageLastModified returns the latest updated date of products, or the zero
+// time for an empty page.
+func pageLastModified(products []*domain.Product) time.Time {
+	var latest time.Time
+	for _, p := range products {
+		if p.UpdatedDate.After(latest) {
+			latest = p.UpdatedDate
+		}
+	}
+	return latest
+}
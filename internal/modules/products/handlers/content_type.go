@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gaborage/go-bricks/server"
+)
+
+const (
+	headerContentType = "Content-Type"
+	jsonContentType   = "application/json"
+
+	errCodeUnsupportedMediaType = "UNSUPPORTED_MEDIA_TYPE"
+)
+
+// requireContentType is route middleware for write endpoints. It rejects
+// requests whose Content-Type media type is missing or not allowlisted with
+// 415 before the typed handler tries to bind the body. Parameters such as
+// charset are ignored.
+func (h *ProductHandler) requireContentType(ctx server.HandlerContext, next func() error) error {
+	mediaType, _, err := mime.ParseMediaType(ctx.RequestHeader(headerContentType))
+	if err == nil && slices.Contains(h.contentTypes, mediaType) {
+		return next()
+	}
+
+	return server.NewBaseAPIError(
+		errCodeUnsupportedMediaType,
+		"Content-Type must be one of: "+strings.Join(h.contentTypes, ", "),
+		http.StatusUnsupportedMediaType,
+	)
+}
@@ -3,69 +3,299 @@ package handlers
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"math"
+	"net/http"
+	"strings"
+	"time"
 
 	"github.com/gaborage/go-bricks-demo-project/internal/modules/products/domain"
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/products/job"
 	"github.com/gaborage/go-bricks-demo-project/internal/modules/products/repository"
 	"github.com/gaborage/go-bricks-demo-project/internal/modules/products/service"
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/shared/admin"
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/shared/apitime"
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/shared/correlation"
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/shared/dbutil"
 	"github.com/gaborage/go-bricks/logger"
 	"github.com/gaborage/go-bricks/server"
+	"golang.org/x/sync/singleflight"
 )
 
 type CreateProductRequest struct {
-	Name        string  `json:"name" binding:"required"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price" binding:"required"`
-	ImageURL    string  `json:"imageURL"`
+	// SKU is the product's unique business identifier: letters, digits and
+	// dashes. It is stored upper-case.
+	SKU         string `json:"sku" binding:"required"`
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+	// Price is a decimal number or string, e.g. 19.99 or "19.99". It is read
+	// from its digits, never through float64, and may not carry more decimals
+	// than Currency's minor unit.
+	Price json.Number `json:"price" binding:"required"`
+	// Currency is an ISO 4217 code; it defaults to USD.
+	Currency string `json:"currency"`
+	ImageURL string `json:"imageURL"`
+	// CategoryID optionally names an existing category by its UUID.
+	CategoryID string `json:"categoryId"`
+	// StockQuantity is the initial number of units in stock; it defaults to 0.
+	StockQuantity int `json:"stockQuantity"`
+}
+
+// CreateProductsRequest is the body of POST /products/batch: a JSON array of
+// products, each shaped like CreateProductRequest.
+type CreateProductsRequest struct {
+	Items []CreateProductRequest
+}
+
+// UnmarshalJSON decodes the top-level array into Items.
+func (r *CreateProductsRequest) UnmarshalJSON(data []byte) error {
+	return json.Unmarshal(data, &r.Items)
 }
 
 type UpdateProductRequest struct {
-	ID          string   `param:"id" binding:"required"`
-	Name        *string  `json:"name"`
-	Description *string  `json:"description"`
-	Price       *float64 `json:"price"`
-	ImageURL    *string  `json:"imageURL"`
+	ID   string  `param:"id" binding:"required"`
+	Name *string `json:"name"`
+	// Price is a decimal in the product's currency, like CreateProductRequest.Price.
+	Price *json.Number `json:"price"`
+	// Description is left unchanged when absent and cleared by "" or null.
+	Description domain.OptionalString `json:"description"`
+	// ImageURL is left unchanged when absent and cleared by "" or null.
+	ImageURL domain.OptionalString `json:"imageURL"`
+	// CategoryID is left unchanged when absent and cleared by "" or null.
+	CategoryID domain.OptionalString `json:"categoryId"`
+	// Version, when set, must match the product's current version or the
+	// update is rejected with 409 Conflict.
+	Version *int `json:"version"`
 }
 
 type GetProductRequest struct {
 	ID string `param:"id"  binding:"required"`
 }
 
+// GetProductBySKURequest looks a product up by SKU, case-insensitively.
+type GetProductBySKURequest struct {
+	SKU string `param:"sku" binding:"required"`
+}
+
+// ListProductsRequest selects a page by number or, when the cursor query
+// parameter is present, by keyset cursor. Page is ignored in cursor mode.
 type ListProductsRequest struct {
-	Page     int `query:"page" binding:"required"`
-	PageSize int `query:"pageSize" binding:"required"`
+	Page     int    `query:"page"`
+	PageSize int    `query:"pageSize" binding:"required"`
+	Cursor   string `query:"cursor"`
+	// Search filters by name or description (case-insensitive substring).
+	Search string `query:"q"`
+	// SortBy is one of createdDate, name or price; SortOrder is asc or desc.
+	SortBy    string `query:"sortBy"`
+	SortOrder string `query:"sortOrder"`
+	// Currency keeps only products priced in it. Price bounds are in its
+	// units and imply USD when it is not given.
+	Currency string `query:"currency"`
+	// CategoryID keeps only products in that category.
+	CategoryID string `query:"categoryId"`
+	// MinPrice and MaxPrice are optional inclusive price bounds as decimal
+	// strings, such as "19.99".
+	MinPrice string `query:"minPrice"`
+	MaxPrice string `query:"maxPrice"`
+}
+
+// ListOptions returns the filtering and sorting part of the request.
+func (r ListProductsRequest) ListOptions() service.ListOptions {
+	return service.ListOptions{
+		Search:     r.Search,
+		SortBy:     r.SortBy,
+		SortOrder:  r.SortOrder,
+		Currency:   r.Currency,
+		CategoryID: r.CategoryID,
+		MinPrice:   r.MinPrice,
+		MaxPrice:   r.MaxPrice,
+	}
+}
+
+// Filtered reports whether the request narrows the catalog with a search,
+// currency, category or price bound. Sorting alone does not count.
+func (r ListProductsRequest) Filtered() bool {
+	return r.Search != "" || r.Currency != "" || r.CategoryID != "" || r.MinPrice != "" || r.MaxPrice != ""
 }
 
 type DeleteProductRequest struct {
 	ID string `param:"id" binding:"required"`
 }
 
+// SetProductLockRequest names the product an admin locks or unlocks.
+type SetProductLockRequest struct {
+	ID string `param:"id" binding:"required"`
+}
+
+// ChangeStockRequest reserves or releases Quantity units of a product.
+type ChangeStockRequest struct {
+	ID       string `param:"id" binding:"required"`
+	Quantity int    `json:"quantity" binding:"required"`
+}
+
+// PriceStatsRequest selects the currency whose products are summarized (USD
+// when empty) and optionally restricts the statistics to the category whose
+// UUID is given.
+type PriceStatsRequest struct {
+	Currency string `query:"currency"`
+	Category string `query:"category"`
+}
+
+// PriceStatsResponse reports prices with the decimal scale of Currency. All
+// values are zero when no product matches.
+type PriceStatsResponse struct {
+	Min      json.Number `json:"min"`
+	Max      json.Number `json:"max"`
+	Avg      json.Number `json:"avg"`
+	Count    int         `json:"count"`
+	Currency string      `json:"currency"`
+}
+
+// FindDuplicatesRequest carries no input; the report covers the whole catalog.
+type FindDuplicatesRequest struct{}
+
+// DuplicateGroupResponse lists products sharing the same normalized Field value.
+type DuplicateGroupResponse struct {
+	Field      string   `json:"field"`
+	Key        string   `json:"key"`
+	Count      int      `json:"count"`
+	ProductIDs []string `json:"productIds"`
+}
+
+type FindDuplicatesResponse struct {
+	Groups []DuplicateGroupResponse `json:"groups"`
+}
+
+// RunReportRequest carries no input; the report covers the whole catalog.
+type RunReportRequest struct{}
+
+// RunReportResponse describes the report uploaded by POST /products/report/run.
+type RunReportResponse struct {
+	Destination string `json:"destination"`
+	Rows        int    `json:"rows"`
+}
+
 type ProductResponse struct {
-	ID          string  `json:"id"`
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price"`
-	ImageURL    string  `json:"imageURL"`
-	CreatedDate string  `json:"createdDate"`
-	UpdatedDate string  `json:"updatedDate"`
+	ID          string `json:"id"`
+	SKU         string `json:"sku"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Price is rendered with the decimal scale of Currency, e.g. 19.99 for
+	// USD and 1999 for JPY.
+	Price       json.Number `json:"price"`
+	Currency    string      `json:"currency"`
+	ImageURL    string      `json:"imageURL"`
+	CreatedDate string      `json:"createdDate"`
+	UpdatedDate string      `json:"updatedDate"`
+	Version     int         `json:"version"`
+	// Locked products reject updates and deletes with 423 until an admin
+	// unlocks them.
+	Locked bool `json:"locked"`
+	// CategoryID is omitted for uncategorized products.
+	CategoryID string `json:"categoryId,omitempty"`
+	// StockQuantity is the number of units available to reserve.
+	StockQuantity int `json:"stockQuantity"`
+
+	// Stats is the product's view statistics baseline. It is only set on
+	// create responses that ask for it with ?includeStats=true.
+	Stats *ViewStatsResponse `json:"stats,omitempty"`
+
+	// Changes lists the fields an update changed. It is only set on update
+	// responses that ask for it with ?includeChanges=true, and is omitted
+	// when the update changed nothing.
+	Changes []FieldChangeResponse `json:"changes,omitempty"`
+
+	// Links are the product's hypermedia links. They are only set on reads
+	// that ask for them with ?links=true or Accept: application/hal+json.
+	Links *ProductLinks `json:"_links,omitempty"`
+}
+
+// ViewStatsResponse is the view statistics baseline of a new product. Its
+// fields match the counters of GET /analytics/views/:productId, so clients
+// can read both the same way; it is declared here so the products module does
+// not depend on the analytics module's handlers.
+type ViewStatsResponse struct {
+	ProductID     string `json:"productId"`
+	TotalViews    int64  `json:"totalViews"`
+	ViewsToday    int64  `json:"viewsToday"`
+	ViewsThisWeek int64  `json:"viewsThisWeek"`
+	UniqueViewers int64  `json:"uniqueViewers"`
+	UniqueIPs     int64  `json:"uniqueIps"`
 }
 
+// FieldChangeResponse is one field an update changed, with its value before
+// and after the update.
+type FieldChangeResponse struct {
+	Field  string `json:"field"`
+	Before any    `json:"before"`
+	After  any    `json:"after"`
+}
+
+// BatchItemErrorResponse explains why the batch item at Index was rejected.
+type BatchItemErrorResponse struct {
+	Index   int    `json:"index"`
+	Message string `json:"message"`
+}
+
+// CreateProductsResponse lists the created products in input order. Rejected
+// is only set for ?partial=true batches with invalid items.
+type CreateProductsResponse struct {
+	Products []ProductResponse        `json:"products"`
+	Rejected []BatchItemErrorResponse `json:"rejected,omitempty"`
+}
+
+// ListProductsResponse is a page of products. Cursor-mode pages skip the
+// count, so Total and Page are zero; NextCursor is empty on the last page.
+// Filtered is set when a search or price filter was applied, so a zero Total
+// means "nothing matched" rather than "the catalog is empty".
 type ListProductsResponse struct {
-	Products []ProductResponse `json:"products"`
-	Total    int               `json:"total"`
-	Page     int               `json:"page"`
-	PageSize int               `json:"pageSize"`
+	Products   []ProductResponse `json:"products"`
+	Total      int               `json:"total"`
+	Page       int               `json:"page"`
+	PageSize   int               `json:"pageSize"`
+	NextCursor string            `json:"nextCursor,omitempty"`
+	Filtered   bool              `json:"filtered,omitempty"`
+	// Links navigate between pages. They are only set when the request asks
+	// for links, like ProductResponse.Links.
+	Links *PageLinks `json:"_links,omitempty"`
+
+	// lastModified is the latest updated date on the page, sent as
+	// Last-Modified.
+	lastModified time.Time
 }
 
 func ToProductResponse(p *domain.Product) *ProductResponse {
-	return &ProductResponse{
-		ID:          p.ID,
-		Name:        p.Name,
-		Description: p.Description,
-		Price:       p.Price,
-		ImageURL:    p.ImageURL,
-		CreatedDate: p.CreatedDate.Format("2006-01-02T15:04:05Z07:00"),
-		UpdatedDate: p.UpdatedDate.Format("2006-01-02T15:04:05Z07:00"),
+	r := toProductResponse(p)
+	return &r
+}
+
+// ToProductResponseList converts a page of products into one slice of
+// responses, filling each element in place instead of allocating a
+// *ProductResponse per product and copying it.
+func ToProductResponseList(products []*domain.Product) []ProductResponse {
+	responses := make([]ProductResponse, len(products))
+	for i, p := range products {
+		responses[i] = toProductResponse(p)
+	}
+	return responses
+}
+
+func toProductResponse(p *domain.Product) ProductResponse {
+	return ProductResponse{
+		ID:            p.ID,
+		SKU:           p.SKU,
+		Name:          p.Name,
+		Description:   p.Description,
+		Price:         json.Number(p.FormattedPrice()),
+		Currency:      p.Currency,
+		ImageURL:      p.ImageURL,
+		CreatedDate:   apitime.Format(p.CreatedDate),
+		UpdatedDate:   apitime.Format(p.UpdatedDate),
+		Version:       p.Version,
+		Locked:        p.Locked,
+		CategoryID:    p.CategoryID,
+		StockQuantity: p.StockQuantity,
 	}
 }
 
@@ -73,118 +303,754 @@ func ToProductResponse(p *domain.Product) *ProductResponse {
 //
 //nolint:dupl // Interface matches test mock signatures - this is expected
 type ProductServiceInterface interface {
-	CreateProduct(ctx context.Context, name, description string, price float64, imageURL string) (*domain.Product, error)
+	CreateProduct(ctx context.Context, in service.CreateProductInput) (*domain.Product, error)
+	CreateProducts(ctx context.Context, items []service.CreateProductInput) ([]*domain.Product, error)
+	CreateProductsPartial(ctx context.Context, items []service.CreateProductInput) ([]*domain.Product, []service.BatchItemError, error)
 	GetProductByID(ctx context.Context, id string) (*domain.Product, error)
-	ListProducts(ctx context.Context, page, pageSize int) ([]*domain.Product, int, error)
-	UpdateProduct(ctx context.Context, id string, name *string, description *string, price *float64, imageURL *string) (*domain.Product, error)
+	GetProductBySKU(ctx context.Context, sku string) (*domain.Product, error)
+	ListProducts(ctx context.Context, page, pageSize int, opts service.ListOptions) ([]*domain.Product, int, error)
+	ListProductsAfter(ctx context.Context, cursor string, pageSize int) ([]*domain.Product, string, error)
+	StreamProducts(ctx context.Context, opts repository.StreamOptions, fn func(*domain.Product) error) error
+	FindDuplicates(ctx context.Context) ([]repository.DuplicateGroup, error)
+	PriceStats(ctx context.Context, currency string, category *string) (repository.PriceStats, error)
+	UpdateProduct(ctx context.Context, id string, name *string, description domain.OptionalString, price *string, imageURL domain.OptionalString, categoryID domain.OptionalString, version *int) (*domain.Product, []domain.FieldChange, error)
 	DeleteProduct(ctx context.Context, id string) error
+	LockProduct(ctx context.Context, id string) error
+	UnlockProduct(ctx context.Context, id string) error
+	ReserveStock(ctx context.Context, id string, qty int) error
+	ReleaseStock(ctx context.Context, id string, qty int) error
 }
 
+const (
+	ndjsonContentType = "application/x-ndjson"
+
+	// streamFlushInterval is the number of NDJSON lines written between flushes.
+	streamFlushInterval = 100
+
+	// headerResultLarge is set on list responses whose total exceeds the
+	// large-result threshold, telling clients to paginate or use the stream.
+	headerResultLarge = "X-Result-Large"
+	// headerResultTruncated is sent as a trailer when the stream stops at its
+	// row cap; it cannot be a header because the cap is hit mid-body.
+	headerResultTruncated = "X-Result-Truncated"
+
+	errCodeProductLocked = "PRODUCT_LOCKED"
+)
+
+// errStreamLimitReached stops the product stream once the row cap is hit.
+var errStreamLimitReached = errors.New("stream row limit reached")
+
 type ProductHandler struct {
 	service ProductServiceInterface
 	logger  logger.Logger
+
+	// compressionMinBytes enables gzip for handler-written bodies at or above
+	// this size. Zero disables it.
+	compressionMinBytes int
+
+	// strictQueryParams rejects unrecognized query parameters on list endpoints.
+	strictQueryParams bool
+
+	// contentTypes is the allowlist of request media types for create/update.
+	contentTypes []string
+
+	// guard gates admin-only options such as includeDeleted on the export.
+	guard *admin.Guard
+
+	// largeResultThreshold marks list responses with more matching rows as
+	// large. Zero disables the header.
+	largeResultThreshold int
+
+	// streamMaxRows caps GET /products/stream. Zero streams every row.
+	streamMaxRows int
+
+	// report generates the product report on demand. Nil disables the
+	// manual trigger.
+	report ReportRunner
+
+	// idempotency remembers creates by Idempotency-Key. Nil ignores the header.
+	idempotency       IdempotencyStore
+	idempotencyFlight singleflight.Group
+
+	// basePath is the route prefix used in hypermedia links, e.g. /api/v1.
+	basePath string
+}
+
+// ReportRunner generates and uploads the product report. It is satisfied by
+// *job.ReportJob, so manual runs match the scheduled ones.
+type ReportRunner interface {
+	Run(ctx context.Context) (job.ReportResult, error)
+}
+
+// HandlerOption configures optional ProductHandler behavior.
+type HandlerOption func(*ProductHandler)
+
+// WithCompression gzip-encodes handler-written bodies (stream, export) of at
+// least minBytes when the client accepts gzip. Typed JSON routes such as the
+// product list are encoded by the framework and rely on server.gzip instead.
+func WithCompression(minBytes int) HandlerOption {
+	return func(h *ProductHandler) {
+		h.compressionMinBytes = minBytes
+	}
 }
 
-func NewProductHandler(s ProductServiceInterface, l logger.Logger) *ProductHandler {
-	return &ProductHandler{
-		service: s,
-		logger:  l,
+// WithStrictQueryParams makes list endpoints reject unrecognized query
+// parameters with 400 instead of ignoring them.
+func WithStrictQueryParams(strict bool) HandlerOption {
+	return func(h *ProductHandler) {
+		h.strictQueryParams = strict
 	}
 }
 
-func (h *ProductHandler) GetProduct(req GetProductRequest, ctx server.HandlerContext) (*ProductResponse, server.IAPIError) {
-	product, err := h.service.GetProductByID(ctx.RequestContext(), req.ID)
+// WithAllowedContentTypes replaces the media types accepted by create and
+// update. Entries are matched case-insensitively without parameters.
+func WithAllowedContentTypes(types ...string) HandlerOption {
+	return func(h *ProductHandler) {
+		if len(types) == 0 {
+			return
+		}
+		h.contentTypes = make([]string, len(types))
+		for i, t := range types {
+			h.contentTypes[i] = strings.ToLower(strings.TrimSpace(t))
+		}
+	}
+}
+
+// WithAdminGuard enables admin-only request options. Without it they are
+// always rejected with 403.
+func WithAdminGuard(guard *admin.Guard) HandlerOption {
+	return func(h *ProductHandler) {
+		h.guard = guard
+	}
+}
+
+// WithReportRunner enables POST /products/report/run. Without it the
+// endpoint responds 503.
+func WithReportRunner(runner ReportRunner) HandlerOption {
+	return func(h *ProductHandler) {
+		h.report = runner
+	}
+}
+
+// WithLargeResultThreshold sets X-Result-Large: true on list responses whose
+// total row count exceeds threshold.
+func WithLargeResultThreshold(threshold int) HandlerOption {
+	return func(h *ProductHandler) {
+		h.largeResultThreshold = threshold
+	}
+}
+
+// WithStreamMaxRows stops the NDJSON stream after maxRows lines and reports
+// the cut with an X-Result-Truncated: true trailer.
+func WithStreamMaxRows(maxRows int) HandlerOption {
+	return func(h *ProductHandler) {
+		h.streamMaxRows = maxRows
+	}
+}
+
+func NewProductHandler(s ProductServiceInterface, l logger.Logger, opts ...HandlerOption) *ProductHandler {
+	h := &ProductHandler{
+		service:      s,
+		logger:       l,
+		contentTypes: []string{jsonContentType},
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// log returns the handler logger tagged with the request's correlation ID.
+func (h *ProductHandler) log(ctx server.HandlerContext) logger.Logger {
+	return correlation.Logger(correlation.FromRequest(ctx), h.logger)
+}
+
+// GetProduct serves GET /products/:id. The response carries an ETag and a
+// Last-Modified date, and a request whose If-None-Match or If-Modified-Since
+// shows the product is unchanged gets 304 Not Modified with no body.
+func (h *ProductHandler) GetProduct(req GetProductRequest, ctx server.HandlerContext) (server.Result[*ProductResponse], server.IAPIError) {
+	links, badRequest := linksRequested(ctx)
+	if badRequest != nil {
+		return server.Result[*ProductResponse]{}, badRequest
+	}
+
+	product, err := h.service.GetProductByID(correlation.FromRequest(ctx), req.ID)
+	if err != nil {
+		if errors.Is(err, repository.ErrProductNotFound) {
+			return server.Result[*ProductResponse]{}, server.NewNotFoundError("Product")
+		}
+		h.log(ctx).Error().Err(err).Str("productID", req.ID).Msg("Failed to get product")
+		return server.Result[*ProductResponse]{}, dbutil.APIError(ctx, err, "Failed to retrieve product")
+	}
+
+	resp := ToProductResponse(product)
+	if links {
+		resp.Links = h.productLinks(ctx, product.ID)
+	}
+	return conditionalProduct(ctx, resp, product.UpdatedDate), nil
+}
+
+// GetProductBySKU serves GET /products/sku/:sku.
+func (h *ProductHandler) GetProductBySKU(req GetProductBySKURequest, ctx server.HandlerContext) (*ProductResponse, server.IAPIError) {
+	links, badRequest := linksRequested(ctx)
+	if badRequest != nil {
+		return nil, badRequest
+	}
+
+	product, err := h.service.GetProductBySKU(correlation.FromRequest(ctx), req.SKU)
 	if err != nil {
 		if errors.Is(err, repository.ErrProductNotFound) {
 			return nil, server.NewNotFoundError("Product")
 		}
-		h.logger.Error().Err(err).Str("productID", req.ID).Msg("Failed to get product")
-		return nil, server.NewInternalServerError("Failed to retrieve product")
+		if errors.Is(err, service.ErrValidation) {
+			return nil, server.NewBadRequestError(err.Error())
+		}
+		h.log(ctx).Error().Err(err).Str("sku", req.SKU).Msg("Failed to get product by SKU")
+		return nil, dbutil.APIError(ctx, err, "Failed to retrieve product")
 	}
 
-	return ToProductResponse(product), nil
+	resp := ToProductResponse(product)
+	if links {
+		resp.Links = h.productLinks(ctx, product.ID)
+	}
+	return resp, nil
 }
 
-func (h *ProductHandler) ListProducts(req ListProductsRequest, ctx server.HandlerContext) (*ListProductsResponse, server.IAPIError) {
-	products, total, err := h.service.ListProducts(ctx.RequestContext(), req.Page, req.PageSize)
+// ListProducts serves GET /products. The response's Last-Modified is the
+// latest updated date on the page, and a request whose If-Modified-Since is
+// not older gets 304 Not Modified with no body.
+func (h *ProductHandler) ListProducts(req ListProductsRequest, ctx server.HandlerContext) (server.Result[*ListProductsResponse], server.IAPIError) {
+	links, badRequest := linksRequested(ctx)
+	if badRequest != nil {
+		return server.Result[*ListProductsResponse]{}, badRequest
+	}
+
+	resp, apiErr := h.listProducts(req, ctx)
+	if apiErr != nil {
+		return server.Result[*ListProductsResponse]{}, apiErr
+	}
+	if links {
+		h.addLinks(ctx, resp)
+	}
+	return listResult(ctx, resp), nil
+}
+
+// listProducts serves GET /products in page mode, or cursor mode when the
+// request has a cursor.
+func (h *ProductHandler) listProducts(req ListProductsRequest, ctx server.HandlerContext) (*ListProductsResponse, server.IAPIError) {
+	if apiErr := h.checkQueryParams(ctx.Request(), listProductsQueryParams); apiErr != nil {
+		return nil, apiErr
+	}
+	if ctx.Query(queryIncludeDeleted) != "" {
+		return nil, server.NewBadRequestError(queryIncludeDeleted + " is only supported by the product export (GET /products/stream)")
+	}
+	if ctx.Request().URL.Query().Has(queryCursor) {
+		return h.listProductsAfter(req, ctx)
+	}
+
+	products, total, err := h.service.ListProducts(correlation.FromRequest(ctx), req.Page, req.PageSize, req.ListOptions())
 	if err != nil {
-		h.logger.Error().Err(err).Int("page", req.Page).Int("pageSize", req.PageSize).Msg("Failed to list products")
-		if errors.Is(err, service.ErrInternal) {
-			return nil, server.NewInternalServerError("Failed to retrieve products")
+		if errors.Is(err, service.ErrValidation) {
+			return nil, server.NewBadRequestError(err.Error())
 		}
-		// Validation errors (page/pageSize) return as bad request
-		return nil, server.NewBadRequestError(err.Error())
+		h.log(ctx).Error().Err(err).Int("page", req.Page).Int("pageSize", req.PageSize).Msg("Failed to list products")
+		return nil, dbutil.APIError(ctx, err, "Failed to retrieve products")
 	}
 
-	// Convert products to response format
-	productResponses := make([]ProductResponse, len(products))
-	for i, p := range products {
-		productResponses[i] = *ToProductResponse(p)
+	if h.largeResultThreshold > 0 && total > h.largeResultThreshold {
+		ctx.ResponseWriter().Header().Set(headerResultLarge, "true")
 	}
 
+	productResponses := ToProductResponseList(products)
+
 	return &ListProductsResponse{
 		Products: productResponses,
 		Total:    total,
 		Page:     req.Page,
 		PageSize: req.PageSize,
+		Filtered: req.Filtered(),
+
+		lastModified: pageLastModified(products),
 	}, nil
 }
 
+// listProductsAfter serves GET /products in cursor mode.
+func (h *ProductHandler) listProductsAfter(req ListProductsRequest, ctx server.HandlerContext) (*ListProductsResponse, server.IAPIError) {
+	if req.ListOptions() != (service.ListOptions{}) {
+		return nil, server.NewBadRequestError("q, sortBy, sortOrder, currency, categoryId, minPrice and maxPrice are not supported with cursor pagination")
+	}
+
+	products, nextCursor, err := h.service.ListProductsAfter(correlation.FromRequest(ctx), req.Cursor, req.PageSize)
+	if err != nil {
+		if errors.Is(err, service.ErrValidation) {
+			return nil, server.NewBadRequestError(err.Error())
+		}
+		h.log(ctx).Error().Err(err).Int("pageSize", req.PageSize).Msg("Failed to list products by cursor")
+		return nil, dbutil.APIError(ctx, err, "Failed to retrieve products")
+	}
+
+	productResponses := ToProductResponseList(products)
+
+	return &ListProductsResponse{
+		Products:   productResponses,
+		PageSize:   req.PageSize,
+		NextCursor: nextCursor,
+
+		lastModified: pageLastModified(products),
+	}, nil
+}
+
+// StreamProducts writes every product as newline-delimited JSON, one object per
+// line, as rows are read from the database. It is a plain server.Handler rather
+// than a typed handler because the body is written incrementally; like
+// WithRawResponse routes it bypasses the APIResponse envelope.
+func (h *ProductHandler) StreamProducts(ctx server.HandlerContext) error {
+	if apiErr := h.checkQueryParams(ctx.Request(), streamQueryParams); apiErr != nil {
+		return apiErr
+	}
+	opts, err := h.exportOptions(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := newGzipResponseWriter(ctx.ResponseWriter(), ctx.Request(), h.compressionMinBytes)
+	encoder := json.NewEncoder(w)
+
+	written := 0
+	err = h.service.StreamProducts(correlation.FromRequest(ctx), opts, func(p *domain.Product) error {
+		if h.streamMaxRows > 0 && written >= h.streamMaxRows {
+			return errStreamLimitReached
+		}
+		if written == 0 {
+			w.Header().Set("Content-Type", ndjsonContentType)
+			if h.streamMaxRows > 0 {
+				w.Header().Set("Trailer", headerResultTruncated)
+			}
+			w.WriteHeader(http.StatusOK)
+		}
+		if err := encoder.Encode(ToProductResponse(p)); err != nil {
+			return err
+		}
+		written++
+		if written%streamFlushInterval == 0 {
+			w.Flush()
+		}
+		return nil
+	})
+	if errors.Is(err, errStreamLimitReached) {
+		h.log(ctx).Warn().Int("maxRows", h.streamMaxRows).Msg("Product stream truncated at row cap")
+		w.Header().Set(headerResultTruncated, "true")
+		return w.Close()
+	}
+	if err != nil {
+		h.log(ctx).Error().Err(err).Int("written", written).Msg("Failed to stream products")
+		if written == 0 {
+			return dbutil.HandlerError(ctx, err, "Failed to stream products")
+		}
+		// Headers are already sent; the truncated body is all the client gets.
+		return w.Close()
+	}
+
+	if written == 0 {
+		w.Header().Set("Content-Type", ndjsonContentType)
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.Close()
+}
+
+// CreateProduct serves POST /products. With an idempotency store configured,
+// a request carrying an Idempotency-Key header that was already used replays
+// the original 201 response, or gets 409 if its body differs.
 func (h *ProductHandler) CreateProduct(req CreateProductRequest, ctx server.HandlerContext) (server.Result[*ProductResponse], server.IAPIError) {
-	product, err := h.service.CreateProduct(
-		ctx.RequestContext(),
-		req.Name,
-		req.Description,
-		req.Price,
-		req.ImageURL,
-	)
+	includeStats, badRequest := queryBool(ctx, queryIncludeStats)
+	if badRequest != nil {
+		return server.Result[*ProductResponse]{}, badRequest
+	}
+
+	create := func() (*ProductResponse, server.IAPIError) {
+		return h.createProduct(ctx, req, includeStats)
+	}
+	var response *ProductResponse
+	var apiErr server.IAPIError
+	if key := ctx.RequestHeader(headerIdempotencyKey); key != "" && h.idempotency != nil {
+		response, apiErr = h.createIdempotent(ctx, key, req, create)
+	} else {
+		response, apiErr = create()
+	}
+	if apiErr != nil {
+		return server.Result[*ProductResponse]{}, apiErr
+	}
+	return server.Created(response), nil
+}
+
+// createProduct creates the product described by req and builds its response.
+func (h *ProductHandler) createProduct(ctx server.HandlerContext, req CreateProductRequest, includeStats bool) (*ProductResponse, server.IAPIError) {
+	product, err := h.service.CreateProduct(correlation.FromRequest(ctx), service.CreateProductInput{
+		SKU:           req.SKU,
+		Name:          req.Name,
+		Description:   req.Description,
+		Price:         req.Price.String(),
+		Currency:      req.Currency,
+		ImageURL:      req.ImageURL,
+		CategoryID:    req.CategoryID,
+		StockQuantity: req.StockQuantity,
+	})
 	if err != nil {
-		h.logger.Error().Err(err).Str("name", req.Name).Msg("Failed to create product")
-		return server.Result[*ProductResponse]{}, server.NewBadRequestError(err.Error())
+		if errors.Is(err, service.ErrValidation) {
+			return nil, server.NewBadRequestError(err.Error())
+		}
+		if errors.Is(err, repository.ErrDuplicateSKU) {
+			return nil, newDuplicateSKUError()
+		}
+		h.log(ctx).Error().Err(err).Str("name", req.Name).Msg("Failed to create product")
+		return nil, dbutil.APIError(ctx, err, "Failed to create product")
 	}
 
 	response := ToProductResponse(product)
-	return server.Created(response), nil
+	if includeStats {
+		// A new product has no views yet; return the zeroed stats so clients
+		// can render the detail page without a missing-stats case.
+		response.Stats = &ViewStatsResponse{ProductID: product.ID}
+	}
+	return response, nil
 }
 
+// CreateProducts serves POST /products/batch. The batch is all-or-nothing
+// unless ?partial=true, which inserts the valid items and lists the rejected ones.
+func (h *ProductHandler) CreateProducts(req CreateProductsRequest, ctx server.HandlerContext) (server.Result[*CreateProductsResponse], server.IAPIError) {
+	partial, apiErr := queryBool(ctx, queryPartial)
+	if apiErr != nil {
+		return server.Result[*CreateProductsResponse]{}, apiErr
+	}
+
+	items := make([]service.CreateProductInput, len(req.Items))
+	for i, item := range req.Items {
+		items[i] = service.CreateProductInput{
+			SKU:           item.SKU,
+			Name:          item.Name,
+			Description:   item.Description,
+			Price:         item.Price.String(),
+			Currency:      item.Currency,
+			ImageURL:      item.ImageURL,
+			CategoryID:    item.CategoryID,
+			StockQuantity: item.StockQuantity,
+		}
+	}
+
+	var products []*domain.Product
+	var rejected []service.BatchItemError
+	var err error
+	if partial {
+		products, rejected, err = h.service.CreateProductsPartial(correlation.FromRequest(ctx), items)
+	} else {
+		products, err = h.service.CreateProducts(correlation.FromRequest(ctx), items)
+	}
+	if err != nil {
+		var batchErr *service.BatchError
+		if errors.As(err, &batchErr) {
+			return server.Result[*CreateProductsResponse]{}, server.NewBadRequestError(err.Error()).
+				WithDetails("items", toBatchItemErrorResponses(batchErr.Items))
+		}
+		if errors.Is(err, service.ErrValidation) {
+			return server.Result[*CreateProductsResponse]{}, server.NewBadRequestError(err.Error())
+		}
+		if errors.Is(err, repository.ErrDuplicateSKU) {
+			return server.Result[*CreateProductsResponse]{}, newDuplicateSKUError()
+		}
+		h.log(ctx).Error().Err(err).Int("count", len(items)).Msg("Failed to create products")
+		return server.Result[*CreateProductsResponse]{}, dbutil.APIError(ctx, err, "Failed to create products")
+	}
+
+	productResponses := ToProductResponseList(products)
+	return server.Created(&CreateProductsResponse{
+		Products: productResponses,
+		Rejected: toBatchItemErrorResponses(rejected),
+	}), nil
+}
+
+// toBatchItemErrorResponses converts rejected batch items, returning nil for none.
+func toBatchItemErrorResponses(items []service.BatchItemError) []BatchItemErrorResponse {
+	if len(items) == 0 {
+		return nil
+	}
+	responses := make([]BatchItemErrorResponse, len(items))
+	for i, item := range items {
+		responses[i] = BatchItemErrorResponse{Index: item.Index, Message: item.Error()}
+	}
+	return responses
+}
+
+// UpdateProduct serves PUT and PATCH /products/:id; both are partial updates
+// where an absent field is left unchanged. With ?includeChanges=true the
+// response also lists the fields the update changed.
 func (h *ProductHandler) UpdateProduct(req UpdateProductRequest, ctx server.HandlerContext) (*ProductResponse, server.IAPIError) {
-	product, err := h.service.UpdateProduct(
-		ctx.RequestContext(),
+	includeChanges, badRequest := queryBool(ctx, queryIncludeChanges)
+	if badRequest != nil {
+		return nil, badRequest
+	}
+
+	var price *string
+	if req.Price != nil {
+		s := req.Price.String()
+		price = &s
+	}
+
+	product, changes, err := h.service.UpdateProduct(
+		correlation.FromRequest(ctx),
 		req.ID,
 		req.Name,
 		req.Description,
-		req.Price,
+		price,
 		req.ImageURL,
+		req.CategoryID,
+		req.Version,
 	)
 	if err != nil {
 		if errors.Is(err, repository.ErrProductNotFound) {
 			return nil, server.NewNotFoundError("Product")
 		}
-		h.logger.Error().Err(err).Str("productID", req.ID).Msg("Failed to update product")
-		return nil, server.NewBadRequestError(err.Error())
+		if errors.Is(err, repository.ErrConcurrentModification) {
+			return nil, server.NewConflictError("Product was modified by another request; fetch it again and retry")
+		}
+		if errors.Is(err, service.ErrLocked) {
+			return nil, newLockedError()
+		}
+		if errors.Is(err, service.ErrValidation) {
+			return nil, server.NewBadRequestError(err.Error())
+		}
+		h.log(ctx).Error().Err(err).Str("productID", req.ID).Msg("Failed to update product")
+		return nil, dbutil.APIError(ctx, err, "Failed to update product")
+	}
+
+	response := ToProductResponse(product)
+	if includeChanges {
+		response.Changes = toFieldChangeResponses(changes, response.Currency)
+	}
+	return response, nil
+}
+
+// toFieldChangeResponses converts changes for an update response. Prices are
+// rendered with the currency's decimal scale, like ProductResponse.Price.
+func toFieldChangeResponses(changes []domain.FieldChange, currency string) []FieldChangeResponse {
+	responses := make([]FieldChangeResponse, len(changes))
+	for i, c := range changes {
+		responses[i] = FieldChangeResponse{Field: c.Field, Before: c.Before, After: c.After}
+		if c.Field == "price" {
+			responses[i].Before = formatPriceValue(c.Before, currency)
+			responses[i].After = formatPriceValue(c.After, currency)
+		}
 	}
+	return responses
+}
 
-	return ToProductResponse(product), nil
+// formatPriceValue formats a price in minor units for JSON; other values pass through.
+func formatPriceValue(v any, currency string) any {
+	if minor, ok := v.(int64); ok {
+		return json.Number(domain.FormatMinorUnits(minor, currency))
+	}
+	return v
 }
 
 func (h *ProductHandler) DeleteProduct(req DeleteProductRequest, ctx server.HandlerContext) (server.NoContentResult, server.IAPIError) {
-	err := h.service.DeleteProduct(ctx.RequestContext(), req.ID)
+	err := h.service.DeleteProduct(correlation.FromRequest(ctx), req.ID)
 	if err != nil {
 		if errors.Is(err, repository.ErrProductNotFound) {
 			return server.NoContentResult{}, server.NewNotFoundError("Product")
 		}
-		h.logger.Error().Err(err).Str("productID", req.ID).Msg("Failed to delete product")
-		return server.NoContentResult{}, server.NewInternalServerError("Failed to delete product")
+		if errors.Is(err, service.ErrLocked) {
+			return server.NoContentResult{}, newLockedError()
+		}
+		h.log(ctx).Error().Err(err).Str("productID", req.ID).Msg("Failed to delete product")
+		return server.NoContentResult{}, dbutil.APIError(ctx, err, "Failed to delete product")
 	}
 
 	return server.NoContent(), nil
 }
 
+// newLockedError is the 423 returned for writes to a locked product.
+func newLockedError() server.IAPIError {
+	return server.NewBaseAPIError(errCodeProductLocked, "Product is locked; an admin must unlock it first", http.StatusLocked)
+}
+
+// newDuplicateSKUError is the 409 returned when a created product's SKU is taken.
+func newDuplicateSKUError() server.IAPIError {
+	return server.NewConflictError("A product with this SKU already exists")
+}
+
+// LockProduct locks a product against updates and deletes. It is an admin
+// endpoint.
+func (h *ProductHandler) LockProduct(req SetProductLockRequest, ctx server.HandlerContext) (server.NoContentResult, server.IAPIError) {
+	return h.setProductLock(req, ctx, true)
+}
+
+// UnlockProduct clears a product's lock. It is an admin endpoint and the only
+// way to make a locked product writable again.
+func (h *ProductHandler) UnlockProduct(req SetProductLockRequest, ctx server.HandlerContext) (server.NoContentResult, server.IAPIError) {
+	return h.setProductLock(req, ctx, false)
+}
+
+func (h *ProductHandler) setProductLock(req SetProductLockRequest, ctx server.HandlerContext, locked bool) (server.NoContentResult, server.IAPIError) {
+	if apiErr := h.guard.Authorize(ctx); apiErr != nil {
+		return server.NoContentResult{}, apiErr
+	}
+
+	setLocked := h.service.UnlockProduct
+	if locked {
+		setLocked = h.service.LockProduct
+	}
+	if err := setLocked(correlation.FromRequest(ctx), req.ID); err != nil {
+		if errors.Is(err, repository.ErrProductNotFound) {
+			return server.NoContentResult{}, server.NewNotFoundError("Product")
+		}
+		h.log(ctx).Error().Err(err).Str("productID", req.ID).Bool("locked", locked).Msg("Failed to set product lock")
+		return server.NoContentResult{}, dbutil.APIError(ctx, err, "Failed to set product lock")
+	}
+
+	return server.NoContent(), nil
+}
+
+// ReserveStock serves POST /products/:id/reserve. A reservation of more units
+// than are in stock is rejected with 409 and changes nothing.
+func (h *ProductHandler) ReserveStock(req ChangeStockRequest, ctx server.HandlerContext) (server.NoContentResult, server.IAPIError) {
+	return h.changeStock(req, ctx, true)
+}
+
+// ReleaseStock serves POST /products/:id/release, returning reserved units
+// to stock.
+func (h *ProductHandler) ReleaseStock(req ChangeStockRequest, ctx server.HandlerContext) (server.NoContentResult, server.IAPIError) {
+	return h.changeStock(req, ctx, false)
+}
+
+func (h *ProductHandler) changeStock(req ChangeStockRequest, ctx server.HandlerContext, reserve bool) (server.NoContentResult, server.IAPIError) {
+	change := h.service.ReleaseStock
+	if reserve {
+		change = h.service.ReserveStock
+	}
+	if err := change(correlation.FromRequest(ctx), req.ID, req.Quantity); err != nil {
+		if errors.Is(err, repository.ErrProductNotFound) {
+			return server.NoContentResult{}, server.NewNotFoundError("Product")
+		}
+		if errors.Is(err, service.ErrInsufficientStock) {
+			return server.NoContentResult{}, server.NewConflictError("Not enough units of the product in stock")
+		}
+		if errors.Is(err, service.ErrValidation) {
+			return server.NoContentResult{}, server.NewBadRequestError(err.Error())
+		}
+		h.log(ctx).Error().Err(err).Str("productID", req.ID).Bool("reserve", reserve).Msg("Failed to change product stock")
+		return server.NoContentResult{}, dbutil.APIError(ctx, err, "Failed to change product stock")
+	}
+
+	return server.NoContent(), nil
+}
+
+// PriceStats serves GET /products/price-stats.
+func (h *ProductHandler) PriceStats(req PriceStatsRequest, ctx server.HandlerContext) (*PriceStatsResponse, server.IAPIError) {
+	var category *string
+	if ctx.Request().URL.Query().Has("category") {
+		category = &req.Category
+	}
+
+	stats, err := h.service.PriceStats(correlation.FromRequest(ctx), req.Currency, category)
+	if err != nil {
+		if errors.Is(err, service.ErrValidation) {
+			return nil, server.NewBadRequestError(err.Error())
+		}
+		h.log(ctx).Error().Err(err).Msg("Failed to compute price stats")
+		return nil, dbutil.APIError(ctx, err, "Failed to compute price statistics")
+	}
+
+	avg := stats.Avg / math.Pow10(domain.CurrencyScale(stats.Currency))
+	return &PriceStatsResponse{
+		Min:      json.Number(domain.FormatMinorUnits(stats.Min, stats.Currency)),
+		Max:      json.Number(domain.FormatMinorUnits(stats.Max, stats.Currency)),
+		Avg:      json.Number(domain.FormatPrice(avg, stats.Currency)),
+		Count:    stats.Count,
+		Currency: stats.Currency,
+	}, nil
+}
+
+// FindDuplicates reports likely duplicate products. It is an admin endpoint.
+func (h *ProductHandler) FindDuplicates(_ FindDuplicatesRequest, ctx server.HandlerContext) (*FindDuplicatesResponse, server.IAPIError) {
+	if apiErr := h.guard.Authorize(ctx); apiErr != nil {
+		return nil, apiErr
+	}
+
+	groups, err := h.service.FindDuplicates(correlation.FromRequest(ctx))
+	if err != nil {
+		h.log(ctx).Error().Err(err).Msg("Failed to find duplicate products")
+		return nil, dbutil.APIError(ctx, err, "Failed to find duplicate products")
+	}
+
+	response := &FindDuplicatesResponse{Groups: make([]DuplicateGroupResponse, len(groups))}
+	for i, g := range groups {
+		response.Groups[i] = DuplicateGroupResponse{
+			Field:      g.Field,
+			Key:        g.Key,
+			Count:      len(g.ProductIDs),
+			ProductIDs: g.ProductIDs,
+		}
+	}
+
+	return response, nil
+}
+
+// RunReport generates and uploads the product report synchronously, as the
+// scheduled report job does. It is an admin endpoint; a request made while
+// another run, manual or scheduled, is in progress gets 409.
+func (h *ProductHandler) RunReport(_ RunReportRequest, ctx server.HandlerContext) (*RunReportResponse, server.IAPIError) {
+	if apiErr := h.guard.Authorize(ctx); apiErr != nil {
+		return nil, apiErr
+	}
+	if h.report == nil {
+		return nil, server.NewServiceUnavailableError("The product report is disabled")
+	}
+
+	result, err := h.report.Run(correlation.FromRequest(ctx))
+	if err != nil {
+		if errors.Is(err, job.ErrReportRunning) {
+			return nil, server.NewConflictError("A product report run is already in progress")
+		}
+		h.log(ctx).Error().Err(err).Msg("Failed to run product report")
+		return nil, dbutil.APIError(ctx, err, "Failed to run product report")
+	}
+
+	h.log(ctx).Info().
+		Int("rows", result.Rows).
+		Str("destination", result.Destination).
+		Msg("Product report uploaded on demand")
+	return &RunReportResponse{Destination: result.Destination, Rows: result.Rows}, nil
+}
+
 // RegisterProductRoutes registers product-related HTTP routes
 func (h *ProductHandler) RegisterProductRoutes(hr *server.HandlerRegistry, r server.RouteRegistrar) {
-	server.GET(hr, r, "/products/:id", h.GetProduct)
+	r.Add(http.MethodGet, "/products/stream", h.StreamProducts)
+	server.GET(hr, r, "/products/price-stats", h.PriceStats)
 	server.GET(hr, r, "/products", h.ListProducts)
-	server.POST(hr, r, "/products", h.CreateProduct)
-	server.PUT(hr, r, "/products/:id", h.UpdateProduct)
+	server.GET(hr, r, "/products/sku/:sku", h.GetProductBySKU)
 	server.DELETE(hr, r, "/products/:id", h.DeleteProduct)
+	server.GET(hr, r, "/admin/products/duplicates", h.FindDuplicates,
+		server.WithTags("admin"),
+	)
+	server.POST(hr, r, "/admin/products/:id/lock", h.LockProduct,
+		server.WithTags("admin"),
+	)
+	server.POST(hr, r, "/admin/products/:id/unlock", h.UnlockProduct,
+		server.WithTags("admin"),
+	)
+	server.POST(hr, r, "/products/report/run", h.RunReport,
+		server.WithTags("admin"),
+	)
+
+	// X-Raw-Response: true returns the product without the APIResponse envelope.
+	reads := r.Group("", rawResponse(h.getProductRaw))
+	server.GET(hr, reads, "/products/:id", h.GetProduct)
+
+	// Write endpoints reject non-JSON bodies with 415 before binding.
+	writes := r.Group("", h.requireContentType)
+	server.POST(hr, writes, "/products", h.CreateProduct)
+	server.POST(hr, writes, "/products/batch", h.CreateProducts)
+	server.PUT(hr, writes, "/products/:id", h.UpdateProduct)
+	server.PATCH(hr, writes, "/products/:id", h.UpdateProduct)
+	server.POST(hr, writes, "/products/:id/reserve", h.ReserveStock)
+	server.POST(hr, writes, "/products/:id/release", h.ReleaseStock)
 }
@@ -1,23 +1,33 @@
 package handlers
 
 import (
+	"compress/gzip"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
-	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gaborage/go-bricks-demo-project/internal/modules/products/domain"
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/products/job"
 	"github.com/gaborage/go-bricks-demo-project/internal/modules/products/repository"
 	"github.com/gaborage/go-bricks-demo-project/internal/modules/products/service"
-	"github.com/gaborage/go-bricks/config"
-	"github.com/gaborage/go-bricks/logger"
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/shared/admin"
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/shared/dbutil"
+	"github.com/gaborage/go-bricks-demo-project/internal/testutil"
 	"github.com/gaborage/go-bricks/server"
 )
 
 const (
 	testID              = "test-id"
+	testSKU             = "MUG-001"
+	testCategoryID      = "7c9e6679-7425-40de-944b-e07fc1f90ae1"
 	missingID           = "missing-id"
 	productNotFoundName = "product not found"
 	internalErrorName   = "internal error"
@@ -25,24 +35,55 @@ const (
 	errCodeNotFound     = "NOT_FOUND"
 	errCodeInternal     = "INTERNAL_ERROR"
 	errCodeBadRequest   = "BAD_REQUEST"
+	errCodeConflict     = "CONFLICT"
+	lockedProductName   = "locked product"
 )
 
 // mockService implements service methods for testing
 type mockService struct {
-	createProductFunc  func(ctx context.Context, name, description string, price float64, imageURL string) (*domain.Product, error)
-	getProductByIDFunc func(ctx context.Context, id string) (*domain.Product, error)
-	listProductsFunc   func(ctx context.Context, page, pageSize int) ([]*domain.Product, int, error)
-	updateProductFunc  func(ctx context.Context, id string, name *string, description *string, price *float64, imageURL *string) (*domain.Product, error)
-	deleteProductFunc  func(ctx context.Context, id string) error
+	createProductFunc     func(ctx context.Context, in service.CreateProductInput) (*domain.Product, error)
+	createBatchFunc       func(ctx context.Context, items []service.CreateProductInput) ([]*domain.Product, error)
+	createPartialFunc     func(ctx context.Context, items []service.CreateProductInput) ([]*domain.Product, []service.BatchItemError, error)
+	getProductByIDFunc    func(ctx context.Context, id string) (*domain.Product, error)
+	getProductBySKUFunc   func(ctx context.Context, sku string) (*domain.Product, error)
+	listProductsFunc      func(ctx context.Context, page, pageSize int) ([]*domain.Product, int, error)
+	listProductsAfterFunc func(ctx context.Context, cursor string, pageSize int) ([]*domain.Product, string, error)
+	streamProductsFunc    func(ctx context.Context, fn func(*domain.Product) error) error
+	findDuplicatesFunc    func(ctx context.Context) ([]repository.DuplicateGroup, error)
+	updateProductFunc     func(ctx context.Context, id string, name *string, description domain.OptionalString, price *string, imageURL domain.OptionalString, categoryID domain.OptionalString, version *int) (*domain.Product, error)
+	// updateChanges is returned by UpdateProduct alongside updateProductFunc's product.
+	updateChanges     []domain.FieldChange
+	deleteProductFunc func(ctx context.Context, id string) error
+	setLockedFunc     func(ctx context.Context, id string, locked bool) error
+	changeStockFunc   func(ctx context.Context, id string, qty int, reserve bool) error
+
+	// streamOpts records the options of the last StreamProducts call.
+	streamOpts repository.StreamOptions
+	// listOpts records the options of the last ListProducts call.
+	listOpts service.ListOptions
 }
 
-func (m *mockService) CreateProduct(ctx context.Context, name, description string, price float64, imageURL string) (*domain.Product, error) {
+func (m *mockService) CreateProduct(ctx context.Context, in service.CreateProductInput) (*domain.Product, error) {
 	if m.createProductFunc != nil {
-		return m.createProductFunc(ctx, name, description, price, imageURL)
+		return m.createProductFunc(ctx, in)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockService) CreateProducts(ctx context.Context, items []service.CreateProductInput) ([]*domain.Product, error) {
+	if m.createBatchFunc != nil {
+		return m.createBatchFunc(ctx, items)
 	}
 	return nil, errors.New("not implemented")
 }
 
+func (m *mockService) CreateProductsPartial(ctx context.Context, items []service.CreateProductInput) ([]*domain.Product, []service.BatchItemError, error) {
+	if m.createPartialFunc != nil {
+		return m.createPartialFunc(ctx, items)
+	}
+	return nil, nil, errors.New("not implemented")
+}
+
 func (m *mockService) GetProductByID(ctx context.Context, id string) (*domain.Product, error) {
 	if m.getProductByIDFunc != nil {
 		return m.getProductByIDFunc(ctx, id)
@@ -50,20 +91,55 @@ func (m *mockService) GetProductByID(ctx context.Context, id string) (*domain.Pr
 	return nil, errors.New("not implemented")
 }
 
-func (m *mockService) ListProducts(ctx context.Context, page, pageSize int) ([]*domain.Product, int, error) {
+func (m *mockService) GetProductBySKU(ctx context.Context, sku string) (*domain.Product, error) {
+	if m.getProductBySKUFunc != nil {
+		return m.getProductBySKUFunc(ctx, sku)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockService) ListProducts(ctx context.Context, page, pageSize int, opts service.ListOptions) ([]*domain.Product, int, error) {
+	m.listOpts = opts
 	if m.listProductsFunc != nil {
 		return m.listProductsFunc(ctx, page, pageSize)
 	}
 	return nil, 0, errors.New("not implemented")
 }
 
-func (m *mockService) UpdateProduct(ctx context.Context, id string, name *string, description *string, price *float64, imageURL *string) (*domain.Product, error) {
-	if m.updateProductFunc != nil {
-		return m.updateProductFunc(ctx, id, name, description, price, imageURL)
+func (m *mockService) ListProductsAfter(ctx context.Context, cursor string, pageSize int) ([]*domain.Product, string, error) {
+	if m.listProductsAfterFunc != nil {
+		return m.listProductsAfterFunc(ctx, cursor, pageSize)
+	}
+	return nil, "", errors.New("not implemented")
+}
+
+func (m *mockService) StreamProducts(ctx context.Context, opts repository.StreamOptions, fn func(*domain.Product) error) error {
+	m.streamOpts = opts
+	if m.streamProductsFunc != nil {
+		return m.streamProductsFunc(ctx, fn)
+	}
+	return errors.New("not implemented")
+}
+
+func (m *mockService) FindDuplicates(ctx context.Context) ([]repository.DuplicateGroup, error) {
+	if m.findDuplicatesFunc != nil {
+		return m.findDuplicatesFunc(ctx)
 	}
 	return nil, errors.New("not implemented")
 }
 
+func (m *mockService) PriceStats(context.Context, string, *string) (repository.PriceStats, error) {
+	return repository.PriceStats{}, errors.New("not implemented")
+}
+
+func (m *mockService) UpdateProduct(ctx context.Context, id string, name *string, description domain.OptionalString, price *string, imageURL domain.OptionalString, categoryID domain.OptionalString, version *int) (*domain.Product, []domain.FieldChange, error) {
+	if m.updateProductFunc != nil {
+		product, err := m.updateProductFunc(ctx, id, name, description, price, imageURL, categoryID, version)
+		return product, m.updateChanges, err
+	}
+	return nil, nil, errors.New("not implemented")
+}
+
 func (m *mockService) DeleteProduct(ctx context.Context, id string) error {
 	if m.deleteProductFunc != nil {
 		return m.deleteProductFunc(ctx, id)
@@ -71,30 +147,47 @@ func (m *mockService) DeleteProduct(ctx context.Context, id string) error {
 	return errors.New("not implemented")
 }
 
-func newMockLogger() logger.Logger {
-	return logger.New("info", false)
+func (m *mockService) LockProduct(ctx context.Context, id string) error {
+	return m.setLocked(ctx, id, true)
 }
 
-func newMockConfig() *config.Config {
-	return &config.Config{
-		App: config.AppConfig{
-			Name:    "test",
-			Version: "1.0.0",
-			Env:     "test",
-			Debug:   true,
-		},
+func (m *mockService) UnlockProduct(ctx context.Context, id string) error {
+	return m.setLocked(ctx, id, false)
+}
+
+func (m *mockService) setLocked(ctx context.Context, id string, locked bool) error {
+	if m.setLockedFunc != nil {
+		return m.setLockedFunc(ctx, id, locked)
+	}
+	return errors.New("not implemented")
+}
+
+func (m *mockService) ReserveStock(ctx context.Context, id string, qty int) error {
+	return m.changeStock(ctx, id, qty, true)
+}
+
+func (m *mockService) ReleaseStock(ctx context.Context, id string, qty int) error {
+	return m.changeStock(ctx, id, qty, false)
+}
+
+func (m *mockService) changeStock(ctx context.Context, id string, qty int, reserve bool) error {
+	if m.changeStockFunc != nil {
+		return m.changeStockFunc(ctx, id, qty, reserve)
 	}
+	return errors.New("not implemented")
 }
 
-func newTestContext(cfg *config.Config) server.HandlerContext {
-	req := httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/", nil)
-	rec := httptest.NewRecorder()
-	return server.NewHandlerContextForTest(rec, req, cfg)
+// newTestProduct builds a product the way the service would from a request's
+// decimal price and currency. Invalid prices become zero.
+func newTestProduct(id, sku, name, description, price, currency, imageURL string) *domain.Product {
+	currency, _ = domain.NormalizeCurrency(currency)
+	priceMinor, _ := domain.ParsePrice(price, currency)
+	return domain.New(id, sku, name, description, priceMinor, currency, imageURL)
 }
 
 func TestGetProduct(t *testing.T) {
-	log := newMockLogger()
-	cfg := newMockConfig()
+	log := testutil.NewLogger()
+	cfg := testutil.NewConfig()
 
 	tests := []struct {
 		name          string
@@ -109,7 +202,7 @@ func TestGetProduct(t *testing.T) {
 			name:      "successful get",
 			productID: testID,
 			serviceFunc: func(ctx context.Context, id string) (*domain.Product, error) {
-				return domain.New(id, "Test Product", "Description", 99.99, "https://example.com/image.jpg"), nil
+				return domain.New(id, testSKU, "Test Product", "Description", 9999, domain.DefaultCurrency, "https://example.com/image.jpg"), nil
 			},
 			wantStatus:    http.StatusOK,
 			checkResponse: true,
@@ -144,9 +237,10 @@ func TestGetProduct(t *testing.T) {
 			handler := NewProductHandler(mockSvc, log)
 
 			req := &GetProductRequest{ID: tt.productID}
-			ctx := newTestContext(cfg)
+			ctx := testutil.NewContext(cfg)
 
-			response, apiErr := handler.GetProduct(*req, ctx)
+			result, apiErr := handler.GetProduct(*req, ctx)
+			response := result.Data
 
 			if apiErr != nil {
 				if apiErr.HTTPStatus() != tt.wantStatus {
@@ -171,9 +265,436 @@ func TestGetProduct(t *testing.T) {
 	}
 }
 
+func TestGetProductConditional(t *testing.T) {
+	product := domain.New(testID, testSKU, "Test Product", "Description", 9999, domain.DefaultCurrency, "")
+	mockSvc := &mockService{
+		getProductByIDFunc: func(context.Context, string) (*domain.Product, error) {
+			return product, nil
+		},
+	}
+	handler := NewProductHandler(mockSvc, testutil.NewLogger())
+
+	first, apiErr := handler.GetProduct(GetProductRequest{ID: testID}, testutil.NewContext(testutil.NewConfig()))
+	if apiErr != nil {
+		t.Fatalf("GetProduct() unexpected error: %v", apiErr)
+	}
+	etag := first.Headers.Get(headerETag)
+	if first.Status != http.StatusOK || first.Data == nil || !strings.HasPrefix(etag, `W/"`) {
+		t.Fatalf("GetProduct() = status %d, ETag %q, want 200 with a weak ETag", first.Status, etag)
+	}
+
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		wantStatus  int
+	}{
+		{name: "matching", ifNoneMatch: etag, wantStatus: http.StatusNotModified},
+		{name: "strong form", ifNoneMatch: strings.TrimPrefix(etag, "W/"), wantStatus: http.StatusNotModified},
+		{name: "in a list", ifNoneMatch: `"other", ` + etag, wantStatus: http.StatusNotModified},
+		{name: "wildcard", ifNoneMatch: "*", wantStatus: http.StatusNotModified},
+		{name: "stale", ifNoneMatch: `W/"stale"`, wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, _ := testutil.NewRequestContext(testutil.NewConfig(), http.MethodGet, "/products/"+testID,
+				testutil.WithHeader(headerIfNoneMatch, tt.ifNoneMatch))
+
+			result, apiErr := handler.GetProduct(GetProductRequest{ID: testID}, ctx)
+
+			if apiErr != nil {
+				t.Fatalf("GetProduct() unexpected error: %v", apiErr)
+			}
+			if result.Status != tt.wantStatus {
+				t.Errorf("GetProduct() status = %d, want %d", result.Status, tt.wantStatus)
+			}
+			if got := result.Headers.Get(headerETag); got != etag {
+				t.Errorf("GetProduct() ETag = %q, want %q", got, etag)
+			}
+			if (result.Data == nil) != (tt.wantStatus == http.StatusNotModified) {
+				t.Errorf("GetProduct() data = %v, want a body only with 200", result.Data)
+			}
+		})
+	}
+
+	t.Run("changed product", func(t *testing.T) {
+		renamed := *product
+		renamed.Name = "Renamed Product"
+		changed := &mockService{
+			getProductByIDFunc: func(context.Context, string) (*domain.Product, error) {
+				return &renamed, nil
+			},
+		}
+		ctx, _ := testutil.NewRequestContext(testutil.NewConfig(), http.MethodGet, "/products/"+testID,
+			testutil.WithHeader(headerIfNoneMatch, etag))
+
+		result, _ := NewProductHandler(changed, testutil.NewLogger()).GetProduct(GetProductRequest{ID: testID}, ctx)
+
+		if result.Status != http.StatusOK || result.Headers.Get(headerETag) == etag {
+			t.Errorf("GetProduct() = status %d, ETag %q, want 200 with a new ETag", result.Status, result.Headers.Get(headerETag))
+		}
+	})
+}
+
+func TestLastModified(t *testing.T) {
+	updated := time.Date(2025, 3, 1, 9, 30, 15, 500_000_000, time.UTC)
+	older, newer := *domain.New("id-1", testSKU, "Mug", "", 1000, domain.DefaultCurrency, ""), *domain.New("id-2", "CUP-002", "Cup", "", 500, domain.DefaultCurrency, "")
+	older.UpdatedDate = updated.Add(-time.Hour)
+	newer.UpdatedDate = updated
+	mockSvc := &mockService{
+		getProductByIDFunc: func(context.Context, string) (*domain.Product, error) {
+			return &newer, nil
+		},
+		listProductsFunc: func(context.Context, int, int) ([]*domain.Product, int, error) {
+			return []*domain.Product{&older, &newer}, 2, nil
+		},
+	}
+	handler := NewProductHandler(mockSvc, testutil.NewLogger())
+	const wantLastModified = "Sat, 01 Mar 2025 09:30:15 GMT"
+
+	tests := []struct {
+		name            string
+		ifModifiedSince string
+		ifNoneMatch     string
+		wantStatus      int
+	}{
+		{name: "no condition", wantStatus: http.StatusOK},
+		{name: "same second", ifModifiedSince: wantLastModified, wantStatus: http.StatusNotModified},
+		{name: "later", ifModifiedSince: "Sun, 02 Mar 2025 00:00:00 GMT", wantStatus: http.StatusNotModified},
+		{name: "earlier", ifModifiedSince: "Sat, 01 Mar 2025 09:30:14 GMT", wantStatus: http.StatusOK},
+		{name: "unparseable", ifModifiedSince: "yesterday", wantStatus: http.StatusOK},
+		{name: "If-None-Match takes precedence", ifModifiedSince: wantLastModified, ifNoneMatch: `W/"stale"`, wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts []testutil.RequestOption
+			if tt.ifModifiedSince != "" {
+				opts = append(opts, testutil.WithHeader(headerIfModifiedSince, tt.ifModifiedSince))
+			}
+			if tt.ifNoneMatch != "" {
+				opts = append(opts, testutil.WithHeader(headerIfNoneMatch, tt.ifNoneMatch))
+			}
+
+			ctx, _ := testutil.NewRequestContext(testutil.NewConfig(), http.MethodGet, "/products/id-2", opts...)
+			product, apiErr := handler.GetProduct(GetProductRequest{ID: "id-2"}, ctx)
+			if apiErr != nil {
+				t.Fatalf("GetProduct() unexpected error: %v", apiErr)
+			}
+			if product.Status != tt.wantStatus || product.Headers.Get(headerLastModified) != wantLastModified {
+				t.Errorf("GetProduct() = status %d, Last-Modified %q, want %d, %q", product.Status, product.Headers.Get(headerLastModified), tt.wantStatus, wantLastModified)
+			}
+
+			ctx, _ = testutil.NewRequestContext(testutil.NewConfig(), http.MethodGet, "/products", opts...)
+			list, apiErr := handler.ListProducts(ListProductsRequest{Page: 1, PageSize: 10}, ctx)
+			if apiErr != nil {
+				t.Fatalf("ListProducts() unexpected error: %v", apiErr)
+			}
+			if list.Status != tt.wantStatus || list.Headers.Get(headerLastModified) != wantLastModified {
+				t.Errorf("ListProducts() = status %d, Last-Modified %q, want %d, %q", list.Status, list.Headers.Get(headerLastModified), tt.wantStatus, wantLastModified)
+			}
+			if (list.Data == nil) != (tt.wantStatus == http.StatusNotModified) {
+				t.Errorf("ListProducts() data = %v, want a body only with 200", list.Data)
+			}
+		})
+	}
+
+	t.Run("empty page", func(t *testing.T) {
+		empty := NewProductHandler(&mockService{
+			listProductsFunc: func(context.Context, int, int) ([]*domain.Product, int, error) {
+				return nil, 0, nil
+			},
+		}, testutil.NewLogger())
+		ctx, _ := testutil.NewRequestContext(testutil.NewConfig(), http.MethodGet, "/products",
+			testutil.WithHeader(headerIfModifiedSince, wantLastModified))
+
+		list, _ := empty.ListProducts(ListProductsRequest{Page: 1, PageSize: 10}, ctx)
+
+		if list.Status != http.StatusOK || list.Headers.Get(headerLastModified) != "" {
+			t.Errorf("ListProducts() = status %d, Last-Modified %q, want 200 without Last-Modified", list.Status, list.Headers.Get(headerLastModified))
+		}
+	})
+}
+
+func TestLastModifiedAfterUpdate(t *testing.T) {
+	product := domain.New(testID, testSKU, "Mug", "", 1000, domain.DefaultCurrency, "")
+	product.UpdatedDate = time.Date(2025, 3, 1, 9, 30, 15, 0, time.UTC)
+	mockSvc := &mockService{
+		getProductByIDFunc: func(context.Context, string) (*domain.Product, error) {
+			current := *product
+			return &current, nil
+		},
+		// The repository sets the updated date on every update.
+		updateProductFunc: func(_ context.Context, _ string, name *string, _ domain.OptionalString, _ *string, _ domain.OptionalString, _ domain.OptionalString, _ *int) (*domain.Product, error) {
+			product.Name = *name
+			product.UpdatedDate = time.Now().UTC()
+			updated := *product
+			return &updated, nil
+		},
+	}
+	handler := NewProductHandler(mockSvc, testutil.NewLogger())
+
+	get := func(ifModifiedSince string) server.Result[*ProductResponse] {
+		t.Helper()
+		var opts []testutil.RequestOption
+		if ifModifiedSince != "" {
+			opts = append(opts, testutil.WithHeader(headerIfModifiedSince, ifModifiedSince))
+		}
+		ctx, _ := testutil.NewRequestContext(testutil.NewConfig(), http.MethodGet, "/products/"+testID, opts...)
+		result, apiErr := handler.GetProduct(GetProductRequest{ID: testID}, ctx)
+		if apiErr != nil {
+			t.Fatalf("GetProduct() unexpected error: %v", apiErr)
+		}
+		return result
+	}
+
+	oldLastModified := get("").Headers.Get(headerLastModified)
+	if got := get(oldLastModified); got.Status != http.StatusNotModified {
+		t.Fatalf("GetProduct() before the update = status %d, want %d", got.Status, http.StatusNotModified)
+	}
+
+	name := "Renamed mug"
+	ctx, _ := testutil.NewRequestContext(testutil.NewConfig(), http.MethodPut, "/products/"+testID)
+	if _, apiErr := handler.UpdateProduct(UpdateProductRequest{ID: testID, Name: &name}, ctx); apiErr != nil {
+		t.Fatalf("UpdateProduct() unexpected error: %v", apiErr)
+	}
+
+	got := get(oldLastModified)
+	if got.Status != http.StatusOK || got.Data == nil || got.Data.Name != name {
+		t.Fatalf("GetProduct() after the update = status %d, data %v, want 200 with the renamed product", got.Status, got.Data)
+	}
+	oldTime, _ := http.ParseTime(oldLastModified)
+	newTime, err := http.ParseTime(got.Headers.Get(headerLastModified))
+	if err != nil || !newTime.After(oldTime) {
+		t.Errorf("GetProduct() Last-Modified = %q, want later than %q", got.Headers.Get(headerLastModified), oldLastModified)
+	}
+}
+
+func TestProductLinks(t *testing.T) {
+	product := domain.New(testID, testSKU, "Test Product", "Description", 9999, domain.DefaultCurrency, "")
+	mockSvc := &mockService{
+		getProductByIDFunc: func(context.Context, string) (*domain.Product, error) {
+			return product, nil
+		},
+		listProductsFunc: func(context.Context, int, int) ([]*domain.Product, int, error) {
+			return []*domain.Product{product}, 5, nil
+		},
+		listProductsAfterFunc: func(context.Context, string, int) ([]*domain.Product, string, error) {
+			return []*domain.Product{product}, "next-page", nil
+		},
+	}
+	handler := NewProductHandler(mockSvc, testutil.NewLogger(), WithBasePath("api/v1/"))
+	productURL := "http://example.com/api/v1/products/" + testID
+
+	tests := []struct {
+		name      string
+		target    string
+		opts      []testutil.RequestOption
+		wantLinks bool
+	}{
+		{name: "default shape", target: "/api/v1/products/" + testID},
+		{name: "query", target: "/api/v1/products/" + testID + "?links=true", wantLinks: true},
+		{name: "accept", target: "/api/v1/products/" + testID, opts: []testutil.RequestOption{testutil.WithHeader("Accept", "application/json, application/hal+json;q=0.9")}, wantLinks: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, rec := testutil.NewRequestContext(testutil.NewConfig(), http.MethodGet, tt.target, tt.opts...)
+
+			result, apiErr := handler.GetProduct(GetProductRequest{ID: testID}, ctx)
+
+			if apiErr != nil {
+				t.Fatalf("GetProduct() unexpected error: %v", apiErr)
+			}
+			if rec.Header().Get("Vary") != "Accept" {
+				t.Errorf("GetProduct() Vary = %q, want Accept", rec.Header().Get("Vary"))
+			}
+			links := result.Data.Links
+			if !tt.wantLinks {
+				if links != nil {
+					t.Errorf("GetProduct() links = %+v, want none", links)
+				}
+				return
+			}
+			want := &ProductLinks{
+				Self:   Link{Href: productURL},
+				Update: Link{Href: productURL, Method: http.MethodPut},
+				Delete: Link{Href: productURL, Method: http.MethodDelete},
+			}
+			if links == nil || *links != *want {
+				t.Errorf("GetProduct() links = %+v, want %+v", links, want)
+			}
+		})
+	}
+
+	t.Run("invalid query", func(t *testing.T) {
+		ctx, _ := testutil.NewRequestContext(testutil.NewConfig(), http.MethodGet, "/api/v1/products/"+testID+"?links=maybe")
+		if _, apiErr := handler.GetProduct(GetProductRequest{ID: testID}, ctx); apiErr == nil || apiErr.HTTPStatus() != http.StatusBadRequest {
+			t.Errorf("GetProduct() error = %v, want 400", apiErr)
+		}
+	})
+
+	t.Run("page", func(t *testing.T) {
+		ctx, _ := testutil.NewRequestContext(testutil.NewConfig(), http.MethodGet, "/api/v1/products?links=true&q=mug&page=2&pageSize=2",
+			testutil.WithHeader("X-Forwarded-Proto", "https"))
+
+		result, apiErr := handler.ListProducts(ListProductsRequest{Page: 2, PageSize: 2, Search: "mug"}, ctx)
+
+		if apiErr != nil {
+			t.Fatalf("ListProducts() unexpected error: %v", apiErr)
+		}
+		links := result.Data.Links
+		if links == nil || links.Next == nil || links.Prev == nil {
+			t.Fatalf("ListProducts() links = %+v, want self, next and prev", links)
+		}
+		const pageURL = "https://example.com/api/v1/products?links=true&page=%d&pageSize=2&q=mug"
+		if links.Self.Href != fmt.Sprintf(pageURL, 2) || links.Next.Href != fmt.Sprintf(pageURL, 3) || links.Prev.Href != fmt.Sprintf(pageURL, 1) {
+			t.Errorf("ListProducts() links = self %s, next %s, prev %s", links.Self.Href, links.Next.Href, links.Prev.Href)
+		}
+		if item := result.Data.Products[0].Links; item == nil || item.Self.Href != "https://example.com/api/v1/products/"+testID {
+			t.Errorf("ListProducts() product links = %+v, want links to the product", item)
+		}
+	})
+
+	t.Run("last page", func(t *testing.T) {
+		ctx, _ := testutil.NewRequestContext(testutil.NewConfig(), http.MethodGet, "/api/v1/products?links=true&page=1&pageSize=10")
+
+		result, _ := handler.ListProducts(ListProductsRequest{Page: 1, PageSize: 10}, ctx)
+
+		if links := result.Data.Links; links == nil || links.Next != nil || links.Prev != nil {
+			t.Errorf("ListProducts() links = %+v, want only self on the only page", links)
+		}
+	})
+
+	t.Run("cursor", func(t *testing.T) {
+		ctx, _ := testutil.NewRequestContext(testutil.NewConfig(), http.MethodGet, "/api/v1/products?links=true&cursor=abc&pageSize=10")
+
+		result, _ := handler.ListProducts(ListProductsRequest{PageSize: 10, Cursor: "abc"}, ctx)
+
+		links := result.Data.Links
+		if links == nil || links.Next == nil || links.Prev != nil {
+			t.Fatalf("ListProducts() links = %+v, want self and next", links)
+		}
+		if want := "http://example.com/api/v1/products?cursor=next-page&links=true&pageSize=10"; links.Next.Href != want {
+			t.Errorf("ListProducts() next = %s, want %s", links.Next.Href, want)
+		}
+	})
+
+	t.Run("default list shape", func(t *testing.T) {
+		ctx, _ := testutil.NewRequestContext(testutil.NewConfig(), http.MethodGet, "/api/v1/products?page=1&pageSize=10")
+
+		result, _ := handler.ListProducts(ListProductsRequest{Page: 1, PageSize: 10}, ctx)
+
+		if result.Data.Links != nil || result.Data.Products[0].Links != nil {
+			t.Errorf("ListProducts() links = %+v, want none", result.Data.Links)
+		}
+	})
+}
+
+func TestGetProductBySKU(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+	}{
+		{name: "successful get", wantStatus: http.StatusOK},
+		{name: productNotFoundName, err: repository.ErrProductNotFound, wantStatus: http.StatusNotFound},
+		{name: validationErrorName, err: fmt.Errorf("%w: product SKU is required", service.ErrValidation), wantStatus: http.StatusBadRequest},
+		{name: internalErrorName, err: errors.New("database error"), wantStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := &mockService{
+				getProductBySKUFunc: func(ctx context.Context, sku string) (*domain.Product, error) {
+					if tt.err != nil {
+						return nil, tt.err
+					}
+					return domain.New(testID, sku, "Test Product", "Description", 9999, domain.DefaultCurrency, ""), nil
+				},
+			}
+			handler := NewProductHandler(mockSvc, testutil.NewLogger())
+
+			response, apiErr := handler.GetProductBySKU(GetProductBySKURequest{SKU: testSKU}, testutil.NewContext(testutil.NewConfig()))
+
+			if tt.err != nil {
+				if apiErr == nil || apiErr.HTTPStatus() != tt.wantStatus {
+					t.Errorf("GetProductBySKU() error = %v, want status %d", apiErr, tt.wantStatus)
+				}
+				return
+			}
+			if apiErr != nil {
+				t.Fatalf("GetProductBySKU() unexpected error = %v", apiErr)
+			}
+			if response.ID != testID || response.SKU != testSKU {
+				t.Errorf("GetProductBySKU() = %s/%s, want %s/%s", response.ID, response.SKU, testID, testSKU)
+			}
+		})
+	}
+}
+
+func TestDatabaseUnavailable(t *testing.T) {
+	unavailable := fmt.Errorf("%w: failed to get product: %w", service.ErrInternal,
+		dbutil.Unavailable(errors.New("connection refused")))
+	mockSvc := &mockService{
+		getProductByIDFunc: func(ctx context.Context, id string) (*domain.Product, error) {
+			return nil, unavailable
+		},
+	}
+	handler := NewProductHandler(mockSvc, testutil.NewLogger())
+
+	ctx, rec := testutil.NewRequestContext(testutil.NewConfig(), http.MethodGet, "/products/"+testID)
+
+	_, apiErr := handler.GetProduct(GetProductRequest{ID: testID}, ctx)
+
+	if apiErr == nil || apiErr.HTTPStatus() != http.StatusServiceUnavailable {
+		t.Fatalf("GetProduct() error = %v, want status %d", apiErr, http.StatusServiceUnavailable)
+	}
+	if got := rec.Header().Get("Retry-After"); got != dbutil.RetryAfterSeconds {
+		t.Errorf("GetProduct() Retry-After = %q, want %q", got, dbutil.RetryAfterSeconds)
+	}
+}
+
+func TestContextErrors(t *testing.T) {
+	expired, cancelExpired := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancelExpired()
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name       string
+		reqCtx     context.Context
+		err        error
+		wantStatus int
+	}{
+		{name: "client disconnected", reqCtx: canceled, err: context.Canceled, wantStatus: dbutil.StatusClientClosedRequest},
+		{name: "request deadline exceeded", reqCtx: expired, err: context.DeadlineExceeded, wantStatus: http.StatusRequestTimeout},
+		{name: "query timeout", reqCtx: context.Background(), err: context.DeadlineExceeded, wantStatus: http.StatusGatewayTimeout},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := &mockService{
+				getProductByIDFunc: func(ctx context.Context, id string) (*domain.Product, error) {
+					return nil, fmt.Errorf("%w: failed to get product: %w", service.ErrInternal, dbutil.Internal(tt.err))
+				},
+			}
+			handler := NewProductHandler(mockSvc, testutil.NewLogger())
+
+			ctx, _ := testutil.NewRequestContext(testutil.NewConfig(), http.MethodGet, "/products/"+testID, testutil.WithContext(tt.reqCtx))
+
+			_, apiErr := handler.GetProduct(GetProductRequest{ID: testID}, ctx)
+
+			if apiErr == nil || apiErr.HTTPStatus() != tt.wantStatus {
+				t.Fatalf("GetProduct() error = %v, want status %d", apiErr, tt.wantStatus)
+			}
+		})
+	}
+}
+
 func TestListProducts(t *testing.T) {
-	log := newMockLogger()
-	cfg := newMockConfig()
+	log := testutil.NewLogger()
+	cfg := testutil.NewConfig()
 
 	tests := []struct {
 		name        string
@@ -191,8 +712,8 @@ func TestListProducts(t *testing.T) {
 			pageSize: 10,
 			serviceFunc: func(ctx context.Context, page, pageSize int) ([]*domain.Product, int, error) {
 				products := []*domain.Product{
-					domain.New("1", "Product 1", "Desc 1", 10.00, ""),
-					domain.New("2", "Product 2", "Desc 2", 20.00, ""),
+					domain.New("1", testSKU, "Product 1", "Desc 1", 1000, domain.DefaultCurrency, ""),
+					domain.New("2", testSKU, "Product 2", "Desc 2", 2000, domain.DefaultCurrency, ""),
 				}
 				return products, 2, nil
 			},
@@ -245,9 +766,10 @@ func TestListProducts(t *testing.T) {
 				Page:     tt.page,
 				PageSize: tt.pageSize,
 			}
-			ctx := newTestContext(cfg)
+			ctx := testutil.NewContext(cfg)
 
-			response, apiErr := handler.ListProducts(*req, ctx)
+			result, apiErr := handler.ListProducts(*req, ctx)
+			response := result.Data
 
 			if apiErr != nil {
 				if apiErr.HTTPStatus() != tt.wantStatus {
@@ -275,255 +797,1780 @@ func TestListProducts(t *testing.T) {
 	}
 }
 
-func TestCreateProduct(t *testing.T) {
-	log := newMockLogger()
-	cfg := newMockConfig()
-
+func TestListProductsCursor(t *testing.T) {
 	tests := []struct {
-		name        string
-		request     *CreateProductRequest
-		serviceFunc func(ctx context.Context, name, description string, price float64, imageURL string) (*domain.Product, error)
-		wantStatus  int
-		wantErrCode string
+		name           string
+		query          string
+		cursor         string
+		serviceErr     error
+		wantStatus     int
+		wantErrCode    string
+		wantCursorMode bool
+		wantNextCursor string
 	}{
 		{
-			name: "successful create",
-			request: &CreateProductRequest{
-				Name:        "New Product",
-				Description: "Description",
-				Price:       99.99,
-				ImageURL:    "https://example.com/image.jpg",
-			},
-			serviceFunc: func(ctx context.Context, name, description string, price float64, imageURL string) (*domain.Product, error) {
-				return domain.New("new-id", name, description, price, imageURL), nil
-			},
-			wantStatus: http.StatusCreated,
+			name:           "cursor returns next page",
+			query:          "pageSize=10&cursor=abc",
+			cursor:         "abc",
+			wantStatus:     http.StatusOK,
+			wantCursorMode: true,
+			wantNextCursor: "def",
 		},
 		{
-			name: validationErrorName,
-			request: &CreateProductRequest{
-				Name:        "",
-				Description: "Description",
-				Price:       99.99,
-				ImageURL:    "",
-			},
-			serviceFunc: func(ctx context.Context, name, description string, price float64, imageURL string) (*domain.Product, error) {
-				return nil, errors.New("product name is required")
-			},
-			wantStatus:  http.StatusBadRequest,
-			wantErrCode: errCodeBadRequest,
+			name:           "empty cursor is the first page",
+			query:          "pageSize=10&cursor=",
+			wantStatus:     http.StatusOK,
+			wantCursorMode: true,
+			wantNextCursor: "def",
+		},
+		{
+			name:           "invalid cursor",
+			query:          "pageSize=10&cursor=%21%21",
+			cursor:         "!!",
+			serviceErr:     fmt.Errorf("%w: invalid cursor", service.ErrValidation),
+			wantStatus:     http.StatusBadRequest,
+			wantErrCode:    errCodeBadRequest,
+			wantCursorMode: true,
+		},
+		{
+			name:       "no cursor uses page numbers",
+			query:      "page=1&pageSize=10",
+			wantStatus: http.StatusOK,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			var cursorCalled, pageCalled bool
+			var gotCursor string
 			mockSvc := &mockService{
-				createProductFunc: tt.serviceFunc,
+				listProductsFunc: func(ctx context.Context, page, pageSize int) ([]*domain.Product, int, error) {
+					pageCalled = true
+					return []*domain.Product{}, 0, nil
+				},
+				listProductsAfterFunc: func(ctx context.Context, cursor string, pageSize int) ([]*domain.Product, string, error) {
+					cursorCalled = true
+					gotCursor = cursor
+					if tt.serviceErr != nil {
+						return nil, "", tt.serviceErr
+					}
+					return []*domain.Product{domain.New("id-1", testSKU, "Product", "Description", 1000, domain.DefaultCurrency, "")}, "def", nil
+				},
 			}
+			handler := NewProductHandler(mockSvc, testutil.NewLogger())
 
-			handler := NewProductHandler(mockSvc, log)
+			ctx, _ := testutil.NewRequestContext(testutil.NewConfig(), http.MethodGet, "/products?"+tt.query)
 
-			ctx := newTestContext(cfg)
+			result, apiErr := handler.ListProducts(ListProductsRequest{PageSize: 10, Cursor: tt.cursor}, ctx)
+			response := result.Data
 
-			result, apiErr := handler.CreateProduct(*tt.request, ctx)
+			if cursorCalled != tt.wantCursorMode || pageCalled == tt.wantCursorMode {
+				t.Fatalf("ListProducts() cursor mode = %v, page mode = %v, want cursor mode %v", cursorCalled, pageCalled, tt.wantCursorMode)
+			}
+			if tt.wantCursorMode && gotCursor != tt.cursor {
+				t.Errorf("ListProductsAfter() cursor = %q, want %q", gotCursor, tt.cursor)
+			}
 
-			if apiErr != nil {
+			if tt.wantStatus != http.StatusOK {
+				if apiErr == nil {
+					t.Fatalf("ListProducts() expected error with status %d, got nil", tt.wantStatus)
+				}
 				if apiErr.HTTPStatus() != tt.wantStatus {
-					t.Errorf("CreateProduct() status = %v, want %v", apiErr.HTTPStatus(), tt.wantStatus)
+					t.Errorf("ListProducts() status = %v, want %v", apiErr.HTTPStatus(), tt.wantStatus)
 				}
-				if tt.wantErrCode != "" && apiErr.ErrorCode() != tt.wantErrCode {
-					t.Errorf("CreateProduct() errorCode = %v, want %v", apiErr.ErrorCode(), tt.wantErrCode)
+				if apiErr.ErrorCode() != tt.wantErrCode {
+					t.Errorf("ListProducts() errorCode = %v, want %v", apiErr.ErrorCode(), tt.wantErrCode)
 				}
 				return
 			}
 
-			// Check that Created() result has correct status
-			status, _, _ := result.ResultMeta()
-			if status != tt.wantStatus {
-				t.Errorf("CreateProduct() result status = %v, want %v", status, tt.wantStatus)
+			if apiErr != nil {
+				t.Fatalf("ListProducts() unexpected error = %v", apiErr)
+			}
+			if response.NextCursor != tt.wantNextCursor {
+				t.Errorf("ListProducts() nextCursor = %q, want %q", response.NextCursor, tt.wantNextCursor)
 			}
 		})
 	}
 }
 
-func TestUpdateProduct(t *testing.T) {
-	log := newMockLogger()
-	cfg := newMockConfig()
-
-	updatedName := "Updated Product"
-	updatedPrice := 149.99
-
+func TestListProductsCursorRejectsFilters(t *testing.T) {
 	tests := []struct {
-		name        string
-		request     *UpdateProductRequest
-		serviceFunc func(ctx context.Context, id string, name *string, description *string, price *float64, imageURL *string) (*domain.Product, error)
-		wantStatus  int
-		wantErrCode string
+		name  string
+		req   ListProductsRequest
+		query string
 	}{
-		{
-			name: "successful update",
-			request: &UpdateProductRequest{
-				ID:    testID,
-				Name:  &updatedName,
-				Price: &updatedPrice,
-			},
-			serviceFunc: func(ctx context.Context, id string, name *string, description *string, price *float64, imageURL *string) (*domain.Product, error) {
-				return domain.New(id, *name, "Description", *price, ""), nil
-			},
+		{name: "search", req: ListProductsRequest{Search: "mug"}, query: "q=mug"},
+		{name: "category", req: ListProductsRequest{CategoryID: "7c9e6679-7425-40de-944b-e07fc1f90ae1"}, query: "categoryId=7c9e6679-7425-40de-944b-e07fc1f90ae1"},
+		{name: "price bound", req: ListProductsRequest{MinPrice: "5"}, query: "minPrice=5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewProductHandler(&mockService{}, testutil.NewLogger())
+			ctx, _ := testutil.NewRequestContext(testutil.NewConfig(), http.MethodGet, "/products?pageSize=10&cursor=abc&"+tt.query)
+			tt.req.PageSize, tt.req.Cursor = 10, "abc"
+
+			_, apiErr := handler.ListProducts(tt.req, ctx)
+
+			if apiErr == nil || apiErr.HTTPStatus() != http.StatusBadRequest {
+				t.Fatalf("ListProducts() error = %v, want 400", apiErr)
+			}
+			if !strings.Contains(apiErr.Message(), "categoryId") {
+				t.Errorf("ListProducts() message = %q, want it to name categoryId", apiErr.Message())
+			}
+		})
+	}
+}
+
+func TestListProductsSearch(t *testing.T) {
+	mockSvc := &mockService{
+		listProductsFunc: func(ctx context.Context, page, pageSize int) ([]*domain.Product, int, error) {
+			return []*domain.Product{}, 0, nil
+		},
+	}
+	handler := NewProductHandler(mockSvc, testutil.NewLogger(), WithStrictQueryParams(true))
+
+	ctx, _ := testutil.NewRequestContext(testutil.NewConfig(), http.MethodGet, "/products?page=1&pageSize=10&q=mug")
+
+	_, apiErr := handler.ListProducts(ListProductsRequest{Page: 1, PageSize: 10, Search: "mug"}, ctx)
+
+	if apiErr != nil {
+		t.Fatalf("ListProducts() unexpected error = %v", apiErr)
+	}
+	if mockSvc.listOpts.Search != "mug" {
+		t.Errorf("ListProducts() search = %q, want %q", mockSvc.listOpts.Search, "mug")
+	}
+}
+
+func TestListProductsEmptyResultFiltered(t *testing.T) {
+
+	tests := []struct {
+		name         string
+		request      ListProductsRequest
+		wantFiltered bool
+	}{
+		{name: "unfiltered empty catalog", request: ListProductsRequest{Page: 1, PageSize: 10}},
+		{name: "sorting alone is not a filter", request: ListProductsRequest{Page: 1, PageSize: 10, SortBy: "name"}},
+		{name: "search without matches", request: ListProductsRequest{Page: 1, PageSize: 10, Search: "nothing"}, wantFiltered: true},
+		{name: "price bound without matches", request: ListProductsRequest{Page: 1, PageSize: 10, MinPrice: "1000"}, wantFiltered: true},
+		{name: "currency without matches", request: ListProductsRequest{Page: 1, PageSize: 10, Currency: "JPY"}, wantFiltered: true},
+		{name: "category without matches", request: ListProductsRequest{Page: 1, PageSize: 10, CategoryID: testCategoryID}, wantFiltered: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := &mockService{
+				listProductsFunc: func(ctx context.Context, page, pageSize int) ([]*domain.Product, int, error) {
+					return []*domain.Product{}, 0, nil
+				},
+			}
+			handler := NewProductHandler(mockSvc, testutil.NewLogger())
+
+			result, apiErr := handler.ListProducts(tt.request, testutil.NewContext(testutil.NewConfig()))
+			response := result.Data
+			if apiErr != nil {
+				t.Fatalf("ListProducts() unexpected error = %v", apiErr)
+			}
+			if response.Total != 0 {
+				t.Errorf("ListProducts() total = %d, want 0", response.Total)
+			}
+			if response.Filtered != tt.wantFiltered {
+				t.Errorf("ListProducts() filtered = %v, want %v", response.Filtered, tt.wantFiltered)
+			}
+
+			body, err := json.Marshal(response)
+			if err != nil {
+				t.Fatalf("json.Marshal() error = %v", err)
+			}
+			if got := strings.Contains(string(body), `"filtered":true`); got != tt.wantFiltered {
+				t.Errorf("ListProducts() body = %s, want filtered flag %v", body, tt.wantFiltered)
+			}
+		})
+	}
+}
+
+func TestListProductsStrictQueryParams(t *testing.T) {
+	tests := []struct {
+		name        string
+		strict      bool
+		query       string
+		wantStatus  int
+		wantMessage string
+	}{
+		{
+			name:       "recognized params in strict mode",
+			strict:     true,
+			query:      "page=1&pageSize=10",
 			wantStatus: http.StatusOK,
 		},
 		{
-			name: productNotFoundName,
-			request: &UpdateProductRequest{
-				ID:   missingID,
-				Name: &updatedName,
+			name:        "unrecognized param in strict mode",
+			strict:      true,
+			query:       "page=1&pagesize=10&sort=name",
+			wantStatus:  http.StatusBadRequest,
+			wantMessage: "pagesize, sort",
+		},
+		{
+			name:       "unrecognized param in lenient mode",
+			strict:     false,
+			query:      "page=1&pagesize=10",
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := &mockService{
+				listProductsFunc: func(ctx context.Context, page, pageSize int) ([]*domain.Product, int, error) {
+					return []*domain.Product{}, 0, nil
+				},
+			}
+			handler := NewProductHandler(mockSvc, testutil.NewLogger(), WithStrictQueryParams(tt.strict))
+
+			ctx, _ := testutil.NewRequestContext(testutil.NewConfig(), http.MethodGet, "/products?"+tt.query)
+
+			_, apiErr := handler.ListProducts(ListProductsRequest{Page: 1, PageSize: 10}, ctx)
+
+			if tt.wantStatus == http.StatusOK {
+				if apiErr != nil {
+					t.Fatalf("ListProducts() unexpected error = %v", apiErr)
+				}
+				return
+			}
+
+			if apiErr == nil {
+				t.Fatalf("ListProducts() expected error with status %d, got nil", tt.wantStatus)
+			}
+			if apiErr.HTTPStatus() != tt.wantStatus {
+				t.Errorf("ListProducts() status = %v, want %v", apiErr.HTTPStatus(), tt.wantStatus)
+			}
+			if !strings.Contains(apiErr.Message(), tt.wantMessage) {
+				t.Errorf("ListProducts() message = %q, want it to name %q", apiErr.Message(), tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestCreateProduct(t *testing.T) {
+	log := testutil.NewLogger()
+	cfg := testutil.NewConfig()
+
+	tests := []struct {
+		name        string
+		request     *CreateProductRequest
+		serviceFunc func(ctx context.Context, in service.CreateProductInput) (*domain.Product, error)
+		wantStatus  int
+		wantErrCode string
+	}{
+		{
+			name: "successful create",
+			request: &CreateProductRequest{
+				SKU:         testSKU,
+				Name:        "New Product",
+				Description: "Description",
+				Price:       "99.99",
+				ImageURL:    "https://example.com/image.jpg",
 			},
-			serviceFunc: func(ctx context.Context, id string, name *string, description *string, price *float64, imageURL *string) (*domain.Product, error) {
-				return nil, repository.ErrProductNotFound
+			serviceFunc: func(ctx context.Context, in service.CreateProductInput) (*domain.Product, error) {
+				return newTestProduct("new-id", in.SKU, in.Name, in.Description, in.Price, in.Currency, in.ImageURL), nil
 			},
-			wantStatus:  http.StatusNotFound,
-			wantErrCode: errCodeNotFound,
+			wantStatus: http.StatusCreated,
 		},
 		{
 			name: validationErrorName,
-			request: &UpdateProductRequest{
-				ID:   testID,
-				Name: &updatedName,
+			request: &CreateProductRequest{
+				Name:        "",
+				Description: "Description",
+				Price:       "99.99",
+				ImageURL:    "",
 			},
-			serviceFunc: func(ctx context.Context, id string, name *string, description *string, price *float64, imageURL *string) (*domain.Product, error) {
-				return nil, errors.New("validation failed")
+			serviceFunc: func(ctx context.Context, in service.CreateProductInput) (*domain.Product, error) {
+				return nil, fmt.Errorf("%w: product name is required", service.ErrValidation)
 			},
 			wantStatus:  http.StatusBadRequest,
 			wantErrCode: errCodeBadRequest,
 		},
+		{
+			name: "duplicate SKU",
+			request: &CreateProductRequest{
+				SKU:   testSKU,
+				Name:  "Test Product",
+				Price: "99.99",
+			},
+			serviceFunc: func(ctx context.Context, in service.CreateProductInput) (*domain.Product, error) {
+				return nil, fmt.Errorf("%w: unique violation", repository.ErrDuplicateSKU)
+			},
+			wantStatus:  http.StatusConflict,
+			wantErrCode: errCodeConflict,
+		},
+		{
+			name: "internal error",
+			request: &CreateProductRequest{
+				Name:  "Test Product",
+				Price: "99.99",
+			},
+			serviceFunc: func(ctx context.Context, in service.CreateProductInput) (*domain.Product, error) {
+				return nil, fmt.Errorf("%w: failed to create product: database error", service.ErrInternal)
+			},
+			wantStatus:  http.StatusInternalServerError,
+			wantErrCode: errCodeInternal,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockSvc := &mockService{
-				updateProductFunc: tt.serviceFunc,
+				createProductFunc: tt.serviceFunc,
 			}
 
 			handler := NewProductHandler(mockSvc, log)
 
-			ctx := newTestContext(cfg)
+			ctx := testutil.NewContext(cfg)
 
-			response, apiErr := handler.UpdateProduct(*tt.request, ctx)
+			result, apiErr := handler.CreateProduct(*tt.request, ctx)
 
 			if apiErr != nil {
 				if apiErr.HTTPStatus() != tt.wantStatus {
-					t.Errorf("UpdateProduct() status = %v, want %v", apiErr.HTTPStatus(), tt.wantStatus)
+					t.Errorf("CreateProduct() status = %v, want %v", apiErr.HTTPStatus(), tt.wantStatus)
 				}
 				if tt.wantErrCode != "" && apiErr.ErrorCode() != tt.wantErrCode {
-					t.Errorf("UpdateProduct() errorCode = %v, want %v", apiErr.ErrorCode(), tt.wantErrCode)
+					t.Errorf("CreateProduct() errorCode = %v, want %v", apiErr.ErrorCode(), tt.wantErrCode)
 				}
 				return
 			}
 
-			if response == nil {
-				t.Errorf("UpdateProduct() response = nil, want non-nil")
+			// Check that Created() result has correct status
+			status, _, _ := result.ResultMeta()
+			if status != tt.wantStatus {
+				t.Errorf("CreateProduct() result status = %v, want %v", status, tt.wantStatus)
 			}
 		})
 	}
 }
 
-func TestDeleteProduct(t *testing.T) {
-	log := newMockLogger()
-	cfg := newMockConfig()
+func TestCreateProducts(t *testing.T) {
+	items := []CreateProductRequest{
+		{SKU: "A-1", Name: "First", Price: "1"},
+		{SKU: "A-2", Name: "", Price: "2"},
+		{SKU: "A-3", Name: "Third", Price: "3", Currency: "EUR"},
+	}
+	nameRequired := fmt.Errorf("%w: product name is required", service.ErrValidation)
+
+	created := func(items []service.CreateProductInput) []*domain.Product {
+		var products []*domain.Product
+		for i, item := range items {
+			if item.Name != "" {
+				products = append(products, newTestProduct(fmt.Sprintf("id-%d", i), item.SKU, item.Name, item.Description, item.Price, item.Currency, item.ImageURL))
+			}
+		}
+		return products
+	}
 
 	tests := []struct {
-		name        string
-		productID   string
-		serviceFunc func(ctx context.Context, id string) error
-		wantStatus  int
-		wantErrCode string
+		name         string
+		query        string
+		batchErr     error
+		wantStatus   int
+		wantCount    int
+		wantRejected []int
 	}{
 		{
-			name:      "successful delete",
-			productID: testID,
-			serviceFunc: func(ctx context.Context, id string) error {
-				return nil
-			},
-			wantStatus: http.StatusNoContent,
+			name:       "invalid item fails the whole batch",
+			batchErr:   &service.BatchError{Items: []service.BatchItemError{{Index: 1, Err: nameRequired}}},
+			wantStatus: http.StatusBadRequest,
 		},
 		{
-			name:      productNotFoundName,
-			productID: missingID,
-			serviceFunc: func(ctx context.Context, id string) error {
-				return repository.ErrProductNotFound
-			},
-			wantStatus:  http.StatusNotFound,
-			wantErrCode: errCodeNotFound,
+			name:       "internal error",
+			batchErr:   fmt.Errorf("%w: failed to create products: database error", service.ErrInternal),
+			wantStatus: http.StatusInternalServerError,
 		},
 		{
-			name:      internalErrorName,
-			productID: testID,
-			serviceFunc: func(ctx context.Context, id string) error {
-				return errors.New("database error")
-			},
-			wantStatus:  http.StatusInternalServerError,
-			wantErrCode: errCodeInternal,
+			name:         "partial inserts valid items",
+			query:        "?partial=true",
+			wantStatus:   http.StatusCreated,
+			wantCount:    2,
+			wantRejected: []int{1},
+		},
+		{
+			name:       "invalid partial flag",
+			query:      "?partial=maybe",
+			wantStatus: http.StatusBadRequest,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockSvc := &mockService{
-				deleteProductFunc: tt.serviceFunc,
+				createBatchFunc: func(ctx context.Context, items []service.CreateProductInput) ([]*domain.Product, error) {
+					if tt.batchErr != nil {
+						return nil, tt.batchErr
+					}
+					return created(items), nil
+				},
+				createPartialFunc: func(ctx context.Context, items []service.CreateProductInput) ([]*domain.Product, []service.BatchItemError, error) {
+					return created(items), []service.BatchItemError{{Index: 1, Err: nameRequired}}, nil
+				},
 			}
+			handler := NewProductHandler(mockSvc, testutil.NewLogger())
 
-			handler := NewProductHandler(mockSvc, log)
-
-			req := &DeleteProductRequest{ID: tt.productID}
-			ctx := newTestContext(cfg)
+			ctx, _ := testutil.NewRequestContext(testutil.NewConfig(), http.MethodPost, "/products/batch"+tt.query)
 
-			result, apiErr := handler.DeleteProduct(*req, ctx)
+			result, apiErr := handler.CreateProducts(CreateProductsRequest{Items: items}, ctx)
 
 			if apiErr != nil {
 				if apiErr.HTTPStatus() != tt.wantStatus {
-					t.Errorf("DeleteProduct() status = %v, want %v", apiErr.HTTPStatus(), tt.wantStatus)
+					t.Errorf("CreateProducts() status = %v, want %v", apiErr.HTTPStatus(), tt.wantStatus)
 				}
-				if tt.wantErrCode != "" && apiErr.ErrorCode() != tt.wantErrCode {
-					t.Errorf("DeleteProduct() errorCode = %v, want %v", apiErr.ErrorCode(), tt.wantErrCode)
+				var batchErr *service.BatchError
+				if errors.As(tt.batchErr, &batchErr) {
+					details, _ := apiErr.Details()["items"].([]BatchItemErrorResponse)
+					if len(details) != 1 || details[0].Index != 1 {
+						t.Errorf("CreateProducts() details = %v, want item 1", apiErr.Details())
+					}
 				}
 				return
 			}
+			if tt.wantStatus != http.StatusCreated {
+				t.Fatalf("CreateProducts() expected error with status %d, got nil", tt.wantStatus)
+			}
 
-			// Check NoContent result
 			status, _, _ := result.ResultMeta()
-			if status != tt.wantStatus {
-				t.Errorf("DeleteProduct() result status = %v, want %v", status, tt.wantStatus)
+			if status != http.StatusCreated {
+				t.Errorf("CreateProducts() status = %v, want %v", status, http.StatusCreated)
+			}
+			if len(result.Data.Products) != tt.wantCount {
+				t.Errorf("CreateProducts() created = %d, want %d", len(result.Data.Products), tt.wantCount)
+			}
+			var rejected []int
+			for _, r := range result.Data.Rejected {
+				rejected = append(rejected, r.Index)
+			}
+			if !slices.Equal(rejected, tt.wantRejected) {
+				t.Errorf("CreateProducts() rejected = %v, want %v", rejected, tt.wantRejected)
 			}
 		})
 	}
 }
 
-func TestToProductResponse(t *testing.T) {
-	product := domain.New("test-id", "Test Product", "Description", 99.99, "https://example.com/image.jpg")
+func TestCreateProductsRequestUnmarshal(t *testing.T) {
+	var req CreateProductsRequest
+	body := `[{"name":"First","price":1.5},{"name":"Second","price":2,"imageURL":"https://example.com/a.png"}]`
+	if err := json.Unmarshal([]byte(body), &req); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if len(req.Items) != 2 || req.Items[1].ImageURL != "https://example.com/a.png" {
+		t.Errorf("json.Unmarshal() items = %+v, want the two array elements", req.Items)
+	}
+}
 
-	response := ToProductResponse(product)
+func TestUpdateProductRequestUnmarshal(t *testing.T) {
+	tests := []struct {
+		body string
+		want domain.OptionalString
+	}{
+		{body: `{"name":"Renamed"}`, want: domain.OptionalString{}},
+		{body: `{"description":null}`, want: domain.NullString()},
+		{body: `{"description":""}`, want: domain.SomeString("")},
+		{body: `{"description":"New"}`, want: domain.SomeString("New")},
+	}
 
-	if response == nil {
-		t.Fatal("ToProductResponse() returned nil")
+	for _, tt := range tests {
+		var req UpdateProductRequest
+		if err := json.Unmarshal([]byte(tt.body), &req); err != nil {
+			t.Fatalf("json.Unmarshal(%s) error = %v", tt.body, err)
+		}
+		if req.Description != tt.want {
+			t.Errorf("json.Unmarshal(%s) description = %+v, want %+v", tt.body, req.Description, tt.want)
+		}
 	}
+}
 
-	if response.ID != product.ID {
-		t.Errorf("ToProductResponse() ID = %v, want %v", response.ID, product.ID)
+func TestCreateProductIncludeStats(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		wantStats bool
+	}{
+		{name: "omitted by default", query: ""},
+		{name: "omitted when false", query: "?includeStats=false"},
+		{name: "zeroed baseline when requested", query: "?includeStats=true", wantStats: true},
 	}
 
-	if response.Name != product.Name {
-		t.Errorf("ToProductResponse() Name = %v, want %v", response.Name, product.Name)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := &mockService{
+				createProductFunc: func(ctx context.Context, in service.CreateProductInput) (*domain.Product, error) {
+					return newTestProduct("new-id", in.SKU, in.Name, in.Description, in.Price, in.Currency, in.ImageURL), nil
+				},
+			}
+			handler := NewProductHandler(mockSvc, testutil.NewLogger())
+
+			ctx, _ := testutil.NewRequestContext(testutil.NewConfig(), http.MethodPost, "/products"+tt.query)
+
+			result, apiErr := handler.CreateProduct(CreateProductRequest{SKU: testSKU, Name: "New Product", Price: "10"}, ctx)
+			if apiErr != nil {
+				t.Fatalf("CreateProduct() unexpected error = %v", apiErr)
+			}
+
+			body, err := json.Marshal(result.Data)
+			if err != nil {
+				t.Fatalf("json.Marshal() error = %v", err)
+			}
+			var decoded map[string]any
+			if err := json.Unmarshal(body, &decoded); err != nil {
+				t.Fatalf("json.Unmarshal() error = %v", err)
+			}
+
+			stats, ok := decoded["stats"].(map[string]any)
+			if ok != tt.wantStats {
+				t.Fatalf("CreateProduct() stats present = %v, want %v (body %s)", ok, tt.wantStats, body)
+			}
+			if !tt.wantStats {
+				return
+			}
+			if stats["productId"] != "new-id" {
+				t.Errorf("CreateProduct() stats.productId = %v, want %v", stats["productId"], "new-id")
+			}
+			for _, field := range []string{"totalViews", "viewsToday", "viewsThisWeek"} {
+				if stats[field] != float64(0) {
+					t.Errorf("CreateProduct() stats.%s = %v, want 0", field, stats[field])
+				}
+			}
+			if _, present := stats["lastViewedAt"]; present {
+				t.Error("CreateProduct() stats.lastViewedAt present, want omitted for a product with no views")
+			}
+		})
+	}
+
+	t.Run("invalid flag", func(t *testing.T) {
+		handler := NewProductHandler(&mockService{}, testutil.NewLogger())
+		ctx, _ := testutil.NewRequestContext(testutil.NewConfig(), http.MethodPost, "/products?includeStats=maybe")
+
+		_, apiErr := handler.CreateProduct(CreateProductRequest{SKU: testSKU, Name: "New Product", Price: "10"}, ctx)
+
+		if apiErr == nil || apiErr.HTTPStatus() != http.StatusBadRequest {
+			t.Errorf("CreateProduct() error = %v, want status %d", apiErr, http.StatusBadRequest)
+		}
+	})
+}
+
+func TestCreateProductIdempotencyKey(t *testing.T) {
+	var created int
+	mockSvc := &mockService{
+		createProductFunc: func(ctx context.Context, in service.CreateProductInput) (*domain.Product, error) {
+			created++
+			return newTestProduct(fmt.Sprintf("id-%d", created), in.SKU, in.Name, in.Description, in.Price, in.Currency, in.ImageURL), nil
+		},
 	}
+	store := NewMemoryIdempotencyStore(time.Minute, 10)
+	defer store.Close()
+	handler := NewProductHandler(mockSvc, testutil.NewLogger(), WithIdempotencyStore(store))
 
-	if response.Price != product.Price {
-		t.Errorf("ToProductResponse() Price = %v, want %v", response.Price, product.Price)
+	create := func(key string, req CreateProductRequest) (server.Result[*ProductResponse], server.IAPIError) {
+		var opts []testutil.RequestOption
+		if key != "" {
+			opts = append(opts, testutil.WithHeader(headerIdempotencyKey, key))
+		}
+		ctx, _ := testutil.NewRequestContext(testutil.NewConfig(), http.MethodPost, "/products", opts...)
+		return handler.CreateProduct(req, ctx)
 	}
+	req := CreateProductRequest{SKU: testSKU, Name: "Mug", Price: "9.5"}
 
-	if response.CreatedDate == "" {
-		t.Error("ToProductResponse() CreatedDate is empty")
+	first, apiErr := create("key-1", req)
+	if apiErr != nil {
+		t.Fatalf("CreateProduct() unexpected error = %v", apiErr)
 	}
 
-	if response.UpdatedDate == "" {
-		t.Error("ToProductResponse() UpdatedDate is empty")
+	t.Run("replay returns the original response", func(t *testing.T) {
+		replay, apiErr := create("key-1", req)
+		if apiErr != nil {
+			t.Fatalf("CreateProduct() replay error = %v", apiErr)
+		}
+		if replay.Status != http.StatusCreated || replay.Data.ID != first.Data.ID {
+			t.Errorf("replay = %d %s, want 201 %s", replay.Status, replay.Data.ID, first.Data.ID)
+		}
+		if created != 1 {
+			t.Errorf("service created %d products, want 1", created)
+		}
+	})
+
+	t.Run("different body with the same key conflicts", func(t *testing.T) {
+		_, apiErr := create("key-1", CreateProductRequest{SKU: "PLATE-001", Name: "Plate", Price: "9.5"})
+		if apiErr == nil || apiErr.HTTPStatus() != http.StatusConflict {
+			t.Fatalf("CreateProduct() error = %v, want 409", apiErr)
+		}
+		if created != 1 {
+			t.Errorf("service created %d products, want 1", created)
+		}
+	})
+
+	t.Run("new key or no key creates again", func(t *testing.T) {
+		before := created
+		if _, apiErr := create("key-2", req); apiErr != nil {
+			t.Fatalf("CreateProduct() error = %v", apiErr)
+		}
+		if _, apiErr := create("", req); apiErr != nil {
+			t.Fatalf("CreateProduct() error = %v", apiErr)
+		}
+		if created != before+2 {
+			t.Errorf("service created %d products, want %d", created, before+2)
+		}
+	})
+}
+
+func TestRequireContentType(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		allowed     []string
+		wantNext    bool
+		wantStatus  int
+	}{
+		{
+			name:        "json",
+			contentType: "application/json",
+			wantNext:    true,
+		},
+		{
+			name:        "json with charset",
+			contentType: "application/json; charset=utf-8",
+			wantNext:    true,
+		},
+		{
+			name:        "form encoded",
+			contentType: "application/x-www-form-urlencoded",
+			wantStatus:  http.StatusUnsupportedMediaType,
+		},
+		{
+			name:       "missing",
+			wantStatus: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:        "allowlisted alternative",
+			contentType: "application/merge-patch+json",
+			allowed:     []string{"application/json", "application/merge-patch+json"},
+			wantNext:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewProductHandler(&mockService{}, testutil.NewLogger(), WithAllowedContentTypes(tt.allowed...))
+
+			opts := []testutil.RequestOption{testutil.WithBody(`{"name":"x","price":1}`)}
+			if tt.contentType != "" {
+				opts = append(opts, testutil.WithHeader("Content-Type", tt.contentType))
+			}
+			ctx, _ := testutil.NewRequestContext(testutil.NewConfig(), http.MethodPost, "/products", opts...)
+
+			nextCalled := false
+			err := handler.requireContentType(ctx, func() error {
+				nextCalled = true
+				return nil
+			})
+
+			if nextCalled != tt.wantNext {
+				t.Errorf("requireContentType() called next = %v, want %v", nextCalled, tt.wantNext)
+			}
+			if tt.wantNext {
+				if err != nil {
+					t.Errorf("requireContentType() unexpected error = %v", err)
+				}
+				return
+			}
+
+			var apiErr server.IAPIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("requireContentType() error = %v, want IAPIError", err)
+			}
+			if apiErr.HTTPStatus() != tt.wantStatus {
+				t.Errorf("requireContentType() status = %v, want %v", apiErr.HTTPStatus(), tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestRawResponse(t *testing.T) {
+	tests := []struct {
+		name       string
+		header     string
+		productID  string
+		wantRaw    bool
+		wantStatus int
+	}{
+		{name: "no header", productID: testID},
+		{name: "header true", header: "true", productID: testID, wantRaw: true},
+		{name: "header false", header: "false", productID: testID},
+		{name: "unparseable header", header: "please", productID: testID},
+		{name: "raw not found", header: "1", productID: missingID, wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := &mockService{
+				getProductByIDFunc: func(_ context.Context, id string) (*domain.Product, error) {
+					if id == missingID {
+						return nil, repository.ErrProductNotFound
+					}
+					return domain.New(id, testSKU, "Test Product", "Description", 9999, domain.DefaultCurrency, ""), nil
+				},
+			}
+			handler := NewProductHandler(mockSvc, testutil.NewLogger())
+
+			var opts []testutil.RequestOption
+			if tt.header != "" {
+				opts = append(opts, testutil.WithHeader(headerRawResponse, tt.header))
+			}
+			ctx, rec := testutil.NewRequestContext(testutil.NewConfig(), http.MethodGet, "/products/"+tt.productID, opts...)
+			ctx.SetPathParams([]server.PathParam{{Name: "id", Value: tt.productID}})
+
+			// next stands in for the typed route, which writes the envelope.
+			nextCalled := false
+			err := rawResponse(handler.getProductRaw)(ctx, func() error {
+				nextCalled = true
+				return nil
+			})
+
+			if tt.wantStatus != 0 {
+				var apiErr server.IAPIError
+				if !errors.As(err, &apiErr) {
+					t.Fatalf("rawResponse() error = %v, want IAPIError", err)
+				}
+				if apiErr.HTTPStatus() != tt.wantStatus {
+					t.Errorf("rawResponse() status = %v, want %v", apiErr.HTTPStatus(), tt.wantStatus)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("rawResponse() unexpected error = %v", err)
+			}
+
+			if nextCalled == tt.wantRaw {
+				t.Errorf("rawResponse() called next = %v, want %v", nextCalled, !tt.wantRaw)
+			}
+			if !tt.wantRaw {
+				if rec.Body.Len() != 0 {
+					t.Errorf("rawResponse() wrote %q for an enveloped request, want nothing", rec.Body.String())
+				}
+				return
+			}
+
+			var body map[string]any
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("raw body is not JSON: %v (%q)", err, rec.Body.String())
+			}
+			if _, ok := body["data"]; ok {
+				t.Errorf("raw body = %v, want no data envelope", body)
+			}
+			if body["id"] != tt.productID {
+				t.Errorf("raw body id = %v, want %v", body["id"], tt.productID)
+			}
+		})
+	}
+}
+
+func TestRawResponseConditional(t *testing.T) {
+	product := domain.New(testID, testSKU, "Test Product", "Description", 9999, domain.DefaultCurrency, "")
+	mockSvc := &mockService{
+		getProductByIDFunc: func(context.Context, string) (*domain.Product, error) {
+			return product, nil
+		},
+	}
+	handler := NewProductHandler(mockSvc, testutil.NewLogger())
+	etag := productETag(ToProductResponse(product))
+
+	for _, tt := range []struct {
+		ifNoneMatch string
+		wantStatus  int
+	}{
+		{wantStatus: http.StatusOK},
+		{ifNoneMatch: etag, wantStatus: http.StatusNotModified},
+	} {
+		opts := []testutil.RequestOption{testutil.WithHeader(headerRawResponse, "true")}
+		if tt.ifNoneMatch != "" {
+			opts = append(opts, testutil.WithHeader(headerIfNoneMatch, tt.ifNoneMatch))
+		}
+		ctx, rec := testutil.NewRequestContext(testutil.NewConfig(), http.MethodGet, "/products/"+testID, opts...)
+		ctx.SetPathParams([]server.PathParam{{Name: "id", Value: testID}})
+
+		if err := handler.getProductRaw(ctx); err != nil {
+			t.Fatalf("getProductRaw() If-None-Match %q error = %v", tt.ifNoneMatch, err)
+		}
+		if rec.Code != tt.wantStatus {
+			t.Errorf("getProductRaw() If-None-Match %q status = %d, want %d", tt.ifNoneMatch, rec.Code, tt.wantStatus)
+		}
+		if got := rec.Header().Get(headerETag); got != etag {
+			t.Errorf("getProductRaw() ETag = %q, want %q", got, etag)
+		}
+		if tt.wantStatus == http.StatusNotModified && rec.Body.Len() != 0 {
+			t.Errorf("getProductRaw() 304 body = %q, want empty", rec.Body.String())
+		}
+	}
+}
+
+func TestUpdateProduct(t *testing.T) {
+	log := testutil.NewLogger()
+	cfg := testutil.NewConfig()
+
+	updatedName := "Updated Product"
+	updatedPrice := json.Number("149.99")
+	staleVersion := 3
+
+	tests := []struct {
+		name        string
+		request     *UpdateProductRequest
+		serviceFunc func(ctx context.Context, id string, name *string, description domain.OptionalString, price *string, imageURL domain.OptionalString, categoryID domain.OptionalString, version *int) (*domain.Product, error)
+		wantStatus  int
+		wantErrCode string
+	}{
+		{
+			name: "successful update",
+			request: &UpdateProductRequest{
+				ID:    testID,
+				Name:  &updatedName,
+				Price: &updatedPrice,
+			},
+			serviceFunc: func(ctx context.Context, id string, name *string, description domain.OptionalString, price *string, imageURL domain.OptionalString, categoryID domain.OptionalString, version *int) (*domain.Product, error) {
+				return newTestProduct(id, testSKU, *name, "Description", *price, "", ""), nil
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: productNotFoundName,
+			request: &UpdateProductRequest{
+				ID:   missingID,
+				Name: &updatedName,
+			},
+			serviceFunc: func(ctx context.Context, id string, name *string, description domain.OptionalString, price *string, imageURL domain.OptionalString, categoryID domain.OptionalString, version *int) (*domain.Product, error) {
+				return nil, repository.ErrProductNotFound
+			},
+			wantStatus:  http.StatusNotFound,
+			wantErrCode: errCodeNotFound,
+		},
+		{
+			name: validationErrorName,
+			request: &UpdateProductRequest{
+				ID:   testID,
+				Name: &updatedName,
+			},
+			serviceFunc: func(ctx context.Context, id string, name *string, description domain.OptionalString, price *string, imageURL domain.OptionalString, categoryID domain.OptionalString, version *int) (*domain.Product, error) {
+				return nil, fmt.Errorf("%w: validation failed", service.ErrValidation)
+			},
+			wantStatus:  http.StatusBadRequest,
+			wantErrCode: errCodeBadRequest,
+		},
+		{
+			name: "stale version",
+			request: &UpdateProductRequest{
+				ID:      testID,
+				Name:    &updatedName,
+				Version: &staleVersion,
+			},
+			serviceFunc: func(ctx context.Context, id string, name *string, description domain.OptionalString, price *string, imageURL domain.OptionalString, categoryID domain.OptionalString, version *int) (*domain.Product, error) {
+				if version == nil || *version != staleVersion {
+					return nil, errors.New("version not forwarded")
+				}
+				return nil, repository.ErrConcurrentModification
+			},
+			wantStatus:  http.StatusConflict,
+			wantErrCode: errCodeConflict,
+		},
+		{
+			name: lockedProductName,
+			request: &UpdateProductRequest{
+				ID:   testID,
+				Name: &updatedName,
+			},
+			serviceFunc: func(ctx context.Context, id string, name *string, description domain.OptionalString, price *string, imageURL domain.OptionalString, categoryID domain.OptionalString, version *int) (*domain.Product, error) {
+				return nil, service.ErrLocked
+			},
+			wantStatus:  http.StatusLocked,
+			wantErrCode: errCodeProductLocked,
+		},
+		{
+			name: "internal error",
+			request: &UpdateProductRequest{
+				ID:   testID,
+				Name: &updatedName,
+			},
+			serviceFunc: func(ctx context.Context, id string, name *string, description domain.OptionalString, price *string, imageURL domain.OptionalString, categoryID domain.OptionalString, version *int) (*domain.Product, error) {
+				return nil, fmt.Errorf("%w: failed to update product: database error", service.ErrInternal)
+			},
+			wantStatus:  http.StatusInternalServerError,
+			wantErrCode: errCodeInternal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := &mockService{
+				updateProductFunc: tt.serviceFunc,
+			}
+
+			handler := NewProductHandler(mockSvc, log)
+
+			ctx := testutil.NewContext(cfg)
+
+			response, apiErr := handler.UpdateProduct(*tt.request, ctx)
+
+			if apiErr != nil {
+				if apiErr.HTTPStatus() != tt.wantStatus {
+					t.Errorf("UpdateProduct() status = %v, want %v", apiErr.HTTPStatus(), tt.wantStatus)
+				}
+				if tt.wantErrCode != "" && apiErr.ErrorCode() != tt.wantErrCode {
+					t.Errorf("UpdateProduct() errorCode = %v, want %v", apiErr.ErrorCode(), tt.wantErrCode)
+				}
+				return
+			}
+
+			if response == nil {
+				t.Errorf("UpdateProduct() response = nil, want non-nil")
+			}
+		})
+	}
+}
+
+func TestUpdateProductIncludeChanges(t *testing.T) {
+	name := "Renamed"
+	changes := []domain.FieldChange{
+		{Field: "name", Before: "Original", After: name},
+		{Field: "price", Before: int64(1000), After: int64(1250)},
+	}
+
+	tests := []struct {
+		name        string
+		query       string
+		wantChanges bool
+	}{
+		{name: "omitted by default"},
+		{name: "included on request", query: "?includeChanges=true", wantChanges: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := &mockService{
+				updateProductFunc: func(_ context.Context, id string, _ *string, _ domain.OptionalString, _ *string, _ domain.OptionalString, _ domain.OptionalString, _ *int) (*domain.Product, error) {
+					return domain.New(id, testSKU, name, "Description", 1250, domain.DefaultCurrency, ""), nil
+				},
+				updateChanges: changes,
+			}
+			handler := NewProductHandler(mockSvc, testutil.NewLogger())
+			ctx, _ := testutil.NewRequestContext(testutil.NewConfig(), http.MethodPut, "/products/"+testID+tt.query)
+
+			response, apiErr := handler.UpdateProduct(UpdateProductRequest{ID: testID, Name: &name}, ctx)
+			if apiErr != nil {
+				t.Fatalf("UpdateProduct() unexpected error = %v", apiErr)
+			}
+
+			body, err := json.Marshal(response)
+			if err != nil {
+				t.Fatalf("marshal response: %v", err)
+			}
+			var got struct {
+				Changes []map[string]any `json:"changes"`
+			}
+			if err := json.Unmarshal(body, &got); err != nil {
+				t.Fatalf("unmarshal response: %v", err)
+			}
+
+			if !tt.wantChanges {
+				if got.Changes != nil {
+					t.Errorf("UpdateProduct() changes = %v, want omitted", got.Changes)
+				}
+				return
+			}
+			if len(got.Changes) != len(changes) {
+				t.Fatalf("UpdateProduct() changes = %v, want %d entries", got.Changes, len(changes))
+			}
+			if got.Changes[0]["field"] != "name" || got.Changes[0]["before"] != "Original" || got.Changes[0]["after"] != name {
+				t.Errorf("UpdateProduct() changes[0] = %v, want name Original -> %s", got.Changes[0], name)
+			}
+			// Prices use the currency's decimal scale, like the product price.
+			if !strings.Contains(string(body), `"before":10.00,"after":12.50`) {
+				t.Errorf("UpdateProduct() body = %s, want price change 10.00 -> 12.50", body)
+			}
+		})
+	}
+}
+
+func TestDeleteProduct(t *testing.T) {
+	log := testutil.NewLogger()
+	cfg := testutil.NewConfig()
+
+	tests := []struct {
+		name        string
+		productID   string
+		serviceFunc func(ctx context.Context, id string) error
+		wantStatus  int
+		wantErrCode string
+	}{
+		{
+			name:      "successful delete",
+			productID: testID,
+			serviceFunc: func(ctx context.Context, id string) error {
+				return nil
+			},
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name:      productNotFoundName,
+			productID: missingID,
+			serviceFunc: func(ctx context.Context, id string) error {
+				return repository.ErrProductNotFound
+			},
+			wantStatus:  http.StatusNotFound,
+			wantErrCode: errCodeNotFound,
+		},
+		{
+			name:      lockedProductName,
+			productID: testID,
+			serviceFunc: func(ctx context.Context, id string) error {
+				return service.ErrLocked
+			},
+			wantStatus:  http.StatusLocked,
+			wantErrCode: errCodeProductLocked,
+		},
+		{
+			name:      internalErrorName,
+			productID: testID,
+			serviceFunc: func(ctx context.Context, id string) error {
+				return errors.New("database error")
+			},
+			wantStatus:  http.StatusInternalServerError,
+			wantErrCode: errCodeInternal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := &mockService{
+				deleteProductFunc: tt.serviceFunc,
+			}
+
+			handler := NewProductHandler(mockSvc, log)
+
+			req := &DeleteProductRequest{ID: tt.productID}
+			ctx := testutil.NewContext(cfg)
+
+			result, apiErr := handler.DeleteProduct(*req, ctx)
+
+			if apiErr != nil {
+				if apiErr.HTTPStatus() != tt.wantStatus {
+					t.Errorf("DeleteProduct() status = %v, want %v", apiErr.HTTPStatus(), tt.wantStatus)
+				}
+				if tt.wantErrCode != "" && apiErr.ErrorCode() != tt.wantErrCode {
+					t.Errorf("DeleteProduct() errorCode = %v, want %v", apiErr.ErrorCode(), tt.wantErrCode)
+				}
+				return
+			}
+
+			// Check NoContent result
+			status, _, _ := result.ResultMeta()
+			if status != tt.wantStatus {
+				t.Errorf("DeleteProduct() result status = %v, want %v", status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestSetProductLock(t *testing.T) {
+	const adminToken = "s3cret"
+	guard := admin.NewGuard(admin.Config{Enabled: true, Token: adminToken})
+
+	tests := []struct {
+		name       string
+		unlock     bool
+		token      string
+		serviceErr error
+		wantStatus int
+		wantCalled bool
+	}{
+		{name: "lock", token: adminToken, wantStatus: http.StatusNoContent, wantCalled: true},
+		{name: "unlock", unlock: true, token: adminToken, wantStatus: http.StatusNoContent, wantCalled: true},
+		{name: "unlock without admin token", unlock: true, wantStatus: http.StatusUnauthorized},
+		{name: productNotFoundName, unlock: true, token: adminToken, serviceErr: repository.ErrProductNotFound, wantStatus: http.StatusNotFound, wantCalled: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotLocked *bool
+			mockSvc := &mockService{
+				setLockedFunc: func(_ context.Context, id string, locked bool) error {
+					if id != testID {
+						t.Errorf("setLocked() id = %q, want %q", id, testID)
+					}
+					gotLocked = &locked
+					return tt.serviceErr
+				},
+			}
+			handler := NewProductHandler(mockSvc, testutil.NewLogger(), WithAdminGuard(guard))
+
+			var opts []testutil.RequestOption
+			if tt.token != "" {
+				opts = append(opts, testutil.WithHeader(admin.HeaderToken, tt.token))
+			}
+			ctx, _ := testutil.NewRequestContext(testutil.NewConfig(), http.MethodPost, "/admin/products/"+testID+"/lock", opts...)
+
+			req := SetProductLockRequest{ID: testID}
+			setLock := handler.LockProduct
+			if tt.unlock {
+				setLock = handler.UnlockProduct
+			}
+			result, apiErr := setLock(req, ctx)
+
+			status := 0
+			if apiErr != nil {
+				status = apiErr.HTTPStatus()
+			} else {
+				status, _, _ = result.ResultMeta()
+			}
+			if status != tt.wantStatus {
+				t.Errorf("status = %v, want %v", status, tt.wantStatus)
+			}
+			if (gotLocked != nil) != tt.wantCalled {
+				t.Fatalf("service called = %v, want %v", gotLocked != nil, tt.wantCalled)
+			}
+			if gotLocked != nil && *gotLocked == tt.unlock {
+				t.Errorf("service locked = %v, want %v", *gotLocked, !tt.unlock)
+			}
+		})
+	}
+}
+
+func TestChangeStock(t *testing.T) {
+	tests := []struct {
+		name       string
+		release    bool
+		serviceErr error
+		wantStatus int
+	}{
+		{name: "reserve", wantStatus: http.StatusNoContent},
+		{name: "release", release: true, wantStatus: http.StatusNoContent},
+		{name: "insufficient stock", serviceErr: fmt.Errorf("reserve: %w", service.ErrInsufficientStock), wantStatus: http.StatusConflict},
+		{name: productNotFoundName, release: true, serviceErr: repository.ErrProductNotFound, wantStatus: http.StatusNotFound},
+		{name: validationErrorName, serviceErr: fmt.Errorf("%w: quantity must be positive", service.ErrValidation), wantStatus: http.StatusBadRequest},
+		{name: internalErrorName, serviceErr: fmt.Errorf("%w: database error", service.ErrInternal), wantStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotReserve *bool
+			mockSvc := &mockService{
+				changeStockFunc: func(_ context.Context, id string, qty int, reserve bool) error {
+					if id != testID || qty != 3 {
+						t.Errorf("changeStock() id, qty = %q, %d, want %q, 3", id, qty, testID)
+					}
+					gotReserve = &reserve
+					return tt.serviceErr
+				},
+			}
+			handler := NewProductHandler(mockSvc, testutil.NewLogger())
+			ctx := testutil.NewContext(testutil.NewConfig())
+
+			req := ChangeStockRequest{ID: testID, Quantity: 3}
+			change := handler.ReserveStock
+			if tt.release {
+				change = handler.ReleaseStock
+			}
+			result, apiErr := change(req, ctx)
+
+			status := 0
+			if apiErr != nil {
+				status = apiErr.HTTPStatus()
+			} else {
+				status, _, _ = result.ResultMeta()
+			}
+			if status != tt.wantStatus {
+				t.Errorf("status = %v, want %v", status, tt.wantStatus)
+			}
+			if gotReserve == nil || *gotReserve == tt.release {
+				t.Errorf("service reserve = %v, want %v", gotReserve, !tt.release)
+			}
+		})
+	}
+}
+
+func TestStreamProducts(t *testing.T) {
+	log := testutil.NewLogger()
+	cfg := testutil.NewConfig()
+
+	products := []*domain.Product{
+		domain.New("id-1", testSKU, "First", "Description", 1050, domain.DefaultCurrency, ""),
+		domain.New("id-2", testSKU, "Second", "Description", 2000, domain.DefaultCurrency, ""),
+		domain.New("id-3", testSKU, "Third", "Description", 3025, domain.DefaultCurrency, ""),
+	}
+
+	mockSvc := &mockService{
+		streamProductsFunc: func(_ context.Context, fn func(*domain.Product) error) error {
+			for _, p := range products {
+				if err := fn(p); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+	handler := NewProductHandler(mockSvc, log)
+
+	ctx, rec := testutil.NewRequestContext(cfg, http.MethodGet, "/products/stream")
+
+	if err := handler.StreamProducts(ctx); err != nil {
+		t.Fatalf("StreamProducts() unexpected error = %v", err)
+	}
+
+	if got := rec.Header().Get("Content-Type"); got != ndjsonContentType {
+		t.Errorf("StreamProducts() Content-Type = %q, want %q", got, ndjsonContentType)
+	}
+
+	lines := strings.Split(strings.TrimRight(rec.Body.String(), "\n"), "\n")
+	if len(lines) != len(products) {
+		t.Fatalf("StreamProducts() wrote %d lines, want %d", len(lines), len(products))
+	}
+
+	for i, line := range lines {
+		var got ProductResponse
+		if err := json.Unmarshal([]byte(line), &got); err != nil {
+			t.Fatalf("line %d is not a product: %v (%q)", i, err, line)
+		}
+		if got.ID != products[i].ID {
+			t.Errorf("line %d ID = %v, want %v", i, got.ID, products[i].ID)
+		}
+		if want := products[i].FormattedPrice(); got.Price.String() != want {
+			t.Errorf("line %d Price = %v, want %v", i, got.Price, want)
+		}
+	}
+}
+
+func TestListProductsLargeResultHeader(t *testing.T) {
+	const threshold = 50
+
+	tests := []struct {
+		name      string
+		total     int
+		wantLarge bool
+	}{
+		{name: "below threshold", total: 10},
+		{name: "at threshold", total: threshold},
+		{name: "above threshold", total: threshold + 1, wantLarge: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := &mockService{
+				listProductsFunc: func(ctx context.Context, page, pageSize int) ([]*domain.Product, int, error) {
+					return []*domain.Product{domain.New("1", testSKU, "Product 1", "Desc 1", 1000, domain.DefaultCurrency, "")}, tt.total, nil
+				},
+			}
+			handler := NewProductHandler(mockSvc, testutil.NewLogger(), WithLargeResultThreshold(threshold))
+
+			ctx, rec := testutil.NewRequestContext(testutil.NewConfig(), http.MethodGet, "/products")
+
+			_, apiErr := handler.ListProducts(ListProductsRequest{Page: 1, PageSize: 10}, ctx)
+			if apiErr != nil {
+				t.Fatalf("ListProducts() unexpected error = %v", apiErr)
+			}
+
+			got := rec.Header().Get(headerResultLarge)
+			if tt.wantLarge && got != "true" {
+				t.Errorf("ListProducts() %s = %q, want %q", headerResultLarge, got, "true")
+			}
+			if !tt.wantLarge && got != "" {
+				t.Errorf("ListProducts() %s = %q, want header absent", headerResultLarge, got)
+			}
+		})
+	}
+}
+
+func TestStreamProductsMaxRows(t *testing.T) {
+	const maxRows = 3
+
+	tests := []struct {
+		name          string
+		productCount  int
+		wantLines     int
+		wantTruncated bool
+	}{
+		{name: "below cap", productCount: 2, wantLines: 2},
+		{name: "exactly at cap", productCount: maxRows, wantLines: maxRows},
+		{name: "above cap", productCount: 10, wantLines: maxRows, wantTruncated: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := &mockService{
+				streamProductsFunc: func(_ context.Context, fn func(*domain.Product) error) error {
+					for i := range tt.productCount {
+						if err := fn(domain.New(fmt.Sprintf("id-%d", i), testSKU, "Product", "Description", 1000, domain.DefaultCurrency, "")); err != nil {
+							return err
+						}
+					}
+					return nil
+				},
+			}
+			handler := NewProductHandler(mockSvc, testutil.NewLogger(), WithStreamMaxRows(maxRows))
+
+			ctx, rec := testutil.NewRequestContext(testutil.NewConfig(), http.MethodGet, "/products/stream")
+
+			if err := handler.StreamProducts(ctx); err != nil {
+				t.Fatalf("StreamProducts() unexpected error = %v", err)
+			}
+
+			lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+			if len(lines) != tt.wantLines {
+				t.Errorf("StreamProducts() wrote %d lines, want %d", len(lines), tt.wantLines)
+			}
+
+			got := rec.Result().Trailer.Get(headerResultTruncated)
+			if tt.wantTruncated && got != "true" {
+				t.Errorf("StreamProducts() %s trailer = %q, want %q", headerResultTruncated, got, "true")
+			}
+			if !tt.wantTruncated && got != "" {
+				t.Errorf("StreamProducts() %s trailer = %q, want absent", headerResultTruncated, got)
+			}
+		})
+	}
+}
+
+func TestStreamProductsIncludeDeleted(t *testing.T) {
+	const adminToken = "s3cret"
+
+	live := []*domain.Product{
+		domain.New("id-1", testSKU, "Live", "Description", 1000, domain.DefaultCurrency, ""),
+		domain.New("id-2", testSKU, "Also live", "Description", 2000, domain.DefaultCurrency, ""),
+	}
+	deleted := domain.New("id-3", testSKU, "Deleted", "Description", 3000, domain.DefaultCurrency, "")
+
+	tests := []struct {
+		name        string
+		query       string
+		token       string
+		guard       *admin.Guard
+		wantStatus  int
+		wantDeleted bool
+		wantLines   int
+	}{
+		{
+			name:       "default export excludes deleted rows",
+			guard:      admin.NewGuard(admin.Config{Enabled: true, Token: adminToken}),
+			wantStatus: http.StatusOK,
+			wantLines:  len(live),
+		},
+		{
+			name:        "admin export includes deleted rows",
+			query:       "?includeDeleted=true",
+			token:       adminToken,
+			guard:       admin.NewGuard(admin.Config{Enabled: true, Token: adminToken}),
+			wantStatus:  http.StatusOK,
+			wantDeleted: true,
+			wantLines:   len(live) + 1,
+		},
+		{
+			name:       "includeDeleted without admin access",
+			query:      "?includeDeleted=true",
+			guard:      admin.NewGuard(admin.Config{}),
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "includeDeleted with wrong token",
+			query:      "?includeDeleted=true",
+			token:      "wrong",
+			guard:      admin.NewGuard(admin.Config{Enabled: true, Token: adminToken}),
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "invalid includeDeleted",
+			query:      "?includeDeleted=maybe",
+			guard:      admin.NewGuard(admin.Config{Enabled: true, Token: adminToken}),
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			mockSvc := &mockService{}
+			mockSvc.streamProductsFunc = func(_ context.Context, fn func(*domain.Product) error) error {
+				called = true
+				rows := live
+				if mockSvc.streamOpts.IncludeDeleted {
+					rows = append(append([]*domain.Product{}, live...), deleted)
+				}
+				for _, p := range rows {
+					if err := fn(p); err != nil {
+						return err
+					}
+				}
+				return nil
+			}
+			handler := NewProductHandler(mockSvc, testutil.NewLogger(), WithAdminGuard(tt.guard))
+
+			var opts []testutil.RequestOption
+			if tt.token != "" {
+				opts = append(opts, testutil.WithHeader(admin.HeaderToken, tt.token))
+			}
+			ctx, rec := testutil.NewRequestContext(testutil.NewConfig(), http.MethodGet, "/products/stream"+tt.query, opts...)
+
+			err := handler.StreamProducts(ctx)
+
+			if tt.wantStatus != http.StatusOK {
+				var apiErr server.IAPIError
+				if !errors.As(err, &apiErr) {
+					t.Fatalf("StreamProducts() error = %v, want server.IAPIError", err)
+				}
+				if apiErr.HTTPStatus() != tt.wantStatus {
+					t.Errorf("StreamProducts() status = %v, want %v", apiErr.HTTPStatus(), tt.wantStatus)
+				}
+				if called {
+					t.Error("StreamProducts() read products despite rejecting the request")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("StreamProducts() unexpected error = %v", err)
+			}
+			if mockSvc.streamOpts.IncludeDeleted != tt.wantDeleted {
+				t.Errorf("StreamProducts() IncludeDeleted = %v, want %v", mockSvc.streamOpts.IncludeDeleted, tt.wantDeleted)
+			}
+			lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+			if len(lines) != tt.wantLines {
+				t.Errorf("StreamProducts() wrote %d lines, want %d", len(lines), tt.wantLines)
+			}
+		})
+	}
+}
+
+func TestListProductsRejectsIncludeDeleted(t *testing.T) {
+	mockSvc := &mockService{
+		listProductsFunc: func(ctx context.Context, page, pageSize int) ([]*domain.Product, int, error) {
+			t.Error("ListProducts() queried the service despite includeDeleted")
+			return nil, 0, nil
+		},
+	}
+	handler := NewProductHandler(mockSvc, testutil.NewLogger())
+
+	ctx, _ := testutil.NewRequestContext(testutil.NewConfig(), http.MethodGet, "/products?page=1&pageSize=10&includeDeleted=true")
+
+	_, apiErr := handler.ListProducts(ListProductsRequest{Page: 1, PageSize: 10}, ctx)
+
+	if apiErr == nil {
+		t.Fatal("ListProducts() expected error for includeDeleted, got nil")
+	}
+	if apiErr.HTTPStatus() != http.StatusBadRequest {
+		t.Errorf("ListProducts() status = %v, want %v", apiErr.HTTPStatus(), http.StatusBadRequest)
+	}
+}
+
+func TestStreamProductsErrorBeforeFirstLine(t *testing.T) {
+	mockSvc := &mockService{
+		streamProductsFunc: func(context.Context, func(*domain.Product) error) error {
+			return fmt.Errorf("%w: database unavailable", service.ErrInternal)
+		},
+	}
+	handler := NewProductHandler(mockSvc, testutil.NewLogger())
+
+	ctx, rec := testutil.NewRequestContext(testutil.NewConfig(), http.MethodGet, "/products/stream")
+
+	err := handler.StreamProducts(ctx)
+
+	var apiErr server.IAPIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("StreamProducts() error = %v, want server.IAPIError", err)
+	}
+	if apiErr.HTTPStatus() != http.StatusInternalServerError {
+		t.Errorf("StreamProducts() status = %v, want %v", apiErr.HTTPStatus(), http.StatusInternalServerError)
+	}
+	if rec.Body.Len() != 0 {
+		t.Errorf("StreamProducts() wrote %d bytes before failing, want 0", rec.Body.Len())
+	}
+}
+
+func TestStreamProductsCompression(t *testing.T) {
+	const minBytes = 1024
+
+	tests := []struct {
+		name           string
+		productCount   int
+		acceptEncoding string
+		wantGzip       bool
+	}{
+		{name: "large response is gzip encoded", productCount: 50, acceptEncoding: "gzip, deflate", wantGzip: true},
+		{name: "small response is not encoded", productCount: 1, acceptEncoding: "gzip", wantGzip: false},
+		{name: "client without gzip support", productCount: 50, acceptEncoding: "", wantGzip: false},
+		{name: "client refusing gzip", productCount: 50, acceptEncoding: "gzip;q=0", wantGzip: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := &mockService{
+				streamProductsFunc: func(_ context.Context, fn func(*domain.Product) error) error {
+					for i := range tt.productCount {
+						p := domain.New(fmt.Sprintf("id-%d", i), testSKU, "Product", "A reasonably long description", 999, domain.DefaultCurrency, "https://example.com/image.jpg")
+						if err := fn(p); err != nil {
+							return err
+						}
+					}
+					return nil
+				},
+			}
+			handler := NewProductHandler(mockSvc, testutil.NewLogger(), WithCompression(minBytes))
+
+			var opts []testutil.RequestOption
+			if tt.acceptEncoding != "" {
+				opts = append(opts, testutil.WithHeader("Accept-Encoding", tt.acceptEncoding))
+			}
+			ctx, rec := testutil.NewRequestContext(testutil.NewConfig(), http.MethodGet, "/products/stream", opts...)
+
+			if err := handler.StreamProducts(ctx); err != nil {
+				t.Fatalf("StreamProducts() unexpected error = %v", err)
+			}
+
+			gotGzip := rec.Header().Get("Content-Encoding") == "gzip"
+			if gotGzip != tt.wantGzip {
+				t.Fatalf("Content-Encoding gzip = %v, want %v", gotGzip, tt.wantGzip)
+			}
+
+			body := rec.Body.Bytes()
+			if gotGzip {
+				reader, err := gzip.NewReader(rec.Body)
+				if err != nil {
+					t.Fatalf("gzip.NewReader() error = %v", err)
+				}
+				if body, err = io.ReadAll(reader); err != nil {
+					t.Fatalf("reading gzip body: %v", err)
+				}
+			}
+
+			lines := strings.Split(strings.TrimRight(string(body), "\n"), "\n")
+			if len(lines) != tt.productCount {
+				t.Errorf("decoded %d lines, want %d", len(lines), tt.productCount)
+			}
+		})
+	}
+}
+
+func TestToProductResponse(t *testing.T) {
+	product := domain.New("test-id", testSKU, "Test Product", "Description", 9999, domain.DefaultCurrency, "https://example.com/image.jpg")
+
+	response := ToProductResponse(product)
+
+	if response == nil {
+		t.Fatal("ToProductResponse() returned nil")
+	}
+
+	if response.ID != product.ID {
+		t.Errorf("ToProductResponse() ID = %v, want %v", response.ID, product.ID)
+	}
+
+	if response.Name != product.Name {
+		t.Errorf("ToProductResponse() Name = %v, want %v", response.Name, product.Name)
+	}
+
+	if response.Price.String() != product.FormattedPrice() || response.Currency != product.Currency {
+		t.Errorf("ToProductResponse() Price = %v %s, want %s %s", response.Price, response.Currency, product.FormattedPrice(), product.Currency)
+	}
+
+	if response.CreatedDate == "" {
+		t.Error("ToProductResponse() CreatedDate is empty")
+	}
+
+	if response.UpdatedDate == "" {
+		t.Error("ToProductResponse() UpdatedDate is empty")
+	}
+}
+
+func TestProductResponseCategoryID(t *testing.T) {
+	product := domain.New("test-id", testSKU, "Test Product", "Description", 9999, domain.DefaultCurrency, "")
+
+	body, err := json.Marshal(ToProductResponse(product))
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if strings.Contains(string(body), "categoryId") {
+		t.Errorf("ProductResponse JSON = %s, want categoryId omitted for an uncategorized product", body)
+	}
+
+	product.CategoryID = testCategoryID
+	body, err = json.Marshal(ToProductResponse(product))
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if !strings.Contains(string(body), `"categoryId":"`+testCategoryID+`"`) {
+		t.Errorf("ProductResponse JSON = %s, want categoryId %s", body, testCategoryID)
+	}
+}
+
+func TestProductResponseJSONPricePrecision(t *testing.T) {
+	tests := []struct {
+		name       string
+		priceMinor int64
+		currency   string
+		wantPrice  string
+	}{
+		{name: "two decimal currency", priceMinor: 1999, currency: "USD", wantPrice: "19.99"},
+		{name: "trailing zero is kept", priceMinor: 1990, currency: "EUR", wantPrice: "19.90"},
+		{name: "zero decimal currency", priceMinor: 1500, currency: "JPY", wantPrice: "1500"},
+		{name: "three decimal currency", priceMinor: 1500, currency: "KWD", wantPrice: "1.500"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := ToProductResponse(domain.New(testID, testSKU, "Mug", "", tt.priceMinor, tt.currency, ""))
+
+			body, err := json.Marshal(response)
+			if err != nil {
+				t.Fatalf("json.Marshal() error = %v", err)
+			}
+
+			var raw map[string]json.RawMessage
+			if err := json.Unmarshal(body, &raw); err != nil {
+				t.Fatalf("json.Unmarshal() error = %v", err)
+			}
+
+			if got := string(raw["price"]); got != tt.wantPrice {
+				t.Errorf("price = %s, want %s", got, tt.wantPrice)
+			}
+			if _, ok := raw["id"]; !ok {
+				t.Error("marshaled response is missing id")
+			}
+		})
+	}
+}
+
+// testEntities returns n product entities as the repository scans them:
+// timestamps in the driver's zone and every other product in a category.
+func testEntities(n int) []*domain.ProductEntity {
+	created := time.Date(2024, 3, 1, 9, 0, 0, 0, time.FixedZone("UTC+2", 2*60*60))
+	categoryID := "category-1"
+	entities := make([]*domain.ProductEntity, n)
+	for i := range entities {
+		entities[i] = &domain.ProductEntity{
+			ID:            fmt.Sprintf("product-%d", i),
+			SKU:           fmt.Sprintf("SKU-%d", i),
+			Name:          fmt.Sprintf("Product %d", i),
+			Description:   "Benchmark product",
+			PriceMinor:    int64(i)*100 + 99,
+			Currency:      domain.DefaultCurrency,
+			ImageURL:      "https://example.com/image.png",
+			CreatedDate:   created,
+			UpdatedDate:   created.Add(time.Duration(i) * time.Minute),
+			Version:       i + 1,
+			Locked:        i%3 == 0,
+			StockQuantity: i,
+		}
+		if i%2 == 0 {
+			entities[i].CategoryID = &categoryID
+		}
+	}
+	return entities
+}
+
+// toProductResponsesPerItem is the mapping the list handlers used before
+// ToProductResponseList: one *ProductResponse per product, copied into the slice.
+func toProductResponsesPerItem(products []*domain.Product) []ProductResponse {
+	responses := make([]ProductResponse, len(products))
+	for i, p := range products {
+		responses[i] = *ToProductResponse(p)
+	}
+	return responses
+}
+
+func TestToProductResponseList(t *testing.T) {
+	products := domain.ToProductList(testEntities(25))
+
+	got := ToProductResponseList(products)
+	want := toProductResponsesPerItem(products)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ToProductResponseList() = %v, want %v", got, want)
+	}
+
+	if got := ToProductResponseList(nil); got == nil || len(got) != 0 {
+		t.Errorf("ToProductResponseList(nil) = %#v, want an empty, non-nil slice", got)
+	}
+}
+
+func BenchmarkProductListMapping(b *testing.B) {
+	entities := testEntities(100)
+
+	b.Run("per item", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			_ = toProductResponsesPerItem(domain.ToProductList(entities))
+		}
+	})
+	b.Run("list", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			_ = ToProductResponseList(domain.ToProductList(entities))
+		}
+	})
+}
+
+// reportRunnerFunc adapts a function to ReportRunner
+type reportRunnerFunc func(ctx context.Context) (job.ReportResult, error)
+
+func (f reportRunnerFunc) Run(ctx context.Context) (job.ReportResult, error) { return f(ctx) }
+
+func TestRunReport(t *testing.T) {
+	const adminToken = "s3cret"
+	guard := admin.NewGuard(admin.Config{Enabled: true, Token: adminToken})
+	uploaded := job.ReportResult{Destination: "products/products-20250102T030405Z.txt", Rows: 42}
+
+	tests := []struct {
+		name       string
+		token      string
+		disabled   bool
+		runErr     error
+		wantStatus int
+		wantCalled bool
+	}{
+		{name: "uploads the report", token: adminToken, wantStatus: http.StatusOK, wantCalled: true},
+		{name: "without admin token", wantStatus: http.StatusUnauthorized},
+		{name: "run in progress", token: adminToken, runErr: job.ErrReportRunning, wantStatus: http.StatusConflict, wantCalled: true},
+		{name: "upload failure", token: adminToken, runErr: errors.New("connection reset"), wantStatus: http.StatusInternalServerError, wantCalled: true},
+		{name: "report disabled", token: adminToken, disabled: true, wantStatus: http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			opts := []HandlerOption{WithAdminGuard(guard)}
+			if !tt.disabled {
+				opts = append(opts, WithReportRunner(reportRunnerFunc(func(context.Context) (job.ReportResult, error) {
+					called = true
+					if tt.runErr != nil {
+						return job.ReportResult{}, tt.runErr
+					}
+					return uploaded, nil
+				})))
+			}
+			handler := NewProductHandler(&mockService{}, testutil.NewLogger(), opts...)
+
+			var reqOpts []testutil.RequestOption
+			if tt.token != "" {
+				reqOpts = append(reqOpts, testutil.WithHeader(admin.HeaderToken, tt.token))
+			}
+			ctx, _ := testutil.NewRequestContext(testutil.NewConfig(), http.MethodPost, "/products/report/run", reqOpts...)
+
+			resp, apiErr := handler.RunReport(RunReportRequest{}, ctx)
+
+			if called != tt.wantCalled {
+				t.Fatalf("report run = %v, want %v", called, tt.wantCalled)
+			}
+			if tt.wantStatus != http.StatusOK {
+				if apiErr == nil || apiErr.HTTPStatus() != tt.wantStatus {
+					t.Fatalf("RunReport() error = %v, want status %d", apiErr, tt.wantStatus)
+				}
+				return
+			}
+			if apiErr != nil {
+				t.Fatalf("RunReport() unexpected error = %v", apiErr)
+			}
+			want := RunReportResponse{Destination: uploaded.Destination, Rows: uploaded.Rows}
+			if *resp != want {
+				t.Errorf("RunReport() = %+v, want %+v", *resp, want)
+			}
+		})
 	}
 }
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/shared/ttlcache"
+	"github.com/gaborage/go-bricks/multitenant"
+	"github.com/gaborage/go-bricks/server"
+)
+
+const (
+	// headerIdempotencyKey lets clients retry POST /products safely: a
+	// replayed key returns the original response instead of a new product.
+	headerIdempotencyKey = "Idempotency-Key"
+
+	// maxIdempotencyKeyLength bounds the keys clients may send.
+	maxIdempotencyKeyLength = 255
+)
+
+// IdempotencyRecord is the outcome of a create remembered under an
+// idempotency key.
+type IdempotencyRecord struct {
+	ProductID string
+	// Fingerprint identifies the request body; a replay with a different
+	// body is rejected.
+	Fingerprint string
+	Response    ProductResponse
+}
+
+// IdempotencyStore remembers create outcomes by idempotency key.
+type IdempotencyStore interface {
+	Get(key string) (IdempotencyRecord, bool)
+	Set(key string, record IdempotencyRecord)
+}
+
+// MemoryIdempotencyStore keeps records in process for a TTL, on top of
+// ttlcache.Cache. Records are lost on restart and not shared between
+// instances, so replays are only caught by the instance that served the
+// original request.
+type MemoryIdempotencyStore struct {
+	cache *ttlcache.Cache
+}
+
+// NewMemoryIdempotencyStore creates a store keeping up to maxSize records for ttl.
+func NewMemoryIdempotencyStore(ttl time.Duration, maxSize int) *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{cache: ttlcache.New(ttl, maxSize)}
+}
+
+// Get returns the record stored under key, if it has not expired.
+func (s *MemoryIdempotencyStore) Get(key string) (IdempotencyRecord, bool) {
+	record, ok := s.cache.Get(key).(IdempotencyRecord)
+	return record, ok
+}
+
+// Set stores record under key for the store's TTL.
+func (s *MemoryIdempotencyStore) Set(key string, record IdempotencyRecord) {
+	s.cache.Set(key, record)
+}
+
+// Close stops the store's background cleanup.
+func (s *MemoryIdempotencyStore) Close() {
+	s.cache.Close()
+}
+
+// WithIdempotencyStore enables the Idempotency-Key header on POST /products,
+// remembering outcomes in store. Without a store the header is ignored.
+func WithIdempotencyStore(store IdempotencyStore) HandlerOption {
+	return func(h *ProductHandler) {
+		h.idempotency = store
+	}
+}
+
+// idempotentOutcome is the shared result of concurrent creates with one key.
+type idempotentOutcome struct {
+	record IdempotencyRecord
+	apiErr server.IAPIError
+}
+
+// createIdempotent runs create once per key: a stored record with the same
+// request fingerprint is replayed as the original 201, a different one is a
+// 409. Concurrent requests with the same key wait for the first one. Failed
+// creates are not remembered, so the client may retry them.
+func (h *ProductHandler) createIdempotent(ctx server.HandlerContext, key string, req CreateProductRequest,
+	create func() (*ProductResponse, server.IAPIError)) (*ProductResponse, server.IAPIError) {
+	if len(key) > maxIdempotencyKeyLength {
+		return nil, server.NewBadRequestError(fmt.Sprintf("%s must be at most %d characters", headerIdempotencyKey, maxIdempotencyKeyLength))
+	}
+
+	fingerprint := requestFingerprint(req)
+	tenantID, _ := multitenant.GetTenant(ctx.RequestContext())
+	scopedKey := tenantID + "\x00" + key
+
+	v, _, _ := h.idempotencyFlight.Do(scopedKey, func() (any, error) {
+		if record, ok := h.idempotency.Get(scopedKey); ok {
+			return idempotentOutcome{record: record}, nil
+		}
+		response, apiErr := create()
+		if apiErr != nil {
+			return idempotentOutcome{apiErr: apiErr}, nil
+		}
+		record := IdempotencyRecord{ProductID: response.ID, Fingerprint: fingerprint, Response: *response}
+		h.idempotency.Set(scopedKey, record)
+		return idempotentOutcome{record: record}, nil
+	})
+	outcome := v.(idempotentOutcome)
+
+	if outcome.apiErr != nil {
+		return nil, outcome.apiErr
+	}
+	if outcome.record.Fingerprint != fingerprint {
+		return nil, server.NewConflictError(headerIdempotencyKey + " was already used with a different request body")
+	}
+	response := outcome.record.Response
+	return &response, nil
+}
+
+// requestFingerprint hashes the create request so replays can be compared.
+func requestFingerprint(req CreateProductRequest) string {
+	data, _ := json.Marshal(req) //nolint:errchkjson // plain struct of strings and a float
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gaborage/go-bricks/server"
+)
+
+const (
+	// queryLinks adds hypermedia links to product responses.
+	queryLinks = "links"
+	// halContentType in Accept asks for hypermedia links like ?links=true.
+	halContentType = "application/hal+json"
+
+	headerAccept         = "Accept"
+	headerForwardedProto = "X-Forwarded-Proto"
+)
+
+// Link is a hypermedia link. Method is set for links that are not followed
+// with GET.
+type Link struct {
+	Href   string `json:"href"`
+	Method string `json:"method,omitempty"`
+}
+
+// ProductLinks are the links of a product: where to read, update and delete it.
+type ProductLinks struct {
+	Self   Link `json:"self"`
+	Update Link `json:"update"`
+	Delete Link `json:"delete"`
+}
+
+// PageLinks are the links of a product list page. Next and Prev are omitted
+// on the last and first page; cursor pages have no Prev.
+type PageLinks struct {
+	Self Link  `json:"self"`
+	Next *Link `json:"next,omitempty"`
+	Prev *Link `json:"prev,omitempty"`
+}
+
+// WithBasePath sets the path the routes are mounted under, server.path.base,
+// so hypermedia links point at the real endpoints.
+func WithBasePath(basePath string) HandlerOption {
+	return func(h *ProductHandler) {
+		basePath = strings.Trim(basePath, "/")
+		if basePath == "" {
+			h.basePath = ""
+			return
+		}
+		h.basePath = "/" + basePath
+	}
+}
+
+// linksRequested reports whether the request asks for hypermedia links with
+// ?links=true or an Accept header naming application/hal+json. Because
+// Accept can change the body, the response is marked Vary: Accept.
+func linksRequested(ctx server.HandlerContext) (bool, *server.BadRequestError) {
+	ctx.ResponseWriter().Header().Add(headerVary, headerAccept)
+
+	links, badRequest := queryBool(ctx, queryLinks)
+	if badRequest != nil || links {
+		return links, badRequest
+	}
+	for _, accepted := range strings.Split(ctx.RequestHeader(headerAccept), ",") {
+		mediaType, _, err := mime.ParseMediaType(accepted)
+		if err == nil && mediaType == halContentType {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// requestOrigin returns the scheme and host the client used, such as
+// https://api.example.com. X-Forwarded-Proto from a TLS-terminating proxy
+// overrides the scheme of the connection.
+func requestOrigin(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := strings.ToLower(r.Header.Get(headerForwardedProto)); proto == "http" || proto == "https" {
+		scheme = proto
+	}
+	return scheme + "://" + r.Host
+}
+
+// productLinks returns the links of the product with id, as absolute URLs
+// on the origin of ctx's request.
+func (h *ProductHandler) productLinks(ctx server.HandlerContext, id string) *ProductLinks {
+	href := requestOrigin(ctx.Request()) + h.basePath + "/products/" + url.PathEscape(id)
+	return &ProductLinks{
+		Self:   Link{Href: href},
+		Update: Link{Href: href, Method: http.MethodPut},
+		Delete: Link{Href: href, Method: http.MethodDelete},
+	}
+}
+
+// addLinks sets the links of every product on the page and the page's
+// navigation links. Page links keep the request's other query parameters,
+// such as filters, and change only page or cursor.
+func (h *ProductHandler) addLinks(ctx server.HandlerContext, resp *ListProductsResponse) {
+	for i := range resp.Products {
+		resp.Products[i].Links = h.productLinks(ctx, resp.Products[i].ID)
+	}
+
+	r := ctx.Request()
+	pageURL := func(param, value string) *Link {
+		query := r.URL.Query()
+		if value != "" {
+			query.Set(param, value)
+		}
+		u := url.URL{Path: r.URL.Path, RawQuery: query.Encode()}
+		return &Link{Href: requestOrigin(r) + u.String()}
+	}
+
+	links := &PageLinks{Self: *pageURL("", "")}
+	switch {
+	case resp.NextCursor != "":
+		links.Next = pageURL(queryCursor, resp.NextCursor)
+	case resp.Page > 0:
+		if resp.Page*resp.PageSize < resp.Total {
+			links.Next = pageURL("page", strconv.Itoa(resp.Page+1))
+		}
+		if resp.Page > 1 {
+			links.Prev = pageURL("page", strconv.Itoa(resp.Page-1))
+		}
+	}
+	resp.Links = links
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/products/repository"
+	"github.com/gaborage/go-bricks/server"
+)
+
+const (
+	// queryIncludeDeleted asks the export for soft-deleted products as well.
+	queryIncludeDeleted = "includeDeleted"
+	// queryCursor switches GET /products to keyset pagination.
+	queryCursor = "cursor"
+	// queryIncludeStats adds a zeroed view stats baseline to create responses.
+	queryIncludeStats = "includeStats"
+	// queryPartial lets a batch create insert its valid items and report the rest.
+	queryPartial = "partial"
+	// queryIncludeChanges adds the changed fields to update responses.
+	queryIncludeChanges = "includeChanges"
+)
+
+var (
+	// listProductsQueryParams is the allowlist for GET /products in strict mode.
+	listProductsQueryParams = []string{"page", "pageSize", queryCursor, "q", "sortBy", "sortOrder", "currency", "categoryId", "minPrice", "maxPrice", queryLinks}
+	// streamQueryParams is the allowlist for GET /products/stream in strict mode.
+	streamQueryParams = []string{queryIncludeDeleted}
+)
+
+// checkQueryParams rejects query parameters outside allowed when strict query
+// mode is on. Names are matched exactly, so a typo such as "pagesize" is
+// reported instead of being silently ignored.
+func (h *ProductHandler) checkQueryParams(r *http.Request, allowed []string) *server.BadRequestError {
+	if !h.strictQueryParams {
+		return nil
+	}
+
+	unknown := unknownQueryParams(r, allowed)
+	if len(unknown) == 0 {
+		return nil
+	}
+	return server.NewBadRequestError("Unknown query parameters: " + strings.Join(unknown, ", "))
+}
+
+// exportOptions parses the export's query flags. includeDeleted exposes
+// deleted products, so it is only honoured for admin requests.
+func (h *ProductHandler) exportOptions(ctx server.HandlerContext) (repository.StreamOptions, error) {
+	var opts repository.StreamOptions
+
+	includeDeleted, apiErr := queryBool(ctx, queryIncludeDeleted)
+	if apiErr != nil {
+		return opts, apiErr
+	}
+	if includeDeleted {
+		if authErr := h.guard.Authorize(ctx); authErr != nil {
+			return opts, authErr
+		}
+	}
+
+	opts.IncludeDeleted = includeDeleted
+	return opts, nil
+}
+
+// queryBool parses an optional boolean query parameter; absent means false.
+func queryBool(ctx server.HandlerContext, name string) (bool, *server.BadRequestError) {
+	raw := ctx.Query(name)
+	if raw == "" {
+		return false, nil
+	}
+
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, server.NewBadRequestError(name + " must be true or false")
+	}
+	return value, nil
+}
+
+// unknownQueryParams returns the sorted names of query parameters not in allowed.
+func unknownQueryParams(r *http.Request, allowed []string) []string {
+	var unknown []string
+	for name := range r.URL.Query() {
+		if !slices.Contains(allowed, name) {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gaborage/go-bricks/server"
+)
+
+// headerRawResponse lets a client opt out of the APIResponse envelope per
+// request, for consumers migrating to it gradually.
+const headerRawResponse = "X-Raw-Response"
+
+// wantsRawResponse reports whether the request sent X-Raw-Response with a
+// true value. Values strconv.ParseBool rejects are treated as false.
+func wantsRawResponse(ctx server.HandlerContext) bool {
+	raw, err := strconv.ParseBool(ctx.RequestHeader(headerRawResponse))
+	return err == nil && raw
+}
+
+// rawResponse is route middleware that serves requests carrying
+// X-Raw-Response: true with the plain handler raw instead of the typed route.
+// Typed routes choose envelope or raw when they are registered, so the switch
+// has to happen before the typed handler runs. Other requests go to next and
+// are enveloped as usual.
+func rawResponse(raw server.Handler) server.MiddlewareFunc {
+	return func(ctx server.HandlerContext, next func() error) error {
+		if !wantsRawResponse(ctx) {
+			return next()
+		}
+		return raw(ctx)
+	}
+}
+
+// getProductRaw serves GET /products/:id without the envelope, writing the
+// ProductResponse as the whole body. Errors keep the framework's error format,
+// and conditional requests get the same ETag and 304 as the typed route.
+func (h *ProductHandler) getProductRaw(ctx server.HandlerContext) error {
+	result, apiErr := h.GetProduct(GetProductRequest{ID: ctx.Param("id")}, ctx)
+	if apiErr != nil {
+		if err, ok := apiErr.(error); ok {
+			return err
+		}
+		return server.NewInternalServerError("Failed to retrieve product")
+	}
+	for k, vals := range result.Headers {
+		for _, v := range vals {
+			ctx.ResponseWriter().Header().Add(k, v)
+		}
+	}
+	if result.Status == http.StatusNotModified {
+		ctx.ResponseWriter().WriteHeader(http.StatusNotModified)
+		return nil
+	}
+	return ctx.JSON(result.Status, result.Data)
+}
@@ -0,0 +1,106 @@
+package job
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/products/domain"
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/products/repository"
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/products/service"
+	"github.com/gaborage/go-bricks/messaging"
+	"github.com/gaborage/go-bricks/outbox"
+	"github.com/gaborage/go-bricks/scheduler"
+)
+
+// LowStockEventType is the routing key of the messages LowStockJob publishes
+// on service.EventsExchange.
+const LowStockEventType = "product.low_stock"
+
+// Publisher sends messages. It is satisfied by *publisher.Publisher.
+type Publisher interface {
+	Publish(ctx context.Context, options messaging.PublishOptions, data []byte) error
+}
+
+// LowStockEvent is the payload of a product.low_stock message.
+type LowStockEvent struct {
+	ID            string `json:"id"`
+	SKU           string `json:"sku"`
+	Name          string `json:"name"`
+	StockQuantity int    `json:"stockQuantity"`
+	// Threshold is the stock level the product fell below.
+	Threshold int `json:"threshold"`
+}
+
+// LowStockJob publishes a product.low_stock message for every product with
+// fewer than threshold units in stock. It runs on every tick, so a product
+// is reported again each run until it is restocked.
+type LowStockJob struct {
+	repo      Repository
+	events    Publisher
+	threshold int
+}
+
+// NewLowStockJob creates a job reporting the products in repo with fewer than
+// threshold units in stock through events.
+func NewLowStockJob(repo Repository, events Publisher, threshold int) *LowStockJob {
+	return &LowStockJob{
+		repo:      repo,
+		events:    events,
+		threshold: threshold,
+	}
+}
+
+// Execute implements scheduler.Job. Every low-stock product is published even
+// when some publishes fail; the run then fails with the first error.
+func (j *LowStockJob) Execute(ctx scheduler.JobContext) error {
+	logger := ctx.Logger()
+
+	found, failed := 0, 0
+	var firstErr error
+	err := j.repo.Stream(ctx, repository.StreamOptions{StockBelow: j.threshold}, func(p *domain.Product) error {
+		found++
+		if err := j.publish(ctx, p); err != nil {
+			logger.Warn().Err(err).Str("productID", p.ID).Msg("Failed to publish low-stock event")
+			failed++
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read low-stock products: %w", err)
+	}
+
+	logger.Info().
+		Str("jobID", ctx.JobID()).
+		Int("threshold", j.threshold).
+		Int("lowStock", found).
+		Msg("Low-stock check completed")
+
+	if firstErr != nil {
+		return fmt.Errorf("failed to publish %d of %d low-stock events: %w", failed, found, firstErr)
+	}
+	return nil
+}
+
+// publish sends the product.low_stock message for p.
+func (j *LowStockJob) publish(ctx context.Context, p *domain.Product) error {
+	data, err := json.Marshal(LowStockEvent{
+		ID:            p.ID,
+		SKU:           p.SKU,
+		Name:          p.Name,
+		StockQuantity: p.StockQuantity,
+		Threshold:     j.threshold,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode low-stock event: %w", err)
+	}
+
+	return j.events.Publish(ctx, messaging.PublishOptions{
+		Exchange:   service.EventsExchange,
+		RoutingKey: LowStockEventType,
+		Headers:    map[string]any{outbox.HeaderEventType: LowStockEventType},
+	}, data)
+}
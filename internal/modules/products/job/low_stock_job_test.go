@@ -0,0 +1,90 @@
+package job
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/products/domain"
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/products/service"
+	"github.com/gaborage/go-bricks/messaging"
+)
+
+// recordingPublisher keeps every published message and fails the ones whose
+// product ID is in failIDs
+type recordingPublisher struct {
+	options []messaging.PublishOptions
+	events  []LowStockEvent
+	failIDs map[string]bool
+}
+
+func (p *recordingPublisher) Publish(_ context.Context, options messaging.PublishOptions, data []byte) error {
+	var event LowStockEvent
+	if err := json.Unmarshal(data, &event); err != nil {
+		return err
+	}
+	p.options = append(p.options, options)
+	p.events = append(p.events, event)
+	if p.failIDs[event.ID] {
+		return errors.New("channel closed")
+	}
+	return nil
+}
+
+func lowStockProduct(id string, stock int) *domain.Product {
+	p := domain.New(id, "SKU-"+id, "Product "+id, "", 100, domain.DefaultCurrency, "")
+	p.StockQuantity = stock
+	return p
+}
+
+func TestLowStockJobExecute(t *testing.T) {
+	repo := &streamRepository{products: []*domain.Product{lowStockProduct("p-1", 0), lowStockProduct("p-2", 4)}}
+	events := &recordingPublisher{}
+	job := NewLowStockJob(repo, events, 5)
+
+	if err := job.Execute(testJobContext{context.Background()}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	if repo.opts.StockBelow != 5 {
+		t.Errorf("Stream() StockBelow = %d, want the threshold 5", repo.opts.StockBelow)
+	}
+	if len(events.events) != 2 {
+		t.Fatalf("published %d events, want one per low-stock product", len(events.events))
+	}
+	want := LowStockEvent{ID: "p-2", SKU: "SKU-p-2", Name: "Product p-2", StockQuantity: 4, Threshold: 5}
+	if events.events[1] != want {
+		t.Errorf("event = %+v, want %+v", events.events[1], want)
+	}
+	if opts := events.options[0]; opts.Exchange != service.EventsExchange || opts.RoutingKey != LowStockEventType {
+		t.Errorf("published to %s/%s, want %s/%s", opts.Exchange, opts.RoutingKey, service.EventsExchange, LowStockEventType)
+	}
+}
+
+func TestLowStockJobExecuteErrors(t *testing.T) {
+	t.Run("publish failure still publishes the rest", func(t *testing.T) {
+		repo := &streamRepository{products: []*domain.Product{lowStockProduct("p-1", 0), lowStockProduct("p-2", 1)}}
+		events := &recordingPublisher{failIDs: map[string]bool{"p-1": true}}
+		job := NewLowStockJob(repo, events, 5)
+
+		if err := job.Execute(testJobContext{context.Background()}); err == nil {
+			t.Error("Execute() error = nil, want the publish error")
+		}
+		if len(events.events) != 2 {
+			t.Errorf("published %d events, want 2", len(events.events))
+		}
+	})
+
+	t.Run("query failure publishes nothing", func(t *testing.T) {
+		events := &recordingPublisher{}
+		job := NewLowStockJob(&streamRepository{err: errors.New("connection refused")}, events, 5)
+
+		if err := job.Execute(testJobContext{context.Background()}); err == nil {
+			t.Error("Execute() error = nil, want the query error")
+		}
+		if len(events.events) != 0 {
+			t.Errorf("published %d events, want none", len(events.events))
+		}
+	})
+}
@@ -0,0 +1,205 @@
+package job
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/products/domain"
+)
+
+// ReportFormat selects the file format of the product report.
+type ReportFormat string
+
+const (
+	// ReportFormatText is a tab-delimited text file with a header line. It is
+	// the default.
+	ReportFormatText ReportFormat = "txt"
+	// ReportFormatCSV is an RFC 4180 CSV file with a header record.
+	ReportFormatCSV ReportFormat = "csv"
+	// ReportFormatJSON is a JSON array with one object per product.
+	ReportFormatJSON ReportFormat = "json"
+)
+
+// ErrInvalidReportFormat is returned by ParseReportFormat for an unknown format.
+var ErrInvalidReportFormat = errors.New("invalid report format")
+
+// ParseReportFormat converts a configured format name to a ReportFormat. An
+// empty name is ReportFormatText.
+func ParseReportFormat(name string) (ReportFormat, error) {
+	switch ReportFormat(name) {
+	case "", ReportFormatText:
+		return ReportFormatText, nil
+	case ReportFormatCSV, ReportFormatJSON:
+		return ReportFormat(name), nil
+	default:
+		return "", fmt.Errorf("%w %q: want %q, %q or %q", ErrInvalidReportFormat, name, ReportFormatText, ReportFormatCSV, ReportFormatJSON)
+	}
+}
+
+// reportHeader names the report columns, in reportFields order.
+var reportHeader = []string{"id", "sku", "name", "description", "price", "currency", "image_url", "created_date", "updated_date"}
+
+// fieldSanitizer keeps free-text values on one line and in one column.
+var fieldSanitizer = strings.NewReplacer("\t", " ", "\r", " ", "\n", " ")
+
+// reportFields returns the values of p's report columns.
+func reportFields(p *domain.Product) []string {
+	return []string{
+		p.ID,
+		p.SKU,
+		p.Name,
+		p.Description,
+		p.FormattedPrice(),
+		p.Currency,
+		p.ImageURL,
+		reportTime(p.CreatedDate),
+		reportTime(p.UpdatedDate),
+	}
+}
+
+// reportTime renders t in UTC as RFC 3339.
+func reportTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// reportFormatter writes the products of one report run in a file format.
+// A new formatter is created for every run.
+type reportFormatter interface {
+	// extension is the destination file extension, without the dot.
+	extension() string
+	// contentType is the media type the report is uploaded with.
+	contentType() string
+	// begin writes what precedes the first product, such as a header.
+	begin(w io.Writer) error
+	// write writes one product.
+	write(w io.Writer, p *domain.Product) error
+	// end writes what follows the last product.
+	end(w io.Writer) error
+}
+
+// newReportFormatter returns a formatter for format.
+func newReportFormatter(format ReportFormat) (reportFormatter, error) {
+	switch format {
+	case "", ReportFormatText:
+		return textFormatter{}, nil
+	case ReportFormatCSV:
+		return csvFormatter{}, nil
+	case ReportFormatJSON:
+		return &jsonFormatter{}, nil
+	default:
+		return nil, fmt.Errorf("%w %q", ErrInvalidReportFormat, format)
+	}
+}
+
+// textFormatter writes tab-delimited lines. Tabs and line breaks inside
+// values are replaced with spaces.
+type textFormatter struct{}
+
+func (textFormatter) extension() string   { return "txt" }
+func (textFormatter) contentType() string { return "text/plain; charset=utf-8" }
+
+func (textFormatter) begin(w io.Writer) error {
+	_, err := io.WriteString(w, strings.Join(reportHeader, "\t")+"\n")
+	return err
+}
+
+func (textFormatter) write(w io.Writer, p *domain.Product) error {
+	fields := reportFields(p)
+	for i, f := range fields {
+		fields[i] = fieldSanitizer.Replace(f)
+	}
+	_, err := io.WriteString(w, strings.Join(fields, "\t")+"\n")
+	return err
+}
+
+func (textFormatter) end(io.Writer) error { return nil }
+
+// csvFormatter writes CSV records, quoting values as needed.
+type csvFormatter struct{}
+
+func (csvFormatter) extension() string   { return "csv" }
+func (csvFormatter) contentType() string { return "text/csv; charset=utf-8" }
+
+func (csvFormatter) begin(w io.Writer) error {
+	return writeCSVRecord(w, reportHeader)
+}
+
+func (csvFormatter) write(w io.Writer, p *domain.Product) error {
+	return writeCSVRecord(w, reportFields(p))
+}
+
+func (csvFormatter) end(io.Writer) error { return nil }
+
+func writeCSVRecord(w io.Writer, record []string) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(record); err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// jsonFormatter writes a JSON array of objects keyed by the report columns.
+// Prices are JSON numbers with the currency's exact decimals.
+type jsonFormatter struct {
+	written int
+}
+
+// jsonReportProduct is one element of the JSON report.
+type jsonReportProduct struct {
+	ID          string      `json:"id"`
+	SKU         string      `json:"sku"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Price       json.Number `json:"price"`
+	Currency    string      `json:"currency"`
+	ImageURL    string      `json:"image_url"`
+	CreatedDate string      `json:"created_date"`
+	UpdatedDate string      `json:"updated_date"`
+}
+
+func (*jsonFormatter) extension() string   { return "json" }
+func (*jsonFormatter) contentType() string { return "application/json" }
+
+func (*jsonFormatter) begin(w io.Writer) error {
+	_, err := io.WriteString(w, "[")
+	return err
+}
+
+func (f *jsonFormatter) write(w io.Writer, p *domain.Product) error {
+	data, err := json.Marshal(jsonReportProduct{
+		ID:          p.ID,
+		SKU:         p.SKU,
+		Name:        p.Name,
+		Description: p.Description,
+		Price:       json.Number(p.FormattedPrice()),
+		Currency:    p.Currency,
+		ImageURL:    p.ImageURL,
+		CreatedDate: reportTime(p.CreatedDate),
+		UpdatedDate: reportTime(p.UpdatedDate),
+	})
+	if err != nil {
+		return err
+	}
+	sep := ",\n"
+	if f.written == 0 {
+		sep = "\n"
+	}
+	f.written++
+	_, err = io.WriteString(w, sep+string(data))
+	return err
+}
+
+func (f *jsonFormatter) end(w io.Writer) error {
+	closing := "\n]\n"
+	if f.written == 0 {
+		closing = "]\n"
+	}
+	_, err := io.WriteString(w, closing)
+	return err
+}
@@ -0,0 +1,134 @@
+package job
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/products/domain"
+)
+
+// formatterProducts is the small product slice every formatter test writes.
+func formatterProducts() []*domain.Product {
+	created := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	first := domain.New("p-1", "MUG-001", "Mug, \"large\"", "Tab\there\nand a line break", 950, domain.DefaultCurrency, "https://example.com/p.jpg")
+	second := domain.New("p-2", "CUP-002", "Cup", "", 1200, "JPY", "")
+	for _, p := range []*domain.Product{first, second} {
+		p.CreatedDate, p.UpdatedDate = created, created
+	}
+	return []*domain.Product{first, second}
+}
+
+// formatReport runs formatter over products.
+func formatReport(t *testing.T, formatter reportFormatter, products []*domain.Product) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := formatter.begin(&buf); err != nil {
+		t.Fatalf("begin() error = %v", err)
+	}
+	for _, p := range products {
+		if err := formatter.write(&buf, p); err != nil {
+			t.Fatalf("write(%s) error = %v", p.ID, err)
+		}
+	}
+	if err := formatter.end(&buf); err != nil {
+		t.Fatalf("end() error = %v", err)
+	}
+	return buf.String()
+}
+
+func TestTextFormatter(t *testing.T) {
+	got := formatReport(t, textFormatter{}, formatterProducts())
+
+	want := "id\tsku\tname\tdescription\tprice\tcurrency\timage_url\tcreated_date\tupdated_date\n" +
+		"p-1\tMUG-001\tMug, \"large\"\tTab here and a line break\t9.50\tUSD\thttps://example.com/p.jpg\t2025-01-02T03:04:05Z\t2025-01-02T03:04:05Z\n" +
+		"p-2\tCUP-002\tCup\t\t1200\tJPY\t\t2025-01-02T03:04:05Z\t2025-01-02T03:04:05Z\n"
+	if got != want {
+		t.Errorf("report = %q, want %q", got, want)
+	}
+}
+
+func TestCSVFormatter(t *testing.T) {
+	got := formatReport(t, csvFormatter{}, formatterProducts())
+
+	want := "id,sku,name,description,price,currency,image_url,created_date,updated_date\n" +
+		"p-1,MUG-001,\"Mug, \"\"large\"\"\",\"Tab\there\nand a line break\",9.50,USD,https://example.com/p.jpg,2025-01-02T03:04:05Z,2025-01-02T03:04:05Z\n" +
+		"p-2,CUP-002,Cup,,1200,JPY,,2025-01-02T03:04:05Z,2025-01-02T03:04:05Z\n"
+	if got != want {
+		t.Errorf("report = %q, want %q", got, want)
+	}
+}
+
+func TestJSONFormatter(t *testing.T) {
+	got := formatReport(t, &jsonFormatter{}, formatterProducts())
+
+	var decoded []map[string]any
+	decoder := json.NewDecoder(bytes.NewReader([]byte(got)))
+	decoder.UseNumber()
+	if err := decoder.Decode(&decoded); err != nil {
+		t.Fatalf("report is not a JSON array: %v\n%s", err, got)
+	}
+	if len(decoded) != 2 {
+		t.Fatalf("report has %d products, want 2", len(decoded))
+	}
+	first := decoded[0]
+	if first["id"] != "p-1" || first["name"] != "Mug, \"large\"" || first["description"] != "Tab\there\nand a line break" {
+		t.Errorf("first product = %v, want the values unchanged", first)
+	}
+	if first["price"] != json.Number("9.50") || decoded[1]["price"] != json.Number("1200") {
+		t.Errorf("prices = %v, %v, want 9.50 and 1200", first["price"], decoded[1]["price"])
+	}
+	if first["created_date"] != "2025-01-02T03:04:05Z" {
+		t.Errorf("created_date = %v, want 2025-01-02T03:04:05Z", first["created_date"])
+	}
+
+	if empty := formatReport(t, &jsonFormatter{}, nil); empty != "[]\n" {
+		t.Errorf("empty report = %q, want []", empty)
+	}
+}
+
+func TestParseReportFormat(t *testing.T) {
+	for name, want := range map[string]ReportFormat{"": ReportFormatText, "txt": ReportFormatText, "csv": ReportFormatCSV, "json": ReportFormatJSON} {
+		if got, err := ParseReportFormat(name); err != nil || got != want {
+			t.Errorf("ParseReportFormat(%q) = %q, %v, want %q", name, got, err, want)
+		}
+	}
+	if _, err := ParseReportFormat("parquet"); !errors.Is(err, ErrInvalidReportFormat) {
+		t.Errorf("ParseReportFormat(parquet) error = %v, want ErrInvalidReportFormat", err)
+	}
+}
+
+func TestReportJobFormats(t *testing.T) {
+	tests := []struct {
+		format          ReportFormat
+		wantDestination string
+		wantContentType string
+	}{
+		{format: ReportFormatText, wantDestination: "reports/products-20250102T030405Z.txt", wantContentType: "text/plain; charset=utf-8"},
+		{format: ReportFormatCSV, wantDestination: "reports/products-20250102T030405Z.csv", wantContentType: "text/csv; charset=utf-8"},
+		{format: ReportFormatJSON, wantDestination: "reports/products-20250102T030405Z.json", wantContentType: "application/json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			uploader := &recordingUploader{}
+			job := NewReportJob(&streamRepository{products: formatterProducts()}, uploader, "reports", tt.format, 0)
+			job.now = func() time.Time { return time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC) }
+
+			result, err := job.Run(context.Background())
+
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if uploader.path != tt.wantDestination || uploader.contentType != tt.wantContentType {
+				t.Errorf("uploaded %s as %q, want %s as %q", uploader.path, uploader.contentType, tt.wantDestination, tt.wantContentType)
+			}
+			if result.Rows != 2 || result.Destination != tt.wantDestination {
+				t.Errorf("Run() = %+v, want 2 rows at %s", result, tt.wantDestination)
+			}
+		})
+	}
+}
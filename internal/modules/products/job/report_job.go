@@ -1,22 +1,140 @@
 package job
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"sync/atomic"
+	"time"
+
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/products/domain"
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/products/repository"
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/shared/storage"
 	"github.com/gaborage/go-bricks/scheduler"
 )
 
-// ReportJob demonstrates publishing messages through JobContext
-type ReportJob struct{}
+// Repository reads the products a report lists. It is satisfied by
+// repository.ProductRepository.
+type Repository interface {
+	Stream(ctx context.Context, opts repository.StreamOptions, fn func(*domain.Product) error) error
+}
+
+// ErrReportRunning is returned by ReportJob.Run while another run of the same
+// job is in progress.
+var ErrReportRunning = errors.New("report run already in progress")
+
+// ReportResult describes an uploaded report.
+type ReportResult struct {
+	// Destination is the path the report was uploaded to.
+	Destination string
+	// Rows is the number of products in the report.
+	Rows int
+	// ContentType is the media type of the report's format.
+	ContentType string
+}
+
+// ReportJob writes every product to a report in its ReportFormat and uploads
+// it to destinationDir/products-<UTC timestamp>.<format extension>. Scheduled
+// and manual runs share the job, and only one of them runs at a time.
+type ReportJob struct {
+	repo           Repository
+	uploader       storage.Uploader
+	destinationDir string
+	format         ReportFormat
+	timeout        time.Duration
+	now            func() time.Time
+	running        atomic.Bool
+}
+
+// NewReportJob creates a report job reading from repo and uploading reports in
+// format through uploader. Each run is cancelled after timeout; zero leaves it
+// bounded only by the caller's context.
+func NewReportJob(repo Repository, uploader storage.Uploader, destinationDir string, format ReportFormat, timeout time.Duration) *ReportJob {
+	return &ReportJob{
+		repo:           repo,
+		uploader:       uploader,
+		destinationDir: destinationDir,
+		format:         format,
+		timeout:        timeout,
+		now:            time.Now,
+	}
+}
 
-// Execute implements scheduler.Job
+// Execute implements scheduler.Job. A failed query or upload is returned so
+// the scheduler records the run as failed. A tick that finds a run still in
+// progress, such as one triggered manually, is skipped.
 func (j *ReportJob) Execute(ctx scheduler.JobContext) error {
 	logger := ctx.Logger()
+
+	result, err := j.Run(ctx)
+	if errors.Is(err, ErrReportRunning) {
+		logger.Info().Str("jobID", ctx.JobID()).Msg("Product report already running, skipping")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
 	logger.Info().
 		Str("jobID", ctx.JobID()).
-		Msg("Generating daily report")
+		Int("rows", result.Rows).
+		Str("destination", result.Destination).
+		Msg("Product report uploaded")
+	return nil
+}
 
-	// Query product's database and generate report...
-	// generate txt report file with all products data
-	// upload report to storage service (sftp, s3, etc.) -> make it dynamic via interface storage.Upload(destinationPath, fileContents)
+// Run generates and uploads the report, failing with ErrReportRunning when
+// another run is in progress. When ctx is cancelled (e.g. on shutdown) or the
+// timeout expires, it stops at the next product and returns the context's
+// error without uploading a partial report.
+func (j *ReportJob) Run(ctx context.Context) (ReportResult, error) {
+	if !j.running.CompareAndSwap(false, true) {
+		return ReportResult{}, ErrReportRunning
+	}
+	defer j.running.Store(false)
 
-	return nil
+	if j.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, j.timeout)
+		defer cancel()
+	}
+
+	formatter, err := newReportFormatter(j.format)
+	if err != nil {
+		return ReportResult{}, err
+	}
+
+	var buf bytes.Buffer
+	if err := formatter.begin(&buf); err != nil {
+		return ReportResult{}, fmt.Errorf("failed to write report: %w", err)
+	}
+	rows := 0
+	err = j.repo.Stream(ctx, repository.StreamOptions{}, func(p *domain.Product) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := formatter.write(&buf, p); err != nil {
+			return fmt.Errorf("failed to write product %s: %w", p.ID, err)
+		}
+		rows++
+		return nil
+	})
+	if err != nil {
+		return ReportResult{}, fmt.Errorf("failed to read products for report: %w", err)
+	}
+	if err := formatter.end(&buf); err != nil {
+		return ReportResult{}, fmt.Errorf("failed to write report: %w", err)
+	}
+	if err := ctx.Err(); err != nil {
+		return ReportResult{}, fmt.Errorf("report cancelled before upload: %w", err)
+	}
+
+	name := "products-" + j.now().UTC().Format("20060102T150405Z") + "." + formatter.extension()
+	destination := path.Join(j.destinationDir, name)
+	if err := j.uploader.Upload(ctx, destination, formatter.contentType(), &buf); err != nil {
+		return ReportResult{}, fmt.Errorf("failed to upload report to %s: %w", destination, err)
+	}
+	return ReportResult{Destination: destination, Rows: rows, ContentType: formatter.contentType()}, nil
 }
@@ -0,0 +1,223 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/products/domain"
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/products/repository"
+	"github.com/gaborage/go-bricks/config"
+	dbtypes "github.com/gaborage/go-bricks/database/types"
+	"github.com/gaborage/go-bricks/logger"
+	"github.com/gaborage/go-bricks/messaging"
+)
+
+// testJobContext implements scheduler.JobContext for testing
+type testJobContext struct {
+	context.Context
+}
+
+func (testJobContext) JobID() string               { return "report-job" }
+func (testJobContext) TriggerType() string         { return "manual" }
+func (testJobContext) Logger() logger.Logger       { return logger.New("info", false) }
+func (testJobContext) DB() dbtypes.Interface       { return nil }
+func (testJobContext) Messaging() messaging.Client { return nil }
+func (testJobContext) Config() *config.Config      { return &config.Config{} }
+
+// streamRepository serves products to Stream, or fails with err. It records
+// the options of the last call and runs beforeRow, when set, before serving
+// each product. With block set it waits for the context to be done instead.
+type streamRepository struct {
+	products  []*domain.Product
+	err       error
+	opts      repository.StreamOptions
+	beforeRow func(i int)
+	block     bool
+}
+
+func (r *streamRepository) Stream(ctx context.Context, opts repository.StreamOptions, fn func(*domain.Product) error) error {
+	r.opts = opts
+	if r.err != nil {
+		return r.err
+	}
+	if r.block {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	for i, p := range r.products {
+		if r.beforeRow != nil {
+			r.beforeRow(i)
+		}
+		if err := fn(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordingUploader keeps the last upload, or fails with err
+type recordingUploader struct {
+	path        string
+	contentType string
+	contents    string
+	err         error
+}
+
+func (u *recordingUploader) Upload(_ context.Context, destinationPath, contentType string, contents io.Reader) error {
+	data, _ := io.ReadAll(contents)
+	u.path, u.contentType, u.contents = destinationPath, contentType, string(data)
+	return u.err
+}
+
+func (u *recordingUploader) Close() error { return nil }
+
+func TestReportJobExecute(t *testing.T) {
+	created := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	product := domain.New("p-1", "MUG-001", "Tab\tName", "Line\nbreak", 950, domain.DefaultCurrency, "https://example.com/p.jpg")
+	product.CreatedDate, product.UpdatedDate = created, created
+
+	repo := &streamRepository{products: []*domain.Product{product}}
+	uploader := &recordingUploader{}
+	job := NewReportJob(repo, uploader, "reports", ReportFormatText, 0)
+	job.now = func() time.Time { return created }
+
+	if err := job.Execute(testJobContext{context.Background()}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	if uploader.path != "reports/products-20250102T030405Z.txt" {
+		t.Errorf("uploaded to %q, want reports/products-20250102T030405Z.txt", uploader.path)
+	}
+	want := "id\tsku\tname\tdescription\tprice\tcurrency\timage_url\tcreated_date\tupdated_date\n" +
+		"p-1\tMUG-001\tTab Name\tLine break\t9.50\tUSD\thttps://example.com/p.jpg\t2025-01-02T03:04:05Z\t2025-01-02T03:04:05Z\n"
+	if uploader.contents != want {
+		t.Errorf("report = %q, want %q", uploader.contents, want)
+	}
+	if uploader.contentType != "text/plain; charset=utf-8" {
+		t.Errorf("content type = %q, want text/plain; charset=utf-8", uploader.contentType)
+	}
+}
+
+func TestReportJobExecuteErrors(t *testing.T) {
+	t.Run("upload failure", func(t *testing.T) {
+		uploadErr := errors.New("bucket not found")
+		job := NewReportJob(&streamRepository{}, &recordingUploader{err: uploadErr}, "reports", ReportFormatText, 0)
+
+		if err := job.Execute(testJobContext{context.Background()}); !errors.Is(err, uploadErr) {
+			t.Errorf("Execute() error = %v, want %v", err, uploadErr)
+		}
+	})
+
+	t.Run("query failure skips the upload", func(t *testing.T) {
+		uploader := &recordingUploader{}
+		job := NewReportJob(&streamRepository{err: errors.New("connection refused")}, uploader, "reports", ReportFormatText, 0)
+
+		if err := job.Execute(testJobContext{context.Background()}); err == nil {
+			t.Error("Execute() error = nil, want the query error")
+		}
+		if uploader.path != "" {
+			t.Errorf("uploaded to %q, want no upload", uploader.path)
+		}
+	})
+}
+
+func TestReportJobExecuteCancellation(t *testing.T) {
+	products := []*domain.Product{
+		domain.New("p-1", "MUG-001", "First", "", 100, domain.DefaultCurrency, ""),
+		domain.New("p-2", "MUG-002", "Second", "", 200, domain.DefaultCurrency, ""),
+		domain.New("p-3", "MUG-003", "Third", "", 300, domain.DefaultCurrency, ""),
+	}
+
+	t.Run("cancelled mid-report", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		served := 0
+		repo := &streamRepository{products: products, beforeRow: func(i int) {
+			served = i + 1
+			if i == 1 {
+				cancel() // the scheduler shuts down while the report is being built
+			}
+		}}
+		uploader := &recordingUploader{}
+		job := NewReportJob(repo, uploader, "reports", ReportFormatText, time.Minute)
+
+		err := job.Execute(testJobContext{ctx})
+
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Execute() error = %v, want context.Canceled", err)
+		}
+		if served != 2 {
+			t.Errorf("Execute() read %d products, want it to stop after the cancellation", served)
+		}
+		if uploader.path != "" {
+			t.Errorf("uploaded to %q, want no upload of a partial report", uploader.path)
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		uploader := &recordingUploader{}
+		job := NewReportJob(&streamRepository{block: true}, uploader, "reports", ReportFormatText, 10*time.Millisecond)
+
+		err := job.Execute(testJobContext{context.Background()})
+
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Execute() error = %v, want context.DeadlineExceeded", err)
+		}
+		if uploader.path != "" {
+			t.Errorf("uploaded to %q, want no upload", uploader.path)
+		}
+	})
+}
+
+func TestReportJobRun(t *testing.T) {
+	products := []*domain.Product{
+		domain.New("p-1", "MUG-001", "First", "", 100, domain.DefaultCurrency, ""),
+		domain.New("p-2", "MUG-002", "Second", "", 200, domain.DefaultCurrency, ""),
+	}
+	repo := &streamRepository{products: products}
+	uploader := &recordingUploader{}
+	job := NewReportJob(repo, uploader, "reports", ReportFormatText, time.Minute)
+	job.now = func() time.Time { return time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC) }
+
+	// Runs started while the report is being built are refused.
+	var nestedRunErr, nestedExecuteErr error
+	repo.beforeRow = func(i int) {
+		if i == 0 {
+			_, nestedRunErr = job.Run(context.Background())
+			nestedExecuteErr = job.Execute(testJobContext{context.Background()})
+		}
+	}
+
+	result, err := job.Run(context.Background())
+
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	want := ReportResult{Destination: "reports/products-20250102T030405Z.txt", Rows: 2, ContentType: "text/plain; charset=utf-8"}
+	if result != want {
+		t.Errorf("Run() = %+v, want %+v", result, want)
+	}
+	if !errors.Is(nestedRunErr, ErrReportRunning) {
+		t.Errorf("concurrent Run() error = %v, want ErrReportRunning", nestedRunErr)
+	}
+	if nestedExecuteErr != nil {
+		t.Errorf("concurrent Execute() error = %v, want the tick skipped", nestedExecuteErr)
+	}
+	if uploader.path != want.Destination {
+		t.Errorf("uploaded to %q, want only the outer run's upload", uploader.path)
+	}
+
+	// The guard is released once the run finishes, even after a failure.
+	repo.beforeRow = nil
+	repo.err = errors.New("connection refused")
+	if _, err := job.Run(context.Background()); err == nil || errors.Is(err, ErrReportRunning) {
+		t.Fatalf("Run() error = %v, want the repository error", err)
+	}
+	repo.err = nil
+	if _, err := job.Run(context.Background()); err != nil {
+		t.Errorf("Run() after a failed run error = %v", err)
+	}
+}
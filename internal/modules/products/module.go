@@ -2,12 +2,16 @@ package products
 
 import (
 	"context"
-	"time"
+	"fmt"
 
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/products/domain"
 	"github.com/gaborage/go-bricks-demo-project/internal/modules/products/handlers"
 	"github.com/gaborage/go-bricks-demo-project/internal/modules/products/job"
 	"github.com/gaborage/go-bricks-demo-project/internal/modules/products/repository"
 	"github.com/gaborage/go-bricks-demo-project/internal/modules/products/service"
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/shared/admin"
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/shared/publisher"
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/shared/storage"
 	"github.com/gaborage/go-bricks/app"
 	"github.com/gaborage/go-bricks/database"
 	"github.com/gaborage/go-bricks/logger"
@@ -22,6 +26,11 @@ type Module struct {
 	handler      *handlers.ProductHandler
 	repo         repository.ProductRepository
 	logger       logger.Logger
+	cfg          Config
+	uploader     storage.Uploader
+	report       *job.ReportJob
+	events       *publisher.Publisher
+	idempotency  *handlers.MemoryIdempotencyStore
 	getDB        func(context.Context) (database.Interface, error)
 	getMessaging func(context.Context) (messaging.AMQPClient, error)
 }
@@ -48,12 +57,83 @@ func (m *Module) Init(deps *app.ModuleDeps) error {
 
 	m.logger.Info().Msg("Initializing products module")
 
+	if err := deps.Config.InjectInto(&m.cfg); err != nil {
+		return fmt.Errorf("failed to load products config: %w", err)
+	}
+
+	var adminCfg admin.Config
+	if err := deps.Config.InjectInto(&adminCfg); err != nil {
+		return fmt.Errorf("failed to load admin config: %w", err)
+	}
+
 	m.logger.Info().Msg("Using existing database schema for products")
 
 	// Initialize repository, service, jobs and handler
-	m.repo = *repository.NewSQLProductRepository(m.getDB)
-	m.service = service.NewService(&m.repo, m.logger, deps.Outbox, deps.DB)
-	m.handler = handlers.NewProductHandler(m.service, m.logger)
+	deletePolicy, err := repository.ParseDeletePolicy(m.cfg.DeletePolicy)
+	if err != nil {
+		return fmt.Errorf("custom.products.delete.policy: %w", err)
+	}
+	m.repo = *repository.NewSQLProductRepository(m.getDB,
+		repository.WithDeletePolicy(deletePolicy),
+		repository.WithMaxListLimit(m.cfg.MaxListLimit),
+		repository.WithLogger(m.logger),
+	)
+	defaultCurrency, err := domain.NormalizeCurrency(m.cfg.DefaultCurrency)
+	if err != nil {
+		return fmt.Errorf("custom.products.price.default.currency: %w", err)
+	}
+	var publisherCfg publisher.Config
+	if err := deps.Config.InjectInto(&publisherCfg); err != nil {
+		return fmt.Errorf("failed to load publisher config: %w", err)
+	}
+	m.events = publisher.NewPublisher(m.getMessaging, publisherCfg, m.logger)
+
+	serviceOpts := []service.Option{
+		service.WithEventPublisher(m.events),
+		service.WithMaxListOffset(m.cfg.MaxListOffset),
+		service.WithAllowZeroPrice(m.cfg.AllowZeroPrice),
+		service.WithDefaultCurrency(defaultCurrency),
+		service.WithMaxDescriptionLength(m.cfg.MaxDescriptionLength),
+		service.WithImageURLSchemes(m.cfg.ImageURLSchemes...),
+		service.WithImageURLHosts(m.cfg.ImageURLHosts...),
+		service.WithCategoryChecker(&m.repo),
+	}
+	if m.cfg.CacheEnabled {
+		serviceOpts = append(serviceOpts, service.WithProductCache(m.cfg.CacheTTL, m.cfg.CacheMaxSize))
+	}
+	m.service = service.NewService(&m.repo, m.logger, deps.Outbox, deps.DB, serviceOpts...)
+	handlerOpts := []handlers.HandlerOption{
+		handlers.WithCompression(m.cfg.compressionThreshold()),
+		handlers.WithStrictQueryParams(m.cfg.StrictQueryParams),
+		handlers.WithAllowedContentTypes(m.cfg.AllowedContentTypes...),
+		handlers.WithLargeResultThreshold(m.cfg.LargeResultThreshold),
+		handlers.WithStreamMaxRows(m.cfg.StreamMaxRows),
+		handlers.WithBasePath(deps.Config.Server.Path.Base),
+		handlers.WithAdminGuard(admin.NewGuard(adminCfg)),
+	}
+	if m.cfg.ReportJobEnabled {
+		var storageCfg storage.Config
+		if err := deps.Config.InjectInto(&storageCfg); err != nil {
+			return fmt.Errorf("failed to load storage config: %w", err)
+		}
+		uploader, err := storage.New(context.Background(), storageCfg)
+		if err != nil {
+			return fmt.Errorf("failed to create report uploader: %w", err)
+		}
+		m.uploader = storage.NewRetryingUploader(uploader, storageCfg.UploadMaxAttempts, storageCfg.UploadRetryDelay, m.logger)
+		// Scheduled runs and POST /products/report/run share the job, so
+		// they never overlap.
+		if m.report, err = m.newReportJob(); err != nil {
+			return err
+		}
+		handlerOpts = append(handlerOpts, handlers.WithReportRunner(m.report))
+	}
+
+	if m.cfg.IdempotencyTTL > 0 {
+		m.idempotency = handlers.NewMemoryIdempotencyStore(m.cfg.IdempotencyTTL, m.cfg.IdempotencyMaxSize)
+		handlerOpts = append(handlerOpts, handlers.WithIdempotencyStore(m.idempotency))
+	}
+	m.handler = handlers.NewProductHandler(m.service, m.logger, handlerOpts...)
 
 	m.logger.Info().Msg("Products module initialized successfully")
 
@@ -66,22 +146,99 @@ func (m *Module) RegisterRoutes(hr *server.HandlerRegistry, r server.RouteRegist
 	m.handler.RegisterProductRoutes(hr, r)
 }
 
-// DeclareMessaging declares messaging infrastructure for this module
+// DeclareMessaging declares messaging infrastructure for this module.
+// Products only publish; each consuming module declares and binds its own
+// queue on the exchange (see the analytics module), so no queue is declared here.
 func (m *Module) DeclareMessaging(decls *messaging.Declarations) {
-	// Declare the exchange used by outbox events for product lifecycle events
+	// Declare the exchange used by outbox and direct product lifecycle events
 	decls.RegisterExchange(&messaging.ExchangeDeclaration{
-		Name:    "product-events",
+		Name:    service.EventsExchange,
 		Type:    "topic",
 		Durable: true,
 	})
 }
 
+// Scheduled job IDs. The report job keeps its original ID so existing
+// monitoring of it continues to match.
+const (
+	reportJobID   = "test-job"
+	lowStockJobID = "low-stock-job"
+)
+
+// RegisterJobs registers the report and low-stock jobs at their configured
+// intervals. A job that is disabled for this environment, or whose interval
+// is zero, is not registered.
 func (m *Module) RegisterJobs(scheduler app.JobRegistrar) error {
-	// Register scheduled jobs
-	return scheduler.FixedRate("test-job", &job.ReportJob{}, 30*time.Second)
+	if err := m.registerReportJob(scheduler); err != nil {
+		return err
+	}
+	return m.registerLowStockJob(scheduler)
+}
+
+func (m *Module) registerReportJob(scheduler app.JobRegistrar) error {
+	if !m.cfg.ReportJobEnabled || m.cfg.ReportJobInterval == 0 {
+		m.logger.Info().Msg("Report job disabled, not registering it")
+		return nil
+	}
+	if m.cfg.ReportJobInterval < 0 {
+		return fmt.Errorf("custom.products.report.job.interval must be positive, got %s", m.cfg.ReportJobInterval)
+	}
+	if m.report == nil {
+		report, err := m.newReportJob()
+		if err != nil {
+			return err
+		}
+		m.report = report
+	}
+	m.logger.Info().
+		Str("jobID", reportJobID).
+		Dur("interval", m.cfg.ReportJobInterval).
+		Dur("timeout", m.cfg.reportJobTimeout()).
+		Msg("Registering report job")
+	return scheduler.FixedRate(reportJobID, m.report, m.cfg.ReportJobInterval)
+}
+
+// newReportJob builds the report job from the module configuration.
+func (m *Module) newReportJob() (*job.ReportJob, error) {
+	format, err := job.ParseReportFormat(m.cfg.ReportJobFormat)
+	if err != nil {
+		return nil, fmt.Errorf("custom.products.report.job.format: %w", err)
+	}
+	return job.NewReportJob(&m.repo, m.uploader, m.cfg.ReportJobDestination, format, m.cfg.reportJobTimeout()), nil
+}
+
+func (m *Module) registerLowStockJob(scheduler app.JobRegistrar) error {
+	if !m.cfg.LowStockJobEnabled || m.cfg.LowStockJobInterval == 0 {
+		m.logger.Info().Msg("Low-stock job disabled, not registering it")
+		return nil
+	}
+	if m.cfg.LowStockJobInterval < 0 {
+		return fmt.Errorf("custom.products.lowstock.job.interval must be positive, got %s", m.cfg.LowStockJobInterval)
+	}
+	if m.cfg.LowStockThreshold <= 0 {
+		return fmt.Errorf("custom.products.lowstock.threshold must be positive, got %d", m.cfg.LowStockThreshold)
+	}
+	m.logger.Info().
+		Str("jobID", lowStockJobID).
+		Dur("interval", m.cfg.LowStockJobInterval).
+		Int("threshold", m.cfg.LowStockThreshold).
+		Msg("Registering low-stock job")
+	lowStockJob := job.NewLowStockJob(&m.repo, m.events, m.cfg.LowStockThreshold)
+	return scheduler.FixedRate(lowStockJobID, lowStockJob, m.cfg.LowStockJobInterval)
 }
 
 // Shutdown performs cleanup when the module is stopped
 func (m *Module) Shutdown() error {
+	if m.service != nil {
+		m.service.Close()
+	}
+	if m.idempotency != nil {
+		m.idempotency.Close()
+	}
+	if m.uploader != nil {
+		if err := m.uploader.Close(); err != nil {
+			return fmt.Errorf("failed to close report uploader: %w", err)
+		}
+	}
 	return nil
 }
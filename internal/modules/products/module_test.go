@@ -0,0 +1,137 @@
+package products
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/gaborage/go-bricks/logger"
+)
+
+// recordingRegistrar implements app.JobRegistrar and records FixedRate calls
+type recordingRegistrar struct {
+	jobIDs    []string
+	intervals []time.Duration
+}
+
+func (r *recordingRegistrar) FixedRate(jobID string, _ any, interval time.Duration) error {
+	r.jobIDs = append(r.jobIDs, jobID)
+	r.intervals = append(r.intervals, interval)
+	return nil
+}
+
+func (r *recordingRegistrar) DailyAt(string, any, time.Time) error { return nil }
+
+func (r *recordingRegistrar) WeeklyAt(string, any, time.Weekday, time.Time) error { return nil }
+
+func (r *recordingRegistrar) HourlyAt(string, any, int) error { return nil }
+
+func (r *recordingRegistrar) MonthlyAt(string, any, int, time.Time) error { return nil }
+
+// closingUploader implements storage.Uploader and records Close calls
+type closingUploader struct {
+	closed int
+	err    error
+}
+
+func (u *closingUploader) Upload(context.Context, string, string, io.Reader) error { return nil }
+
+func (u *closingUploader) Close() error {
+	u.closed++
+	return u.err
+}
+
+func TestShutdownClosesUploader(t *testing.T) {
+	uploader := &closingUploader{}
+	m := &Module{uploader: uploader}
+	if err := m.Shutdown(); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	if uploader.closed != 1 {
+		t.Errorf("uploader closed %d times, want 1", uploader.closed)
+	}
+
+	closeErr := errors.New("connection reset")
+	m = &Module{uploader: &closingUploader{err: closeErr}}
+	if err := m.Shutdown(); !errors.Is(err, closeErr) {
+		t.Errorf("Shutdown() error = %v, want %v", err, closeErr)
+	}
+
+	if err := (&Module{}).Shutdown(); err != nil {
+		t.Errorf("Shutdown() without an uploader error = %v", err)
+	}
+}
+
+func TestRegisterJobs(t *testing.T) {
+	tests := []struct {
+		name          string
+		cfg           Config
+		wantErr       bool
+		wantIntervals []time.Duration
+	}{
+		{
+			name: "disabled registers nothing",
+			cfg:  Config{ReportJobEnabled: false, ReportJobInterval: time.Minute},
+		},
+		{
+			name: "low-stock job uses the configured interval",
+			cfg: Config{
+				ReportJobEnabled: true, ReportJobInterval: time.Minute,
+				LowStockJobEnabled: true, LowStockJobInterval: 10 * time.Minute, LowStockThreshold: 3,
+			},
+			wantIntervals: []time.Duration{time.Minute, 10 * time.Minute},
+		},
+		{
+			name:    "low-stock job with a negative interval is rejected",
+			cfg:     Config{LowStockJobEnabled: true, LowStockJobInterval: -time.Second, LowStockThreshold: 3},
+			wantErr: true,
+		},
+		{
+			name:    "low-stock job with a non-positive threshold is rejected",
+			cfg:     Config{LowStockJobEnabled: true, LowStockJobInterval: time.Minute},
+			wantErr: true,
+		},
+		{
+			name:          "enabled uses the configured interval",
+			cfg:           Config{ReportJobEnabled: true, ReportJobInterval: 5 * time.Minute},
+			wantIntervals: []time.Duration{5 * time.Minute},
+		},
+		{
+			name: "enabled with a zero interval registers nothing",
+			cfg:  Config{ReportJobEnabled: true},
+		},
+		{
+			name:    "enabled with a negative interval is rejected",
+			cfg:     Config{ReportJobEnabled: true, ReportJobInterval: -time.Second},
+			wantErr: true,
+		},
+		{
+			name:    "enabled with an unknown format is rejected",
+			cfg:     Config{ReportJobEnabled: true, ReportJobInterval: time.Minute, ReportJobFormat: "xml"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Module{cfg: tt.cfg, logger: logger.New("info", false)}
+			registrar := &recordingRegistrar{}
+
+			err := m.RegisterJobs(registrar)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RegisterJobs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(registrar.intervals) != len(tt.wantIntervals) {
+				t.Fatalf("RegisterJobs() registered %d jobs, want %d", len(registrar.intervals), len(tt.wantIntervals))
+			}
+			for i, interval := range tt.wantIntervals {
+				if registrar.intervals[i] != interval {
+					t.Errorf("job %q interval = %s, want %s", registrar.jobIDs[i], registrar.intervals[i], interval)
+				}
+			}
+		})
+	}
+}
@@ -5,57 +5,303 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/gaborage/go-bricks-demo-project/internal/modules/products/domain"
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/shared/correlation"
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/shared/dbutil"
 	"github.com/gaborage/go-bricks/database"
 	dbtypes "github.com/gaborage/go-bricks/database/types"
+	"github.com/gaborage/go-bricks/logger"
 )
 
+// DefaultMaxListLimit is the largest page List returns unless WithMaxListLimit
+// says otherwise. It sits well above the page sizes the service accepts.
+const DefaultMaxListLimit = 1000
+
 var (
 	ErrProductNotFound = errors.New("product not found")
+
+	// ErrDuplicateSKU is returned by creates when a live product already has
+	// the SKU. Errors matching it also match dbutil.ErrConflict.
+	ErrDuplicateSKU = errors.New("a product with this SKU already exists")
+
+	// ErrConcurrentModification is returned by updates that carry an expected
+	// version when the product has since been updated by someone else.
+	ErrConcurrentModification = errors.New("product was modified concurrently")
+
+	// ErrProductLocked is returned by updates and deletes of a locked
+	// product. Only SetLocked and stock changes apply to a locked product.
+	ErrProductLocked = errors.New("product is locked")
+
+	// ErrInsufficientStock is returned by Reserve when the product has fewer
+	// units in stock than requested.
+	ErrInsufficientStock = errors.New("insufficient stock")
+)
+
+// StreamOptions controls which rows Stream returns.
+type StreamOptions struct {
+	// IncludeDeleted also returns soft-deleted products, for compliance
+	// exports. Under DeletePolicyHard there are no deleted rows to include
+	// and the flag has no effect.
+	IncludeDeleted bool
+	// StockBelow, when positive, returns only products with fewer units in
+	// stock, e.g. for low-stock alerts.
+	StockBelow int
+}
+
+// DeletePolicy selects what Delete does with a product's row.
+type DeletePolicy string
+
+const (
+	// DeletePolicyHard removes the row. It is the default.
+	DeletePolicyHard DeletePolicy = "hard"
+
+	// DeletePolicySoft sets deleted_at and keeps the row. Every read and
+	// update then skips rows with deleted_at set, so a deleted product is
+	// not found just as under DeletePolicyHard.
+	DeletePolicySoft DeletePolicy = "soft"
+)
+
+// ErrInvalidDeletePolicy is returned by ParseDeletePolicy for an unknown policy.
+var ErrInvalidDeletePolicy = errors.New("invalid delete policy")
+
+// ParseDeletePolicy converts a configured policy name to a DeletePolicy. An
+// empty name is DeletePolicyHard.
+func ParseDeletePolicy(name string) (DeletePolicy, error) {
+	switch DeletePolicy(name) {
+	case "", DeletePolicyHard:
+		return DeletePolicyHard, nil
+	case DeletePolicySoft:
+		return DeletePolicySoft, nil
+	default:
+		return "", fmt.Errorf("%w %q: want %q or %q", ErrInvalidDeletePolicy, name, DeletePolicyHard, DeletePolicySoft)
+	}
+}
+
+// Cursor is a keyset pagination position: the creation date and ID of the
+// last product of the previous page.
+type Cursor struct {
+	CreatedDate time.Time
+	ID          string
+}
+
+// SortField is a product attribute List can order by.
+type SortField string
+
+const (
+	SortByCreatedDate SortField = "createdDate"
+	SortByName        SortField = "name"
+	SortByPrice       SortField = "price"
 )
 
-// Repository defines the interface for product data access
+// sortColumns maps each SortField to its ProductEntity field. Only these
+// columns can ever reach the ORDER BY clause.
+var sortColumns = map[SortField]string{
+	SortByCreatedDate: "CreatedDate",
+	SortByName:        "Name",
+	SortByPrice:       "PriceMinor",
+}
+
+// ListFilter restricts List results. The zero value matches every product.
+type ListFilter struct {
+	// Search keeps products whose name or description contains it, case-insensitively.
+	Search string
+	// Currency, when set, keeps only products priced in that currency.
+	Currency string
+	// CategoryID, when set, keeps only products in that category.
+	CategoryID string
+	// MinPrice and MaxPrice are inclusive price bounds in minor units; nil
+	// leaves that side open. Minor units only compare within one currency, so
+	// set Currency along with them.
+	MinPrice *int64
+	MaxPrice *int64
+}
+
+// Sort orders List results. The zero value is DefaultSort.
+type Sort struct {
+	Field      SortField
+	Descending bool
+}
+
+// DefaultSort lists the newest products first.
+var DefaultSort = Sort{Field: SortByCreatedDate, Descending: true}
+
+// PriceStats summarizes the prices of the products in one currency, in minor
+// units of Currency. All values but Currency are zero when no product has it.
+type PriceStats struct {
+	Currency string
+	Min      int64
+	Max      int64
+	Avg      float64
+	Count    int
+}
+
+// DuplicateFieldName marks groups matched on the normalized product name.
+const DuplicateFieldName = "name"
+
+// DuplicateGroup is a set of products that share a normalized attribute value
+// and are likely duplicates of each other.
+type DuplicateGroup struct {
+	// Field is the attribute the products share, e.g. DuplicateFieldName.
+	Field string
+	// Key is the shared normalized value.
+	Key        string
+	ProductIDs []string
+}
+
+// Repository defines the interface for product data access.
+// Connection failures match dbutil.ErrDBUnavailable and failed statements
+// match dbutil.ErrInternal.
 type Repository interface {
 	Create(ctx context.Context, product *domain.Product) error
+	CreateBatch(ctx context.Context, products []*domain.Product) error
 	GetByID(ctx context.Context, id string) (*domain.Product, error)
-	List(ctx context.Context, limit, offset int) ([]*domain.Product, int, error)
+	GetBySKU(ctx context.Context, sku string) (*domain.Product, error)
+	Exists(ctx context.Context, id string) (bool, error)
+	List(ctx context.Context, limit, offset int, filter ListFilter, sort Sort) ([]*domain.Product, int, error)
+	ListAfter(ctx context.Context, after *Cursor, limit int) ([]*domain.Product, error)
+	Stream(ctx context.Context, opts StreamOptions, fn func(*domain.Product) error) error
+	FindDuplicates(ctx context.Context) ([]DuplicateGroup, error)
+	PriceStats(ctx context.Context, currency string, category *string) (PriceStats, error)
+	// Update and UpdateAndFetch bump the product version. When updates holds
+	// an expected version under the "version" key, a product at any other
+	// version is left unchanged and ErrConcurrentModification is returned.
 	Update(ctx context.Context, id string, updates map[string]any) error
+	// UpdateAndFetch applies updates and returns the updated product atomically.
+	UpdateAndFetch(ctx context.Context, id string, updates map[string]any) (*domain.Product, error)
+	// UpdateAndCompare is UpdateAndFetch that also returns the product as it
+	// was just before the update.
+	UpdateAndCompare(ctx context.Context, id string, updates map[string]any) (before, after *domain.Product, err error)
 	Delete(ctx context.Context, id string) error
+	// SetLocked locks or unlocks a product. Locked products reject Update,
+	// UpdateAndFetch, UpdateAndCompare, Delete and DeleteTx with
+	// ErrProductLocked.
+	SetLocked(ctx context.Context, id string, locked bool) error
+	// Reserve takes qty units out of stock, or returns ErrInsufficientStock
+	// and leaves the stock unchanged when fewer are available. Release puts
+	// qty units back. Both are single atomic statements, so concurrent
+	// reservations cannot oversell, and neither bumps the version.
+	Reserve(ctx context.Context, id string, qty int) error
+	Release(ctx context.Context, id string, qty int) error
 
 	// Transaction-aware variants for use with the transactional outbox pattern.
 	// These accept a dbtypes.Tx so the caller can atomically commit business data
 	// and outbox events in the same database transaction.
 	CreateTx(ctx context.Context, tx dbtypes.Tx, product *domain.Product) error
+	CreateBatchTx(ctx context.Context, tx dbtypes.Tx, products []*domain.Product) error
 	DeleteTx(ctx context.Context, tx dbtypes.Tx, id string) error
 }
 
+// likeEscaper escapes LIKE wildcards so search terms match literally.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
 const (
 	dbUnavailableErrMsg = "failed to get database connection: %w"
 
 	// fieldKeyName is the JSON/updates-map key for the product name field,
 	// shared with repository_test.go where it is used as a map key literal.
 	fieldKeyName = "name"
+
+	// fieldKeyVersion is the updates-map key carrying the version an update
+	// expects the product to have.
+	fieldKeyVersion = "version"
+
+	// columnDeletedAt marks soft-deleted products. It is not a ProductEntity
+	// field, so it stays out of cols.All() and the row scans.
+	columnDeletedAt = "deleted_at"
 )
 
 type ProductRepository struct {
-	getDB func(context.Context) (database.Interface, error)
-	cols  dbtypes.Columns // Cached column metadata for type-safe queries
+	getDB        func(context.Context) (database.Interface, error)
+	cols         dbtypes.Columns // Cached column metadata for type-safe queries
+	deletePolicy DeletePolicy
+	maxLimit     int
+	logger       logger.Logger // nil when clamping is not logged
 }
 
-func NewSQLProductRepository(getDB func(context.Context) (database.Interface, error)) *ProductRepository {
+// Option configures a ProductRepository.
+type Option func(*ProductRepository)
+
+// WithDeletePolicy selects how Delete and DeleteTx remove a product. The
+// default is DeletePolicyHard.
+func WithDeletePolicy(policy DeletePolicy) Option {
+	return func(r *ProductRepository) {
+		r.deletePolicy = policy
+	}
+}
+
+// WithMaxListLimit caps the limit List passes to the database, whatever the
+// caller asks for. Zero or less leaves List unlimited.
+func WithMaxListLimit(limit int) Option {
+	return func(r *ProductRepository) {
+		r.maxLimit = limit
+	}
+}
+
+// WithLogger sets the logger List warns on when it clamps a limit.
+func WithLogger(log logger.Logger) Option {
+	return func(r *ProductRepository) {
+		r.logger = log
+	}
+}
+
+func NewSQLProductRepository(getDB func(context.Context) (database.Interface, error), opts ...Option) *ProductRepository {
 	qb := database.NewQueryBuilder(database.PostgreSQL)
-	return &ProductRepository{
-		getDB: getDB,
-		cols:  qb.Columns(&domain.ProductEntity{}), // Cache once at construction
+	r := &ProductRepository{
+		getDB:        getDB,
+		cols:         qb.Columns(&domain.ProductEntity{}), // Cache once at construction
+		deletePolicy: DeletePolicyHard,
+		maxLimit:     DefaultMaxListLimit,
+	}
+	for _, opt := range opts {
+		opt(r)
 	}
+	return r
+}
+
+// clampLimit returns limit capped at the configured maximum, logging when it
+// has to cap it. The service validates page sizes already; this protects
+// direct callers such as jobs and exports.
+func (r *ProductRepository) clampLimit(ctx context.Context, limit int) int {
+	if r.maxLimit <= 0 || limit <= r.maxLimit {
+		return limit
+	}
+	if r.logger != nil {
+		correlation.Logger(ctx, r.logger).Warn().
+			Int("requested_limit", limit).
+			Int("max_limit", r.maxLimit).
+			Msg("Clamping product list limit to the configured maximum")
+	}
+	return r.maxLimit
+}
+
+// softDeletes reports whether deleted products keep their row, so queries
+// must skip rows with deleted_at set.
+func (r *ProductRepository) softDeletes() bool {
+	return r.deletePolicy == DeletePolicySoft
+}
+
+// notDeleted restricts match to products that are not soft-deleted. Under
+// DeletePolicyHard match is returned unchanged.
+func (r *ProductRepository) notDeleted(f dbtypes.FilterFactory, match dbtypes.Filter) dbtypes.Filter {
+	if !r.softDeletes() {
+		return match
+	}
+	return f.And(match, f.Null(columnDeletedAt))
+}
+
+// unlocked restricts match to products that are not locked.
+func (r *ProductRepository) unlocked(f dbtypes.FilterFactory, match dbtypes.Filter) dbtypes.Filter {
+	return f.And(match, f.Eq(r.cols.Col("Locked"), false))
 }
 
 // Create inserts a new product into the database using type-safe InsertStruct
 func (r *ProductRepository) Create(ctx context.Context, product *domain.Product) error {
 	db, err := r.getDB(ctx)
 	if err != nil {
-		return fmt.Errorf(dbUnavailableErrMsg, err)
+		return fmt.Errorf(dbUnavailableErrMsg, dbutil.Unavailable(err))
 	}
 
 	entity := domain.ToProductEntity(product)
@@ -64,94 +310,309 @@ func (r *ProductRepository) Create(ctx context.Context, product *domain.Product)
 	qb := database.NewQueryBuilder(database.PostgreSQL)
 	query, args, err := qb.InsertStruct(entity.TableName(), entity).ToSQL()
 	if err != nil {
-		return fmt.Errorf("failed to build insert query: %w", err)
+		return fmt.Errorf("failed to build insert query: %w", dbutil.Internal(err))
+	}
+
+	_, err = db.Exec(ctx, query, args...)
+	if err != nil {
+		return insertError("failed to insert product", err)
+	}
+
+	return nil
+}
+
+// CreateBatch inserts products with a single multi-row INSERT, so either all
+// rows are written or none are. An empty batch is a no-op.
+func (r *ProductRepository) CreateBatch(ctx context.Context, products []*domain.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+
+	db, err := r.getDB(ctx)
+	if err != nil {
+		return fmt.Errorf(dbUnavailableErrMsg, dbutil.Unavailable(err))
+	}
+
+	query, args, err := r.batchInsertQuery(products)
+	if err != nil {
+		return err
 	}
 
 	_, err = db.Exec(ctx, query, args...)
 	if err != nil {
-		return fmt.Errorf("failed to insert product: %w", err)
+		return insertError("failed to insert products", err)
 	}
 
 	return nil
 }
 
+// insertError classifies a failed INSERT with dbutil.Statement. Product IDs
+// are generated UUIDs, so a conflict comes from the SKU index and also
+// matches ErrDuplicateSKU; anything else is described by msg.
+func insertError(msg string, err error) error {
+	err = dbutil.Statement(err)
+	if errors.Is(err, dbutil.ErrConflict) {
+		return fmt.Errorf("%w: %w", ErrDuplicateSKU, err)
+	}
+	return fmt.Errorf("%s: %w", msg, err)
+}
+
+// batchInsertQuery builds one INSERT with a VALUES row per product.
+func (r *ProductRepository) batchInsertQuery(products []*domain.Product) (string, []any, error) {
+	qb := database.NewQueryBuilder(database.PostgreSQL)
+	insert := qb.Insert((&domain.ProductEntity{}).TableName())
+	for i, product := range products {
+		if product == nil {
+			return "", nil, fmt.Errorf("product %d is nil", i)
+		}
+		columns, values := r.cols.AllFields(domain.ToProductEntity(product))
+		if i == 0 {
+			insert = insert.Columns(columns...)
+		}
+		insert = insert.Values(values...)
+	}
+
+	query, args, err := insert.ToSQL()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to build batch insert query: %w", dbutil.Internal(err))
+	}
+	return query, args, nil
+}
+
 // GetByID retrieves a product by its ID using type-safe column references
 func (r *ProductRepository) GetByID(ctx context.Context, id string) (*domain.Product, error) {
 	db, err := r.getDB(ctx)
 	if err != nil {
-		return nil, fmt.Errorf(dbUnavailableErrMsg, err)
+		return nil, fmt.Errorf(dbUnavailableErrMsg, dbutil.Unavailable(err))
+	}
+
+	return r.getByIDOn(ctx, db, id)
+}
+
+// GetBySKU retrieves a product by its SKU. SKUs are stored upper-case, so sku
+// must already be normalized.
+func (r *ProductRepository) GetBySKU(ctx context.Context, sku string) (*domain.Product, error) {
+	db, err := r.getDB(ctx)
+	if err != nil {
+		return nil, fmt.Errorf(dbUnavailableErrMsg, dbutil.Unavailable(err))
+	}
+
+	return r.selectOneOn(ctx, db, "SKU", sku, false)
+}
+
+// Exists reports whether a product with id exists, without reading the row.
+func (r *ProductRepository) Exists(ctx context.Context, id string) (bool, error) {
+	db, err := r.getDB(ctx)
+	if err != nil {
+		return false, fmt.Errorf(dbUnavailableErrMsg, dbutil.Unavailable(err))
+	}
+
+	qb := database.NewQueryBuilder(database.PostgreSQL)
+	f := qb.Filter()
+
+	query, args, err := qb.Select("1").
+		From("products").
+		Where(r.notDeleted(f, f.Eq(r.cols.Col("ID"), id))).
+		Limit(1).
+		ToSQL()
+	if err != nil {
+		return false, fmt.Errorf("failed to build exists query: %w", dbutil.Internal(err))
+	}
+
+	var one int
+	if err := db.QueryRow(ctx, query, args...).Scan(&one); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to check product existence: %w", dbutil.Internal(err))
+	}
+
+	return true, nil
+}
+
+// CategoryCurrency reports whether a category with id exists and the
+// currency its products must be priced in, which is empty for a category
+// that accepts any currency. It lets the repository serve as the service's
+// category checker.
+func (r *ProductRepository) CategoryCurrency(ctx context.Context, id string) (string, bool, error) {
+	db, err := r.getDB(ctx)
+	if err != nil {
+		return "", false, fmt.Errorf(dbUnavailableErrMsg, dbutil.Unavailable(err))
 	}
 
 	qb := database.NewQueryBuilder(database.PostgreSQL)
 	f := qb.Filter()
 
+	query, args, err := qb.Select("currency").
+		From("categories").
+		Where(f.Eq("id", id)).
+		Limit(1).
+		ToSQL()
+	if err != nil {
+		return "", false, fmt.Errorf("failed to build category currency query: %w", dbutil.Internal(err))
+	}
+
+	var currency *string
+	if err := db.QueryRow(ctx, query, args...).Scan(&currency); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", false, nil
+		}
+		return "", false, fmt.Errorf("failed to read category currency: %w", dbutil.Internal(err))
+	}
+
+	if currency == nil {
+		return "", true, nil
+	}
+	return *currency, true, nil
+}
+
+// txOrDB is what reads and updates run on: a database.Interface or a dbtypes.Tx.
+type txOrDB interface {
+	QueryRow(ctx context.Context, query string, args ...any) dbtypes.Row
+	Exec(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// getByIDOn reads a product by ID on the given executor (db or tx).
+func (r *ProductRepository) getByIDOn(ctx context.Context, executor txOrDB, id string) (*domain.Product, error) {
+	return r.selectByIDOn(ctx, executor, id, false)
+}
+
+// lockByIDOn reads a product by ID with SELECT ... FOR UPDATE, holding the
+// row lock until tx ends.
+func (r *ProductRepository) lockByIDOn(ctx context.Context, tx dbtypes.Tx, id string) (*domain.Product, error) {
+	return r.selectByIDOn(ctx, tx, id, true)
+}
+
+func (r *ProductRepository) selectByIDOn(ctx context.Context, executor txOrDB, id string, forUpdate bool) (*domain.Product, error) {
+	return r.selectOneOn(ctx, executor, "ID", id, forUpdate)
+}
+
+// selectOneOn reads the live product whose unique field (a ProductEntity
+// field name) equals value.
+func (r *ProductRepository) selectOneOn(ctx context.Context, executor txOrDB, field, value string, forUpdate bool) (*domain.Product, error) {
+	qb := database.NewQueryBuilder(database.PostgreSQL)
+	f := qb.Filter()
+
 	// Use cols.All() for type-safe column selection and cols.Col() for filter
 	query, args, err := qb.Select(r.cols.All()).
 		From("products").
-		Where(f.Eq(r.cols.Col("ID"), id)).
+		Where(r.notDeleted(f, f.Eq(r.cols.Col(field), value))).
 		ToSQL()
 	if err != nil {
-		return nil, fmt.Errorf("failed to build select query: %w", err)
+		return nil, fmt.Errorf("failed to build select query: %w", dbutil.Internal(err))
+	}
+	if forUpdate {
+		query += " FOR UPDATE"
 	}
 
 	var entity domain.ProductEntity
-	row := db.QueryRow(ctx, query, args...)
+	row := executor.QueryRow(ctx, query, args...)
 	err = row.Scan(
 		&entity.ID,
+		&entity.SKU,
 		&entity.Name,
 		&entity.Description,
-		&entity.Price,
+		&entity.PriceMinor,
+		&entity.Currency,
 		&entity.ImageURL,
 		&entity.CreatedDate,
 		&entity.UpdatedDate,
+		&entity.Version,
+		&entity.Locked,
+		&entity.CategoryID,
+		&entity.StockQuantity,
 	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, ErrProductNotFound
 		}
-		return nil, fmt.Errorf("failed to scan product: %w", err)
+		return nil, fmt.Errorf("failed to scan product: %w", dbutil.Internal(err))
 	}
 
 	return domain.ToProduct(&entity), nil
 }
 
-// List retrieves a paginated list of products with total count using type-safe columns
-func (r *ProductRepository) List(ctx context.Context, limit, offset int) ([]*domain.Product, int, error) {
+// List retrieves a paginated list of products with total count using type-safe columns.
+// Only products matching filter are returned and the total counts the same
+// filtered rows. Rows are ordered by sort, which must name one of the
+// SortField constants. A limit above the configured maximum is clamped to it.
+func (r *ProductRepository) List(ctx context.Context, limit, offset int, filter ListFilter, sort Sort) ([]*domain.Product, int, error) {
+	limit = r.clampLimit(ctx, limit)
+
+	sortCol, tiebreakCol, err := r.orderBy(sort)
+	if err != nil {
+		return nil, 0, err
+	}
+
 	db, err := r.getDB(ctx)
 	if err != nil {
-		return nil, 0, fmt.Errorf(dbUnavailableErrMsg, err)
+		return nil, 0, fmt.Errorf(dbUnavailableErrMsg, dbutil.Unavailable(err))
 	}
 
 	qb := database.NewQueryBuilder(database.PostgreSQL)
+	countBuilder := qb.Select("COUNT(*)").From("products")
+	listBuilder := qb.Select(r.cols.All()).From("products")
+
+	f := qb.Filter()
+	if r.softDeletes() {
+		countBuilder = countBuilder.Where(f.Null(columnDeletedAt))
+		listBuilder = listBuilder.Where(f.Null(columnDeletedAt))
+	}
+	if filter.Search != "" {
+		// Like wraps the term in % wildcards itself.
+		term := likeEscaper.Replace(filter.Search)
+		match := f.Or(
+			f.Like(r.cols.Col("Name"), term),
+			f.Like(r.cols.Col("Description"), term),
+		)
+		countBuilder = countBuilder.Where(match)
+		listBuilder = listBuilder.Where(match)
+	}
+	if filter.Currency != "" {
+		inCurrency := f.Eq(r.cols.Col("Currency"), filter.Currency)
+		countBuilder = countBuilder.Where(inCurrency)
+		listBuilder = listBuilder.Where(inCurrency)
+	}
+	if filter.CategoryID != "" {
+		inCategory := f.Eq(r.cols.Col("CategoryID"), filter.CategoryID)
+		countBuilder = countBuilder.Where(inCategory)
+		listBuilder = listBuilder.Where(inCategory)
+	}
+	if filter.MinPrice != nil {
+		atLeast := f.Gte(r.cols.Col("PriceMinor"), *filter.MinPrice)
+		countBuilder = countBuilder.Where(atLeast)
+		listBuilder = listBuilder.Where(atLeast)
+	}
+	if filter.MaxPrice != nil {
+		atMost := f.Lte(r.cols.Col("PriceMinor"), *filter.MaxPrice)
+		countBuilder = countBuilder.Where(atMost)
+		listBuilder = listBuilder.Where(atMost)
+	}
 
 	// First, get total count
-	countQuery, countArgs, err := qb.Select("COUNT(*)").
-		From("products").
-		ToSQL()
+	countQuery, countArgs, err := countBuilder.ToSQL()
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to build count query: %w", err)
+		return nil, 0, fmt.Errorf("failed to build count query: %w", dbutil.Internal(err))
 	}
 
-	var total int
-	countRow := db.QueryRow(ctx, countQuery, countArgs...)
-	if err := countRow.Scan(&total); err != nil {
-		return nil, 0, fmt.Errorf("failed to get total count: %w", err)
+	total, err := dbutil.ScanScalar[int](ctx, db, countQuery, countArgs...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get total count: %w", dbutil.Internal(err))
 	}
 
 	// Use cols.All() for type-safe column selection and cols.Col() for ordering
-	query, args, err := qb.Select(r.cols.All()).
-		From("products").
-		OrderBy(r.cols.Col("CreatedDate") + " DESC").
+	query, args, err := listBuilder.
+		OrderBy(sortCol, tiebreakCol).
 		Limit(uint64(limit)).
 		Offset(uint64(offset)).
 		ToSQL()
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to build list query: %w", err)
+		return nil, 0, fmt.Errorf("failed to build list query: %w", dbutil.Internal(err))
 	}
 
 	rows, err := db.Query(ctx, query, args...)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to query products: %w", err)
+		return nil, 0, fmt.Errorf("failed to query products: %w", dbutil.Internal(err))
 	}
 	defer rows.Close()
 
@@ -160,47 +621,375 @@ func (r *ProductRepository) List(ctx context.Context, limit, offset int) ([]*dom
 		var entity domain.ProductEntity
 		err := rows.Scan(
 			&entity.ID,
+			&entity.SKU,
 			&entity.Name,
 			&entity.Description,
-			&entity.Price,
+			&entity.PriceMinor,
+			&entity.Currency,
 			&entity.ImageURL,
 			&entity.CreatedDate,
 			&entity.UpdatedDate,
+			&entity.Version,
+			&entity.Locked,
+			&entity.CategoryID,
+			&entity.StockQuantity,
 		)
 		if err != nil {
-			return nil, 0, fmt.Errorf("failed to scan product: %w", err)
+			return nil, 0, fmt.Errorf("failed to scan product: %w", dbutil.Internal(err))
 		}
 		entities = append(entities, &entity)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, 0, fmt.Errorf("error iterating products: %w", err)
+		return nil, 0, fmt.Errorf("error iterating products: %w", dbutil.Internal(err))
 	}
 
 	products := domain.ToProductList(entities)
 	return products, total, nil
 }
 
+// orderBy returns the ORDER BY terms for sort using type-safe column names:
+// the sort column and id DESC as a tiebreaker. They are separate terms
+// because the query builder takes one column per ORDER BY argument.
+func (r *ProductRepository) orderBy(sort Sort) (sortCol, tiebreakCol string, err error) {
+	if sort.Field == "" {
+		sort = DefaultSort
+	}
+
+	field, ok := sortColumns[sort.Field]
+	if !ok {
+		return "", "", fmt.Errorf("unsupported sort field %q", sort.Field)
+	}
+
+	// id breaks ties so rows sharing a sort value, such as a batch inserted in
+	// the same millisecond, keep one order across pages.
+	tiebreakCol = r.cols.Col("ID") + " DESC"
+	if sort.Descending {
+		return r.cols.Col(field) + " DESC", tiebreakCol, nil
+	}
+	return r.cols.Col(field) + " ASC", tiebreakCol, nil
+}
+
+// ListAfter returns up to limit products, newest first, that sort after the
+// cursor position; a nil cursor starts from the newest product. Rows are
+// ordered by (created_date, id) so pages stay stable under concurrent inserts
+// and deep pages cost the same as the first.
+func (r *ProductRepository) ListAfter(ctx context.Context, after *Cursor, limit int) ([]*domain.Product, error) {
+	db, err := r.getDB(ctx)
+	if err != nil {
+		return nil, fmt.Errorf(dbUnavailableErrMsg, dbutil.Unavailable(err))
+	}
+
+	qb := database.NewQueryBuilder(database.PostgreSQL)
+	f := qb.Filter()
+	createdDate := r.cols.Col("CreatedDate")
+	id := r.cols.Col("ID")
+
+	sb := qb.Select(r.cols.All()).From("products")
+	if r.softDeletes() {
+		sb = sb.Where(f.Null(columnDeletedAt))
+	}
+	if after != nil {
+		sb = sb.Where(f.Or(
+			f.Lt(createdDate, after.CreatedDate),
+			f.And(f.Eq(createdDate, after.CreatedDate), f.Lt(id, after.ID)),
+		))
+	}
+	query, args, err := sb.
+		OrderBy(createdDate+" DESC", id+" DESC").
+		Limit(uint64(limit)).
+		ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build keyset list query: %w", dbutil.Internal(err))
+	}
+
+	rows, err := db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query products: %w", dbutil.Internal(err))
+	}
+	defer rows.Close()
+
+	var entities []*domain.ProductEntity
+	for rows.Next() {
+		var entity domain.ProductEntity
+		err := rows.Scan(
+			&entity.ID,
+			&entity.SKU,
+			&entity.Name,
+			&entity.Description,
+			&entity.PriceMinor,
+			&entity.Currency,
+			&entity.ImageURL,
+			&entity.CreatedDate,
+			&entity.UpdatedDate,
+			&entity.Version,
+			&entity.Locked,
+			&entity.CategoryID,
+			&entity.StockQuantity,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan product: %w", dbutil.Internal(err))
+		}
+		entities = append(entities, &entity)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating products: %w", dbutil.Internal(err))
+	}
+
+	return domain.ToProductList(entities), nil
+}
+
+// Stream reads all products ordered by creation date and passes each one to fn
+// as soon as it is scanned, so memory use stays constant regardless of table size.
+// Iteration stops at the first error returned by fn, which is returned unchanged.
+func (r *ProductRepository) Stream(ctx context.Context, opts StreamOptions, fn func(*domain.Product) error) error {
+	db, err := r.getDB(ctx)
+	if err != nil {
+		return fmt.Errorf(dbUnavailableErrMsg, dbutil.Unavailable(err))
+	}
+
+	qb := database.NewQueryBuilder(database.PostgreSQL)
+	sb := qb.Select(r.cols.All()).From("products")
+	if r.softDeletes() && !opts.IncludeDeleted {
+		sb = sb.Where(qb.Filter().Null(columnDeletedAt))
+	}
+	if opts.StockBelow > 0 {
+		sb = sb.Where(qb.Filter().Lt(r.cols.Col("StockQuantity"), opts.StockBelow))
+	}
+	query, args, err := sb.
+		OrderBy(r.cols.Col("CreatedDate") + " DESC").
+		ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build stream query: %w", dbutil.Internal(err))
+	}
+
+	rows, err := db.Query(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to query products: %w", dbutil.Internal(err))
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entity domain.ProductEntity
+		err := rows.Scan(
+			&entity.ID,
+			&entity.SKU,
+			&entity.Name,
+			&entity.Description,
+			&entity.PriceMinor,
+			&entity.Currency,
+			&entity.ImageURL,
+			&entity.CreatedDate,
+			&entity.UpdatedDate,
+			&entity.Version,
+			&entity.Locked,
+			&entity.CategoryID,
+			&entity.StockQuantity,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to scan product: %w", dbutil.Internal(err))
+		}
+		if err := fn(domain.ToProduct(&entity)); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating products: %w", dbutil.Internal(err))
+	}
+
+	return nil
+}
+
+// FindDuplicates groups products by case-insensitive name and returns every
+// group with more than one member, largest first. Product IDs within a group
+// are sorted so reports are stable between runs.
+func (r *ProductRepository) FindDuplicates(ctx context.Context) ([]DuplicateGroup, error) {
+	db, err := r.getDB(ctx)
+	if err != nil {
+		return nil, fmt.Errorf(dbUnavailableErrMsg, dbutil.Unavailable(err))
+	}
+
+	normalizedName := "LOWER(" + r.cols.Col("Name") + ")"
+	id := r.cols.Col("ID")
+
+	qb := database.NewQueryBuilder(database.PostgreSQL)
+	sb := qb.Select(
+		qb.MustExpr(normalizedName, "duplicate_key"),
+		qb.MustExpr("STRING_AGG("+id+", ',' ORDER BY "+id+")", "product_ids"),
+	).
+		From("products")
+	if r.softDeletes() {
+		sb = sb.Where(qb.Filter().Null(columnDeletedAt))
+	}
+	query, args, err := sb.
+		GroupBy(qb.MustExpr(normalizedName)).
+		Having("COUNT(*) > ?", 1).
+		OrderBy(qb.MustExpr("COUNT(*) DESC"), qb.MustExpr(normalizedName)).
+		ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build duplicates query: %w", dbutil.Internal(err))
+	}
+
+	rows, err := db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query duplicate products: %w", dbutil.Internal(err))
+	}
+	defer rows.Close()
+
+	var groups []DuplicateGroup
+	for rows.Next() {
+		var key, ids string
+		if err := rows.Scan(&key, &ids); err != nil {
+			return nil, fmt.Errorf("failed to scan duplicate group: %w", dbutil.Internal(err))
+		}
+		groups = append(groups, DuplicateGroup{
+			Field:      DuplicateFieldName,
+			Key:        key,
+			ProductIDs: strings.Split(ids, ","),
+		})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating duplicate groups: %w", dbutil.Internal(err))
+	}
+
+	return groups, nil
+}
+
+// PriceStats computes the minimum, maximum and average price of the products
+// priced in currency. A non-nil category, a category ID, restricts the
+// statistics to that category.
+func (r *ProductRepository) PriceStats(ctx context.Context, currency string, category *string) (PriceStats, error) {
+	db, err := r.getDB(ctx)
+	if err != nil {
+		return PriceStats{}, fmt.Errorf(dbUnavailableErrMsg, dbutil.Unavailable(err))
+	}
+
+	// Aggregates are NULL on an empty table; COALESCE turns them into zeros.
+	price := r.cols.Col("PriceMinor")
+	qb := database.NewQueryBuilder(database.PostgreSQL)
+	f := qb.Filter()
+	match := f.Eq(r.cols.Col("Currency"), currency)
+	if category != nil {
+		match = f.And(match, f.Eq(r.cols.Col("CategoryID"), *category))
+	}
+	sb := qb.Select(
+		qb.MustExpr("COALESCE(MIN("+price+"), 0)", "min_price"),
+		qb.MustExpr("COALESCE(MAX("+price+"), 0)", "max_price"),
+		qb.MustExpr("COALESCE(AVG("+price+"), 0)", "avg_price"),
+		qb.MustExpr("COUNT(*)", "product_count"),
+	).
+		From("products").
+		Where(r.notDeleted(f, match))
+	query, args, err := sb.ToSQL()
+	if err != nil {
+		return PriceStats{}, fmt.Errorf("failed to build price stats query: %w", dbutil.Internal(err))
+	}
+
+	stats := PriceStats{Currency: currency}
+	row := db.QueryRow(ctx, query, args...)
+	if err := row.Scan(&stats.Min, &stats.Max, &stats.Avg, &stats.Count); err != nil {
+		return PriceStats{}, fmt.Errorf("failed to scan price stats: %w", dbutil.Internal(err))
+	}
+
+	return stats, nil
+}
+
 // Update performs a partial update on a product using type-safe column mapping
 func (r *ProductRepository) Update(ctx context.Context, id string, updates map[string]any) error {
 	db, err := r.getDB(ctx)
 	if err != nil {
-		return fmt.Errorf(dbUnavailableErrMsg, err)
+		return fmt.Errorf(dbUnavailableErrMsg, dbutil.Unavailable(err))
+	}
+
+	return r.execUpdateOn(ctx, db, id, updates)
+}
+
+// UpdateAndFetch applies updates and re-reads the product in one transaction.
+// The UPDATE locks the row, so the returned product is exactly the result of
+// this update even when other writers race with it. It returns
+// ErrProductNotFound when no product has the given ID and
+// ErrConcurrentModification when updates carry a stale version.
+func (r *ProductRepository) UpdateAndFetch(ctx context.Context, id string, updates map[string]any) (*domain.Product, error) {
+	var product *domain.Product
+	err := r.withTx(ctx, func(tx dbtypes.Tx) error {
+		if err := r.execUpdateOn(ctx, tx, id, updates); err != nil {
+			return err
+		}
+
+		var err error
+		product, err = r.getByIDOn(ctx, tx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return product, nil
+}
+
+// UpdateAndCompare is UpdateAndFetch that also returns the product as it was
+// before the update. The row is locked while it is read, so no other writer
+// can change it between the two reads and before/after differ by exactly
+// this update.
+func (r *ProductRepository) UpdateAndCompare(ctx context.Context, id string, updates map[string]any) (before, after *domain.Product, err error) {
+	err = r.withTx(ctx, func(tx dbtypes.Tx) error {
+		var err error
+		if before, err = r.lockByIDOn(ctx, tx, id); err != nil {
+			return err
+		}
+		if before.Locked {
+			return ErrProductLocked
+		}
+		if err := r.execUpdateOn(ctx, tx, id, updates); err != nil {
+			return err
+		}
+		after, err = r.getByIDOn(ctx, tx, id)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return before, after, nil
+}
+
+// withTx runs fn in a new transaction, committing when fn succeeds and
+// rolling back when it returns an error. fn's error is returned unchanged.
+func (r *ProductRepository) withTx(ctx context.Context, fn func(tx dbtypes.Tx) error) error {
+	db, err := r.getDB(ctx)
+	if err != nil {
+		return fmt.Errorf(dbUnavailableErrMsg, dbutil.Unavailable(err))
 	}
 
-	// Check if product exists
-	_, err = r.GetByID(ctx, id)
+	tx, err := db.Begin(ctx)
 	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", dbutil.Internal(err))
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // no-op if already committed
+
+	if err := fn(tx); err != nil {
 		return err
 	}
 
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", dbutil.Internal(err))
+	}
+	return nil
+}
+
+// execUpdateOn builds and executes a partial UPDATE against any executor.
+// Every update also sets the updated date, which drives Last-Modified.
+func (r *ProductRepository) execUpdateOn(ctx context.Context, executor txOrDB, id string, updates map[string]any) error {
 	// Map JSON field names (camelCase per struct tags) to type-safe database column names
 	fieldToColumn := map[string]string{
 		fieldKeyName:  r.cols.Col("Name"),
 		"description": r.cols.Col("Description"),
-		"price":       r.cols.Col("Price"),
+		"price":       r.cols.Col("PriceMinor"),
 		"imageURL":    r.cols.Col("ImageURL"),
-		"updatedDate": r.cols.Col("UpdatedDate"),
+		"categoryId":  r.cols.Col("CategoryID"),
 	}
 
 	qb := database.NewQueryBuilder(database.PostgreSQL)
@@ -221,38 +1010,161 @@ func (r *ProductRepository) Update(ctx context.Context, id string, updates map[s
 		return fmt.Errorf("no valid fields to update")
 	}
 
+	updateBuilder = updateBuilder.Set(r.cols.Col("UpdatedDate"), time.Now().UTC())
+
+	// Every update bumps the version; an expected version makes it conditional.
+	version := r.cols.Col("Version")
+	updateBuilder = updateBuilder.Set(version, f.Raw(version+" + 1"))
+	match := r.unlocked(f, r.notDeleted(f, f.Eq(r.cols.Col("ID"), id)))
+	expectedVersion, checkVersion := updates[fieldKeyVersion].(int)
+	if checkVersion {
+		match = f.And(match, f.Eq(version, expectedVersion))
+	}
+
 	query, args, err := updateBuilder.
-		Where(f.Eq(r.cols.Col("ID"), id)).
+		Where(match).
 		ToSQL()
 	if err != nil {
-		return fmt.Errorf("failed to build update query: %w", err)
+		return fmt.Errorf("failed to build update query: %w", dbutil.Internal(err))
 	}
 
-	result, err := db.Exec(ctx, query, args...)
+	result, err := executor.Exec(ctx, query, args...)
 	if err != nil {
-		return fmt.Errorf("failed to update product: %w", err)
+		return fmt.Errorf("failed to update product: %w", dbutil.Statement(err))
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+		return fmt.Errorf("failed to get rows affected: %w", dbutil.Internal(err))
 	}
 
 	if rowsAffected == 0 {
+		// Nothing matched: tell a missing product from a locked one or a stale version.
+		product, err := r.getByIDOn(ctx, executor, id)
+		switch {
+		case err != nil:
+			return err
+		case product.Locked:
+			return ErrProductLocked
+		case checkVersion:
+			return ErrConcurrentModification
+		}
 		return ErrProductNotFound
 	}
 
 	return nil
 }
 
-// Delete removes a product from the database using type-safe column reference
+// Delete removes a product using type-safe column references: the row is
+// deleted under DeletePolicyHard and marked deleted under DeletePolicySoft.
 func (r *ProductRepository) Delete(ctx context.Context, id string) error {
 	db, err := r.getDB(ctx)
 	if err != nil {
-		return fmt.Errorf(dbUnavailableErrMsg, err)
+		return fmt.Errorf(dbUnavailableErrMsg, dbutil.Unavailable(err))
 	}
 
-	return r.execDelete(ctx, db, id)
+	return r.execDeleteOn(ctx, db, id)
+}
+
+// SetLocked sets the locked flag of a product. Apart from stock changes it is
+// the only write allowed on a locked product and leaves the version
+// unchanged, since the product's data does not change.
+func (r *ProductRepository) SetLocked(ctx context.Context, id string, locked bool) error {
+	db, err := r.getDB(ctx)
+	if err != nil {
+		return fmt.Errorf(dbUnavailableErrMsg, dbutil.Unavailable(err))
+	}
+
+	qb := database.NewQueryBuilder(database.PostgreSQL)
+	f := qb.Filter()
+	query, args, err := qb.Update("products").
+		Set(r.cols.Col("Locked"), locked).
+		Set(r.cols.Col("UpdatedDate"), time.Now().UTC()).
+		Where(r.notDeleted(f, f.Eq(r.cols.Col("ID"), id))).
+		ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build lock query: %w", dbutil.Internal(err))
+	}
+
+	result, err := db.Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to set product lock: %w", dbutil.Statement(err))
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", dbutil.Internal(err))
+	}
+
+	if rowsAffected == 0 {
+		return ErrProductNotFound
+	}
+
+	return nil
+}
+
+// Reserve decrements the stock of a product by qty. The stock check is part
+// of the UPDATE's WHERE clause, so the row lock taken by the UPDATE makes
+// check and decrement atomic: of two concurrent reservations for the last
+// units, one fails with ErrInsufficientStock. Stock changes leave the version
+// unchanged so they do not turn concurrent edits into conflicts, and they
+// apply to locked products, whose data they do not change.
+func (r *ProductRepository) Reserve(ctx context.Context, id string, qty int) error {
+	return r.changeStock(ctx, id, -qty)
+}
+
+// Release increments the stock of a product by qty, returning units taken
+// by Reserve.
+func (r *ProductRepository) Release(ctx context.Context, id string, qty int) error {
+	return r.changeStock(ctx, id, qty)
+}
+
+// changeStock adds delta to the stock of a product in one UPDATE. A negative
+// delta only applies while the stock covers it.
+func (r *ProductRepository) changeStock(ctx context.Context, id string, delta int) error {
+	db, err := r.getDB(ctx)
+	if err != nil {
+		return fmt.Errorf(dbUnavailableErrMsg, dbutil.Unavailable(err))
+	}
+
+	qb := database.NewQueryBuilder(database.PostgreSQL)
+	f := qb.Filter()
+	stock := r.cols.Col("StockQuantity")
+	match := r.notDeleted(f, f.Eq(r.cols.Col("ID"), id))
+	if delta < 0 {
+		match = f.And(match, f.Gte(stock, -delta))
+	}
+	query, args, err := qb.Update("products").
+		Set(stock, f.Raw(stock+" + ?", delta)).
+		Set(r.cols.Col("UpdatedDate"), time.Now().UTC()).
+		Where(match).
+		ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build stock query: %w", dbutil.Internal(err))
+	}
+
+	result, err := db.Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to update product stock: %w", dbutil.Statement(err))
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", dbutil.Internal(err))
+	}
+
+	if rowsAffected == 0 {
+		// Nothing matched: tell a missing product from one short of stock.
+		if delta >= 0 {
+			return ErrProductNotFound
+		}
+		if _, err := r.getByIDOn(ctx, db, id); err != nil {
+			return err
+		}
+		return ErrInsufficientStock
+	}
+
+	return nil
 }
 
 // CreateTx inserts a new product within an existing transaction.
@@ -271,12 +1183,35 @@ func (r *ProductRepository) CreateTx(ctx context.Context, tx dbtypes.Tx, product
 	qb := database.NewQueryBuilder(database.PostgreSQL)
 	query, args, err := qb.InsertStruct(entity.TableName(), entity).ToSQL()
 	if err != nil {
-		return fmt.Errorf("failed to build insert query: %w", err)
+		return fmt.Errorf("failed to build insert query: %w", dbutil.Internal(err))
 	}
 
 	_, err = tx.Exec(ctx, query, args...)
 	if err != nil {
-		return fmt.Errorf("failed to insert product: %w", err)
+		return insertError("failed to insert product", err)
+	}
+
+	return nil
+}
+
+// CreateBatchTx inserts products with a single multi-row INSERT within an
+// existing transaction. An empty batch is a no-op.
+func (r *ProductRepository) CreateBatchTx(ctx context.Context, tx dbtypes.Tx, products []*domain.Product) error {
+	if tx == nil {
+		return fmt.Errorf("transaction is required")
+	}
+	if len(products) == 0 {
+		return nil
+	}
+
+	query, args, err := r.batchInsertQuery(products)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, query, args...)
+	if err != nil {
+		return insertError("failed to insert products", err)
 	}
 
 	return nil
@@ -295,35 +1230,52 @@ func (r *ProductRepository) DeleteTx(ctx context.Context, tx dbtypes.Tx, id stri
 	return r.execDeleteOn(ctx, tx, id)
 }
 
-// execDelete runs a DELETE on the given executor (db or tx).
-func (r *ProductRepository) execDelete(ctx context.Context, executor dbtypes.Querier, id string) error {
-	return r.execDeleteOn(ctx, executor, id)
-}
-
-// execDeleteOn builds and executes a DELETE query against any executor.
-func (r *ProductRepository) execDeleteOn(ctx context.Context, executor interface {
-	Exec(ctx context.Context, query string, args ...any) (sql.Result, error)
-}, id string) error {
+// execDeleteOn builds and executes the delete for the repository's
+// DeletePolicy against any executor: a DELETE, or an UPDATE setting
+// deleted_at on a product not already deleted. Locked products are left
+// in place.
+func (r *ProductRepository) execDeleteOn(ctx context.Context, executor txOrDB, id string) error {
 	qb := database.NewQueryBuilder(database.PostgreSQL)
 	f := qb.Filter()
-	query, args, err := qb.Delete("products").
-		Where(f.Eq(r.cols.Col("ID"), id)).
-		ToSQL()
+	match := r.unlocked(f, f.Eq(r.cols.Col("ID"), id))
+
+	var query string
+	var args []any
+	var err error
+	if r.softDeletes() {
+		now := time.Now().UTC()
+		query, args, err = qb.Update("products").
+			Set(columnDeletedAt, now).
+			Set(r.cols.Col("UpdatedDate"), now).
+			Where(r.notDeleted(f, match)).
+			ToSQL()
+	} else {
+		query, args, err = qb.Delete("products").
+			Where(match).
+			ToSQL()
+	}
 	if err != nil {
-		return fmt.Errorf("failed to build delete query: %w", err)
+		return fmt.Errorf("failed to build delete query: %w", dbutil.Internal(err))
 	}
 
 	result, err := executor.Exec(ctx, query, args...)
 	if err != nil {
-		return fmt.Errorf("failed to delete product: %w", err)
+		return fmt.Errorf("failed to delete product: %w", dbutil.Statement(err))
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+		return fmt.Errorf("failed to get rows affected: %w", dbutil.Internal(err))
 	}
 
 	if rowsAffected == 0 {
+		product, err := r.getByIDOn(ctx, executor, id)
+		if err != nil {
+			return err
+		}
+		if product.Locked {
+			return ErrProductLocked
+		}
 		return ErrProductNotFound
 	}
 
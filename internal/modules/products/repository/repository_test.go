@@ -4,18 +4,28 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"reflect"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/gaborage/go-bricks-demo-project/internal/modules/products/domain"
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/shared/dbutil"
 	"github.com/gaborage/go-bricks/database"
 	dbtest "github.com/gaborage/go-bricks/database/testing"
 	dbtypes "github.com/gaborage/go-bricks/database/types"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	testSKU        = "MUG-001"
+	testCategoryID = "7c9e6679-7425-40de-944b-e07fc1f90ae2"
 )
 
 func TestCreate(t *testing.T) {
 	ctx := context.Background()
-	product := domain.New("test-id", "Test Product", "Description", 99.99, "https://example.com/image.jpg")
+	product := domain.New("test-id", testSKU, "Test Product", "Description", 9999, domain.DefaultCurrency, "https://example.com/image.jpg")
 
 	t.Run("successful create", func(t *testing.T) {
 		db := dbtest.NewTestDB(dbtypes.PostgreSQL)
@@ -48,6 +58,83 @@ func TestCreate(t *testing.T) {
 		if err == nil {
 			t.Error("Create() expected error, got nil")
 		}
+		if errors.Is(err, ErrDuplicateSKU) {
+			t.Errorf("Create() error = %v, want it not to be ErrDuplicateSKU", err)
+		}
+	})
+
+	t.Run("duplicate SKU", func(t *testing.T) {
+		db := dbtest.NewTestDB(dbtypes.PostgreSQL)
+		db.ExpectExec("INSERT INTO products").WillReturnError(&pgconn.PgError{Code: "23505", ConstraintName: "uq_products_sku"})
+
+		getDB := func(ctx context.Context) (database.Interface, error) {
+			return db, nil
+		}
+
+		repo := NewSQLProductRepository(getDB)
+		if err := repo.Create(ctx, product); !errors.Is(err, ErrDuplicateSKU) {
+			t.Errorf("Create() error = %v, want ErrDuplicateSKU", err)
+		}
+	})
+}
+
+func TestCreateBatch(t *testing.T) {
+	ctx := context.Background()
+	products := []*domain.Product{
+		domain.New("id-1", testSKU, "First", "Description", 150, domain.DefaultCurrency, ""),
+		domain.New("id-2", testSKU, "Second", "Description", 250, domain.DefaultCurrency, ""),
+		domain.New("id-3", testSKU, "Third", "Description", 350, domain.DefaultCurrency, ""),
+	}
+
+	t.Run("single multi-row insert", func(t *testing.T) {
+		db := dbtest.NewTestDB(dbtypes.PostgreSQL)
+		db.ExpectExec("INSERT INTO products").WillReturnRowsAffected(int64(len(products)))
+
+		getDB := func(ctx context.Context) (database.Interface, error) {
+			return db, nil
+		}
+
+		repo := NewSQLProductRepository(getDB)
+		if err := repo.CreateBatch(ctx, products); err != nil {
+			t.Fatalf("CreateBatch() unexpected error = %v", err)
+		}
+
+		dbtest.AssertExecCount(t, db, "INSERT", 1)
+		call := db.ExecLog()[0]
+		if rows := strings.Count(call.SQL, "),("); rows != len(products)-1 {
+			t.Errorf("CreateBatch() query %q has %d VALUES rows, want %d", call.SQL, rows+1, len(products))
+		}
+		columns, _ := repo.cols.AllFields(domain.ToProductEntity(products[0]))
+		if want := len(products) * len(columns); len(call.Args) != want {
+			t.Errorf("CreateBatch() args = %d, want %d", len(call.Args), want)
+		}
+	})
+
+	t.Run("empty batch", func(t *testing.T) {
+		db := dbtest.NewTestDB(dbtypes.PostgreSQL)
+		getDB := func(ctx context.Context) (database.Interface, error) {
+			return db, nil
+		}
+
+		repo := NewSQLProductRepository(getDB)
+		if err := repo.CreateBatch(ctx, nil); err != nil {
+			t.Errorf("CreateBatch() unexpected error = %v", err)
+		}
+		dbtest.AssertExecNotExecuted(t, db, "INSERT")
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		db := dbtest.NewTestDB(dbtypes.PostgreSQL)
+		db.ExpectExec("INSERT INTO products").WillReturnError(errors.New("database error"))
+
+		getDB := func(ctx context.Context) (database.Interface, error) {
+			return db, nil
+		}
+
+		repo := NewSQLProductRepository(getDB)
+		if err := repo.CreateBatch(ctx, products); err == nil {
+			t.Error("CreateBatch() expected error, got nil")
+		}
 	})
 }
 
@@ -59,8 +146,8 @@ func TestGetByID(t *testing.T) {
 		db := dbtest.NewTestDB(dbtypes.PostgreSQL)
 		db.ExpectQuery("SELECT").
 			WillReturnRows(
-				dbtest.NewRowSet("id", "name", "description", "price", "image_url", "created_date", "updated_date").
-					AddRow("test-id", "Test Product", "Description", 99.99, "https://example.com/image.jpg", now, now),
+				dbtest.NewRowSet("id", "sku", "name", "description", "price_minor", "currency", "image_url", "created_date", "updated_date", "version", "locked", "category_id", "stock_quantity").
+					AddRow("test-id", testSKU, "Test Product", "Description", int64(9999), "USD", "https://example.com/image.jpg", now, now, 1, false, nil, 0),
 			)
 
 		getDB := func(ctx context.Context) (database.Interface, error) {
@@ -77,8 +164,8 @@ func TestGetByID(t *testing.T) {
 		if product.Name != "Test Product" {
 			t.Errorf("GetByID() name = %v, want %v", product.Name, "Test Product")
 		}
-		if product.Price != 99.99 {
-			t.Errorf("GetByID() price = %v, want %v", product.Price, 99.99)
+		if product.PriceMinor != 9999 || product.Currency != "USD" {
+			t.Errorf("GetByID() price = %d %s, want 9999 USD", product.PriceMinor, product.Currency)
 		}
 		dbtest.AssertQueryExecuted(t, db, "SELECT")
 	})
@@ -116,19 +203,139 @@ func TestGetByID(t *testing.T) {
 	})
 }
 
-func TestUpdate(t *testing.T) {
+func TestGetBySKU(t *testing.T) {
 	ctx := context.Background()
 	now := time.Now().UTC()
 
-	t.Run("successful update", func(t *testing.T) {
+	t.Run("successful get", func(t *testing.T) {
 		db := dbtest.NewTestDB(dbtypes.PostgreSQL)
-		// First call: GetByID check (SELECT)
-		db.ExpectQuery("SELECT").
+		db.ExpectQuery("sku").
 			WillReturnRows(
-				dbtest.NewRowSet("id", "name", "description", "price", "image_url", "created_date", "updated_date").
-					AddRow("test-id", "Test Product", "Description", 99.99, "https://example.com/image.jpg", now, now),
+				dbtest.NewRowSet("id", "sku", "name", "description", "price_minor", "currency", "image_url", "created_date", "updated_date", "version", "locked", "category_id", "stock_quantity").
+					AddRow("test-id", testSKU, "Test Product", "Description", int64(9999), "USD", "", now, now, 1, false, nil, 0),
 			)
-		// Second call: UPDATE
+
+		getDB := func(ctx context.Context) (database.Interface, error) {
+			return db, nil
+		}
+
+		repo := NewSQLProductRepository(getDB)
+		product, err := repo.GetBySKU(ctx, testSKU)
+
+		if err != nil {
+			t.Fatalf("GetBySKU() unexpected error = %v", err)
+		}
+		if product.ID != "test-id" || product.SKU != testSKU {
+			t.Errorf("GetBySKU() = %s/%s, want test-id/%s", product.ID, product.SKU, testSKU)
+		}
+		dbtest.AssertQueryExecuted(t, db, "sku")
+	})
+
+	t.Run("product not found", func(t *testing.T) {
+		db := dbtest.NewTestDB(dbtypes.PostgreSQL)
+		db.ExpectQuery("SELECT").WillReturnError(sql.ErrNoRows)
+
+		getDB := func(ctx context.Context) (database.Interface, error) {
+			return db, nil
+		}
+
+		repo := NewSQLProductRepository(getDB)
+		if _, err := repo.GetBySKU(ctx, "MISSING-1"); !errors.Is(err, ErrProductNotFound) {
+			t.Errorf("GetBySKU() error = %v, want %v", err, ErrProductNotFound)
+		}
+	})
+}
+
+func TestExists(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name       string
+		rows       *dbtest.RowSet
+		queryErr   error
+		wantExists bool
+		wantErr    bool
+	}{
+		{name: "exists", rows: dbtest.NewRowSet("?column?").AddRow(1), wantExists: true},
+		{name: "missing", rows: dbtest.NewRowSet("?column?")},
+		{name: "database error", queryErr: errors.New("database error"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := dbtest.NewTestDB(dbtypes.PostgreSQL)
+			if tt.queryErr != nil {
+				db.ExpectQuery("SELECT 1 FROM products").WillReturnError(tt.queryErr)
+			} else {
+				db.ExpectQuery("SELECT 1 FROM products").WillReturnRows(tt.rows)
+			}
+			getDB := func(ctx context.Context) (database.Interface, error) {
+				return db, nil
+			}
+
+			repo := NewSQLProductRepository(getDB)
+			exists, err := repo.Exists(ctx, "test-id")
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Exists() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if exists != tt.wantExists {
+				t.Errorf("Exists() = %v, want %v", exists, tt.wantExists)
+			}
+			dbtest.AssertQueryExecuted(t, db, "LIMIT 1")
+		})
+	}
+}
+
+func TestCategoryCurrency(t *testing.T) {
+	ctx := context.Background()
+	eur := "EUR"
+
+	tests := []struct {
+		name         string
+		rows         *dbtest.RowSet
+		queryErr     error
+		wantCurrency string
+		wantExists   bool
+		wantErr      bool
+	}{
+		{name: "any currency", rows: dbtest.NewRowSet("currency").AddRow(nil), wantExists: true},
+		{name: "fixed currency", rows: dbtest.NewRowSet("currency").AddRow(&eur), wantCurrency: "EUR", wantExists: true},
+		{name: "missing", rows: dbtest.NewRowSet("currency")},
+		{name: "database error", queryErr: errors.New("database error"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := dbtest.NewTestDB(dbtypes.PostgreSQL)
+			if tt.queryErr != nil {
+				db.ExpectQuery("SELECT currency FROM categories").WillReturnError(tt.queryErr)
+			} else {
+				db.ExpectQuery("SELECT currency FROM categories").WillReturnRows(tt.rows)
+			}
+			getDB := func(ctx context.Context) (database.Interface, error) {
+				return db, nil
+			}
+
+			repo := NewSQLProductRepository(getDB)
+			currency, exists, err := repo.CategoryCurrency(ctx, testCategoryID)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CategoryCurrency() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if currency != tt.wantCurrency || exists != tt.wantExists {
+				t.Errorf("CategoryCurrency() = %q, %v, want %q, %v", currency, exists, tt.wantCurrency, tt.wantExists)
+			}
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	t.Run("successful update", func(t *testing.T) {
+		db := dbtest.NewTestDB(dbtypes.PostgreSQL)
 		db.ExpectExec("UPDATE products").WillReturnRowsAffected(1)
 
 		getDB := func(ctx context.Context) (database.Interface, error) {
@@ -138,17 +345,48 @@ func TestUpdate(t *testing.T) {
 		repo := NewSQLProductRepository(getDB)
 		err := repo.Update(ctx, "test-id", map[string]any{
 			fieldKeyName: "Updated Name",
-			"price":      149.99,
+			"price":      int64(14999),
 		})
 
 		if err != nil {
 			t.Errorf("Update() unexpected error = %v", err)
 		}
 		dbtest.AssertExecExecuted(t, db, "UPDATE")
+		dbtest.AssertQueryNotExecuted(t, db, "SELECT")
+	})
+
+	t.Run("sets the updated date", func(t *testing.T) {
+		db := dbtest.NewTestDB(dbtypes.PostgreSQL)
+		db.ExpectExec("UPDATE products").WillReturnRowsAffected(1)
+
+		getDB := func(ctx context.Context) (database.Interface, error) {
+			return db, nil
+		}
+
+		repo := NewSQLProductRepository(getDB)
+		before := time.Now().UTC()
+		if err := repo.Update(ctx, "test-id", map[string]any{fieldKeyName: "Updated Name"}); err != nil {
+			t.Fatalf("Update() unexpected error = %v", err)
+		}
+
+		call := db.ExecLog()[0]
+		if !strings.Contains(call.SQL, "updated_date = $") {
+			t.Errorf("Update() query %q does not set updated_date", call.SQL)
+		}
+		var updated time.Time
+		for _, arg := range call.Args {
+			if ts, ok := arg.(time.Time); ok {
+				updated = ts
+			}
+		}
+		if updated.Before(before) || updated.Location() != time.UTC {
+			t.Errorf("Update() updated_date = %v, want a UTC time no earlier than %v", updated, before)
+		}
 	})
 
-	t.Run("product not found on get", func(t *testing.T) {
+	t.Run("product not found", func(t *testing.T) {
 		db := dbtest.NewTestDB(dbtypes.PostgreSQL)
+		db.ExpectExec("UPDATE products").WillReturnRowsAffected(0)
 		db.ExpectQuery("SELECT").WillReturnError(sql.ErrNoRows)
 
 		getDB := func(ctx context.Context) (database.Interface, error) {
@@ -165,12 +403,12 @@ func TestUpdate(t *testing.T) {
 
 	t.Run("no rows affected", func(t *testing.T) {
 		db := dbtest.NewTestDB(dbtypes.PostgreSQL)
+		db.ExpectExec("UPDATE products").WillReturnRowsAffected(0)
 		db.ExpectQuery("SELECT").
 			WillReturnRows(
-				dbtest.NewRowSet("id", "name", "description", "price", "image_url", "created_date", "updated_date").
-					AddRow("test-id", "Test Product", "Description", 99.99, "https://example.com/image.jpg", now, now),
+				dbtest.NewRowSet("id", "sku", "name", "description", "price_minor", "currency", "image_url", "created_date", "updated_date", "version", "locked", "category_id", "stock_quantity").
+					AddRow("test-id", testSKU, "Test Product", "Description", int64(9999), "USD", "https://example.com/image.jpg", now, now, 1, false, nil, 0),
 			)
-		db.ExpectExec("UPDATE products").WillReturnRowsAffected(0)
 
 		getDB := func(ctx context.Context) (database.Interface, error) {
 			return db, nil
@@ -185,9 +423,228 @@ func TestUpdate(t *testing.T) {
 	})
 }
 
+func TestUpdateVersion(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+	existing := func() *dbtest.RowSet {
+		return dbtest.NewRowSet("id", "sku", "name", "description", "price_minor", "currency", "image_url", "created_date", "updated_date", "version", "locked", "category_id", "stock_quantity").
+			AddRow("test-id", testSKU, "Test Product", "Description", int64(9999), "USD", "", now, now, 4, false, nil, 0)
+	}
+
+	tests := []struct {
+		name         string
+		updates      map[string]any
+		rowsAffected int64
+		wantErr      error
+		wantSQL      []string
+	}{
+		{
+			name:         "unconditional update bumps the version",
+			updates:      map[string]any{fieldKeyName: "Renamed"},
+			rowsAffected: 1,
+			wantSQL:      []string{"version = version + 1"},
+		},
+		{
+			name:         "matching version",
+			updates:      map[string]any{fieldKeyName: "Renamed", fieldKeyVersion: 4},
+			rowsAffected: 1,
+			wantSQL:      []string{"version = version + 1", "version = $"},
+		},
+		{
+			name:         "stale version",
+			updates:      map[string]any{fieldKeyName: "Renamed", fieldKeyVersion: 3},
+			rowsAffected: 0,
+			wantErr:      ErrConcurrentModification,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := dbtest.NewTestDB(dbtypes.PostgreSQL)
+			db.ExpectExec("UPDATE products").WillReturnRowsAffected(tt.rowsAffected)
+			db.ExpectQuery("SELECT").WillReturnRows(existing())
+
+			getDB := func(ctx context.Context) (database.Interface, error) {
+				return db, nil
+			}
+
+			repo := NewSQLProductRepository(getDB)
+			err := repo.Update(ctx, "test-id", tt.updates)
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Update() error = %v, want %v", err, tt.wantErr)
+			}
+			call := db.ExecLog()[0]
+			for _, want := range tt.wantSQL {
+				if !strings.Contains(call.SQL, want) {
+					t.Errorf("Update() query %q does not contain %q", call.SQL, want)
+				}
+			}
+			if version, ok := tt.updates[fieldKeyVersion]; ok && !slices.Contains(call.Args, version) {
+				t.Errorf("Update() args = %v, want expected version %v", call.Args, version)
+			}
+		})
+	}
+
+	t.Run("stale version of a deleted product is not found", func(t *testing.T) {
+		db := dbtest.NewTestDB(dbtypes.PostgreSQL)
+		db.ExpectTransaction().
+			ExpectExec("UPDATE products").WillReturnRowsAffected(0).
+			ExpectQuery("SELECT").WillReturnRows(dbtest.NewRowSet("id"))
+
+		getDB := func(ctx context.Context) (database.Interface, error) {
+			return db, nil
+		}
+
+		repo := NewSQLProductRepository(getDB)
+		_, err := repo.UpdateAndFetch(ctx, "missing-id", map[string]any{fieldKeyName: "Renamed", fieldKeyVersion: 1})
+
+		if !errors.Is(err, ErrProductNotFound) {
+			t.Errorf("UpdateAndFetch() error = %v, want %v", err, ErrProductNotFound)
+		}
+	})
+}
+
+func TestUpdateAndFetch(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	t.Run("update and re-read share a transaction", func(t *testing.T) {
+		db := dbtest.NewTestDB(dbtypes.PostgreSQL)
+		db.ExpectTransaction().
+			ExpectExec("UPDATE products").WillReturnRowsAffected(1).
+			ExpectQuery("SELECT").
+			WillReturnRows(
+				dbtest.NewRowSet("id", "sku", "name", "description", "price_minor", "currency", "image_url", "created_date", "updated_date", "version", "locked", "category_id", "stock_quantity").
+					AddRow("test-id", testSKU, "Updated Name", "Description", int64(14999), "USD", "https://example.com/image.jpg", now, now, 2, false, nil, 0),
+			)
+
+		getDB := func(ctx context.Context) (database.Interface, error) {
+			return db, nil
+		}
+
+		repo := NewSQLProductRepository(getDB)
+		product, err := repo.UpdateAndFetch(ctx, "test-id", map[string]any{
+			fieldKeyName: "Updated Name",
+			"price":      int64(14999),
+		})
+		if err != nil {
+			t.Fatalf("UpdateAndFetch() unexpected error = %v", err)
+		}
+
+		if product.Name != "Updated Name" || product.PriceMinor != 14999 {
+			t.Errorf("UpdateAndFetch() = %+v, want the updated product", product)
+		}
+		dbtest.AssertTransactionCommitted(t, db)
+		// The re-read must run on the transaction, not on a pooled connection.
+		dbtest.AssertQueryNotExecuted(t, db, "SELECT")
+	})
+
+	t.Run("product not found rolls back", func(t *testing.T) {
+		db := dbtest.NewTestDB(dbtypes.PostgreSQL)
+		db.ExpectTransaction().
+			ExpectExec("UPDATE products").WillReturnRowsAffected(0).
+			ExpectQuery("SELECT").WillReturnRows(dbtest.NewRowSet("id"))
+
+		getDB := func(ctx context.Context) (database.Interface, error) {
+			return db, nil
+		}
+
+		repo := NewSQLProductRepository(getDB)
+		product, err := repo.UpdateAndFetch(ctx, "missing-id", map[string]any{fieldKeyName: "Updated"})
+
+		if !errors.Is(err, ErrProductNotFound) {
+			t.Errorf("UpdateAndFetch() error = %v, want %v", err, ErrProductNotFound)
+		}
+		if product != nil {
+			t.Errorf("UpdateAndFetch() product = %+v, want nil", product)
+		}
+		dbtest.AssertTransactionRolledBack(t, db)
+	})
+
+	t.Run("fetch error rolls back", func(t *testing.T) {
+		db := dbtest.NewTestDB(dbtypes.PostgreSQL)
+		db.ExpectTransaction().
+			ExpectExec("UPDATE products").WillReturnRowsAffected(1).
+			ExpectQuery("SELECT").WillReturnError(errors.New("connection reset"))
+
+		getDB := func(ctx context.Context) (database.Interface, error) {
+			return db, nil
+		}
+
+		repo := NewSQLProductRepository(getDB)
+		_, err := repo.UpdateAndFetch(ctx, "test-id", map[string]any{fieldKeyName: "Updated"})
+
+		if err == nil {
+			t.Error("UpdateAndFetch() expected error when the re-read fails")
+		}
+		dbtest.AssertTransactionRolledBack(t, db)
+	})
+}
+
+func TestUpdateAndCompare(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+	columns := []string{"id", "sku", "name", "description", "price_minor", "currency", "image_url", "created_date", "updated_date", "version", "locked", "category_id", "stock_quantity"}
+
+	t.Run("locked read, update and re-read share a transaction", func(t *testing.T) {
+		db := dbtest.NewTestDB(dbtypes.PostgreSQL)
+		// The locked read is matched first; the plain re-read falls through to SELECT.
+		db.ExpectTransaction().
+			ExpectQuery("FOR UPDATE").
+			WillReturnRows(dbtest.NewRowSet(columns...).AddRow("test-id", testSKU, "Old Name", "Description", int64(9999), "USD", "", now, now, 1, false, nil, 0)).
+			ExpectExec("UPDATE products").WillReturnRowsAffected(1).
+			ExpectQuery("SELECT").
+			WillReturnRows(dbtest.NewRowSet(columns...).AddRow("test-id", testSKU, "New Name", "Description", int64(9999), "USD", "", now, now, 2, false, nil, 0))
+
+		getDB := func(ctx context.Context) (database.Interface, error) {
+			return db, nil
+		}
+
+		repo := NewSQLProductRepository(getDB)
+		before, after, err := repo.UpdateAndCompare(ctx, "test-id", map[string]any{fieldKeyName: "New Name"})
+		if err != nil {
+			t.Fatalf("UpdateAndCompare() unexpected error = %v", err)
+		}
+
+		if before.Name != "Old Name" || before.Version != 1 {
+			t.Errorf("UpdateAndCompare() before = %+v, want the product as it was", before)
+		}
+		if after.Name != "New Name" || after.Version != 2 {
+			t.Errorf("UpdateAndCompare() after = %+v, want the updated product", after)
+		}
+		dbtest.AssertTransactionCommitted(t, db)
+		dbtest.AssertQueryNotExecuted(t, db, "SELECT")
+	})
+
+	t.Run("product not found rolls back before updating", func(t *testing.T) {
+		db := dbtest.NewTestDB(dbtypes.PostgreSQL)
+		tx := db.ExpectTransaction().
+			ExpectQuery("FOR UPDATE").WillReturnRows(dbtest.NewRowSet(columns...))
+
+		getDB := func(ctx context.Context) (database.Interface, error) {
+			return db, nil
+		}
+
+		repo := NewSQLProductRepository(getDB)
+		before, after, err := repo.UpdateAndCompare(ctx, "missing-id", map[string]any{fieldKeyName: "Updated"})
+
+		if !errors.Is(err, ErrProductNotFound) {
+			t.Errorf("UpdateAndCompare() error = %v, want %v", err, ErrProductNotFound)
+		}
+		if before != nil || after != nil {
+			t.Errorf("UpdateAndCompare() = %+v, %+v, want nil products", before, after)
+		}
+		dbtest.AssertTransactionRolledBack(t, db)
+		if len(tx.ExecLog()) != 0 {
+			t.Errorf("UpdateAndCompare() ran %d statements, want none", len(tx.ExecLog()))
+		}
+	})
+}
+
 func TestCreateTx(t *testing.T) {
 	ctx := context.Background()
-	product := domain.New("tx-id", "Tx Product", "Description", 49.99, "")
+	product := domain.New("tx-id", testSKU, "Tx Product", "Description", 4999, domain.DefaultCurrency, "")
 
 	t.Run("successful create within transaction", func(t *testing.T) {
 		db := dbtest.NewTestDB(dbtypes.PostgreSQL)
@@ -297,7 +754,8 @@ func TestDeleteTx(t *testing.T) {
 	t.Run("not found within transaction", func(t *testing.T) {
 		db := dbtest.NewTestDB(dbtypes.PostgreSQL)
 		db.ExpectTransaction().
-			ExpectExec("DELETE FROM products").WillReturnRowsAffected(0)
+			ExpectExec("DELETE FROM products").WillReturnRowsAffected(0).
+			ExpectQuery("SELECT").WillReturnRows(dbtest.NewRowSet("id"))
 
 		getDB := func(ctx context.Context) (database.Interface, error) {
 			return db, nil
@@ -369,6 +827,7 @@ func TestDelete(t *testing.T) {
 	t.Run("product not found", func(t *testing.T) {
 		db := dbtest.NewTestDB(dbtypes.PostgreSQL)
 		db.ExpectExec("DELETE FROM products").WillReturnRowsAffected(0)
+		db.ExpectQuery("SELECT").WillReturnRows(dbtest.NewRowSet("id"))
 
 		getDB := func(ctx context.Context) (database.Interface, error) {
 			return db, nil
@@ -398,3 +857,781 @@ func TestDelete(t *testing.T) {
 		}
 	})
 }
+
+func TestDeletePolicy(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name       string
+		policy     DeletePolicy
+		deleteSQL  string
+		wantFilter bool
+	}{
+		{name: "hard", policy: DeletePolicyHard, deleteSQL: "DELETE FROM products"},
+		{name: "soft", policy: DeletePolicySoft, deleteSQL: "UPDATE products SET deleted_at", wantFilter: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := dbtest.NewTestDB(dbtypes.PostgreSQL)
+			db.ExpectExec(tt.deleteSQL).WillReturnRowsAffected(1)
+			db.ExpectQuery("SELECT").WillReturnRows(
+				dbtest.NewRowSet("id", "sku", "name", "description", "price_minor", "currency", "image_url", "created_date", "updated_date", "version", "locked", "category_id", "stock_quantity"),
+			)
+
+			getDB := func(ctx context.Context) (database.Interface, error) {
+				return db, nil
+			}
+			repo := NewSQLProductRepository(getDB, WithDeletePolicy(tt.policy))
+
+			if err := repo.Delete(ctx, "test-id"); err != nil {
+				t.Fatalf("Delete() unexpected error = %v", err)
+			}
+			if _, err := repo.GetByID(ctx, "test-id"); !errors.Is(err, ErrProductNotFound) {
+				t.Errorf("GetByID() after Delete() error = %v, want %v", err, ErrProductNotFound)
+			}
+
+			dbtest.AssertExecCount(t, db, tt.deleteSQL, 1)
+			if tt.wantFilter {
+				dbtest.AssertExecCount(t, db, "DELETE FROM products", 0)
+			}
+			// A soft-deleted row is still there, so reads must skip it.
+			get := db.QueryLog()[0]
+			if hasFilter := strings.Contains(get.SQL, "deleted_at IS NULL"); hasFilter != tt.wantFilter {
+				t.Errorf("GetByID() query %q skips deleted rows = %v, want %v", get.SQL, hasFilter, tt.wantFilter)
+			}
+		})
+	}
+
+	t.Run("soft delete of a deleted product", func(t *testing.T) {
+		db := dbtest.NewTestDB(dbtypes.PostgreSQL)
+		db.ExpectExec("UPDATE products SET deleted_at").WillReturnRowsAffected(0)
+		db.ExpectQuery("SELECT").WillReturnRows(dbtest.NewRowSet("id"))
+
+		getDB := func(ctx context.Context) (database.Interface, error) {
+			return db, nil
+		}
+		repo := NewSQLProductRepository(getDB, WithDeletePolicy(DeletePolicySoft))
+
+		if err := repo.Delete(ctx, "deleted-id"); !errors.Is(err, ErrProductNotFound) {
+			t.Errorf("Delete() error = %v, want %v", err, ErrProductNotFound)
+		}
+		if call := db.ExecLog()[0]; !strings.Contains(call.SQL, "deleted_at IS NULL") {
+			t.Errorf("Delete() query %q does not skip deleted rows", call.SQL)
+		}
+	})
+}
+
+func TestLockedProduct(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+	product := func(locked bool) *dbtest.RowSet {
+		return dbtest.NewRowSet("id", "sku", "name", "description", "price_minor", "currency", "image_url", "created_date", "updated_date", "version", "locked", "category_id", "stock_quantity").
+			AddRow("test-id", testSKU, "Test Product", "Description", int64(9999), "USD", "", now, now, 1, locked, nil, 0)
+	}
+	repoFor := func(db *dbtest.TestDB) *ProductRepository {
+		return NewSQLProductRepository(func(context.Context) (database.Interface, error) {
+			return db, nil
+		})
+	}
+
+	tests := []struct {
+		name         string
+		locked       bool
+		rowsAffected int64
+		wantErr      error
+	}{
+		{name: "unlocked product proceeds", rowsAffected: 1},
+		{name: "locked product is rejected", locked: true, wantErr: ErrProductLocked},
+	}
+
+	for _, tt := range tests {
+		t.Run("update "+tt.name, func(t *testing.T) {
+			db := dbtest.NewTestDB(dbtypes.PostgreSQL)
+			db.ExpectQuery("SELECT").WillReturnRows(product(tt.locked))
+			db.ExpectExec("UPDATE products").WillReturnRowsAffected(tt.rowsAffected)
+
+			err := repoFor(db).Update(ctx, "test-id", map[string]any{fieldKeyName: "Renamed"})
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Update() error = %v, want %v", err, tt.wantErr)
+			}
+			if call := db.ExecLog()[0]; !strings.Contains(call.SQL, "locked = $") {
+				t.Errorf("Update() query %q does not skip locked products", call.SQL)
+			}
+		})
+
+		t.Run("delete "+tt.name, func(t *testing.T) {
+			db := dbtest.NewTestDB(dbtypes.PostgreSQL)
+			db.ExpectExec("DELETE FROM products").WillReturnRowsAffected(tt.rowsAffected)
+			db.ExpectQuery("SELECT").WillReturnRows(product(tt.locked))
+
+			err := repoFor(db).Delete(ctx, "test-id")
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Delete() error = %v, want %v", err, tt.wantErr)
+			}
+			if call := db.ExecLog()[0]; !strings.Contains(call.SQL, "locked = $") {
+				t.Errorf("Delete() query %q does not skip locked products", call.SQL)
+			}
+		})
+	}
+
+	t.Run("update and compare of a locked product runs no update", func(t *testing.T) {
+		db := dbtest.NewTestDB(dbtypes.PostgreSQL)
+		tx := db.ExpectTransaction().
+			ExpectQuery("FOR UPDATE").WillReturnRows(product(true))
+
+		_, _, err := repoFor(db).UpdateAndCompare(ctx, "test-id", map[string]any{fieldKeyName: "Renamed"})
+
+		if !errors.Is(err, ErrProductLocked) {
+			t.Errorf("UpdateAndCompare() error = %v, want %v", err, ErrProductLocked)
+		}
+		dbtest.AssertTransactionRolledBack(t, db)
+		if len(tx.ExecLog()) != 0 {
+			t.Errorf("UpdateAndCompare() ran %d statements, want none", len(tx.ExecLog()))
+		}
+	})
+}
+
+func TestSetLocked(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name         string
+		locked       bool
+		rowsAffected int64
+		wantErr      error
+	}{
+		{name: "lock", locked: true, rowsAffected: 1},
+		{name: "unlock", rowsAffected: 1},
+		{name: "product not found", locked: true, wantErr: ErrProductNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := dbtest.NewTestDB(dbtypes.PostgreSQL)
+			db.ExpectExec("UPDATE products SET locked").WillReturnRowsAffected(tt.rowsAffected)
+
+			repo := NewSQLProductRepository(func(context.Context) (database.Interface, error) {
+				return db, nil
+			})
+			err := repo.SetLocked(ctx, "test-id", tt.locked)
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SetLocked() error = %v, want %v", err, tt.wantErr)
+			}
+			call := db.ExecLog()[0]
+			if !slices.Contains(call.Args, any(tt.locked)) {
+				t.Errorf("SetLocked() args = %v, want locked %v", call.Args, tt.locked)
+			}
+			// Unlocking must reach locked products, so the lock is not a filter.
+			if strings.Contains(call.SQL, "WHERE locked") || strings.Contains(call.SQL, "AND locked") {
+				t.Errorf("SetLocked() query %q filters on the lock", call.SQL)
+			}
+		})
+	}
+}
+
+func TestReserveAndRelease(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+	product := dbtest.NewRowSet("id", "sku", "name", "description", "price_minor", "currency", "image_url", "created_date", "updated_date", "version", "locked", "category_id", "stock_quantity").
+		AddRow("test-id", testSKU, "Test Product", "Description", int64(9999), "USD", "", now, now, 1, false, nil, 2)
+
+	tests := []struct {
+		name         string
+		release      bool
+		rowsAffected int64
+		lookup       *dbtest.RowSet // returned when nothing was updated
+		wantErr      error
+	}{
+		{name: "reserve", rowsAffected: 1},
+		{name: "reserve more than in stock", lookup: product, wantErr: ErrInsufficientStock},
+		{name: "reserve missing product", lookup: dbtest.NewRowSet("id"), wantErr: ErrProductNotFound},
+		{name: "release", release: true, rowsAffected: 1},
+		{name: "release missing product", release: true, wantErr: ErrProductNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := dbtest.NewTestDB(dbtypes.PostgreSQL)
+			db.ExpectExec("UPDATE products SET stock_quantity").WillReturnRowsAffected(tt.rowsAffected)
+			if tt.lookup != nil {
+				db.ExpectQuery("SELECT").WillReturnRows(tt.lookup)
+			}
+
+			repo := NewSQLProductRepository(func(context.Context) (database.Interface, error) {
+				return db, nil
+			})
+			change, wantDelta := repo.Reserve, -3
+			if tt.release {
+				change, wantDelta = repo.Release, 3
+			}
+			err := change(ctx, "test-id", 3)
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			call := db.ExecLog()[0]
+			if !strings.Contains(call.SQL, "stock_quantity + $") || !slices.Contains(call.Args, any(wantDelta)) {
+				t.Errorf("query %q args %v, want stock changed by %d", call.SQL, call.Args, wantDelta)
+			}
+			// The stock check must be part of the UPDATE so it is atomic with the decrement.
+			if checksStock := strings.Contains(call.SQL, "stock_quantity >= $"); checksStock == tt.release {
+				t.Errorf("query %q stock check = %v, want %v", call.SQL, checksStock, !tt.release)
+			}
+			if strings.Contains(call.SQL, "version") {
+				t.Errorf("query %q changes the version", call.SQL)
+			}
+		})
+	}
+}
+
+func TestStreamStockBelow(t *testing.T) {
+	ctx := context.Background()
+
+	for _, stockBelow := range []int{0, 5} {
+		db := dbtest.NewTestDB(dbtypes.PostgreSQL)
+		db.ExpectQuery("SELECT").WillReturnRows(dbtest.NewRowSet("id"))
+		repo := NewSQLProductRepository(func(context.Context) (database.Interface, error) {
+			return db, nil
+		})
+
+		if err := repo.Stream(ctx, StreamOptions{StockBelow: stockBelow}, func(*domain.Product) error { return nil }); err != nil {
+			t.Fatalf("Stream(StockBelow: %d) unexpected error = %v", stockBelow, err)
+		}
+
+		call := db.QueryLog()[0]
+		filtered := strings.Contains(call.SQL, "stock_quantity < $")
+		if filtered != (stockBelow > 0) {
+			t.Errorf("Stream(StockBelow: %d) query %q filters on stock = %v", stockBelow, call.SQL, filtered)
+		}
+		if filtered && !slices.Contains(call.Args, any(stockBelow)) {
+			t.Errorf("Stream(StockBelow: %d) args = %v, want the threshold", stockBelow, call.Args)
+		}
+	}
+}
+
+func TestParseDeletePolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    DeletePolicy
+		wantErr error
+	}{
+		{name: "", want: DeletePolicyHard},
+		{name: "hard", want: DeletePolicyHard},
+		{name: "soft", want: DeletePolicySoft},
+		{name: "archive", wantErr: ErrInvalidDeletePolicy},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseDeletePolicy(tt.name)
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("ParseDeletePolicy(%q) error = %v, want %v", tt.name, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("ParseDeletePolicy(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFindDuplicates(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("groups duplicate rows", func(t *testing.T) {
+		db := dbtest.NewTestDB(dbtypes.PostgreSQL)
+		db.ExpectQuery("GROUP BY LOWER(name) HAVING COUNT(*) >").
+			WillReturnRows(
+				dbtest.NewRowSet("duplicate_key", "product_ids").
+					AddRow("blue mug", "id-1,id-4,id-7").
+					AddRow("desk lamp", "id-2,id-3"),
+			)
+
+		getDB := func(ctx context.Context) (database.Interface, error) {
+			return db, nil
+		}
+
+		repo := NewSQLProductRepository(getDB)
+		groups, err := repo.FindDuplicates(ctx)
+
+		if err != nil {
+			t.Fatalf("FindDuplicates() unexpected error = %v", err)
+		}
+		want := []DuplicateGroup{
+			{Field: DuplicateFieldName, Key: "blue mug", ProductIDs: []string{"id-1", "id-4", "id-7"}},
+			{Field: DuplicateFieldName, Key: "desk lamp", ProductIDs: []string{"id-2", "id-3"}},
+		}
+		if !reflect.DeepEqual(groups, want) {
+			t.Errorf("FindDuplicates() = %+v, want %+v", groups, want)
+		}
+		dbtest.AssertQueryExecuted(t, db, "SELECT")
+	})
+
+	t.Run("no duplicates", func(t *testing.T) {
+		db := dbtest.NewTestDB(dbtypes.PostgreSQL)
+		db.ExpectQuery("SELECT").WillReturnRows(dbtest.NewRowSet("duplicate_key", "product_ids"))
+
+		getDB := func(ctx context.Context) (database.Interface, error) {
+			return db, nil
+		}
+
+		repo := NewSQLProductRepository(getDB)
+		groups, err := repo.FindDuplicates(ctx)
+
+		if err != nil {
+			t.Fatalf("FindDuplicates() unexpected error = %v", err)
+		}
+		if len(groups) != 0 {
+			t.Errorf("FindDuplicates() returned %d groups, want 0", len(groups))
+		}
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		db := dbtest.NewTestDB(dbtypes.PostgreSQL)
+		db.ExpectQuery("SELECT").WillReturnError(errors.New("database error"))
+
+		getDB := func(ctx context.Context) (database.Interface, error) {
+			return db, nil
+		}
+
+		repo := NewSQLProductRepository(getDB)
+		_, err := repo.FindDuplicates(ctx)
+
+		if err == nil {
+			t.Error("FindDuplicates() expected error, got nil")
+		}
+	})
+}
+
+func TestListSearch(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	tests := []struct {
+		name        string
+		search      string
+		wantFilter  bool
+		wantPattern string
+	}{
+		{name: "empty search lists everything", search: ""},
+		{name: "search filters name and description", search: "mug", wantFilter: true, wantPattern: "%mug%"},
+		{name: "wildcards match literally", search: `50%_off\`, wantFilter: true, wantPattern: `%50\%\_off\\%`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := dbtest.NewTestDB(dbtypes.PostgreSQL)
+			db.ExpectQuery("COUNT(*)").WillReturnRows(dbtest.NewRowSet("count").AddRow(1))
+			db.ExpectQuery("ORDER BY").WillReturnRows(
+				dbtest.NewRowSet("id", "sku", "name", "description", "price_minor", "currency", "image_url", "created_date", "updated_date", "version", "locked", "category_id", "stock_quantity").
+					AddRow("test-id", testSKU, "Blue Mug", "Description", int64(999), "USD", "", now, now, 1, false, nil, 0),
+			)
+
+			getDB := func(ctx context.Context) (database.Interface, error) {
+				return db, nil
+			}
+
+			repo := NewSQLProductRepository(getDB)
+			products, total, err := repo.List(ctx, 10, 0, ListFilter{Search: tt.search}, Sort{})
+
+			if err != nil {
+				t.Fatalf("List() unexpected error = %v", err)
+			}
+			if total != 1 || len(products) != 1 {
+				t.Errorf("List() total = %d, count = %d, want 1, 1", total, len(products))
+			}
+
+			// The count and page queries must apply the same filter.
+			for _, call := range db.QueryLog() {
+				if hasFilter := strings.Contains(call.SQL, "ILIKE"); hasFilter != tt.wantFilter {
+					t.Errorf("List() query %q has ILIKE filter = %v, want %v", call.SQL, hasFilter, tt.wantFilter)
+				}
+				if tt.wantFilter && !slices.Contains(call.Args, any(tt.wantPattern)) {
+					t.Errorf("List() query args = %v, want pattern %q", call.Args, tt.wantPattern)
+				}
+			}
+		})
+	}
+}
+
+func TestListPriceRange(t *testing.T) {
+	ctx := context.Background()
+	price := func(v int64) *int64 { return &v }
+
+	tests := []struct {
+		name     string
+		filter   ListFilter
+		wantSQL  []string
+		wantArgs []any
+	}{
+		{name: "no bounds lists everything", filter: ListFilter{}},
+		{name: "currency only", filter: ListFilter{Currency: "EUR"}, wantSQL: []string{"currency ="}, wantArgs: []any{"EUR"}},
+		{name: "category only", filter: ListFilter{CategoryID: testCategoryID}, wantSQL: []string{"category_id ="}, wantArgs: []any{testCategoryID}},
+		{
+			name:     "category with price bound",
+			filter:   ListFilter{CategoryID: testCategoryID, Currency: "USD", MinPrice: price(500)},
+			wantSQL:  []string{"category_id =", "currency =", "price_minor >="},
+			wantArgs: []any{testCategoryID, "USD", int64(500)},
+		},
+		{
+			name:     "min only",
+			filter:   ListFilter{Currency: "USD", MinPrice: price(500)},
+			wantSQL:  []string{"currency =", "price_minor >="},
+			wantArgs: []any{"USD", int64(500)},
+		},
+		{
+			name:     "max only",
+			filter:   ListFilter{Currency: "USD", MaxPrice: price(2000)},
+			wantSQL:  []string{"currency =", "price_minor <="},
+			wantArgs: []any{"USD", int64(2000)},
+		},
+		{
+			name:     "both bounds",
+			filter:   ListFilter{Currency: "USD", MinPrice: price(500), MaxPrice: price(2000)},
+			wantSQL:  []string{"price_minor >=", "price_minor <="},
+			wantArgs: []any{int64(500), int64(2000)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := dbtest.NewTestDB(dbtypes.PostgreSQL)
+			db.ExpectQuery("COUNT(*)").WillReturnRows(dbtest.NewRowSet("count").AddRow(0))
+			db.ExpectQuery("ORDER BY").WillReturnRows(
+				dbtest.NewRowSet("id", "sku", "name", "description", "price_minor", "currency", "image_url", "created_date", "updated_date", "version", "locked", "category_id", "stock_quantity"),
+			)
+
+			getDB := func(ctx context.Context) (database.Interface, error) {
+				return db, nil
+			}
+
+			repo := NewSQLProductRepository(getDB)
+			if _, _, err := repo.List(ctx, 10, 0, tt.filter, Sort{}); err != nil {
+				t.Fatalf("List() unexpected error = %v", err)
+			}
+
+			// The count and page queries must apply the same bounds.
+			for _, call := range db.QueryLog() {
+				if len(tt.wantSQL) == 0 && strings.Contains(call.SQL, "WHERE") {
+					t.Errorf("List() query %q has a WHERE clause, want none", call.SQL)
+				}
+				for _, want := range tt.wantSQL {
+					if !strings.Contains(call.SQL, want) {
+						t.Errorf("List() query %q missing %q", call.SQL, want)
+					}
+				}
+				for _, want := range tt.wantArgs {
+					if !slices.Contains(call.Args, want) {
+						t.Errorf("List() query args = %v, want %v", call.Args, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestPriceStats(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		row  []any
+		want PriceStats
+	}{
+		{
+			name: "populated catalog",
+			row:  []any{int64(499), int64(14950), 4225.0, 12},
+			want: PriceStats{Currency: "USD", Min: 499, Max: 14950, Avg: 4225, Count: 12},
+		},
+		{
+			name: "empty catalog",
+			row:  []any{int64(0), int64(0), 0.0, 0},
+			want: PriceStats{Currency: "USD"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := dbtest.NewTestDB(dbtypes.PostgreSQL)
+			db.ExpectQuery("COALESCE(MIN(price_minor), 0)").WillReturnRows(
+				dbtest.NewRowSet("min_price", "max_price", "avg_price", "product_count").AddRow(tt.row...),
+			)
+
+			getDB := func(ctx context.Context) (database.Interface, error) {
+				return db, nil
+			}
+
+			repo := NewSQLProductRepository(getDB)
+			stats, err := repo.PriceStats(ctx, "USD", nil)
+
+			if err != nil {
+				t.Fatalf("PriceStats() unexpected error = %v", err)
+			}
+			dbtest.AssertQueryExecuted(t, db, "currency =")
+			if stats != tt.want {
+				t.Errorf("PriceStats() = %+v, want %+v", stats, tt.want)
+			}
+		})
+	}
+
+	t.Run("category filter", func(t *testing.T) {
+		db := dbtest.NewTestDB(dbtypes.PostgreSQL)
+		db.ExpectQuery("category_id =").WillReturnRows(
+			dbtest.NewRowSet("min_price", "max_price", "avg_price", "product_count").AddRow(int64(500), int64(500), float64(500), 1),
+		)
+		getDB := func(ctx context.Context) (database.Interface, error) {
+			return db, nil
+		}
+
+		category := testCategoryID
+		repo := NewSQLProductRepository(getDB)
+		stats, err := repo.PriceStats(ctx, "USD", &category)
+
+		if err != nil {
+			t.Fatalf("PriceStats() unexpected error = %v", err)
+		}
+		if stats.Count != 1 {
+			t.Errorf("PriceStats() count = %d, want 1", stats.Count)
+		}
+		call := db.QueryLog()[0]
+		if !slices.Contains(call.Args, any(testCategoryID)) || !strings.Contains(call.SQL, "currency =") {
+			t.Errorf("PriceStats() query %q with args %v, want it filtered by currency and category", call.SQL, call.Args)
+		}
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		db := dbtest.NewTestDB(dbtypes.PostgreSQL)
+		db.ExpectQuery("SELECT").WillReturnError(errors.New("database error"))
+
+		getDB := func(ctx context.Context) (database.Interface, error) {
+			return db, nil
+		}
+
+		repo := NewSQLProductRepository(getDB)
+		_, err := repo.PriceStats(ctx, "USD", nil)
+
+		if err == nil {
+			t.Error("PriceStats() expected error, got nil")
+		}
+	})
+}
+
+func TestListSort(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name        string
+		sort        Sort
+		wantOrderBy string
+		wantErr     bool
+	}{
+		{name: "zero value is newest first", sort: Sort{}, wantOrderBy: "ORDER BY created_date DESC, id DESC"},
+		{name: "name ascending", sort: Sort{Field: SortByName}, wantOrderBy: "ORDER BY name ASC, id DESC"},
+		{name: "price descending", sort: Sort{Field: SortByPrice, Descending: true}, wantOrderBy: "ORDER BY price_minor DESC, id DESC"},
+		{name: "unknown field", sort: Sort{Field: "image_url"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := dbtest.NewTestDB(dbtypes.PostgreSQL)
+			db.ExpectQuery("COUNT(*)").WillReturnRows(dbtest.NewRowSet("count").AddRow(0))
+			db.ExpectQuery("ORDER BY").WillReturnRows(
+				dbtest.NewRowSet("id", "sku", "name", "description", "price_minor", "currency", "image_url", "created_date", "updated_date", "version", "locked", "category_id", "stock_quantity"),
+			)
+
+			getDB := func(ctx context.Context) (database.Interface, error) {
+				return db, nil
+			}
+
+			repo := NewSQLProductRepository(getDB)
+			_, _, err := repo.List(ctx, 10, 0, ListFilter{}, tt.sort)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Error("List() expected error for unknown sort field, got nil")
+				}
+				dbtest.AssertQueryNotExecuted(t, db, "SELECT")
+				return
+			}
+			if err != nil {
+				t.Fatalf("List() unexpected error = %v", err)
+			}
+			dbtest.AssertQueryExecuted(t, db, tt.wantOrderBy)
+		})
+	}
+}
+
+func TestListTiebreakerAcrossPages(t *testing.T) {
+	ctx := context.Background()
+	created := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	columns := []string{"id", "sku", "name", "description", "price_minor", "currency", "image_url", "created_date", "updated_date", "version", "locked", "category_id", "stock_quantity"}
+
+	// Both products share a created_date; the database orders them by id DESC.
+	// The second page's expectation is registered first because the first
+	// matching expectation wins.
+	db := dbtest.NewTestDB(dbtypes.PostgreSQL)
+	db.ExpectQuery("COUNT(*)").WillReturnRows(dbtest.NewRowSet("count").AddRow(2))
+	db.ExpectQuery("OFFSET 1").WillReturnRows(
+		dbtest.NewRowSet(columns...).AddRow("product-a", testSKU, "Batch product", "", int64(100), "USD", "", created, created, 1, false, nil, 0),
+	)
+	db.ExpectQuery("ORDER BY").WillReturnRows(
+		dbtest.NewRowSet(columns...).AddRow("product-b", testSKU, "Batch product", "", int64(100), "USD", "", created, created, 1, false, nil, 0),
+	)
+	getDB := func(ctx context.Context) (database.Interface, error) {
+		return db, nil
+	}
+	repo := NewSQLProductRepository(getDB)
+
+	var seen []string
+	for page := range 2 {
+		products, _, err := repo.List(ctx, 1, page, ListFilter{}, DefaultSort)
+		if err != nil {
+			t.Fatalf("List() page %d unexpected error = %v", page+1, err)
+		}
+		for _, p := range products {
+			seen = append(seen, p.ID)
+		}
+	}
+
+	if !slices.Equal(seen, []string{"product-b", "product-a"}) {
+		t.Errorf("List() across pages = %v, want [product-b product-a]", seen)
+	}
+	for _, q := range db.QueryLog() {
+		if strings.Contains(q.SQL, "ORDER BY") && !strings.Contains(q.SQL, "ORDER BY created_date DESC, id DESC") {
+			t.Errorf("List() query %q lacks the id tiebreaker", q.SQL)
+		}
+	}
+}
+
+func TestListClampsLimit(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		opts      []Option
+		limit     int
+		wantLimit string
+	}{
+		{name: "over the configured maximum", opts: []Option{WithMaxListLimit(50)}, limit: 1000000, wantLimit: "LIMIT 50 OFFSET 5"},
+		{name: "within the configured maximum", opts: []Option{WithMaxListLimit(50)}, limit: 20, wantLimit: "LIMIT 20 OFFSET 5"},
+		{name: "over the default maximum", limit: 1000000, wantLimit: "LIMIT 1000 OFFSET 5"},
+		{name: "unlimited", opts: []Option{WithMaxListLimit(0)}, limit: 1000000, wantLimit: "LIMIT 1000000 OFFSET 5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := dbtest.NewTestDB(dbtypes.PostgreSQL)
+			db.ExpectQuery("COUNT(*)").WillReturnRows(dbtest.NewRowSet("count").AddRow(0))
+			db.ExpectQuery("ORDER BY").WillReturnRows(
+				dbtest.NewRowSet("id", "sku", "name", "description", "price_minor", "currency", "image_url", "created_date", "updated_date", "version", "locked", "category_id", "stock_quantity"),
+			)
+			getDB := func(ctx context.Context) (database.Interface, error) {
+				return db, nil
+			}
+
+			repo := NewSQLProductRepository(getDB, tt.opts...)
+			if _, _, err := repo.List(ctx, tt.limit, 5, ListFilter{}, DefaultSort); err != nil {
+				t.Fatalf("List() unexpected error = %v", err)
+			}
+			dbtest.AssertQueryExecuted(t, db, tt.wantLimit)
+		})
+	}
+}
+
+func TestErrorClassification(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name            string
+		setup           func(db *dbtest.TestDB)
+		getDBErr        error
+		call            func(repo *ProductRepository) error
+		wantUnavailable bool
+		wantInternal    bool
+		wantConflict    bool
+		wantNotFound    bool
+	}{
+		{
+			name:     "connection failure",
+			getDBErr: errors.New("connection refused"),
+			call: func(repo *ProductRepository) error {
+				_, err := repo.GetByID(ctx, "test-id")
+				return err
+			},
+			wantUnavailable: true,
+		},
+		{
+			name: "query failure",
+			setup: func(db *dbtest.TestDB) {
+				db.ExpectQuery("SELECT").WillReturnError(errors.New("relation does not exist"))
+			},
+			call: func(repo *ProductRepository) error {
+				_, err := repo.GetByID(ctx, "test-id")
+				return err
+			},
+			wantInternal: true,
+		},
+		{
+			name: "statement failure",
+			setup: func(db *dbtest.TestDB) {
+				db.ExpectExec("DELETE FROM products").WillReturnError(errors.New("deadlock detected"))
+			},
+			call: func(repo *ProductRepository) error {
+				return repo.Delete(ctx, "test-id")
+			},
+			wantInternal: true,
+		},
+		{
+			name: "unique violation",
+			setup: func(db *dbtest.TestDB) {
+				db.ExpectExec("INSERT INTO products").WillReturnError(&pgconn.PgError{Code: "23505"})
+			},
+			call: func(repo *ProductRepository) error {
+				return repo.Create(ctx, domain.New("test-id", testSKU, "Test Product", "", 100, domain.DefaultCurrency, ""))
+			},
+			wantConflict: true,
+		},
+		{
+			name: "not found is neither",
+			setup: func(db *dbtest.TestDB) {
+				db.ExpectQuery("SELECT").WillReturnError(sql.ErrNoRows)
+			},
+			call: func(repo *ProductRepository) error {
+				_, err := repo.GetByID(ctx, "missing-id")
+				return err
+			},
+			wantNotFound: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := dbtest.NewTestDB(dbtypes.PostgreSQL)
+			if tt.setup != nil {
+				tt.setup(db)
+			}
+			getDB := func(ctx context.Context) (database.Interface, error) {
+				if tt.getDBErr != nil {
+					return nil, tt.getDBErr
+				}
+				return db, nil
+			}
+
+			err := tt.call(NewSQLProductRepository(getDB))
+
+			if got := errors.Is(err, dbutil.ErrDBUnavailable); got != tt.wantUnavailable {
+				t.Errorf("errors.Is(%v, ErrDBUnavailable) = %v, want %v", err, got, tt.wantUnavailable)
+			}
+			if got := errors.Is(err, dbutil.ErrInternal); got != tt.wantInternal {
+				t.Errorf("errors.Is(%v, ErrInternal) = %v, want %v", err, got, tt.wantInternal)
+			}
+			if got := errors.Is(err, dbutil.ErrConflict); got != tt.wantConflict {
+				t.Errorf("errors.Is(%v, ErrConflict) = %v, want %v", err, got, tt.wantConflict)
+			}
+			if got := errors.Is(err, ErrProductNotFound); got != tt.wantNotFound {
+				t.Errorf("errors.Is(%v, ErrProductNotFound) = %v, want %v", err, got, tt.wantNotFound)
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/products/domain"
+	"github.com/gaborage/go-bricks/app"
+)
+
+// MaxBatchSize bounds the number of products in one CreateProducts call so a
+// batch stays a single, reasonably sized INSERT.
+const MaxBatchSize = 100
+
+// BatchItemError reports why the batch item at Index was rejected.
+type BatchItemError struct {
+	Index int
+	Err   error
+}
+
+func (e BatchItemError) Error() string {
+	// Item errors wrap ErrValidation; drop its prefix so a BatchError does not repeat it per item.
+	return fmt.Sprintf("item %d: %s", e.Index, strings.TrimPrefix(e.Err.Error(), ErrValidation.Error()+": "))
+}
+
+func (e BatchItemError) Unwrap() error {
+	return e.Err
+}
+
+// BatchError lists every rejected item of a batch. It wraps ErrValidation.
+type BatchError struct {
+	Items []BatchItemError
+}
+
+func (e *BatchError) Error() string {
+	msgs := make([]string, len(e.Items))
+	for i, item := range e.Items {
+		msgs[i] = item.Error()
+	}
+	return fmt.Sprintf("%v: %s", ErrValidation, strings.Join(msgs, "; "))
+}
+
+func (e *BatchError) Unwrap() error {
+	return ErrValidation
+}
+
+// CreateProducts validates and inserts a batch of products in one statement.
+// If any item is invalid nothing is inserted and the error is a *BatchError
+// naming every offending index.
+// A SKU already used by a live product fails the whole batch with
+// repository.ErrDuplicateSKU, which matches ErrConflict.
+func (s *ProductService) CreateProducts(ctx context.Context, items []CreateProductInput) ([]*domain.Product, error) {
+	products, rejected, err := s.prepareBatch(ctx, items)
+	if err != nil {
+		return nil, err
+	}
+	if len(rejected) > 0 {
+		return nil, &BatchError{Items: rejected}
+	}
+
+	if err := s.insertBatch(ctx, products); err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+// CreateProductsPartial is CreateProducts that inserts the valid items and
+// reports the invalid ones instead of failing the whole batch.
+func (s *ProductService) CreateProductsPartial(ctx context.Context, items []CreateProductInput) ([]*domain.Product, []BatchItemError, error) {
+	products, rejected, err := s.prepareBatch(ctx, items)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.insertBatch(ctx, products); err != nil {
+		return nil, nil, err
+	}
+	return products, rejected, nil
+}
+
+// categoryLookup is the outcome of looking up one category of a batch.
+type categoryLookup struct {
+	currency string
+	err      error
+}
+
+// prepareBatch builds a product for every valid item and collects the
+// rejected ones, in input order. Each distinct category is checked once; a
+// failed check aborts the batch rather than rejecting the item.
+func (s *ProductService) prepareBatch(ctx context.Context, items []CreateProductInput) ([]*domain.Product, []BatchItemError, error) {
+	if len(items) == 0 {
+		return nil, nil, fmt.Errorf("%w: batch must contain at least one product", ErrValidation)
+	}
+	if len(items) > MaxBatchSize {
+		return nil, nil, fmt.Errorf("%w: batch must contain at most %d products", ErrValidation, MaxBatchSize)
+	}
+
+	products := make([]*domain.Product, 0, len(items))
+	var rejected []BatchItemError
+	firstWithSKU := make(map[string]int, len(items))
+	categories := make(map[string]categoryLookup)
+	for i, item := range items {
+		product, err := s.newProduct(item)
+		if err != nil {
+			rejected = append(rejected, BatchItemError{Index: i, Err: err})
+			continue
+		}
+		category, checked := categories[product.CategoryID]
+		if !checked {
+			category.currency, category.err = s.categoryCurrency(ctx, product.CategoryID)
+			if errors.Is(category.err, ErrInternal) {
+				return nil, nil, category.err
+			}
+			categories[product.CategoryID] = category
+		}
+		categoryErr := category.err
+		if categoryErr == nil {
+			categoryErr = checkCategoryCurrency(product.CategoryID, category.currency, product.Currency)
+		}
+		if categoryErr != nil {
+			rejected = append(rejected, BatchItemError{Index: i, Err: categoryErr})
+			continue
+		}
+		if first, ok := firstWithSKU[product.SKU]; ok {
+			rejected = append(rejected, BatchItemError{
+				Index: i,
+				Err:   fmt.Errorf("%w: SKU %s is already used by item %d", ErrValidation, product.SKU, first),
+			})
+			continue
+		}
+		firstWithSKU[product.SKU] = i
+		products = append(products, product)
+	}
+	return products, rejected, nil
+}
+
+// insertBatch writes products with one multi-row INSERT, together with a
+// "product.created" event per product, like CreateProduct.
+func (s *ProductService) insertBatch(ctx context.Context, products []*domain.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+
+	if s.outbox != nil && s.getDB != nil {
+		if err := s.createBatchWithOutbox(ctx, products); err != nil {
+			return s.batchCreateError(ctx, err, len(products))
+		}
+	} else {
+		if err := s.repository.CreateBatch(ctx, products); err != nil {
+			return s.batchCreateError(ctx, err, len(products))
+		}
+		for _, product := range products {
+			s.publishDirect(ctx, EventProductCreated, newProductCreatedEvent(ctx, product))
+		}
+	}
+
+	s.log(ctx).Info().Int("count", len(products)).Msg("Products created successfully")
+	return nil
+}
+
+// batchCreateError is createError for a batch of count products.
+func (s *ProductService) batchCreateError(ctx context.Context, err error, count int) error {
+	if errors.Is(err, ErrConflict) {
+		s.log(ctx).Warn().Err(err).Int("count", count).Msg("Products conflict with an existing product")
+		return err
+	}
+	s.log(ctx).Error().Err(err).Int("count", count).Msg("Failed to create products")
+	return fmt.Errorf("%w: failed to create products: %w", ErrInternal, err)
+}
+
+// createBatchWithOutbox wraps the batch insert and its outbox events in a single transaction.
+func (s *ProductService) createBatchWithOutbox(ctx context.Context, products []*domain.Product) error {
+	db, err := s.getDB(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get database: %w", err)
+	}
+
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // no-op if already committed
+
+	if err := s.repository.CreateBatchTx(ctx, tx, products); err != nil {
+		return err
+	}
+
+	for _, product := range products {
+		_, err = s.outbox.Publish(ctx, tx, &app.OutboxEvent{
+			EventType:   EventProductCreated,
+			AggregateID: product.ID,
+			Payload:     newProductCreatedEvent(ctx, product),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to publish outbox event: %w", err)
+		}
+	}
+
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,264 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/products/domain"
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/products/repository"
+	"github.com/gaborage/go-bricks/database"
+	dbtest "github.com/gaborage/go-bricks/database/testing"
+	dbtypes "github.com/gaborage/go-bricks/database/types"
+	outboxtest "github.com/gaborage/go-bricks/outbox/testing"
+	"github.com/google/uuid"
+)
+
+func TestCreateProducts(t *testing.T) {
+	ctx := context.Background()
+	log := newMockLogger()
+
+	valid := func(sku string) CreateProductInput {
+		return CreateProductInput{SKU: sku, Name: testProductName, Price: "10"}
+	}
+	tests := []struct {
+		name         string
+		items        []CreateProductInput
+		wantErr      bool
+		wantRejected []int
+	}{
+		{name: "all valid", items: []CreateProductInput{valid("A-1"), valid("A-2"), valid("A-3")}},
+		{
+			name:         "invalid items reject the batch",
+			items:        []CreateProductInput{valid("A-1"), {SKU: "A-2", Name: "", Price: "1"}, valid("A-3"), {SKU: "A-4", Name: "Bad URL", Price: "1", ImageURL: notAURLValue}},
+			wantErr:      true,
+			wantRejected: []int{1, 3},
+		},
+		{
+			name:         "repeated SKU rejects the batch",
+			items:        []CreateProductInput{valid("A-1"), valid("A-2"), valid("a-1")},
+			wantErr:      true,
+			wantRejected: []int{2},
+		},
+		{name: "empty batch", items: nil, wantErr: true},
+		{name: "too many items", items: make([]CreateProductInput, MaxBatchSize+1), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var inserted []*domain.Product
+			mockRepo := &mockRepository{
+				batchFunc: func(ctx context.Context, products []*domain.Product) error {
+					inserted = products
+					return nil
+				},
+			}
+			svc := NewService(mockRepo, log, nil, nil)
+
+			products, err := svc.CreateProducts(ctx, tt.items)
+
+			if tt.wantErr {
+				if !errors.Is(err, ErrValidation) {
+					t.Fatalf("CreateProducts() error = %v, want ErrValidation", err)
+				}
+				if inserted != nil {
+					t.Errorf("CreateProducts() inserted %d products, want none", len(inserted))
+				}
+				if tt.wantRejected == nil {
+					return
+				}
+				var batchErr *BatchError
+				if !errors.As(err, &batchErr) {
+					t.Fatalf("CreateProducts() error = %T, want *BatchError", err)
+				}
+				var rejected []int
+				for _, item := range batchErr.Items {
+					rejected = append(rejected, item.Index)
+				}
+				if !slices.Equal(rejected, tt.wantRejected) {
+					t.Errorf("CreateProducts() rejected = %v, want %v", rejected, tt.wantRejected)
+				}
+				if !strings.Contains(err.Error(), fmt.Sprintf("item %d: ", tt.wantRejected[0])) {
+					t.Errorf("CreateProducts() error = %q, want it to name item %d", err, tt.wantRejected[0])
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("CreateProducts() unexpected error = %v", err)
+			}
+			if len(products) != len(tt.items) || len(inserted) != len(tt.items) {
+				t.Errorf("CreateProducts() returned %d, inserted %d, want %d", len(products), len(inserted), len(tt.items))
+			}
+		})
+	}
+
+	t.Run("repository error", func(t *testing.T) {
+		mockRepo := &mockRepository{
+			batchFunc: func(ctx context.Context, products []*domain.Product) error {
+				return errors.New("database error")
+			},
+		}
+		svc := NewService(mockRepo, log, nil, nil)
+
+		_, err := svc.CreateProducts(ctx, []CreateProductInput{valid("A-1")})
+		if !errors.Is(err, ErrInternal) {
+			t.Errorf("CreateProducts() error = %v, want ErrInternal", err)
+		}
+	})
+
+	t.Run("SKU already taken", func(t *testing.T) {
+		mockRepo := &mockRepository{
+			batchFunc: func(ctx context.Context, products []*domain.Product) error {
+				return fmt.Errorf("%w: %w", repository.ErrDuplicateSKU, ErrConflict)
+			},
+		}
+		svc := NewService(mockRepo, log, nil, nil)
+
+		_, err := svc.CreateProducts(ctx, []CreateProductInput{valid("A-1")})
+		if !errors.Is(err, repository.ErrDuplicateSKU) || errors.Is(err, ErrInternal) {
+			t.Errorf("CreateProducts() error = %v, want ErrDuplicateSKU", err)
+		}
+	})
+}
+
+func TestCreateProductsPartial(t *testing.T) {
+	ctx := context.Background()
+
+	var inserted []*domain.Product
+	mockRepo := &mockRepository{
+		batchFunc: func(ctx context.Context, products []*domain.Product) error {
+			inserted = products
+			return nil
+		},
+	}
+	svc := NewService(mockRepo, newMockLogger(), nil, nil)
+
+	items := []CreateProductInput{
+		{SKU: "A-1", Name: "First", Price: "1"},
+		{SKU: "A-2", Name: "Negative", Price: "-1"},
+		{SKU: "A-3", Name: "Third", Price: "3"},
+	}
+	products, rejected, err := svc.CreateProductsPartial(ctx, items)
+	if err != nil {
+		t.Fatalf("CreateProductsPartial() unexpected error = %v", err)
+	}
+
+	if len(products) != 2 || len(inserted) != 2 || products[0].Name != "First" || products[1].Name != "Third" {
+		t.Errorf("CreateProductsPartial() created = %v, want First and Third", products)
+	}
+	if len(rejected) != 1 || rejected[0].Index != 1 || !errors.Is(rejected[0], ErrValidation) {
+		t.Errorf("CreateProductsPartial() rejected = %v, want item 1 as a validation error", rejected)
+	}
+}
+
+func TestCreateProductsCategories(t *testing.T) {
+	ctx := context.Background()
+	unknown := uuid.NewString()
+
+	t.Run("checks each category once", func(t *testing.T) {
+		checker := &fakeCategoryChecker{ids: map[string]bool{testCategoryID: true}}
+		svc := NewService(&mockRepository{}, newMockLogger(), nil, nil, WithCategoryChecker(checker))
+
+		products, rejected, err := svc.CreateProductsPartial(ctx, []CreateProductInput{
+			{SKU: "A-1", Name: "First", Price: "1", CategoryID: testCategoryID},
+			{SKU: "A-2", Name: "Unknown", Price: "2", CategoryID: unknown},
+			{SKU: "A-3", Name: "Third", Price: "3", CategoryID: testCategoryID},
+			{SKU: "A-4", Name: "Uncategorized", Price: "4"},
+		})
+		if err != nil {
+			t.Fatalf("CreateProductsPartial() unexpected error = %v", err)
+		}
+
+		if len(products) != 3 || products[0].CategoryID != testCategoryID || products[2].CategoryID != "" {
+			t.Errorf("CreateProductsPartial() created = %v, want First, Third and Uncategorized", products)
+		}
+		if len(rejected) != 1 || rejected[0].Index != 1 || !errors.Is(rejected[0], ErrValidation) {
+			t.Errorf("CreateProductsPartial() rejected = %v, want item 1 as a validation error", rejected)
+		}
+		if checker.calls != 2 {
+			t.Errorf("CreateProductsPartial() checked categories %d times, want once per distinct category", checker.calls)
+		}
+	})
+
+	t.Run("rejects a currency the category does not accept", func(t *testing.T) {
+		checker := newEURCategoryChecker()
+		svc := NewService(&mockRepository{}, newMockLogger(), nil, nil, WithCategoryChecker(checker))
+
+		products, rejected, err := svc.CreateProductsPartial(ctx, []CreateProductInput{
+			{SKU: "A-1", Name: "Euro", Price: "1", Currency: "EUR", CategoryID: testEURCategoryID},
+			{SKU: "A-2", Name: "Dollar", Price: "2", CategoryID: testEURCategoryID},
+		})
+		if err != nil {
+			t.Fatalf("CreateProductsPartial() unexpected error = %v", err)
+		}
+
+		if len(products) != 1 || products[0].Name != "Euro" {
+			t.Errorf("CreateProductsPartial() created = %v, want Euro", products)
+		}
+		if len(rejected) != 1 || rejected[0].Index != 1 || !errors.Is(rejected[0], ErrValidation) {
+			t.Errorf("CreateProductsPartial() rejected = %v, want item 1 as a validation error", rejected)
+		}
+		if checker.calls != 1 {
+			t.Errorf("CreateProductsPartial() checked categories %d times, want once", checker.calls)
+		}
+	})
+
+	t.Run("lookup failure aborts the batch", func(t *testing.T) {
+		checker := &fakeCategoryChecker{err: errors.New("connection refused")}
+		inserted := false
+		mockRepo := &mockRepository{
+			batchFunc: func(context.Context, []*domain.Product) error {
+				inserted = true
+				return nil
+			},
+		}
+		svc := NewService(mockRepo, newMockLogger(), nil, nil, WithCategoryChecker(checker))
+
+		_, _, err := svc.CreateProductsPartial(ctx, []CreateProductInput{
+			{SKU: "A-1", Name: "First", Price: "1", CategoryID: testCategoryID},
+		})
+
+		if !errors.Is(err, ErrInternal) {
+			t.Errorf("CreateProductsPartial() error = %v, want ErrInternal", err)
+		}
+		if inserted {
+			t.Error("CreateProductsPartial() inserted products after a failed category lookup")
+		}
+	})
+}
+
+func TestCreateProductsWithOutbox(t *testing.T) {
+	ctx := context.Background()
+	mockOutbox := outboxtest.NewMockOutbox()
+	db := dbtest.NewTestDB(dbtypes.PostgreSQL)
+	db.ExpectTransaction().
+		ExpectExec("INSERT INTO products").WillReturnRowsAffected(2)
+
+	getDB := func(ctx context.Context) (database.Interface, error) {
+		return db, nil
+	}
+
+	mockRepo := &mockRepository{
+		batchTxFunc: func(ctx context.Context, tx dbtypes.Tx, products []*domain.Product) error {
+			_, err := tx.Exec(ctx, "INSERT INTO products")
+			return err
+		},
+	}
+
+	svc := NewService(mockRepo, newMockLogger(), mockOutbox, getDB)
+	_, err := svc.CreateProducts(ctx, []CreateProductInput{
+		{SKU: "A-1", Name: "First", Price: "1"},
+		{SKU: "A-2", Name: "Second", Price: "2"},
+	})
+	if err != nil {
+		t.Fatalf("CreateProducts() error = %v", err)
+	}
+
+	if events := mockOutbox.EventsByType("product.created"); len(events) != 2 {
+		t.Errorf("expected 2 product.created events, got %d", len(events))
+	}
+}
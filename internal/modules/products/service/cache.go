@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/products/domain"
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/shared/ttlcache"
+	"github.com/gaborage/go-bricks/multitenant"
+	"golang.org/x/sync/singleflight"
+)
+
+// productCache fronts GetProductByID with a short-TTL in-process cache.
+// Concurrent misses for the same product share a single repository read.
+type productCache struct {
+	entries *ttlcache.Cache
+	loads   singleflight.Group
+
+	// generation is bumped on every invalidation. A load that started before
+	// an invalidation does not store its (possibly stale) result.
+	generation atomic.Uint64
+}
+
+func newProductCache(ttl time.Duration, maxSize int) *productCache {
+	return &productCache{entries: ttlcache.New(ttl, maxSize)}
+}
+
+// cacheKey scopes id to the request tenant so tenants never share entries.
+func cacheKey(ctx context.Context, id string) string {
+	tenantID, _ := multitenant.GetTenant(ctx)
+	return tenantID + "/" + id
+}
+
+// get returns the cached product for id, or calls load once for all
+// concurrent callers and caches a successful result.
+func (c *productCache) get(ctx context.Context, id string, load func() (*domain.Product, error)) (*domain.Product, error) {
+	key := cacheKey(ctx, id)
+	if cached, ok := c.entries.Get(key).(*domain.Product); ok {
+		return copyProduct(cached), nil
+	}
+
+	v, err, _ := c.loads.Do(key, func() (any, error) {
+		gen := c.generation.Load()
+		product, err := load()
+		if err != nil {
+			return nil, err
+		}
+		if c.generation.Load() == gen {
+			c.entries.Set(key, product)
+		}
+		return product, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return copyProduct(v.(*domain.Product)), nil
+}
+
+// invalidate drops the cached entry for id after it was updated or deleted.
+func (c *productCache) invalidate(ctx context.Context, id string) {
+	key := cacheKey(ctx, id)
+	c.generation.Add(1)
+	c.loads.Forget(key)
+	c.entries.Delete(key)
+}
+
+// close stops the cache's background cleanup.
+func (c *productCache) close() {
+	c.entries.Close()
+}
+
+// copyProduct hands callers their own copy so they cannot mutate cached state.
+func copyProduct(p *domain.Product) *domain.Product {
+	cp := *p
+	return &cp
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/products/domain"
+	"github.com/gaborage/go-bricks/multitenant"
+)
+
+// countingRepository returns a mock repository whose GetByID serves the
+// product stored in *current and counts its calls.
+func countingRepository(current **domain.Product, calls *atomic.Int32) *mockRepository {
+	return &mockRepository{
+		getByIDFunc: func(ctx context.Context, id string) (*domain.Product, error) {
+			calls.Add(1)
+			p := **current
+			return &p, nil
+		},
+	}
+}
+
+func TestGetProductByIDCacheHit(t *testing.T) {
+	ctx := context.Background()
+	product := domain.New(testID, testSKU, testProductName, testDescription, 1000, domain.DefaultCurrency, "")
+	var calls atomic.Int32
+
+	svc := NewService(countingRepository(&product, &calls), newMockLogger(), nil, nil, WithProductCache(time.Minute, 10))
+	defer svc.Close()
+
+	for range 3 {
+		got, err := svc.GetProductByID(ctx, testID)
+		if err != nil {
+			t.Fatalf("GetProductByID() unexpected error = %v", err)
+		}
+		if got.Name != testProductName {
+			t.Errorf("GetProductByID() name = %q, want %q", got.Name, testProductName)
+		}
+		// Callers get their own copy; mutating it must not leak into the cache.
+		got.Name = "mutated"
+	}
+
+	if n := calls.Load(); n != 1 {
+		t.Errorf("GetProductByID() repository calls = %d, want 1", n)
+	}
+}
+
+func TestGetProductByIDCacheTenantIsolation(t *testing.T) {
+	product := domain.New(testID, testSKU, testProductName, testDescription, 1000, domain.DefaultCurrency, "")
+	var calls atomic.Int32
+
+	svc := NewService(countingRepository(&product, &calls), newMockLogger(), nil, nil, WithProductCache(time.Minute, 10))
+	defer svc.Close()
+
+	for _, tenant := range []string{"tenant-a", "tenant-b", "tenant-a"} {
+		if _, err := svc.GetProductByID(multitenant.SetTenant(context.Background(), tenant), testID); err != nil {
+			t.Fatalf("GetProductByID() unexpected error = %v", err)
+		}
+	}
+
+	if n := calls.Load(); n != 2 {
+		t.Errorf("GetProductByID() repository calls = %d, want 2 (one per tenant)", n)
+	}
+}
+
+func TestGetProductByIDCacheInvalidation(t *testing.T) {
+	ctx := context.Background()
+	product := domain.New(testID, testSKU, testProductName, testDescription, 1000, domain.DefaultCurrency, "")
+	var calls atomic.Int32
+
+	mockRepo := countingRepository(&product, &calls)
+	mockRepo.fetchFunc = func(ctx context.Context, id string, updates map[string]any) (*domain.Product, error) {
+		updated := *product
+		updated.Name = updates["name"].(string)
+		product = &updated
+		return copyProduct(product), nil
+	}
+	mockRepo.deleteFunc = func(ctx context.Context, id string) error {
+		return nil
+	}
+
+	svc := NewService(mockRepo, newMockLogger(), nil, nil, WithProductCache(time.Minute, 10))
+	defer svc.Close()
+
+	if _, err := svc.GetProductByID(ctx, testID); err != nil {
+		t.Fatalf("GetProductByID() unexpected error = %v", err)
+	}
+
+	newName := "Renamed Product"
+	if _, _, err := svc.UpdateProduct(ctx, testID, &newName, domain.OptionalString{}, nil, domain.OptionalString{}, domain.OptionalString{}, nil); err != nil {
+		t.Fatalf("UpdateProduct() unexpected error = %v", err)
+	}
+
+	got, err := svc.GetProductByID(ctx, testID)
+	if err != nil {
+		t.Fatalf("GetProductByID() unexpected error = %v", err)
+	}
+	if got.Name != newName {
+		t.Errorf("GetProductByID() after update name = %q, want %q", got.Name, newName)
+	}
+
+	if err := svc.DeleteProduct(ctx, testID); err != nil {
+		t.Fatalf("DeleteProduct() unexpected error = %v", err)
+	}
+	before := calls.Load()
+	if _, err := svc.GetProductByID(ctx, testID); err != nil {
+		t.Fatalf("GetProductByID() unexpected error = %v", err)
+	}
+	if calls.Load() != before+1 {
+		t.Error("GetProductByID() after delete was served from cache, want a repository read")
+	}
+}
+
+func TestGetProductByIDCacheCoalescesConcurrentReads(t *testing.T) {
+	ctx := context.Background()
+	product := domain.New(testID, testSKU, testProductName, testDescription, 1000, domain.DefaultCurrency, "")
+	release := make(chan struct{})
+	var calls atomic.Int32
+
+	mockRepo := &mockRepository{
+		getByIDFunc: func(ctx context.Context, id string) (*domain.Product, error) {
+			calls.Add(1)
+			<-release
+			return product, nil
+		},
+	}
+
+	svc := NewService(mockRepo, newMockLogger(), nil, nil, WithProductCache(time.Minute, 10))
+	defer svc.Close()
+
+	const readers = 10
+	var wg sync.WaitGroup
+	errs := make(chan error, readers)
+	for range readers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := svc.GetProductByID(ctx, testID)
+			errs <- err
+		}()
+	}
+
+	// Give the readers time to pile up behind the first load. Any that arrive
+	// after it completes are served from the cache, so the count still holds.
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("GetProductByID() unexpected error = %v", err)
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("GetProductByID() repository calls = %d, want 1", n)
+	}
+}
@@ -0,0 +1,42 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/products/domain"
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/products/repository"
+)
+
+// cursorPayload is the JSON form of a keyset cursor. Clients treat the encoded
+// value as opaque, so its shape may change between releases.
+type cursorPayload struct {
+	CreatedDate time.Time `json:"c"`
+	ID          string    `json:"i"`
+}
+
+// encodeCursor returns the opaque cursor pointing just past p.
+func encodeCursor(p *domain.Product) string {
+	raw, _ := json.Marshal(cursorPayload{CreatedDate: p.CreatedDate, ID: p.ID})
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// decodeCursor parses a cursor produced by encodeCursor.
+func decodeCursor(cursor string) (*repository.Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	var payload cursorPayload
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return nil, err
+	}
+	if payload.ID == "" || payload.CreatedDate.IsZero() {
+		return nil, errors.New("incomplete cursor")
+	}
+
+	return &repository.Cursor{CreatedDate: payload.CreatedDate, ID: payload.ID}, nil
+}
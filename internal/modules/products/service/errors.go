@@ -1,6 +1,11 @@
 package service
 
-import "errors"
+import (
+	"errors"
+
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/products/repository"
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/shared/dbutil"
+)
 
 // Sentinel errors for service-layer error classification.
 // These allow handlers to use errors.Is() for type-safe error checking
@@ -9,6 +14,25 @@ var (
 	// ErrValidation indicates input validation failure (HTTP 400).
 	ErrValidation = errors.New("validation error")
 
-	// ErrInternal indicates an internal service error (HTTP 500).
-	ErrInternal = errors.New("internal error")
+	// ErrInternal indicates an internal service error (HTTP 500). It is the
+	// repositories' dbutil.ErrInternal, so failed statements match it too.
+	ErrInternal = dbutil.ErrInternal
+
+	// ErrDBUnavailable indicates the database could not be reached (HTTP 503).
+	// Internal errors caused by it match both ErrInternal and ErrDBUnavailable,
+	// so handlers check it first.
+	ErrDBUnavailable = dbutil.ErrDBUnavailable
+
+	// ErrConflict indicates a write violated a unique constraint (HTTP 409).
+	// It is the repositories' dbutil.ErrConflict, which repository.ErrDuplicateSKU
+	// errors also match.
+	ErrConflict = dbutil.ErrConflict
+
+	// ErrLocked indicates a write to a locked product (HTTP 423). It is the
+	// repository's ErrProductLocked; only UnlockProduct clears the lock.
+	ErrLocked = repository.ErrProductLocked
+
+	// ErrInsufficientStock indicates a reservation of more units than are in
+	// stock (HTTP 409). It is the repository's ErrInsufficientStock.
+	ErrInsufficientStock = repository.ErrInsufficientStock
 )
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/products/domain"
+	"github.com/gaborage/go-bricks/multitenant"
+)
+
+// Event types, also used as routing keys.
+const (
+	// EventProductCreated is the event type of ProductCreatedEvent.
+	EventProductCreated = "product.created"
+	// EventProductUpdated is the event type of ProductUpdatedEvent.
+	EventProductUpdated = "product.updated"
+)
+
+// ProductCreatedEvent is the payload of a product.created message.
+// TenantID is the tenant the product was created for and is empty in
+// single-tenant deployments; in multi-tenant mode the framework also sets
+// the x-tenant-id header on the message.
+type ProductCreatedEvent struct {
+	ID   string `json:"id"`
+	SKU  string `json:"sku"`
+	Name string `json:"name"`
+	// Price is rendered with the decimal scale of Currency.
+	Price      json.Number `json:"price"`
+	Currency   string      `json:"currency"`
+	CategoryID string      `json:"categoryId,omitempty"`
+	TenantID   string      `json:"tenantId,omitempty"`
+}
+
+func newProductCreatedEvent(ctx context.Context, p *domain.Product) ProductCreatedEvent {
+	tenantID, _ := multitenant.GetTenant(ctx)
+	return ProductCreatedEvent{
+		ID:         p.ID,
+		SKU:        p.SKU,
+		Name:       p.Name,
+		Price:      json.Number(p.FormattedPrice()),
+		Currency:   p.Currency,
+		CategoryID: p.CategoryID,
+		TenantID:   tenantID,
+	}
+}
+
+// ProductUpdatedEvent is the payload of a product.updated message: the
+// product's fields as written by the update, plus the changes it made.
+type ProductUpdatedEvent struct {
+	*domain.Product
+	Changes []domain.FieldChange `json:"changes"`
+}
@@ -0,0 +1,18 @@
+package service
+
+import "github.com/google/uuid"
+
+// IDGenerator produces identifiers for newly created products.
+// Inject a custom implementation with WithIDGenerator (e.g. a ULID strategy,
+// or a fixed value in tests).
+type IDGenerator interface {
+	NewID() string
+}
+
+// UUIDGenerator generates random version 4 UUIDs. It is the default IDGenerator.
+type UUIDGenerator struct{}
+
+// NewID returns a new random UUID string.
+func (UUIDGenerator) NewID() string {
+	return uuid.New().String()
+}
@@ -2,62 +2,279 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/url"
+	"path"
+	"regexp"
+	"slices"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/gaborage/go-bricks-demo-project/internal/modules/products/domain"
 	"github.com/gaborage/go-bricks-demo-project/internal/modules/products/repository"
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/shared/correlation"
 	"github.com/gaborage/go-bricks/app"
 	"github.com/gaborage/go-bricks/database"
 	"github.com/gaborage/go-bricks/logger"
+	"github.com/gaborage/go-bricks/messaging"
+	"github.com/gaborage/go-bricks/outbox"
 	"github.com/google/uuid"
 )
 
+const (
+	// EventsExchange is the topic exchange product lifecycle events are published to.
+	EventsExchange = "product-events"
+
+	// DefaultMaxDescriptionLength is the longest description, in characters,
+	// accepted unless WithMaxDescriptionLength says otherwise.
+	DefaultMaxDescriptionLength = 2000
+
+	// maxSearchLength bounds list search terms so clients cannot send
+	// arbitrarily long ILIKE patterns.
+	maxSearchLength = 100
+
+	// maxSKULength matches the width of the products.sku column.
+	maxSKULength = 64
+)
+
+// skuPattern is the format of a normalized SKU: groups of upper-case letters
+// and digits joined by single dashes.
+var skuPattern = regexp.MustCompile(`^[A-Z0-9]+(-[A-Z0-9]+)*$`)
+
+// defaultImageURLSchemes is the image URL scheme allowlist used unless
+// WithImageURLSchemes overrides it.
+var defaultImageURLSchemes = []string{"http", "https"}
+
+// imageExtensions are the file extensions an image URL's path may end in.
+var imageExtensions = []string{".jpg", ".jpeg", ".png", ".gif", ".webp", ".avif", ".svg"}
+
+// CategoryChecker reports whether a category exists and the currency its
+// products must be priced in, which is empty when it accepts any. It is
+// satisfied by *repository.ProductRepository.
+type CategoryChecker interface {
+	CategoryCurrency(ctx context.Context, id string) (currency string, exists bool, err error)
+}
+
+// EventPublisher sends events straight to the broker with publisher confirms.
+// It is satisfied by publisher.Publisher.
+type EventPublisher interface {
+	Publish(ctx context.Context, options messaging.PublishOptions, data []byte) error
+}
+
 type ProductService struct {
 	repository repository.Repository
 	logger     logger.Logger
 	outbox     app.OutboxPublisher
 	getDB      func(context.Context) (database.Interface, error)
+	idGen      IDGenerator
+	events     EventPublisher
+
+	// categories validates the category of created and updated products.
+	// Without it, products cannot be given a category.
+	categories CategoryChecker
+
+	// defaultCurrency is given to created products that omit a currency.
+	defaultCurrency string
+
+	// maxListOffset caps the OFFSET ListProducts may request. Zero means unlimited.
+	maxListOffset int
+
+	// allowZeroPrice accepts free products. When false a price of exactly 0 is rejected.
+	allowZeroPrice bool
+
+	// maxDescriptionLength caps descriptions, in characters. Zero means unlimited.
+	maxDescriptionLength int
+
+	// cache fronts GetProductByID when set; see WithProductCache.
+	cache *productCache
+
+	// imageURLSchemes lists the lowercase URL schemes accepted for image URLs.
+	imageURLSchemes []string
+
+	// imageURLHosts lists the lowercase hosts accepted for image URLs. Empty
+	// accepts any host.
+	imageURLHosts []string
+}
+
+// Option configures optional ProductService dependencies.
+type Option func(*ProductService)
+
+// WithIDGenerator overrides the default UUID-based product ID generator.
+func WithIDGenerator(gen IDGenerator) Option {
+	return func(s *ProductService) {
+		if gen != nil {
+			s.idGen = gen
+		}
+	}
+}
+
+// WithEventPublisher publishes product events directly to the broker when no
+// outbox is configured. Without it, events are dropped in that mode.
+func WithEventPublisher(p EventPublisher) Option {
+	return func(s *ProductService) {
+		s.events = p
+	}
+}
+
+// WithCategoryChecker validates that the category a product is created or
+// updated with exists. Without it, any category is rejected.
+func WithCategoryChecker(c CategoryChecker) Option {
+	return func(s *ProductService) {
+		s.categories = c
+	}
+}
+
+// WithDefaultCurrency sets the currency given to created products that omit
+// one. currency must be a supported ISO 4217 code; an empty one keeps
+// domain.DefaultCurrency.
+func WithDefaultCurrency(currency string) Option {
+	return func(s *ProductService) {
+		if currency != "" {
+			s.defaultCurrency = currency
+		}
+	}
+}
+
+// WithMaxListOffset rejects list pages whose offset would exceed maxOffset,
+// protecting the database from deep OFFSET scans. Zero keeps paging unlimited.
+func WithMaxListOffset(maxOffset int) Option {
+	return func(s *ProductService) {
+		s.maxListOffset = maxOffset
+	}
+}
+
+// WithAllowZeroPrice controls whether products may be free. Catalogs that
+// forbid free products pass false; the default accepts a price of 0.
+func WithAllowZeroPrice(allow bool) Option {
+	return func(s *ProductService) {
+		s.allowZeroPrice = allow
+	}
+}
+
+// WithMaxDescriptionLength rejects descriptions longer than maxLength
+// characters. Zero accepts descriptions of any length.
+func WithMaxDescriptionLength(maxLength int) Option {
+	return func(s *ProductService) {
+		s.maxDescriptionLength = maxLength
+	}
+}
+
+// WithImageURLSchemes replaces the URL schemes accepted for image URLs, e.g.
+// "https" alone to forbid plain http, or "http", "https", "s3" for internal
+// references. Schemes are case-insensitive; an empty list keeps the default
+// http/https.
+func WithImageURLSchemes(schemes ...string) Option {
+	return func(s *ProductService) {
+		var normalized []string
+		for _, scheme := range schemes {
+			if scheme = strings.ToLower(strings.TrimSpace(scheme)); scheme != "" {
+				normalized = append(normalized, scheme)
+			}
+		}
+		if len(normalized) > 0 {
+			s.imageURLSchemes = normalized
+		}
+	}
 }
 
-func NewService(repo repository.Repository, log logger.Logger, outbox app.OutboxPublisher, getDB func(context.Context) (database.Interface, error)) *ProductService {
-	return &ProductService{
+// WithImageURLHosts restricts image URLs to the given hosts, e.g. the CDN
+// that serves product images. Hosts are case-insensitive and matched exactly,
+// without a port; an empty list accepts any host.
+func WithImageURLHosts(hosts ...string) Option {
+	return func(s *ProductService) {
+		s.imageURLHosts = nil
+		for _, host := range hosts {
+			if host = strings.ToLower(strings.TrimSpace(host)); host != "" {
+				s.imageURLHosts = append(s.imageURLHosts, host)
+			}
+		}
+	}
+}
+
+// WithProductCache caches GetProductByID results in process for ttl, keeping
+// at most maxSize products. Concurrent reads of the same uncached product are
+// coalesced into one repository call, and entries are dropped when the product
+// is updated or deleted through this service. Call Close to release the cache.
+// A non-positive ttl or maxSize leaves caching off.
+func WithProductCache(ttl time.Duration, maxSize int) Option {
+	return func(s *ProductService) {
+		if ttl > 0 && maxSize > 0 {
+			s.cache = newProductCache(ttl, maxSize)
+		}
+	}
+}
+
+func NewService(repo repository.Repository, log logger.Logger, outbox app.OutboxPublisher, getDB func(context.Context) (database.Interface, error), opts ...Option) *ProductService {
+	s := &ProductService{
 		repository: repo,
 		logger:     log,
 		outbox:     outbox,
 		getDB:      getDB,
+		idGen:      UUIDGenerator{},
+
+		defaultCurrency:      domain.DefaultCurrency,
+		allowZeroPrice:       true,
+		maxDescriptionLength: DefaultMaxDescriptionLength,
+		imageURLSchemes:      defaultImageURLSchemes,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// log returns the service logger tagged with the correlation ID of ctx.
+func (s *ProductService) log(ctx context.Context) logger.Logger {
+	return correlation.Logger(ctx, s.logger)
+}
+
+// newID returns the next product ID, defaulting to a UUID when no generator is set.
+func (s *ProductService) newID() string {
+	if s.idGen == nil {
+		return UUIDGenerator{}.NewID()
+	}
+	return s.idGen.NewID()
+}
+
+// CreateProductInput holds the fields of a product to create, for
+// CreateProduct and for each item of a CreateProducts batch.
+type CreateProductInput struct {
+	SKU         string
+	Name        string
+	Description string
+	// Price is a decimal string in Currency, which defaults to the
+	// service's default currency (see WithDefaultCurrency).
+	Price    string
+	Currency string
+	ImageURL string
+	// CategoryID is optional; a category that does not exist, or that fixes
+	// a currency other than Currency, fails with ErrValidation.
+	CategoryID string
+	// StockQuantity is the initial stock and may not be negative.
+	StockQuantity int
 }
 
 // CreateProduct creates a new product with validation.
 // When an outbox publisher is configured, the insert and a "product.created"
 // event are committed in the same database transaction (dual-write pattern).
-func (s *ProductService) CreateProduct(ctx context.Context, name, description string, price float64, imageURL string) (*domain.Product, error) {
-	// Validate name
-	if err := validateName(name); err != nil {
-		return nil, fmt.Errorf("%w: %v", ErrValidation, err)
-	}
-
-	// Validate price
-	if price < 0 {
-		return nil, fmt.Errorf("%w: price must be non-negative", ErrValidation)
+// Otherwise the event is published to the broker after the insert; that is
+// best-effort, so a publish failure is logged and the product still returned.
+// A SKU already used by a live product fails with repository.ErrDuplicateSKU.
+func (s *ProductService) CreateProduct(ctx context.Context, in CreateProductInput) (*domain.Product, error) {
+	// Validate fields and create product domain object with a new ID
+	product, err := s.newProduct(in)
+	if err != nil {
+		return nil, err
 	}
+	id := product.ID
 
-	// Validate image URL if provided
-	if imageURL != "" {
-		if err := validateURL(imageURL); err != nil {
-			return nil, fmt.Errorf("%w: invalid image URL: %v", ErrValidation, err)
-		}
+	if err := s.checkCategory(ctx, product.CategoryID, product.Currency); err != nil {
+		return nil, err
 	}
 
-	// Generate UUID for new product
-	id := uuid.New().String()
-
-	// Create product domain object
-	product := domain.New(id, name, description, price, imageURL)
-
 	// Validate domain object
 	if err := product.Validate(); err != nil {
 		return nil, fmt.Errorf("%w: %v", ErrValidation, err)
@@ -66,21 +283,31 @@ func (s *ProductService) CreateProduct(ctx context.Context, name, description st
 	// Transactional path: insert + outbox event in one transaction
 	if s.outbox != nil && s.getDB != nil {
 		if err := s.createWithOutbox(ctx, product); err != nil {
-			s.logger.Error().Err(err).Str("productID", id).Msg("Failed to create product")
-			return nil, fmt.Errorf("%w: failed to create product: %v", ErrInternal, err)
+			return nil, s.createError(ctx, err, product)
 		}
 	} else {
 		// Non-transactional fallback (legacy module, tests without outbox)
 		if err := s.repository.Create(ctx, product); err != nil {
-			s.logger.Error().Err(err).Str("productID", id).Msg("Failed to create product")
-			return nil, fmt.Errorf("%w: failed to create product: %v", ErrInternal, err)
+			return nil, s.createError(ctx, err, product)
 		}
+		s.publishDirect(ctx, EventProductCreated, newProductCreatedEvent(ctx, product))
 	}
 
-	s.logger.Info().Str("productID", id).Str("name", name).Msg("Product created successfully")
+	s.log(ctx).Info().Str("productID", id).Str("name", product.Name).Msg("Product created successfully")
 	return product, nil
 }
 
+// createError logs a failed insert of product and classifies it: a conflict
+// such as a duplicate SKU is returned as is, anything else wraps ErrInternal.
+func (s *ProductService) createError(ctx context.Context, err error, product *domain.Product) error {
+	if errors.Is(err, ErrConflict) {
+		s.log(ctx).Warn().Err(err).Str("sku", product.SKU).Msg("Product conflicts with an existing product")
+		return err
+	}
+	s.log(ctx).Error().Err(err).Str("productID", product.ID).Msg("Failed to create product")
+	return fmt.Errorf("%w: failed to create product: %w", ErrInternal, err)
+}
+
 // createWithOutbox wraps insert + outbox publish in a single transaction.
 func (s *ProductService) createWithOutbox(ctx context.Context, product *domain.Product) error {
 	db, err := s.getDB(ctx)
@@ -99,9 +326,9 @@ func (s *ProductService) createWithOutbox(ctx context.Context, product *domain.P
 	}
 
 	_, err = s.outbox.Publish(ctx, tx, &app.OutboxEvent{
-		EventType:   "product.created",
+		EventType:   EventProductCreated,
 		AggregateID: product.ID,
-		Payload:     product,
+		Payload:     newProductCreatedEvent(ctx, product),
 	})
 	if err != nil {
 		return fmt.Errorf("failed to publish outbox event: %w", err)
@@ -110,56 +337,308 @@ func (s *ProductService) createWithOutbox(ctx context.Context, product *domain.P
 	return tx.Commit(ctx)
 }
 
-// GetProductByID retrieves a product by its ID
+// GetProductByID retrieves a product by its ID, from the product cache when enabled.
 func (s *ProductService) GetProductByID(ctx context.Context, id string) (*domain.Product, error) {
-	product, err := s.repository.GetByID(ctx, id)
+	var product *domain.Product
+	var err error
+	if s.cache != nil {
+		product, err = s.cache.get(ctx, id, func() (*domain.Product, error) {
+			return s.repository.GetByID(ctx, id)
+		})
+	} else {
+		product, err = s.repository.GetByID(ctx, id)
+	}
+	if err != nil {
+		if errors.Is(err, repository.ErrProductNotFound) {
+			return nil, err
+		}
+		s.log(ctx).Error().Err(err).Str("productID", id).Msg("Failed to get product")
+		return nil, fmt.Errorf("%w: failed to get product: %w", ErrInternal, err)
+	}
+
+	return product, nil
+}
+
+// GetProductBySKU retrieves a product by its SKU, which is matched
+// case-insensitively. A malformed SKU fails with ErrValidation.
+func (s *ProductService) GetProductBySKU(ctx context.Context, sku string) (*domain.Product, error) {
+	sku, err := normalizeSKU(sku)
+	if err != nil {
+		return nil, err
+	}
+
+	product, err := s.repository.GetBySKU(ctx, sku)
 	if err != nil {
 		if errors.Is(err, repository.ErrProductNotFound) {
 			return nil, err
 		}
-		s.logger.Error().Err(err).Str("productID", id).Msg("Failed to get product")
-		return nil, fmt.Errorf("%w: failed to get product: %v", ErrInternal, err)
+		s.log(ctx).Error().Err(err).Str("sku", sku).Msg("Failed to get product by SKU")
+		return nil, fmt.Errorf("%w: failed to get product: %w", ErrInternal, err)
 	}
 
 	return product, nil
 }
 
-// validateName checks if the product name is valid
+// ProductExists reports whether a product with id exists. Unlike
+// GetProductByID it reads no columns and a missing product is not an error.
+func (s *ProductService) ProductExists(ctx context.Context, id string) (bool, error) {
+	exists, err := s.repository.Exists(ctx, id)
+	if err != nil {
+		s.log(ctx).Error().Err(err).Str("productID", id).Msg("Failed to check product existence")
+		return false, fmt.Errorf("%w: failed to check product existence: %w", ErrInternal, err)
+	}
+	return exists, nil
+}
+
+// newProduct runs the field checks for a product being created and builds it
+// with a new ID, its SKU normalized and its price in minor units.
+// Errors wrap ErrValidation.
+func (s *ProductService) newProduct(in CreateProductInput) (*domain.Product, error) {
+	// Validate SKU
+	sku, err := normalizeSKU(in.SKU)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate name
+	if err := validateName(in.Name); err != nil {
+		return nil, err
+	}
+
+	// Validate description
+	if err := s.validateDescription(in.Description); err != nil {
+		return nil, err
+	}
+
+	// Validate currency and price; an omitted currency gets the default
+	currency := in.Currency
+	if strings.TrimSpace(currency) == "" {
+		currency = s.defaultCurrency
+	}
+	currency, err = validateCurrency(currency)
+	if err != nil {
+		return nil, err
+	}
+	priceMinor, err := s.parsePrice(in.Price, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate image URL if provided
+	if in.ImageURL != "" {
+		if err := validateURL(in.ImageURL, s.imageURLSchemes, s.imageURLHosts); err != nil {
+			return nil, fmt.Errorf("invalid image URL: %w", err)
+		}
+	}
+
+	categoryID, err := normalizeCategoryID(in.CategoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	if in.StockQuantity < 0 {
+		return nil, fmt.Errorf("%w: stockQuantity cannot be negative", ErrValidation)
+	}
+
+	product := domain.New(s.newID(), sku, in.Name, in.Description, priceMinor, currency, in.ImageURL)
+	product.CategoryID = categoryID
+	product.StockQuantity = in.StockQuantity
+	return product, nil
+}
+
+// normalizeCategoryID trims id and returns it in canonical UUID form; an
+// empty id means no category. Errors wrap ErrValidation.
+func normalizeCategoryID(id string) (string, error) {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return "", nil
+	}
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		return "", fmt.Errorf("%w: categoryId must be a UUID", ErrValidation)
+	}
+	return parsed.String(), nil
+}
+
+// categoryCurrency returns the currency the normalized category id fixes
+// for its products, or "" when it accepts any. An empty id (no category)
+// fixes none. A missing category, or any category when no CategoryChecker is
+// configured, fails with ErrValidation; a failed lookup wraps ErrInternal.
+func (s *ProductService) categoryCurrency(ctx context.Context, id string) (string, error) {
+	if id == "" {
+		return "", nil
+	}
+	if s.categories == nil {
+		return "", fmt.Errorf("%w: product categories are not enabled", ErrValidation)
+	}
+
+	currency, exists, err := s.categories.CategoryCurrency(ctx, id)
+	if err != nil {
+		s.log(ctx).Error().Err(err).Str("categoryID", id).Msg("Failed to check category")
+		return "", fmt.Errorf("%w: failed to check category: %w", ErrInternal, err)
+	}
+	if !exists {
+		return "", fmt.Errorf("%w: category %s does not exist", ErrValidation, id)
+	}
+	return strings.ToUpper(strings.TrimSpace(currency)), nil
+}
+
+// checkCategory verifies that the normalized category id exists and accepts
+// products priced in currency. An empty id passes. Errors are those of
+// categoryCurrency and checkCategoryCurrency.
+func (s *ProductService) checkCategory(ctx context.Context, id, currency string) error {
+	fixed, err := s.categoryCurrency(ctx, id)
+	if err != nil {
+		return err
+	}
+	return checkCategoryCurrency(id, fixed, currency)
+}
+
+// checkCategoryCurrency rejects currency with ErrValidation when category id
+// fixes another one. An empty fixed currency accepts any.
+func checkCategoryCurrency(id, fixed, currency string) error {
+	if fixed != "" && fixed != currency {
+		return fmt.Errorf("%w: category %s only accepts products priced in %s, not %s", ErrValidation, id, fixed, currency)
+	}
+	return nil
+}
+
+// normalizeSKU trims and upper-cases sku and checks it is letters and digits
+// joined by single dashes, at most maxSKULength long. Errors wrap ErrValidation.
+func normalizeSKU(sku string) (string, error) {
+	sku = strings.ToUpper(strings.TrimSpace(sku))
+	if sku == "" {
+		return "", fmt.Errorf("%w: product SKU is required", ErrValidation)
+	}
+	if len(sku) > maxSKULength {
+		return "", fmt.Errorf("%w: product SKU must be at most %d characters", ErrValidation, maxSKULength)
+	}
+	if !skuPattern.MatchString(sku) {
+		return "", fmt.Errorf("%w: product SKU must contain only letters, digits and single dashes between them", ErrValidation)
+	}
+	return sku, nil
+}
+
+// validateName checks if the product name is valid. Errors wrap ErrValidation.
 func validateName(name string) error {
 	name = strings.TrimSpace(name)
 	if name == "" {
-		return fmt.Errorf("product name is required")
+		return fmt.Errorf("%w: product name is required", ErrValidation)
 	}
 	if len(name) > 150 {
-		return fmt.Errorf("product name must be less than 150 characters")
+		return fmt.Errorf("%w: product name must be less than 150 characters", ErrValidation)
 	}
 	return nil
 }
 
-// validateURL checks if the URL is valid
-func validateURL(urlStr string) error {
+// validateDescription rejects descriptions longer than the configured maximum
+// number of characters. Errors wrap ErrValidation.
+func (s *ProductService) validateDescription(description string) error {
+	if s.maxDescriptionLength > 0 && utf8.RuneCountInString(description) > s.maxDescriptionLength {
+		return fmt.Errorf("%w: description must be at most %d characters", ErrValidation, s.maxDescriptionLength)
+	}
+	return nil
+}
+
+// validateCurrency normalizes an ISO 4217 code, defaulting an empty one to
+// domain.DefaultCurrency. Unsupported codes are rejected; errors wrap
+// ErrValidation.
+func validateCurrency(currency string) (string, error) {
+	normalized, err := domain.NormalizeCurrency(currency)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+	return normalized, nil
+}
+
+// parsePrice converts a decimal price string to minor units of currency and
+// validates the result. Errors wrap ErrValidation.
+func (s *ProductService) parsePrice(price, currency string) (int64, error) {
+	priceMinor, err := domain.ParsePrice(price, currency)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+	if err := s.validatePrice(priceMinor); err != nil {
+		return 0, err
+	}
+	return priceMinor, nil
+}
+
+// validatePrice rejects negative prices, and zero when free products are not
+// allowed. price is in minor units. Errors wrap ErrValidation.
+func (s *ProductService) validatePrice(price int64) error {
+	if price < 0 {
+		return fmt.Errorf("%w: price must be non-negative", ErrValidation)
+	}
+	if price == 0 && !s.allowZeroPrice {
+		return fmt.Errorf("%w: price must be greater than zero", ErrValidation)
+	}
+	return nil
+}
+
+// validateURL checks that the URL is absolute, uses one of schemes, has a
+// host in hosts (any host when hosts is empty) and a path ending in an image
+// extension. The empty URL means no image and is valid. Errors wrap
+// ErrValidation.
+func validateURL(urlStr string, schemes, hosts []string) error {
 	if urlStr == "" {
 		return nil
 	}
 
 	parsedURL, err := url.Parse(urlStr)
 	if err != nil {
-		return err
+		return fmt.Errorf("%w: %v", ErrValidation, err)
 	}
 
-	if parsedURL.Scheme != "http" && parsedURL.Scheme != "https" {
-		return fmt.Errorf("URL must use http or https scheme")
+	if !slices.Contains(schemes, strings.ToLower(parsedURL.Scheme)) {
+		return fmt.Errorf("%w: URL must use %s scheme", ErrValidation, joinOr(schemes))
 	}
 
 	if parsedURL.Host == "" {
-		return fmt.Errorf("URL must have a valid host")
+		return fmt.Errorf("%w: URL must have a valid host", ErrValidation)
+	}
+
+	if len(hosts) > 0 && !slices.Contains(hosts, strings.ToLower(parsedURL.Hostname())) {
+		return fmt.Errorf("%w: URL host %q is not allowed; use %s", ErrValidation, parsedURL.Hostname(), joinOr(hosts))
+	}
+
+	if !slices.Contains(imageExtensions, strings.ToLower(path.Ext(parsedURL.Path))) {
+		return fmt.Errorf("%w: URL path must end in an image extension (%s)", ErrValidation, joinOr(imageExtensions))
 	}
 
 	return nil
 }
 
-// ListProducts retrieves a paginated list of products
-func (s *ProductService) ListProducts(ctx context.Context, page, pageSize int) ([]*domain.Product, int, error) {
+// joinOr renders items as "a", "a or b" or "a, b or c".
+func joinOr(items []string) string {
+	if len(items) <= 1 {
+		return strings.Join(items, "")
+	}
+	return strings.Join(items[:len(items)-1], ", ") + " or " + items[len(items)-1]
+}
+
+// ListOptions narrows and orders ListProducts. The zero value lists every
+// product, newest first.
+type ListOptions struct {
+	// Search keeps products whose name or description contains the term.
+	Search string
+	// SortBy is one of createdDate, name or price; SortOrder is asc or desc.
+	SortBy    string
+	SortOrder string
+	// Currency keeps only products priced in it. Price bounds are in its
+	// major units and imply domain.DefaultCurrency when it is empty.
+	Currency string
+	// CategoryID keeps only products in that category.
+	CategoryID string
+	// MinPrice and MaxPrice are optional inclusive price bounds as decimal
+	// strings, such as "19.99". Empty means unbounded.
+	MinPrice string
+	MaxPrice string
+}
+
+// ListProducts retrieves a paginated list of products matching opts.
+// Sort fields are validated against an allowlist.
+func (s *ProductService) ListProducts(ctx context.Context, page, pageSize int, opts ListOptions) ([]*domain.Product, int, error) {
 	// Validate pagination parameters
 	if page < 1 {
 		return nil, 0, fmt.Errorf("%w: page must be greater than 0", ErrValidation)
@@ -168,110 +647,429 @@ func (s *ProductService) ListProducts(ctx context.Context, page, pageSize int) (
 		return nil, 0, fmt.Errorf("%w: pageSize must be between 1 and 100", ErrValidation)
 	}
 
+	filter, err := listFilter(opts)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	sort, err := parseSort(opts.SortBy, opts.SortOrder)
+	if err != nil {
+		return nil, 0, err
+	}
+
 	// Calculate offset
 	offset := (page - 1) * pageSize
+	if s.maxListOffset > 0 && offset > s.maxListOffset {
+		return nil, 0, fmt.Errorf("%w: page %d exceeds the maximum list depth of %d items; use cursor pagination to read further",
+			ErrValidation, page, s.maxListOffset)
+	}
 
 	// Fetch from repository
-	products, total, err := s.repository.List(ctx, pageSize, offset)
+	products, total, err := s.repository.List(ctx, pageSize, offset, filter, sort)
 	if err != nil {
-		s.logger.Error().Err(err).Int("page", page).Int("pageSize", pageSize).Msg("Failed to list products")
-		return nil, 0, fmt.Errorf("%w: failed to list products: %v", ErrInternal, err)
+		s.log(ctx).Error().Err(err).Int("page", page).Int("pageSize", pageSize).Msg("Failed to list products")
+		return nil, 0, fmt.Errorf("%w: failed to list products: %w", ErrInternal, err)
 	}
 
 	return products, total, nil
 }
 
-// UpdateProduct performs a partial update on a product.
-// After a successful update, publishes a "product.updated" event to the outbox
-// (non-transactional — the single UPDATE statement is inherently atomic).
-func (s *ProductService) UpdateProduct(ctx context.Context, id string, name *string, description *string, price *float64, imageURL *string) (*domain.Product, error) {
+// listFilter validates the filtering part of opts and converts it for the
+// repository. Errors wrap ErrValidation.
+func listFilter(opts ListOptions) (repository.ListFilter, error) {
+	search := strings.TrimSpace(opts.Search)
+	if utf8.RuneCountInString(search) > maxSearchLength {
+		return repository.ListFilter{}, fmt.Errorf("%w: search must be at most %d characters", ErrValidation, maxSearchLength)
+	}
+
+	categoryID, err := normalizeCategoryID(opts.CategoryID)
+	if err != nil {
+		return repository.ListFilter{}, err
+	}
+
+	filter := repository.ListFilter{Search: search, CategoryID: categoryID}
+	if opts.Currency == "" && opts.MinPrice == "" && opts.MaxPrice == "" {
+		return filter, nil
+	}
+
+	// Minor units only compare within a currency, so price bounds always
+	// restrict the currency too.
+	currency, err := validateCurrency(opts.Currency)
+	if err != nil {
+		return repository.ListFilter{}, err
+	}
+	filter.Currency = currency
+	if filter.MinPrice, err = parsePriceBound("minPrice", opts.MinPrice, currency); err != nil {
+		return repository.ListFilter{}, err
+	}
+	if filter.MaxPrice, err = parsePriceBound("maxPrice", opts.MaxPrice, currency); err != nil {
+		return repository.ListFilter{}, err
+	}
+	if filter.MinPrice != nil && filter.MaxPrice != nil && *filter.MinPrice > *filter.MaxPrice {
+		return repository.ListFilter{}, fmt.Errorf("%w: minPrice must not be greater than maxPrice", ErrValidation)
+	}
+	return filter, nil
+}
+
+// parsePriceBound converts the decimal price bound named name to minor units
+// of currency, like prices on create and update. An empty bound returns nil.
+// Errors wrap ErrValidation.
+func parsePriceBound(name, bound, currency string) (*int64, error) {
+	if bound == "" {
+		return nil, nil
+	}
+	minor, err := domain.ParsePrice(bound, currency)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrValidation, name, err)
+	}
+	if minor < 0 {
+		return nil, fmt.Errorf("%w: %s must be non-negative", ErrValidation, name)
+	}
+	return &minor, nil
+}
+
+// ListProductsAfter returns the page of products that follows cursor, newest
+// first, using keyset pagination. An empty cursor returns the first page. The
+// returned cursor fetches the next page and is empty on the last one.
+func (s *ProductService) ListProductsAfter(ctx context.Context, cursor string, pageSize int) ([]*domain.Product, string, error) {
+	if pageSize < 1 || pageSize > 100 {
+		return nil, "", fmt.Errorf("%w: pageSize must be between 1 and 100", ErrValidation)
+	}
+
+	var after *repository.Cursor
+	if cursor != "" {
+		decoded, err := decodeCursor(cursor)
+		if err != nil {
+			return nil, "", fmt.Errorf("%w: invalid cursor", ErrValidation)
+		}
+		after = decoded
+	}
+
+	// Fetch one extra row to learn whether another page follows.
+	products, err := s.repository.ListAfter(ctx, after, pageSize+1)
+	if err != nil {
+		s.log(ctx).Error().Err(err).Int("pageSize", pageSize).Msg("Failed to list products by cursor")
+		return nil, "", fmt.Errorf("%w: failed to list products: %w", ErrInternal, err)
+	}
+
+	var nextCursor string
+	if len(products) > pageSize {
+		products = products[:pageSize]
+		nextCursor = encodeCursor(products[pageSize-1])
+	}
+
+	return products, nextCursor, nil
+}
+
+// StreamProducts passes every product to fn in creation-date order without
+// loading the full list into memory. Errors returned by fn are passed through
+// unchanged so callers can tell a failed write from a failed read.
+func (s *ProductService) StreamProducts(ctx context.Context, opts repository.StreamOptions, fn func(*domain.Product) error) error {
+	var fnErr error
+	err := s.repository.Stream(ctx, opts, func(p *domain.Product) error {
+		fnErr = fn(p)
+		return fnErr
+	})
+	if err != nil {
+		if fnErr != nil {
+			return fnErr
+		}
+		s.log(ctx).Error().Err(err).Msg("Failed to stream products")
+		return fmt.Errorf("%w: failed to stream products: %w", ErrInternal, err)
+	}
+
+	return nil
+}
+
+// FindDuplicates reports groups of products that are likely duplicates of
+// each other, for catalog clean-up.
+func (s *ProductService) FindDuplicates(ctx context.Context) ([]repository.DuplicateGroup, error) {
+	groups, err := s.repository.FindDuplicates(ctx)
+	if err != nil {
+		s.log(ctx).Error().Err(err).Msg("Failed to find duplicate products")
+		return nil, fmt.Errorf("%w: failed to find duplicate products: %w", ErrInternal, err)
+	}
+
+	return groups, nil
+}
+
+// PriceStats returns min/max/average prices of the products priced in
+// currency (domain.DefaultCurrency when empty), optionally for the single
+// category whose ID category points to.
+func (s *ProductService) PriceStats(ctx context.Context, currency string, category *string) (repository.PriceStats, error) {
+	currency, err := validateCurrency(currency)
+	if err != nil {
+		return repository.PriceStats{}, err
+	}
+	if category != nil {
+		categoryID, err := normalizeCategoryID(*category)
+		if err != nil {
+			return repository.PriceStats{}, err
+		}
+		if categoryID == "" {
+			return repository.PriceStats{}, fmt.Errorf("%w: category must not be empty", ErrValidation)
+		}
+		category = &categoryID
+	}
+
+	stats, err := s.repository.PriceStats(ctx, currency, category)
+	if err != nil {
+		s.log(ctx).Error().Err(err).Msg("Failed to compute price stats")
+		return repository.PriceStats{}, fmt.Errorf("%w: failed to compute price stats: %w", ErrInternal, err)
+	}
+
+	return stats, nil
+}
+
+// UpdateProduct performs a partial update on a product and returns it as
+// written, re-read in the same transaction as the update, along with the
+// fields the update changed compared to the product just before it. Fields
+// set to their current value are not reported. A non-nil version
+// makes the update fail with repository.ErrConcurrentModification unless the
+// product is still at that version. A locked product fails with ErrLocked.
+// An absent description or imageURL leaves the field unchanged, while an
+// empty string or null clears it; any other imageURL must be a valid image URL.
+// categoryID follows the same rule and must name an existing category that
+// accepts the product's currency.
+// price is a decimal string in the product's currency, which an update never
+// changes.
+// After a successful update, publishes a "product.updated" event carrying the
+// product and its changes to the outbox (non-transactional — it is published
+// once the update has committed).
+func (s *ProductService) UpdateProduct(ctx context.Context, id string, name *string, description domain.OptionalString, price *string, imageURL domain.OptionalString, categoryID domain.OptionalString, version *int) (*domain.Product, []domain.FieldChange, error) {
 	// Build update map with only provided fields
 	updates := make(map[string]any)
 
 	if name != nil {
 		if err := validateName(*name); err != nil {
-			return nil, fmt.Errorf("%w: %v", ErrValidation, err)
+			return nil, nil, err
 		}
 		updates["name"] = *name
 	}
 
-	if description != nil {
-		updates["description"] = *description
+	if description.Present {
+		if err := s.validateDescription(description.Value); err != nil {
+			return nil, nil, err
+		}
+		// Value is empty for null, so both clear the description.
+		updates["description"] = description.Value
 	}
 
 	if price != nil {
-		if *price < 0 {
-			return nil, fmt.Errorf("%w: price must be non-negative", ErrValidation)
+		priceMinor, err := s.parseUpdatedPrice(ctx, id, *price)
+		if err != nil {
+			return nil, nil, err
 		}
-		updates["price"] = *price
+		updates["price"] = priceMinor
 	}
 
-	if imageURL != nil {
-		if *imageURL != "" {
-			if err := validateURL(*imageURL); err != nil {
-				return nil, fmt.Errorf("%w: invalid image URL: %v", ErrValidation, err)
+	if imageURL.Present {
+		// Value is empty for null, so both clear the image.
+		if imageURL.Value != "" {
+			if err := validateURL(imageURL.Value, s.imageURLSchemes, s.imageURLHosts); err != nil {
+				return nil, nil, fmt.Errorf("invalid image URL: %w", err)
 			}
 		}
-		updates["image_url"] = *imageURL
+		updates["imageURL"] = imageURL.Value
+	}
+
+	if categoryID.Present {
+		normalized, err := normalizeCategoryID(categoryID.Value)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := s.checkUpdatedCategory(ctx, id, normalized); err != nil {
+			return nil, nil, err
+		}
+		// Uncategorized products store NULL, so clearing writes nil.
+		if normalized == "" {
+			updates["categoryId"] = nil
+		} else {
+			updates["categoryId"] = normalized
+		}
 	}
 
 	// Return error if no fields to update
 	if len(updates) == 0 {
-		return nil, fmt.Errorf("%w: no fields to update", ErrValidation)
+		return nil, nil, fmt.Errorf("%w: no fields to update", ErrValidation)
 	}
 
-	// Always update the updated_date
-	updates["updated_date"] = "NOW()"
+	// Only update the product if it is still at the version the client read
+	if version != nil {
+		updates["version"] = *version
+	}
 
-	// Perform update in repository
-	if err := s.repository.Update(ctx, id, updates); err != nil {
-		if errors.Is(err, repository.ErrProductNotFound) {
-			return nil, err
+	// Read, update and re-read in one transaction so the response and the
+	// changes reflect exactly this update
+	before, product, err := s.repository.UpdateAndCompare(ctx, id, updates)
+	s.invalidateCache(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrProductNotFound) || errors.Is(err, repository.ErrConcurrentModification) ||
+			errors.Is(err, ErrLocked) || errors.Is(err, ErrConflict) {
+			return nil, nil, err
 		}
-		s.logger.Error().Err(err).Str("productID", id).Msg("Failed to update product")
-		return nil, fmt.Errorf("%w: failed to update product: %v", ErrInternal, err)
+		s.log(ctx).Error().Err(err).Str("productID", id).Msg("Failed to update product")
+		return nil, nil, fmt.Errorf("%w: failed to update product: %w", ErrInternal, err)
 	}
 
-	// Fetch and return updated product
-	product, err := s.repository.GetByID(ctx, id)
+	changes := domain.Diff(before, product)
+
+	// Publish outbox event after successful update (best-effort, non-transactional)
+	s.publishEvent(ctx, EventProductUpdated, id, ProductUpdatedEvent{Product: product, Changes: changes})
+
+	s.log(ctx).Info().
+		Str("productID", id).
+		Interface("changes", changes).
+		Msg("Product updated successfully")
+	return product, changes, nil
+}
+
+// parseUpdatedPrice converts an update's price to minor units of the
+// product's currency.
+func (s *ProductService) parseUpdatedPrice(ctx context.Context, id, price string) (int64, error) {
+	currency, err := s.productCurrency(ctx, id)
 	if err != nil {
-		s.logger.Error().Err(err).Str("productID", id).Msg("Failed to fetch updated product")
-		return nil, fmt.Errorf("%w: failed to fetch updated product: %v", ErrInternal, err)
+		return 0, err
 	}
+	return s.parsePrice(price, currency)
+}
 
-	// Publish outbox event after successful update (best-effort, non-transactional)
-	s.publishEvent(ctx, "product.updated", id, product)
+// checkUpdatedCategory verifies the normalized category an update moves
+// product id to. The product's currency is only read when the category fixes
+// one.
+func (s *ProductService) checkUpdatedCategory(ctx context.Context, id, categoryID string) error {
+	fixed, err := s.categoryCurrency(ctx, categoryID)
+	if err != nil || fixed == "" {
+		return err
+	}
+	currency, err := s.productCurrency(ctx, id)
+	if err != nil {
+		return err
+	}
+	return checkCategoryCurrency(categoryID, fixed, currency)
+}
 
-	s.logger.Info().Str("productID", id).Msg("Product updated successfully")
-	return product, nil
+// productCurrency reads the currency of the product being updated. It is read
+// up front; it never changes after creation, so the read cannot race with the
+// update.
+func (s *ProductService) productCurrency(ctx context.Context, id string) (string, error) {
+	current, err := s.repository.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrProductNotFound) {
+			return "", err
+		}
+		s.log(ctx).Error().Err(err).Str("productID", id).Msg("Failed to get product")
+		return "", fmt.Errorf("%w: failed to get product: %w", ErrInternal, err)
+	}
+	return current.Currency, nil
 }
 
-// DeleteProduct removes a product.
+// DeleteProduct removes a product. A locked product is kept and ErrLocked
+// is returned.
 // When an outbox publisher is configured, the delete and a "product.deleted"
 // event are committed in the same database transaction.
 func (s *ProductService) DeleteProduct(ctx context.Context, id string) error {
+	defer s.invalidateCache(ctx, id)
+
 	if s.outbox != nil && s.getDB != nil {
 		if err := s.deleteWithOutbox(ctx, id); err != nil {
-			if errors.Is(err, repository.ErrProductNotFound) {
+			if errors.Is(err, repository.ErrProductNotFound) || errors.Is(err, ErrLocked) {
 				return err
 			}
-			s.logger.Error().Err(err).Str("productID", id).Msg("Failed to delete product")
-			return fmt.Errorf("%w: failed to delete product: %v", ErrInternal, err)
+			s.log(ctx).Error().Err(err).Str("productID", id).Msg("Failed to delete product")
+			return fmt.Errorf("%w: failed to delete product: %w", ErrInternal, err)
 		}
 	} else {
 		if err := s.repository.Delete(ctx, id); err != nil {
-			if errors.Is(err, repository.ErrProductNotFound) {
+			if errors.Is(err, repository.ErrProductNotFound) || errors.Is(err, ErrLocked) {
 				return err
 			}
-			s.logger.Error().Err(err).Str("productID", id).Msg("Failed to delete product")
-			return fmt.Errorf("%w: failed to delete product: %v", ErrInternal, err)
+			s.log(ctx).Error().Err(err).Str("productID", id).Msg("Failed to delete product")
+			return fmt.Errorf("%w: failed to delete product: %w", ErrInternal, err)
 		}
+		s.publishDirect(ctx, "product.deleted", map[string]string{"id": id})
 	}
 
-	s.logger.Info().Str("productID", id).Msg("Product deleted successfully")
+	s.log(ctx).Info().Str("productID", id).Msg("Product deleted successfully")
 	return nil
 }
 
+// LockProduct locks a product so that UpdateProduct and DeleteProduct reject
+// it with ErrLocked. Locking a locked product is a no-op.
+func (s *ProductService) LockProduct(ctx context.Context, id string) error {
+	return s.setLocked(ctx, id, true)
+}
+
+// UnlockProduct clears the lock set by LockProduct. It is the only way to
+// make a locked product writable again.
+func (s *ProductService) UnlockProduct(ctx context.Context, id string) error {
+	return s.setLocked(ctx, id, false)
+}
+
+func (s *ProductService) setLocked(ctx context.Context, id string, locked bool) error {
+	defer s.invalidateCache(ctx, id)
+
+	if err := s.repository.SetLocked(ctx, id, locked); err != nil {
+		if errors.Is(err, repository.ErrProductNotFound) {
+			return err
+		}
+		s.log(ctx).Error().Err(err).Str("productID", id).Bool("locked", locked).Msg("Failed to set product lock")
+		return fmt.Errorf("%w: failed to set product lock: %w", ErrInternal, err)
+	}
+
+	s.log(ctx).Info().Str("productID", id).Bool("locked", locked).Msg("Product lock updated")
+	return nil
+}
+
+// ReserveStock takes qty units of a product out of stock. It fails with
+// ErrInsufficientStock, leaving the stock unchanged, when fewer than qty are
+// available; concurrent reservations never oversell.
+func (s *ProductService) ReserveStock(ctx context.Context, id string, qty int) error {
+	return s.changeStock(ctx, id, qty, true)
+}
+
+// ReleaseStock puts qty units taken by ReserveStock back into stock.
+func (s *ProductService) ReleaseStock(ctx context.Context, id string, qty int) error {
+	return s.changeStock(ctx, id, qty, false)
+}
+
+func (s *ProductService) changeStock(ctx context.Context, id string, qty int, reserve bool) error {
+	if qty <= 0 {
+		return fmt.Errorf("%w: quantity must be positive", ErrValidation)
+	}
+	defer s.invalidateCache(ctx, id)
+
+	change := s.repository.Release
+	if reserve {
+		change = s.repository.Reserve
+	}
+	if err := change(ctx, id, qty); err != nil {
+		if errors.Is(err, repository.ErrProductNotFound) || errors.Is(err, ErrInsufficientStock) {
+			return err
+		}
+		s.log(ctx).Error().Err(err).Str("productID", id).Int("quantity", qty).Bool("reserve", reserve).Msg("Failed to change product stock")
+		return fmt.Errorf("%w: failed to change product stock: %w", ErrInternal, err)
+	}
+
+	s.log(ctx).Info().Str("productID", id).Int("quantity", qty).Bool("reserve", reserve).Msg("Product stock changed")
+	return nil
+}
+
+// invalidateCache drops id from the product cache, if enabled. It runs even
+// when a write fails, since the row may have changed anyway.
+func (s *ProductService) invalidateCache(ctx context.Context, id string) {
+	if s.cache != nil {
+		s.cache.invalidate(ctx, id)
+	}
+}
+
+// Close releases resources held by the service, such as the product cache.
+func (s *ProductService) Close() {
+	if s.cache != nil {
+		s.cache.close()
+	}
+}
+
 // deleteWithOutbox wraps delete + outbox publish in a single transaction.
 func (s *ProductService) deleteWithOutbox(ctx context.Context, id string) error {
 	db, err := s.getDB(ctx)
@@ -305,18 +1103,19 @@ func (s *ProductService) deleteWithOutbox(ctx context.Context, id string) error
 // Used for updates where the single UPDATE is already atomic.
 func (s *ProductService) publishEvent(ctx context.Context, eventType, aggregateID string, payload any) {
 	if s.outbox == nil || s.getDB == nil {
+		s.publishDirect(ctx, eventType, payload)
 		return
 	}
 
 	db, err := s.getDB(ctx)
 	if err != nil {
-		s.logger.Warn().Err(err).Str("eventType", eventType).Msg("Failed to get DB for outbox event")
+		s.log(ctx).Warn().Err(err).Str("eventType", eventType).Msg("Failed to get DB for outbox event")
 		return
 	}
 
 	tx, err := db.Begin(ctx)
 	if err != nil {
-		s.logger.Warn().Err(err).Str("eventType", eventType).Msg("Failed to begin tx for outbox event")
+		s.log(ctx).Warn().Err(err).Str("eventType", eventType).Msg("Failed to begin tx for outbox event")
 		return
 	}
 	defer tx.Rollback(ctx) //nolint:errcheck
@@ -327,11 +1126,35 @@ func (s *ProductService) publishEvent(ctx context.Context, eventType, aggregateI
 		Payload:     payload,
 	})
 	if err != nil {
-		s.logger.Warn().Err(err).Str("eventType", eventType).Msg("Failed to publish outbox event")
+		s.log(ctx).Warn().Err(err).Str("eventType", eventType).Msg("Failed to publish outbox event")
 		return
 	}
 
 	if err := tx.Commit(ctx); err != nil {
-		s.logger.Warn().Err(err).Str("eventType", eventType).Msg("Failed to commit outbox event")
+		s.log(ctx).Warn().Err(err).Str("eventType", eventType).Msg("Failed to commit outbox event")
+	}
+}
+
+// publishDirect sends an event to the broker when no outbox is configured.
+// The publisher waits for the broker ack and retries transient failures, but
+// unlike the outbox the event is not committed atomically with the data change.
+func (s *ProductService) publishDirect(ctx context.Context, eventType string, payload any) {
+	if s.events == nil {
+		return
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		s.log(ctx).Warn().Err(err).Str("eventType", eventType).Msg("Failed to encode event payload")
+		return
+	}
+
+	err = s.events.Publish(ctx, messaging.PublishOptions{
+		Exchange:   EventsExchange,
+		RoutingKey: eventType,
+		Headers:    map[string]any{outbox.HeaderEventType: eventType},
+	}, data)
+	if err != nil {
+		s.log(ctx).Warn().Err(err).Str("eventType", eventType).Msg("Failed to publish event")
 	}
 }
@@ -2,9 +2,13 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gaborage/go-bricks-demo-project/internal/modules/products/domain"
 	"github.com/gaborage/go-bricks-demo-project/internal/modules/products/repository"
@@ -12,11 +16,15 @@ import (
 	dbtest "github.com/gaborage/go-bricks/database/testing"
 	dbtypes "github.com/gaborage/go-bricks/database/types"
 	"github.com/gaborage/go-bricks/logger"
+	"github.com/gaborage/go-bricks/messaging"
+	"github.com/gaborage/go-bricks/multitenant"
 	outboxtest "github.com/gaborage/go-bricks/outbox/testing"
+	"github.com/google/uuid"
 )
 
 const (
 	testProductName     = "Test Product"
+	testSKU             = "MUG-001"
 	testDescription     = "Test Description"
 	testImageURL        = "https://example.com/image.jpg"
 	requiredMsg         = "required"
@@ -31,13 +39,27 @@ const (
 
 // mockRepository implements repository methods for testing
 type mockRepository struct {
-	createFunc   func(ctx context.Context, product *domain.Product) error
-	createTxFunc func(ctx context.Context, tx dbtypes.Tx, product *domain.Product) error
-	getByIDFunc  func(ctx context.Context, id string) (*domain.Product, error)
-	listFunc     func(ctx context.Context, limit, offset int) ([]*domain.Product, int, error)
-	updateFunc   func(ctx context.Context, id string, updates map[string]any) error
-	deleteFunc   func(ctx context.Context, id string) error
-	deleteTxFunc func(ctx context.Context, tx dbtypes.Tx, id string) error
+	createFunc    func(ctx context.Context, product *domain.Product) error
+	createTxFunc  func(ctx context.Context, tx dbtypes.Tx, product *domain.Product) error
+	batchFunc     func(ctx context.Context, products []*domain.Product) error
+	batchTxFunc   func(ctx context.Context, tx dbtypes.Tx, products []*domain.Product) error
+	getByIDFunc   func(ctx context.Context, id string) (*domain.Product, error)
+	getBySKUFunc  func(ctx context.Context, sku string) (*domain.Product, error)
+	existsFunc    func(ctx context.Context, id string) (bool, error)
+	listFunc      func(ctx context.Context, limit, offset int) ([]*domain.Product, int, error)
+	listAfterFunc func(ctx context.Context, after *repository.Cursor, limit int) ([]*domain.Product, error)
+	streamFunc    func(ctx context.Context, fn func(*domain.Product) error) error
+	updateFunc    func(ctx context.Context, id string, updates map[string]any) error
+	fetchFunc     func(ctx context.Context, id string, updates map[string]any) (*domain.Product, error)
+	compareFunc   func(ctx context.Context, id string, updates map[string]any) (*domain.Product, *domain.Product, error)
+	deleteFunc    func(ctx context.Context, id string) error
+	deleteTxFunc  func(ctx context.Context, tx dbtypes.Tx, id string) error
+	lockFunc      func(ctx context.Context, id string, locked bool) error
+	stockFunc     func(ctx context.Context, id string, delta int) error
+
+	// listFilter and listSort record the arguments of the last List call.
+	listFilter repository.ListFilter
+	listSort   repository.Sort
 }
 
 func (m *mockRepository) Create(ctx context.Context, product *domain.Product) error {
@@ -54,6 +76,20 @@ func (m *mockRepository) CreateTx(ctx context.Context, tx dbtypes.Tx, product *d
 	return nil
 }
 
+func (m *mockRepository) CreateBatch(ctx context.Context, products []*domain.Product) error {
+	if m.batchFunc != nil {
+		return m.batchFunc(ctx, products)
+	}
+	return nil
+}
+
+func (m *mockRepository) CreateBatchTx(ctx context.Context, tx dbtypes.Tx, products []*domain.Product) error {
+	if m.batchTxFunc != nil {
+		return m.batchTxFunc(ctx, tx, products)
+	}
+	return nil
+}
+
 func (m *mockRepository) GetByID(ctx context.Context, id string) (*domain.Product, error) {
 	if m.getByIDFunc != nil {
 		return m.getByIDFunc(ctx, id)
@@ -61,13 +97,51 @@ func (m *mockRepository) GetByID(ctx context.Context, id string) (*domain.Produc
 	return nil, errors.New("not implemented")
 }
 
-func (m *mockRepository) List(ctx context.Context, limit, offset int) ([]*domain.Product, int, error) {
+func (m *mockRepository) GetBySKU(ctx context.Context, sku string) (*domain.Product, error) {
+	if m.getBySKUFunc != nil {
+		return m.getBySKUFunc(ctx, sku)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockRepository) Exists(ctx context.Context, id string) (bool, error) {
+	if m.existsFunc != nil {
+		return m.existsFunc(ctx, id)
+	}
+	return false, errors.New("not implemented")
+}
+
+func (m *mockRepository) List(ctx context.Context, limit, offset int, filter repository.ListFilter, sort repository.Sort) ([]*domain.Product, int, error) {
+	m.listFilter = filter
+	m.listSort = sort
 	if m.listFunc != nil {
 		return m.listFunc(ctx, limit, offset)
 	}
 	return nil, 0, errors.New("not implemented")
 }
 
+func (m *mockRepository) ListAfter(ctx context.Context, after *repository.Cursor, limit int) ([]*domain.Product, error) {
+	if m.listAfterFunc != nil {
+		return m.listAfterFunc(ctx, after, limit)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockRepository) Stream(ctx context.Context, _ repository.StreamOptions, fn func(*domain.Product) error) error {
+	if m.streamFunc != nil {
+		return m.streamFunc(ctx, fn)
+	}
+	return errors.New("not implemented")
+}
+
+func (m *mockRepository) FindDuplicates(context.Context) ([]repository.DuplicateGroup, error) {
+	return nil, nil
+}
+
+func (m *mockRepository) PriceStats(context.Context, string, *string) (repository.PriceStats, error) {
+	return repository.PriceStats{}, nil
+}
+
 func (m *mockRepository) Update(ctx context.Context, id string, updates map[string]any) error {
 	if m.updateFunc != nil {
 		return m.updateFunc(ctx, id, updates)
@@ -75,6 +149,37 @@ func (m *mockRepository) Update(ctx context.Context, id string, updates map[stri
 	return nil
 }
 
+func (m *mockRepository) UpdateAndFetch(ctx context.Context, id string, updates map[string]any) (*domain.Product, error) {
+	if m.fetchFunc != nil {
+		return m.fetchFunc(ctx, id, updates)
+	}
+	return nil, errors.New("not implemented")
+}
+
+// UpdateAndCompare calls compareFunc when set. Otherwise it reads before with
+// getByIDFunc when set, or uses the updated product so no changes are
+// reported.
+func (m *mockRepository) UpdateAndCompare(ctx context.Context, id string, updates map[string]any) (*domain.Product, *domain.Product, error) {
+	if m.compareFunc != nil {
+		return m.compareFunc(ctx, id, updates)
+	}
+	var before *domain.Product
+	if m.getByIDFunc != nil {
+		var err error
+		if before, err = m.getByIDFunc(ctx, id); err != nil {
+			return nil, nil, err
+		}
+	}
+	after, err := m.UpdateAndFetch(ctx, id, updates)
+	if err != nil {
+		return nil, nil, err
+	}
+	if before == nil {
+		before = after
+	}
+	return before, after, nil
+}
+
 func (m *mockRepository) Delete(ctx context.Context, id string) error {
 	if m.deleteFunc != nil {
 		return m.deleteFunc(ctx, id)
@@ -89,39 +194,118 @@ func (m *mockRepository) DeleteTx(ctx context.Context, tx dbtypes.Tx, id string)
 	return nil
 }
 
+func (m *mockRepository) SetLocked(ctx context.Context, id string, locked bool) error {
+	if m.lockFunc != nil {
+		return m.lockFunc(ctx, id, locked)
+	}
+	return nil
+}
+
+// Reserve and Release pass stockFunc the signed change in stock.
+func (m *mockRepository) Reserve(ctx context.Context, id string, qty int) error {
+	if m.stockFunc != nil {
+		return m.stockFunc(ctx, id, -qty)
+	}
+	return nil
+}
+
+func (m *mockRepository) Release(ctx context.Context, id string, qty int) error {
+	if m.stockFunc != nil {
+		return m.stockFunc(ctx, id, qty)
+	}
+	return nil
+}
+
+// recordingPublisher implements EventPublisher and records every publish.
+// A non-nil err fails every publish after recording it.
+type recordingPublisher struct {
+	published []messaging.PublishOptions
+	payloads  [][]byte
+	err       error
+}
+
+func (p *recordingPublisher) Publish(_ context.Context, options messaging.PublishOptions, data []byte) error {
+	p.published = append(p.published, options)
+	p.payloads = append(p.payloads, data)
+	return p.err
+}
+
 func newMockLogger() logger.Logger {
 	return logger.New("info", false)
 }
 
+// fixedIDGenerator always returns the same ID so tests can assert on it
+type fixedIDGenerator struct {
+	id string
+}
+
+func (g fixedIDGenerator) NewID() string {
+	return g.id
+}
+
 func TestCreateProduct(t *testing.T) {
 	ctx := context.Background()
 	log := newMockLogger()
 
 	tests := []struct {
 		name        string
+		sku         string // testSKU when empty
 		productName string
 		description string
-		price       float64
+		price       string
+		currency    string
 		imageURL    string
 		repoErr     error
 		wantErr     bool
 		errContains string
 		wantErrType error // Sentinel error type to check with errors.Is
+		wantMinor   int64
+		wantCurr    string
 	}{
 		{
 			name:        "successful create",
 			productName: testProductName,
 			description: testDescription,
-			price:       99.99,
+			price:       "99.99",
 			imageURL:    testImageURL,
 			repoErr:     nil,
 			wantErr:     false,
+			wantMinor:   9999,
+			wantCurr:    domain.DefaultCurrency,
+		},
+		{
+			name:        "zero-decimal currency",
+			productName: testProductName,
+			description: testDescription,
+			price:       "1999",
+			currency:    "jpy",
+			wantMinor:   1999,
+			wantCurr:    "JPY",
+		},
+		{
+			name:        "more decimals than the currency allows",
+			productName: testProductName,
+			description: testDescription,
+			price:       "19.999",
+			wantErr:     true,
+			errContains: "decimal places",
+			wantErrType: ErrValidation,
+		},
+		{
+			name:        "unsupported currency",
+			productName: testProductName,
+			description: testDescription,
+			price:       "99.99",
+			currency:    "XYZ",
+			wantErr:     true,
+			errContains: "unsupported currency",
+			wantErrType: ErrValidation,
 		},
 		{
 			name:        "empty name",
 			productName: "",
 			description: testDescription,
-			price:       99.99,
+			price:       "99.99",
 			imageURL:    "",
 			wantErr:     true,
 			errContains: requiredMsg,
@@ -131,7 +315,7 @@ func TestCreateProduct(t *testing.T) {
 			name:        "name too long",
 			productName: strings.Repeat("a", 151),
 			description: testDescription,
-			price:       99.99,
+			price:       "99.99",
 			imageURL:    "",
 			wantErr:     true,
 			errContains: "150 characters",
@@ -141,7 +325,7 @@ func TestCreateProduct(t *testing.T) {
 			name:        "negative price",
 			productName: testProductName,
 			description: testDescription,
-			price:       -10.00,
+			price:       "-10.00",
 			imageURL:    "",
 			wantErr:     true,
 			errContains: "non-negative",
@@ -151,7 +335,7 @@ func TestCreateProduct(t *testing.T) {
 			name:        "invalid URL scheme",
 			productName: testProductName,
 			description: testDescription,
-			price:       99.99,
+			price:       "99.99",
 			imageURL:    "ftp://example.com/image.jpg",
 			wantErr:     true,
 			errContains: invalidImageURLMsg,
@@ -161,17 +345,65 @@ func TestCreateProduct(t *testing.T) {
 			name:        "invalid URL format",
 			productName: testProductName,
 			description: testDescription,
-			price:       99.99,
+			price:       "99.99",
 			imageURL:    notAURLValue,
 			wantErr:     true,
 			errContains: invalidImageURLMsg,
 			wantErrType: ErrValidation,
 		},
+		{
+			name:        "lowercase SKU is normalized",
+			sku:         " mug-001 ",
+			productName: testProductName,
+			description: testDescription,
+			price:       "99.99",
+			wantMinor:   9999,
+			wantCurr:    domain.DefaultCurrency,
+		},
+		{
+			name:        "blank SKU",
+			sku:         "   ",
+			productName: testProductName,
+			description: testDescription,
+			price:       "99.99",
+			wantErr:     true,
+			errContains: "SKU is required",
+			wantErrType: ErrValidation,
+		},
+		{
+			name:        "SKU with invalid characters",
+			sku:         "MUG_001",
+			productName: testProductName,
+			description: testDescription,
+			price:       "99.99",
+			wantErr:     true,
+			errContains: "single dashes",
+			wantErrType: ErrValidation,
+		},
+		{
+			name:        "SKU too long",
+			sku:         strings.Repeat("A", 65),
+			productName: testProductName,
+			description: testDescription,
+			price:       "99.99",
+			wantErr:     true,
+			errContains: "at most 64 characters",
+			wantErrType: ErrValidation,
+		},
+		{
+			name:        "duplicate SKU",
+			productName: testProductName,
+			description: testDescription,
+			price:       "99.99",
+			repoErr:     fmt.Errorf("%w: %w", repository.ErrDuplicateSKU, ErrConflict),
+			wantErr:     true,
+			wantErrType: repository.ErrDuplicateSKU,
+		},
 		{
 			name:        repositoryErrorName,
 			productName: testProductName,
 			description: testDescription,
-			price:       99.99,
+			price:       "99.99",
 			imageURL:    testImageURL,
 			repoErr:     errors.New("database error"),
 			wantErr:     true,
@@ -188,12 +420,13 @@ func TestCreateProduct(t *testing.T) {
 				},
 			}
 
-			svc := &ProductService{
-				repository: mockRepo,
-				logger:     log,
-			}
+			svc := NewService(mockRepo, log, nil, nil, WithIDGenerator(fixedIDGenerator{id: testID}))
 
-			product, err := svc.CreateProduct(ctx, tt.productName, tt.description, tt.price, tt.imageURL)
+			sku := testSKU
+			if tt.sku != "" {
+				sku = tt.sku
+			}
+			product, err := svc.CreateProduct(ctx, CreateProductInput{SKU: sku, Name: tt.productName, Description: tt.description, Price: tt.price, Currency: tt.currency, ImageURL: tt.imageURL})
 
 			if tt.wantErr {
 				if err == nil {
@@ -214,16 +447,36 @@ func TestCreateProduct(t *testing.T) {
 				return
 			}
 
+			if product.ID != testID {
+				t.Errorf("CreateProduct() id = %v, want %v", product.ID, testID)
+			}
+			if product.SKU != testSKU {
+				t.Errorf("CreateProduct() sku = %v, want %v", product.SKU, testSKU)
+			}
 			if product.Name != tt.productName {
 				t.Errorf("CreateProduct() name = %v, want %v", product.Name, tt.productName)
 			}
-			if product.Price != tt.price {
-				t.Errorf("CreateProduct() price = %v, want %v", product.Price, tt.price)
+			if product.PriceMinor != tt.wantMinor || product.Currency != tt.wantCurr {
+				t.Errorf("CreateProduct() price = %d %s, want %d %s", product.PriceMinor, product.Currency, tt.wantMinor, tt.wantCurr)
 			}
 		})
 	}
 }
 
+func TestCreateProductDefaultIDGenerator(t *testing.T) {
+	mockRepo := &mockRepository{}
+	svc := NewService(mockRepo, newMockLogger(), nil, nil)
+
+	product, err := svc.CreateProduct(context.Background(), CreateProductInput{SKU: testSKU, Name: testProductName, Description: testDescription, Price: "10"})
+	if err != nil {
+		t.Fatalf("CreateProduct() unexpected error = %v", err)
+	}
+
+	if _, err := uuid.Parse(product.ID); err != nil {
+		t.Errorf("CreateProduct() id = %q is not a UUID: %v", product.ID, err)
+	}
+}
+
 func TestCreateProductWithOutbox(t *testing.T) {
 	ctx := context.Background()
 	log := newMockLogger()
@@ -248,8 +501,8 @@ func TestCreateProductWithOutbox(t *testing.T) {
 			},
 		}
 
-		svc := NewService(mockRepo, log, mockOutbox, getDB)
-		product, err := svc.CreateProduct(ctx, "Outbox Product", "Desc", 49.99, "")
+		svc := NewService(mockRepo, log, mockOutbox, getDB, WithIDGenerator(fixedIDGenerator{id: testID}))
+		product, err := svc.CreateProduct(ctx, CreateProductInput{SKU: testSKU, Name: "Outbox Product", Description: "Desc", Price: "49.99"})
 		if err != nil {
 			t.Fatalf("CreateProduct() error = %v", err)
 		}
@@ -262,8 +515,8 @@ func TestCreateProductWithOutbox(t *testing.T) {
 		if len(events) != 1 {
 			t.Fatalf("expected 1 product.created event, got %d", len(events))
 		}
-		if events[0].Event.AggregateID != product.ID {
-			t.Errorf("AggregateID = %q, want %q", events[0].Event.AggregateID, product.ID)
+		if events[0].Event.AggregateID != testID {
+			t.Errorf("AggregateID = %q, want %q", events[0].Event.AggregateID, testID)
 		}
 	})
 
@@ -275,10 +528,60 @@ func TestCreateProductWithOutbox(t *testing.T) {
 		}
 
 		svc := NewService(mockRepo, log, nil, nil)
-		_, err := svc.CreateProduct(ctx, "No Outbox", "Desc", 10.00, "")
+		_, err := svc.CreateProduct(ctx, CreateProductInput{SKU: testSKU, Name: "No Outbox", Description: "Desc", Price: "10.00"})
+		if err != nil {
+			t.Fatalf("CreateProduct() error = %v", err)
+		}
+	})
+
+	t.Run("publishes directly when outbox is nil", func(t *testing.T) {
+		mockRepo := &mockRepository{
+			createFunc: func(ctx context.Context, product *domain.Product) error {
+				return nil
+			},
+		}
+		events := &recordingPublisher{}
+
+		svc := NewService(mockRepo, log, nil, nil, WithEventPublisher(events))
+		product, err := svc.CreateProduct(multitenant.SetTenant(ctx, "tenant-a"), CreateProductInput{SKU: testSKU, Name: "Direct Publish", Description: "Desc", Price: "10.00"})
 		if err != nil {
 			t.Fatalf("CreateProduct() error = %v", err)
 		}
+
+		if len(events.published) != 1 {
+			t.Fatalf("expected 1 published event, got %d", len(events.published))
+		}
+		if got := events.published[0]; got.Exchange != EventsExchange || got.RoutingKey != EventProductCreated {
+			t.Errorf("published to %q/%q, want %q/%q", got.Exchange, got.RoutingKey, EventsExchange, EventProductCreated)
+		}
+
+		var event ProductCreatedEvent
+		if err := json.Unmarshal(events.payloads[0], &event); err != nil {
+			t.Fatalf("unmarshal payload: %v", err)
+		}
+		want := ProductCreatedEvent{ID: product.ID, SKU: testSKU, Name: "Direct Publish", Price: "10.00", Currency: domain.DefaultCurrency, TenantID: "tenant-a"}
+		if event != want {
+			t.Errorf("payload = %+v, want %+v", event, want)
+		}
+	})
+
+	t.Run("publish failure does not fail the create", func(t *testing.T) {
+		var created bool
+		mockRepo := &mockRepository{
+			createFunc: func(ctx context.Context, product *domain.Product) error {
+				created = true
+				return nil
+			},
+		}
+		events := &recordingPublisher{err: errors.New("broker unavailable")}
+
+		svc := NewService(mockRepo, log, nil, nil, WithEventPublisher(events))
+		if _, err := svc.CreateProduct(ctx, CreateProductInput{SKU: testSKU, Name: "Direct Publish", Description: "Desc", Price: "10.00"}); err != nil {
+			t.Fatalf("CreateProduct() error = %v, want nil", err)
+		}
+		if !created || len(events.published) != 1 {
+			t.Errorf("created = %v, publishes = %d, want the product stored and one publish attempt", created, len(events.published))
+		}
 	})
 }
 
@@ -367,7 +670,7 @@ func TestGetProductByID(t *testing.T) {
 					if tt.repoError != nil {
 						return nil, tt.repoError
 					}
-					return domain.New(id, testProductName, "Description", 99.99, testImageURL), nil
+					return domain.New(id, testSKU, testProductName, "Description", 9999, domain.DefaultCurrency, testImageURL), nil
 				},
 			}
 
@@ -397,6 +700,82 @@ func TestGetProductByID(t *testing.T) {
 	}
 }
 
+func TestGetProductBySKU(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		sku       string
+		repoError error
+		wantErr   error
+	}{
+		{name: "successful get", sku: testSKU},
+		{name: "lowercase SKU", sku: "mug-001"},
+		{name: productNotFoundName, sku: testSKU, repoError: repository.ErrProductNotFound, wantErr: repository.ErrProductNotFound},
+		{name: repositoryErrorName, sku: testSKU, repoError: errors.New("database error"), wantErr: ErrInternal},
+		{name: "malformed SKU", sku: "MUG 001", wantErr: ErrValidation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotSKU string
+			mockRepo := &mockRepository{
+				getBySKUFunc: func(ctx context.Context, sku string) (*domain.Product, error) {
+					gotSKU = sku
+					if tt.repoError != nil {
+						return nil, tt.repoError
+					}
+					return domain.New(testID, sku, testProductName, testDescription, 9999, domain.DefaultCurrency, ""), nil
+				},
+			}
+			svc := NewService(mockRepo, newMockLogger(), nil, nil)
+
+			product, err := svc.GetProductBySKU(ctx, tt.sku)
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("GetProductBySKU() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetProductBySKU() unexpected error = %v", err)
+			}
+			if gotSKU != testSKU || product.SKU != testSKU {
+				t.Errorf("GetProductBySKU() looked up %q and got %q, want %q", gotSKU, product.SKU, testSKU)
+			}
+		})
+	}
+}
+
+func TestProductExists(t *testing.T) {
+	ctx := context.Background()
+
+	for _, want := range []bool{true, false} {
+		mockRepo := &mockRepository{
+			existsFunc: func(ctx context.Context, id string) (bool, error) {
+				return want, nil
+			},
+		}
+		svc := NewService(mockRepo, newMockLogger(), nil, nil)
+
+		got, err := svc.ProductExists(ctx, testID)
+		if err != nil || got != want {
+			t.Errorf("ProductExists() = %v, %v, want %v, nil", got, err, want)
+		}
+	}
+
+	mockRepo := &mockRepository{
+		existsFunc: func(ctx context.Context, id string) (bool, error) {
+			return false, errors.New("database error")
+		},
+	}
+	svc := NewService(mockRepo, newMockLogger(), nil, nil)
+	if _, err := svc.ProductExists(ctx, testID); !errors.Is(err, ErrInternal) {
+		t.Errorf("ProductExists() error = %v, want ErrInternal", err)
+	}
+}
+
 func TestListProducts(t *testing.T) {
 	ctx := context.Background()
 	log := newMockLogger()
@@ -489,7 +868,7 @@ func TestListProducts(t *testing.T) {
 					// Create mock products
 					products := make([]*domain.Product, tt.wantCount)
 					for i := 0; i < tt.wantCount; i++ {
-						products[i] = domain.New("id", "Product", "Description", 99.99, "")
+						products[i] = domain.New("id", testSKU, "Product", "Description", 9999, domain.DefaultCurrency, "")
 					}
 					return products, tt.wantTotal, nil
 				},
@@ -500,7 +879,7 @@ func TestListProducts(t *testing.T) {
 				logger:     log,
 			}
 
-			products, total, err := svc.ListProducts(ctx, tt.page, tt.pageSize)
+			products, total, err := svc.ListProducts(ctx, tt.page, tt.pageSize, ListOptions{})
 
 			if tt.wantErr {
 				if err == nil {
@@ -532,124 +911,676 @@ func TestListProducts(t *testing.T) {
 	}
 }
 
-func TestUpdateProduct(t *testing.T) {
+func TestListProductsSearch(t *testing.T) {
 	ctx := context.Background()
 	log := newMockLogger()
 
-	name := "Updated Product"
-	price := 149.99
-	invalidURL := notAURLValue
-
 	tests := []struct {
-		name        string
-		id          string
-		updateName  *string
-		updatePrice *float64
-		updateURL   *string
-		updateErr   error
-		getByIDErr  error
-		wantErr     bool
-		errContains string
-		wantErrType error // Sentinel error type to check with errors.Is
+		name       string
+		search     string
+		wantSearch string
+		wantErr    bool
 	}{
-		{
-			name:        "successful update name and price",
-			id:          testID,
-			updateName:  &name,
-			updatePrice: &price,
-			updateErr:   nil,
-			getByIDErr:  nil,
-			wantErr:     false,
-		},
-		{
-			name:        "no fields to update",
-			id:          testID,
-			wantErr:     true,
-			errContains: "no fields to update",
-			wantErrType: ErrValidation,
-		},
-		{
-			name:        productNotFoundName,
-			id:          missingID,
-			updateName:  &name,
-			updateErr:   repository.ErrProductNotFound,
-			wantErr:     true,
-			wantErrType: repository.ErrProductNotFound,
-		},
-		{
-			name:        "invalid URL",
-			id:          testID,
-			updateURL:   &invalidURL,
-			wantErr:     true,
-			errContains: invalidImageURLMsg,
-			wantErrType: ErrValidation,
-		},
-		{
-			name:        "repository error on fetch",
-			id:          testID,
-			updateName:  &name,
-			updateErr:   nil,
-			getByIDErr:  errors.New("database error"),
-			wantErr:     true,
-			wantErrType: ErrInternal,
-		},
+		{name: "empty search", search: "", wantSearch: ""},
+		{name: "whitespace only", search: "   ", wantSearch: ""},
+		{name: "trimmed", search: "  blue mug ", wantSearch: "blue mug"},
+		{name: "at length limit", search: strings.Repeat("é", maxSearchLength), wantSearch: strings.Repeat("é", maxSearchLength)},
+		{name: "too long", search: strings.Repeat("a", maxSearchLength+1), wantErr: true},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			repoCalled := false
 			mockRepo := &mockRepository{
-				updateFunc: func(ctx context.Context, id string, updates map[string]any) error {
-					return tt.updateErr
-				},
-				getByIDFunc: func(ctx context.Context, id string) (*domain.Product, error) {
-					if tt.getByIDErr != nil {
-						return nil, tt.getByIDErr
-					}
-					return domain.New(id, "Updated Product", "Description", 149.99, testImageURL), nil
+				listFunc: func(ctx context.Context, limit, offset int) ([]*domain.Product, int, error) {
+					repoCalled = true
+					return []*domain.Product{}, 0, nil
 				},
 			}
+			svc := NewService(mockRepo, log, nil, nil)
 
-			svc := &ProductService{
-				repository: mockRepo,
-				logger:     log,
-			}
-
-			product, err := svc.UpdateProduct(ctx, tt.id, tt.updateName, nil, tt.updatePrice, tt.updateURL)
+			_, _, err := svc.ListProducts(ctx, 1, 10, ListOptions{Search: tt.search})
 
 			if tt.wantErr {
-				if err == nil {
-					t.Errorf("UpdateProduct() error = nil, wantErr %v", tt.wantErr)
-					return
-				}
-				if tt.errContains != "" && !strings.Contains(err.Error(), tt.errContains) {
-					t.Errorf("UpdateProduct() error = %v, want error containing %v", err, tt.errContains)
+				if !errors.Is(err, ErrValidation) {
+					t.Errorf("ListProducts() error = %v, want ErrValidation", err)
 				}
-				if tt.wantErrType != nil && !errors.Is(err, tt.wantErrType) {
-					t.Errorf("UpdateProduct() error type = %T, want errors.Is(%v) = true", err, tt.wantErrType)
+				if repoCalled {
+					t.Error("ListProducts() queried the repository with an over-long search")
 				}
 				return
 			}
-
 			if err != nil {
-				t.Errorf("UpdateProduct() unexpected error = %v", err)
-				return
+				t.Fatalf("ListProducts() unexpected error = %v", err)
 			}
-
-			if product == nil {
-				t.Errorf("UpdateProduct() returned nil product")
+			if mockRepo.listFilter.Search != tt.wantSearch {
+				t.Errorf("ListProducts() repository search = %q, want %q", mockRepo.listFilter.Search, tt.wantSearch)
 			}
 		})
 	}
 }
 
-func TestDeleteProduct(t *testing.T) {
+func TestListProductsSort(t *testing.T) {
 	ctx := context.Background()
 	log := newMockLogger()
 
 	tests := []struct {
-		name        string
-		id          string
-		repoErr     error
+		name      string
+		sortBy    string
+		sortOrder string
+		want      repository.Sort
+		wantErr   bool
+	}{
+		{name: "default newest first", want: repository.DefaultSort},
+		{name: "name defaults to ascending", sortBy: "name", want: repository.Sort{Field: repository.SortByName}},
+		{name: "price descending", sortBy: "price", sortOrder: "desc", want: repository.Sort{Field: repository.SortByPrice, Descending: true}},
+		{name: "created date ascending", sortBy: "createdDate", sortOrder: "ASC", want: repository.Sort{Field: repository.SortByCreatedDate}},
+		{name: "order only", sortOrder: "asc", want: repository.Sort{Field: repository.SortByCreatedDate}},
+		{name: "column name is not a sort field", sortBy: "created_date", wantErr: true},
+		{name: "injection attempt", sortBy: "name; DROP TABLE products", wantErr: true},
+		{name: "invalid order", sortBy: "name", sortOrder: "sideways", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repoCalled := false
+			mockRepo := &mockRepository{
+				listFunc: func(ctx context.Context, limit, offset int) ([]*domain.Product, int, error) {
+					repoCalled = true
+					return []*domain.Product{}, 0, nil
+				},
+			}
+			svc := NewService(mockRepo, log, nil, nil)
+
+			_, _, err := svc.ListProducts(ctx, 1, 10, ListOptions{SortBy: tt.sortBy, SortOrder: tt.sortOrder})
+
+			if tt.wantErr {
+				if !errors.Is(err, ErrValidation) {
+					t.Errorf("ListProducts() error = %v, want ErrValidation", err)
+				}
+				if repoCalled {
+					t.Error("ListProducts() queried the repository with an invalid sort")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ListProducts() unexpected error = %v", err)
+			}
+			if mockRepo.listSort != tt.want {
+				t.Errorf("ListProducts() sort = %+v, want %+v", mockRepo.listSort, tt.want)
+			}
+		})
+	}
+}
+
+func TestListProductsPriceRange(t *testing.T) {
+	ctx := context.Background()
+	log := newMockLogger()
+	minor := func(v int64) *int64 { return &v }
+
+	tests := []struct {
+		name         string
+		currency     string
+		minPrice     string
+		maxPrice     string
+		wantCurrency string
+		wantMin      *int64
+		wantMax      *int64
+		wantErr      bool
+	}{
+		{name: "no bounds"},
+		{name: "min only", minPrice: "5", wantCurrency: domain.DefaultCurrency, wantMin: minor(500)},
+		{name: "max only", maxPrice: "20", wantCurrency: domain.DefaultCurrency, wantMax: minor(2000)},
+		{name: "both bounds", minPrice: "5", maxPrice: "20", wantCurrency: domain.DefaultCurrency, wantMin: minor(500), wantMax: minor(2000)},
+		{name: "equal bounds", minPrice: "10", maxPrice: "10", wantCurrency: domain.DefaultCurrency, wantMin: minor(1000), wantMax: minor(1000)},
+		{name: "zero min", minPrice: "0", wantCurrency: domain.DefaultCurrency, wantMin: minor(0)},
+		{name: "fractional bound", minPrice: "19.99", wantCurrency: domain.DefaultCurrency, wantMin: minor(1999)},
+		{name: "currency only", currency: "eur", wantCurrency: "EUR"},
+		{name: "zero-decimal currency", currency: "JPY", maxPrice: "1500", wantCurrency: "JPY", wantMax: minor(1500)},
+		{name: "unsupported currency", currency: "XYZ", wantErr: true},
+		{name: "negative min", minPrice: "-1", wantErr: true},
+		{name: "negative max", maxPrice: "-0.01", wantErr: true},
+		{name: "min above max", minPrice: "20", maxPrice: "5", wantErr: true},
+		{name: "not a number", minPrice: "cheap", wantErr: true},
+		{name: "exponent", maxPrice: "1e3", wantErr: true},
+		{name: "too many decimals", minPrice: "19.999", wantErr: true},
+		{name: "decimals in zero-decimal currency", currency: "JPY", minPrice: "1500.5", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repoCalled := false
+			mockRepo := &mockRepository{
+				listFunc: func(ctx context.Context, limit, offset int) ([]*domain.Product, int, error) {
+					repoCalled = true
+					return []*domain.Product{}, 0, nil
+				},
+			}
+			svc := NewService(mockRepo, log, nil, nil)
+
+			_, _, err := svc.ListProducts(ctx, 1, 10, ListOptions{Currency: tt.currency, MinPrice: tt.minPrice, MaxPrice: tt.maxPrice})
+
+			if tt.wantErr {
+				if !errors.Is(err, ErrValidation) {
+					t.Errorf("ListProducts() error = %v, want ErrValidation", err)
+				}
+				if repoCalled {
+					t.Error("ListProducts() queried the repository with an invalid price range")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ListProducts() unexpected error = %v", err)
+			}
+			got := mockRepo.listFilter
+			if got.Currency != tt.wantCurrency || !equalMinor(got.MinPrice, tt.wantMin) || !equalMinor(got.MaxPrice, tt.wantMax) {
+				t.Errorf("ListProducts() repository filter = %s (%v, %v), want %s (%v, %v)",
+					got.Currency, got.MinPrice, got.MaxPrice, tt.wantCurrency, tt.wantMin, tt.wantMax)
+			}
+		})
+	}
+}
+
+// equalMinor reports whether two optional minor-unit amounts are both unset or equal.
+func equalMinor(a, b *int64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func TestListProductsMaxOffset(t *testing.T) {
+	ctx := context.Background()
+	log := newMockLogger()
+
+	tests := []struct {
+		name      string
+		maxOffset int
+		page      int
+		pageSize  int
+		wantErr   bool
+	}{
+		{name: "unlimited by default", maxOffset: 0, page: 1_000_000, pageSize: 100},
+		{name: "within limit", maxOffset: 1000, page: 10, pageSize: 100},
+		{name: "at limit", maxOffset: 1000, page: 11, pageSize: 100},
+		{name: "beyond limit", maxOffset: 1000, page: 12, pageSize: 100, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repoCalled := false
+			mockRepo := &mockRepository{
+				listFunc: func(ctx context.Context, limit, offset int) ([]*domain.Product, int, error) {
+					repoCalled = true
+					return []*domain.Product{}, 0, nil
+				},
+			}
+
+			svc := NewService(mockRepo, log, nil, nil, WithMaxListOffset(tt.maxOffset))
+			_, _, err := svc.ListProducts(ctx, tt.page, tt.pageSize, ListOptions{})
+
+			if !tt.wantErr {
+				if err != nil {
+					t.Errorf("ListProducts() unexpected error = %v", err)
+				}
+				return
+			}
+
+			if !errors.Is(err, ErrValidation) {
+				t.Errorf("ListProducts() error = %v, want ErrValidation", err)
+			}
+			if err != nil && !strings.Contains(err.Error(), "cursor pagination") {
+				t.Errorf("ListProducts() error = %v, want cursor pagination suggestion", err)
+			}
+			if repoCalled {
+				t.Error("ListProducts() queried the repository for a page beyond the limit")
+			}
+		})
+	}
+}
+
+func TestListProductsAfter(t *testing.T) {
+	ctx := context.Background()
+	log := newMockLogger()
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	// Three products, newest first, as the repository returns them.
+	catalog := make([]*domain.Product, 3)
+	for i := range catalog {
+		p := domain.New(fmt.Sprintf("id-%d", 3-i), testSKU, testProductName, testDescription, 1000, domain.DefaultCurrency, "")
+		p.CreatedDate = base.Add(-time.Duration(i) * time.Minute)
+		catalog[i] = p
+	}
+
+	var seen []*repository.Cursor
+	mockRepo := &mockRepository{
+		listAfterFunc: func(ctx context.Context, after *repository.Cursor, limit int) ([]*domain.Product, error) {
+			seen = append(seen, after)
+			start := 0
+			if after != nil {
+				for i, p := range catalog {
+					if p.ID == after.ID && p.CreatedDate.Equal(after.CreatedDate) {
+						start = i + 1
+					}
+				}
+			}
+			end := min(start+limit, len(catalog))
+			return catalog[start:end], nil
+		},
+	}
+	svc := NewService(mockRepo, log, nil, nil)
+
+	first, next, err := svc.ListProductsAfter(ctx, "", 2)
+	if err != nil {
+		t.Fatalf("ListProductsAfter() first page error = %v", err)
+	}
+	if len(first) != 2 || first[0].ID != "id-3" || first[1].ID != "id-2" {
+		t.Fatalf("ListProductsAfter() first page = %v, want [id-3 id-2]", productIDs(first))
+	}
+	if next == "" {
+		t.Fatal("ListProductsAfter() first page nextCursor is empty, want a cursor")
+	}
+	if seen[0] != nil {
+		t.Errorf("ListProductsAfter() empty cursor passed %+v, want nil", seen[0])
+	}
+
+	second, next, err := svc.ListProductsAfter(ctx, next, 2)
+	if err != nil {
+		t.Fatalf("ListProductsAfter() second page error = %v", err)
+	}
+	if len(second) != 1 || second[0].ID != "id-1" {
+		t.Errorf("ListProductsAfter() second page = %v, want [id-1]", productIDs(second))
+	}
+	if next != "" {
+		t.Errorf("ListProductsAfter() last page nextCursor = %q, want empty", next)
+	}
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		for _, cursor := range []string{"not base64!", "bm90IGpzb24", "e30"} {
+			_, _, err := svc.ListProductsAfter(ctx, cursor, 2)
+			if !errors.Is(err, ErrValidation) {
+				t.Errorf("ListProductsAfter(%q) error = %v, want ErrValidation", cursor, err)
+			}
+		}
+	})
+
+	t.Run("invalid page size", func(t *testing.T) {
+		_, _, err := svc.ListProductsAfter(ctx, "", 0)
+		if !errors.Is(err, ErrValidation) {
+			t.Errorf("ListProductsAfter() error = %v, want ErrValidation", err)
+		}
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		failing := NewService(&mockRepository{
+			listAfterFunc: func(context.Context, *repository.Cursor, int) ([]*domain.Product, error) {
+				return nil, errors.New("database error")
+			},
+		}, log, nil, nil)
+
+		_, _, err := failing.ListProductsAfter(ctx, "", 2)
+		if !errors.Is(err, ErrInternal) {
+			t.Errorf("ListProductsAfter() error = %v, want ErrInternal", err)
+		}
+	})
+}
+
+func productIDs(products []*domain.Product) []string {
+	ids := make([]string, len(products))
+	for i, p := range products {
+		ids[i] = p.ID
+	}
+	return ids
+}
+
+func TestZeroPrice(t *testing.T) {
+	ctx := context.Background()
+	log := newMockLogger()
+	zero := "0"
+
+	tests := []struct {
+		name    string
+		opts    []Option
+		wantErr bool
+	}{
+		{name: "allowed by default"},
+		{name: "allowed explicitly", opts: []Option{WithAllowZeroPrice(true)}},
+		{name: "forbidden", opts: []Option{WithAllowZeroPrice(false)}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &mockRepository{
+				getByIDFunc: func(ctx context.Context, id string) (*domain.Product, error) {
+					return domain.New(id, testSKU, testProductName, testDescription, 0, domain.DefaultCurrency, ""), nil
+				},
+				compareFunc: func(ctx context.Context, id string, updates map[string]any) (*domain.Product, *domain.Product, error) {
+					before := domain.New(id, testSKU, testProductName, testDescription, 1000, domain.DefaultCurrency, "")
+					after := domain.New(id, testSKU, testProductName, testDescription, 0, domain.DefaultCurrency, "")
+					return before, after, nil
+				},
+			}
+			svc := NewService(mockRepo, log, nil, nil, tt.opts...)
+
+			_, createErr := svc.CreateProduct(ctx, CreateProductInput{SKU: testSKU, Name: testProductName, Description: testDescription, Price: "0"})
+			_, _, updateErr := svc.UpdateProduct(ctx, "test-id", nil, domain.OptionalString{}, &zero, domain.OptionalString{}, domain.OptionalString{}, nil)
+
+			for op, err := range map[string]error{"CreateProduct": createErr, "UpdateProduct": updateErr} {
+				if (err != nil) != tt.wantErr {
+					t.Errorf("%s() error = %v, wantErr %v", op, err, tt.wantErr)
+					continue
+				}
+				if tt.wantErr && !errors.Is(err, ErrValidation) {
+					t.Errorf("%s() error = %v, want ErrValidation", op, err)
+				}
+			}
+		})
+	}
+
+	t.Run("negative price rejected regardless", func(t *testing.T) {
+		svc := NewService(&mockRepository{}, log, nil, nil, WithAllowZeroPrice(true))
+
+		_, err := svc.CreateProduct(ctx, CreateProductInput{SKU: testSKU, Name: testProductName, Description: testDescription, Price: "-1"})
+
+		if !errors.Is(err, ErrValidation) {
+			t.Errorf("CreateProduct() error = %v, want ErrValidation", err)
+		}
+	})
+}
+
+func TestUpdateProduct(t *testing.T) {
+	ctx := context.Background()
+	log := newMockLogger()
+
+	name := "Updated Product"
+	price := "149.99"
+	tooPrecise := "149.999"
+	invalidURL := domain.SomeString(notAURLValue)
+	version := 2
+
+	tests := []struct {
+		name        string
+		id          string
+		updateName  *string
+		updatePrice *string
+		updateURL   domain.OptionalString
+		version     *int
+		updateErr   error
+		wantErr     bool
+		errContains string
+		wantErrType error // Sentinel error type to check with errors.Is
+	}{
+		{
+			name:        "successful update name and price",
+			id:          testID,
+			updateName:  &name,
+			updatePrice: &price,
+			wantErr:     false,
+		},
+		{
+			name:        "no fields to update",
+			id:          testID,
+			wantErr:     true,
+			errContains: "no fields to update",
+			wantErrType: ErrValidation,
+		},
+		{
+			name:        productNotFoundName,
+			id:          missingID,
+			updateName:  &name,
+			updateErr:   repository.ErrProductNotFound,
+			wantErr:     true,
+			wantErrType: repository.ErrProductNotFound,
+		},
+		{
+			name:        "stale version",
+			id:          testID,
+			updateName:  &name,
+			version:     &version,
+			updateErr:   repository.ErrConcurrentModification,
+			wantErr:     true,
+			wantErrType: repository.ErrConcurrentModification,
+		},
+		{
+			name:        "locked product",
+			id:          testID,
+			updateName:  &name,
+			updateErr:   repository.ErrProductLocked,
+			wantErr:     true,
+			wantErrType: ErrLocked,
+		},
+		{
+			name:        "unique constraint violated",
+			id:          testID,
+			updateName:  &name,
+			updateErr:   fmt.Errorf("failed to update product: %w", ErrConflict),
+			wantErr:     true,
+			wantErrType: ErrConflict,
+		},
+		{
+			name:        "price finer than the currency",
+			id:          testID,
+			updatePrice: &tooPrecise,
+			wantErr:     true,
+			errContains: "decimal places",
+			wantErrType: ErrValidation,
+		},
+		{
+			name:        "invalid URL",
+			id:          testID,
+			updateURL:   invalidURL,
+			wantErr:     true,
+			errContains: invalidImageURLMsg,
+			wantErrType: ErrValidation,
+		},
+		{
+			name:        "repository error",
+			id:          testID,
+			updateName:  &name,
+			updateErr:   errors.New("database error"),
+			wantErr:     true,
+			wantErrType: ErrInternal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &mockRepository{
+				getByIDFunc: func(ctx context.Context, id string) (*domain.Product, error) {
+					return domain.New(id, testSKU, testProductName, testDescription, 9999, domain.DefaultCurrency, testImageURL), nil
+				},
+				fetchFunc: func(ctx context.Context, id string, updates map[string]any) (*domain.Product, error) {
+					if tt.version != nil && updates["version"] != *tt.version {
+						t.Errorf("UpdateProduct() updates[version] = %v, want %d", updates["version"], *tt.version)
+					}
+					if tt.updateErr != nil {
+						return nil, tt.updateErr
+					}
+					return domain.New(id, testSKU, "Updated Product", "Description", 14999, domain.DefaultCurrency, testImageURL), nil
+				},
+			}
+
+			svc := &ProductService{
+				repository: mockRepo,
+				logger:     log,
+			}
+
+			product, _, err := svc.UpdateProduct(ctx, tt.id, tt.updateName, domain.OptionalString{}, tt.updatePrice, tt.updateURL, domain.OptionalString{}, tt.version)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("UpdateProduct() error = nil, wantErr %v", tt.wantErr)
+					return
+				}
+				if tt.errContains != "" && !strings.Contains(err.Error(), tt.errContains) {
+					t.Errorf("UpdateProduct() error = %v, want error containing %v", err, tt.errContains)
+				}
+				if tt.wantErrType != nil && !errors.Is(err, tt.wantErrType) {
+					t.Errorf("UpdateProduct() error type = %T, want errors.Is(%v) = true", err, tt.wantErrType)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("UpdateProduct() unexpected error = %v", err)
+				return
+			}
+
+			if product == nil {
+				t.Errorf("UpdateProduct() returned nil product")
+			}
+		})
+	}
+}
+
+func TestUpdateProductImageURL(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		imageURL  domain.OptionalString
+		wantSet   bool
+		wantValue string
+	}{
+		{name: "absent leaves the image unchanged", imageURL: domain.OptionalString{}},
+		{name: "empty string clears the image", imageURL: domain.SomeString(""), wantSet: true},
+		{name: "null clears the image", imageURL: domain.NullString(), wantSet: true},
+		{name: "URL replaces the image", imageURL: domain.SomeString(testImageURL), wantSet: true, wantValue: testImageURL},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got map[string]any
+			mockRepo := &mockRepository{
+				fetchFunc: func(_ context.Context, id string, updates map[string]any) (*domain.Product, error) {
+					got = updates
+					return domain.New(id, testSKU, testProductName, testDescription, 1000, domain.DefaultCurrency, ""), nil
+				},
+			}
+			svc := NewService(mockRepo, newMockLogger(), nil, nil)
+
+			// A name keeps the update non-empty when the image URL is absent.
+			name := testProductName
+			if _, _, err := svc.UpdateProduct(ctx, testID, &name, domain.OptionalString{}, nil, tt.imageURL, domain.OptionalString{}, nil); err != nil {
+				t.Fatalf("UpdateProduct() unexpected error = %v", err)
+			}
+
+			value, set := got["imageURL"]
+			if set != tt.wantSet {
+				t.Fatalf("UpdateProduct() updates imageURL = %v, want set %v", set, tt.wantSet)
+			}
+			if set && value != tt.wantValue {
+				t.Errorf("UpdateProduct() updates[imageURL] = %q, want %q", value, tt.wantValue)
+			}
+		})
+	}
+}
+
+func TestUpdateProductChanges(t *testing.T) {
+	ctx := context.Background()
+	newName := "Renamed Product"
+	newPrice := "25.5"
+
+	tests := []struct {
+		name        string
+		updateName  *string
+		description domain.OptionalString
+		price       *string
+		imageURL    domain.OptionalString
+		want        []domain.FieldChange
+	}{
+		{
+			name:       "name only",
+			updateName: &newName,
+			want:       []domain.FieldChange{{Field: "name", Before: testProductName, After: newName}},
+		},
+		{
+			name:        "unchanged value is not reported",
+			updateName:  &newName,
+			description: domain.SomeString(testDescription),
+			want:        []domain.FieldChange{{Field: "name", Before: testProductName, After: newName}},
+		},
+		{
+			name:     "price and cleared image",
+			price:    &newPrice,
+			imageURL: domain.NullString(),
+			want: []domain.FieldChange{
+				{Field: "price", Before: int64(1000), After: int64(2550)},
+				{Field: "imageURL", Before: testImageURL, After: ""},
+			},
+		},
+		{
+			name:        "cleared description",
+			description: domain.NullString(),
+			want:        []domain.FieldChange{{Field: "description", Before: testDescription, After: ""}},
+		},
+		{
+			name:        "nothing changed",
+			description: domain.SomeString(testDescription),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := domain.New(testID, testSKU, testProductName, testDescription, 1000, domain.DefaultCurrency, testImageURL)
+			mockRepo := &mockRepository{
+				getByIDFunc: func(context.Context, string) (*domain.Product, error) {
+					return copyProduct(before), nil
+				},
+				fetchFunc: func(_ context.Context, _ string, updates map[string]any) (*domain.Product, error) {
+					after := copyProduct(before)
+					if v, ok := updates["name"].(string); ok {
+						after.Name = v
+					}
+					if v, ok := updates["description"].(string); ok {
+						after.Description = v
+					}
+					if v, ok := updates["price"].(int64); ok {
+						after.PriceMinor = v
+					}
+					if v, ok := updates["imageURL"].(string); ok {
+						after.ImageURL = v
+					}
+					after.Version++
+					return after, nil
+				},
+			}
+			events := &recordingPublisher{}
+			svc := NewService(mockRepo, newMockLogger(), nil, nil, WithEventPublisher(events))
+
+			_, changes, err := svc.UpdateProduct(ctx, testID, tt.updateName, tt.description, tt.price, tt.imageURL, domain.OptionalString{}, nil)
+			if err != nil {
+				t.Fatalf("UpdateProduct() unexpected error = %v", err)
+			}
+			if !reflect.DeepEqual(changes, tt.want) {
+				t.Errorf("UpdateProduct() changes = %+v, want %+v", changes, tt.want)
+			}
+
+			if len(events.payloads) != 1 {
+				t.Fatalf("expected 1 published event, got %d", len(events.payloads))
+			}
+			var event struct {
+				ID      string            `json:"id"`
+				Changes []json.RawMessage `json:"changes"`
+			}
+			if err := json.Unmarshal(events.payloads[0], &event); err != nil {
+				t.Fatalf("unmarshal payload: %v", err)
+			}
+			if event.ID != testID || len(event.Changes) != len(tt.want) {
+				t.Errorf("payload = %s, want product %s with %d changes", events.payloads[0], testID, len(tt.want))
+			}
+		})
+	}
+}
+
+func TestDeleteProduct(t *testing.T) {
+	ctx := context.Background()
+	log := newMockLogger()
+
+	tests := []struct {
+		name        string
+		id          string
+		repoErr     error
 		wantErr     bool
 		wantErrType error // Sentinel error type to check with errors.Is
 	}{
@@ -666,6 +1597,13 @@ func TestDeleteProduct(t *testing.T) {
 			wantErr:     true,
 			wantErrType: repository.ErrProductNotFound,
 		},
+		{
+			name:        "locked product",
+			id:          testID,
+			repoErr:     repository.ErrProductLocked,
+			wantErr:     true,
+			wantErrType: ErrLocked,
+		},
 		{
 			name:        repositoryErrorName,
 			id:          testID,
@@ -707,6 +1645,158 @@ func TestDeleteProduct(t *testing.T) {
 	}
 }
 
+func TestLockProduct(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name       string
+		lock       bool
+		repoErr    error
+		wantErr    error
+		wantLocked bool
+	}{
+		{name: "lock", lock: true, wantLocked: true},
+		{name: "unlock", wantLocked: false},
+		{name: productNotFoundName, lock: true, repoErr: repository.ErrProductNotFound, wantErr: repository.ErrProductNotFound, wantLocked: true},
+		{name: repositoryErrorName, repoErr: errors.New("database error"), wantErr: ErrInternal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotLocked *bool
+			mockRepo := &mockRepository{
+				lockFunc: func(_ context.Context, _ string, locked bool) error {
+					gotLocked = &locked
+					return tt.repoErr
+				},
+			}
+			svc := &ProductService{repository: mockRepo, logger: newMockLogger()}
+
+			var err error
+			if tt.lock {
+				err = svc.LockProduct(ctx, testID)
+			} else {
+				err = svc.UnlockProduct(ctx, testID)
+			}
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if gotLocked == nil || *gotLocked != tt.wantLocked {
+				t.Errorf("SetLocked() locked = %v, want %v", gotLocked, tt.wantLocked)
+			}
+		})
+	}
+}
+
+func TestChangeStock(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		release   bool
+		qty       int
+		repoErr   error
+		wantErr   error
+		wantDelta int
+	}{
+		{name: "reserve", qty: 2, wantDelta: -2},
+		{name: "release", release: true, qty: 2, wantDelta: 2},
+		{name: "insufficient stock", qty: 5, repoErr: repository.ErrInsufficientStock, wantErr: ErrInsufficientStock, wantDelta: -5},
+		{name: productNotFoundName, release: true, qty: 1, repoErr: repository.ErrProductNotFound, wantErr: repository.ErrProductNotFound, wantDelta: 1},
+		{name: repositoryErrorName, qty: 1, repoErr: errors.New("database error"), wantErr: ErrInternal, wantDelta: -1},
+		{name: "zero quantity", qty: 0, wantErr: ErrValidation},
+		{name: "negative quantity", release: true, qty: -1, wantErr: ErrValidation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotDelta int
+			mockRepo := &mockRepository{
+				stockFunc: func(_ context.Context, _ string, delta int) error {
+					gotDelta = delta
+					return tt.repoErr
+				},
+			}
+			svc := &ProductService{repository: mockRepo, logger: newMockLogger()}
+
+			var err error
+			if tt.release {
+				err = svc.ReleaseStock(ctx, testID, tt.qty)
+			} else {
+				err = svc.ReserveStock(ctx, testID, tt.qty)
+			}
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if gotDelta != tt.wantDelta {
+				t.Errorf("repository stock change = %d, want %d", gotDelta, tt.wantDelta)
+			}
+		})
+	}
+}
+
+func TestCreateProductStockQuantity(t *testing.T) {
+	ctx := context.Background()
+	svc := NewService(&mockRepository{}, newMockLogger(), nil, nil)
+
+	product, err := svc.CreateProduct(ctx, CreateProductInput{SKU: testSKU, Name: testProductName, Description: testDescription, Price: "10", StockQuantity: 25})
+	if err != nil {
+		t.Fatalf("CreateProduct() unexpected error = %v", err)
+	}
+	if product.StockQuantity != 25 {
+		t.Errorf("CreateProduct() stockQuantity = %d, want 25", product.StockQuantity)
+	}
+
+	if _, err := svc.CreateProduct(ctx, CreateProductInput{SKU: testSKU, Name: testProductName, Description: testDescription, Price: "10", StockQuantity: -1}); !errors.Is(err, ErrValidation) {
+		t.Errorf("CreateProduct() error = %v, want ErrValidation for negative stock", err)
+	}
+}
+
+func TestImageURLSchemes(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name        string
+		schemes     []string
+		imageURL    string
+		wantErr     bool
+		errContains string
+	}{
+		{name: "default accepts http", imageURL: "http://example.com/a.png"},
+		{name: "default rejects s3", imageURL: "s3://bucket/a.png", wantErr: true, errContains: httpOrHTTPSMsg},
+		{name: "https only accepts https", schemes: []string{"https"}, imageURL: testImageURL},
+		{name: "https only rejects http", schemes: []string{"https"}, imageURL: "http://example.com/a.png", wantErr: true, errContains: "use https scheme"},
+		{name: "extended accepts custom scheme", schemes: []string{"http", "HTTPS", "s3"}, imageURL: "s3://bucket/a.png"},
+		{name: "extended is case-insensitive", schemes: []string{"https", "s3"}, imageURL: "S3://bucket/a.png"},
+		{name: "extended rejects unlisted scheme", schemes: []string{"https", "s3"}, imageURL: "ftp://example.com/a.png", wantErr: true, errContains: "https or s3"},
+		{name: "empty list keeps default", schemes: []string{" "}, imageURL: "http://example.com/a.png"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &mockRepository{}
+			svc := NewService(mockRepo, newMockLogger(), nil, nil, WithImageURLSchemes(tt.schemes...))
+
+			_, err := svc.CreateProduct(ctx, CreateProductInput{SKU: testSKU, Name: testProductName, Description: testDescription, Price: "10", ImageURL: tt.imageURL})
+
+			if !tt.wantErr {
+				if err != nil {
+					t.Errorf("CreateProduct() unexpected error = %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrValidation) {
+				t.Fatalf("CreateProduct() error = %v, want ErrValidation", err)
+			}
+			if !strings.Contains(err.Error(), tt.errContains) {
+				t.Errorf("CreateProduct() error = %v, want error containing %q", err, tt.errContains)
+			}
+		})
+	}
+}
+
 func TestValidateName(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -753,6 +1843,9 @@ func TestValidateName(t *testing.T) {
 					t.Errorf("validateName() error = nil, wantErr %v", tt.wantErr)
 					return
 				}
+				if !errors.Is(err, ErrValidation) {
+					t.Errorf("validateName() error = %v, want it to wrap ErrValidation", err)
+				}
 				if tt.errContains != "" && !strings.Contains(err.Error(), tt.errContains) {
 					t.Errorf("validateName() error = %v, want error containing %v", err, tt.errContains)
 				}
@@ -766,10 +1859,299 @@ func TestValidateName(t *testing.T) {
 	}
 }
 
+func TestValidateDescription(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxLength   int
+		description string
+		wantErr     bool
+	}{
+		{
+			name:        "empty description",
+			maxLength:   DefaultMaxDescriptionLength,
+			description: "",
+		},
+		{
+			name:        "description exactly at the limit",
+			maxLength:   DefaultMaxDescriptionLength,
+			description: strings.Repeat("a", DefaultMaxDescriptionLength),
+		},
+		{
+			name:        "description too long",
+			maxLength:   DefaultMaxDescriptionLength,
+			description: strings.Repeat("a", DefaultMaxDescriptionLength+1),
+			wantErr:     true,
+		},
+		{
+			name:        "multi-byte characters count once",
+			maxLength:   DefaultMaxDescriptionLength,
+			description: strings.Repeat("é", DefaultMaxDescriptionLength),
+		},
+		{
+			name:        "custom limit",
+			maxLength:   10,
+			description: strings.Repeat("a", 11),
+			wantErr:     true,
+		},
+		{
+			name:        "zero is unlimited",
+			maxLength:   0,
+			description: strings.Repeat("a", DefaultMaxDescriptionLength+1),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewService(&mockRepository{}, newMockLogger(), nil, nil, WithMaxDescriptionLength(tt.maxLength))
+			err := svc.validateDescription(tt.description)
+
+			if tt.wantErr {
+				if !errors.Is(err, ErrValidation) {
+					t.Errorf("validateDescription() error = %v, want it to wrap ErrValidation", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("validateDescription() unexpected error = %v", err)
+			}
+		})
+	}
+}
+
+func TestCreateAndUpdateRejectLongDescription(t *testing.T) {
+	ctx := context.Background()
+	svc := NewService(&mockRepository{}, newMockLogger(), nil, nil, WithMaxDescriptionLength(10))
+	description := strings.Repeat("a", 11)
+
+	if _, err := svc.CreateProduct(ctx, CreateProductInput{SKU: testSKU, Name: testProductName, Description: description, Price: "10"}); !errors.Is(err, ErrValidation) {
+		t.Errorf("CreateProduct() error = %v, want ErrValidation", err)
+	}
+	if _, _, err := svc.UpdateProduct(ctx, testID, nil, domain.SomeString(description), nil, domain.OptionalString{}, domain.OptionalString{}, nil); !errors.Is(err, ErrValidation) {
+		t.Errorf("UpdateProduct() error = %v, want ErrValidation", err)
+	}
+}
+
+// fakeCategoryChecker knows the categories in ids, with the fixed currencies
+// in currencies, and counts its lookups.
+type fakeCategoryChecker struct {
+	ids        map[string]bool
+	currencies map[string]string
+	err        error
+	calls      int
+}
+
+func (f *fakeCategoryChecker) CategoryCurrency(_ context.Context, id string) (string, bool, error) {
+	f.calls++
+	if f.err != nil {
+		return "", false, f.err
+	}
+	return f.currencies[id], f.ids[id], nil
+}
+
+const (
+	testCategoryID = "7c9e6679-7425-40de-944b-e07fc1f90ae1"
+	// testEURCategoryID is a category whose products must be priced in EUR.
+	testEURCategoryID = "7c9e6679-7425-40de-944b-e07fc1f90ae2"
+)
+
+// newEURCategoryChecker knows testCategoryID, which accepts any currency,
+// and testEURCategoryID.
+func newEURCategoryChecker() *fakeCategoryChecker {
+	return &fakeCategoryChecker{
+		ids:        map[string]bool{testCategoryID: true, testEURCategoryID: true},
+		currencies: map[string]string{testEURCategoryID: "EUR"},
+	}
+}
+
+func TestCreateProductDefaultCurrency(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		opts     []Option
+		currency string
+		want     string
+	}{
+		{name: "built-in default", want: domain.DefaultCurrency},
+		{name: "configured default", opts: []Option{WithDefaultCurrency("EUR")}, want: "EUR"},
+		{name: "explicit currency wins", opts: []Option{WithDefaultCurrency("EUR")}, currency: "gbp", want: "GBP"},
+		{name: "empty default keeps the built-in one", opts: []Option{WithDefaultCurrency("")}, want: domain.DefaultCurrency},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &mockRepository{
+				createFunc: func(context.Context, *domain.Product) error { return nil },
+			}
+			svc := NewService(mockRepo, newMockLogger(), nil, nil, tt.opts...)
+
+			product, err := svc.CreateProduct(ctx, CreateProductInput{SKU: testSKU, Name: testProductName, Price: "10", Currency: tt.currency})
+			if err != nil {
+				t.Fatalf("CreateProduct() unexpected error = %v", err)
+			}
+			if product.Currency != tt.want {
+				t.Errorf("CreateProduct() currency = %q, want %q", product.Currency, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreateProductCategory(t *testing.T) {
+	ctx := context.Background()
+	lookupErr := errors.New("connection refused")
+
+	tests := []struct {
+		name       string
+		categoryID string
+		currency   string
+		opts       []Option
+		checker    *fakeCategoryChecker
+		want       string
+		wantErr    error
+	}{
+		{name: "no category", checker: &fakeCategoryChecker{}},
+		{name: "no category without checker"},
+		{name: "existing category", categoryID: testCategoryID, checker: &fakeCategoryChecker{ids: map[string]bool{testCategoryID: true}}, want: testCategoryID},
+		{name: "canonicalized", categoryID: " " + strings.ToUpper(testCategoryID) + " ", checker: &fakeCategoryChecker{ids: map[string]bool{testCategoryID: true}}, want: testCategoryID},
+		{name: "unknown category", categoryID: testCategoryID, checker: &fakeCategoryChecker{}, wantErr: ErrValidation},
+		{name: "not a UUID", categoryID: "computers", checker: &fakeCategoryChecker{}, wantErr: ErrValidation},
+		{name: "categories not enabled", categoryID: testCategoryID, wantErr: ErrValidation},
+		{name: "lookup fails", categoryID: testCategoryID, checker: &fakeCategoryChecker{err: lookupErr}, wantErr: ErrInternal},
+		{name: "category accepting any currency", categoryID: testCategoryID, currency: "JPY", checker: newEURCategoryChecker(), want: testCategoryID},
+		{name: "matching explicit currency", categoryID: testEURCategoryID, currency: "eur", checker: newEURCategoryChecker(), want: testEURCategoryID},
+		{name: "matching default currency", categoryID: testEURCategoryID, opts: []Option{WithDefaultCurrency("EUR")}, checker: newEURCategoryChecker(), want: testEURCategoryID},
+		{name: "currency mismatch", categoryID: testEURCategoryID, currency: "USD", checker: newEURCategoryChecker(), wantErr: ErrValidation},
+		{name: "default currency mismatch", categoryID: testEURCategoryID, checker: newEURCategoryChecker(), wantErr: ErrValidation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			created := false
+			mockRepo := &mockRepository{
+				createFunc: func(context.Context, *domain.Product) error {
+					created = true
+					return nil
+				},
+			}
+			opts := tt.opts
+			if tt.checker != nil {
+				opts = append(opts, WithCategoryChecker(tt.checker))
+			}
+			svc := NewService(mockRepo, newMockLogger(), nil, nil, opts...)
+
+			product, err := svc.CreateProduct(ctx, CreateProductInput{SKU: testSKU, Name: testProductName, Description: testDescription, Price: "10", Currency: tt.currency, CategoryID: tt.categoryID})
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("CreateProduct() error = %v, want %v", err, tt.wantErr)
+				}
+				if created {
+					t.Error("CreateProduct() stored a product with an invalid category")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CreateProduct() unexpected error = %v", err)
+			}
+			if product.CategoryID != tt.want {
+				t.Errorf("CreateProduct() categoryID = %q, want %q", product.CategoryID, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateProductCategory(t *testing.T) {
+	ctx := context.Background()
+	checker := newEURCategoryChecker()
+
+	tests := []struct {
+		name       string
+		categoryID domain.OptionalString
+		currency   string
+		wantSet    bool
+		wantValue  any
+		wantErr    bool
+	}{
+		{name: "absent leaves the category unchanged", categoryID: domain.OptionalString{}},
+		{name: "empty string clears the category", categoryID: domain.SomeString(""), wantSet: true, wantValue: nil},
+		{name: "null clears the category", categoryID: domain.NullString(), wantSet: true, wantValue: nil},
+		{name: "existing category is set", categoryID: domain.SomeString(testCategoryID), wantSet: true, wantValue: testCategoryID},
+		{name: "unknown category", categoryID: domain.SomeString(uuid.NewString()), wantErr: true},
+		{name: "not a UUID", categoryID: domain.SomeString("computers"), wantErr: true},
+		{name: "category fixing the product's currency", categoryID: domain.SomeString(testEURCategoryID), currency: "EUR", wantSet: true, wantValue: testEURCategoryID},
+		{name: "category fixing another currency", categoryID: domain.SomeString(testEURCategoryID), currency: "USD", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got map[string]any
+			mockRepo := &mockRepository{
+				getByIDFunc: func(_ context.Context, id string) (*domain.Product, error) {
+					return domain.New(id, testSKU, testProductName, testDescription, 1000, tt.currency, ""), nil
+				},
+				fetchFunc: func(_ context.Context, id string, updates map[string]any) (*domain.Product, error) {
+					got = updates
+					return domain.New(id, testSKU, testProductName, testDescription, 1000, domain.DefaultCurrency, ""), nil
+				},
+			}
+			svc := NewService(mockRepo, newMockLogger(), nil, nil, WithCategoryChecker(checker))
+
+			// A name keeps the update non-empty when the category is absent.
+			name := testProductName
+			_, _, err := svc.UpdateProduct(ctx, testID, &name, domain.OptionalString{}, nil, domain.OptionalString{}, tt.categoryID, nil)
+
+			if tt.wantErr {
+				if !errors.Is(err, ErrValidation) {
+					t.Errorf("UpdateProduct() error = %v, want ErrValidation", err)
+				}
+				if got != nil {
+					t.Error("UpdateProduct() updated a product with an invalid category")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("UpdateProduct() unexpected error = %v", err)
+			}
+			value, set := got["categoryId"]
+			if set != tt.wantSet {
+				t.Fatalf("UpdateProduct() updates categoryId = %v, want set %v", set, tt.wantSet)
+			}
+			if set && value != tt.wantValue {
+				t.Errorf("UpdateProduct() updates[categoryId] = %v, want %v", value, tt.wantValue)
+			}
+		})
+	}
+}
+
+func TestListProductsCategory(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := &mockRepository{
+		listFunc: func(ctx context.Context, limit, offset int) ([]*domain.Product, int, error) {
+			return []*domain.Product{}, 0, nil
+		},
+	}
+	svc := NewService(mockRepo, newMockLogger(), nil, nil)
+
+	if _, _, err := svc.ListProducts(ctx, 1, 10, ListOptions{CategoryID: strings.ToUpper(testCategoryID)}); err != nil {
+		t.Fatalf("ListProducts() unexpected error = %v", err)
+	}
+	if mockRepo.listFilter.CategoryID != testCategoryID {
+		t.Errorf("ListProducts() repository categoryID = %q, want %q", mockRepo.listFilter.CategoryID, testCategoryID)
+	}
+
+	if _, _, err := svc.ListProducts(ctx, 1, 10, ListOptions{CategoryID: "computers"}); !errors.Is(err, ErrValidation) {
+		t.Errorf("ListProducts() error = %v, want ErrValidation for a malformed category", err)
+	}
+}
+
 func TestValidateURL(t *testing.T) {
+	cdnHosts := []string{"cdn.example.com"}
+
 	tests := []struct {
 		name        string
 		url         string
+		hosts       []string
 		wantErr     bool
 		errContains string
 	}{
@@ -812,17 +2194,50 @@ func TestValidateURL(t *testing.T) {
 			wantErr:     true,
 			errContains: "valid host",
 		},
+		{
+			name:        "no path",
+			url:         "https://localhost",
+			wantErr:     true,
+			errContains: "image extension",
+		},
+		{
+			name:        "non-image extension",
+			url:         "https://example.com/catalog.pdf",
+			wantErr:     true,
+			errContains: "image extension",
+		},
+		{
+			name:    "extension is case-insensitive and ignores the query",
+			url:     "https://example.com/image.PNG?v=2",
+			wantErr: false,
+		},
+		{
+			name:    "allowed host",
+			url:     "https://CDN.example.com:8443/image.webp",
+			hosts:   cdnHosts,
+			wantErr: false,
+		},
+		{
+			name:        "host not allowed",
+			url:         "https://images.example.org/image.webp",
+			hosts:       cdnHosts,
+			wantErr:     true,
+			errContains: "not allowed",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := validateURL(tt.url)
+			err := validateURL(tt.url, defaultImageURLSchemes, tt.hosts)
 
 			if tt.wantErr {
 				if err == nil {
 					t.Errorf("validateURL() error = nil, wantErr %v", tt.wantErr)
 					return
 				}
+				if !errors.Is(err, ErrValidation) {
+					t.Errorf("validateURL() error = %v, want it to wrap ErrValidation", err)
+				}
 				if tt.errContains != "" && !strings.Contains(err.Error(), tt.errContains) {
 					t.Errorf("validateURL() error = %v, want error containing %v", err, tt.errContains)
 				}
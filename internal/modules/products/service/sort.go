@@ -0,0 +1,43 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/products/repository"
+)
+
+// sortFields is the allowlist of sortBy values accepted by ListProducts.
+var sortFields = map[string]repository.SortField{
+	"createdDate": repository.SortByCreatedDate,
+	"name":        repository.SortByName,
+	"price":       repository.SortByPrice,
+}
+
+// parseSort validates the client's sortBy/sortOrder against the allowlist.
+// Without either the list is newest first; with a sortBy and no sortOrder it
+// is ascending. Errors wrap ErrValidation.
+func parseSort(sortBy, sortOrder string) (repository.Sort, error) {
+	if sortBy == "" && sortOrder == "" {
+		return repository.DefaultSort, nil
+	}
+
+	sort := repository.Sort{Field: repository.SortByCreatedDate}
+	if sortBy != "" {
+		field, ok := sortFields[sortBy]
+		if !ok {
+			return repository.Sort{}, fmt.Errorf("%w: sortBy must be one of createdDate, name, price", ErrValidation)
+		}
+		sort.Field = field
+	}
+
+	switch strings.ToLower(sortOrder) {
+	case "", "asc":
+	case "desc":
+		sort.Descending = true
+	default:
+		return repository.Sort{}, fmt.Errorf("%w: sortOrder must be asc or desc", ErrValidation)
+	}
+
+	return sort, nil
+}
@@ -0,0 +1,83 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gaborage/go-bricks/database"
+	"github.com/google/uuid"
+)
+
+// Audited admin actions.
+const (
+	ActionTenantCacheRewarm = "tenant_cache.rewarm"
+)
+
+// AuditEntry records a single admin mutation.
+type AuditEntry struct {
+	Actor     string
+	Action    string
+	Target    string
+	Timestamp time.Time
+}
+
+// AuditLogger is an append-only sink for admin mutations.
+type AuditLogger interface {
+	Record(ctx context.Context, entry AuditEntry) error
+}
+
+// auditEntryEntity is the admin_audit_log row.
+type auditEntryEntity struct {
+	ID        string    `db:"id"`
+	Actor     string    `db:"actor"`
+	Action    string    `db:"action"`
+	Target    string    `db:"target"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+func (e *auditEntryEntity) TableName() string {
+	return "admin_audit_log"
+}
+
+// DBAuditLogger writes audit entries to the admin_audit_log table. It only
+// ever inserts; entries are never updated or deleted by the application.
+type DBAuditLogger struct {
+	getDB func(context.Context) (database.Interface, error)
+}
+
+// NewDBAuditLogger creates a DB-backed audit logger.
+func NewDBAuditLogger(getDB func(context.Context) (database.Interface, error)) *DBAuditLogger {
+	return &DBAuditLogger{getDB: getDB}
+}
+
+// Record inserts entry. A zero Timestamp is set to the current UTC time.
+func (l *DBAuditLogger) Record(ctx context.Context, entry AuditEntry) error {
+	db, err := l.getDB(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get database for audit log: %w", err)
+	}
+
+	timestamp := entry.Timestamp
+	if timestamp.IsZero() {
+		timestamp = time.Now().UTC()
+	}
+	entity := &auditEntryEntity{
+		ID:        uuid.New().String(),
+		Actor:     entry.Actor,
+		Action:    entry.Action,
+		Target:    entry.Target,
+		CreatedAt: timestamp.UTC(),
+	}
+
+	qb := database.NewQueryBuilder(database.PostgreSQL)
+	query, args, err := qb.InsertStruct(entity.TableName(), entity).ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build audit insert query: %w", err)
+	}
+
+	if _, err := db.Exec(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to write audit entry: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gaborage/go-bricks/database"
+	dbtest "github.com/gaborage/go-bricks/database/testing"
+	dbtypes "github.com/gaborage/go-bricks/database/types"
+)
+
+func TestDBAuditLoggerRecord(t *testing.T) {
+	ctx := context.Background()
+	entry := AuditEntry{
+		Actor:     "ops@example.com",
+		Action:    ActionTenantCacheRewarm,
+		Target:    "tenant-cache",
+		Timestamp: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+
+	t.Run("inserts entry", func(t *testing.T) {
+		db := dbtest.NewTestDB(dbtypes.PostgreSQL)
+		db.ExpectExec("INSERT INTO admin_audit_log").WillReturnRowsAffected(1)
+
+		logger := NewDBAuditLogger(func(context.Context) (database.Interface, error) {
+			return db, nil
+		})
+
+		if err := logger.Record(ctx, entry); err != nil {
+			t.Errorf("Record() unexpected error = %v", err)
+		}
+		dbtest.AssertExecExecuted(t, db, "INSERT")
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		db := dbtest.NewTestDB(dbtypes.PostgreSQL)
+		db.ExpectExec("INSERT INTO admin_audit_log").WillReturnError(errors.New("database error"))
+
+		logger := NewDBAuditLogger(func(context.Context) (database.Interface, error) {
+			return db, nil
+		})
+
+		if err := logger.Record(ctx, entry); err == nil {
+			t.Error("Record() expected error, got nil")
+		}
+	})
+
+	t.Run("database unavailable", func(t *testing.T) {
+		logger := NewDBAuditLogger(func(context.Context) (database.Interface, error) {
+			return nil, errors.New("connection refused")
+		})
+
+		if err := logger.Record(ctx, entry); err == nil {
+			t.Error("Record() expected error, got nil")
+		}
+	})
+}
@@ -0,0 +1,70 @@
+// Package admin provides the access guard shared by /admin endpoints.
+package admin
+
+import (
+	"crypto/subtle"
+	"strings"
+
+	"github.com/gaborage/go-bricks/server"
+)
+
+const (
+	// HeaderToken carries the shared admin token on /admin requests.
+	HeaderToken = "X-Admin-Token"
+	// HeaderActor optionally names the operator making an admin request.
+	HeaderActor = "X-Admin-Actor"
+
+	defaultActor = "admin"
+)
+
+// Config controls access to /admin endpoints. Populate it with config.InjectInto.
+type Config struct {
+	// Enabled turns the admin endpoints on. They are rejected with 403 otherwise.
+	Enabled bool `config:"custom.admin.enabled" default:"false"`
+	// Token, when set, must match the X-Admin-Token request header.
+	Token string `config:"custom.admin.token"`
+}
+
+// AuthError is returned by Authorize. It is a server.IAPIError for typed
+// handlers and an error for plain server.Handler routes.
+type AuthError interface {
+	server.IAPIError
+	error
+}
+
+// Guard authorizes requests to admin endpoints.
+type Guard struct {
+	cfg Config
+}
+
+// NewGuard creates a guard for the given admin configuration.
+func NewGuard(cfg Config) *Guard {
+	return &Guard{cfg: cfg}
+}
+
+// Authorize returns nil when the request may use admin endpoints. A nil guard
+// denies everything, so handlers built without admin config stay locked down.
+func (g *Guard) Authorize(ctx server.HandlerContext) AuthError {
+	if g == nil || !g.cfg.Enabled {
+		return server.NewForbiddenError("Admin endpoints are disabled")
+	}
+
+	if g.cfg.Token != "" {
+		provided := ctx.RequestHeader(HeaderToken)
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(g.cfg.Token)) != 1 {
+			return server.NewUnauthorizedError("Invalid admin token")
+		}
+	}
+
+	return nil
+}
+
+// Actor returns the operator recorded in audit entries: the X-Admin-Actor
+// header, or "admin" when it is absent. The token is shared, so the header is
+// informational rather than authenticated.
+func (g *Guard) Actor(ctx server.HandlerContext) string {
+	if actor := strings.TrimSpace(ctx.RequestHeader(HeaderActor)); actor != "" {
+		return actor
+	}
+	return defaultActor
+}
@@ -0,0 +1,29 @@
+// Package apitime formats and parses API timestamps, so every module renders
+// and accepts them the same way.
+package apitime
+
+import "time"
+
+// Layout is the timestamp format of API responses: RFC 3339 with second
+// precision.
+const Layout = time.RFC3339
+
+// Format renders t for an API response.
+func Format(t time.Time) string {
+	return t.Format(Layout)
+}
+
+// Parse reads a timestamp in the API format, such as a query parameter.
+func Parse(s string) (time.Time, error) {
+	return time.Parse(Layout, s)
+}
+
+// FormatOptional is Format for timestamps that may not have happened yet,
+// such as a last view. The zero time renders as "", so an omitempty field is
+// left out instead of reading "0001-01-01T00:00:00Z".
+func FormatOptional(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return Format(t)
+}
@@ -0,0 +1,40 @@
+package apitime
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormat(t *testing.T) {
+	at := time.Date(2024, 3, 1, 9, 30, 15, 123456789, time.FixedZone("CET", 3600))
+
+	if got, want := Format(at), "2024-03-01T09:30:15+01:00"; got != want {
+		t.Errorf("Format() = %q, want %q", got, want)
+	}
+	if got, want := Format(at.UTC()), "2024-03-01T08:30:15Z"; got != want {
+		t.Errorf("Format() of a UTC time = %q, want %q", got, want)
+	}
+}
+
+func TestFormatOptional(t *testing.T) {
+	at := time.Date(2024, 3, 1, 9, 30, 15, 0, time.UTC)
+
+	if got := FormatOptional(time.Time{}); got != "" {
+		t.Errorf("FormatOptional() of the zero time = %q, want empty", got)
+	}
+	if got, want := FormatOptional(at), Format(at); got != want {
+		t.Errorf("FormatOptional() = %q, want %q", got, want)
+	}
+}
+
+func TestParse(t *testing.T) {
+	at := time.Date(2024, 3, 1, 9, 30, 15, 0, time.FixedZone("CET", 3600))
+
+	got, err := Parse(Format(at))
+	if err != nil || !got.Equal(at) {
+		t.Errorf("Parse(Format()) = %v, %v, want %v", got, err, at)
+	}
+	if _, err := Parse("2024-03-01 09:30:15"); err == nil {
+		t.Error("Parse() of a non-RFC 3339 timestamp succeeded, want an error")
+	}
+}
@@ -0,0 +1,71 @@
+// Package correlation carries a per-request correlation ID on the context, so
+// the handler, service and repository logs of one request can be joined.
+package correlation
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/gaborage/go-bricks/logger"
+	"github.com/gaborage/go-bricks/server"
+	gobrickstrace "github.com/gaborage/go-bricks/trace"
+	"github.com/google/uuid"
+)
+
+const (
+	// Header carries a caller-supplied correlation ID.
+	Header = "X-Request-ID"
+	// LogField is the log field holding the correlation ID.
+	LogField = "correlation_id"
+)
+
+// idPattern is the framework's X-Request-ID rule: a header value that does
+// not match is replaced, so callers cannot write arbitrary bytes into logs.
+var idPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,128}$`)
+
+type contextKey struct{}
+
+// WithID returns a copy of ctx carrying id.
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// ID returns the correlation ID on ctx, or "" when there is none.
+func ID(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// FromRequest returns the request context with a correlation ID on it and
+// stores that context back on the request, so later calls see the same ID.
+// The ID is the X-Request-ID header when it is valid, else the trace ID the
+// framework resolved for the request, else a new UUID.
+func FromRequest(ctx server.HandlerContext) context.Context {
+	reqCtx := ctx.RequestContext()
+	if ID(reqCtx) != "" {
+		return reqCtx
+	}
+
+	id := ctx.RequestHeader(Header)
+	if !idPattern.MatchString(id) {
+		if traceID, ok := gobrickstrace.IDFromContext(reqCtx); ok && idPattern.MatchString(traceID) {
+			id = traceID
+		} else {
+			id = uuid.New().String()
+		}
+	}
+
+	reqCtx = WithID(reqCtx, id)
+	ctx.SetRequestContext(reqCtx)
+	return reqCtx
+}
+
+// Logger returns l with the correlation ID of ctx as a field, or l unchanged
+// when ctx has none.
+func Logger(ctx context.Context, l logger.Logger) logger.Logger {
+	id := ID(ctx)
+	if id == "" {
+		return l
+	}
+	return l.WithFields(map[string]any{LogField: id})
+}
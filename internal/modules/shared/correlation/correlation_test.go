@@ -0,0 +1,61 @@
+package correlation
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gaborage/go-bricks/server"
+	gobrickstrace "github.com/gaborage/go-bricks/trace"
+)
+
+func newTestContext(header string, reqCtx context.Context) server.HandlerContext {
+	req := httptest.NewRequestWithContext(reqCtx, http.MethodGet, "/products", http.NoBody)
+	if header != "" {
+		req.Header.Set(Header, header)
+	}
+	return server.NewHandlerContextForTest(httptest.NewRecorder(), req, nil)
+}
+
+func TestWithID(t *testing.T) {
+	if got := ID(context.Background()); got != "" {
+		t.Errorf("ID() = %q on a bare context, want \"\"", got)
+	}
+	if got := ID(WithID(context.Background(), "req-1")); got != "req-1" {
+		t.Errorf("ID() = %q, want %q", got, "req-1")
+	}
+}
+
+func TestFromRequest(t *testing.T) {
+	traced := gobrickstrace.WithTraceID(context.Background(), "trace-1")
+
+	tests := []struct {
+		name   string
+		header string
+		ctx    context.Context
+		want   string // "" means a generated ID
+	}{
+		{name: "header", header: "req-1", ctx: traced, want: "req-1"},
+		{name: "invalid header falls back to the trace ID", header: "bad id\n", ctx: traced, want: "trace-1"},
+		{name: "existing ID", header: "req-1", ctx: WithID(context.Background(), "req-0"), want: "req-0"},
+		{name: "generated", ctx: context.Background()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := newTestContext(tt.header, tt.ctx)
+
+			got := ID(FromRequest(ctx))
+			if tt.want != "" && got != tt.want {
+				t.Errorf("FromRequest() ID = %q, want %q", got, tt.want)
+			}
+			if !idPattern.MatchString(got) {
+				t.Errorf("FromRequest() ID = %q, want a valid ID", got)
+			}
+			if again := ID(FromRequest(ctx)); again != got {
+				t.Errorf("second FromRequest() ID = %q, want the first call's %q", again, got)
+			}
+		})
+	}
+}
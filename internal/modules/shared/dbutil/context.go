@@ -0,0 +1,41 @@
+package dbutil
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gaborage/go-bricks/server"
+)
+
+// StatusClientClosedRequest is the non-standard status (popularized by nginx)
+// recorded when the client disconnected before a response was written.
+const StatusClientClosedRequest = 499
+
+// Error codes for context failures, alongside the framework's status codes.
+const (
+	codeClientClosedRequest = "CLIENT_CLOSED_REQUEST"
+	codeRequestTimeout      = "REQUEST_TIMEOUT"
+	codeGatewayTimeout      = "GATEWAY_TIMEOUT"
+)
+
+// ContextAPIError maps an error caused by a context to a response, reporting
+// false for any other error:
+//   - context.Canceled: 499. The client has gone, so nothing reads the body.
+//   - context.DeadlineExceeded on the request's own context: 408, the request
+//     ran out of time.
+//   - context.DeadlineExceeded on a narrower context (e.g. a query timeout)
+//     while the request is still live: 504, a dependency was too slow.
+func ContextAPIError(ctx server.HandlerContext, err error) (server.IAPIError, bool) {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return server.NewBaseAPIError(codeClientClosedRequest, "Client closed request", StatusClientClosedRequest), true
+	case errors.Is(err, context.DeadlineExceeded):
+		if errors.Is(ctx.RequestContext().Err(), context.DeadlineExceeded) {
+			return server.NewBaseAPIError(codeRequestTimeout, "Request timed out", http.StatusRequestTimeout), true
+		}
+		return server.NewBaseAPIError(codeGatewayTimeout, "Upstream operation timed out", http.StatusGatewayTimeout), true
+	default:
+		return nil, false
+	}
+}
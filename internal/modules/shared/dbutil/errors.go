@@ -0,0 +1,104 @@
+package dbutil
+
+import (
+	"errors"
+
+	"github.com/gaborage/go-bricks/database"
+	"github.com/gaborage/go-bricks/server"
+)
+
+// Sentinel errors classifying repository failures. Repositories mark their
+// errors with Unavailable or Internal so callers can tell a failure worth
+// retrying from one that is not, using errors.Is.
+var (
+	// ErrDBUnavailable indicates no database connection could be obtained (HTTP 503).
+	ErrDBUnavailable = errors.New("database unavailable")
+
+	// ErrInternal indicates a statement reached the database and failed (HTTP 500).
+	ErrInternal = errors.New("internal error")
+
+	// ErrConflict indicates a statement violated a unique constraint (HTTP 409).
+	ErrConflict = errors.New("conflict")
+)
+
+// RetryAfterSeconds is the Retry-After value sent with 503 responses for an
+// unavailable database.
+const RetryAfterSeconds = "5"
+
+// classifiedError tags err with a sentinel while keeping err's message.
+type classifiedError struct {
+	kind error
+	err  error
+}
+
+func (e *classifiedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *classifiedError) Unwrap() []error {
+	return []error{e.kind, e.err}
+}
+
+// Unavailable marks a failure to obtain a database connection. It matches
+// ErrDBUnavailable and err; a nil err stays nil.
+func Unavailable(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &classifiedError{kind: ErrDBUnavailable, err: err}
+}
+
+// Internal marks a failed query or statement. It matches ErrInternal and
+// err; a nil err stays nil.
+func Internal(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &classifiedError{kind: ErrInternal, err: err}
+}
+
+// Statement marks a failed statement by inspecting the driver error: a
+// unique-constraint violation (PostgreSQL SQLSTATE 23505, Oracle ORA-00001)
+// matches ErrConflict and err, anything else is marked Internal. A nil err
+// stays nil.
+func Statement(err error) error {
+	if err == nil {
+		return nil
+	}
+	if database.IsUniqueViolation(err) {
+		return &classifiedError{kind: ErrConflict, err: err}
+	}
+	return Internal(err)
+}
+
+// APIError maps a failure the client cannot fix to a response: a canceled or
+// timed-out context as described by ContextAPIError, 503 with a Retry-After
+// header when the database is unavailable, 409 for a unique-constraint
+// violation the handler did not map to a more specific message, otherwise a
+// 500 carrying msg. For 499 the status is written right away, which commits
+// the response, so the framework skips the JSON body nobody would read.
+func APIError(ctx server.HandlerContext, err error, msg string) server.IAPIError {
+	if apiErr, ok := ContextAPIError(ctx, err); ok {
+		if apiErr.HTTPStatus() == StatusClientClosedRequest {
+			ctx.ResponseWriter().WriteHeader(StatusClientClosedRequest)
+		}
+		return apiErr
+	}
+	if errors.Is(err, ErrDBUnavailable) {
+		ctx.ResponseWriter().Header().Set("Retry-After", RetryAfterSeconds)
+		return server.NewServiceUnavailableError("Database is temporarily unavailable")
+	}
+	if errors.Is(err, ErrConflict) {
+		return server.NewConflictError("The request conflicts with an existing resource")
+	}
+	return server.NewInternalServerError(msg)
+}
+
+// HandlerError is APIError for plain server.Handler routes, which return an
+// error rather than a server.IAPIError.
+func HandlerError(ctx server.HandlerContext, err error, msg string) error {
+	if e, ok := APIError(ctx, err, msg).(error); ok {
+		return e
+	}
+	return server.NewInternalServerError(msg)
+}
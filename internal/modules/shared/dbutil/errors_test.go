@@ -0,0 +1,120 @@
+package dbutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gaborage/go-bricks/config"
+	"github.com/gaborage/go-bricks/server"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestErrorClassification(t *testing.T) {
+	cause := errors.New("connection refused")
+
+	tests := []struct {
+		name            string
+		err             error
+		wantUnavailable bool
+		wantInternal    bool
+	}{
+		{name: "unavailable", err: Unavailable(cause), wantUnavailable: true},
+		{name: "internal", err: Internal(cause), wantInternal: true},
+		{name: "failed statement", err: Statement(cause), wantInternal: true},
+		{name: "unclassified", err: cause},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := errors.Is(tt.err, ErrDBUnavailable); got != tt.wantUnavailable {
+				t.Errorf("errors.Is(ErrDBUnavailable) = %v, want %v", got, tt.wantUnavailable)
+			}
+			if got := errors.Is(tt.err, ErrInternal); got != tt.wantInternal {
+				t.Errorf("errors.Is(ErrInternal) = %v, want %v", got, tt.wantInternal)
+			}
+			if !errors.Is(tt.err, cause) {
+				t.Error("classified error no longer matches its cause")
+			}
+			if tt.err.Error() != cause.Error() {
+				t.Errorf("Error() = %q, want the cause's message %q", tt.err.Error(), cause.Error())
+			}
+		})
+	}
+
+	if Unavailable(nil) != nil || Internal(nil) != nil || Statement(nil) != nil {
+		t.Error("classifying a nil error should return nil")
+	}
+}
+
+func TestStatementUniqueViolation(t *testing.T) {
+	violation := fmt.Errorf("exec failed: %w", &pgconn.PgError{Code: "23505", ConstraintName: "uq_products_sku"})
+
+	err := Statement(violation)
+
+	if !errors.Is(err, ErrConflict) {
+		t.Errorf("Statement() = %v, want it to match ErrConflict", err)
+	}
+	if errors.Is(err, ErrInternal) {
+		t.Errorf("Statement() = %v, want it not to match ErrInternal", err)
+	}
+	if !errors.Is(err, violation) {
+		t.Error("Statement() no longer matches the driver error")
+	}
+
+	other := Statement(&pgconn.PgError{Code: "23503"})
+	if errors.Is(other, ErrConflict) || !errors.Is(other, ErrInternal) {
+		t.Errorf("Statement() of a foreign-key violation = %v, want ErrInternal only", other)
+	}
+}
+
+func TestAPIError(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		wantStatus     int
+		wantRetryAfter string
+		wantCommitted  bool
+	}{
+		{name: "database unavailable", err: Unavailable(errors.New("pool exhausted")), wantStatus: http.StatusServiceUnavailable, wantRetryAfter: RetryAfterSeconds},
+		{name: "query failed", err: Internal(errors.New("syntax error")), wantStatus: http.StatusInternalServerError},
+		{name: "unique violation", err: Statement(&pgconn.PgError{Code: "23505"}), wantStatus: http.StatusConflict},
+		{name: "unclassified", err: errors.New("boom"), wantStatus: http.StatusInternalServerError},
+		{name: "client disconnected", err: Internal(context.Canceled), wantStatus: StatusClientClosedRequest, wantCommitted: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newContext := func() (server.HandlerContext, *httptest.ResponseRecorder) {
+				req := httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/", nil)
+				rec := httptest.NewRecorder()
+				return server.NewHandlerContextForTest(rec, req, &config.Config{}), rec
+			}
+			ctx, rec := newContext()
+
+			apiErr := APIError(ctx, tt.err, "Failed to do the thing")
+
+			if apiErr.HTTPStatus() != tt.wantStatus {
+				t.Errorf("APIError() status = %d, want %d", apiErr.HTTPStatus(), tt.wantStatus)
+			}
+			if got := rec.Header().Get("Retry-After"); got != tt.wantRetryAfter {
+				t.Errorf("APIError() Retry-After = %q, want %q", got, tt.wantRetryAfter)
+			}
+			if committed := rec.Code == tt.wantStatus; committed != tt.wantCommitted {
+				t.Errorf("APIError() wrote status %d, want committed = %v", rec.Code, tt.wantCommitted)
+			}
+			if rec.Body.Len() != 0 {
+				t.Errorf("APIError() wrote body %q, want none", rec.Body.String())
+			}
+
+			ctx, _ = newContext()
+			var handlerErr server.IAPIError
+			if err := HandlerError(ctx, tt.err, "Failed to do the thing"); !errors.As(err, &handlerErr) || handlerErr.HTTPStatus() != tt.wantStatus {
+				t.Errorf("HandlerError() = %v, want an API error with status %d", err, tt.wantStatus)
+			}
+		})
+	}
+}
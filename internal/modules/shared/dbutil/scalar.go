@@ -0,0 +1,27 @@
+// Package dbutil holds small query helpers shared by the module repositories.
+package dbutil
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	dbtypes "github.com/gaborage/go-bricks/database/types"
+)
+
+// ScanScalar runs a query that returns a single value (COUNT, SUM, EXISTS, ...)
+// and scans it into T. When the query returns no row it yields the zero value
+// and an error matching sql.ErrNoRows, so callers can map it to their own
+// not-found error. Use a sql.Null* type for T when the value may be NULL.
+func ScanScalar[T any](ctx context.Context, q dbtypes.Querier, query string, args ...any) (T, error) {
+	var value T
+	if err := q.QueryRow(ctx, query, args...).Scan(&value); err != nil {
+		var zero T
+		if errors.Is(err, sql.ErrNoRows) {
+			return zero, err
+		}
+		return zero, fmt.Errorf("failed to scan scalar: %w", err)
+	}
+	return value, nil
+}
@@ -0,0 +1,57 @@
+package dbutil
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	dbtest "github.com/gaborage/go-bricks/database/testing"
+	dbtypes "github.com/gaborage/go-bricks/database/types"
+)
+
+func TestScanScalar(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("success", func(t *testing.T) {
+		db := dbtest.NewTestDB(dbtypes.PostgreSQL)
+		db.ExpectQuery("SELECT COUNT").WillReturnRows(dbtest.NewRowSet("count").AddRow(42))
+
+		got, err := ScanScalar[int](ctx, db, "SELECT COUNT(*) FROM products")
+
+		if err != nil {
+			t.Fatalf("ScanScalar() unexpected error = %v", err)
+		}
+		if got != 42 {
+			t.Errorf("ScanScalar() = %v, want 42", got)
+		}
+	})
+
+	t.Run("no rows", func(t *testing.T) {
+		db := dbtest.NewTestDB(dbtypes.PostgreSQL)
+		db.ExpectQuery("SELECT id").WillReturnRows(dbtest.NewRowSet("id"))
+
+		got, err := ScanScalar[string](ctx, db, "SELECT id FROM products WHERE id = $1", "missing")
+
+		if !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("ScanScalar() error = %v, want sql.ErrNoRows", err)
+		}
+		if got != "" {
+			t.Errorf("ScanScalar() = %q, want zero value", got)
+		}
+	})
+
+	t.Run("query error", func(t *testing.T) {
+		db := dbtest.NewTestDB(dbtypes.PostgreSQL)
+		db.ExpectQuery("SELECT COUNT").WillReturnError(errors.New("connection reset"))
+
+		_, err := ScanScalar[int](ctx, db, "SELECT COUNT(*) FROM products")
+
+		if err == nil {
+			t.Fatal("ScanScalar() expected error, got nil")
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("ScanScalar() error = %v, should not match sql.ErrNoRows", err)
+		}
+	})
+}
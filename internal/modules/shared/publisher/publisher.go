@@ -0,0 +1,128 @@
+// Package publisher wraps the AMQP client with confirmed, retried publishing
+// for modules that send events directly to the broker.
+package publisher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gaborage/go-bricks/logger"
+	"github.com/gaborage/go-bricks/messaging"
+)
+
+const (
+	defaultConfirmTimeout = 5 * time.Second
+	defaultMaxAttempts    = 3
+)
+
+var (
+	// ErrConfirmTimeout is returned when the broker does not ack a publish
+	// within Config.ConfirmTimeout.
+	ErrConfirmTimeout = errors.New("broker did not confirm publish within timeout")
+	// ErrPublishFailed wraps the last cause once every attempt has failed.
+	ErrPublishFailed = errors.New("publish failed")
+)
+
+// Config controls confirm and retry behaviour. Populate it with config.InjectInto.
+type Config struct {
+	// ConfirmTimeout bounds how long a single attempt waits for the broker ack.
+	ConfirmTimeout time.Duration `config:"custom.messaging.publisher.confirm.timeout" default:"5s"`
+	// MaxAttempts is the total number of attempts for transient failures.
+	MaxAttempts int `config:"custom.messaging.publisher.max.attempts" default:"3"`
+	// RetryBackoff is multiplied by the attempt number between retries.
+	RetryBackoff time.Duration `config:"custom.messaging.publisher.retry.backoff" default:"200ms"`
+}
+
+// Publisher publishes to exchanges over a confirm-mode channel. Each attempt
+// returns only once the broker acks; nacks, confirm timeouts and lost
+// connections are retried up to MaxAttempts.
+type Publisher struct {
+	getClient func(context.Context) (messaging.AMQPClient, error)
+	cfg       Config
+	logger    logger.Logger
+}
+
+// NewPublisher creates a publisher. getClient resolves the AMQP client for the
+// request context, matching app.ModuleDeps.Messaging.
+func NewPublisher(getClient func(context.Context) (messaging.AMQPClient, error), cfg Config, l logger.Logger) *Publisher {
+	if cfg.ConfirmTimeout <= 0 {
+		cfg.ConfirmTimeout = defaultConfirmTimeout
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = defaultMaxAttempts
+	}
+	return &Publisher{
+		getClient: getClient,
+		cfg:       cfg,
+		logger:    l,
+	}
+}
+
+// Publish sends data to options.Exchange and waits for the broker to confirm
+// it. Errors that are not transient, or a cancelled ctx, end retries early.
+func (p *Publisher) Publish(ctx context.Context, options messaging.PublishOptions, data []byte) error {
+	client, err := p.getClient(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get messaging client: %w", err)
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= p.cfg.MaxAttempts; attempt++ {
+		lastErr = p.publishOnce(ctx, client, options, data)
+		if lastErr == nil {
+			return nil
+		}
+		if ctx.Err() != nil || !isTransient(lastErr) {
+			return fmt.Errorf("%w: %w", ErrPublishFailed, lastErr)
+		}
+
+		p.logger.Warn().
+			Err(lastErr).
+			Str("exchange", options.Exchange).
+			Str("routingKey", options.RoutingKey).
+			Int("attempt", attempt).
+			Msg("Publish not confirmed, retrying")
+
+		if attempt < p.cfg.MaxAttempts {
+			if err := p.wait(ctx, attempt); err != nil {
+				return fmt.Errorf("%w: %w", ErrPublishFailed, err)
+			}
+		}
+	}
+
+	return fmt.Errorf("%w after %d attempts: %w", ErrPublishFailed, p.cfg.MaxAttempts, lastErr)
+}
+
+// publishOnce runs a single publish bounded by ConfirmTimeout.
+func (p *Publisher) publishOnce(ctx context.Context, client messaging.AMQPClient, options messaging.PublishOptions, data []byte) error {
+	attemptCtx, cancel := context.WithTimeout(ctx, p.cfg.ConfirmTimeout)
+	defer cancel()
+
+	err := client.PublishToExchange(attemptCtx, options, data)
+	if err != nil && ctx.Err() == nil && errors.Is(attemptCtx.Err(), context.DeadlineExceeded) {
+		return ErrConfirmTimeout
+	}
+	return err
+}
+
+func (p *Publisher) wait(ctx context.Context, attempt int) error {
+	timer := time.NewTimer(time.Duration(attempt) * p.cfg.RetryBackoff)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// isTransient reports whether a publish may succeed if attempted again.
+func isTransient(err error) bool {
+	return errors.Is(err, ErrConfirmTimeout) ||
+		errors.Is(err, messaging.ErrPublishNacked) ||
+		errors.Is(err, messaging.ErrPublishConfirmTimeout) ||
+		errors.Is(err, messaging.ErrNotConnected)
+}
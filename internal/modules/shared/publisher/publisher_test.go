@@ -0,0 +1,126 @@
+package publisher
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gaborage/go-bricks/logger"
+	"github.com/gaborage/go-bricks/messaging"
+)
+
+// fakeAMQPClient implements messaging.AMQPClient. Only PublishToExchange is
+// used by the publisher; the embedded interface panics if anything else is called.
+type fakeAMQPClient struct {
+	messaging.AMQPClient
+	publishFunc func(ctx context.Context, attempt int) error
+	attempts    int
+}
+
+func (f *fakeAMQPClient) PublishToExchange(ctx context.Context, _ messaging.PublishOptions, _ []byte) error {
+	f.attempts++
+	return f.publishFunc(ctx, f.attempts)
+}
+
+func newTestPublisher(client *fakeAMQPClient) *Publisher {
+	return NewPublisher(
+		func(context.Context) (messaging.AMQPClient, error) { return client, nil },
+		Config{ConfirmTimeout: 20 * time.Millisecond, MaxAttempts: 3, RetryBackoff: time.Millisecond},
+		logger.New("info", false),
+	)
+}
+
+func TestPublisherPublish(t *testing.T) {
+	ack := func(context.Context, int) error { return nil }
+	nack := func(context.Context, int) error { return messaging.ErrPublishNacked }
+	noConfirm := func(ctx context.Context, _ int) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	tests := []struct {
+		name         string
+		publishFunc  func(ctx context.Context, attempt int) error
+		wantErr      error
+		wantAttempts int
+	}{
+		{
+			name:         "ack",
+			publishFunc:  ack,
+			wantAttempts: 1,
+		},
+		{
+			name: "nack then ack",
+			publishFunc: func(ctx context.Context, attempt int) error {
+				if attempt == 1 {
+					return nack(ctx, attempt)
+				}
+				return ack(ctx, attempt)
+			},
+			wantAttempts: 2,
+		},
+		{
+			name:         "nack on every attempt",
+			publishFunc:  nack,
+			wantErr:      messaging.ErrPublishNacked,
+			wantAttempts: 3,
+		},
+		{
+			name:         "confirm timeout",
+			publishFunc:  noConfirm,
+			wantErr:      ErrConfirmTimeout,
+			wantAttempts: 3,
+		},
+		{
+			name: "non-transient error is not retried",
+			publishFunc: func(context.Context, int) error {
+				return errors.New("NOT_FOUND - no exchange 'product-events'")
+			},
+			wantErr:      ErrPublishFailed,
+			wantAttempts: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeAMQPClient{publishFunc: tt.publishFunc}
+			p := newTestPublisher(client)
+
+			err := p.Publish(context.Background(), messaging.PublishOptions{Exchange: "product-events", RoutingKey: "product.updated"}, []byte(`{}`))
+
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("Publish() unexpected error = %v", err)
+			}
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Publish() error = %v, want %v", err, tt.wantErr)
+				}
+				if !errors.Is(err, ErrPublishFailed) {
+					t.Errorf("Publish() error = %v, want wrapped %v", err, ErrPublishFailed)
+				}
+			}
+			if client.attempts != tt.wantAttempts {
+				t.Errorf("Publish() attempts = %d, want %d", client.attempts, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestPublisherPublishStopsWhenContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	client := &fakeAMQPClient{publishFunc: func(context.Context, int) error {
+		cancel()
+		return messaging.ErrPublishNacked
+	}}
+	p := newTestPublisher(client)
+
+	err := p.Publish(ctx, messaging.PublishOptions{Exchange: "product-events"}, []byte(`{}`))
+
+	if !errors.Is(err, ErrPublishFailed) {
+		t.Errorf("Publish() error = %v, want %v", err, ErrPublishFailed)
+	}
+	if client.attempts != 1 {
+		t.Errorf("Publish() attempts = %d, want 1", client.attempts)
+	}
+}
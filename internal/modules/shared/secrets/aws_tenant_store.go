@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -14,25 +15,113 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
 	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
 
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/shared/ttlcache"
 	gobricksConfig "github.com/gaborage/go-bricks/config"
 	"github.com/gaborage/go-bricks/logger"
+	"golang.org/x/sync/singleflight"
 )
 
+// AWSSecretsConfig configures the AWS Secrets Manager tenant store.
+// Populate it with config.InjectInto.
 type AWSSecretsConfig struct {
-	Prefix      string        `json:"prefix" koanf:"custom.aws.secrets.prefix"`
-	Cache       time.Duration `json:"cache" koanf:"custom.aws.secrets.cache.ttl"`
-	MaxSize     int           `json:"max" koanf:"custom.aws.secrets.cache.max.size"`
-	EndpointURL string        `json:"endpoint_url" koanf:"custom.aws.endpoint.url"`
+	Prefix      string        `json:"prefix" koanf:"custom.aws.secrets.prefix" config:"custom.aws.secrets.prefix"`
+	Cache       time.Duration `json:"cache" koanf:"custom.aws.secrets.cache.ttl" config:"custom.aws.secrets.cache.ttl" default:"5m"`
+	MaxSize     int           `json:"max" koanf:"custom.aws.secrets.cache.max.size" config:"custom.aws.secrets.cache.max.size" default:"1000"`
+	EndpointURL string        `json:"endpoint_url" koanf:"custom.aws.endpoint.url" config:"custom.aws.endpoint.url"`
+	// MaxConcurrency caps the number of concurrent Secrets Manager fetches in BatchDBConfig.
+	MaxConcurrency int `json:"max_concurrency" koanf:"custom.aws.secrets.max.concurrency" config:"custom.aws.secrets.max.concurrency" default:"5"`
+	// NegativeTTL is how long a tenant without a secret is remembered as
+	// missing, so lookups for unknown tenants do not each hit Secrets Manager.
+	NegativeTTL time.Duration `json:"negative_ttl" koanf:"custom.aws.secrets.cache.negative.ttl" config:"custom.aws.secrets.cache.negative.ttl" default:"30s"`
+	// WarmOnStartup runs WarmCache when the tenants module starts, so the
+	// first request per tenant after a deploy does not pay for the fetch.
+	WarmOnStartup bool `json:"warm_on_startup" koanf:"custom.aws.secrets.cache.warm.startup" config:"custom.aws.secrets.cache.warm.startup" default:"true"`
+	// WarmTimeout bounds the startup warm-up.
+	WarmTimeout time.Duration `json:"warm_timeout" koanf:"custom.aws.secrets.cache.warm.timeout" config:"custom.aws.secrets.cache.warm.timeout" default:"1m"`
+	// RefreshThreshold enables refresh-ahead: a DBConfig cache hit within the
+	// last RefreshThreshold fraction of the TTL (0.2 = last 20%) re-fetches the
+	// tenant in the background. Zero disables it; it must be below 1.
+	RefreshThreshold float64 `json:"refresh_threshold" koanf:"custom.aws.secrets.cache.refresh.threshold" config:"custom.aws.secrets.cache.refresh.threshold" default:"0"`
+	// ServeStaleOnError answers DBConfig with a tenant's last fetched
+	// configuration, even if expired, when a fresh fetch fails. Expired
+	// entries are then kept until the cache needs room. A tenant whose secret
+	// is gone still fails with ErrTenantNotFound.
+	ServeStaleOnError bool `json:"serve_stale_on_error" koanf:"custom.aws.secrets.cache.stale.on.error" config:"custom.aws.secrets.cache.stale.on.error" default:"false"`
+	// RequestTimeout bounds each Secrets Manager call, including each retry.
+	RequestTimeout time.Duration `json:"request_timeout" koanf:"custom.aws.secrets.request.timeout" config:"custom.aws.secrets.request.timeout" default:"5s"`
+	// MaxRetries is how often a call failing with an internal service error,
+	// throttling or RequestTimeout is retried, with exponential backoff.
+	// Zero disables retries.
+	MaxRetries int `json:"max_retries" koanf:"custom.aws.secrets.max.retries" config:"custom.aws.secrets.max.retries" default:"2"`
+	// MaxListResults caps how many tenants ListTenants returns, and so how
+	// many WarmCache loads. Zero lists every tenant.
+	MaxListResults int `json:"max_list_results" koanf:"custom.aws.secrets.list.max.results" config:"custom.aws.secrets.list.max.results" default:"0"`
+	// AllowedTypes lists the database types a tenant secret may name.
+	// Secrets with another type are rejected when fetched.
+	AllowedTypes []string `json:"allowed_types" koanf:"custom.aws.secrets.allowed.types" config:"custom.aws.secrets.allowed.types" default:"postgresql,oracle"`
 }
 
+const (
+	// defaultMaxConcurrency keeps batch fetches well below the Secrets Manager
+	// GetSecretValue request quota.
+	defaultMaxConcurrency = 5
+
+	// refreshTimeout bounds a background refresh-ahead fetch.
+	refreshTimeout = 10 * time.Second
+
+	// fetchTimeout bounds a cache-miss fetch shared by concurrent callers.
+	// The fetch is detached from the caller that started it, so it needs a
+	// deadline of its own.
+	fetchTimeout = 10 * time.Second
+
+	// databaseConfigType is the secret name suffix holding a tenant's
+	// database configuration.
+	databaseConfigType = "database"
+)
+
+// defaultDatabaseTypes are the database types accepted when no allowed types
+// are configured.
+var defaultDatabaseTypes = []string{gobricksConfig.PostgreSQL, gobricksConfig.Oracle}
+
+// ErrInvalidTenantConfig is returned for a tenant whose database secret
+// parses but cannot describe a usable connection.
+var ErrInvalidTenantConfig = errors.New("invalid tenant database config")
+
+// ErrTenantNotFound is returned by DBConfig for a tenant without a database
+// secret, i.e. when Secrets Manager answers ResourceNotFoundException. Other
+// Secrets Manager failures are not wrapped in it, so callers can tell an
+// unknown tenant from a transient error with errors.Is. The result is cached
+// for AWSSecretsConfig.NegativeTTL.
+var ErrTenantNotFound = errors.New("tenant not found")
+
 // AWSSecretsTenantStore implements the database.TenantStore interface
 // using AWS Secrets Manager as the configuration source with intelligent caching
 type AWSSecretsTenantStore struct {
-	client SecretsManagerAPI
-	cache  *Cache
-	prefix string
-	logger logger.Logger
-	mu     sync.RWMutex
+	client         SecretsManagerAPI
+	cache          *ttlcache.Cache
+	prefix         string
+	maxConcurrency int
+	logger         logger.Logger
+	mu             sync.RWMutex
+
+	// refreshThreshold is AWSSecretsConfig.RefreshThreshold; zero disables
+	// refresh-ahead. refreshes keeps it to one fetch per cache key.
+	refreshThreshold float64
+	refreshes        singleflight.Group
+
+	// allowedTypes is AWSSecretsConfig.AllowedTypes; empty means
+	// defaultDatabaseTypes.
+	allowedTypes []string
+
+	// maxListResults is AWSSecretsConfig.MaxListResults.
+	maxListResults int
+
+	// serveStale is AWSSecretsConfig.ServeStaleOnError; the cache then keeps
+	// expired entries.
+	serveStale bool
+
+	// fetches keeps cache misses to one Secrets Manager call per cache key.
+	fetches singleflight.Group
 }
 
 // SecretsManagerAPI defines the interface for AWS Secrets Manager operations
@@ -91,14 +180,20 @@ func NewAWSSecretsTenantStore(ctx context.Context, logger logger.Logger, cfg AWS
 	if cfg.Prefix == "" {
 		return nil, fmt.Errorf("AWS Secrets Manager prefix cannot be empty")
 	}
+	if cfg.RefreshThreshold < 0 || cfg.RefreshThreshold >= 1 {
+		return nil, fmt.Errorf("cache refresh threshold must be in [0, 1), got %v", cfg.RefreshThreshold)
+	}
 	// Load AWS configuration
 	awsConfig, err := loadAWSConfig(cfg, ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load AWS config: %w", err)
 	}
 
-	// Create Secrets Manager client
-	client := secretsmanager.NewFromConfig(awsConfig)
+	// Create Secrets Manager client. Retries are left to the retrying client,
+	// so the SDK makes a single attempt per call.
+	client := newRetryingClient(secretsmanager.NewFromConfig(awsConfig, func(o *secretsmanager.Options) {
+		o.RetryMaxAttempts = 1
+	}), cfg.RequestTimeout, cfg.MaxRetries, logger)
 
 	// Extract configuration from the config
 	prefix := cfg.Prefix
@@ -111,44 +206,126 @@ func NewAWSSecretsTenantStore(ctx context.Context, logger logger.Logger, cfg AWS
 	if cfg.MaxSize > 0 {
 		cacheMaxSize = cfg.MaxSize
 	}
+	negativeTTL := min(cacheTTL, ttlcache.DefaultNegativeTTL)
+	if cfg.NegativeTTL > 0 {
+		negativeTTL = cfg.NegativeTTL
+	}
+	maxConcurrency := defaultMaxConcurrency
+	if cfg.MaxConcurrency > 0 {
+		maxConcurrency = cfg.MaxConcurrency
+	}
 
 	logger.Info().
 		Str("prefix", prefix).
 		Dur("cache_ttl", cacheTTL).
 		Int("cache_max_size", cacheMaxSize).
+		Dur("cache_negative_ttl", negativeTTL).
+		Int("max_concurrency", maxConcurrency).
+		Dur("request_timeout", client.timeout).
+		Int("max_retries", client.maxRetries).
+		Interface("cache_refresh_threshold", cfg.RefreshThreshold).
+		Bool("cache_serve_stale_on_error", cfg.ServeStaleOnError).
 		Msg("Initializing AWS Secrets Manager tenant store")
 
+	cacheOpts := []ttlcache.Option{ttlcache.WithNegativeTTL(negativeTTL)}
+	if cfg.ServeStaleOnError {
+		cacheOpts = append(cacheOpts, ttlcache.WithKeepExpired())
+	}
+
 	return &AWSSecretsTenantStore{
-		client: client,
-		cache:  NewCache(cacheTTL, cacheMaxSize),
-		prefix: prefix,
-		logger: logger,
+		client:           client,
+		cache:            ttlcache.New(cacheTTL, cacheMaxSize, cacheOpts...),
+		prefix:           prefix,
+		maxConcurrency:   maxConcurrency,
+		logger:           logger,
+		refreshThreshold: cfg.RefreshThreshold,
+		allowedTypes:     cfg.AllowedTypes,
+		serveStale:       cfg.ServeStaleOnError,
+		maxListResults:   cfg.MaxListResults,
 	}, nil
 }
 
 // DBConfig implements the database.TenantStore interface
-// It retrieves database configuration for a specific tenant from AWS Secrets Manager
+// It retrieves database configuration for a specific tenant from AWS Secrets Manager.
+// Concurrent cache misses for the same tenant share a single fetch.
+// With a refresh threshold set, a cache hit close to expiry also starts a
+// background refresh, so active tenants are not left to expire.
 func (s *AWSSecretsTenantStore) DBConfig(ctx context.Context, tenantID string) (*gobricksConfig.DatabaseConfig, error) {
 	if tenantID == "" {
 		return nil, fmt.Errorf("tenant ID cannot be empty")
 	}
 
 	// Check cache first
-	cacheKey := fmt.Sprintf("db_%s", tenantID)
-	if cached := s.cache.Get(cacheKey); cached != nil {
+	cacheKey := dbCacheKey(tenantID)
+	cached, expiresAt := s.cache.GetWithExpiry(cacheKey)
+	if cached == ttlcache.NotFound {
+		return nil, fmt.Errorf("%w: %s (cached)", ErrTenantNotFound, tenantID)
+	}
+	if cached != nil {
 		s.logger.Debug().
 			Str("tenant_id", tenantID).
 			Msg("Retrieved database config from cache")
+		if s.dueForRefresh(expiresAt) {
+			s.refreshAhead(tenantID, cacheKey)
+		}
 		return cached.(*gobricksConfig.DatabaseConfig), nil
 	}
 
-	// Cache miss - fetch from AWS Secrets Manager
+	// Cache miss - fetch from AWS Secrets Manager, joining a fetch already in flight
 	s.logger.Debug().
 		Str("tenant_id", tenantID).
 		Msg("Cache miss - fetching database config from AWS Secrets Manager")
 
+	config, err := s.sharedFetch(ctx, cacheKey, func(ctx context.Context) (any, error) {
+		return s.loadDatabaseConfig(ctx, tenantID, cacheKey)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return config.(*gobricksConfig.DatabaseConfig), nil
+}
+
+// sharedFetch runs load once for all concurrent callers missing cacheKey and
+// hands each of them the result. load must cache what it fetched. The shared
+// fetch must not fail every waiter when the caller that started it gives up,
+// so it runs without that caller's cancellation, bounded by fetchTimeout.
+func (s *AWSSecretsTenantStore) sharedFetch(ctx context.Context, cacheKey string, load func(ctx context.Context) (any, error)) (any, error) {
+	result := s.fetches.DoChan(cacheKey, func() (any, error) {
+		fetchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), fetchTimeout)
+		defer cancel()
+		return load(fetchCtx)
+	})
+
+	select {
+	case res := <-result:
+		return res.Val, res.Err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// loadDatabaseConfig fetches a tenant's configuration after a cache miss and
+// caches the result, including a not-found result. When the fetch fails for
+// another reason and stale serving is on, it returns the expired cached
+// configuration instead, if there is one.
+func (s *AWSSecretsTenantStore) loadDatabaseConfig(ctx context.Context, tenantID, cacheKey string) (*gobricksConfig.DatabaseConfig, error) {
 	config, err := s.fetchDatabaseConfig(ctx, tenantID)
+	if errors.Is(err, ErrTenantNotFound) {
+		s.cache.SetNegative(cacheKey)
+		s.logger.Warn().
+			Err(err).
+			Str("tenant_id", tenantID).
+			Msg("Tenant has no database secret; caching not-found result")
+		return nil, err
+	}
 	if err != nil {
+		if stale, ok := s.staleDatabaseConfig(cacheKey); ok {
+			s.logger.Warn().
+				Err(err).
+				Str("tenant_id", tenantID).
+				Msg("Failed to fetch database config from AWS Secrets Manager; serving stale cached config")
+			return stale, nil
+		}
 		s.logger.Error().
 			Err(err).
 			Str("tenant_id", tenantID).
@@ -169,9 +346,164 @@ func (s *AWSSecretsTenantStore) DBConfig(ctx context.Context, tenantID string) (
 	return config, nil
 }
 
+// staleDatabaseConfig returns the expired configuration cached under
+// cacheKey when stale serving is enabled.
+func (s *AWSSecretsTenantStore) staleDatabaseConfig(cacheKey string) (*gobricksConfig.DatabaseConfig, bool) {
+	if !s.serveStale {
+		return nil, false
+	}
+	config, ok := s.cache.GetStale(cacheKey).(*gobricksConfig.DatabaseConfig)
+	return config, ok
+}
+
+// dueForRefresh reports whether an entry expiring at expiresAt is within the
+// refresh threshold of its TTL.
+func (s *AWSSecretsTenantStore) dueForRefresh(expiresAt time.Time) bool {
+	if s.refreshThreshold <= 0 {
+		return false
+	}
+	window := time.Duration(float64(s.cache.TTL()) * s.refreshThreshold)
+	return time.Until(expiresAt) <= window
+}
+
+// refreshAhead re-fetches a tenant's configuration in the background and
+// replaces the cached entry. A refresh already running for cacheKey is
+// joined rather than repeated. On failure the current entry is kept until
+// it expires, except that a deleted secret is cached as not found.
+func (s *AWSSecretsTenantStore) refreshAhead(tenantID, cacheKey string) {
+	s.refreshes.DoChan(cacheKey, func() (any, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), refreshTimeout)
+		defer cancel()
+
+		config, err := s.fetchDatabaseConfig(ctx, tenantID)
+		if errors.Is(err, ErrTenantNotFound) {
+			s.cache.SetNegative(cacheKey)
+			s.logger.Warn().
+				Err(err).
+				Str("tenant_id", tenantID).
+				Msg("Tenant database secret removed; caching not-found result")
+			return nil, err
+		}
+		if err != nil {
+			s.logger.Warn().
+				Err(err).
+				Str("tenant_id", tenantID).
+				Msg("Failed to refresh database config; keeping cached value until it expires")
+			return nil, err
+		}
+
+		s.cache.Set(cacheKey, config)
+		s.logger.Debug().
+			Str("tenant_id", tenantID).
+			Msg("Refreshed cached database config ahead of expiry")
+		return config, nil
+	})
+}
+
+// BatchDBConfig resolves database configuration for several tenants concurrently.
+// Each lookup goes through DBConfig, so cached tenants cost nothing. At most
+// MaxConcurrency fetches are in flight at once to avoid Secrets Manager throttling.
+// Tenants that fail are omitted from the result and reported in the joined error.
+func (s *AWSSecretsTenantStore) BatchDBConfig(ctx context.Context, tenantIDs []string) (map[string]*gobricksConfig.DatabaseConfig, error) {
+	results, failures := s.fetchAll(ctx, tenantIDs)
+
+	var errs []error
+	for _, tenantID := range tenantIDs {
+		if err, ok := failures[tenantID]; ok {
+			errs = append(errs, err)
+		}
+	}
+	return results, errors.Join(errs...)
+}
+
+// WarmResult summarizes a cache warm-up run.
+type WarmResult struct {
+	// Loaded is the number of tenants whose configuration is now cached.
+	Loaded int
+	// Failed maps tenant IDs to the error that prevented them from loading.
+	Failed map[string]error
+}
+
+// WarmCache lists every tenant in Secrets Manager and loads its database
+// configuration into the cache, bounded by MaxConcurrency. Per-tenant failures
+// are reported in the result; only a failure to list tenants returns an error.
+func (s *AWSSecretsTenantStore) WarmCache(ctx context.Context) (*WarmResult, error) {
+	tenants, err := s.ListTenants(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tenants for cache warm-up: %w", err)
+	}
+
+	configs, failures := s.fetchAll(ctx, tenants)
+	for tenantID, err := range failures {
+		s.logger.Warn().
+			Err(err).
+			Str("tenant_id", tenantID).
+			Msg("Failed to warm tenant cache entry")
+	}
+
+	s.logger.Info().
+		Int("tenant_count", len(tenants)).
+		Int("loaded", len(configs)).
+		Int("failed", len(failures)).
+		Msg("Warmed tenant cache")
+
+	return &WarmResult{Loaded: len(configs), Failed: failures}, nil
+}
+
+// fetchAll runs DBConfig for each tenant on a worker pool of MaxConcurrency
+// goroutines. Tenants not dispatched before ctx is done fail with ctx.Err().
+func (s *AWSSecretsTenantStore) fetchAll(ctx context.Context, tenantIDs []string) (map[string]*gobricksConfig.DatabaseConfig, map[string]error) {
+	results := make(map[string]*gobricksConfig.DatabaseConfig, len(tenantIDs))
+	failures := make(map[string]error)
+
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+	sem := make(chan struct{}, s.concurrencyLimit())
+
+	for _, tenantID := range tenantIDs {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			mu.Lock()
+			failures[tenantID] = ctx.Err()
+			mu.Unlock()
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			config, err := s.DBConfig(ctx, tenantID)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				failures[tenantID] = err
+				return
+			}
+			results[tenantID] = config
+		}()
+	}
+
+	wg.Wait()
+	return results, failures
+}
+
+// concurrencyLimit returns the configured fetch concurrency, falling back to the default
+func (s *AWSSecretsTenantStore) concurrencyLimit() int {
+	if s.maxConcurrency > 0 {
+		return s.maxConcurrency
+	}
+	return defaultMaxConcurrency
+}
+
 // fetchDatabaseConfig retrieves and parses database configuration from AWS Secrets Manager
 func (s *AWSSecretsTenantStore) fetchDatabaseConfig(ctx context.Context, tenantID string) (*gobricksConfig.DatabaseConfig, error) {
-	secretName := s.buildSecretName(tenantID, "database")
+	secretName := s.buildSecretName(tenantID, databaseConfigType)
 
 	// Fetch secret from AWS Secrets Manager
 	input := &secretsmanager.GetSecretValueInput{
@@ -180,13 +512,18 @@ func (s *AWSSecretsTenantStore) fetchDatabaseConfig(ctx context.Context, tenantI
 
 	result, err := s.client.GetSecretValue(ctx, input)
 	if err != nil {
-		// Check if it's a resource not found error
-		var notFoundError *types.InvalidParameterException
+		// Only a missing secret means the tenant does not exist; every other
+		// failure may be transient and must not be reported as not found.
+		var missingError *types.ResourceNotFoundException
+		var invalidParameterError *types.InvalidParameterException
 		var decryptError *types.DecryptionFailure
 		var internalServiceError *types.InternalServiceError
 		var invalidRequestError *types.InvalidRequestException
-		if errors.As(err, &notFoundError) {
-			return nil, fmt.Errorf("secret not found for tenant %s (secret: %s): %w", tenantID, secretName, err)
+		if errors.As(err, &missingError) {
+			return nil, fmt.Errorf("%w: no secret for tenant %s (secret: %s): %w", ErrTenantNotFound, tenantID, secretName, err)
+		}
+		if errors.As(err, &invalidParameterError) {
+			return nil, fmt.Errorf("invalid secret request for tenant %s (secret: %s): %w", tenantID, secretName, err)
 		}
 		if errors.As(err, &decryptError) || errors.As(err, &internalServiceError) || errors.As(err, &invalidRequestError) {
 			return nil, fmt.Errorf("error retrieving secret for tenant %s (secret: %s): %w", tenantID, secretName, err)
@@ -206,11 +543,15 @@ func (s *AWSSecretsTenantStore) fetchDatabaseConfig(ctx context.Context, tenantI
 	}
 
 	// Convert to go-bricks DatabaseConfig
-	return s.toDatabaseConfig(&secretConfig), nil
+	config := toDatabaseConfig(&secretConfig)
+	if err := validateDatabaseConfig(tenantID, config, s.allowedTypes); err != nil {
+		return nil, err
+	}
+	return config, nil
 }
 
 // toDatabaseConfig converts SecretDatabaseConfig to go-bricks DatabaseConfig
-func (s *AWSSecretsTenantStore) toDatabaseConfig(secret *SecretDatabaseConfig) *gobricksConfig.DatabaseConfig {
+func toDatabaseConfig(secret *SecretDatabaseConfig) *gobricksConfig.DatabaseConfig {
 	config := &gobricksConfig.DatabaseConfig{
 		Type:     secret.Type,
 		Host:     secret.Host,
@@ -267,17 +608,62 @@ func (s *AWSSecretsTenantStore) toDatabaseConfig(secret *SecretDatabaseConfig) *
 	return config
 }
 
+// validateDatabaseConfig checks that config names an allowed database type, a
+// host and a valid port, so a malformed secret fails when it is read rather
+// than on the first connection. An empty allowedTypes means
+// defaultDatabaseTypes.
+func validateDatabaseConfig(tenantID string, config *gobricksConfig.DatabaseConfig, allowedTypes []string) error {
+	if len(allowedTypes) == 0 {
+		allowedTypes = defaultDatabaseTypes
+	}
+	if !slices.Contains(allowedTypes, config.Type) {
+		return fmt.Errorf("%w: tenant %s: field type: %q is not one of %s", ErrInvalidTenantConfig, tenantID, config.Type, strings.Join(allowedTypes, ", "))
+	}
+	if strings.TrimSpace(config.Host) == "" {
+		return fmt.Errorf("%w: tenant %s: field host: must not be empty", ErrInvalidTenantConfig, tenantID)
+	}
+	if config.Port < 1 || config.Port > 65535 {
+		return fmt.Errorf("%w: tenant %s: field port: %d is not between 1 and 65535", ErrInvalidTenantConfig, tenantID, config.Port)
+	}
+	return nil
+}
+
+// dbCacheKey is the cache key of a tenant's database configuration.
+func dbCacheKey(tenantID string) string {
+	return fmt.Sprintf("db_%s", tenantID)
+}
+
 // buildSecretName constructs the full secret name based on prefix, tenant ID, and config type
 func (s *AWSSecretsTenantStore) buildSecretName(tenantID, configType string) string {
 	return fmt.Sprintf("%s/%s/%s", s.prefix, tenantID, configType)
 }
 
-// ListTenants returns a list of all configured tenants by listing secrets with the correct prefix
+// ListTenants returns the tenants with a database secret under the prefix,
+// up to AWSSecretsConfig.MaxListResults. A truncated list is logged; use
+// ListTenantsLimited to be told about it.
 func (s *AWSSecretsTenantStore) ListTenants(ctx context.Context) ([]string, error) {
+	tenants, truncated, err := s.ListTenantsLimited(ctx, s.maxListResults)
+	if err != nil {
+		return nil, err
+	}
+	if truncated {
+		s.logger.Warn().
+			Int("max_results", s.maxListResults).
+			Msg("Tenant list truncated; raise custom.aws.secrets.list.max.results to list every tenant")
+	}
+	return tenants, nil
+}
+
+// ListTenantsLimited lists the tenants with a database secret under the
+// prefix, paging through ListSecrets until maxResults tenants are found.
+// truncated reports that paging stopped there with secrets left unread. A
+// maxResults of zero or less lists every tenant.
+func (s *AWSSecretsTenantStore) ListTenantsLimited(ctx context.Context, maxResults int) (tenants []string, truncated bool, err error) {
 	prefix := fmt.Sprintf("%s/", s.prefix)
+	start := time.Now()
 
-	var tenants []string
 	var nextToken *string
+	pages := 0
 
 	for {
 		input := &secretsmanager.ListSecretsInput{
@@ -295,39 +681,83 @@ func (s *AWSSecretsTenantStore) ListTenants(ctx context.Context) ([]string, erro
 
 		result, err := s.client.ListSecrets(ctx, input)
 		if err != nil {
-			return nil, fmt.Errorf("failed to list secrets: %w", err)
+			return nil, false, fmt.Errorf("failed to list secrets: %w", err)
 		}
+		pages++
 
 		for _, secret := range result.SecretList {
-			if secret.Name != nil && strings.HasSuffix(*secret.Name, "/database") {
-				// Extract tenant ID from secret name
-				secretName := *secret.Name
-				tenantPart := strings.TrimPrefix(secretName, prefix)
-				tenantID := strings.TrimSuffix(tenantPart, "/database")
-				if tenantID != "" {
-					tenants = append(tenants, tenantID)
-				}
+			tenantID, ok := tenantIDFromSecretName(prefix, aws.ToString(secret.Name))
+			if !ok {
+				continue
+			}
+			if maxResults > 0 && len(tenants) == maxResults {
+				truncated = true
+				break
 			}
+			tenants = append(tenants, tenantID)
 		}
 
 		if result.NextToken == nil {
 			break
 		}
+		if truncated || (maxResults > 0 && len(tenants) == maxResults) {
+			truncated = true
+			break
+		}
 		nextToken = result.NextToken
 	}
 
 	s.logger.Debug().
 		Int("tenant_count", len(tenants)).
 		Str("tenants", strings.Join(tenants, ", ")).
+		Int("pages", pages).
+		Dur("duration", time.Since(start)).
+		Bool("truncated", truncated).
 		Msg("Listed tenants from AWS Secrets Manager")
 
-	return tenants, nil
+	return tenants, truncated, nil
+}
+
+// tenantIDFromSecretName returns the tenant of a <prefix><tenant>/database
+// secret name. Other secrets, including ones whose tenant part would contain
+// a slash, are not tenant database secrets.
+func tenantIDFromSecretName(prefix, name string) (string, bool) {
+	rest, ok := strings.CutPrefix(name, prefix)
+	if !ok {
+		return "", false
+	}
+	tenantID, ok := strings.CutSuffix(rest, "/"+databaseConfigType)
+	if !ok || tenantID == "" || strings.Contains(tenantID, "/") {
+		return "", false
+	}
+	return tenantID, true
+}
+
+// HealthCheck verifies that AWS Secrets Manager is reachable with the store's
+// credentials. It issues a single ListSecrets call limited to one result, which
+// is cheap and does not read any secret value.
+func (s *AWSSecretsTenantStore) HealthCheck(ctx context.Context) error {
+	input := &secretsmanager.ListSecretsInput{
+		MaxResults: aws.Int32(1),
+		Filters: []types.Filter{
+			{
+				Key:    types.FilterNameStringTypeName,
+				Values: []string{fmt.Sprintf("%s/", s.prefix)},
+			},
+		},
+	}
+
+	if _, err := s.client.ListSecrets(ctx, input); err != nil {
+		return fmt.Errorf("tenant store unreachable: %w", err)
+	}
+	return nil
 }
 
-// InvalidateCache removes a specific tenant's configuration from the cache
+// InvalidateCache removes a specific tenant's database and messaging
+// configuration from the cache
 func (s *AWSSecretsTenantStore) InvalidateCache(tenantID string) {
-	cacheKey := fmt.Sprintf("db_%s", tenantID)
-	s.cache.Delete(cacheKey)
+	s.cache.Delete(dbCacheKey(tenantID))
+	s.cache.Delete(messagingCacheKey(tenantID))
 	s.logger.Debug().
 		Str("tenant_id", tenantID).
 		Msg("Invalidated tenant cache")
@@ -340,7 +770,7 @@ func (s *AWSSecretsTenantStore) ClearCache() {
 }
 
 // CacheMetrics returns current cache performance metrics
-func (s *AWSSecretsTenantStore) CacheMetrics() CacheMetrics {
+func (s *AWSSecretsTenantStore) CacheMetrics() ttlcache.Metrics {
 	return s.cache.Metrics()
 }
 
@@ -0,0 +1,540 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/shared/ttlcache"
+	gobricksConfig "github.com/gaborage/go-bricks/config"
+	"github.com/gaborage/go-bricks/logger"
+)
+
+const (
+	testPrefix       = "demo"
+	testSecretString = `{"type":"postgresql","host":"localhost","port":5432,"database":"db","username":"user","password":"password"}`
+)
+
+// fakeSecretsManager implements SecretsManagerAPI with overridable behaviour
+type fakeSecretsManager struct {
+	getSecretValueFunc func(ctx context.Context, params *secretsmanager.GetSecretValueInput) (*secretsmanager.GetSecretValueOutput, error)
+	listSecretsFunc    func(ctx context.Context, params *secretsmanager.ListSecretsInput) (*secretsmanager.ListSecretsOutput, error)
+}
+
+func (f *fakeSecretsManager) GetSecretValue(ctx context.Context, params *secretsmanager.GetSecretValueInput, _ ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error) {
+	if f.getSecretValueFunc != nil {
+		return f.getSecretValueFunc(ctx, params)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (f *fakeSecretsManager) ListSecrets(ctx context.Context, params *secretsmanager.ListSecretsInput, _ ...func(*secretsmanager.Options)) (*secretsmanager.ListSecretsOutput, error) {
+	if f.listSecretsFunc != nil {
+		return f.listSecretsFunc(ctx, params)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func newTestStore(t *testing.T, client SecretsManagerAPI) *AWSSecretsTenantStore {
+	t.Helper()
+
+	store := &AWSSecretsTenantStore{
+		client: client,
+		cache:  ttlcache.New(time.Minute, 10),
+		prefix: testPrefix,
+		logger: logger.New("info", false),
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	return store
+}
+
+func TestAWSSecretsTenantStoreHealthCheck(t *testing.T) {
+	unreachable := errors.New("dial tcp: connection refused")
+
+	tests := []struct {
+		name    string
+		listErr error
+		wantErr bool
+	}{
+		{name: "healthy", listErr: nil, wantErr: false},
+		{name: "unreachable", listErr: unreachable, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int
+			client := &fakeSecretsManager{
+				listSecretsFunc: func(_ context.Context, params *secretsmanager.ListSecretsInput) (*secretsmanager.ListSecretsOutput, error) {
+					calls++
+					if params.MaxResults == nil || *params.MaxResults != 1 {
+						t.Errorf("HealthCheck() MaxResults = %v, want 1", params.MaxResults)
+					}
+					if tt.listErr != nil {
+						return nil, tt.listErr
+					}
+					return &secretsmanager.ListSecretsOutput{}, nil
+				},
+			}
+			store := newTestStore(t, client)
+
+			err := store.HealthCheck(context.Background())
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("HealthCheck() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(err, unreachable) {
+				t.Errorf("HealthCheck() error = %v, want wrapped %v", err, unreachable)
+			}
+			if calls != 1 {
+				t.Errorf("ListSecrets called %d times, want 1", calls)
+			}
+		})
+	}
+}
+
+func TestAWSSecretsTenantStoreValidatesDatabaseConfig(t *testing.T) {
+	tests := []struct {
+		name         string
+		secret       string
+		allowedTypes []string
+		wantField    string
+	}{
+		{name: "valid", secret: testSecretString},
+		{name: "unknown type", secret: `{"type":"mysql","host":"localhost","port":3306}`, wantField: "field type"},
+		{name: "configured type", secret: `{"type":"mysql","host":"localhost","port":3306}`, allowedTypes: []string{"postgresql", "mysql"}},
+		{name: "empty host", secret: `{"type":"postgresql","host":" ","port":5432}`, wantField: "field host"},
+		{name: "missing port", secret: `{"type":"postgresql","host":"localhost"}`, wantField: "field port"},
+		{name: "port out of range", secret: `{"type":"oracle","host":"localhost","port":70000}`, wantField: "field port"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeSecretsManager{
+				getSecretValueFunc: func(_ context.Context, _ *secretsmanager.GetSecretValueInput) (*secretsmanager.GetSecretValueOutput, error) {
+					return &secretsmanager.GetSecretValueOutput{SecretString: aws.String(tt.secret)}, nil
+				},
+			}
+			store := newTestStore(t, client)
+			store.allowedTypes = tt.allowedTypes
+
+			config, err := store.DBConfig(context.Background(), "tenant1")
+
+			if tt.wantField == "" {
+				if err != nil {
+					t.Fatalf("DBConfig() unexpected error = %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidTenantConfig) {
+				t.Fatalf("DBConfig() = %+v, %v, want ErrInvalidTenantConfig", config, err)
+			}
+			if !strings.Contains(err.Error(), "tenant tenant1") || !strings.Contains(err.Error(), tt.wantField) {
+				t.Errorf("DBConfig() error = %q, want it to name the tenant and %s", err, tt.wantField)
+			}
+		})
+	}
+}
+
+func TestAWSSecretsTenantStoreBatchDBConfigBoundsConcurrency(t *testing.T) {
+	const (
+		tenantCount    = 20
+		maxConcurrency = 3
+	)
+
+	var inFlight, maxInFlight atomic.Int32
+	client := &fakeSecretsManager{
+		getSecretValueFunc: func(_ context.Context, _ *secretsmanager.GetSecretValueInput) (*secretsmanager.GetSecretValueOutput, error) {
+			current := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			for {
+				seen := maxInFlight.Load()
+				if current <= seen || maxInFlight.CompareAndSwap(seen, current) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			return &secretsmanager.GetSecretValueOutput{SecretString: aws.String(testSecretString)}, nil
+		},
+	}
+	store := newTestStore(t, client)
+	store.maxConcurrency = maxConcurrency
+
+	tenantIDs := make([]string, tenantCount)
+	for i := range tenantIDs {
+		tenantIDs[i] = fmt.Sprintf("tenant%d", i)
+	}
+
+	configs, err := store.BatchDBConfig(context.Background(), tenantIDs)
+	if err != nil {
+		t.Fatalf("BatchDBConfig() unexpected error = %v", err)
+	}
+	if len(configs) != tenantCount {
+		t.Errorf("BatchDBConfig() returned %d configs, want %d", len(configs), tenantCount)
+	}
+	if got := maxInFlight.Load(); got > maxConcurrency {
+		t.Errorf("BatchDBConfig() had %d fetches in flight, want at most %d", got, maxConcurrency)
+	}
+}
+
+func TestAWSSecretsTenantStoreBatchDBConfigPartialFailure(t *testing.T) {
+	client := &fakeSecretsManager{
+		getSecretValueFunc: func(_ context.Context, params *secretsmanager.GetSecretValueInput) (*secretsmanager.GetSecretValueOutput, error) {
+			if *params.SecretId == testPrefix+"/broken/database" {
+				return nil, errors.New("access denied")
+			}
+			return &secretsmanager.GetSecretValueOutput{SecretString: aws.String(testSecretString)}, nil
+		},
+	}
+	store := newTestStore(t, client)
+
+	configs, err := store.BatchDBConfig(context.Background(), []string{"tenant1", "broken", "tenant2"})
+
+	if err == nil {
+		t.Fatal("BatchDBConfig() expected error for broken tenant, got nil")
+	}
+	if len(configs) != 2 {
+		t.Errorf("BatchDBConfig() returned %d configs, want 2", len(configs))
+	}
+	if _, ok := configs["broken"]; ok {
+		t.Error("BatchDBConfig() returned config for failing tenant")
+	}
+}
+
+func TestAWSSecretsTenantStoreCachesMissingTenant(t *testing.T) {
+	var calls atomic.Int32
+	client := &fakeSecretsManager{
+		getSecretValueFunc: func(_ context.Context, _ *secretsmanager.GetSecretValueInput) (*secretsmanager.GetSecretValueOutput, error) {
+			calls.Add(1)
+			return nil, &types.ResourceNotFoundException{Message: aws.String("secret not found")}
+		},
+	}
+	store := newTestStore(t, client)
+
+	for range 3 {
+		if _, err := store.DBConfig(context.Background(), "unknown"); !errors.Is(err, ErrTenantNotFound) {
+			t.Fatalf("DBConfig() error = %v, want ErrTenantNotFound", err)
+		}
+	}
+
+	if got := calls.Load(); got != 1 {
+		t.Errorf("GetSecretValue called %d times, want 1", got)
+	}
+	if metrics := store.CacheMetrics(); metrics.NegativeHits != 2 {
+		t.Errorf("CacheMetrics().NegativeHits = %d, want 2", metrics.NegativeHits)
+	}
+}
+
+func TestAWSSecretsTenantStoreClassifiesFetchErrors(t *testing.T) {
+	tests := []struct {
+		name         string
+		err          error
+		wantNotFound bool
+	}{
+		{name: "missing secret", err: &types.ResourceNotFoundException{Message: aws.String("secret not found")}, wantNotFound: true},
+		{name: "invalid parameter", err: &types.InvalidParameterException{Message: aws.String("bad secret id")}},
+		{name: "internal service error", err: &types.InternalServiceError{Message: aws.String("try again")}},
+		{name: "network error", err: errors.New("dial tcp: i/o timeout")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeSecretsManager{
+				getSecretValueFunc: func(_ context.Context, _ *secretsmanager.GetSecretValueInput) (*secretsmanager.GetSecretValueOutput, error) {
+					return nil, tt.err
+				},
+			}
+			store := newTestStore(t, client)
+
+			_, err := store.DBConfig(context.Background(), "tenant1")
+
+			if got := errors.Is(err, ErrTenantNotFound); got != tt.wantNotFound {
+				t.Errorf("errors.Is(DBConfig() error, ErrTenantNotFound) = %v, want %v (error: %v)", got, tt.wantNotFound, err)
+			}
+			if !errors.Is(err, tt.err) {
+				t.Errorf("DBConfig() error = %v, want it to wrap the AWS error", err)
+			}
+		})
+	}
+}
+
+func TestAWSSecretsTenantStoreServeStaleOnError(t *testing.T) {
+	unavailable := &types.InternalServiceError{Message: aws.String("service unavailable")}
+
+	tests := []struct {
+		name       string
+		serveStale bool
+		fetchErr   error
+		wantStale  bool
+		wantErr    error
+	}{
+		{name: "transient failure served stale", serveStale: true, fetchErr: unavailable, wantStale: true},
+		{name: "transient failure without stale serving", fetchErr: unavailable, wantErr: unavailable},
+		{name: "deleted secret", serveStale: true, fetchErr: &types.ResourceNotFoundException{Message: aws.String("secret not found")}, wantErr: ErrTenantNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var failing atomic.Bool
+			client := &fakeSecretsManager{
+				getSecretValueFunc: func(_ context.Context, _ *secretsmanager.GetSecretValueInput) (*secretsmanager.GetSecretValueOutput, error) {
+					if failing.Load() {
+						return nil, tt.fetchErr
+					}
+					return &secretsmanager.GetSecretValueOutput{SecretString: aws.String(testSecretString)}, nil
+				},
+			}
+			store := newTestStore(t, client)
+			store.cache.Close()
+			store.cache = ttlcache.New(10*time.Millisecond, 10, ttlcache.WithKeepExpired())
+			store.serveStale = tt.serveStale
+
+			fresh, err := store.DBConfig(context.Background(), "tenant1")
+			if err != nil {
+				t.Fatalf("DBConfig() unexpected error = %v", err)
+			}
+			failing.Store(true)
+			time.Sleep(20 * time.Millisecond)
+
+			config, err := store.DBConfig(context.Background(), "tenant1")
+
+			if tt.wantStale {
+				if err != nil || config != fresh {
+					t.Errorf("DBConfig() = %+v, %v, want the stale config", config, err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("DBConfig() = %+v, %v, want error %v", config, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAWSSecretsTenantStoreDeduplicatesConcurrentMisses(t *testing.T) {
+	const callers = 10
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	client := &fakeSecretsManager{
+		getSecretValueFunc: func(_ context.Context, _ *secretsmanager.GetSecretValueInput) (*secretsmanager.GetSecretValueOutput, error) {
+			calls.Add(1)
+			<-release
+			return &secretsmanager.GetSecretValueOutput{SecretString: aws.String(testSecretString)}, nil
+		},
+	}
+	store := newTestStore(t, client)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, callers)
+	for range callers {
+		wg.Go(func() {
+			config, err := store.DBConfig(context.Background(), "tenant1")
+			if err == nil && config.Host != "localhost" {
+				err = fmt.Errorf("host = %q, want localhost", config.Host)
+			}
+			errs <- err
+		})
+	}
+
+	// Hold the first fetch until every caller has missed the cache and joined it.
+	deadline := time.Now().Add(time.Second)
+	for store.CacheMetrics().Misses < callers {
+		if time.Now().After(deadline) {
+			t.Fatalf("only %d of %d callers missed the cache", store.CacheMetrics().Misses, callers)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	// A miss is counted just before the caller joins the fetch.
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("DBConfig() unexpected error = %v", err)
+		}
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("GetSecretValue called %d times, want 1", got)
+	}
+	if _, err := store.DBConfig(context.Background(), "tenant1"); err != nil || calls.Load() != 1 {
+		t.Errorf("DBConfig() after the shared fetch = %v with %d fetches, want a cache hit", err, calls.Load())
+	}
+}
+
+func TestAWSSecretsTenantStoreWarmCache(t *testing.T) {
+	var fetches atomic.Int32
+	client := &fakeSecretsManager{
+		listSecretsFunc: func(_ context.Context, _ *secretsmanager.ListSecretsInput) (*secretsmanager.ListSecretsOutput, error) {
+			return &secretsmanager.ListSecretsOutput{SecretList: []types.SecretListEntry{
+				{Name: aws.String(testPrefix + "/tenant1/database")},
+				{Name: aws.String(testPrefix + "/broken/database")},
+				{Name: aws.String(testPrefix + "/tenant2/database")},
+			}}, nil
+		},
+		getSecretValueFunc: func(_ context.Context, params *secretsmanager.GetSecretValueInput) (*secretsmanager.GetSecretValueOutput, error) {
+			fetches.Add(1)
+			if *params.SecretId == testPrefix+"/broken/database" {
+				return nil, errors.New("access denied")
+			}
+			return &secretsmanager.GetSecretValueOutput{SecretString: aws.String(testSecretString)}, nil
+		},
+	}
+	store := newTestStore(t, client)
+
+	result, err := store.WarmCache(context.Background())
+	if err != nil {
+		t.Fatalf("WarmCache() unexpected error = %v", err)
+	}
+	if result.Loaded != 2 {
+		t.Errorf("WarmCache() Loaded = %d, want 2", result.Loaded)
+	}
+	if len(result.Failed) != 1 || result.Failed["broken"] == nil {
+		t.Errorf("WarmCache() Failed = %v, want only broken", result.Failed)
+	}
+
+	// Warmed tenants are served from the cache.
+	before := fetches.Load()
+	for _, tenantID := range []string{"tenant1", "tenant2"} {
+		if _, err := store.DBConfig(context.Background(), tenantID); err != nil {
+			t.Fatalf("DBConfig(%s) unexpected error = %v", tenantID, err)
+		}
+	}
+	if got := fetches.Load(); got != before {
+		t.Errorf("GetSecretValue called %d more times after warm-up, want 0", got-before)
+	}
+}
+
+func TestAWSSecretsTenantStoreWarmCacheListFailure(t *testing.T) {
+	client := &fakeSecretsManager{
+		listSecretsFunc: func(_ context.Context, _ *secretsmanager.ListSecretsInput) (*secretsmanager.ListSecretsOutput, error) {
+			return nil, errors.New("throttled")
+		},
+	}
+	store := newTestStore(t, client)
+
+	if result, err := store.WarmCache(context.Background()); err == nil {
+		t.Fatalf("WarmCache() = %+v, want error when tenants cannot be listed", result)
+	}
+}
+
+func TestAWSSecretsTenantStoreListTenantsLimited(t *testing.T) {
+	pages := [][]string{
+		{testPrefix + "/tenant1/database", testPrefix + "/tenant1/messaging", testPrefix + "/a/b/database"},
+		{"other/tenant9/database", testPrefix + "//database", testPrefix + "/tenant2/database"},
+		{testPrefix + "/tenant3/database"},
+	}
+
+	tests := []struct {
+		name          string
+		maxResults    int
+		wantTenants   []string
+		wantTruncated bool
+		wantPages     int32
+	}{
+		{name: "unlimited", maxResults: 0, wantTenants: []string{"tenant1", "tenant2", "tenant3"}, wantPages: 3},
+		{name: "cap inside a page", maxResults: 1, wantTenants: []string{"tenant1"}, wantTruncated: true, wantPages: 1},
+		{name: "cap at a page boundary", maxResults: 2, wantTenants: []string{"tenant1", "tenant2"}, wantTruncated: true, wantPages: 2},
+		{name: "cap above the tenant count", maxResults: 10, wantTenants: []string{"tenant1", "tenant2", "tenant3"}, wantPages: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			client := &fakeSecretsManager{
+				listSecretsFunc: func(_ context.Context, params *secretsmanager.ListSecretsInput) (*secretsmanager.ListSecretsOutput, error) {
+					page := int(calls.Add(1)) - 1
+					if got := aws.ToString(params.NextToken); page > 0 && got != fmt.Sprintf("page-%d", page) {
+						t.Errorf("ListSecrets() NextToken = %q on call %d", got, page+1)
+					}
+					output := &secretsmanager.ListSecretsOutput{}
+					for _, name := range pages[page] {
+						output.SecretList = append(output.SecretList, types.SecretListEntry{Name: aws.String(name)})
+					}
+					if page+1 < len(pages) {
+						output.NextToken = aws.String(fmt.Sprintf("page-%d", page+1))
+					}
+					return output, nil
+				},
+			}
+			store := newTestStore(t, client)
+
+			tenants, truncated, err := store.ListTenantsLimited(context.Background(), tt.maxResults)
+			if err != nil {
+				t.Fatalf("ListTenantsLimited() unexpected error = %v", err)
+			}
+			if !slices.Equal(tenants, tt.wantTenants) || truncated != tt.wantTruncated {
+				t.Errorf("ListTenantsLimited() = %v, %v, want %v, %v", tenants, truncated, tt.wantTenants, tt.wantTruncated)
+			}
+			if got := calls.Load(); got != tt.wantPages {
+				t.Errorf("ListTenantsLimited() fetched %d pages, want %d", got, tt.wantPages)
+			}
+		})
+	}
+}
+
+func TestAWSSecretsTenantStoreRefreshAhead(t *testing.T) {
+	const refreshedHost = "refreshed-host"
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	client := &fakeSecretsManager{
+		getSecretValueFunc: func(_ context.Context, _ *secretsmanager.GetSecretValueInput) (*secretsmanager.GetSecretValueOutput, error) {
+			if calls.Add(1) == 1 {
+				return &secretsmanager.GetSecretValueOutput{SecretString: aws.String(testSecretString)}, nil
+			}
+			// Hold the refresh so every read below finds it in flight.
+			<-release
+			secret := `{"type":"postgresql","host":"` + refreshedHost + `","port":5432,"database":"db","username":"user","password":"password"}`
+			return &secretsmanager.GetSecretValueOutput{SecretString: aws.String(secret)}, nil
+		},
+	}
+	store := newTestStore(t, client)
+	store.cache.Close()
+	store.cache = ttlcache.New(200*time.Millisecond, 10)
+	store.refreshThreshold = 0.9
+
+	ctx := context.Background()
+	if _, err := store.DBConfig(ctx, "tenant1"); err != nil {
+		t.Fatalf("DBConfig() unexpected error = %v", err)
+	}
+
+	// Past 10% of the TTL every hit is inside the refresh window.
+	time.Sleep(30 * time.Millisecond)
+	for range 5 {
+		config, err := store.DBConfig(ctx, "tenant1")
+		if err != nil {
+			t.Fatalf("DBConfig() unexpected error = %v", err)
+		}
+		if config.Host != "localhost" {
+			t.Errorf("DBConfig() host = %q during refresh, want the cached localhost", config.Host)
+		}
+	}
+	close(release)
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		if config, ok := store.cache.Get("db_tenant1").(*gobricksConfig.DatabaseConfig); ok && config.Host == refreshedHost {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("cached config was not refreshed")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if got := calls.Load(); got != 2 {
+		t.Errorf("GetSecretValue called %d times, want 2 (initial fetch and one refresh)", got)
+	}
+}
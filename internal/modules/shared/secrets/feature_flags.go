@@ -0,0 +1,114 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/shared/ttlcache"
+	"github.com/gaborage/go-bricks/logger"
+)
+
+// featuresConfigType is the secret name suffix holding a tenant's feature flags.
+const featuresConfigType = "features"
+
+// FeatureFlagStore reads per-tenant feature flags from the secrets backend.
+// Each tenant's flags live in <prefix>/<tenant>/features as a JSON object of
+// flag name to boolean. Tenants without that secret have every flag off.
+type FeatureFlagStore struct {
+	client SecretsManagerAPI
+	cache  *ttlcache.Cache
+	prefix string
+	logger logger.Logger
+}
+
+// NewFeatureFlagStore creates a feature flag store. The cache may be shared
+// with an AWSSecretsTenantStore; flag entries use their own key namespace.
+func NewFeatureFlagStore(client SecretsManagerAPI, cache *ttlcache.Cache, prefix string, l logger.Logger) *FeatureFlagStore {
+	return &FeatureFlagStore{
+		client: client,
+		cache:  cache,
+		prefix: prefix,
+		logger: l,
+	}
+}
+
+// FeatureFlags returns a flag store that shares this tenant store's client,
+// prefix and cache, so ClearCache also drops cached flags.
+func (s *AWSSecretsTenantStore) FeatureFlags() *FeatureFlagStore {
+	return NewFeatureFlagStore(s.client, s.cache, s.prefix, s.logger)
+}
+
+// IsEnabled reports whether flag is on for the tenant. Unknown flags are off.
+func (s *FeatureFlagStore) IsEnabled(ctx context.Context, tenantID, flag string) (bool, error) {
+	flags, err := s.Flags(ctx, tenantID)
+	if err != nil {
+		return false, err
+	}
+	return flags[flag], nil
+}
+
+// Flags returns every flag configured for the tenant. The result is cached
+// and must not be modified.
+func (s *FeatureFlagStore) Flags(ctx context.Context, tenantID string) (map[string]bool, error) {
+	if tenantID == "" {
+		return nil, fmt.Errorf("tenant ID cannot be empty")
+	}
+
+	cacheKey := fmt.Sprintf("features_%s", tenantID)
+	if cached := s.cache.Get(cacheKey); cached != nil {
+		return cached.(map[string]bool), nil
+	}
+
+	flags, err := s.fetchFlags(ctx, tenantID)
+	if err != nil {
+		s.logger.Error().
+			Err(err).
+			Str("tenant_id", tenantID).
+			Msg("Failed to fetch feature flags from AWS Secrets Manager")
+		return nil, err
+	}
+
+	s.cache.Set(cacheKey, flags)
+	return flags, nil
+}
+
+// Invalidate drops the tenant's cached flags so the next lookup refetches them.
+func (s *FeatureFlagStore) Invalidate(tenantID string) {
+	s.cache.Delete(fmt.Sprintf("features_%s", tenantID))
+}
+
+// fetchFlags reads and parses the tenant's flags secret. A missing secret
+// yields an empty set rather than an error.
+func (s *FeatureFlagStore) fetchFlags(ctx context.Context, tenantID string) (map[string]bool, error) {
+	secretName := fmt.Sprintf("%s/%s/%s", s.prefix, tenantID, featuresConfigType)
+
+	result, err := s.client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(secretName),
+	})
+	if err != nil {
+		var notFound *types.ResourceNotFoundException
+		if errors.As(err, &notFound) {
+			s.logger.Debug().
+				Str("tenant_id", tenantID).
+				Msg("No feature flags secret for tenant - all flags off")
+			return map[string]bool{}, nil
+		}
+		return nil, fmt.Errorf("failed to retrieve feature flags for tenant %s: %w", tenantID, err)
+	}
+
+	if result.SecretString == nil {
+		return map[string]bool{}, nil
+	}
+
+	flags := map[string]bool{}
+	if err := json.Unmarshal([]byte(*result.SecretString), &flags); err != nil {
+		return nil, fmt.Errorf("failed to parse feature flags JSON for tenant %s: %w", tenantID, err)
+	}
+	return flags, nil
+}
@@ -0,0 +1,128 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/shared/ttlcache"
+	"github.com/gaborage/go-bricks/logger"
+)
+
+func newTestFlagStore(t *testing.T, client SecretsManagerAPI) *FeatureFlagStore {
+	t.Helper()
+
+	cache := ttlcache.New(time.Minute, 10)
+	t.Cleanup(cache.Close)
+	return NewFeatureFlagStore(client, cache, testPrefix, logger.New("info", false))
+}
+
+func TestFeatureFlagStoreIsEnabled(t *testing.T) {
+	tests := []struct {
+		name        string
+		secret      *string
+		fetchErr    error
+		flag        string
+		wantEnabled bool
+		wantErr     bool
+	}{
+		{name: "enabled flag", secret: aws.String(`{"beta-search":true,"new-checkout":false}`), flag: "beta-search", wantEnabled: true},
+		{name: "disabled flag", secret: aws.String(`{"beta-search":true,"new-checkout":false}`), flag: "new-checkout"},
+		{name: "unknown flag", secret: aws.String(`{"beta-search":true}`), flag: "dark-mode"},
+		{name: "missing secret defaults to all off", fetchErr: &types.ResourceNotFoundException{}, flag: "beta-search"},
+		{name: "empty secret", secret: nil, flag: "beta-search"},
+		{name: "malformed secret", secret: aws.String(`{"beta-search":"yes"}`), flag: "beta-search", wantErr: true},
+		{name: "backend error", fetchErr: errors.New("throttled"), flag: "beta-search", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeSecretsManager{
+				getSecretValueFunc: func(_ context.Context, params *secretsmanager.GetSecretValueInput) (*secretsmanager.GetSecretValueOutput, error) {
+					if want := testPrefix + "/tenant1/features"; *params.SecretId != want {
+						t.Errorf("GetSecretValue() SecretId = %q, want %q", *params.SecretId, want)
+					}
+					if tt.fetchErr != nil {
+						return nil, tt.fetchErr
+					}
+					return &secretsmanager.GetSecretValueOutput{SecretString: tt.secret}, nil
+				},
+			}
+			store := newTestFlagStore(t, client)
+
+			enabled, err := store.IsEnabled(context.Background(), "tenant1", tt.flag)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("IsEnabled() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if enabled != tt.wantEnabled {
+				t.Errorf("IsEnabled() = %v, want %v", enabled, tt.wantEnabled)
+			}
+		})
+	}
+}
+
+func TestFeatureFlagStoreCaching(t *testing.T) {
+	var calls int
+	client := &fakeSecretsManager{
+		getSecretValueFunc: func(_ context.Context, _ *secretsmanager.GetSecretValueInput) (*secretsmanager.GetSecretValueOutput, error) {
+			calls++
+			if calls == 1 {
+				return nil, &types.ResourceNotFoundException{}
+			}
+			return &secretsmanager.GetSecretValueOutput{SecretString: aws.String(`{"beta-search":true}`)}, nil
+		},
+	}
+	store := newTestFlagStore(t, client)
+	ctx := context.Background()
+
+	// A missing secret is cached as all-off rather than refetched on every check.
+	for range 3 {
+		if enabled, err := store.IsEnabled(ctx, "tenant1", "beta-search"); err != nil || enabled {
+			t.Fatalf("IsEnabled() = %v, %v, want false, nil", enabled, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("GetSecretValue called %d times, want 1", calls)
+	}
+
+	store.Invalidate("tenant1")
+
+	enabled, err := store.IsEnabled(ctx, "tenant1", "beta-search")
+	if err != nil || !enabled {
+		t.Errorf("IsEnabled() after Invalidate = %v, %v, want true, nil", enabled, err)
+	}
+	if calls != 2 {
+		t.Errorf("GetSecretValue called %d times after Invalidate, want 2", calls)
+	}
+}
+
+func TestFeatureFlagStoreSharesTenantStoreCache(t *testing.T) {
+	var calls int
+	client := &fakeSecretsManager{
+		getSecretValueFunc: func(_ context.Context, _ *secretsmanager.GetSecretValueInput) (*secretsmanager.GetSecretValueOutput, error) {
+			calls++
+			return &secretsmanager.GetSecretValueOutput{SecretString: aws.String(`{"beta-search":true}`)}, nil
+		},
+	}
+	tenants := newTestStore(t, client)
+	flags := tenants.FeatureFlags()
+	ctx := context.Background()
+
+	if _, err := flags.IsEnabled(ctx, "tenant1", "beta-search"); err != nil {
+		t.Fatalf("IsEnabled() unexpected error = %v", err)
+	}
+	tenants.ClearCache()
+	if _, err := flags.IsEnabled(ctx, "tenant1", "beta-search"); err != nil {
+		t.Fatalf("IsEnabled() unexpected error = %v", err)
+	}
+
+	if calls != 2 {
+		t.Errorf("GetSecretValue called %d times, want 2 (ClearCache should drop cached flags)", calls)
+	}
+}
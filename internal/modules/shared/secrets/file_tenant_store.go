@@ -0,0 +1,323 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	gobricksConfig "github.com/gaborage/go-bricks/config"
+	"github.com/gaborage/go-bricks/logger"
+	"go.yaml.in/yaml/v3"
+)
+
+// tenantFileExtensions lists the tenant file formats in lookup order. A
+// tenant with files in several formats is read from the first one found.
+var tenantFileExtensions = []string{".json", ".yaml", ".yml"}
+
+// FileStoreConfig configures the file-based tenant store.
+// Populate it with config.InjectInto.
+type FileStoreConfig struct {
+	// Dir holds one file per tenant, named <tenant>.json, <tenant>.yaml or
+	// <tenant>.yml, with the same fields as the AWS database secret.
+	Dir string `json:"dir" koanf:"custom.tenants.file.dir" config:"custom.tenants.file.dir"`
+	// Watch drops a tenant's cached configuration when its file changes, so
+	// edits apply without a restart.
+	Watch bool `json:"watch" koanf:"custom.tenants.file.watch" config:"custom.tenants.file.watch" default:"false"`
+	// AllowedTypes lists the database types a tenant file may name.
+	AllowedTypes []string `json:"allowed_types" koanf:"custom.tenants.file.allowed.types" config:"custom.tenants.file.allowed.types" default:"postgresql,oracle"`
+}
+
+// FileTenantStore implements the database.TenantStore interface by reading
+// tenant database configuration from a local directory. It is meant for
+// local development and tests of multi-tenant routing without AWS.
+type FileTenantStore struct {
+	dir     string
+	configs map[string]*gobricksConfig.DatabaseConfig // parsed files, by tenant
+	logger  logger.Logger
+	mu      sync.RWMutex
+	// generation counts invalidations, so a file read that raced one is not
+	// cached.
+	generation uint64
+
+	// allowedTypes is FileStoreConfig.AllowedTypes; empty means
+	// defaultDatabaseTypes.
+	allowedTypes []string
+
+	// watcher is nil unless FileStoreConfig.Watch is set; watching is done
+	// once it has been closed and watchLoop has returned.
+	watcher  *fsnotify.Watcher
+	watching sync.WaitGroup
+}
+
+// NewFileTenantStore creates a tenant store reading from cfg.Dir, which must
+// be an existing directory.
+func NewFileTenantStore(logger logger.Logger, cfg FileStoreConfig) (*FileTenantStore, error) {
+	if cfg.Dir == "" {
+		return nil, fmt.Errorf("tenant directory cannot be empty")
+	}
+	info, err := os.Stat(cfg.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open tenant directory: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("tenant directory %s is not a directory", cfg.Dir)
+	}
+
+	store := &FileTenantStore{
+		dir:          cfg.Dir,
+		configs:      make(map[string]*gobricksConfig.DatabaseConfig),
+		logger:       logger,
+		allowedTypes: cfg.AllowedTypes,
+	}
+
+	if cfg.Watch {
+		watcher, err := fsnotify.NewWatcher()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create tenant directory watcher: %w", err)
+		}
+		if err := watcher.Add(cfg.Dir); err != nil {
+			_ = watcher.Close()
+			return nil, fmt.Errorf("failed to watch tenant directory: %w", err)
+		}
+		store.watcher = watcher
+		store.watching.Go(store.watchLoop)
+	}
+
+	logger.Info().
+		Str("dir", cfg.Dir).
+		Bool("watch", cfg.Watch).
+		Msg("Initializing file tenant store")
+
+	return store, nil
+}
+
+// DBConfig implements the database.TenantStore interface. A tenant's file is
+// parsed on first use and cached until the store sees the file change.
+func (f *FileTenantStore) DBConfig(ctx context.Context, tenantID string) (*gobricksConfig.DatabaseConfig, error) {
+	if tenantID == "" {
+		return nil, fmt.Errorf("tenant ID cannot be empty")
+	}
+
+	f.mu.RLock()
+	config, cached := f.configs[tenantID]
+	generation := f.generation
+	f.mu.RUnlock()
+	if cached {
+		return config, nil
+	}
+
+	config, err := f.loadDatabaseConfig(tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	if f.generation == generation {
+		f.configs[tenantID] = config
+	}
+	f.mu.Unlock()
+
+	f.logger.Debug().
+		Str("tenant_id", tenantID).
+		Str("db_type", config.Type).
+		Str("host", config.Host).
+		Int("port", config.Port).
+		Msg("Loaded database config from tenant file")
+
+	return config, nil
+}
+
+// loadDatabaseConfig reads and parses the file of tenantID.
+func (f *FileTenantStore) loadDatabaseConfig(tenantID string) (*gobricksConfig.DatabaseConfig, error) {
+	// A tenant ID is a file name, never a path.
+	if strings.ContainsAny(tenantID, `/\`) || tenantID == "." || tenantID == ".." {
+		return nil, fmt.Errorf("%w: %s", ErrTenantNotFound, tenantID)
+	}
+
+	for _, ext := range tenantFileExtensions {
+		path := filepath.Join(f.dir, tenantID+ext)
+		data, err := os.ReadFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tenant file %s: %w", path, err)
+		}
+
+		var secret SecretDatabaseConfig
+		if err := decodeTenantFile(ext, data, &secret); err != nil {
+			return nil, fmt.Errorf("failed to parse tenant file %s: %w", path, err)
+		}
+		config := toDatabaseConfig(&secret)
+		if err := validateDatabaseConfig(tenantID, config, f.allowedTypes); err != nil {
+			return nil, fmt.Errorf("tenant file %s: %w", path, err)
+		}
+		return config, nil
+	}
+
+	return nil, fmt.Errorf("%w: no file for tenant %s in %s", ErrTenantNotFound, tenantID, f.dir)
+}
+
+// decodeTenantFile decodes a tenant file into secret. YAML is converted to
+// JSON first so both formats share the JSON field names and value formats of
+// the AWS secret, e.g. durations in nanoseconds.
+func decodeTenantFile(ext string, data []byte, secret *SecretDatabaseConfig) error {
+	if ext != ".json" {
+		var doc any
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return err
+		}
+		converted, err := json.Marshal(doc)
+		if err != nil {
+			return err
+		}
+		data = converted
+	}
+	return json.Unmarshal(data, secret)
+}
+
+// tenantIDFromFile returns the tenant a file name belongs to, or false for
+// files that are not tenant files.
+func tenantIDFromFile(name string) (string, bool) {
+	base := filepath.Base(name)
+	if strings.HasPrefix(base, ".") {
+		return "", false
+	}
+	ext := filepath.Ext(base)
+	if !slices.Contains(tenantFileExtensions, ext) {
+		return "", false
+	}
+	tenantID := strings.TrimSuffix(base, ext)
+	return tenantID, tenantID != ""
+}
+
+// ListTenants returns the tenants with a file in the directory, sorted.
+func (f *FileTenantStore) ListTenants(ctx context.Context) ([]string, error) {
+	entries, err := os.ReadDir(f.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tenant directory: %w", err)
+	}
+
+	var tenants []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if tenantID, ok := tenantIDFromFile(entry.Name()); ok && !slices.Contains(tenants, tenantID) {
+			tenants = append(tenants, tenantID)
+		}
+	}
+	slices.Sort(tenants)
+
+	f.logger.Debug().
+		Int("tenant_count", len(tenants)).
+		Str("tenants", strings.Join(tenants, ", ")).
+		Msg("Listed tenants from tenant directory")
+
+	return tenants, nil
+}
+
+// HealthCheck verifies that the tenant directory can still be read.
+func (f *FileTenantStore) HealthCheck(_ context.Context) error {
+	if _, err := os.ReadDir(f.dir); err != nil {
+		return fmt.Errorf("tenant store unreachable: %w", err)
+	}
+	return nil
+}
+
+// InvalidateCache drops a tenant's cached configuration, so the next DBConfig
+// re-reads its file.
+func (f *FileTenantStore) InvalidateCache(tenantID string) {
+	f.mu.Lock()
+	delete(f.configs, tenantID)
+	f.generation++
+	f.mu.Unlock()
+
+	f.logger.Debug().
+		Str("tenant_id", tenantID).
+		Msg("Invalidated tenant cache")
+}
+
+// ClearCache drops every cached configuration.
+func (f *FileTenantStore) ClearCache() {
+	f.mu.Lock()
+	f.configs = make(map[string]*gobricksConfig.DatabaseConfig)
+	f.generation++
+	f.mu.Unlock()
+
+	f.logger.Debug().Msg("Cleared all tenant cache")
+}
+
+// WarmCache parses every tenant file into the cache. Files that fail to
+// parse are reported in the result; only a failure to list the directory
+// returns an error.
+func (f *FileTenantStore) WarmCache(ctx context.Context) (*WarmResult, error) {
+	tenants, err := f.ListTenants(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tenants for cache warm-up: %w", err)
+	}
+
+	result := &WarmResult{Failed: make(map[string]error)}
+	for _, tenantID := range tenants {
+		if _, err := f.DBConfig(ctx, tenantID); err != nil {
+			result.Failed[tenantID] = err
+			continue
+		}
+		result.Loaded++
+	}
+
+	f.logger.Info().
+		Int("tenant_count", len(tenants)).
+		Int("loaded", result.Loaded).
+		Int("failed", len(result.Failed)).
+		Msg("Warmed tenant cache")
+
+	return result, nil
+}
+
+// watchLoop invalidates a tenant whenever its file is written, created,
+// removed or renamed. It returns once the watcher is closed.
+func (f *FileTenantStore) watchLoop() {
+	for {
+		select {
+		case event, ok := <-f.watcher.Events:
+			if !ok {
+				return
+			}
+			if !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) &&
+				!event.Has(fsnotify.Remove) && !event.Has(fsnotify.Rename) {
+				continue
+			}
+			if tenantID, ok := tenantIDFromFile(event.Name); ok {
+				f.InvalidateCache(tenantID)
+			}
+		case err, ok := <-f.watcher.Errors:
+			if !ok {
+				return
+			}
+			f.logger.Warn().
+				Err(err).
+				Str("dir", f.dir).
+				Msg("Tenant directory watch error; cached tenants may be stale")
+		}
+	}
+}
+
+// Close stops watching the tenant directory.
+func (f *FileTenantStore) Close() error {
+	var err error
+	if f.watcher != nil {
+		err = f.watcher.Close()
+		f.watching.Wait()
+	}
+	f.logger.Debug().Msg("Closed file tenant store")
+	return err
+}
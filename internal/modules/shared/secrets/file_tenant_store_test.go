@@ -0,0 +1,166 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gaborage/go-bricks/logger"
+)
+
+const testTenantYAML = `type: postgresql
+host: localhost
+port: 5434
+database: tenant2_db
+username: tenant2_user
+password: tenant2_pass
+pool:
+  max:
+    connections: 15
+`
+
+func newTestFileStore(t *testing.T, dir string, watch bool) *FileTenantStore {
+	t.Helper()
+
+	store, err := NewFileTenantStore(logger.New("info", false), FileStoreConfig{Dir: dir, Watch: watch})
+	if err != nil {
+		t.Fatalf("NewFileTenantStore() unexpected error = %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	return store
+}
+
+func writeTenantFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write tenant file: %v", err)
+	}
+}
+
+func TestFileTenantStoreDBConfig(t *testing.T) {
+	dir := t.TempDir()
+	writeTenantFile(t, dir, "tenant1.json", testSecretString)
+	writeTenantFile(t, dir, "tenant2.yaml", testTenantYAML)
+	writeTenantFile(t, dir, "broken.json", `{"type":`)
+	writeTenantFile(t, dir, "noport.yaml", "type: postgresql\nhost: localhost\n")
+	store := newTestFileStore(t, dir, false)
+
+	config, err := store.DBConfig(context.Background(), "tenant1")
+	if err != nil {
+		t.Fatalf("DBConfig(tenant1) unexpected error = %v", err)
+	}
+	if config.Type != "postgresql" || config.Host != "localhost" || config.Port != 5432 || config.Database != "db" {
+		t.Errorf("DBConfig(tenant1) = %+v, want the JSON file's settings", config)
+	}
+
+	config, err = store.DBConfig(context.Background(), "tenant2")
+	if err != nil {
+		t.Fatalf("DBConfig(tenant2) unexpected error = %v", err)
+	}
+	if config.Port != 5434 || config.Username != "tenant2_user" || config.Pool.Max.Connections != 15 {
+		t.Errorf("DBConfig(tenant2) = %+v, want the YAML file's settings", config)
+	}
+
+	if _, err := store.DBConfig(context.Background(), "broken"); err == nil || errors.Is(err, ErrTenantNotFound) {
+		t.Errorf("DBConfig(broken) error = %v, want a parse error", err)
+	}
+	if _, err := store.DBConfig(context.Background(), "noport"); !errors.Is(err, ErrInvalidTenantConfig) {
+		t.Errorf("DBConfig(noport) error = %v, want ErrInvalidTenantConfig", err)
+	}
+	for _, tenantID := range []string{"unknown", "../tenant1"} {
+		if _, err := store.DBConfig(context.Background(), tenantID); !errors.Is(err, ErrTenantNotFound) {
+			t.Errorf("DBConfig(%q) error = %v, want ErrTenantNotFound", tenantID, err)
+		}
+	}
+}
+
+func TestFileTenantStoreListTenants(t *testing.T) {
+	dir := t.TempDir()
+	writeTenantFile(t, dir, "tenant2.yml", testTenantYAML)
+	writeTenantFile(t, dir, "tenant1.json", testSecretString)
+	writeTenantFile(t, dir, "tenant1.yaml", testTenantYAML)
+	writeTenantFile(t, dir, "README.md", "not a tenant")
+	writeTenantFile(t, dir, ".tenant3.json", testSecretString)
+	if err := os.Mkdir(filepath.Join(dir, "archive.json"), 0o700); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	store := newTestFileStore(t, dir, false)
+
+	tenants, err := store.ListTenants(context.Background())
+	if err != nil {
+		t.Fatalf("ListTenants() unexpected error = %v", err)
+	}
+	if want := []string{"tenant1", "tenant2"}; !slices.Equal(tenants, want) {
+		t.Errorf("ListTenants() = %v, want %v", tenants, want)
+	}
+}
+
+func TestFileTenantStoreCachesUntilInvalidated(t *testing.T) {
+	dir := t.TempDir()
+	writeTenantFile(t, dir, "tenant1.json", testSecretString)
+	store := newTestFileStore(t, dir, false)
+
+	if _, err := store.DBConfig(context.Background(), "tenant1"); err != nil {
+		t.Fatalf("DBConfig() unexpected error = %v", err)
+	}
+	writeTenantFile(t, dir, "tenant1.json", `{"type":"postgresql","host":"db.internal","port":5432}`)
+
+	config, err := store.DBConfig(context.Background(), "tenant1")
+	if err != nil {
+		t.Fatalf("DBConfig() unexpected error = %v", err)
+	}
+	if config.Host != "localhost" {
+		t.Errorf("DBConfig() host = %q without watching, want the cached %q", config.Host, "localhost")
+	}
+
+	store.InvalidateCache("tenant1")
+	config, err = store.DBConfig(context.Background(), "tenant1")
+	if err != nil {
+		t.Fatalf("DBConfig() unexpected error = %v", err)
+	}
+	if config.Host != "db.internal" {
+		t.Errorf("DBConfig() host = %q after InvalidateCache, want %q", config.Host, "db.internal")
+	}
+}
+
+func TestFileTenantStoreWatch(t *testing.T) {
+	dir := t.TempDir()
+	writeTenantFile(t, dir, "tenant1.json", testSecretString)
+	store := newTestFileStore(t, dir, true)
+
+	if _, err := store.DBConfig(context.Background(), "tenant1"); err != nil {
+		t.Fatalf("DBConfig() unexpected error = %v", err)
+	}
+	writeTenantFile(t, dir, "tenant1.json", `{"type":"postgresql","host":"db.internal","port":5432}`)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		config, err := store.DBConfig(context.Background(), "tenant1")
+		if err == nil && config.Host == "db.internal" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("DBConfig() = %+v, %v, want the rewritten file's host after the change was watched", config, err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := store.Close(); err != nil {
+		t.Errorf("Close() unexpected error = %v", err)
+	}
+}
+
+func TestNewFileTenantStoreRejectsMissingDirectory(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing")
+
+	for _, cfg := range []FileStoreConfig{{}, {Dir: missing}} {
+		if _, err := NewFileTenantStore(logger.New("info", false), cfg); err == nil {
+			t.Errorf("NewFileTenantStore(%+v) expected error, got nil", cfg)
+		}
+	}
+}
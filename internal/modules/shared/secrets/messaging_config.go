@@ -0,0 +1,172 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/shared/ttlcache"
+)
+
+// messagingConfigType is the secret name suffix holding a tenant's AMQP settings.
+const messagingConfigType = "messaging"
+
+// ErrMessagingNotConfigured is returned by MessagingConfig for a tenant
+// without a messaging secret. The result is cached for
+// AWSSecretsConfig.NegativeTTL.
+var ErrMessagingNotConfigured = errors.New("tenant messaging not configured")
+
+// SecretMessagingConfig represents the structure of messaging configuration
+// stored in AWS Secrets Manager. VHost, Username and Password override the
+// ones embedded in URL.
+type SecretMessagingConfig struct {
+	URL      string `json:"url"`
+	VHost    string `json:"vhost"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// MessagingConfig is a tenant's AMQP broker connection settings.
+type MessagingConfig struct {
+	// BrokerURL is the broker address without credentials or vhost, e.g.
+	// amqps://broker.example.com:5671, so it is safe to log.
+	BrokerURL   string
+	VirtualHost string
+	Username    string
+	Password    string
+}
+
+// MessagingConfig retrieves AMQP configuration for a tenant from the
+// <prefix>/<tenant>/messaging secret. It shares the store's cache under its
+// own key namespace, and concurrent cache misses share a single fetch.
+func (s *AWSSecretsTenantStore) MessagingConfig(ctx context.Context, tenantID string) (*MessagingConfig, error) {
+	if tenantID == "" {
+		return nil, fmt.Errorf("tenant ID cannot be empty")
+	}
+
+	cacheKey := messagingCacheKey(tenantID)
+	cached := s.cache.Get(cacheKey)
+	if cached == ttlcache.NotFound {
+		return nil, fmt.Errorf("%w: %s (cached)", ErrMessagingNotConfigured, tenantID)
+	}
+	if cached != nil {
+		return cached.(*MessagingConfig), nil
+	}
+
+	config, err := s.sharedFetch(ctx, cacheKey, func(ctx context.Context) (any, error) {
+		return s.loadMessagingConfig(ctx, tenantID, cacheKey)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return config.(*MessagingConfig), nil
+}
+
+// loadMessagingConfig fetches a tenant's messaging configuration after a
+// cache miss and caches the result, including a not-found result.
+func (s *AWSSecretsTenantStore) loadMessagingConfig(ctx context.Context, tenantID, cacheKey string) (*MessagingConfig, error) {
+	config, err := s.fetchMessagingConfig(ctx, tenantID)
+	if errors.Is(err, ErrMessagingNotConfigured) {
+		s.cache.SetNegative(cacheKey)
+		s.logger.Debug().
+			Str("tenant_id", tenantID).
+			Msg("Tenant has no messaging secret; caching not-found result")
+		return nil, err
+	}
+	if err != nil {
+		s.logger.Error().
+			Err(err).
+			Str("tenant_id", tenantID).
+			Msg("Failed to fetch messaging config from AWS Secrets Manager")
+		return nil, err
+	}
+
+	s.cache.Set(cacheKey, config)
+
+	s.logger.Info().
+		Str("tenant_id", tenantID).
+		Str("broker_url", config.BrokerURL).
+		Str("vhost", config.VirtualHost).
+		Msg("Successfully retrieved and cached messaging config")
+
+	return config, nil
+}
+
+// fetchMessagingConfig retrieves and parses messaging configuration from AWS Secrets Manager
+func (s *AWSSecretsTenantStore) fetchMessagingConfig(ctx context.Context, tenantID string) (*MessagingConfig, error) {
+	secretName := s.buildSecretName(tenantID, messagingConfigType)
+
+	result, err := s.client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(secretName),
+	})
+	if err != nil {
+		var missingError *types.ResourceNotFoundException
+		if errors.As(err, &missingError) {
+			return nil, fmt.Errorf("%w: no secret for tenant %s (secret: %s): %w", ErrMessagingNotConfigured, tenantID, secretName, err)
+		}
+		return nil, fmt.Errorf("failed to retrieve messaging secret for tenant %s: %w", tenantID, err)
+	}
+
+	if result.SecretString == nil {
+		return nil, fmt.Errorf("messaging secret value is empty for tenant %s", tenantID)
+	}
+
+	var secretConfig SecretMessagingConfig
+	if err := json.Unmarshal([]byte(*result.SecretString), &secretConfig); err != nil {
+		return nil, fmt.Errorf("failed to parse messaging secret JSON for tenant %s: %w", tenantID, err)
+	}
+
+	config, err := toMessagingConfig(&secretConfig)
+	if err != nil {
+		return nil, fmt.Errorf("invalid messaging secret for tenant %s: %w", tenantID, err)
+	}
+	return config, nil
+}
+
+// toMessagingConfig parses the broker URL of secret and splits out the
+// credentials and vhost it may embed. Explicit secret fields take precedence.
+func toMessagingConfig(secret *SecretMessagingConfig) (*MessagingConfig, error) {
+	broker, err := url.Parse(secret.URL)
+	if err != nil {
+		// url.Error repeats the URL, which may hold a password.
+		return nil, errors.New("broker URL cannot be parsed")
+	}
+	if broker.Scheme != "amqp" && broker.Scheme != "amqps" {
+		return nil, fmt.Errorf("broker URL scheme must be amqp or amqps, got %q", broker.Scheme)
+	}
+	if broker.Host == "" {
+		return nil, errors.New("broker URL has no host")
+	}
+
+	config := &MessagingConfig{
+		VirtualHost: strings.TrimPrefix(broker.Path, "/"),
+		Username:    broker.User.Username(),
+	}
+	config.Password, _ = broker.User.Password()
+
+	if secret.VHost != "" {
+		config.VirtualHost = secret.VHost
+	}
+	if secret.Username != "" {
+		config.Username = secret.Username
+	}
+	if secret.Password != "" {
+		config.Password = secret.Password
+	}
+
+	broker.User = nil
+	broker.Path, broker.RawPath = "", ""
+	config.BrokerURL = broker.String()
+	return config, nil
+}
+
+// messagingCacheKey is the cache key of a tenant's messaging configuration.
+func messagingCacheKey(tenantID string) string {
+	return fmt.Sprintf("messaging_%s", tenantID)
+}
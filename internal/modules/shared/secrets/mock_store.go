@@ -103,6 +103,22 @@ func (m *MockTenantStore) ListTenants(ctx context.Context) ([]string, error) {
 	return tenants, nil
 }
 
+// HealthCheck always succeeds since the mock store is held in memory
+func (m *MockTenantStore) HealthCheck(_ context.Context) error {
+	return nil
+}
+
+// ClearCache does nothing; the mock store has no cache
+func (m *MockTenantStore) ClearCache() {}
+
+// WarmCache reports every configured tenant as loaded, since the mock store
+// holds them in memory
+func (m *MockTenantStore) WarmCache(_ context.Context) (*WarmResult, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return &WarmResult{Loaded: len(m.configs), Failed: map[string]error{}}, nil
+}
+
 // Close implements the cleanup interface
 func (m *MockTenantStore) Close() error {
 	m.logger.Debug().Msg("Closed mock tenant store")
@@ -0,0 +1,125 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	gobricksConfig "github.com/gaborage/go-bricks/config"
+	"github.com/gaborage/go-bricks/logger"
+)
+
+// Tenant store backends selectable with ProviderConfig.Backend.
+const (
+	BackendAWS  = "aws"
+	BackendFile = "file"
+	BackendMock = "mock"
+)
+
+// TenantStore is the tenant store API shared by every backend.
+type TenantStore interface {
+	DBConfig(ctx context.Context, tenantID string) (*gobricksConfig.DatabaseConfig, error)
+	ListTenants(ctx context.Context) ([]string, error)
+	HealthCheck(ctx context.Context) error
+	ClearCache()
+	WarmCache(ctx context.Context) (*WarmResult, error)
+	Close() error
+}
+
+// ProviderConfig selects and configures the tenant store backend. Only the
+// settings of the selected backend are used. Load it with LoadProviderConfig.
+type ProviderConfig struct {
+	// Backend is "aws", "file" or "mock".
+	Backend string `json:"backend" koanf:"custom.tenants.store" config:"custom.tenants.store" default:"aws"`
+	AWS     AWSSecretsConfig
+	File    FileStoreConfig
+}
+
+// LoadProviderConfig reads the backend selection and the settings of every
+// backend from cfg.
+func LoadProviderConfig(cfg *gobricksConfig.Config) (ProviderConfig, error) {
+	var providerCfg ProviderConfig
+	if err := cfg.InjectInto(&providerCfg); err != nil {
+		return providerCfg, fmt.Errorf("failed to load tenant store config: %w", err)
+	}
+	if err := cfg.InjectInto(&providerCfg.AWS); err != nil {
+		return providerCfg, fmt.Errorf("failed to load AWS Secrets Manager config: %w", err)
+	}
+	if err := cfg.InjectInto(&providerCfg.File); err != nil {
+		return providerCfg, fmt.Errorf("failed to load file tenant store config: %w", err)
+	}
+	return providerCfg, nil
+}
+
+// Provider shares one tenant store between the modules that need it. Create
+// a single Provider in main and hand it to each module: the first Store call
+// builds the configured backend, later calls return the same store, and the
+// store is closed when the last module releases it.
+type Provider struct {
+	mu    sync.Mutex
+	store TenantStore
+	users int
+
+	// newAWSStore builds the AWS backend; tests replace it to avoid AWS.
+	newAWSStore func(ctx context.Context, logger logger.Logger, cfg AWSSecretsConfig) (TenantStore, error)
+}
+
+// NewProvider creates a provider that has not built a store yet.
+func NewProvider() *Provider {
+	return &Provider{
+		newAWSStore: func(ctx context.Context, logger logger.Logger, cfg AWSSecretsConfig) (TenantStore, error) {
+			return NewAWSSecretsTenantStore(ctx, logger, cfg)
+		},
+	}
+}
+
+// Store returns the shared tenant store, building it from cfg on first use.
+// The configuration of later calls is ignored. Every successful call must be
+// paired with a Release.
+func (p *Provider) Store(ctx context.Context, logger logger.Logger, cfg ProviderConfig) (TenantStore, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.store == nil {
+		store, err := p.build(ctx, logger, cfg)
+		if err != nil {
+			return nil, err
+		}
+		p.store = store
+	}
+	p.users++
+	return p.store, nil
+}
+
+// build creates the store of the backend cfg selects.
+func (p *Provider) build(ctx context.Context, logger logger.Logger, cfg ProviderConfig) (TenantStore, error) {
+	switch cfg.Backend {
+	case BackendAWS:
+		return p.newAWSStore(ctx, logger, cfg.AWS)
+	case BackendFile:
+		return NewFileTenantStore(logger, cfg.File)
+	case BackendMock:
+		return NewMockTenantStore(logger), nil
+	default:
+		return nil, fmt.Errorf("unknown tenant store backend %q (want %s, %s or %s)", cfg.Backend, BackendAWS, BackendFile, BackendMock)
+	}
+}
+
+// Release gives up one Store reference. The last release closes the store;
+// a later Store builds a new one.
+func (p *Provider) Release() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.users == 0 {
+		return nil
+	}
+	p.users--
+	if p.users > 0 {
+		return nil
+	}
+
+	store := p.store
+	p.store = nil
+	return store.Close()
+}
@@ -0,0 +1,125 @@
+package secrets
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gaborage/go-bricks/logger"
+)
+
+// closeCountingStore is a TenantStore that counts Close calls
+type closeCountingStore struct {
+	*MockTenantStore
+	closed int
+}
+
+func (s *closeCountingStore) Close() error {
+	s.closed++
+	return nil
+}
+
+func TestProviderSelectsBackend(t *testing.T) {
+	var awsBuilds int
+	var awsCfg AWSSecretsConfig
+	awsStore := &closeCountingStore{MockTenantStore: NewMockTenantStore(logger.New("info", false))}
+
+	tests := []struct {
+		name    string
+		cfg     ProviderConfig
+		check   func(t *testing.T, store TenantStore)
+		wantErr bool
+	}{
+		{
+			name: "aws",
+			cfg:  ProviderConfig{Backend: BackendAWS, AWS: AWSSecretsConfig{Prefix: testPrefix}},
+			check: func(t *testing.T, store TenantStore) {
+				if store != awsStore || awsBuilds != 1 || awsCfg.Prefix != testPrefix {
+					t.Errorf("Store() = %T after %d AWS builds with %+v, want the AWS store built once with the AWS settings", store, awsBuilds, awsCfg)
+				}
+			},
+		},
+		{
+			name: "file",
+			cfg:  ProviderConfig{Backend: BackendFile, File: FileStoreConfig{Dir: t.TempDir()}},
+			check: func(t *testing.T, store TenantStore) {
+				if _, ok := store.(*FileTenantStore); !ok {
+					t.Errorf("Store() = %T, want *FileTenantStore", store)
+				}
+			},
+		},
+		{
+			name: "mock",
+			cfg:  ProviderConfig{Backend: BackendMock},
+			check: func(t *testing.T, store TenantStore) {
+				if _, ok := store.(*MockTenantStore); !ok {
+					t.Errorf("Store() = %T, want *MockTenantStore", store)
+				}
+			},
+		},
+		{name: "file without a directory", cfg: ProviderConfig{Backend: BackendFile}, wantErr: true},
+		{name: "unknown backend", cfg: ProviderConfig{Backend: "vault"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := NewProvider()
+			provider.newAWSStore = func(_ context.Context, _ logger.Logger, cfg AWSSecretsConfig) (TenantStore, error) {
+				awsBuilds++
+				awsCfg = cfg
+				return awsStore, nil
+			}
+
+			store, err := provider.Store(context.Background(), logger.New("info", false), tt.cfg)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Store() = %T, want error", store)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Store() unexpected error = %v", err)
+			}
+			t.Cleanup(func() { _ = provider.Release() })
+			tt.check(t, store)
+		})
+	}
+}
+
+func TestProviderSharesStoreUntilLastRelease(t *testing.T) {
+	builds := 0
+	store := &closeCountingStore{MockTenantStore: NewMockTenantStore(logger.New("info", false))}
+	provider := NewProvider()
+	provider.newAWSStore = func(_ context.Context, _ logger.Logger, _ AWSSecretsConfig) (TenantStore, error) {
+		builds++
+		return store, nil
+	}
+	cfg := ProviderConfig{Backend: BackendAWS}
+
+	first, err := provider.Store(context.Background(), logger.New("info", false), cfg)
+	if err != nil {
+		t.Fatalf("Store() unexpected error = %v", err)
+	}
+	second, err := provider.Store(context.Background(), logger.New("info", false), ProviderConfig{Backend: BackendMock})
+	if err != nil {
+		t.Fatalf("Store() unexpected error = %v", err)
+	}
+	if first != second || builds != 1 {
+		t.Fatalf("Store() twice built %d stores, want one shared store", builds)
+	}
+
+	if err := provider.Release(); err != nil {
+		t.Fatalf("Release() unexpected error = %v", err)
+	}
+	if store.closed != 0 {
+		t.Fatal("Release() closed the store while another module still holds it")
+	}
+	if err := provider.Release(); err != nil {
+		t.Fatalf("Release() unexpected error = %v", err)
+	}
+	if err := provider.Release(); err != nil {
+		t.Fatalf("Release() without a holder unexpected error = %v", err)
+	}
+	if store.closed != 1 {
+		t.Errorf("store closed %d times, want once after the last release", store.closed)
+	}
+}
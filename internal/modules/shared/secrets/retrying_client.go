@@ -0,0 +1,121 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+	"github.com/aws/smithy-go"
+	"github.com/gaborage/go-bricks/logger"
+)
+
+const (
+	// defaultRequestTimeout bounds a single Secrets Manager call when
+	// AWSSecretsConfig.RequestTimeout is not set.
+	defaultRequestTimeout = 5 * time.Second
+
+	// retryBaseDelay is the wait before the first retry; it doubles for
+	// every retry after that.
+	retryBaseDelay = 100 * time.Millisecond
+)
+
+// throttlingErrorCodes are the AWS error codes for a throttled request.
+var throttlingErrorCodes = []string{"ThrottlingException", "Throttling", "TooManyRequestsException", "RequestLimitExceeded"}
+
+// retryingClient wraps a SecretsManagerAPI so every call is bounded by a
+// per-call timeout and transient failures are retried with exponential
+// backoff. A cancelled caller context ends retries early.
+type retryingClient struct {
+	next       SecretsManagerAPI
+	timeout    time.Duration
+	maxRetries int
+	baseDelay  time.Duration
+	logger     logger.Logger
+}
+
+// newRetryingClient wraps next. A non-positive timeout uses
+// defaultRequestTimeout; a negative maxRetries disables retries.
+func newRetryingClient(next SecretsManagerAPI, timeout time.Duration, maxRetries int, l logger.Logger) *retryingClient {
+	if timeout <= 0 {
+		timeout = defaultRequestTimeout
+	}
+	return &retryingClient{
+		next:       next,
+		timeout:    timeout,
+		maxRetries: max(maxRetries, 0),
+		baseDelay:  retryBaseDelay,
+		logger:     l,
+	}
+}
+
+// GetSecretValue implements SecretsManagerAPI.
+func (c *retryingClient) GetSecretValue(ctx context.Context, params *secretsmanager.GetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error) {
+	return retryCall(ctx, c, "GetSecretValue", func(ctx context.Context) (*secretsmanager.GetSecretValueOutput, error) {
+		return c.next.GetSecretValue(ctx, params, optFns...)
+	})
+}
+
+// ListSecrets implements SecretsManagerAPI.
+func (c *retryingClient) ListSecrets(ctx context.Context, params *secretsmanager.ListSecretsInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.ListSecretsOutput, error) {
+	return retryCall(ctx, c, "ListSecrets", func(ctx context.Context) (*secretsmanager.ListSecretsOutput, error) {
+		return c.next.ListSecrets(ctx, params, optFns...)
+	})
+}
+
+// retryCall runs call with up to c.maxRetries retries. Each attempt gets its
+// own c.timeout deadline derived from ctx.
+func retryCall[T any](ctx context.Context, c *retryingClient, operation string, call func(context.Context) (T, error)) (T, error) {
+	for retry := 0; ; retry++ {
+		result, err := attemptCall(ctx, c.timeout, call)
+		if err == nil || retry == c.maxRetries || ctx.Err() != nil || !isTransientAWSError(err) {
+			return result, err
+		}
+
+		delay := c.baseDelay << retry
+		c.logger.Warn().
+			Err(err).
+			Str("operation", operation).
+			Int("retry", retry+1).
+			Dur("backoff", delay).
+			Msg("Secrets Manager call failed, retrying")
+
+		if err := waitRetry(ctx, delay); err != nil {
+			var zero T
+			return zero, err
+		}
+	}
+}
+
+// attemptCall runs a single call bounded by timeout.
+func attemptCall[T any](ctx context.Context, timeout time.Duration, call func(context.Context) (T, error)) (T, error) {
+	attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return call(attemptCtx)
+}
+
+// waitRetry sleeps for delay unless ctx is done first.
+func waitRetry(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// isTransientAWSError reports whether a Secrets Manager call may succeed if
+// made again: an internal service error, throttling or a timed-out attempt.
+func isTransientAWSError(err error) bool {
+	var internalServiceError *types.InternalServiceError
+	if errors.As(err, &internalServiceError) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var apiErr smithy.APIError
+	return errors.As(err, &apiErr) && slices.Contains(throttlingErrorCodes, apiErr.ErrorCode())
+}
@@ -0,0 +1,115 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+	"github.com/aws/smithy-go"
+	"github.com/gaborage/go-bricks/logger"
+)
+
+func newTestRetryingClient(next SecretsManagerAPI, timeout time.Duration, maxRetries int) *retryingClient {
+	client := newRetryingClient(next, timeout, maxRetries, logger.New("info", false))
+	client.baseDelay = time.Millisecond
+	return client
+}
+
+func TestRetryingClientRetriesTransientErrors(t *testing.T) {
+	internal := &types.InternalServiceError{Message: aws.String("try again")}
+	throttled := &smithy.GenericAPIError{Code: "ThrottlingException", Message: "rate exceeded"}
+	missing := &types.ResourceNotFoundException{Message: aws.String("secret not found")}
+
+	tests := []struct {
+		name      string
+		errs      []error // returned by successive calls; later calls succeed
+		wantCalls int32
+		wantErr   error
+	}{
+		{name: "internal error then success", errs: []error{internal, internal}, wantCalls: 3},
+		{name: "throttled then success", errs: []error{throttled}, wantCalls: 2},
+		{name: "retries exhausted", errs: []error{internal, internal, internal}, wantCalls: 3, wantErr: internal},
+		{name: "not transient", errs: []error{missing}, wantCalls: 1, wantErr: missing},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			next := &fakeSecretsManager{
+				getSecretValueFunc: func(_ context.Context, _ *secretsmanager.GetSecretValueInput) (*secretsmanager.GetSecretValueOutput, error) {
+					call := int(calls.Add(1))
+					if call <= len(tt.errs) {
+						return nil, tt.errs[call-1]
+					}
+					return &secretsmanager.GetSecretValueOutput{SecretString: aws.String(testSecretString)}, nil
+				},
+			}
+			client := newTestRetryingClient(next, time.Second, 2)
+
+			_, err := client.GetSecretValue(context.Background(), &secretsmanager.GetSecretValueInput{})
+
+			if tt.wantErr == nil && err != nil {
+				t.Errorf("GetSecretValue() unexpected error = %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("GetSecretValue() error = %v, want %v", err, tt.wantErr)
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("GetSecretValue() made %d calls, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestRetryingClientTimesOutEachCall(t *testing.T) {
+	var calls atomic.Int32
+	next := &fakeSecretsManager{
+		listSecretsFunc: func(ctx context.Context, _ *secretsmanager.ListSecretsInput) (*secretsmanager.ListSecretsOutput, error) {
+			calls.Add(1)
+			<-ctx.Done() // a hung endpoint
+			return nil, ctx.Err()
+		},
+	}
+	client := newTestRetryingClient(next, 10*time.Millisecond, 1)
+
+	_, err := client.ListSecrets(context.Background(), &secretsmanager.ListSecretsInput{})
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("ListSecrets() error = %v, want context.DeadlineExceeded", err)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("ListSecrets() made %d calls, want a timed-out call and one retry", got)
+	}
+}
+
+func TestRetryingClientStopsWhenCallerCancels(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var calls atomic.Int32
+	next := &fakeSecretsManager{
+		getSecretValueFunc: func(_ context.Context, _ *secretsmanager.GetSecretValueInput) (*secretsmanager.GetSecretValueOutput, error) {
+			calls.Add(1)
+			cancel()
+			return nil, &types.InternalServiceError{Message: aws.String("try again")}
+		},
+	}
+	client := newTestRetryingClient(next, time.Second, 5)
+	client.baseDelay = time.Hour
+
+	start := time.Now()
+	_, err := client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{})
+
+	if err == nil {
+		t.Fatal("GetSecretValue() expected error after the caller cancelled, got nil")
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("GetSecretValue() made %d calls after the caller cancelled, want 1", got)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("GetSecretValue() took %v after the caller cancelled, want it to return without backing off", elapsed)
+	}
+}
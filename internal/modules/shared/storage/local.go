@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LocalUploader writes files below a root directory.
+type LocalUploader struct {
+	root string
+}
+
+// NewLocalUploader creates an uploader rooted at dir. The directory is
+// created on the first upload if it does not exist.
+func NewLocalUploader(dir string) (*LocalUploader, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("custom.storage.local.dir is required for the local backend")
+	}
+	return &LocalUploader{root: dir}, nil
+}
+
+// Upload writes contents to destinationPath below the root. The file is
+// written to a temporary name and renamed, so readers never see a partial
+// file. Paths escaping the root are rejected. Files carry no content type,
+// so contentType is ignored.
+func (u *LocalUploader) Upload(ctx context.Context, destinationPath, _ string, contents io.Reader) error {
+	rel := filepath.Clean(filepath.FromSlash(destinationPath))
+	if rel == "." || filepath.IsAbs(rel) || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("invalid destination path %q", destinationPath)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	dest := filepath.Join(u.root, rel)
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(dest), "."+filepath.Base(dest)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name()) //nolint:errcheck // no-op once renamed
+
+	if _, err := io.Copy(tmp, contents); err != nil {
+		tmp.Close() //nolint:errcheck // the write error is reported instead
+		return fmt.Errorf("failed to write %s: %w", dest, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", dest, err)
+	}
+	if err := os.Rename(tmp.Name(), dest); err != nil {
+		return fmt.Errorf("failed to move file into place: %w", err)
+	}
+	return nil
+}
+
+// Close implements Uploader; the local backend holds no resources.
+func (u *LocalUploader) Close() error {
+	return nil
+}
@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalUploaderUpload(t *testing.T) {
+	root := t.TempDir()
+	uploader, err := NewLocalUploader(root)
+	if err != nil {
+		t.Fatalf("NewLocalUploader() error = %v", err)
+	}
+
+	if err := uploader.Upload(context.Background(), "reports/products.txt", "text/plain", strings.NewReader("id\tname\n")); err != nil {
+		t.Fatalf("Upload() error = %v", err)
+	}
+
+	got, err := os.ReadFile(filepath.Join(root, "reports", "products.txt"))
+	if err != nil {
+		t.Fatalf("read uploaded file: %v", err)
+	}
+	if string(got) != "id\tname\n" {
+		t.Errorf("uploaded contents = %q, want %q", got, "id\tname\n")
+	}
+	entries, _ := os.ReadDir(filepath.Join(root, "reports"))
+	if len(entries) != 1 {
+		t.Errorf("reports directory has %d entries, want only the uploaded file", len(entries))
+	}
+}
+
+func TestLocalUploaderRejectsEscapingPaths(t *testing.T) {
+	uploader, err := NewLocalUploader(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalUploader() error = %v", err)
+	}
+
+	for _, path := range []string{"", "../outside.txt", "/etc/passwd", "reports/../../outside.txt"} {
+		if err := uploader.Upload(context.Background(), path, "text/plain", strings.NewReader("x")); err == nil {
+			t.Errorf("Upload(%q) error = nil, want an invalid path error", path)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/gaborage/go-bricks/logger"
+)
+
+const (
+	defaultUploadAttempts  = 3
+	defaultUploadBaseDelay = 500 * time.Millisecond
+)
+
+// RetryingUploader wraps an Uploader so transient upload failures, such as
+// timeouts and dropped connections, are retried with exponential backoff.
+// Other failures, such as rejected credentials, are returned immediately.
+type RetryingUploader struct {
+	next        Uploader
+	maxAttempts int
+	baseDelay   time.Duration
+	logger      logger.Logger
+}
+
+// NewRetryingUploader wraps next. Up to maxAttempts uploads are made, waiting
+// baseDelay before the first retry and doubling it for each retry after that.
+// Non-positive values use 3 attempts and 500ms.
+func NewRetryingUploader(next Uploader, maxAttempts int, baseDelay time.Duration, l logger.Logger) *RetryingUploader {
+	if maxAttempts <= 0 {
+		maxAttempts = defaultUploadAttempts
+	}
+	if baseDelay <= 0 {
+		baseDelay = defaultUploadBaseDelay
+	}
+	return &RetryingUploader{
+		next:        next,
+		maxAttempts: maxAttempts,
+		baseDelay:   baseDelay,
+		logger:      l,
+	}
+}
+
+// Upload implements Uploader. contents is read into memory once so every
+// attempt sends the same bytes. Retries stop when ctx is done, and a retry
+// is not started when its backoff would outlast the ctx deadline.
+func (u *RetryingUploader) Upload(ctx context.Context, destinationPath, contentType string, contents io.Reader) error {
+	body, err := io.ReadAll(contents)
+	if err != nil {
+		return fmt.Errorf("failed to read contents: %w", err)
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := u.next.Upload(ctx, destinationPath, contentType, bytes.NewReader(body))
+		if err == nil || ctx.Err() != nil || !IsRetryable(err) {
+			return err
+		}
+		if attempt == u.maxAttempts {
+			return fmt.Errorf("upload failed after %d attempts: %w", attempt, err)
+		}
+
+		delay := u.baseDelay << (attempt - 1)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			return fmt.Errorf("upload failed after %d attempts, no time left to retry: %w", attempt, err)
+		}
+
+		u.logger.Warn().
+			Err(err).
+			Str("destination", destinationPath).
+			Int("attempt", attempt).
+			Dur("backoff", delay).
+			Msg("Upload failed, retrying")
+
+		if err := waitRetry(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// Close implements Uploader by closing the wrapped uploader.
+func (u *RetryingUploader) Close() error {
+	return u.next.Close()
+}
+
+// waitRetry sleeps for delay unless ctx is done first.
+func waitRetry(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// IsRetryable reports whether an upload may succeed if made again: a timeout,
+// a refused or dropped connection, or an S3 throttling or server error.
+// Everything else, including authentication and permission failures, is not.
+func IsRetryable(err error) bool {
+	var statusErr *s3StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.code == http.StatusRequestTimeout ||
+			statusErr.code == http.StatusTooManyRequests ||
+			statusErr.code >= http.StatusInternalServerError
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, net.ErrClosed)
+}
@@ -0,0 +1,129 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/gaborage/go-bricks/logger"
+)
+
+// flakyUploader fails with errs on successive uploads, then succeeds
+type flakyUploader struct {
+	errs     []error
+	calls    int
+	contents []string
+	closed   bool
+}
+
+func (u *flakyUploader) Upload(_ context.Context, _, _ string, contents io.Reader) error {
+	data, _ := io.ReadAll(contents)
+	u.contents = append(u.contents, string(data))
+	u.calls++
+	if u.calls <= len(u.errs) {
+		return u.errs[u.calls-1]
+	}
+	return nil
+}
+
+func (u *flakyUploader) Close() error {
+	u.closed = true
+	return nil
+}
+
+func TestRetryingUploader(t *testing.T) {
+	timeout := fmt.Errorf("dial tcp: %w", os.ErrDeadlineExceeded)
+	reset := fmt.Errorf("write: %w", syscall.ECONNRESET)
+	denied := &s3StatusError{object: "s3://reports/products.txt", code: http.StatusForbidden, status: "403 Forbidden", body: "AccessDenied"}
+	throttled := &s3StatusError{object: "s3://reports/products.txt", code: http.StatusServiceUnavailable, status: "503 Service Unavailable", body: "SlowDown"}
+	authFailed := errors.New("ssh: handshake failed: ssh: unable to authenticate")
+
+	tests := []struct {
+		name      string
+		errs      []error
+		wantCalls int
+		wantErr   error
+	}{
+		{name: "success", wantCalls: 1},
+		{name: "timeout then success", errs: []error{timeout}, wantCalls: 2},
+		{name: "reset and throttled then success", errs: []error{reset, throttled}, wantCalls: 3},
+		{name: "attempts exhausted", errs: []error{timeout, timeout, timeout}, wantCalls: 3, wantErr: timeout},
+		{name: "access denied", errs: []error{denied}, wantCalls: 1, wantErr: denied},
+		{name: "authentication failure", errs: []error{authFailed}, wantCalls: 1, wantErr: authFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := &flakyUploader{errs: tt.errs}
+			uploader := NewRetryingUploader(next, 3, time.Millisecond, logger.New("info", false))
+
+			err := uploader.Upload(context.Background(), "products.txt", "text/plain", strings.NewReader("report"))
+
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil) != (err == nil) {
+				t.Fatalf("Upload() error = %v, want %v", err, tt.wantErr)
+			}
+			if next.calls != tt.wantCalls {
+				t.Errorf("uploads = %d, want %d", next.calls, tt.wantCalls)
+			}
+			for i, got := range next.contents {
+				if got != "report" {
+					t.Errorf("attempt %d sent %q, want the full contents", i+1, got)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryingUploaderRespectsContext(t *testing.T) {
+	timeout := fmt.Errorf("dial tcp: %w", os.ErrDeadlineExceeded)
+
+	t.Run("cancelled during backoff", func(t *testing.T) {
+		next := &flakyUploader{errs: []error{timeout, timeout}}
+		uploader := NewRetryingUploader(next, 3, time.Hour, logger.New("info", false))
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(10*time.Millisecond, cancel)
+
+		err := uploader.Upload(ctx, "products.txt", "text/plain", strings.NewReader("report"))
+
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Upload() error = %v, want context.Canceled", err)
+		}
+		if next.calls != 1 {
+			t.Errorf("uploads = %d, want 1", next.calls)
+		}
+	})
+
+	t.Run("backoff past the deadline", func(t *testing.T) {
+		next := &flakyUploader{errs: []error{timeout, timeout}}
+		uploader := NewRetryingUploader(next, 3, time.Hour, logger.New("info", false))
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		start := time.Now()
+		err := uploader.Upload(ctx, "products.txt", "text/plain", strings.NewReader("report"))
+
+		if !errors.Is(err, timeout) {
+			t.Errorf("Upload() error = %v, want the upload error", err)
+		}
+		if next.calls != 1 || time.Since(start) > time.Second {
+			t.Errorf("uploads = %d after %s, want 1 without waiting", next.calls, time.Since(start))
+		}
+	})
+}
+
+func TestRetryingUploaderClose(t *testing.T) {
+	next := &flakyUploader{}
+	if err := NewRetryingUploader(next, 0, 0, logger.New("info", false)).Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if !next.closed {
+		t.Error("Close() did not close the wrapped uploader")
+	}
+}
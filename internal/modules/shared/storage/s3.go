@@ -0,0 +1,128 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+)
+
+// maxErrorBody bounds how much of an S3 error response is kept in the error.
+const maxErrorBody = 512
+
+// S3Uploader puts objects into an S3 bucket with SigV4-signed HTTP requests.
+// Contents are buffered in memory to sign the payload, which suits reports
+// but not arbitrarily large files.
+type S3Uploader struct {
+	bucket      string
+	region      string
+	endpoint    string
+	credentials aws.CredentialsProvider
+	signer      *v4.Signer
+	client      *http.Client
+}
+
+// NewS3Uploader creates an uploader for bucket using the region, credentials
+// and optional BaseEndpoint of awsCfg.
+func NewS3Uploader(awsCfg aws.Config, bucket string, client *http.Client) (*S3Uploader, error) {
+	if awsCfg.Region == "" {
+		return nil, fmt.Errorf("AWS region is required for the s3 backend")
+	}
+	if awsCfg.Credentials == nil {
+		return nil, fmt.Errorf("AWS credentials are required for the s3 backend")
+	}
+	endpoint := ""
+	if awsCfg.BaseEndpoint != nil {
+		endpoint = strings.TrimRight(*awsCfg.BaseEndpoint, "/")
+	}
+	return &S3Uploader{
+		bucket:      bucket,
+		region:      awsCfg.Region,
+		endpoint:    endpoint,
+		credentials: awsCfg.Credentials,
+		signer:      v4.NewSigner(),
+		client:      client,
+	}, nil
+}
+
+// Upload puts contents at key destinationPath, stored with contentType when it
+// is set. A non-2xx response is an error.
+func (u *S3Uploader) Upload(ctx context.Context, destinationPath, contentType string, contents io.Reader) error {
+	body, err := io.ReadAll(contents)
+	if err != nil {
+		return fmt.Errorf("failed to read contents: %w", err)
+	}
+	sum := sha256.Sum256(body)
+	payloadHash := hex.EncodeToString(sum[:])
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u.objectURL(destinationPath), bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build S3 request: %w", err)
+	}
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	creds, err := u.credentials.Retrieve(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve AWS credentials: %w", err)
+	}
+	if err := u.signer.SignHTTP(ctx, creds, req, payloadHash, "s3", u.region, time.Now()); err != nil {
+		return fmt.Errorf("failed to sign S3 request: %w", err)
+	}
+
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to upload to s3://%s/%s: %w", u.bucket, destinationPath, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return &s3StatusError{
+			object: fmt.Sprintf("s3://%s/%s", u.bucket, destinationPath),
+			code:   resp.StatusCode,
+			status: resp.Status,
+			body:   string(bytes.TrimSpace(msg)),
+		}
+	}
+	return nil
+}
+
+// s3StatusError is a non-2xx response to an upload.
+type s3StatusError struct {
+	object string
+	code   int
+	status string
+	body   string
+}
+
+func (e *s3StatusError) Error() string {
+	return fmt.Sprintf("failed to upload to %s: %s: %s", e.object, e.status, e.body)
+}
+
+// Close implements Uploader. Connections belong to the shared HTTP client,
+// so there is nothing to release.
+func (u *S3Uploader) Close() error {
+	return nil
+}
+
+// objectURL addresses the object virtual-hosted style on AWS, or path style
+// below a custom endpoint such as LocalStack.
+func (u *S3Uploader) objectURL(key string) string {
+	escaped := (&url.URL{Path: "/" + strings.TrimLeft(key, "/")}).EscapedPath()
+	if u.endpoint != "" {
+		return u.endpoint + "/" + u.bucket + escaped
+	}
+	return fmt.Sprintf("https://%s.s3.%s.amazonaws.com%s", u.bucket, u.region, escaped)
+}
@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+func testAWSConfig(endpoint string) aws.Config {
+	return aws.Config{
+		Region:       "eu-west-1",
+		BaseEndpoint: aws.String(endpoint),
+		Credentials: aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+			return aws.Credentials{AccessKeyID: "test", SecretAccessKey: "test"}, nil
+		}),
+	}
+}
+
+func TestS3UploaderUpload(t *testing.T) {
+	var gotMethod, gotPath, gotBody, gotAuth, gotContentType string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotMethod, gotPath, gotBody, gotAuth = r.Method, r.URL.Path, string(body), r.Header.Get("Authorization")
+		gotContentType = r.Header.Get("Content-Type")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	uploader, err := NewS3Uploader(testAWSConfig(srv.URL), "reports-bucket", srv.Client())
+	if err != nil {
+		t.Fatalf("NewS3Uploader() error = %v", err)
+	}
+
+	if err := uploader.Upload(context.Background(), "daily/products.txt", "text/csv; charset=utf-8", strings.NewReader("report")); err != nil {
+		t.Fatalf("Upload() error = %v", err)
+	}
+
+	if gotMethod != http.MethodPut || gotPath != "/reports-bucket/daily/products.txt" {
+		t.Errorf("request = %s %s, want PUT /reports-bucket/daily/products.txt", gotMethod, gotPath)
+	}
+	if gotBody != "report" {
+		t.Errorf("body = %q, want %q", gotBody, "report")
+	}
+	if !strings.HasPrefix(gotAuth, "AWS4-HMAC-SHA256 ") || !strings.Contains(gotAuth, "/eu-west-1/s3/") {
+		t.Errorf("Authorization = %q, want a SigV4 signature for s3 in eu-west-1", gotAuth)
+	}
+	if gotContentType != "text/csv; charset=utf-8" {
+		t.Errorf("Content-Type = %q, want text/csv; charset=utf-8", gotContentType)
+	}
+}
+
+func TestS3UploaderUploadError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "<Error><Code>NoSuchBucket</Code></Error>", http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	uploader, err := NewS3Uploader(testAWSConfig(srv.URL), "missing-bucket", srv.Client())
+	if err != nil {
+		t.Fatalf("NewS3Uploader() error = %v", err)
+	}
+
+	err = uploader.Upload(context.Background(), "products.txt", "", strings.NewReader("report"))
+	if err == nil || !strings.Contains(err.Error(), "NoSuchBucket") {
+		t.Errorf("Upload() error = %v, want the S3 error code", err)
+	}
+}
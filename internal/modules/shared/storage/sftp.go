@@ -0,0 +1,219 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// sftpDialTimeout bounds the TCP connect and SSH handshake of the sftp backend.
+const sftpDialTimeout = 10 * time.Second
+
+// SFTPUploader writes files below a base path on an SFTP server. One SSH
+// connection is opened on the first upload and reused until it fails or the
+// uploader is closed; the next upload after a failure reconnects.
+type SFTPUploader struct {
+	addr     string
+	config   *ssh.ClientConfig
+	basePath string
+	// dial opens the SFTP session; tests replace it with an in-memory server.
+	dial func(ctx context.Context) (*sftpClient, io.Closer, error)
+
+	mu     sync.Mutex
+	client *sftpClient
+	conn   io.Closer
+}
+
+// NewSFTPUploader creates an uploader for the server at addr (host:port)
+// writing below basePath. The connection is opened lazily.
+func NewSFTPUploader(addr string, config *ssh.ClientConfig, basePath string) *SFTPUploader {
+	u := &SFTPUploader{
+		addr:     addr,
+		config:   config,
+		basePath: basePath,
+	}
+	u.dial = u.dialSSH
+	return u
+}
+
+// newSFTPUploaderFromConfig builds the ssh client configuration from the
+// custom.storage.sftp.* settings. The server's host key is required; password
+// and private key authentication are both offered when configured.
+func newSFTPUploaderFromConfig(cfg Config) (*SFTPUploader, error) {
+	if cfg.SFTPHost == "" {
+		return nil, fmt.Errorf("custom.storage.sftp.host is required for the sftp backend")
+	}
+	if cfg.SFTPUser == "" {
+		return nil, fmt.Errorf("custom.storage.sftp.user is required for the sftp backend")
+	}
+	if cfg.SFTPHostKey == "" {
+		return nil, fmt.Errorf("custom.storage.sftp.host.key is required for the sftp backend")
+	}
+	hostKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(cfg.SFTPHostKey))
+	if err != nil {
+		return nil, fmt.Errorf("invalid custom.storage.sftp.host.key: %w", err)
+	}
+
+	var auth []ssh.AuthMethod
+	if cfg.SFTPKeyFile != "" {
+		pem, err := os.ReadFile(cfg.SFTPKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read custom.storage.sftp.key.file: %w", err)
+		}
+		signer, err := ssh.ParsePrivateKey(pem)
+		if err != nil {
+			return nil, fmt.Errorf("invalid private key in %s: %w", cfg.SFTPKeyFile, err)
+		}
+		auth = append(auth, ssh.PublicKeys(signer))
+	}
+	if cfg.SFTPPassword != "" {
+		auth = append(auth, ssh.Password(cfg.SFTPPassword))
+	}
+	if len(auth) == 0 {
+		return nil, fmt.Errorf("custom.storage.sftp.password or custom.storage.sftp.key.file is required for the sftp backend")
+	}
+
+	port := cfg.SFTPPort
+	if port == 0 {
+		port = 22
+	}
+	return NewSFTPUploader(net.JoinHostPort(cfg.SFTPHost, strconv.Itoa(port)), &ssh.ClientConfig{
+		User:            cfg.SFTPUser,
+		Auth:            auth,
+		HostKeyCallback: ssh.FixedHostKey(hostKey),
+		Timeout:         sftpDialTimeout,
+	}, cfg.SFTPBasePath), nil
+}
+
+// Upload writes contents to destinationPath below the base path, creating
+// missing directories. The file is written to a temporary name and renamed,
+// so readers never see a partial file. Paths escaping the base path are
+// rejected. Cancelling ctx aborts the upload by closing the connection. SFTP
+// has no content types, so contentType is ignored.
+func (u *SFTPUploader) Upload(ctx context.Context, destinationPath, _ string, contents io.Reader) error {
+	rel := path.Clean(destinationPath)
+	if rel == "." || path.IsAbs(rel) || rel == ".." || strings.HasPrefix(rel, "../") {
+		return fmt.Errorf("invalid destination path %q", destinationPath)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if u.client == nil {
+		client, conn, err := u.dial(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to connect to sftp://%s: %w", u.addr, err)
+		}
+		u.client, u.conn = client, conn
+	}
+	conn := u.conn
+	stop := context.AfterFunc(ctx, func() { conn.Close() }) //nolint:errcheck // the upload reports the failure
+	defer stop()
+
+	err := u.put(u.client, path.Join(u.basePath, rel), contents)
+	if err == nil {
+		return nil
+	}
+	var status *sftpStatusError
+	if !errors.As(err, &status) {
+		// The session is unusable after a transport error or cancellation.
+		u.disconnect() //nolint:errcheck // the upload error is reported instead
+	}
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	return err
+}
+
+// put writes contents to dest through a temporary file next to it.
+func (u *SFTPUploader) put(c *sftpClient, dest string, contents io.Reader) error {
+	if err := c.mkdirAll(path.Dir(dest)); err != nil {
+		return err
+	}
+
+	tmp := path.Join(path.Dir(dest), "."+path.Base(dest)+".part")
+	if err := c.writeFile(tmp, contents); err != nil {
+		c.remove(tmp) //nolint:errcheck // the write error is reported instead
+		return err
+	}
+	if err := c.rename(tmp, dest); err != nil {
+		c.remove(tmp) //nolint:errcheck // the rename error is reported instead
+		return err
+	}
+	return nil
+}
+
+// Close closes the SSH connection, if one is open.
+func (u *SFTPUploader) Close() error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.disconnect()
+}
+
+// disconnect closes and forgets the current connection. u.mu must be held.
+func (u *SFTPUploader) disconnect() error {
+	if u.conn == nil {
+		return nil
+	}
+	err := u.conn.Close()
+	u.client, u.conn = nil, nil
+	return err
+}
+
+// dialSSH connects to the server and starts the sftp subsystem.
+func (u *SFTPUploader) dialSSH(ctx context.Context) (*sftpClient, io.Closer, error) {
+	dialer := net.Dialer{Timeout: u.config.Timeout}
+	netConn, err := dialer.DialContext(ctx, "tcp", u.addr)
+	if err != nil {
+		return nil, nil, err
+	}
+	stop := context.AfterFunc(ctx, func() { netConn.Close() }) //nolint:errcheck // the handshake reports the failure
+	defer stop()
+
+	sshConn, chans, reqs, err := ssh.NewClientConn(netConn, u.addr, u.config)
+	if err != nil {
+		netConn.Close() //nolint:errcheck // the handshake error is reported instead
+		return nil, nil, err
+	}
+	conn := ssh.NewClient(sshConn, chans, reqs)
+
+	client, err := startSFTP(conn)
+	if err != nil {
+		conn.Close() //nolint:errcheck // the subsystem error is reported instead
+		return nil, nil, err
+	}
+	return client, conn, nil
+}
+
+// startSFTP opens a session on conn running the sftp subsystem.
+func startSFTP(conn *ssh.Client) (*sftpClient, error) {
+	session, err := conn.NewSession()
+	if err != nil {
+		return nil, err
+	}
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := session.RequestSubsystem("sftp"); err != nil {
+		return nil, fmt.Errorf("failed to start sftp subsystem: %w", err)
+	}
+	return newSFTPClient(stdout, stdin)
+}
@@ -0,0 +1,296 @@
+package storage
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// SFTP protocol version 3 packet types and constants used by sftpClient
+// (draft-ietf-secsh-filexfer-02).
+const (
+	sftpVersion = 3
+
+	fxpInit     = 1
+	fxpVersion  = 2
+	fxpOpen     = 3
+	fxpClose    = 4
+	fxpWrite    = 6
+	fxpRemove   = 13
+	fxpMkdir    = 14
+	fxpStat     = 17
+	fxpRename   = 18
+	fxpStatus   = 101
+	fxpHandle   = 102
+	fxpAttrs    = 105
+	fxpExtended = 200
+
+	fxOK = 0
+
+	fxfWrite = 0x02
+	fxfCreat = 0x08
+	fxfTrunc = 0x10
+
+	// posixRenameExtension replaces the target atomically; plain SSH_FXP_RENAME
+	// fails when the target exists.
+	posixRenameExtension = "posix-rename@openssh.com"
+
+	// sftpChunkSize is the payload of one SSH_FXP_WRITE, the size every
+	// server must accept.
+	sftpChunkSize = 32 * 1024
+	// sftpMaxPacket bounds the responses sftpClient reads.
+	sftpMaxPacket = 256 * 1024
+)
+
+// sftpStatusError is an SSH_FXP_STATUS failure reported by the server. The
+// session stays usable after one.
+type sftpStatusError struct {
+	op   string
+	path string
+	code uint32
+	msg  string
+}
+
+func (e *sftpStatusError) Error() string {
+	return fmt.Sprintf("sftp %s %s: %s (status %d)", e.op, e.path, e.msg, e.code)
+}
+
+// sftpClient speaks the subset of SFTP version 3 needed to upload files. It
+// sends one request at a time and is not safe for concurrent use.
+type sftpClient struct {
+	r           io.Reader
+	w           io.Writer
+	nextID      uint32
+	posixRename bool
+}
+
+// newSFTPClient performs the SSH_FXP_INIT handshake over r and w, the
+// subsystem's stdout and stdin.
+func newSFTPClient(r io.Reader, w io.Writer) (*sftpClient, error) {
+	c := &sftpClient{r: r, w: w}
+	if err := c.writePacket(fxpInit, binary.BigEndian.AppendUint32(nil, sftpVersion)); err != nil {
+		return nil, fmt.Errorf("failed to send sftp init: %w", err)
+	}
+	typ, body, err := c.readPacket()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sftp version: %w", err)
+	}
+	if typ != fxpVersion || len(body) < 4 {
+		return nil, fmt.Errorf("unexpected sftp packet %d during handshake", typ)
+	}
+	if v := binary.BigEndian.Uint32(body); v < sftpVersion {
+		return nil, fmt.Errorf("unsupported sftp version %d", v)
+	}
+	for rest := body[4:]; len(rest) > 0; {
+		var name string
+		var ok bool
+		if name, rest, ok = readString(rest); !ok {
+			break
+		}
+		if _, rest, ok = readString(rest); !ok {
+			break
+		}
+		if name == posixRenameExtension {
+			c.posixRename = true
+		}
+	}
+	return c, nil
+}
+
+// mkdirAll creates dir and its missing parents. Directories that already
+// exist are left alone.
+func (c *sftpClient) mkdirAll(dir string) error {
+	if dir == "" || dir == "." || dir == "/" {
+		return nil
+	}
+	if err := c.stat(dir); err == nil {
+		return nil
+	}
+	if err := c.mkdirAll(path.Dir(dir)); err != nil {
+		return err
+	}
+	err := c.call("mkdir", dir, fxpMkdir, appendString(nil, dir), noAttrs)
+	if err != nil && c.stat(dir) == nil {
+		return nil // created concurrently
+	}
+	return err
+}
+
+// writeFile creates or truncates name and writes contents to it.
+func (c *sftpClient) writeFile(name string, contents io.Reader) error {
+	payload := appendString(nil, name)
+	payload = binary.BigEndian.AppendUint32(payload, fxfWrite|fxfCreat|fxfTrunc)
+	payload = append(payload, noAttrs...)
+	typ, body, err := c.request(fxpOpen, payload)
+	if err != nil {
+		return err
+	}
+	if typ == fxpStatus {
+		return statusError("open", name, body)
+	}
+	handle, _, ok := readString(body)
+	if typ != fxpHandle || !ok {
+		return fmt.Errorf("unexpected sftp packet %d for open", typ)
+	}
+
+	buf := make([]byte, sftpChunkSize)
+	var offset uint64
+	for {
+		n, readErr := contents.Read(buf)
+		if n > 0 {
+			chunk := appendString(nil, handle)
+			chunk = binary.BigEndian.AppendUint64(chunk, offset)
+			chunk = binary.BigEndian.AppendUint32(chunk, uint32(n))
+			chunk = append(chunk, buf[:n]...)
+			if err := c.call("write", name, fxpWrite, chunk); err != nil {
+				c.call("close", name, fxpClose, appendString(nil, handle)) //nolint:errcheck // the write error is reported instead
+				return err
+			}
+			offset += uint64(n)
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			c.call("close", name, fxpClose, appendString(nil, handle)) //nolint:errcheck // the read error is reported instead
+			return fmt.Errorf("failed to read contents: %w", readErr)
+		}
+	}
+	return c.call("close", name, fxpClose, appendString(nil, handle))
+}
+
+// rename moves oldName to newName, replacing newName. Without the
+// posix-rename extension the target is removed first, so the replacement
+// is not atomic.
+func (c *sftpClient) rename(oldName, newName string) error {
+	if c.posixRename {
+		payload := appendString(nil, posixRenameExtension)
+		payload = appendString(payload, oldName)
+		payload = appendString(payload, newName)
+		return c.call("rename", newName, fxpExtended, payload)
+	}
+	c.remove(newName) //nolint:errcheck // a missing target is expected
+	return c.call("rename", newName, fxpRename, appendString(appendString(nil, oldName), newName))
+}
+
+// remove deletes the file name.
+func (c *sftpClient) remove(name string) error {
+	return c.call("remove", name, fxpRemove, appendString(nil, name))
+}
+
+// stat reports whether name exists; the attributes are discarded.
+func (c *sftpClient) stat(name string) error {
+	typ, body, err := c.request(fxpStat, appendString(nil, name))
+	if err != nil {
+		return err
+	}
+	switch typ {
+	case fxpAttrs:
+		return nil
+	case fxpStatus:
+		return statusError("stat", name, body)
+	default:
+		return fmt.Errorf("unexpected sftp packet %d for stat", typ)
+	}
+}
+
+// call sends a request answered with SSH_FXP_STATUS and fails unless the
+// status is SSH_FX_OK.
+func (c *sftpClient) call(op, name string, typ byte, payload ...[]byte) error {
+	respType, body, err := c.request(typ, concat(payload))
+	if err != nil {
+		return err
+	}
+	if respType != fxpStatus {
+		return fmt.Errorf("unexpected sftp packet %d for %s", respType, op)
+	}
+	return statusError(op, name, body)
+}
+
+// request sends one packet with a fresh request ID and returns the type and
+// payload, after the ID, of the matching response.
+func (c *sftpClient) request(typ byte, payload []byte) (byte, []byte, error) {
+	c.nextID++
+	id := c.nextID
+	if err := c.writePacket(typ, append(binary.BigEndian.AppendUint32(nil, id), payload...)); err != nil {
+		return 0, nil, err
+	}
+	respType, body, err := c.readPacket()
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(body) < 4 || binary.BigEndian.Uint32(body) != id {
+		return 0, nil, fmt.Errorf("sftp response does not match request %d", id)
+	}
+	return respType, body[4:], nil
+}
+
+func (c *sftpClient) writePacket(typ byte, payload []byte) error {
+	pkt := binary.BigEndian.AppendUint32(make([]byte, 0, 5+len(payload)), uint32(1+len(payload)))
+	pkt = append(pkt, typ)
+	pkt = append(pkt, payload...)
+	_, err := c.w.Write(pkt)
+	return err
+}
+
+func (c *sftpClient) readPacket() (byte, []byte, error) {
+	var header [5]byte
+	if _, err := io.ReadFull(c.r, header[:]); err != nil {
+		return 0, nil, err
+	}
+	length := binary.BigEndian.Uint32(header[:4])
+	if length < 1 || length > sftpMaxPacket {
+		return 0, nil, fmt.Errorf("invalid sftp packet length %d", length)
+	}
+	body := make([]byte, length-1)
+	if _, err := io.ReadFull(c.r, body); err != nil {
+		return 0, nil, err
+	}
+	return header[4], body, nil
+}
+
+// noAttrs is an ATTRS structure with no fields set, leaving them to the server.
+var noAttrs = []byte{0, 0, 0, 0}
+
+// statusError decodes an SSH_FXP_STATUS payload; SSH_FX_OK yields nil.
+func statusError(op, name string, body []byte) error {
+	if len(body) < 4 {
+		return errors.New("truncated sftp status")
+	}
+	code := binary.BigEndian.Uint32(body)
+	if code == fxOK {
+		return nil
+	}
+	msg, _, _ := readString(body[4:])
+	if strings.TrimSpace(msg) == "" {
+		msg = "failure"
+	}
+	return &sftpStatusError{op: op, path: name, code: code, msg: msg}
+}
+
+func appendString(b []byte, s string) []byte {
+	b = binary.BigEndian.AppendUint32(b, uint32(len(s)))
+	return append(b, s...)
+}
+
+func readString(b []byte) (string, []byte, bool) {
+	if len(b) < 4 {
+		return "", nil, false
+	}
+	n := binary.BigEndian.Uint32(b)
+	if uint64(len(b)-4) < uint64(n) {
+		return "", nil, false
+	}
+	return string(b[4 : 4+n]), b[4+n:], true
+}
+
+func concat(parts [][]byte) []byte {
+	var out []byte
+	for _, p := range parts {
+		out = append(out, p...)
+	}
+	return out
+}
@@ -0,0 +1,269 @@
+package storage
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// memorySFTPServer is an in-memory SFTP server handling the requests
+// sftpClient sends.
+type memorySFTPServer struct {
+	mu          sync.Mutex
+	dirs        map[string]bool
+	files       map[string][]byte
+	readOnly    bool
+	posixRename bool
+	handles     map[string]string
+}
+
+func newMemorySFTPServer() *memorySFTPServer {
+	return &memorySFTPServer{
+		dirs:    map[string]bool{"/": true},
+		files:   map[string][]byte{},
+		handles: map[string]string{},
+	}
+}
+
+// dial starts a session and returns it the way SFTPUploader.dial does.
+func (s *memorySFTPServer) dial(context.Context) (*sftpClient, io.Closer, error) {
+	clientR, serverW := io.Pipe()
+	serverR, clientW := io.Pipe()
+	go s.serve(serverR, serverW)
+
+	client, err := newSFTPClient(clientR, clientW)
+	if err != nil {
+		return nil, nil, err
+	}
+	return client, closerFunc(func() error {
+		clientW.Close()
+		return clientR.Close()
+	}), nil
+}
+
+func (s *memorySFTPServer) serve(r io.ReadCloser, w io.WriteCloser) {
+	defer w.Close()
+	c := &sftpClient{r: r, w: w}
+	for {
+		typ, body, err := c.readPacket()
+		if err != nil {
+			return
+		}
+		if typ == fxpInit {
+			version := binary.BigEndian.AppendUint32(nil, sftpVersion)
+			if s.posixRename {
+				version = appendString(appendString(version, posixRenameExtension), "1")
+			}
+			c.writePacket(fxpVersion, version)
+			continue
+		}
+		id, body := body[:4], body[4:]
+		respType, resp := s.handle(typ, body)
+		c.writePacket(respType, append(append([]byte{}, id...), resp...))
+	}
+}
+
+func (s *memorySFTPServer) handle(typ byte, body []byte) (byte, []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	name, rest, _ := readString(body)
+	switch typ {
+	case fxpStat:
+		if s.dirs[name] || s.files[name] != nil {
+			return fxpAttrs, noAttrs
+		}
+		return status(2, "No such file")
+	case fxpMkdir:
+		if s.readOnly {
+			return status(3, "Permission denied")
+		}
+		if s.dirs[name] || !s.dirs[path.Dir(name)] {
+			return status(4, "Failure")
+		}
+		s.dirs[name] = true
+	case fxpOpen:
+		if s.readOnly {
+			return status(3, "Permission denied")
+		}
+		if !s.dirs[path.Dir(name)] {
+			return status(2, "No such file")
+		}
+		s.files[name] = []byte{}
+		s.handles[name] = name
+		return fxpHandle, appendString(nil, name)
+	case fxpWrite:
+		file := s.handles[name]
+		offset := binary.BigEndian.Uint64(rest)
+		data, _, _ := readString(rest[8:])
+		s.files[file] = append(s.files[file][:offset], data...)
+	case fxpClose:
+		delete(s.handles, name)
+	case fxpRemove:
+		if s.files[name] == nil {
+			return status(2, "No such file")
+		}
+		delete(s.files, name)
+	case fxpRename:
+		newName, _, _ := readString(rest)
+		if s.files[newName] != nil {
+			return status(4, "Failure")
+		}
+		s.files[newName] = s.files[name]
+		delete(s.files, name)
+	case fxpExtended:
+		oldName, rest, _ := readString(rest)
+		newName, _, _ := readString(rest)
+		s.files[newName] = s.files[oldName]
+		delete(s.files, oldName)
+	default:
+		return status(8, "Operation unsupported")
+	}
+	return status(fxOK, "")
+}
+
+func status(code uint32, msg string) (byte, []byte) {
+	return fxpStatus, appendString(appendString(binary.BigEndian.AppendUint32(nil, code), msg), "")
+}
+
+type closerFunc func() error
+
+func (f closerFunc) Close() error { return f() }
+
+func newTestSFTPUploader(server *memorySFTPServer) *SFTPUploader {
+	u := NewSFTPUploader("sftp.example.com:22", nil, "/upload")
+	u.dial = server.dial
+	return u
+}
+
+func TestSFTPUploaderUpload(t *testing.T) {
+	for _, posixRename := range []bool{false, true} {
+		server := newMemorySFTPServer()
+		server.posixRename = posixRename
+		uploader := newTestSFTPUploader(server)
+
+		contents := strings.Repeat("id\tname\n", sftpChunkSize/4)
+		for _, body := range []string{"stale", contents} {
+			if err := uploader.Upload(context.Background(), "reports/products.txt", "text/plain", strings.NewReader(body)); err != nil {
+				t.Fatalf("Upload() posixRename=%v error = %v", posixRename, err)
+			}
+		}
+
+		if got := string(server.files["/upload/reports/products.txt"]); got != contents {
+			t.Errorf("posixRename=%v uploaded %d bytes, want %d", posixRename, len(got), len(contents))
+		}
+		if len(server.files) != 1 {
+			t.Errorf("posixRename=%v server has %d files, want only the uploaded file", posixRename, len(server.files))
+		}
+		if err := uploader.Close(); err != nil {
+			t.Errorf("Close() error = %v", err)
+		}
+	}
+}
+
+func TestSFTPUploaderErrors(t *testing.T) {
+	t.Run("escaping path", func(t *testing.T) {
+		uploader := newTestSFTPUploader(newMemorySFTPServer())
+		for _, p := range []string{"../outside.txt", "/etc/passwd", "."} {
+			if err := uploader.Upload(context.Background(), p, "text/plain", strings.NewReader("x")); err == nil {
+				t.Errorf("Upload(%q) error = nil, want rejection", p)
+			}
+		}
+	})
+
+	t.Run("server failure keeps the connection", func(t *testing.T) {
+		server := newMemorySFTPServer()
+		server.readOnly = true
+		dials := 0
+		uploader := newTestSFTPUploader(server)
+		uploader.dial = func(ctx context.Context) (*sftpClient, io.Closer, error) {
+			dials++
+			return server.dial(ctx)
+		}
+
+		err := uploader.Upload(context.Background(), "products.txt", "text/plain", strings.NewReader("x"))
+		var status *sftpStatusError
+		if !errors.As(err, &status) || !strings.Contains(err.Error(), "Permission denied") {
+			t.Fatalf("Upload() error = %v, want permission denied status", err)
+		}
+
+		server.readOnly = false
+		if err := uploader.Upload(context.Background(), "products.txt", "text/plain", strings.NewReader("x")); err != nil {
+			t.Fatalf("Upload() error = %v", err)
+		}
+		if dials != 1 {
+			t.Errorf("dialed %d times, want the connection reused", dials)
+		}
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		uploader := newTestSFTPUploader(newMemorySFTPServer())
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if err := uploader.Upload(ctx, "products.txt", "text/plain", strings.NewReader("x")); !errors.Is(err, context.Canceled) {
+			t.Errorf("Upload() error = %v, want context.Canceled", err)
+		}
+	})
+}
+
+func TestNewSFTPConfig(t *testing.T) {
+	const hostKey = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIOMqqnkVzrm0SdG6UOoqKLsabgH5C9okWi0dh2l9GKJl"
+
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr string
+	}{
+		{
+			name: "password",
+			cfg:  Config{Backend: BackendSFTP, SFTPHost: "sftp.example.com", SFTPUser: "reports", SFTPPassword: "secret", SFTPHostKey: hostKey},
+		},
+		{
+			name:    "missing host",
+			cfg:     Config{Backend: BackendSFTP, SFTPUser: "reports", SFTPPassword: "secret", SFTPHostKey: hostKey},
+			wantErr: "custom.storage.sftp.host is required",
+		},
+		{
+			name:    "missing host key",
+			cfg:     Config{Backend: BackendSFTP, SFTPHost: "sftp.example.com", SFTPUser: "reports", SFTPPassword: "secret"},
+			wantErr: "custom.storage.sftp.host.key is required",
+		},
+		{
+			name:    "invalid host key",
+			cfg:     Config{Backend: BackendSFTP, SFTPHost: "sftp.example.com", SFTPUser: "reports", SFTPPassword: "secret", SFTPHostKey: "not-a-key"},
+			wantErr: "invalid custom.storage.sftp.host.key",
+		},
+		{
+			name:    "no credentials",
+			cfg:     Config{Backend: BackendSFTP, SFTPHost: "sftp.example.com", SFTPUser: "reports", SFTPHostKey: hostKey},
+			wantErr: "custom.storage.sftp.password or custom.storage.sftp.key.file is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uploader, err := New(context.Background(), tt.cfg)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("New() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			sftp, ok := uploader.(*SFTPUploader)
+			if !ok {
+				t.Fatalf("New() = %T, want *SFTPUploader", uploader)
+			}
+			if sftp.addr != "sftp.example.com:22" {
+				t.Errorf("addr = %q, want sftp.example.com:22", sftp.addr)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+// Package storage uploads generated files, such as reports, to a configurable
+// backend: the local filesystem, an S3 bucket or an SFTP server.
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+)
+
+// Backends selectable with Config.Backend.
+const (
+	BackendLocal = "local"
+	BackendS3    = "s3"
+	BackendSFTP  = "sftp"
+)
+
+// Uploader stores contents under destinationPath, a slash-separated path
+// relative to the backend's root (directory, bucket or base path).
+// contentType is the media type of contents; backends without object
+// metadata ignore it. Close
+// releases connections held by the backend; the uploader is not used after.
+type Uploader interface {
+	Upload(ctx context.Context, destinationPath, contentType string, contents io.Reader) error
+	Close() error
+}
+
+// Config selects and configures the upload backend. Populate it with config.InjectInto.
+type Config struct {
+	// Backend is "local", "s3" or "sftp".
+	Backend string `config:"custom.storage.backend" default:"local"`
+	// LocalDir is the root directory of the local backend.
+	LocalDir string `config:"custom.storage.local.dir" default:"reports"`
+	// S3Bucket is the bucket of the s3 backend.
+	S3Bucket string `config:"custom.storage.s3.bucket"`
+	// S3Region overrides the region from the default AWS configuration.
+	S3Region string `config:"custom.storage.s3.region"`
+	// EndpointURL points the s3 backend at LocalStack or another
+	// S3-compatible service; objects are then addressed path-style.
+	EndpointURL string `config:"custom.aws.endpoint.url"`
+	// SFTPHost and SFTPPort address the server of the sftp backend.
+	SFTPHost string `config:"custom.storage.sftp.host"`
+	SFTPPort int    `config:"custom.storage.sftp.port" default:"22"`
+	// SFTPUser authenticates with SFTPPassword, the private key in
+	// SFTPKeyFile, or both.
+	SFTPUser     string `config:"custom.storage.sftp.user"`
+	SFTPPassword string `config:"custom.storage.sftp.password"`
+	SFTPKeyFile  string `config:"custom.storage.sftp.key.file"`
+	// SFTPHostKey is the server's public host key in authorized_keys format,
+	// e.g. the contents of /etc/ssh/ssh_host_ed25519_key.pub. Other keys are
+	// rejected.
+	SFTPHostKey string `config:"custom.storage.sftp.host.key"`
+	// SFTPBasePath is the directory on the server uploads are written below.
+	SFTPBasePath string `config:"custom.storage.sftp.base.path"`
+	// UploadMaxAttempts and UploadRetryDelay configure NewRetryingUploader:
+	// transient failures are retried up to UploadMaxAttempts uploads in
+	// total, waiting UploadRetryDelay before the first retry and doubling it
+	// for each retry after that.
+	UploadMaxAttempts int           `config:"custom.storage.upload.max.attempts" default:"3"`
+	UploadRetryDelay  time.Duration `config:"custom.storage.upload.retry.delay" default:"500ms"`
+}
+
+// New creates the uploader selected by cfg.Backend. The s3 backend resolves
+// credentials and region through the default AWS configuration chain; the
+// sftp backend connects on the first upload.
+func New(ctx context.Context, cfg Config) (Uploader, error) {
+	switch cfg.Backend {
+	case BackendLocal:
+		return NewLocalUploader(cfg.LocalDir)
+	case BackendS3:
+		if cfg.S3Bucket == "" {
+			return nil, fmt.Errorf("custom.storage.s3.bucket is required for the s3 backend")
+		}
+		awsCfg, err := awsconfig.LoadDefaultConfig(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load AWS config: %w", err)
+		}
+		if cfg.S3Region != "" {
+			awsCfg.Region = cfg.S3Region
+		}
+		if cfg.EndpointURL != "" {
+			awsCfg.BaseEndpoint = aws.String(cfg.EndpointURL)
+		}
+		return NewS3Uploader(awsCfg, cfg.S3Bucket, http.DefaultClient)
+	case BackendSFTP:
+		return newSFTPUploaderFromConfig(cfg)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q (want %q, %q or %q)", cfg.Backend, BackendLocal, BackendS3, BackendSFTP)
+	}
+}
@@ -0,0 +1,343 @@
+// Package ttlcache provides an in-process, size-bounded cache whose entries
+// expire after a TTL. It holds no domain knowledge, so any module can use it.
+package ttlcache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Entry represents a cached value with expiration time
+type Entry struct {
+	Value     any
+	ExpiresAt time.Time
+	// Negative marks an entry stored by SetNegative
+	Negative bool
+
+	key string // map key, so an entry found by recency can be deleted
+}
+
+// notFound is the type of NotFound
+type notFound struct{}
+
+// NotFound is returned by Get for a key cached with SetNegative, so a key known
+// to be missing can be told apart from a cache miss (nil)
+var NotFound any = notFound{}
+
+// IsExpired checks if the cache entry has expired
+func (e *Entry) IsExpired() bool {
+	return time.Now().After(e.ExpiresAt)
+}
+
+// Metrics tracks cache performance statistics
+type Metrics struct {
+	Hits int64
+	// NegativeHits counts reads answered with NotFound; they are not in Hits
+	NegativeHits int64
+	Misses       int64
+	Evictions    int64
+	TotalReads   int64
+	TotalSize    int64
+}
+
+// HitRate calculates the cache hit rate as a percentage
+func (m *Metrics) HitRate() float64 {
+	if m.TotalReads == 0 {
+		return 0.0
+	}
+	return float64(m.Hits) / float64(m.TotalReads) * 100.0
+}
+
+const (
+	// DefaultCloseGrace is how long Close waits for the cleanup goroutine to exit
+	DefaultCloseGrace = time.Second
+
+	// DefaultNegativeTTL is how long SetNegative entries live unless
+	// WithNegativeTTL says otherwise; it never exceeds the cache TTL
+	DefaultNegativeTTL = 30 * time.Second
+)
+
+// Cache provides thread-safe TTL-based caching with size limits and metrics.
+// When full it evicts expired entries first, then the least recently used one.
+type Cache struct {
+	entries     map[string]*list.Element // values are *Entry
+	recency     *list.List               // most recently used at the front
+	ttl         time.Duration
+	negativeTTL time.Duration
+	maxSize     int
+	// keepExpired leaves expired Set entries to GetStale until the cache
+	// needs room, instead of dropping them in the periodic cleanup
+	keepExpired bool
+	// mu guards entries, recency and metrics. Get updates both, so every
+	// access takes it exclusively.
+	mu      sync.Mutex
+	metrics Metrics
+	stopCh  chan struct{}
+	doneCh  chan struct{} // closed when cleanupLoop returns
+	once    sync.Once
+}
+
+// Option configures optional Cache settings
+type Option func(*Cache)
+
+// WithNegativeTTL sets how long SetNegative entries live
+func WithNegativeTTL(ttl time.Duration) Option {
+	return func(c *Cache) {
+		c.negativeTTL = ttl
+	}
+}
+
+// WithKeepExpired keeps expired Set entries readable through GetStale. They
+// are still misses for Get and are dropped first when the cache is full.
+func WithKeepExpired() Option {
+	return func(c *Cache) {
+		c.keepExpired = true
+	}
+}
+
+// New creates a new cache with specified TTL and maximum size
+func New(ttl time.Duration, maxSize int, opts ...Option) *Cache {
+	cache := &Cache{
+		entries:     make(map[string]*list.Element),
+		recency:     list.New(),
+		ttl:         ttl,
+		negativeTTL: min(ttl, DefaultNegativeTTL),
+		maxSize:     maxSize,
+		stopCh:      make(chan struct{}),
+		doneCh:      make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(cache)
+	}
+
+	// Start background cleanup goroutine
+	go cache.cleanupLoop()
+
+	return cache
+}
+
+// Get retrieves a value from the cache, returning nil if not found or expired
+// and NotFound for a key stored with SetNegative. A hit marks the entry as
+// most recently used.
+func (c *Cache) Get(key string) any {
+	value, _ := c.GetWithExpiry(key)
+	return value
+}
+
+// GetWithExpiry is Get that also returns when the entry expires. The time is
+// zero when the value is nil.
+func (c *Cache) GetWithExpiry(key string) (any, time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.metrics.TotalReads++
+
+	elem, exists := c.entries[key]
+	if !exists || elem.Value.(*Entry).IsExpired() {
+		c.metrics.Misses++
+		return nil, time.Time{}
+	}
+
+	c.recency.MoveToFront(elem)
+	entry := elem.Value.(*Entry)
+
+	if entry.Negative {
+		c.metrics.NegativeHits++
+		return NotFound, entry.ExpiresAt
+	}
+
+	c.metrics.Hits++
+	return entry.Value, entry.ExpiresAt
+}
+
+// GetStale returns the value stored under key by Set even if it has expired,
+// or nil when there is none. Without WithKeepExpired an expired value is only
+// found until the next cleanup. It neither counts as a read nor marks the
+// entry as used.
+func (c *Cache) GetStale(key string) any {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, exists := c.entries[key]
+	if !exists {
+		return nil
+	}
+	entry := elem.Value.(*Entry)
+	if entry.Negative {
+		return nil
+	}
+	return entry.Value
+}
+
+// TTL returns how long Set entries live
+func (c *Cache) TTL() time.Duration {
+	return c.ttl
+}
+
+// Set stores a value in the cache with TTL expiration
+func (c *Cache) Set(key string, value any) {
+	c.store(key, &Entry{
+		Value:     value,
+		ExpiresAt: time.Now().Add(c.ttl),
+	})
+}
+
+// SetNegative records that key does not exist, so Get returns NotFound until
+// the negative TTL expires
+func (c *Cache) SetNegative(key string) {
+	c.store(key, &Entry{
+		ExpiresAt: time.Now().Add(c.negativeTTL),
+		Negative:  true,
+	})
+}
+
+// store adds entry under key as the most recently used entry, making room
+// first when the cache is full. Replacing an existing key evicts nothing.
+func (c *Cache) store(key string, entry *Entry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry.key = key
+	if elem, exists := c.entries[key]; exists {
+		elem.Value = entry
+		c.recency.MoveToFront(elem)
+		return
+	}
+
+	// Evict expired entries if we're at capacity
+	if len(c.entries) >= c.maxSize {
+		c.evictExpiredEntries(false)
+
+		// If still at capacity, evict the least recently used entry
+		if len(c.entries) >= c.maxSize {
+			c.evictLeastRecentlyUsed()
+		}
+	}
+
+	c.entries[key] = c.recency.PushFront(entry)
+
+	c.metrics.TotalSize = int64(len(c.entries))
+}
+
+// Delete removes a specific key from the cache
+func (c *Cache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, exists := c.entries[key]; exists {
+		c.remove(elem)
+	}
+	c.metrics.TotalSize = int64(len(c.entries))
+}
+
+// Clear removes all entries from the cache
+func (c *Cache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = make(map[string]*list.Element)
+	c.recency.Init()
+	c.metrics.TotalSize = 0
+}
+
+// Size returns the current number of entries in the cache
+func (c *Cache) Size() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// Metrics returns a copy of the current cache metrics, taken under the same
+// lock that updates them, so the counters are consistent with each other
+func (c *Cache) Metrics() Metrics {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.metrics
+}
+
+// Close stops the background cleanup goroutine, waiting up to
+// DefaultCloseGrace for an in-flight cleanup to finish
+func (c *Cache) Close() {
+	c.CloseWithGrace(DefaultCloseGrace)
+}
+
+// CloseWithGrace stops the background cleanup goroutine and waits up to grace
+// for it to exit. It reports whether the goroutine exited in time; a grace of
+// zero or less does not wait. It is safe to call more than once.
+func (c *Cache) CloseWithGrace(grace time.Duration) bool {
+	c.once.Do(func() {
+		close(c.stopCh)
+	})
+
+	if grace <= 0 {
+		select {
+		case <-c.doneCh:
+			return true
+		default:
+			return false
+		}
+	}
+
+	timer := time.NewTimer(grace)
+	defer timer.Stop()
+
+	select {
+	case <-c.doneCh:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// cleanupLoop runs periodically to remove expired entries
+func (c *Cache) cleanupLoop() {
+	defer close(c.doneCh)
+
+	ticker := time.NewTicker(c.ttl / 2) // Clean up twice per TTL period
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.cleanup()
+		case <-c.stopCh:
+			return
+		}
+	}
+}
+
+// cleanup removes expired entries from the cache
+func (c *Cache) cleanup() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.evictExpiredEntries(c.keepExpired)
+	c.metrics.TotalSize = int64(len(c.entries))
+}
+
+// evictExpiredEntries removes expired entries, except expired Set entries
+// when keepStale is true (must be called with write lock)
+func (c *Cache) evictExpiredEntries(keepStale bool) {
+	for _, elem := range c.entries {
+		entry := elem.Value.(*Entry)
+		if entry.IsExpired() && (!keepStale || entry.Negative) {
+			c.remove(elem)
+			c.metrics.Evictions++
+		}
+	}
+}
+
+// evictLeastRecentlyUsed removes the entry read or written longest ago (must be called with write lock)
+func (c *Cache) evictLeastRecentlyUsed() {
+	if elem := c.recency.Back(); elem != nil {
+		c.remove(elem)
+		c.metrics.Evictions++
+	}
+}
+
+// remove deletes elem from the map and the recency list (must be called with write lock)
+func (c *Cache) remove(elem *list.Element) {
+	delete(c.entries, elem.Value.(*Entry).key)
+	c.recency.Remove(elem)
+}
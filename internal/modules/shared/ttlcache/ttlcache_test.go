@@ -0,0 +1,278 @@
+package ttlcache
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCacheCloseWaitsForCleanup(t *testing.T) {
+	// Holding the write lock blocks the next cleanup mid-run.
+	cache := New(10*time.Millisecond, 10)
+	cache.mu.Lock()
+	time.Sleep(20 * time.Millisecond)
+
+	if cache.CloseWithGrace(20 * time.Millisecond) {
+		t.Fatal("CloseWithGrace() = true while cleanup is still running, want false after the grace period")
+	}
+
+	cache.mu.Unlock()
+
+	if !cache.CloseWithGrace(time.Second) {
+		t.Fatal("CloseWithGrace() = false after cleanup finished, want true")
+	}
+	select {
+	case <-cache.doneCh:
+	default:
+		t.Error("CloseWithGrace() returned true before the cleanup goroutine exited")
+	}
+}
+
+func TestCacheCloseIdle(t *testing.T) {
+	cache := New(time.Minute, 10)
+
+	start := time.Now()
+	cache.Close()
+
+	if elapsed := time.Since(start); elapsed >= DefaultCloseGrace {
+		t.Errorf("Close() took %v on an idle cache, want it to return once the goroutine exits", elapsed)
+	}
+	if !cache.CloseWithGrace(0) {
+		t.Error("CloseWithGrace(0) after Close() = false, want true")
+	}
+}
+
+func TestCacheSetNegative(t *testing.T) {
+	cache := New(time.Minute, 10, WithNegativeTTL(20*time.Millisecond))
+	defer cache.Close()
+
+	cache.SetNegative("missing")
+
+	if got := cache.Get("missing"); got != NotFound {
+		t.Fatalf("Get() after SetNegative() = %v, want NotFound", got)
+	}
+	if got := cache.Get("unknown"); got != nil {
+		t.Errorf("Get() of an unset key = %v, want nil", got)
+	}
+
+	metrics := cache.Metrics()
+	if metrics.NegativeHits != 1 || metrics.Hits != 0 || metrics.Misses != 1 {
+		t.Errorf("Metrics() = %+v, want 1 negative hit, 0 hits and 1 miss", metrics)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if got := cache.Get("missing"); got != nil {
+		t.Errorf("Get() after the negative TTL = %v, want nil", got)
+	}
+}
+
+func TestCacheKeepExpired(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      []Option
+		wantStale any
+	}{
+		{name: "kept", opts: []Option{WithKeepExpired()}, wantStale: 1},
+		{name: "dropped by cleanup", wantStale: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := New(10*time.Millisecond, 10, tt.opts...)
+			defer cache.Close()
+			cache.Set("present", 1)
+			cache.SetNegative("missing")
+
+			// Several cleanup intervals pass.
+			time.Sleep(50 * time.Millisecond)
+
+			if got := cache.Get("present"); got != nil {
+				t.Errorf("Get() of an expired entry = %v, want nil", got)
+			}
+			if got := cache.GetStale("present"); got != tt.wantStale {
+				t.Errorf("GetStale() = %v, want %v", got, tt.wantStale)
+			}
+			if got := cache.GetStale("missing"); got != nil {
+				t.Errorf("GetStale() of a negative entry = %v, want nil", got)
+			}
+		})
+	}
+}
+
+func TestCacheKeepExpiredMakesRoom(t *testing.T) {
+	cache := New(10*time.Millisecond, 1, WithKeepExpired())
+	defer cache.Close()
+	cache.Set("old", 1)
+	time.Sleep(20 * time.Millisecond)
+
+	cache.Set("new", 2)
+
+	if got := cache.GetStale("old"); got != nil {
+		t.Errorf("GetStale() of an expired entry in a full cache = %v, want it evicted", got)
+	}
+	if got := cache.Get("new"); got != 2 {
+		t.Errorf("Get(new) = %v, want 2", got)
+	}
+}
+
+func TestCacheGetWithExpiry(t *testing.T) {
+	cache := New(time.Minute, 10)
+	defer cache.Close()
+
+	before := time.Now()
+	cache.Set("present", 1)
+
+	value, expiresAt := cache.GetWithExpiry("present")
+	if value != 1 {
+		t.Errorf("GetWithExpiry() value = %v, want 1", value)
+	}
+	if expiresAt.Before(before.Add(time.Minute)) || expiresAt.After(time.Now().Add(time.Minute)) {
+		t.Errorf("GetWithExpiry() expiresAt = %v, want one TTL after Set", expiresAt)
+	}
+
+	if value, expiresAt := cache.GetWithExpiry("unknown"); value != nil || !expiresAt.IsZero() {
+		t.Errorf("GetWithExpiry() of an unset key = %v, %v, want nil and the zero time", value, expiresAt)
+	}
+}
+
+func TestCacheConcurrentGet(t *testing.T) {
+	cache := New(time.Minute, 10)
+	defer cache.Close()
+	cache.Set("present", 1)
+	cache.SetNegative("missing")
+
+	const readers, reads = 8, 500
+	var wg sync.WaitGroup
+	for range readers {
+		wg.Go(func() {
+			for i := range reads {
+				switch i % 3 {
+				case 0:
+					cache.Get("present")
+				case 1:
+					cache.Get("missing")
+				default:
+					cache.Get("unknown")
+				}
+			}
+		})
+	}
+	wg.Wait()
+
+	metrics := cache.Metrics()
+	if metrics.TotalReads != readers*reads {
+		t.Errorf("Metrics().TotalReads = %d, want %d", metrics.TotalReads, readers*reads)
+	}
+	if sum := metrics.Hits + metrics.NegativeHits + metrics.Misses; sum != metrics.TotalReads {
+		t.Errorf("Metrics() hits+negative hits+misses = %d, want TotalReads %d", sum, metrics.TotalReads)
+	}
+}
+
+func TestCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := New(time.Minute, 2)
+	defer cache.Close()
+
+	cache.Set("a", 1)
+	cache.Set("b", 2)
+	cache.Get("a") // b is now the least recently used
+	cache.Set("c", 3)
+
+	if got := cache.Get("b"); got != nil {
+		t.Errorf("Get(b) = %v, want nil after eviction", got)
+	}
+	if got := cache.Get("a"); got != 1 {
+		t.Errorf("Get(a) = %v, want 1", got)
+	}
+	if got := cache.Get("c"); got != 3 {
+		t.Errorf("Get(c) = %v, want 3", got)
+	}
+
+	// Replacing a cached key makes no room and evicts nothing.
+	cache.Set("a", 10)
+	if metrics := cache.Metrics(); metrics.Evictions != 1 || metrics.TotalSize != 2 {
+		t.Errorf("Metrics() = %+v, want 1 eviction and size 2", metrics)
+	}
+}
+
+// readThroughCache is the part of Cache the eviction benchmark exercises.
+type readThroughCache interface {
+	Get(key string) any
+	Set(key string, value any)
+}
+
+// expiryOrderedCache is the eviction strategy Cache used before LRU: when
+// full, drop the entry with the earliest expiration, i.e. the oldest write.
+type expiryOrderedCache struct {
+	entries map[string]time.Time
+	maxSize int
+	ttl     time.Duration
+}
+
+func (c *expiryOrderedCache) Get(key string) any {
+	if _, ok := c.entries[key]; ok {
+		return key
+	}
+	return nil
+}
+
+func (c *expiryOrderedCache) Set(key string, _ any) {
+	if len(c.entries) >= c.maxSize {
+		var oldestKey string
+		var oldest time.Time
+		for k, expiresAt := range c.entries {
+			if oldestKey == "" || expiresAt.Before(oldest) {
+				oldestKey, oldest = k, expiresAt
+			}
+		}
+		delete(c.entries, oldestKey)
+	}
+	c.entries[key] = time.Now().Add(c.ttl)
+}
+
+// BenchmarkCacheEvictionHitRate replays a read-through workload where 80% of
+// reads go to a small hot set and reports the hit rate of each strategy.
+func BenchmarkCacheEvictionHitRate(b *testing.B) {
+	const (
+		maxSize  = 100
+		hotKeys  = 50
+		coldKeys = 10000
+	)
+
+	strategies := []struct {
+		name  string
+		cache func() readThroughCache
+	}{
+		{name: "lru", cache: func() readThroughCache {
+			return New(time.Hour, maxSize)
+		}},
+		{name: "earliest-expiry", cache: func() readThroughCache {
+			return &expiryOrderedCache{entries: make(map[string]time.Time), maxSize: maxSize, ttl: time.Hour}
+		}},
+	}
+
+	for _, strategy := range strategies {
+		b.Run(strategy.name, func(b *testing.B) {
+			cache := strategy.cache()
+			if closer, ok := cache.(interface{ Close() }); ok {
+				defer closer.Close()
+			}
+			rng := rand.New(rand.NewPCG(1, 2))
+
+			hits := 0
+			for b.Loop() {
+				key := fmt.Sprintf("cold-%d", rng.IntN(coldKeys))
+				if rng.IntN(100) < 80 {
+					key = fmt.Sprintf("hot-%d", rng.IntN(hotKeys))
+				}
+				if cache.Get(key) != nil {
+					hits++
+					continue
+				}
+				cache.Set(key, key)
+			}
+			b.ReportMetric(float64(hits)/float64(b.N)*100, "hit%")
+		})
+	}
+}
@@ -0,0 +1,194 @@
+// Package handlers provides HTTP handlers for the tenants module.
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/shared/admin"
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/shared/secrets"
+	"github.com/gaborage/go-bricks/logger"
+	"github.com/gaborage/go-bricks/server"
+)
+
+const (
+	readinessReady      = "ready"
+	healthOK            = "ok"
+	tenantStoreOK       = "reachable"
+	tenantStoreDisabled = "disabled"
+
+	// tenantCacheTarget is the audit target of cache-wide operations.
+	tenantCacheTarget = "tenant-cache"
+
+	// HealthRoute is the health endpoint the handler serves with WithHealthRoute.
+	HealthRoute = "/health"
+)
+
+// TenantStore is the subset of the tenant store used by the tenant endpoints.
+type TenantStore interface {
+	HealthCheck(ctx context.Context) error
+	ClearCache()
+	WarmCache(ctx context.Context) (*secrets.WarmResult, error)
+}
+
+// ReadinessRequest carries no input; /readyz is a plain GET.
+type ReadinessRequest struct{}
+
+// ReadinessResponse reports whether the tenant store can be reached.
+type ReadinessResponse struct {
+	Status      string `json:"status"`
+	TenantStore string `json:"tenantStore"`
+}
+
+// HealthRequest carries no input; /health is a plain GET.
+type HealthRequest struct{}
+
+// HealthResponse reports the service as healthy along with the state of the
+// tenant store.
+type HealthResponse struct {
+	Status      string `json:"status"`
+	TenantStore string `json:"tenantStore"`
+}
+
+// RewarmCacheRequest carries no input; the rewarm covers every tenant.
+type RewarmCacheRequest struct{}
+
+// RewarmCacheResponse summarizes a cache rewarm. Errors maps tenant IDs to
+// the reason their configuration could not be reloaded.
+type RewarmCacheResponse struct {
+	Reloaded int               `json:"reloaded"`
+	Errors   map[string]string `json:"errors,omitempty"`
+}
+
+// TenantHandler serves tenant store endpoints.
+type TenantHandler struct {
+	store       TenantStore
+	guard       *admin.Guard
+	audit       admin.AuditLogger
+	logger      logger.Logger
+	serveHealth bool
+}
+
+// HandlerOption configures a TenantHandler.
+type HandlerOption func(*TenantHandler)
+
+// WithHealthRoute makes the handler serve GET /health, reporting the tenant
+// store alongside liveness. go-bricks registers its own probe on
+// server.path.health, so only use it when that probe is on another path.
+func WithHealthRoute() HandlerOption {
+	return func(h *TenantHandler) {
+		h.serveHealth = true
+	}
+}
+
+// NewTenantHandler creates a new tenant handler. A nil store means the
+// deployment is single-tenant and readiness does not depend on it.
+func NewTenantHandler(store TenantStore, guard *admin.Guard, audit admin.AuditLogger, l logger.Logger, opts ...HandlerOption) *TenantHandler {
+	h := &TenantHandler{
+		store:  store,
+		guard:  guard,
+		audit:  audit,
+		logger: l,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// Readyz reports 503 when the tenant store is unreachable, since no tenant
+// database config can be resolved without it.
+func (h *TenantHandler) Readyz(_ ReadinessRequest, ctx server.HandlerContext) (*ReadinessResponse, server.IAPIError) {
+	if h.store == nil {
+		return &ReadinessResponse{Status: readinessReady, TenantStore: tenantStoreDisabled}, nil
+	}
+
+	if err := h.store.HealthCheck(ctx.RequestContext()); err != nil {
+		h.logger.Warn().Err(err).Msg("Tenant store readiness check failed")
+		return nil, server.NewServiceUnavailableError("Tenant store is unreachable")
+	}
+
+	return &ReadinessResponse{Status: readinessReady, TenantStore: tenantStoreOK}, nil
+}
+
+// Health reports 503 when the tenant store is unreachable, so /health shows
+// whether a multi-tenant deployment can serve any tenant. In single-tenant
+// mode the store is reported as disabled.
+func (h *TenantHandler) Health(_ HealthRequest, ctx server.HandlerContext) (*HealthResponse, server.IAPIError) {
+	if h.store == nil {
+		return &HealthResponse{Status: healthOK, TenantStore: tenantStoreDisabled}, nil
+	}
+
+	if err := h.store.HealthCheck(ctx.RequestContext()); err != nil {
+		h.logger.Warn().Err(err).Msg("Tenant store health check failed")
+		return nil, server.NewServiceUnavailableError("Tenant store is unreachable")
+	}
+
+	return &HealthResponse{Status: healthOK, TenantStore: tenantStoreOK}, nil
+}
+
+// RewarmCache drops every cached tenant configuration and reloads it from the
+// store. Tenants that fail to load are reported individually; only a failure
+// to list tenants fails the request.
+func (h *TenantHandler) RewarmCache(_ RewarmCacheRequest, ctx server.HandlerContext) (*RewarmCacheResponse, server.IAPIError) {
+	if apiErr := h.guard.Authorize(ctx); apiErr != nil {
+		return nil, apiErr
+	}
+	if h.store == nil {
+		return nil, server.NewNotFoundError("Tenant store")
+	}
+
+	h.store.ClearCache()
+
+	result, err := h.store.WarmCache(ctx.RequestContext())
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Failed to rewarm tenant cache")
+		return nil, server.NewServiceUnavailableError("Tenant store is unreachable")
+	}
+
+	h.recordAudit(ctx, admin.ActionTenantCacheRewarm, tenantCacheTarget)
+
+	response := &RewarmCacheResponse{Reloaded: result.Loaded}
+	if len(result.Failed) > 0 {
+		response.Errors = make(map[string]string, len(result.Failed))
+		for tenantID, failure := range result.Failed {
+			response.Errors[tenantID] = failure.Error()
+		}
+	}
+
+	return response, nil
+}
+
+// recordAudit writes an audit entry for a completed admin mutation. The
+// mutation has already happened, so a failed write is logged, not returned.
+func (h *TenantHandler) recordAudit(ctx server.HandlerContext, action, target string) {
+	if h.audit == nil {
+		return
+	}
+	entry := admin.AuditEntry{
+		Actor:     h.guard.Actor(ctx),
+		Action:    action,
+		Target:    target,
+		Timestamp: time.Now().UTC(),
+	}
+	if err := h.audit.Record(ctx.RequestContext(), entry); err != nil {
+		h.logger.Error().Err(err).Str("action", action).Msg("Failed to write audit entry")
+	}
+}
+
+// RegisterRoutes registers tenant-related HTTP routes
+func (h *TenantHandler) RegisterRoutes(hr *server.HandlerRegistry, r server.RouteRegistrar) {
+	server.GET(hr, r, "/readyz", h.Readyz,
+		server.WithRawResponse(),
+		server.WithTags("health"),
+	)
+	if h.serveHealth {
+		server.GET(hr, r, HealthRoute, h.Health,
+			server.WithRawResponse(),
+			server.WithTags("health"),
+		)
+	}
+	server.POST(hr, r, "/admin/tenant-cache/rewarm", h.RewarmCache,
+		server.WithTags("admin"),
+	)
+}
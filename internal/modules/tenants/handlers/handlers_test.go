@@ -0,0 +1,315 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/shared/admin"
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/shared/secrets"
+	"github.com/gaborage/go-bricks/config"
+	"github.com/gaborage/go-bricks/logger"
+	"github.com/gaborage/go-bricks/server"
+)
+
+const (
+	testAdminToken = "s3cret"
+	testAdminActor = "ops@example.com"
+)
+
+// mockTenantStore implements TenantStore for testing and records the order of cache calls
+type mockTenantStore struct {
+	healthErr  error
+	warmResult *secrets.WarmResult
+	warmErr    error
+	calls      []string
+}
+
+func (m *mockTenantStore) HealthCheck(context.Context) error {
+	return m.healthErr
+}
+
+func (m *mockTenantStore) ClearCache() {
+	m.calls = append(m.calls, "clear")
+}
+
+func (m *mockTenantStore) WarmCache(context.Context) (*secrets.WarmResult, error) {
+	m.calls = append(m.calls, "warm")
+	if m.warmErr != nil {
+		return nil, m.warmErr
+	}
+	if m.warmResult == nil {
+		return nil, errors.New("not implemented")
+	}
+	return m.warmResult, nil
+}
+
+// recordingAuditLogger implements admin.AuditLogger and keeps every entry
+type recordingAuditLogger struct {
+	entries []admin.AuditEntry
+}
+
+func (r *recordingAuditLogger) Record(_ context.Context, entry admin.AuditEntry) error {
+	r.entries = append(r.entries, entry)
+	return nil
+}
+
+func newTestContext() server.HandlerContext {
+	req := httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/readyz", nil)
+	rec := httptest.NewRecorder()
+	return server.NewHandlerContextForTest(rec, req, &config.Config{})
+}
+
+func newAdminTestContext(token string) server.HandlerContext {
+	req := httptest.NewRequestWithContext(context.Background(), http.MethodPost, "/admin/tenant-cache/rewarm", nil)
+	if token != "" {
+		req.Header.Set(admin.HeaderToken, token)
+	}
+	req.Header.Set(admin.HeaderActor, testAdminActor)
+	rec := httptest.NewRecorder()
+	return server.NewHandlerContextForTest(rec, req, &config.Config{})
+}
+
+func TestReadyz(t *testing.T) {
+	tests := []struct {
+		name            string
+		store           TenantStore
+		wantStatus      int
+		wantTenantStore string
+	}{
+		{
+			name:            "healthy store",
+			store:           &mockTenantStore{},
+			wantStatus:      http.StatusOK,
+			wantTenantStore: tenantStoreOK,
+		},
+		{
+			name:       "unreachable store",
+			store:      &mockTenantStore{healthErr: errors.New("connection refused")},
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:            "no store configured",
+			store:           nil,
+			wantStatus:      http.StatusOK,
+			wantTenantStore: tenantStoreDisabled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewTenantHandler(tt.store, nil, nil, logger.New("info", false))
+
+			response, apiErr := handler.Readyz(ReadinessRequest{}, newTestContext())
+
+			if tt.wantStatus != http.StatusOK {
+				if apiErr == nil {
+					t.Fatalf("Readyz() expected error with status %d, got nil", tt.wantStatus)
+				}
+				if apiErr.HTTPStatus() != tt.wantStatus {
+					t.Errorf("Readyz() status = %v, want %v", apiErr.HTTPStatus(), tt.wantStatus)
+				}
+				return
+			}
+
+			if apiErr != nil {
+				t.Fatalf("Readyz() unexpected error = %v", apiErr)
+			}
+			if response.TenantStore != tt.wantTenantStore {
+				t.Errorf("Readyz() tenantStore = %v, want %v", response.TenantStore, tt.wantTenantStore)
+			}
+		})
+	}
+}
+
+func TestRewarmCache(t *testing.T) {
+	enabledGuard := admin.NewGuard(admin.Config{Enabled: true, Token: testAdminToken})
+
+	tests := []struct {
+		name       string
+		guard      *admin.Guard
+		token      string
+		store      *mockTenantStore
+		wantStatus int
+		wantCalls  []string
+		wantResp   *RewarmCacheResponse
+	}{
+		{
+			name:  "clears then warms and returns summary",
+			guard: enabledGuard,
+			token: testAdminToken,
+			store: &mockTenantStore{warmResult: &secrets.WarmResult{
+				Loaded: 2,
+				Failed: map[string]error{"tenant3": errors.New("access denied")},
+			}},
+			wantStatus: http.StatusOK,
+			wantCalls:  []string{"clear", "warm"},
+			wantResp: &RewarmCacheResponse{
+				Reloaded: 2,
+				Errors:   map[string]string{"tenant3": "access denied"},
+			},
+		},
+		{
+			name:       "list failure",
+			guard:      enabledGuard,
+			token:      testAdminToken,
+			store:      &mockTenantStore{warmErr: errors.New("throttled")},
+			wantStatus: http.StatusServiceUnavailable,
+			wantCalls:  []string{"clear", "warm"},
+		},
+		{
+			name:       "admin disabled",
+			guard:      admin.NewGuard(admin.Config{}),
+			token:      testAdminToken,
+			store:      &mockTenantStore{},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "wrong token",
+			guard:      enabledGuard,
+			token:      "wrong",
+			store:      &mockTenantStore{},
+			wantStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			audit := &recordingAuditLogger{}
+			handler := NewTenantHandler(tt.store, tt.guard, audit, logger.New("info", false))
+
+			response, apiErr := handler.RewarmCache(RewarmCacheRequest{}, newAdminTestContext(tt.token))
+
+			if !reflect.DeepEqual(tt.store.calls, tt.wantCalls) {
+				t.Errorf("RewarmCache() store calls = %v, want %v", tt.store.calls, tt.wantCalls)
+			}
+
+			wantAudited := tt.wantStatus == http.StatusOK
+			if (len(audit.entries) == 1) != wantAudited {
+				t.Fatalf("RewarmCache() wrote %d audit entries, want audited = %v", len(audit.entries), wantAudited)
+			}
+			if wantAudited {
+				entry := audit.entries[0]
+				if entry.Action != admin.ActionTenantCacheRewarm || entry.Target != tenantCacheTarget || entry.Actor != testAdminActor {
+					t.Errorf("RewarmCache() audit entry = %+v, want action %q target %q actor %q",
+						entry, admin.ActionTenantCacheRewarm, tenantCacheTarget, testAdminActor)
+				}
+				if entry.Timestamp.IsZero() {
+					t.Error("RewarmCache() audit entry has zero timestamp")
+				}
+			}
+
+			if tt.wantStatus != http.StatusOK {
+				if apiErr == nil {
+					t.Fatalf("RewarmCache() expected error with status %d, got nil", tt.wantStatus)
+				}
+				if apiErr.HTTPStatus() != tt.wantStatus {
+					t.Errorf("RewarmCache() status = %v, want %v", apiErr.HTTPStatus(), tt.wantStatus)
+				}
+				return
+			}
+
+			if apiErr != nil {
+				t.Fatalf("RewarmCache() unexpected error = %v", apiErr)
+			}
+			if !reflect.DeepEqual(response, tt.wantResp) {
+				t.Errorf("RewarmCache() response = %+v, want %+v", response, tt.wantResp)
+			}
+		})
+	}
+}
+
+func TestHealth(t *testing.T) {
+	tests := []struct {
+		name            string
+		store           TenantStore
+		wantStatus      int
+		wantTenantStore string
+	}{
+		{
+			name:            "healthy store",
+			store:           &mockTenantStore{},
+			wantStatus:      http.StatusOK,
+			wantTenantStore: tenantStoreOK,
+		},
+		{
+			name:       "unreachable store",
+			store:      &mockTenantStore{healthErr: errors.New("connection refused")},
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:            "no store configured",
+			store:           nil,
+			wantStatus:      http.StatusOK,
+			wantTenantStore: tenantStoreDisabled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewTenantHandler(tt.store, nil, nil, logger.New("info", false), WithHealthRoute())
+
+			response, apiErr := handler.Health(HealthRequest{}, newTestContext())
+
+			if tt.wantStatus != http.StatusOK {
+				if apiErr == nil || apiErr.HTTPStatus() != tt.wantStatus {
+					t.Fatalf("Health() error = %v, want status %d", apiErr, tt.wantStatus)
+				}
+				return
+			}
+
+			if apiErr != nil {
+				t.Fatalf("Health() unexpected error = %v", apiErr)
+			}
+			if response.Status != healthOK || response.TenantStore != tt.wantTenantStore {
+				t.Errorf("Health() = %+v, want status %q tenantStore %q", response, healthOK, tt.wantTenantStore)
+			}
+		})
+	}
+}
+
+// recordingRegistrar implements server.RouteRegistrar and keeps the paths
+// of registered routes.
+type recordingRegistrar struct {
+	paths []string
+}
+
+func (r *recordingRegistrar) Add(_, path string, _ server.Handler, _ ...server.MiddlewareFunc) {
+	r.paths = append(r.paths, path)
+}
+
+func (r *recordingRegistrar) Group(string, ...server.MiddlewareFunc) server.RouteRegistrar {
+	return r
+}
+
+func (r *recordingRegistrar) Use(...server.MiddlewareFunc) {}
+
+func (r *recordingRegistrar) FullPath(path string) string { return path }
+
+func TestRegisterRoutesHealth(t *testing.T) {
+	tests := []struct {
+		name       string
+		opts       []HandlerOption
+		wantHealth bool
+	}{
+		{name: "framework owns /health"},
+		{name: "with health route", opts: []HandlerOption{WithHealthRoute()}, wantHealth: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewTenantHandler(&mockTenantStore{}, nil, nil, logger.New("info", false), tt.opts...)
+			registrar := &recordingRegistrar{}
+
+			handler.RegisterRoutes(server.NewHandlerRegistry(&config.Config{}), registrar)
+
+			if got := slices.Contains(registrar.paths, HealthRoute); got != tt.wantHealth {
+				t.Errorf("RegisterRoutes() registered %v, want %s registered = %v", registrar.paths, HealthRoute, tt.wantHealth)
+			}
+		})
+	}
+}
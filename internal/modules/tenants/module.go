@@ -0,0 +1,154 @@
+// Package tenants owns the multi-tenant configuration source. When
+// multitenant mode is enabled it takes the configured tenant store from the
+// shared secrets.Provider, warms an AWS Secrets Manager store's cache in the
+// background, exposes the store's reachability via GET /readyz and GET
+// /health and lets operators rewarm the cache via POST
+// /admin/tenant-cache/rewarm.
+package tenants
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/shared/admin"
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/shared/secrets"
+	"github.com/gaborage/go-bricks-demo-project/internal/modules/tenants/handlers"
+	"github.com/gaborage/go-bricks/app"
+	"github.com/gaborage/go-bricks/config"
+	"github.com/gaborage/go-bricks/logger"
+	"github.com/gaborage/go-bricks/messaging"
+	"github.com/gaborage/go-bricks/server"
+)
+
+// Module wires the tenant store and its HTTP surface.
+type Module struct {
+	stores  *secrets.Provider
+	store   secrets.TenantStore // nil unless multitenant mode is enabled
+	handler *handlers.TenantHandler
+	logger  logger.Logger
+
+	// cancelWarm stops a startup warm-up still running at Shutdown; warming
+	// is done once it has returned.
+	cancelWarm context.CancelFunc
+	warming    sync.WaitGroup
+}
+
+// NewModule creates a new tenants module instance that takes its tenant
+// store from stores.
+func NewModule(stores *secrets.Provider) *Module {
+	return &Module{stores: stores}
+}
+
+// Name returns the module name for registration.
+func (m *Module) Name() string {
+	return "tenants"
+}
+
+// Init acquires the tenant store selected by custom.tenants.store when
+// multitenant mode is enabled. In single-tenant mode no store is created and /readyz reports it as disabled.
+func (m *Module) Init(deps *app.ModuleDeps) error {
+	m.logger = deps.Logger.WithFields(map[string]any{
+		"module": "tenants",
+	})
+
+	var adminCfg admin.Config
+	if err := deps.Config.InjectInto(&adminCfg); err != nil {
+		return fmt.Errorf("failed to load admin config: %w", err)
+	}
+	guard := admin.NewGuard(adminCfg)
+	audit := admin.NewDBAuditLogger(deps.DB)
+
+	var opts []handlers.HandlerOption
+	if healthRouteFree(deps.Config) {
+		opts = append(opts, handlers.WithHealthRoute())
+	}
+
+	if !deps.Config.Multitenant.Enabled {
+		m.logger.Info().Msg("Multitenant mode disabled - tenant store not initialized")
+		m.handler = handlers.NewTenantHandler(nil, guard, audit, m.logger, opts...)
+		return nil
+	}
+	if len(opts) == 0 {
+		m.logger.Warn().Msg("go-bricks serves /health, so only /readyz checks the tenant store; set server.path.health to another path to check it on /health")
+	}
+
+	cfg, err := secrets.LoadProviderConfig(deps.Config)
+	if err != nil {
+		return err
+	}
+
+	store, err := m.stores.Store(context.Background(), m.logger, cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create tenant store: %w", err)
+	}
+	m.store = store
+	m.handler = handlers.NewTenantHandler(store, guard, audit, m.logger, opts...)
+
+	// The other backends read local data, so their first lookups are cheap.
+	if cfg.Backend == secrets.BackendAWS && cfg.AWS.WarmOnStartup {
+		m.startWarmUp(cfg.AWS.WarmTimeout)
+	}
+
+	m.logger.Info().Msg("Tenants module initialized successfully")
+
+	return nil
+}
+
+// healthRouteFree reports whether the go-bricks liveness probe, registered on
+// server.path.health (/health by default), has been moved off /health, so
+// this module can serve /health with the tenant store check.
+func healthRouteFree(cfg *config.Config) bool {
+	path := cfg.Server.Path.Health
+	return path != "" && "/"+strings.TrimPrefix(path, "/") != handlers.HealthRoute
+}
+
+// startWarmUp loads every tenant's database config into the store cache in
+// the background so startup is not held up by Secrets Manager. A
+// non-positive timeout leaves the warm-up bounded only by Shutdown.
+func (m *Module) startWarmUp(timeout time.Duration) {
+	ctx, cancel := context.WithCancel(context.Background())
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), timeout)
+	}
+	m.cancelWarm = cancel
+
+	m.warming.Go(func() {
+		defer cancel()
+		if _, err := m.store.WarmCache(ctx); err != nil {
+			m.logger.Warn().Err(err).Msg("Startup tenant cache warm-up failed; tenants will load on first use")
+		}
+	})
+}
+
+// RegisterRoutes registers HTTP endpoints for tenant store operations.
+func (m *Module) RegisterRoutes(hr *server.HandlerRegistry, r server.RouteRegistrar) {
+	m.handler.RegisterRoutes(hr, r)
+}
+
+// DeclareMessaging declares messaging infrastructure for this module.
+func (m *Module) DeclareMessaging(_ *messaging.Declarations) {
+	// No messaging needed for tenants module.
+}
+
+// RegisterJobs registers scheduled jobs for this module.
+func (m *Module) RegisterJobs(_ app.JobRegistrar) error {
+	// No scheduled jobs for tenants module.
+	return nil
+}
+
+// Shutdown stops a running warm-up and releases the tenant store; the
+// provider closes it once no module holds it.
+func (m *Module) Shutdown() error {
+	if m.cancelWarm != nil {
+		m.cancelWarm()
+		m.warming.Wait()
+	}
+	if m.store != nil {
+		m.store = nil
+		return m.stores.Release()
+	}
+	return nil
+}
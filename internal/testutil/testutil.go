@@ -0,0 +1,87 @@
+// Package testutil provides the logger, config and request context that
+// handler tests across modules build their handlers with.
+package testutil
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/gaborage/go-bricks/config"
+	"github.com/gaborage/go-bricks/logger"
+	"github.com/gaborage/go-bricks/server"
+)
+
+// NewLogger returns an info-level logger for handlers under test.
+func NewLogger() logger.Logger {
+	return logger.New("info", false)
+}
+
+// NewConfig returns a debug-mode test application config, so error
+// responses keep their details.
+func NewConfig() *config.Config {
+	return &config.Config{
+		App: config.AppConfig{
+			Name:    "test",
+			Version: "1.0.0",
+			Env:     "test",
+			Debug:   true,
+		},
+	}
+}
+
+// NewContext returns a handler context for a bare GET / request, for
+// handlers that do not read the request.
+func NewContext(cfg *config.Config) server.HandlerContext {
+	ctx, _ := NewRequestContext(cfg, http.MethodGet, "/")
+	return ctx
+}
+
+// RequestOption customizes the request built by NewRequestContext.
+type RequestOption func(*requestSpec)
+
+type requestSpec struct {
+	ctx     context.Context
+	body    io.Reader
+	headers http.Header
+}
+
+// WithBody sets the request body.
+func WithBody(body string) RequestOption {
+	return func(s *requestSpec) {
+		s.body = strings.NewReader(body)
+	}
+}
+
+// WithHeader sets a request header.
+func WithHeader(key, value string) RequestOption {
+	return func(s *requestSpec) {
+		s.headers.Set(key, value)
+	}
+}
+
+// WithContext sets the request's context, e.g. one that is canceled or past
+// its deadline.
+func WithContext(ctx context.Context) RequestOption {
+	return func(s *requestSpec) {
+		s.ctx = ctx
+	}
+}
+
+// NewRequestContext builds a handler context for a method request to target
+// (path plus optional query) and returns the recorder capturing the response.
+func NewRequestContext(cfg *config.Config, method, target string, opts ...RequestOption) (server.HandlerContext, *httptest.ResponseRecorder) {
+	spec := requestSpec{ctx: context.Background(), headers: http.Header{}}
+	for _, opt := range opts {
+		opt(&spec)
+	}
+
+	req := httptest.NewRequestWithContext(spec.ctx, method, target, spec.body)
+	for key, values := range spec.headers {
+		req.Header[key] = values
+	}
+	rec := httptest.NewRecorder()
+	return server.NewHandlerContextForTest(rec, req, cfg), rec
+}
@@ -0,0 +1,38 @@
+package testutil
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestNewRequestContext(t *testing.T) {
+	reqCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ctx, rec := NewRequestContext(NewConfig(), http.MethodPost, "/products?page=2",
+		WithBody(`{"name":"x"}`),
+		WithHeader("Content-Type", "application/json"),
+		WithContext(reqCtx),
+	)
+
+	req := ctx.Request()
+	if req.Method != http.MethodPost || req.URL.Path != "/products" || ctx.Query("page") != "2" {
+		t.Errorf("request = %s %s, want POST /products?page=2", req.Method, req.URL)
+	}
+	if got := ctx.RequestHeader("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", got)
+	}
+	if body, _ := io.ReadAll(req.Body); string(body) != `{"name":"x"}` {
+		t.Errorf("body = %q, want %q", body, `{"name":"x"}`)
+	}
+	if ctx.RequestContext() != reqCtx {
+		t.Error("request context was not applied")
+	}
+
+	ctx.ResponseWriter().Header().Set("X-Test", "1")
+	if rec.Header().Get("X-Test") != "1" {
+		t.Error("recorder does not capture the handler's response")
+	}
+}
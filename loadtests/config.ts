@@ -74,6 +74,14 @@ export function getRandomProduct(): SampleProduct {
   return products[Math.floor(Math.random() * products.length)];
 }
 
+/**
+ * Helper function to generate a unique product SKU
+ * SKUs must be unique among live products, so every create needs a fresh one
+ */
+export function newSKU(): string {
+  return `LT-${Date.now()}-${Math.random().toString(36).slice(2, 10).toUpperCase()}`;
+}
+
 /**
  * Helper function to get random page number
  */
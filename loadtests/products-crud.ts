@@ -20,6 +20,7 @@ import {
   config,
   getURL,
   getRandomProduct,
+  newSKU,
   getRandomPage,
   getSeededProductID,
   headers,
@@ -157,9 +158,10 @@ function createProduct(): void {
 
   // Add some randomness to make each product unique
   const uniqueProduct: CreateProductInput = {
+    sku: newSKU(),
     name: `${product.name} ${Date.now()}`,
     description: product.description,
-    price: product.price + (Math.random() * 10),
+    price: Math.round((product.price + Math.random() * 10) * 100) / 100,
     imageURL: `https://example.com/products/${Date.now()}.jpg`,
   };
 
@@ -213,7 +215,7 @@ function performUpdate(productID: string): void {
 
   const updates: UpdateProductInput = {
     name: `Updated Product ${Date.now()}`,
-    price: Math.random() * 200 + 10,
+    price: Math.round((Math.random() * 200 + 10) * 100) / 100,
     description: 'Updated during load test',
   };
 
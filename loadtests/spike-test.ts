@@ -21,7 +21,7 @@ import { check } from 'k6';
 import { Rate, Trend, Gauge } from 'k6/metrics';
 import type { Options } from 'k6/options';
 import type { RefinedResponse, ResponseType } from 'k6/http';
-import { config, getURL, getRandomProduct, getRandomPage, getSeededProductID, headers, resolveSpikeScenario, spikePhaseBoundaries, summaryOutputs, maybeSleep } from './config.ts';
+import { config, getURL, getRandomProduct, getRandomPage, getSeededProductID, newSKU, headers, resolveSpikeScenario, spikePhaseBoundaries, summaryOutputs, maybeSleep } from './config.ts';
 import type { ProductResponse, CreateProductInput, UpdateProductInput } from './types/index.ts';
 
 // Custom metrics
@@ -196,6 +196,7 @@ function createProduct(): RefinedResponse<ResponseType | undefined> {
   const url = getURL('/products');
 
   const uniqueProduct: CreateProductInput = {
+    sku: newSKU(),
     name: `${product.name} ${Date.now()}-${__VU}`,
     description: product.description,
     price: product.price,
@@ -241,7 +242,7 @@ function updateProduct(): RefinedResponse<ResponseType | undefined> {
   const url = getURL(`/products/${productID}`);
 
   const updates: UpdateProductInput = {
-    price: Math.random() * 200 + 10,
+    price: Math.round((Math.random() * 200 + 10) * 100) / 100,
   };
 
   const response = http.put(url, JSON.stringify(updates), {
@@ -18,7 +18,7 @@ import { check, sleep } from 'k6';
 import { Rate, Trend, Counter, Gauge } from 'k6/metrics';
 import type { Options } from 'k6/options';
 import type { RefinedResponse, ResponseType } from 'k6/http';
-import { config, getURL, getRandomProduct, getRandomPage, getSeededProductID, headers } from './config.ts';
+import { config, getURL, getRandomProduct, getRandomPage, getSeededProductID, newSKU, headers } from './config.ts';
 import type { ProductResponse, CreateProductInput, UpdateProductInput } from './types/index.ts';
 
 // Custom metrics to detect degradation over time
@@ -213,9 +213,10 @@ function createProduct(): RefinedResponse<ResponseType | undefined> {
   const url = getURL('/products');
 
   const uniqueProduct: CreateProductInput = {
+    sku: newSKU(),
     name: `${product.name} ${Date.now()}-${__VU}-${__ITER}`,
     description: product.description,
-    price: product.price + (Math.random() * 10),
+    price: Math.round((product.price + Math.random() * 10) * 100) / 100,
     imageURL: `https://example.com/products/${Date.now()}-${__VU}.jpg`,
   };
 
@@ -257,7 +258,7 @@ function updateProduct(): RefinedResponse<ResponseType | undefined> {
   const url = getURL(`/products/${productID}`);
 
   const updates: UpdateProductInput = {
-    price: Math.random() * 200 + 10,
+    price: Math.round((Math.random() * 200 + 10) * 100) / 100,
     description: `Updated at ${Date.now()}`,
   };
 
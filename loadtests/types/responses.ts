@@ -5,6 +5,7 @@
  */
 export interface Product {
   id: string;
+  sku: string;
   name: string;
   description: string;
   price: number;
@@ -21,6 +22,7 @@ export interface ProductResponse {
   data?: Product;
   // Fallback for direct product response (no wrapper)
   id?: string;
+  sku?: string;
   name?: string;
   description?: string;
   price?: number;
@@ -54,6 +56,7 @@ export interface ProductListResponse {
  * Product creation input
  */
 export interface CreateProductInput {
+  sku: string;
   name: string;
   description: string;
   price: number;
@@ -0,0 +1,15 @@
+-- V1: Create product views table (analytics database)
+-- One row per product view event
+
+CREATE TABLE IF NOT EXISTS product_views (
+    id UUID PRIMARY KEY,
+    product_id VARCHAR(255) NOT NULL,
+    viewed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    user_agent TEXT,
+    ip_address VARCHAR(45),
+    session_id VARCHAR(255),
+    referrer TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_views_product_id ON product_views(product_id);
+CREATE INDEX IF NOT EXISTS idx_product_views_viewed_at ON product_views(viewed_at DESC);
@@ -0,0 +1,8 @@
+-- V2: Create tracked products table (analytics database)
+-- Products seeded from product.created events so they appear in the
+-- top viewed list with zero views before their first view
+
+CREATE TABLE IF NOT EXISTS tracked_products (
+    product_id VARCHAR(255) PRIMARY KEY,
+    tracked_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
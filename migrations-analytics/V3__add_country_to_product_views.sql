@@ -0,0 +1,4 @@
+-- V3: Add country to product views (analytics database)
+-- Filled in after the view is recorded, once geo-enrichment resolves the IP address
+
+ALTER TABLE product_views ADD COLUMN IF NOT EXISTS country VARCHAR(2);
@@ -0,0 +1,4 @@
+-- V4: Index product views by session (analytics database)
+-- Supports the recent-view lookup used to deduplicate views within a session
+
+CREATE INDEX IF NOT EXISTS idx_product_views_session ON product_views(product_id, session_id, viewed_at DESC);
@@ -0,0 +1,6 @@
+-- V10: Track the units of each product in stock
+-- Reservations decrement the quantity with a conditional UPDATE, and the
+-- CHECK constraint backs that up: stock can never go negative.
+
+ALTER TABLE products ADD COLUMN IF NOT EXISTS stock_quantity INTEGER NOT NULL DEFAULT 0
+    CONSTRAINT chk_products_stock_quantity CHECK (stock_quantity >= 0);
@@ -0,0 +1,5 @@
+-- V11: Let a category fix the currency of its products
+-- A NULL currency accepts products priced in any currency; otherwise
+-- creating a product in the category with another currency is refused.
+
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS currency CHAR(3);
@@ -0,0 +1,4 @@
+-- V4: Add optimistic concurrency version to products
+-- Incremented by every update; an update carrying a stale version affects no rows.
+
+ALTER TABLE products ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,5 @@
+-- V5: Add soft delete marker to products
+-- Set instead of deleting the row when custom.products.delete.policy is "soft";
+-- NULL for live products. Unused under the default "hard" policy.
+
+ALTER TABLE products ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
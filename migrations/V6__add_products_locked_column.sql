@@ -0,0 +1,5 @@
+-- V6: Add lock flag to products
+-- Locked products reject updates and deletes until an admin unlocks them via
+-- POST /admin/products/:id/unlock. FALSE for existing products.
+
+ALTER TABLE products ADD COLUMN IF NOT EXISTS locked BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,9 @@
+-- V7: Store product prices as integer minor units with a currency
+-- DECIMAL prices were read into float64 and could pick up rounding errors.
+-- price_minor holds the price in minor units of currency (cents for USD,
+-- whole yen for JPY). Existing products are USD, so their prices are
+-- multiplied by 100. The price CHECK constraint and index follow the rename.
+
+ALTER TABLE products RENAME COLUMN price TO price_minor;
+ALTER TABLE products ALTER COLUMN price_minor TYPE BIGINT USING ROUND(price_minor * 100)::BIGINT;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'USD';
@@ -0,0 +1,10 @@
+-- V8: Add a unique SKU to products
+-- The SKU is the business identifier clients look products up by. Existing
+-- products get one derived from their ID. Uniqueness only covers live rows, so
+-- a soft-deleted product's SKU can be reused.
+
+ALTER TABLE products ADD COLUMN IF NOT EXISTS sku VARCHAR(64);
+UPDATE products SET sku = 'P-' || UPPER(REPLACE(id::text, '-', '')) WHERE sku IS NULL;
+ALTER TABLE products ALTER COLUMN sku SET NOT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_products_sku ON products(sku) WHERE deleted_at IS NULL;
@@ -0,0 +1,20 @@
+-- V9: Group products into categories
+-- A product belongs to at most one category. Existing products stay
+-- uncategorized; deleting a category that still has products is refused.
+
+CREATE TABLE IF NOT EXISTS categories (
+    id UUID PRIMARY KEY,
+    name VARCHAR(100) NOT NULL UNIQUE,
+    created_date TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE products ADD COLUMN IF NOT EXISTS category_id UUID REFERENCES categories(id);
+
+CREATE INDEX IF NOT EXISTS idx_products_category_id ON products(category_id);
+
+-- Insert sample categories
+INSERT INTO categories (id, name) VALUES
+    ('7c9e6679-7425-40de-944b-e07fc1f90ae1', 'Computers'),
+    ('7c9e6679-7425-40de-944b-e07fc1f90ae2', 'Accessories'),
+    ('7c9e6679-7425-40de-944b-e07fc1f90ae3', 'Audio')
+ON CONFLICT (id) DO NOTHING;